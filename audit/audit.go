@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"zuri.chat/zccore/utils"
+)
+
+// Record stores an audit log entry. Entries are append-only; nothing in the codebase updates them.
+func Record(entry *Log) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := utils.GetCollection(AuditLogCollectionName).InsertOne(context.TODO(), entry)
+
+	return err
+}
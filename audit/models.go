@@ -0,0 +1,42 @@
+package audit
+
+import "time"
+
+const (
+	AuditLogCollectionName = "audit_logs"
+)
+
+const (
+	UserAnonymized = "user.anonymized"
+
+	UserImpersonationStarted = "user.impersonation_started"
+	UserImpersonatedRequest  = "user.impersonated_request"
+
+	OrganizationExportDelivered = "organization.export_delivered"
+	OrganizationExportFailed    = "organization.export_failed"
+	OrganizationMerged          = "organization.merged"
+	OrganizationUpdated         = "organization.updated"
+	OrganizationDeactivated     = "organization.deactivated"
+	OrganizationReactivated     = "organization.reactivated"
+
+	MemberTemporaryRoleGranted = "member.temporary_role_granted"
+	MemberTemporaryRoleRevoked = "member.temporary_role_revoked"
+	MemberTemporaryRoleExpired = "member.temporary_role_expired"
+
+	AuditLogsPurged = "audit_log.purged"
+)
+
+// Log records a privileged action: who did it, what it was done to and when.
+type Log struct {
+	ID         string                 `json:"_id,omitempty" bson:"_id,omitempty"`
+	OrgID      string                 `json:"org_id,omitempty" bson:"org_id,omitempty"`
+	Actor      string                 `json:"actor" bson:"actor"`
+	Action     string                 `json:"action" bson:"action"`
+	TargetType string                 `json:"target_type" bson:"target_type"`
+	TargetID   string                 `json:"target_id" bson:"target_id"`
+	Data       map[string]interface{} `json:"data,omitempty" bson:"data,omitempty"`
+	CreatedAt  time.Time              `json:"created_at" bson:"created_at"`
+
+	// the Zuri admin who was acting as Actor, when the action was taken while impersonating
+	ImpersonatedBy string `json:"impersonated_by,omitempty" bson:"impersonated_by,omitempty"`
+}
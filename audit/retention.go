@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const auditLogPurgeInterval = 24 * time.Hour
+
+// PurgeAuditLogs deletes entries older than the retention window and records the purge itself,
+// so there is a trace of what was removed. It returns how many entries were deleted. A
+// retention of zero keeps entries forever.
+func PurgeAuditLogs(now time.Time, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, nil
+	}
+
+	cutoff := now.Add(-retention)
+
+	res, err := utils.GetCollection(AuditLogCollectionName).DeleteMany(context.TODO(), bson.M{"created_at": bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+
+	if res.DeletedCount == 0 {
+		return 0, nil
+	}
+
+	err = Record(&Log{
+		Actor:      "system",
+		Action:     AuditLogsPurged,
+		TargetType: "audit_log",
+		Data:       map[string]interface{}{"deleted": res.DeletedCount, "before": cutoff},
+		CreatedAt:  now,
+	})
+
+	return res.DeletedCount, err
+}
+
+// RunAuditLogSweeper purges audit log entries past the retention window once a day until the
+// context is cancelled.
+func RunAuditLogSweeper(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(auditLogPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := PurgeAuditLogs(now, retention)
+			if err != nil {
+				logger.Error("could not purge audit logs: %v", err)
+				continue
+			}
+
+			if purged > 0 {
+				logger.Info("%d audit log entries purged", purged)
+			}
+		}
+	}
+}
+
+// ExportAuditLog streams the audit log, oldest entry first, as one JSON entry per line, so the
+// history can be kept elsewhere before it is purged. ?org_id= limits it to one organization.
+func ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	filter := bson.M{}
+	if orgID := r.URL.Query().Get("org_id"); orgID != "" {
+		filter["org_id"] = orgID
+	}
+
+	oldestFirst := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := utils.GetCollection(AuditLogCollectionName).Find(r.Context(), filter, oldestFirst)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	defer cursor.Close(context.TODO())
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=audit-log-%s.ndjson", utils.NowUTC().Format("20060102")))
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	for cursor.Next(r.Context()) {
+		var entry Log
+		if err := cursor.Decode(&entry); err != nil {
+			logger.Error("could not export audit log entry %v: %v", cursor.Current.Lookup("_id"), err)
+			continue
+		}
+
+		if err := enc.Encode(entry); err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+func TestMain(m *testing.M) {
+	if err := godotenv.Load("../.env"); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+
+	if err := utils.ConnectToDB(os.Getenv("CLUSTER_URL")); err != nil {
+		log.Fatal("Could not connect to MongoDB")
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestPurgeAuditLogs(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	orgID := utils.GenUUID()
+	retention := 30 * 24 * time.Hour
+
+	ages := map[string]time.Duration{
+		"old":    31 * 24 * time.Hour,
+		"recent": 29 * 24 * time.Hour,
+	}
+
+	for target, age := range ages {
+		if err := Record(&Log{OrgID: orgID, Actor: "test", Action: "test.action", TargetID: target, CreatedAt: now.Add(-age)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	remaining := func(target string) int64 {
+		return utils.CountCollection(context.TODO(), AuditLogCollectionName, bson.M{"org_id": orgID, "target_id": target})
+	}
+
+	t.Run("test entries are kept forever without a retention", func(t *testing.T) {
+		if _, err := PurgeAuditLogs(now, 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if remaining("old") != 1 {
+			t.Error("expected old entries to be kept")
+		}
+	})
+
+	t.Run("test only entries past the retention window are purged", func(t *testing.T) {
+		purged, err := PurgeAuditLogs(now, retention)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if purged < 1 {
+			t.Errorf("expected at least 1 entry purged, got %d", purged)
+		}
+
+		if remaining("old") != 0 {
+			t.Error("expected the old entry to be purged")
+		}
+
+		if remaining("recent") != 1 {
+			t.Error("expected the recent entry to be kept")
+		}
+
+		purges := utils.CountCollection(context.TODO(), AuditLogCollectionName, bson.M{"action": AuditLogsPurged, "created_at": now})
+		if purges != 1 {
+			t.Errorf("expected the purge to be logged once, found %d", purges)
+		}
+	})
+}
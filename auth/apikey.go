@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	APIKeyCollectionName = "api_keys"
+
+	// APIKeyHeader carries an organization's API key on requests its integrations make.
+	APIKeyHeader = "X-API-Key"
+)
+
+// APIKeyContext holds the *APIKey a request authenticated by IsAPIKeyAuthenticated was made with.
+var APIKeyContext = UserKey("apiKey")
+
+var (
+	errInvalidAPIKey           = errors.New("invalid or revoked API key")
+	errOrganizationDeactivated = errors.New("organization is deactivated")
+)
+
+// APIKey lets an organization's integrations call its routes without a user. Only a hash of
+// the key is stored, see HashAPIKey.
+type APIKey struct {
+	ID        string     `json:"id" bson:"_id,omitempty"`
+	OrgID     string     `json:"org_id" bson:"org_id"`
+	Name      string     `json:"name" bson:"name"`
+	KeyHash   string     `json:"-" bson:"key_hash"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// HashAPIKey is what an API key is stored and looked up as.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKeyAuthenticated lets through requests carrying a live API key of the organization in
+// their path, as long as the organization exists and is not deactivated, and charges each one to the organization's monthly quota, see utils.APIQuota.
+// The requests left are sent in X-Quota-Remaining, and once there are none requests are
+// refused with 429 until the month is over.
+func (au *AuthHandler) IsAPIKeyAuthenticated(nextHandler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			utils.GetError(ErrNoAuthToken, http.StatusUnauthorized, w)
+			return
+		}
+
+		doc, _ := utils.GetMongoDBDoc(r.Context(), APIKeyCollectionName, bson.M{"key_hash": HashAPIKey(key), "revoked_at": nil})
+		if doc == nil {
+			utils.GetError(errInvalidAPIKey, http.StatusUnauthorized, w)
+			return
+		}
+
+		var apiKey APIKey
+		if err := utils.BsonToStruct(doc, &apiKey); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		// keys only open the routes of their own organization
+		if apiKey.OrgID != mux.Vars(r)["id"] {
+			utils.GetError(errInvalidAPIKey, http.StatusUnauthorized, w)
+			return
+		}
+
+		// keys of deleted organizations are revoked with them, this covers any left behind
+		pOrgID, _ := primitive.ObjectIDFromHex(apiKey.OrgID)
+
+		org, _ := utils.GetMongoDBDoc(r.Context(), utils.OrganizationCollectionName, bson.M{"_id": pOrgID}, options.FindOne().SetProjection(bson.M{"deactivated": 1}))
+		if org == nil {
+			utils.GetError(errInvalidAPIKey, http.StatusUnauthorized, w)
+			return
+		}
+
+		if deactivated, _ := org["deactivated"].(bool); deactivated {
+			utils.GetError(errOrganizationDeactivated, http.StatusForbidden, w)
+			return
+		}
+
+		remaining, _, err := au.apiQuota.Charge(r.Context(), apiKey.OrgID)
+		if errors.Is(err, utils.ErrQuotaExhausted) {
+			w.Header().Set(utils.QuotaRemainingHeader, "0")
+			utils.GetError(err, http.StatusTooManyRequests, w)
+
+			return
+		}
+
+		if err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		w.Header().Set(utils.QuotaRemainingHeader, strconv.Itoa(remaining))
+
+		ctx := context.WithValue(r.Context(), APIKeyContext, &apiKey)
+		nextHandler.ServeHTTP(w, r.WithContext(ctx))
+	}
+}
+
+// IsAuthenticatedOrAPIKey authenticates requests carrying an API key with IsAPIKeyAuthenticated
+// and the rest with IsAuthenticated, for routes integrations can call as well as users.
+func (au *AuthHandler) IsAuthenticatedOrAPIKey(nextHandler http.HandlerFunc) http.HandlerFunc {
+	withKey, withUser := au.IsAPIKeyAuthenticated(nextHandler), au.IsAuthenticated(nextHandler)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(APIKeyHeader) != "" {
+			withKey(w, r)
+			return
+		}
+
+		withUser(w, r)
+	}
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestIsAPIKeyAuthenticated(t *testing.T) {
+	quotaConfigs := *configs
+	quotaConfigs.APIQuotas = map[string]int{"free": 2}
+
+	handler := NewAuthHandler(&quotaConfigs, mailService)
+
+	// organizations without a plan are metered on the free plan
+	pOrgID := primitive.NewObjectID()
+	orgID := pOrgID.Hex()
+	key := utils.GenUUID()
+
+	if _, err := utils.CreateMongoDBDoc(context.TODO(), utils.OrganizationCollectionName, map[string]interface{}{"_id": pOrgID, "name": "API key org"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := utils.CreateMongoDBDoc(context.TODO(), APIKeyCollectionName, map[string]interface{}{
+		"org_id":     orgID,
+		"name":       "integration",
+		"key_hash":   HashAPIKey(key),
+		"created_at": utils.NowUTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/organizations/{id}/members", handler.IsAPIKeyAuthenticated(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(APIKeyContext).(*APIKey); !ok {
+			t.Error("expected the API key to be in the request context")
+		}
+	})).Methods("GET")
+
+	get := func(orgID, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequestWithContext(context.TODO(), "GET", "/organizations/"+orgID+"/members", nil)
+		req.Header.Set(APIKeyHeader, key)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("test keys only open their own organization", func(t *testing.T) {
+		if rr := get(primitive.NewObjectID().Hex(), key); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected a key of another organization to be refused, got %d", rr.Code)
+		}
+
+		if rr := get(orgID, "not-a-key"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected an unknown key to be refused, got %d", rr.Code)
+		}
+	})
+
+	t.Run("test requests are refused once the quota is exhausted", func(t *testing.T) {
+		for _, remaining := range []string{"1", "0"} {
+			rr := get(orgID, key)
+			if rr.Code != http.StatusOK || rr.Header().Get(utils.QuotaRemainingHeader) != remaining {
+				t.Errorf("expected the request to pass with %s remaining, got %d with %q", remaining, rr.Code, rr.Header().Get(utils.QuotaRemainingHeader))
+			}
+		}
+
+		if rr := get(orgID, key); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("expected the request past the quota to be refused, got %d", rr.Code)
+		}
+	})
+
+	t.Run("test keys of deleted organizations are refused", func(t *testing.T) {
+		if _, err := utils.DeleteOneMongoDBDoc(context.TODO(), utils.OrganizationCollectionName, orgID); err != nil {
+			t.Fatal(err)
+		}
+
+		if rr := get(orgID, key); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected the key of a deleted organization to be refused, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+// how long the link sent to a new email works for.
+const emailChangeTTL = 24 * time.Hour
+
+var (
+	ErrEmailNotValid   = errors.New("email address is not valid")
+	ErrEmailUnchanged  = errors.New("this is already your email")
+	ErrEmailTaken      = errors.New("a user with this email already exists")
+	ErrEmailChangeCode = errors.New("email change link used, replaced or expired, request a new one")
+)
+
+// ChangeEmail starts a change of the signed in user's email. A link is sent to the new address
+// and the account keeps its current email until the link is followed; asking again replaces the
+// pending change, so earlier links stop working.
+func (au *AuthHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("content-type", "application/json")
+
+	loggedInUser, ok := r.Context().Value(UserContext).(*AuthUser)
+	if !ok {
+		utils.GetError(ErrNotAuthorized, http.StatusUnauthorized, w)
+		return
+	}
+
+	body := struct {
+		Email string `json:"email"`
+	}{}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	if !utils.IsValidEmail(email) {
+		utils.GetError(ErrEmailNotValid, http.StatusBadRequest, w)
+		return
+	}
+
+	u, err := FetchUserByEmail(bson.M{"email": strings.ToLower(loggedInUser.Email)})
+	if err != nil {
+		utils.GetError(ErrUserNotFound, http.StatusNotFound, w)
+		return
+	}
+
+	if email == u.Email {
+		utils.GetError(ErrEmailUnchanged, http.StatusBadRequest, w)
+		return
+	}
+
+	if utils.CountCollection(r.Context(), userCollection, bson.M{"email": email}) > 0 {
+		utils.GetError(ErrEmailTaken, http.StatusConflict, w)
+		return
+	}
+
+	change := &user.UserEmailChange{
+		Email:     email,
+		Token:     utils.GenUUID(),
+		ExpiresAt: utils.NowUTC().Add(emailChangeTTL),
+	}
+
+	id, _ := primitive.ObjectIDFromHex(u.ID)
+	if _, err := utils.GetCollection(userCollection).UpdateByID(r.Context(), id, bson.M{"$set": bson.M{"email_change": change}}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	link := fmt.Sprintf("%s?token=%s", au.configs.EmailChangeURL, url.QueryEscape(change.Token))
+	mailBody := fmt.Sprintf("Hello,\n\nFollow this link to make %s the email of your Zuri Chat account: %s\n\n"+
+		"Your current email keeps working until then. The link expires in 24 hours. If you did not ask for this, you can ignore this email.",
+		email, link)
+
+	msger := au.mailService.NewCustomMail([]string{email}, "Confirm your new email", mailBody)
+	if err := au.mailService.SendMail(msger); err != nil {
+		logger.Error("could not send email change link to %s: %v", email, err)
+	}
+
+	utils.GetSuccess("a confirmation link has been sent to the new email", change, w)
+}
+
+// ConfirmEmailChange swaps a user's email for the one a pending change was verified for. Their
+// memberships follow the new email, and their sessions, which are tied to the old one, end.
+func (au *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("content-type", "application/json")
+
+	body := struct {
+		Token string `json:"token" validate:"required"`
+	}{}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if err := validate.Struct(body); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	u, err := FetchUserByEmail(bson.M{"email_change.token": body.Token})
+	if err != nil || u.EmailChange == nil || !utils.NowUTC().Before(u.EmailChange.ExpiresAt) {
+		utils.GetError(ErrEmailChangeCode, http.StatusBadRequest, w)
+		return
+	}
+
+	oldEmail, newEmail := u.Email, u.EmailChange.Email
+
+	// the address may have been taken since the change was asked for
+	if utils.CountCollection(r.Context(), userCollection, bson.M{"email": newEmail}) > 0 {
+		utils.GetError(ErrEmailTaken, http.StatusConflict, w)
+		return
+	}
+
+	id, _ := primitive.ObjectIDFromHex(u.ID)
+	filter := bson.M{"_id": id, "email_change.token": body.Token}
+	update := bson.M{
+		"$set":   bson.M{"email": newEmail, "updated_at": utils.NowUTC()},
+		"$unset": bson.M{"email_change": ""},
+	}
+
+	res, err := utils.GetCollection(userCollection).UpdateOne(context.Background(), filter, update)
+	if utils.IsDuplicateKeyError(err) {
+		utils.GetError(ErrEmailTaken, http.StatusConflict, w)
+		return
+	}
+
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(ErrEmailChangeCode, http.StatusBadRequest, w)
+		return
+	}
+
+	if _, err := utils.UpdateManyMongoDBDocs(r.Context(), "members", bson.M{"email": oldEmail}, bson.M{"email": newEmail}); err != nil {
+		logger.Error("could not move memberships of %s to %s: %v", oldEmail, newEmail, err)
+	}
+
+	if _, err := utils.UpdateManyMongoDBDocs(r.Context(), "organizations", bson.M{"creator_email": oldEmail}, bson.M{"creator_email": newEmail}); err != nil {
+		logger.Error("could not move organizations created by %s to %s: %v", oldEmail, newEmail, err)
+	}
+
+	if _, err := utils.DeleteManyMongoDBDoc(r.Context(), sessionCollection, bson.M{"user_id": id}); err != nil {
+		logger.Error("could not end sessions of %s: %v", oldEmail, err)
+	}
+
+	notice := fmt.Sprintf("Hello,\n\nThe email of your Zuri Chat account has been changed to %s. "+
+		"If you did not make this change, contact support straight away.", newEmail)
+
+	msger := au.mailService.NewCustomMail([]string{oldEmail}, "Your email has been changed", notice)
+	if err := au.mailService.SendMail(msger); err != nil {
+		logger.Error("could not send email change notice to %s: %v", oldEmail, err)
+	}
+
+	utils.GetSuccess("email changed, log in with your new email", utils.M{"email": newEmail}, w)
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+func TestChangeEmail(t *testing.T) {
+	suffix := utils.GenUUID()
+	current := "current." + suffix + "@gmail.com"
+	taken := "taken." + suffix + "@gmail.com"
+	newEmail := "new." + suffix + "@gmail.com"
+
+	for _, email := range []string{current, taken} {
+		detail, _ := utils.StructToMap(&user.User{Email: email, IsVerified: true})
+		if _, err := utils.CreateMongoDBDoc(context.TODO(), userCollection, detail); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	changeEmail := func(email string) *httptest.ResponseRecorder {
+		body := bytes.NewBufferString(fmt.Sprintf(`{"email": %q}`, email))
+		req, _ := http.NewRequest("POST", "/account/change-email", body)
+		req = req.WithContext(context.WithValue(req.Context(), UserContext, &AuthUser{Email: current}))
+
+		response := httptest.NewRecorder()
+		au.ChangeEmail(response, req)
+
+		return response
+	}
+
+	confirm := func(token string) *httptest.ResponseRecorder {
+		body := bytes.NewBufferString(fmt.Sprintf(`{"token": %q}`, token))
+		req, _ := http.NewRequest("POST", "/account/confirm-email-change", body)
+
+		response := httptest.NewRecorder()
+		au.ConfirmEmailChange(response, req)
+
+		return response
+	}
+
+	pendingToken := func() string {
+		u, err := FetchUserByEmail(bson.M{"email": current})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.EmailChange == nil {
+			t.Fatal("expected a pending email change")
+		}
+
+		return u.EmailChange.Token
+	}
+
+	t.Run("test emails of other users are refused", func(t *testing.T) {
+		if response := changeEmail(" Taken." + suffix + "@Gmail.com"); response.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, response.Code)
+		}
+	})
+
+	t.Run("test the email only changes once the new address is confirmed", func(t *testing.T) {
+		if response := changeEmail("New." + suffix + "@Gmail.com"); response.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, response.Code, response.Body.String())
+		}
+
+		first := pendingToken()
+
+		if response := changeEmail(newEmail); response.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, response.Code)
+		}
+
+		second := pendingToken()
+
+		u, _ := FetchUserByEmail(bson.M{"email": current})
+		if u.EmailChange.Email != newEmail {
+			t.Errorf("expected the pending email to be normalized to %s, got %s", newEmail, u.EmailChange.Email)
+		}
+
+		if response := confirm(first); response.Code != http.StatusBadRequest {
+			t.Errorf("expected a replaced link to be refused, got status %d", response.Code)
+		}
+
+		if response := confirm(second); response.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, response.Code, response.Body.String())
+		}
+
+		if _, err := FetchUserByEmail(bson.M{"email": current}); err == nil {
+			t.Error("expected the old email to be gone")
+		}
+
+		u, err := FetchUserByEmail(bson.M{"email": newEmail})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.EmailChange != nil {
+			t.Errorf("expected the pending change to be cleared, got %+v", u.EmailChange)
+		}
+
+		if response := confirm(second); response.Code != http.StatusBadRequest {
+			t.Errorf("expected a used link to be refused, got status %d", response.Code)
+		}
+	})
+
+	t.Run("test addresses taken while pending are refused", func(t *testing.T) {
+		current = newEmail
+		claimed := "claimed." + suffix + "@gmail.com"
+
+		if response := changeEmail(claimed); response.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, response.Code)
+		}
+
+		detail, _ := utils.StructToMap(&user.User{Email: claimed, IsVerified: true})
+		if _, err := utils.CreateMongoDBDoc(context.TODO(), userCollection, detail); err != nil {
+			t.Fatal(err)
+		}
+
+		if response := confirm(pendingToken()); response.Code != http.StatusConflict {
+			t.Errorf("expected status %d, got %d", http.StatusConflict, response.Code)
+		}
+
+		if _, err := FetchUserByEmail(bson.M{"email": newEmail}); err != nil {
+			t.Error("expected the email to be left as it was")
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// ImpersonationHeader carries an impersonation token on requests a Zuri admin makes as another
+// user. The admin's own session must come with it.
+const ImpersonationHeader = "X-Impersonation-Token"
+
+const (
+	impersonationPurpose = "impersonation"
+	zuriAdminRole        = "admin"
+
+	defaultImpersonationTTL = 30 * time.Minute
+	maxImpersonationTTL     = time.Hour
+)
+
+var (
+	errCannotImpersonateAdmin = errors.New("zuri admins cannot be impersonated")
+	errInvalidImpersonation   = errors.New("invalid or expired impersonation token")
+)
+
+// ImpersonationClaims are the claims of an impersonation token: the admin, the user they act
+// as, and when the token stops working.
+type ImpersonationClaims struct {
+	Purpose      string `json:"purpose"`
+	Impersonator string `json:"impersonator"`
+	Email        string `json:"email"`
+	jwt.StandardClaims
+}
+
+// impersonationTTL is how long tokens last: the configured time, never longer than an hour.
+func impersonationTTL(configured time.Duration) time.Duration {
+	if configured <= 0 {
+		return defaultImpersonationTTL
+	}
+
+	if configured > maxImpersonationTTL {
+		return maxImpersonationTTL
+	}
+
+	return configured
+}
+
+// NewImpersonationToken signs a token letting impersonator act as email until now plus ttl.
+func NewImpersonationToken(secret []byte, impersonator, email string, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := now.Add(impersonationTTL(ttl))
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, ImpersonationClaims{
+		Purpose:      impersonationPurpose,
+		Impersonator: impersonator,
+		Email:        email,
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	})
+
+	signed, err := token.SignedString(secret)
+
+	return signed, expiresAt, err
+}
+
+// ParseImpersonationToken checks an impersonation token is signed with secret and has not
+// expired by now, and returns its claims.
+func ParseImpersonationToken(secret []byte, tokenString string, now time.Time) (*ImpersonationClaims, error) {
+	claims := &ImpersonationClaims{}
+
+	// expiry is checked against now below rather than the clock the parser reads
+	parser := jwt.Parser{SkipClaimsValidation: true}
+
+	token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return secret, nil
+	})
+	if err != nil || !token.Valid || claims.Purpose != impersonationPurpose {
+		return nil, errInvalidImpersonation
+	}
+
+	if claims.ExpiresAt == 0 || !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return nil, errInvalidImpersonation
+	}
+
+	return claims, nil
+}
+
+// impersonatedUser returns who an admin acts as with an impersonation token. The token must have
+// been issued to the admin, and the user must still not be a Zuri admin. The request is written
+// to the audit log under both identities.
+func (au *AuthHandler) impersonatedUser(r *http.Request, admin *AuthUser, tokenString string) (*AuthUser, error) {
+	claims, err := ParseImpersonationToken([]byte(au.configs.HmacSampleSecret), tokenString, utils.NowUTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(claims.Impersonator, admin.Email) {
+		return nil, errInvalidImpersonation
+	}
+
+	target, err := FetchUserByEmail(bson.M{"email": strings.ToLower(claims.Email)})
+	if err != nil || target.Deactivated {
+		return nil, errInvalidImpersonation
+	}
+
+	if target.Role == zuriAdminRole {
+		return nil, errCannotImpersonateAdmin
+	}
+
+	targetID, _ := primitive.ObjectIDFromHex(target.ID)
+
+	entry := &audit.Log{
+		Actor:          target.Email,
+		ImpersonatedBy: admin.Email,
+		Action:         audit.UserImpersonatedRequest,
+		TargetType:     "user",
+		TargetID:       target.ID,
+		Data:           map[string]interface{}{"method": r.Method, "path": r.URL.Path},
+	}
+
+	// an impersonated request that cannot be audited is not served
+	if err := audit.Record(entry); err != nil {
+		logger.Error("could not audit impersonated request by %s: %v", admin.Email, err)
+		return nil, err
+	}
+
+	return &AuthUser{ID: targetID, Email: target.Email, ImpersonatedBy: admin.Email}, nil
+}
+
+// Get a short-lived token for a Zuri admin to act as another user, e.g. to reproduce an issue
+// they reported. Other Zuri admins cannot be impersonated.
+func (au *AuthHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	admin, ok := r.Context().Value(UserContext).(*AuthUser)
+	if !ok {
+		utils.GetError(errors.New("user not logged in"), http.StatusUnauthorized, w)
+		return
+	}
+
+	var body struct {
+		Email string `json:"email"`
+	}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(body.Email))
+	if email == "" {
+		utils.GetError(errors.New("email is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	target, err := FetchUserByEmail(bson.M{"email": email})
+	if err != nil || target.Deactivated {
+		utils.GetError(ErrUserNotFound, http.StatusNotFound, w)
+		return
+	}
+
+	if target.Role == zuriAdminRole {
+		utils.GetError(errCannotImpersonateAdmin, http.StatusForbidden, w)
+		return
+	}
+
+	now := utils.NowUTC()
+
+	token, expiresAt, err := NewImpersonationToken([]byte(au.configs.HmacSampleSecret), admin.Email, target.Email, now, au.configs.ImpersonationTTL)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	entry := &audit.Log{
+		Actor:      admin.Email,
+		Action:     audit.UserImpersonationStarted,
+		TargetType: "user",
+		TargetID:   target.ID,
+		Data:       map[string]interface{}{"expires_at": expiresAt},
+		CreatedAt:  now,
+	}
+
+	if err := audit.Record(entry); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("impersonation token created", utils.M{
+		"token":      token,
+		"header":     ImpersonationHeader,
+		"expires_at": expiresAt,
+	}, w)
+}
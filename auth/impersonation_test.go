@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+func TestImpersonationTokenTimeBox(t *testing.T) {
+	secret := []byte("impersonation-secret")
+	now := time.Now().UTC()
+
+	token, expiresAt, err := NewImpersonationToken(secret, "support@zuri.chat", "member@gmail.com", now, 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test token works until it expires", func(t *testing.T) {
+		claims, err := ParseImpersonationToken(secret, token, expiresAt.Add(-time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if claims.Impersonator != "support@zuri.chat" || claims.Email != "member@gmail.com" {
+			t.Errorf("unexpected claims %+v", claims)
+		}
+
+		if _, err := ParseImpersonationToken(secret, token, expiresAt); err == nil {
+			t.Error("expected the token to be rejected once it expires")
+		}
+	})
+
+	t.Run("test tokens never last longer than the maximum", func(t *testing.T) {
+		_, expiresAt, err := NewImpersonationToken(secret, "support@zuri.chat", "member@gmail.com", now, 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := expiresAt.Sub(now); got != maxImpersonationTTL {
+			t.Errorf("expected the token to last %v, got %v", maxImpersonationTTL, got)
+		}
+	})
+
+	t.Run("test tokens signed otherwise are rejected", func(t *testing.T) {
+		if _, err := ParseImpersonationToken([]byte("another-secret"), token, now); err == nil {
+			t.Error("expected a token signed with another secret to be rejected")
+		}
+
+		if _, err := ParseImpersonationToken(secret, token+"x", now); err == nil {
+			t.Error("expected a tampered token to be rejected")
+		}
+
+		// a session token signed with the same secret is not an impersonation token
+		sessionToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"email": "member@gmail.com",
+			"exp":   now.Add(time.Minute).Unix(),
+		}).SignedString(secret)
+
+		if _, err := ParseImpersonationToken(secret, sessionToken, now); err == nil {
+			t.Error("expected a session token to be rejected")
+		}
+	})
+}
+
+func TestImpersonatedRequestsAreAudited(t *testing.T) {
+	suffix := utils.GenUUID()
+	admin := "support." + suffix + "@zuri.chat"
+	otherAdmin := "othersupport." + suffix + "@zuri.chat"
+	member := "impersonated." + suffix + "@gmail.com"
+
+	for email, role := range map[string]string{admin: zuriAdminRole, otherAdmin: zuriAdminRole, member: ""} {
+		detail, _ := utils.StructToMap(&user.User{Email: email, IsVerified: true, Role: role})
+		if _, err := utils.CreateMongoDBDoc(context.TODO(), userCollection, detail); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secret := []byte(configs.HmacSampleSecret)
+
+	token, _, err := NewImpersonationToken(secret, admin, member, utils.NowUTC(), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test requests carry on as the user and are audited under both identities", func(t *testing.T) {
+		req, _ := http.NewRequest("PATCH", "/organizations/123/members/456", nil)
+
+		u, err := au.impersonatedUser(req, &AuthUser{Email: admin}, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if u.Email != member || u.ImpersonatedBy != admin {
+			t.Errorf("expected to act as %s impersonated by %s, got %+v", member, admin, u)
+		}
+
+		entryDoc, err := utils.GetMongoDBDoc(context.TODO(), audit.AuditLogCollectionName, bson.M{"action": audit.UserImpersonatedRequest, "impersonated_by": admin})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var entry audit.Log
+		if err := utils.BsonToStruct(entryDoc, &entry); err != nil {
+			t.Fatal(err)
+		}
+
+		if entry.Actor != member || entry.Data["path"] != "/organizations/123/members/456" || entry.Data["method"] != "PATCH" {
+			t.Errorf("unexpected audit entry %+v", entry)
+		}
+	})
+
+	t.Run("test tokens only work for the admin they were issued to", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/users/me", nil)
+
+		if _, err := au.impersonatedUser(req, &AuthUser{Email: otherAdmin}, token); err == nil {
+			t.Error("expected another admin to be refused")
+		}
+	})
+
+	t.Run("test zuri admins cannot be impersonated", func(t *testing.T) {
+		body := bytes.NewBufferString(fmt.Sprintf(`{"email": %q}`, otherAdmin))
+		req, _ := http.NewRequest("POST", "/auth/impersonate", body)
+		req = req.WithContext(context.WithValue(req.Context(), UserContext, &AuthUser{Email: admin}))
+
+		response := httptest.NewRecorder()
+		au.Impersonate(response, req)
+
+		if response.Code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, response.Code)
+		}
+
+		adminToken, _, _ := NewImpersonationToken(secret, admin, otherAdmin, utils.NowUTC(), time.Minute)
+		if _, err := au.impersonatedUser(req, &AuthUser{Email: admin}, adminToken); err == nil {
+			t.Error("expected a token for a zuri admin to be refused")
+		}
+	})
+
+	t.Run("test impersonation starts with a token", func(t *testing.T) {
+		body := bytes.NewBufferString(fmt.Sprintf(`{"email": %q}`, member))
+		req, _ := http.NewRequest("POST", "/auth/impersonate", body)
+		req = req.WithContext(context.WithValue(req.Context(), UserContext, &AuthUser{Email: admin}))
+
+		response := httptest.NewRecorder()
+		au.Impersonate(response, req)
+
+		if response.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, response.Code)
+		}
+
+		if n := utils.CountCollection(context.TODO(), audit.AuditLogCollectionName, bson.M{"action": audit.UserImpersonationStarted, "actor": admin}); n != 1 {
+			t.Errorf("expected the start of the impersonation to be audited once, got %d", n)
+		}
+	})
+}
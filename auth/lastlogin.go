@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const memberCollection = "members"
+
+// lastLoginWindow is how long a recorded login stands before a new one is written, so users
+// logging in again and again do not cause a write each time.
+const lastLoginWindow = 15 * time.Minute
+
+// RecordLastLogin sets last_login_at on the user with the given email and on their
+// organization memberships, unless a login was recorded within the last few minutes. It
+// reports whether the login was written.
+func RecordLastLogin(email string, now time.Time) (bool, error) {
+	filter := bson.M{
+		"email": email,
+		"$or": []bson.M{
+			{"last_login_at": nil},
+			{"last_login_at": bson.M{"$lte": now.Add(-lastLoginWindow)}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"last_login_at": now}}
+
+	res, err := utils.GetCollection(userCollection).UpdateOne(context.TODO(), filter, update)
+	if err != nil || res.ModifiedCount == 0 {
+		return false, err
+	}
+
+	// members are listed per organization, so they carry their own copy of the login time
+	if _, err := utils.GetCollection(memberCollection).UpdateMany(context.TODO(), bson.M{"email": email}, update); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// recordLogin records a successful login without holding it up if the write fails.
+func recordLogin(email string) {
+	if _, err := RecordLastLogin(email, utils.NowUTC()); err != nil {
+		logger.Error("could not record last login of %s: %v", email, err)
+	}
+}
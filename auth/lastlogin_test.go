@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+func TestRecordLastLogin(t *testing.T) {
+	email := "lastlogin." + utils.GenUUID() + "@gmail.com"
+
+	detail, _ := utils.StructToMap(&user.User{Email: email, IsVerified: true})
+	if _, err := utils.CreateMongoDBDoc(context.TODO(), userCollection, detail); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := utils.GetCollection(memberCollection).InsertOne(context.TODO(), bson.M{"email": email, "org_id": "lastloginorg"}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	t.Run("test first login is recorded on the user and members", func(t *testing.T) {
+		written, err := RecordLastLogin(email, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !written {
+			t.Fatal("expected the login to be written")
+		}
+
+		for _, coll := range []string{userCollection, memberCollection} {
+			doc, _ := utils.GetMongoDBDoc(context.TODO(), coll, bson.M{"email": email})
+			if got := utils.DocTime(doc, "last_login_at"); !got.Equal(now) {
+				t.Errorf("expected %s last_login_at %v, got %v", coll, now, got)
+			}
+		}
+	})
+
+	t.Run("test logins within the window are not written", func(t *testing.T) {
+		written, err := RecordLastLogin(email, now.Add(lastLoginWindow/2))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if written {
+			t.Error("expected a login within the window not to be written")
+		}
+	})
+
+	t.Run("test logins after the window are written", func(t *testing.T) {
+		written, err := RecordLastLogin(email, now.Add(lastLoginWindow))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !written {
+			t.Error("expected a login after the window to be written")
+		}
+	})
+}
@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt"
@@ -32,6 +31,7 @@ var (
 	ErrInvalidCredentials  = errors.New("invalid login credentials, confirm and try again")
 	ErrAccountConfirmError = errors.New("your account is not verified, kindly check your email for verification code")
 	ErrAccessExpired       = errors.New("error fetching user info, access token expired, kindly login again")
+	ErrAccountDeleted      = errors.New("this account has been deleted")
 )
 
 func (au *AuthHandler) GetAuthToken(u *user.User, sess *sessions.Session) (*Token, error) {
@@ -48,6 +48,11 @@ func (au *AuthHandler) GetAuthToken(u *user.User, sess *sessions.Session) (*Toke
 		return nil, err
 	}
 
+	phone, err := utils.DecryptField(u.Phone, au.configs.FieldEncryptionKeys)
+	if err != nil {
+		return nil, err
+	}
+
 	resp := &Token{
 		SessionID: sess.ID,
 		User: UserResponse{
@@ -55,7 +60,7 @@ func (au *AuthHandler) GetAuthToken(u *user.User, sess *sessions.Session) (*Toke
 			FirstName: u.FirstName,
 			LastName:  u.LastName,
 			Email:     u.Email,
-			Phone:     u.Phone,
+			Phone:     phone,
 			Timezone:  u.Timezone,
 			CreatedAt: u.CreatedAt,
 			UpdatedAt: u.UpdatedAt,
@@ -85,6 +90,11 @@ func (au *AuthHandler) LoginIn(response http.ResponseWriter, request *http.Reque
 		utils.GetError(ErrUserNotFound, http.StatusBadRequest, response)
 		return
 	}
+
+	if vser.Deactivated {
+		utils.GetError(ErrAccountDeleted, http.StatusUnauthorized, response)
+		return
+	}
 	// check if user is verified
 	if !vser.IsVerified {
 		utils.GetError(ErrAccountConfirmError, http.StatusBadRequest, response)
@@ -97,7 +107,7 @@ func (au *AuthHandler) LoginIn(response http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	store := NewMongoStore(utils.GetCollection(sessionCollection), au.configs.SessionMaxAge, true, []byte(au.configs.SecretKey))
+	store := au.sessionStore()
 
 	var session, e = store.Get(request, au.configs.SessionKey)
 	if e != nil {
@@ -123,6 +133,8 @@ func (au *AuthHandler) LoginIn(response http.ResponseWriter, request *http.Reque
 		return
 	}
 
+	recordLogin(vser.Email)
+
 	utils.GetSuccess("login successful", resp, response)
 }
 
@@ -133,12 +145,7 @@ func (au *AuthHandler) LogOutUser(w http.ResponseWriter, r *http.Request) {
 		erro    error
 	)
 
-	store := NewMongoStore(
-		utils.GetCollection(sessionCollection),
-		au.configs.SessionMaxAge,
-		true,
-		[]byte(au.configs.SecretKey),
-	)
+	store := au.sessionStore()
 
 	session, err = store.Get(r, au.configs.SessionKey)
 	status, sessData, _ := GetSessionDataFromToken(r, []byte(au.configs.HmacSampleSecret))
@@ -177,6 +184,7 @@ func (au *AuthHandler) VerifyTokenHandler(response http.ResponseWriter, request
 	// extract user id and email from context
 	loggedIn, _ := request.Context().Value("user").(*AuthUser)
 	u, _ := FetchUserByEmail(bson.M{"email": strings.ToLower(loggedIn.Email)})
+	phone, _ := utils.DecryptField(u.Phone, au.configs.FieldEncryptionKeys)
 
 	resp := &VerifiedTokenResponse{
 		true,
@@ -185,7 +193,7 @@ func (au *AuthHandler) VerifyTokenHandler(response http.ResponseWriter, request
 			FirstName: u.FirstName,
 			LastName:  u.LastName,
 			Email:     u.Email,
-			Phone:     u.Phone,
+			Phone:     phone,
 			Timezone:  u.Timezone,
 			CreatedAt: u.CreatedAt,
 			UpdatedAt: u.UpdatedAt,
@@ -201,12 +209,7 @@ func (au *AuthHandler) LogOutOtherSessions(w http.ResponseWriter, r *http.Reques
 		err     error
 	)
 
-	store := NewMongoStore(
-		utils.GetCollection(sessionCollection),
-		au.configs.SessionMaxAge,
-		true,
-		[]byte(au.configs.SecretKey),
-	)
+	store := au.sessionStore()
 
 	// Get  current session
 	session, err = store.Get(r, au.configs.SessionKey)
@@ -302,7 +305,7 @@ func (au *AuthHandler) SocialAuth(w http.ResponseWriter, r *http.Request) {
 	}
 	defer resp.Body.Close()
 
-	store := NewMongoStore(utils.GetCollection(sessionCollection), au.configs.SessionMaxAge, true, []byte(au.configs.SecretKey))
+	store := au.sessionStore()
 	session, e := store.Get(r, au.configs.SessionKey)
 
 	if e != nil {
@@ -342,19 +345,24 @@ func (au *AuthHandler) SocialAuth(w http.ResponseWriter, r *http.Request) {
 				Social:        social,
 				Timezone:      "Africa/Lagos",                       // set default timezone
 				Organizations: []string{"614679ee1a5607b13c00bcb7"}, // set default org
-				CreatedAt:     time.Now(),
+				CreatedAt:     utils.NowUTC(),
 			}
 			detail, _ := utils.StructToMap(b)
-			res, er := utils.CreateMongoDBDoc(userCollection, detail)
+			res, er := utils.CreateMongoDBDoc(r.Context(), userCollection, detail)
 
 			if er != nil {
-				utils.GetError(er, http.StatusInternalServerError, w)
+				utils.GetWriteError(er, w)
 				return
 			}
 
 			session.Values["id"] = res.InsertedID
 			session.Values["email"] = b.Email
 		} else {
+			if vser.Deactivated {
+				utils.GetError(ErrAccountDeleted, http.StatusUnauthorized, w)
+				return
+			}
+
 			// update record
 			social := map[string]interface{}{
 				"provider_id": socialUser.ID,
@@ -385,6 +393,8 @@ func (au *AuthHandler) SocialAuth(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		recordLogin(strings.ToLower(socialUser.Email))
+
 		utils.GetSuccess("login successful", resp, w)
 
 		return
@@ -27,7 +27,7 @@ func (au *AuthHandler) IsAuthenticated(nextHandler http.HandlerFunc) http.Handle
 			erro         error
 		)
 
-		store := NewMongoStore(utils.GetCollection(sessionCollection), au.configs.SessionMaxAge, true, []byte(au.configs.SecretKey))
+		store := au.sessionStore()
 		session, _ = store.Get(r, au.configs.SessionKey)
 		status, sessData, _ := GetSessionDataFromToken(r, []byte(au.configs.HmacSampleSecret))
 
@@ -57,10 +57,29 @@ func (au *AuthHandler) IsAuthenticated(nextHandler http.HandlerFunc) http.Handle
 			return
 		}
 
+		if isDeletedUser(SessionEmail) {
+			utils.GetError(ErrAccountDeleted, http.StatusUnauthorized, w)
+			return
+		}
+
+		au.recordSessionActivity(r, objID)
+
 		u := &AuthUser{
 			ID:    objID,
 			Email: SessionEmail,
 		}
+
+		// a Zuri admin acting as another user carries on as them
+		if tokenString := r.Header.Get(ImpersonationHeader); tokenString != "" {
+			impersonated, err := au.impersonatedUser(r, u, tokenString)
+			if err != nil {
+				utils.GetError(err, http.StatusUnauthorized, w)
+				return
+			}
+
+			u = impersonated
+		}
+
 		//nolint:staticcheck //CODEI8: lint ignore
 		ctx := context.WithValue(r.Context(), UserContext, u)
 		nextHandler.ServeHTTP(w, r.WithContext(ctx))
@@ -74,7 +93,6 @@ func (au *AuthHandler) IsAuthorized(nextHandler http.HandlerFunc, role string) h
 		var (
 			orgID    string
 			authuser user.User
-			memb     RoleMember
 		)
 
 		if mux.Vars(r)["id"] != "" {
@@ -91,7 +109,7 @@ func (au *AuthHandler) IsAuthorized(nextHandler http.HandlerFunc, role string) h
 		userID := lguser.ID
 		luHexid, _ := primitive.ObjectIDFromHex(userID)
 		_, userCollection, memberCollection := "organizations", "users", "members"
-		userDoc, _ := utils.GetMongoDBDoc(userCollection, bson.M{"_id": luHexid})
+		userDoc, _ := utils.GetMongoDBDoc(r.Context(), userCollection, bson.M{"_id": luHexid})
 
 		if userDoc == nil {
 			utils.GetError(errors.New("user not found"), http.StatusBadRequest, w)
@@ -108,27 +126,23 @@ func (au *AuthHandler) IsAuthorized(nextHandler http.HandlerFunc, role string) h
 			}
 		} else {
 			// Getting member's document from db
-			orgMember, _ := utils.GetMongoDBDoc(memberCollection, bson.M{"org_id": orgID, "email": authuser.Email})
+			orgMember, _ := utils.GetMongoDBDoc(r.Context(), memberCollection, bson.M{"org_id": orgID, "email": authuser.Email, "deleted": bson.M{"$ne": true}})
 			if orgMember == nil {
 				utils.GetError(errors.New("access Denied"), http.StatusUnauthorized, w)
 				return
 			}
 
-			//nolint:errcheck //CODEI8:
-			mapstructure.Decode(orgMember, &memb)
-
-			// check role's access
-			nA := map[string]int{"owner": 4, "admin": 3, "member": 2, "guest": 1}
-
-			if nA[role] > nA[memb.Role] {
+			// check role's access, with temporary roles only until they expire
+			if RoleRanks[role] > RoleRanks[EffectiveRole(orgMember, utils.NowUTC())] {
 				utils.GetError(errors.New("access Denied"), http.StatusUnauthorized, w)
 				return
 			}
 		}
 
 		u := &AuthUser{
-			ID:    luHexid,
-			Email: loggedInUser.Email,
+			ID:             luHexid,
+			Email:          loggedInUser.Email,
+			ImpersonatedBy: loggedInUser.ImpersonatedBy,
 		}
 		//nolint:staticcheck //CODEI8: lint ignore
 		ctx := context.WithValue(r.Context(), UserContext, u)
@@ -143,7 +157,7 @@ func (au *AuthHandler) OptionalAuthentication(nextHandler http.HandlerFunc) http
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("content-type", "application/json")
 
-		store := NewMongoStore(utils.GetCollection(sessionCollection), au.configs.SessionMaxAge, true, []byte(au.configs.SecretKey))
+		store := au.sessionStore()
 		_, er := store.Get(r, au.configs.SessionKey)
 		status, sessData, err := GetSessionDataFromToken(r, []byte(au.configs.HmacSampleSecret))
 
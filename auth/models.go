@@ -75,6 +75,9 @@ type Token struct {
 type AuthUser struct {
 	ID    primitive.ObjectID `json:"id"`
 	Email string             `json:"email"`
+
+	// email of the Zuri admin acting as this user, see Impersonate
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
 }
 
 type MyCustomClaims struct {
@@ -92,6 +95,7 @@ type VerifiedTokenResponse struct {
 type AuthHandler struct {
 	configs     *utils.Configurations
 	mailService service.MailService
+	apiQuota    *utils.APIQuota
 }
 
 type UserKey string
@@ -110,7 +114,7 @@ func DeleteOtherSessions(userID, sessionID string) {
 		"user_id": bson.M{"$eq": uid},
 		"_id":     bson.M{"$ne": sid},
 	}
-	_, err := utils.DeleteManyMongoDBDoc(sessionCollection, filter)
+	_, err := utils.DeleteManyMongoDBDoc(context.TODO(), sessionCollection, filter)
 
 	if err != nil {
 		fmt.Printf("%v", err)
@@ -131,6 +135,13 @@ func FetchUserByEmail(filter map[string]interface{}) (*user.User, error) {
 	return u, err
 }
 
+// isDeletedUser reports whether the user with this email has been deleted, so their sessions
+// no longer authenticate.
+func isDeletedUser(email string) bool {
+	filter := bson.M{"email": strings.ToLower(email), "deactivated": true}
+	return utils.CountCollection(context.TODO(), userCollection, filter) > 0
+}
+
 // Finds User by ID.
 func FetchUserByID(id string) (*user.User, error) {
 	uid, _ := primitive.ObjectIDFromHex(id)
@@ -198,5 +209,5 @@ func ClearSession(m *MongoStore, w http.ResponseWriter, session *sessions.Sessio
 }
 
 func NewAuthHandler(c *utils.Configurations, mail service.MailService) *AuthHandler {
-	return &AuthHandler{configs: c, mailService: mail}
+	return &AuthHandler{configs: c, mailService: mail, apiQuota: utils.NewAPIQuota(c.APIQuotas)}
 }
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
@@ -126,6 +125,11 @@ func (au *AuthHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err = utils.ValidatePassword(rBody.Password, au.configs.PasswordPolicy); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
 	// update password & delete passwordreset object
 	bytes, err := bcrypt.GenerateFromPassword([]byte(rBody.Password), DefaultHashCode)
 	if err != nil {
@@ -181,11 +185,11 @@ func (au *AuthHandler) RequestResetPasswordCode(w http.ResponseWriter, r *http.R
 	_, token := utils.RandomGen(GenNumberLength, "d")
 
 	userPasswordReset := map[string]interface{}{
-		"ip_address": strings.Split(r.RemoteAddr, ":")[0],
+		"ip_address": ipString(utils.ClientIP(r, au.configs.TrustedProxies)),
 		"token":      token,
-		"expired_at": time.Now(),
-		"updated_at": time.Now(),
-		"created_at": time.Now(),
+		"expired_at": utils.NowUTC(),
+		"updated_at": utils.NowUTC(),
+		"created_at": utils.NowUTC(),
 	}
 
 	id, _ := primitive.ObjectIDFromHex(u.ID)
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"time"
+
+	"zuri.chat/zccore/utils"
+)
+
+// RoleRanks orders organization roles by how much access they give.
+var RoleRanks = map[string]int{"owner": 4, "admin": 3, "member": 2, "guest": 1}
+
+// EffectiveRole returns the role an organization member document gives at now. A temporary
+// role raises the member's access until it expires; from then on the member is back on their
+// base role, whether or not the sweeper has cleared the temporary role yet.
+func EffectiveRole(member map[string]interface{}, now time.Time) string {
+	role, _ := member["role"].(string)
+
+	tempRole, _ := member["temporary_role"].(string)
+	if tempRole == "" {
+		return role
+	}
+
+	expiresAt := utils.DocTime(member, "temporary_role_expires_at")
+
+	if !now.Before(expiresAt) || RoleRanks[tempRole] <= RoleRanks[role] {
+		return role
+	}
+
+	return tempRole
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// sessionActivityWindow is how long a session's recorded activity stands before a new one is
+// written, so every request does not cause a write.
+const sessionActivityWindow = time.Minute
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionInfo describes one of a user's sessions, for them to tell their devices apart.
+type SessionInfo struct {
+	ID           string     `json:"id" bson:"_id"`
+	UserAgent    string     `json:"user_agent" bson:"user_agent"`
+	IPAddress    string     `json:"ip_address" bson:"ip_address"`
+	CreatedAt    *time.Time `json:"created_at" bson:"created_at"`
+	LastActiveAt *time.Time `json:"last_active_at" bson:"last_active_at"`
+	Current      bool       `json:"current" bson:"-"`
+}
+
+// sessionStore is the store sessions are kept in, knowing the proxies requests come through.
+func (au *AuthHandler) sessionStore() *MongoStore {
+	store := NewMongoStore(utils.GetCollection(sessionCollection), au.configs.SessionMaxAge, true, []byte(au.configs.SecretKey))
+	store.TrustedProxies = au.configs.TrustedProxies
+
+	return store
+}
+
+// ipString is an IP address as stored on sessions, empty when it is not known.
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+
+	return ip.String()
+}
+
+// RecordSessionActivity sets when a session was last used and the address it was used from,
+// unless its activity was recorded within the last minute. It reports whether it was written.
+func RecordSessionActivity(sessionID primitive.ObjectID, ipAddress string, now time.Time) (bool, error) {
+	filter := bson.M{
+		"_id": sessionID,
+		"$or": []bson.M{
+			{"last_active_at": nil},
+			{"last_active_at": bson.M{"$lte": now.Add(-sessionActivityWindow)}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"last_active_at": now, "ip_address": ipAddress}}
+
+	res, err := utils.GetCollection(sessionCollection).UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+// recordSessionActivity records a session's use without holding up the request if the write fails.
+func (au *AuthHandler) recordSessionActivity(r *http.Request, sessionID primitive.ObjectID) {
+	ip := ipString(utils.ClientIP(r, au.configs.TrustedProxies))
+	if _, err := RecordSessionActivity(sessionID, ip, utils.NowUTC()); err != nil {
+		logger.Error("could not record activity of session %s: %v", sessionID.Hex(), err)
+	}
+}
+
+// sessionOwner returns the id of the signed in user, whose sessions are listed and revoked.
+func sessionOwner(r *http.Request) (*AuthUser, primitive.ObjectID, error) {
+	loggedInUser, ok := r.Context().Value(UserContext).(*AuthUser)
+	if !ok {
+		return nil, primitive.NilObjectID, ErrNotAuthorized
+	}
+
+	u, err := FetchUserByEmail(bson.M{"email": strings.ToLower(loggedInUser.Email)})
+	if err != nil {
+		return nil, primitive.NilObjectID, ErrUserNotFound
+	}
+
+	userID, _ := primitive.ObjectIDFromHex(u.ID)
+
+	return loggedInUser, userID, nil
+}
+
+// activeSessionsFilter matches a user's sessions that have not outlived the session max age.
+func (au *AuthHandler) activeSessionsFilter(userID primitive.ObjectID) bson.M {
+	filter := bson.M{"user_id": userID}
+
+	if au.configs.SessionMaxAge > 0 {
+		filter["modified"] = bson.M{"$gte": utils.NowUTC().Add(-time.Duration(au.configs.SessionMaxAge) * time.Second)}
+	}
+
+	return filter
+}
+
+// List the signed in user's active sessions, most recently active first. The session making
+// the request is marked current.
+func (au *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("content-type", "application/json")
+
+	loggedInUser, userID, err := sessionOwner(r)
+	if err != nil {
+		utils.GetError(err, http.StatusUnauthorized, w)
+		return
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_active_at", Value: -1}, {Key: "modified", Value: -1}}).
+		SetProjection(bson.M{"data": 0})
+
+	cursor, err := utils.GetCollection(sessionCollection).Find(r.Context(), au.activeSessionsFilter(userID), opts)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	sessions := []SessionInfo{}
+	if err := cursor.All(r.Context(), &sessions); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == loggedInUser.ID.Hex()
+	}
+
+	utils.GetSuccess("sessions retrieved successfully", sessions, w)
+}
+
+// Revoke one of the signed in user's sessions, e.g. on a lost device. Its token stops working
+// at once. Revoking the current session logs the user out.
+func (au *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("content-type", "application/json")
+
+	_, userID, err := sessionOwner(r)
+	if err != nil {
+		utils.GetError(err, http.StatusUnauthorized, w)
+		return
+	}
+
+	sessionID, err := primitive.ObjectIDFromHex(mux.Vars(r)["session_id"])
+	if err != nil {
+		utils.GetError(ErrSessionNotFound, http.StatusNotFound, w)
+		return
+	}
+
+	// matching the user keeps anyone from revoking sessions that are not theirs
+	res, err := utils.GetCollection(sessionCollection).DeleteOne(r.Context(), bson.M{"_id": sessionID, "user_id": userID})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.DeletedCount == 0 {
+		utils.GetError(ErrSessionNotFound, http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("session revoked successfully", nil, w)
+}
+
+// Revoke every session of the signed in user but the one making the request.
+func (au *AuthHandler) RevokeAllOtherSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("content-type", "application/json")
+
+	loggedInUser, userID, err := sessionOwner(r)
+	if err != nil {
+		utils.GetError(err, http.StatusUnauthorized, w)
+		return
+	}
+
+	filter := bson.M{"user_id": userID, "_id": bson.M{"$ne": loggedInUser.ID}}
+
+	res, err := utils.GetCollection(sessionCollection).DeleteMany(r.Context(), filter)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("other sessions revoked successfully", utils.M{"revoked": res.DeletedCount}, w)
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+func TestSessions(t *testing.T) {
+	email := fmt.Sprintf("sessions.%s@gmail.com", utils.GenUUID())
+
+	detail, _ := utils.StructToMap(&user.User{Email: email, IsVerified: true})
+
+	res, err := utils.CreateMongoDBDoc(context.TODO(), userCollection, detail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := res.InsertedID.(primitive.ObjectID)
+
+	// three sessions of the user on different devices, and one of someone else
+	devices := []string{"Firefox on Linux", "Safari on iPhone", "Chrome on Windows"}
+	sessionIDs := make([]primitive.ObjectID, len(devices))
+
+	for i, device := range devices {
+		sessionIDs[i] = primitive.NewObjectID()
+		doc := bson.M{"_id": sessionIDs[i], "user_id": userID, "modified": utils.NowUTC(), "created_at": utils.NowUTC(), "user_agent": device}
+
+		if _, err := utils.GetCollection(sessionCollection).InsertOne(context.TODO(), doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	otherSession := primitive.NewObjectID()
+	if _, err := utils.GetCollection(sessionCollection).InsertOne(context.TODO(), bson.M{"_id": otherSession, "user_id": primitive.NewObjectID(), "modified": utils.NowUTC()}); err != nil {
+		t.Fatal(err)
+	}
+
+	current := sessionIDs[0]
+
+	withSession := func(req *http.Request) *http.Request {
+		return req.WithContext(context.WithValue(req.Context(), UserContext, &AuthUser{ID: current, Email: email}))
+	}
+
+	listSessions := func() []SessionInfo {
+		req, _ := http.NewRequest("GET", "/account/sessions", nil)
+
+		response := httptest.NewRecorder()
+		au.ListSessions(response, withSession(req))
+
+		if response.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, response.Code)
+		}
+
+		var body struct {
+			Data []SessionInfo `json:"data"`
+		}
+
+		if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		return body.Data
+	}
+
+	revokeSession := func(id primitive.ObjectID) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("DELETE", "/account/sessions/"+id.Hex(), nil)
+		req = mux.SetURLVars(withSession(req), map[string]string{"session_id": id.Hex()})
+
+		response := httptest.NewRecorder()
+		au.RevokeSession(response, req)
+
+		return response
+	}
+
+	t.Run("test sessions are listed with the current one marked", func(t *testing.T) {
+		sessions := listSessions()
+		if len(sessions) != len(devices) {
+			t.Fatalf("expected %d sessions, got %d", len(devices), len(sessions))
+		}
+
+		for _, session := range sessions {
+			if session.Current != (session.ID == current.Hex()) {
+				t.Errorf("session %s marked current: %v", session.ID, session.Current)
+			}
+
+			if session.UserAgent == "" || session.CreatedAt == nil {
+				t.Errorf("expected the device and start of session %s, got %+v", session.ID, session)
+			}
+		}
+	})
+
+	t.Run("test activity is recorded at most once a minute", func(t *testing.T) {
+		now := utils.NowUTC()
+
+		if written, err := RecordSessionActivity(current, "10.0.0.1", now); err != nil || !written {
+			t.Fatalf("expected activity to be written, got %v (%v)", written, err)
+		}
+
+		if written, _ := RecordSessionActivity(current, "10.0.0.2", now.Add(sessionActivityWindow/2)); written {
+			t.Error("expected activity within the window not to be written")
+		}
+
+		if sessions := listSessions(); sessions[0].ID != current.Hex() || sessions[0].IPAddress != "10.0.0.1" || sessions[0].LastActiveAt == nil {
+			t.Errorf("expected the most recently active session first with its address, got %+v", sessions[0])
+		}
+	})
+
+	t.Run("test a specific session is revoked", func(t *testing.T) {
+		if response := revokeSession(sessionIDs[1]); response.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, response.Code)
+		}
+
+		if utils.CountCollection(context.TODO(), sessionCollection, bson.M{"_id": sessionIDs[1]}) != 0 {
+			t.Error("expected the revoked session record to be gone")
+		}
+
+		if sessions := listSessions(); len(sessions) != len(devices)-1 {
+			t.Errorf("expected %d sessions, got %d", len(devices)-1, len(sessions))
+		}
+
+		if response := revokeSession(sessionIDs[1]); response.Code != http.StatusNotFound {
+			t.Errorf("expected a revoked session to be gone, got status %d", response.Code)
+		}
+	})
+
+	t.Run("test sessions of other users cannot be revoked", func(t *testing.T) {
+		if response := revokeSession(otherSession); response.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, response.Code)
+		}
+	})
+
+	t.Run("test all other sessions are revoked", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", "/account/sessions/others", nil)
+
+		response := httptest.NewRecorder()
+		au.RevokeAllOtherSessions(response, withSession(req))
+
+		if response.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, response.Code)
+		}
+
+		sessions := listSessions()
+		if len(sessions) != 1 || !sessions[0].Current {
+			t.Errorf("expected only the current session to be left, got %+v", sessions)
+		}
+
+		if utils.CountCollection(context.TODO(), sessionCollection, bson.M{"_id": otherSession}) != 1 {
+			t.Error("expected sessions of other users to be left alone")
+		}
+	})
+}
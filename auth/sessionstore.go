@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/utils"
 )
 
 var (
@@ -44,6 +46,9 @@ type MongoStore struct {
 	Options *sessions.Options
 	Token   TokenGetSeter
 	coll    *mongo.Collection
+
+	// proxies whose forwarded headers are believed for the IP address sessions are started from
+	TrustedProxies []*net.IPNet
 }
 
 func NewMongoStore(c *mongo.Collection, maxAge int, ensureTTL bool, keyPairs ...[]byte) *MongoStore {
@@ -139,7 +144,7 @@ func (m *MongoStore) Save(r *http.Request, w http.ResponseWriter, session *sessi
 		session.ID = primitive.NewObjectID().Hex()
 	}
 
-	if err := m.upsert(session); err != nil {
+	if err := m.upsert(r, session); err != nil {
 		return err
 	}
 
@@ -190,7 +195,7 @@ func (m *MongoStore) load(session *sessions.Session) error {
 	return nil
 }
 
-func (m *MongoStore) upsert(session *sessions.Session) error {
+func (m *MongoStore) upsert(r *http.Request, session *sessions.Session) error {
 	ctx := context.Background()
 
 	objID, err := primitive.ObjectIDFromHex(session.ID)
@@ -219,7 +224,7 @@ func (m *MongoStore) upsert(session *sessions.Session) error {
 			return ErrorInvalid
 		}
 	} else {
-		modified = time.Now()
+		modified = time.Now().UTC()
 	}
 
 	encoded, _ := securecookie.EncodeMulti(session.Name(), session.Values, m.Codecs...)
@@ -231,7 +236,15 @@ func (m *MongoStore) upsert(session *sessions.Session) error {
 	}
 	opts := options.Update().SetUpsert(true)
 	filter := bson.M{"_id": s.ID}
-	updateData := bson.M{"$set": s}
+	updateData := bson.M{
+		"$set": s,
+		// the device a session was started on, for users to tell their sessions apart
+		"$setOnInsert": bson.M{
+			"created_at": time.Now().UTC(),
+			"user_agent": r.UserAgent(),
+			"ip_address": ipString(utils.ClientIP(r, m.TrustedProxies)),
+		},
+	}
 
 	if _, err = m.coll.UpdateOne(ctx, filter, updateData, opts); err != nil {
 		return err
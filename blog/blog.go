@@ -19,7 +19,7 @@ import (
 func GetPosts(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
 
-	blogs, err := utils.GetMongoDBDocs(BlogCollectionName, bson.M{"deleted": false})
+	blogs, err := utils.GetMongoDBDocs(request.Context(), BlogCollectionName, bson.M{"deleted": false})
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, response)
 		return
@@ -33,7 +33,7 @@ func GetBlogComments(response http.ResponseWriter, request *http.Request) {
 
 	postID := mux.Vars(request)["post_id"]
 
-	result, err := utils.GetMongoDBDoc(BlogCommentsCollectionName, bson.M{"_id": postID})
+	result, err := utils.GetMongoDBDoc(request.Context(), BlogCommentsCollectionName, bson.M{"_id": postID})
 
 	if err != nil {
 		utils.GetError(errors.New("blog post comments does not exist"), http.StatusNotFound, response)
@@ -64,7 +64,7 @@ func CreatePost(response http.ResponseWriter, request *http.Request) {
 	blogTitle := strings.ToTitle(blogPost.Title)
 
 	// confirm if blog title has already been taken
-	result, _ := utils.GetMongoDBDoc(BlogCollectionName, bson.M{"title": blogTitle})
+	result, _ := utils.GetMongoDBDoc(request.Context(), BlogCollectionName, bson.M{"title": blogTitle})
 
 	if result != nil {
 		utils.GetError(
@@ -85,7 +85,7 @@ func CreatePost(response http.ResponseWriter, request *http.Request) {
 
 	detail, _ := utils.StructToMap(blogPost)
 
-	res, err := utils.CreateMongoDBDoc(BlogCollectionName, detail)
+	res, err := utils.CreateMongoDBDoc(request.Context(), BlogCollectionName, detail)
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, response)
@@ -96,7 +96,7 @@ func CreatePost(response http.ResponseWriter, request *http.Request) {
 
 	blogPostLikes := Likes{ID: insertedPostID, UsersList: []string{}}
 	blogPostLikesMap, _ := utils.StructToMap(blogPostLikes)
-	likeDocResponse, err := utils.CreateMongoDBDoc(BlogLikesCollectionName, blogPostLikesMap)
+	likeDocResponse, err := utils.CreateMongoDBDoc(request.Context(), BlogLikesCollectionName, blogPostLikesMap)
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, response)
@@ -106,7 +106,7 @@ func CreatePost(response http.ResponseWriter, request *http.Request) {
 	blogPostComments := BlogsComment{ID: insertedPostID, Comments: []Comment{}}
 	blogPostCommentsMap, _ := utils.StructToMap(blogPostComments)
 
-	commentDocResponse, err := utils.CreateMongoDBDoc(BlogCommentsCollectionName, blogPostCommentsMap)
+	commentDocResponse, err := utils.CreateMongoDBDoc(request.Context(), BlogCommentsCollectionName, blogPostCommentsMap)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, response)
 		return
@@ -128,7 +128,7 @@ func GetPost(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	result, err := utils.GetMongoDBDoc(BlogCollectionName, bson.M{"_id": objID, "deleted": false})
+	result, err := utils.GetMongoDBDoc(request.Context(), BlogCollectionName, bson.M{"_id": objID, "deleted": false})
 
 	if err != nil {
 		utils.GetError(errors.New("blog post does not exist"), http.StatusNotFound, response)
@@ -154,7 +154,7 @@ func UpdatePost(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	blogExists, er := utils.GetMongoDBDoc(BlogCollectionName, bson.M{"_id": objID})
+	blogExists, er := utils.GetMongoDBDoc(request.Context(), BlogCollectionName, bson.M{"_id": objID})
 
 	if er != nil {
 		utils.GetError(errors.New("blog post does not exist"), http.StatusNotFound, response)
@@ -194,7 +194,7 @@ func UpdatePost(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	updateRes, err := utils.UpdateOneMongoDBDoc(BlogCollectionName, postID, updateFields)
+	updateRes, err := utils.UpdateOneMongoDBDoc(request.Context(), BlogCollectionName, postID, updateFields)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, response)
 		return
@@ -219,7 +219,7 @@ func DeletePost(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	blogExists, err := utils.GetMongoDBDoc(BlogCollectionName, bson.M{"_id": objID})
+	blogExists, err := utils.GetMongoDBDoc(request.Context(), BlogCollectionName, bson.M{"_id": objID})
 	if err != nil {
 		utils.GetError(errors.New("blog post does not exist"), http.StatusNotFound, response)
 		return
@@ -232,7 +232,7 @@ func DeletePost(response http.ResponseWriter, request *http.Request) {
 
 	update := bson.M{"deleted": true, "deleted_at": time.Now()}
 
-	updateRes, err := utils.UpdateOneMongoDBDoc(BlogCollectionName, postID, update)
+	updateRes, err := utils.UpdateOneMongoDBDoc(request.Context(), BlogCollectionName, postID, update)
 	if err != nil {
 		utils.GetError(errors.New("blog post could not be deleted"), http.StatusBadRequest, response)
 		return
@@ -265,7 +265,7 @@ func LikeBlog(response http.ResponseWriter, request *http.Request) {
 
 	filter := bson.M{"_id": postID}
 
-	blogPostLikes, err := utils.GetMongoDBDoc(BlogLikesCollectionName, filter)
+	blogPostLikes, err := utils.GetMongoDBDoc(request.Context(), BlogLikesCollectionName, filter)
 	if err != nil {
 		utils.GetError(errors.New("blog post doesn't exist"), http.StatusBadRequest, response)
 		return
@@ -291,14 +291,14 @@ func LikeBlog(response http.ResponseWriter, request *http.Request) {
 	if !userExists {
 		updateData := bson.M{"$push": bson.M{"users_list": userID}}
 
-		userLikeResult, err := utils.GenericUpdateOneMongoDBDoc(BlogLikesCollectionName, postID, updateData)
+		userLikeResult, err := utils.GenericUpdateOneMongoDBDoc(request.Context(), BlogLikesCollectionName, postID, updateData)
 
 		if err != nil {
 			utils.GetError(errors.New("user could not like blog post"), http.StatusBadRequest, response)
 			return
 		}
 
-		blogPost, err := utils.GenericUpdateOneMongoDBDoc(BlogCollectionName, blogObjID, bson.M{"$inc": bson.M{"likes": 1}})
+		blogPost, err := utils.GenericUpdateOneMongoDBDoc(request.Context(), BlogCollectionName, blogObjID, bson.M{"$inc": bson.M{"likes": 1}})
 
 		if err != nil {
 			utils.GetError(errors.New("blog post like count could not be incremented"), http.StatusBadRequest, response)
@@ -319,14 +319,14 @@ func LikeBlog(response http.ResponseWriter, request *http.Request) {
 	} else {
 		updateData := bson.M{"$pull": bson.M{"users_list": userID}}
 
-		userLikeResult, err := utils.GenericUpdateOneMongoDBDoc(BlogLikesCollectionName, postID, updateData)
+		userLikeResult, err := utils.GenericUpdateOneMongoDBDoc(request.Context(), BlogLikesCollectionName, postID, updateData)
 
 		if err != nil {
 			utils.GetError(errors.New("user could not unlike blog post"), http.StatusBadRequest, response)
 			return
 		}
 
-		blogPost, err := utils.GenericUpdateOneMongoDBDoc(BlogCollectionName, blogObjID, bson.M{"$inc": bson.M{"likes": -1}})
+		blogPost, err := utils.GenericUpdateOneMongoDBDoc(request.Context(), BlogCollectionName, blogObjID, bson.M{"$inc": bson.M{"likes": -1}})
 
 		if err != nil {
 			utils.GetError(errors.New("blog post like count could not be decremented"), http.StatusBadRequest, response)
@@ -372,7 +372,7 @@ func CommentBlog(response http.ResponseWriter, request *http.Request) {
 	blogComment.CommentAt = time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), time.Now().UTC().Hour(), time.Now().Minute(), time.Now().Second(), 0, time.Local)
 	blogComment.CommentLikes = 0
 
-	blogCommentDoc, err := utils.GetMongoDBDoc(BlogCommentsCollectionName, bson.M{"_id": postID})
+	blogCommentDoc, err := utils.GetMongoDBDoc(request.Context(), BlogCommentsCollectionName, bson.M{"_id": postID})
 
 	if err != nil {
 		utils.GetError(errors.New("invalid blog post ID"), http.StatusBadRequest, response)
@@ -388,14 +388,14 @@ func CommentBlog(response http.ResponseWriter, request *http.Request) {
 
 	updateData := bson.M{"$push": bson.M{"comments": data}}
 
-	res, err := utils.GenericUpdateOneMongoDBDoc(BlogCommentsCollectionName, postID, updateData)
+	res, err := utils.GenericUpdateOneMongoDBDoc(request.Context(), BlogCommentsCollectionName, postID, updateData)
 
 	if err != nil {
 		utils.GetError(errors.New("comment unsuccessful"), http.StatusBadRequest, response)
 		return
 	}
 
-	blogPost, err := utils.GenericUpdateOneMongoDBDoc(BlogCollectionName, blogObjID, bson.M{"$inc": bson.M{"comments": 1}})
+	blogPost, err := utils.GenericUpdateOneMongoDBDoc(request.Context(), BlogCollectionName, blogObjID, bson.M{"$inc": bson.M{"comments": 1}})
 
 	if err != nil {
 		utils.GetError(errors.New("blog post comment count could not be incremented"), http.StatusBadRequest, response)
@@ -433,7 +433,7 @@ func SearchBlog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	docs, err := utils.GetMongoDBDocs("blogs", bson.M{"$text": bson.M{"$search": query}})
+	docs, err := utils.GetMongoDBDocs(r.Context(), "blogs", bson.M{"$text": bson.M{"$search": query}})
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -461,7 +461,7 @@ func MailingList(response http.ResponseWriter, request *http.Request) {
 	}
 
 	// confirm if email has not already been subscribed
-	result, _ := utils.GetMongoDBDoc(BlogMailingList, bson.M{"email": blogMail})
+	result, _ := utils.GetMongoDBDoc(request.Context(), BlogMailingList, bson.M{"email": blogMail})
 	if result != nil {
 		utils.GetError(errors.New("you already subscribed"), http.StatusBadRequest, response)
 		return
@@ -472,7 +472,7 @@ func MailingList(response http.ResponseWriter, request *http.Request) {
 
 	detail, _ := utils.StructToMap(mail)
 
-	res, err := utils.CreateMongoDBDoc(BlogMailingList, detail)
+	res, err := utils.CreateMongoDBDoc(request.Context(), BlogMailingList, detail)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, response)
 		return
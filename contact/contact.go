@@ -67,7 +67,7 @@ func MailUs(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		mongoRes, errA := utils.CreateMongoDBDoc("contact", data)
+		mongoRes, errA := utils.CreateMongoDBDoc(r.Context(), "contact", data)
 		if errA != nil {
 			utils.GetError(err, http.StatusInternalServerError, w)
 			return
@@ -88,7 +88,7 @@ func MailUs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mongoRes, err := utils.CreateMongoDBDoc("contact", data)
+	mongoRes, err := utils.CreateMongoDBDoc(r.Context(), "contact", data)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -1,6 +1,7 @@
 package data
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
@@ -21,7 +22,7 @@ func ReadData(w http.ResponseWriter, r *http.Request) {
 	filter := parseURLQuery(r)
 	filter["deleted"] = bson.M{"$ne": true}
 	filter["organization_id"] = orgID
-	docs, err := utils.GetMongoDBDocs(actualCollName, filter)
+	docs, err := utils.GetMongoDBDocs(r.Context(), actualCollName, filter)
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
@@ -168,11 +169,11 @@ func NewRead(w http.ResponseWriter, r *http.Request) {
 }
 
 func findOne(collName string, filter bson.M, opts ...*options.FindOneOptions) (bson.M, error) {
-	return utils.GetMongoDBDoc(collName, filter, opts...)
+	return utils.GetMongoDBDoc(context.TODO(), collName, filter, opts...)
 }
 
 func findMany(collName string, filter bson.M, opts ...*options.FindOptions) ([]bson.M, error) {
-	return utils.GetMongoDBDocs(collName, filter, opts...)
+	return utils.GetMongoDBDocs(context.TODO(), collName, filter, opts...)
 }
 
 func idInFilter(filter bson.M) bool {
@@ -33,7 +33,7 @@ func WriteData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if _, err := utils.GetMongoDBDoc("plugins", bson.M{"_id": mustObjectIDFromHex(reqData.PluginID)}); err != nil {
+	if _, err := utils.GetMongoDBDoc(r.Context(), "plugins", bson.M{"_id": mustObjectIDFromHex(reqData.PluginID)}); err != nil {
 		msg := "plugin with this id does not exist"
 		utils.GetError(errors.New(msg), http.StatusNotFound, w)
 
@@ -148,7 +148,7 @@ func insertMany(collName, orgID string, data interface{}) (*mongo.InsertManyResu
 		return nil, err
 	}
 
-	return utils.CreateManyMongoDBDocs(collName, docs)
+	return utils.CreateManyMongoDBDocs(context.TODO(), collName, docs)
 }
 
 func modifyDocs(docs []interface{}, orgID string) error {
@@ -172,7 +172,7 @@ func updateMany(collName string, filter map[string]interface{}, upd interface{})
 		return nil, errors.New("invalid object type")
 	}
 
-	return utils.UpdateManyMongoDBDocs(collName, filter, update)
+	return utils.UpdateManyMongoDBDocs(context.TODO(), collName, filter, update)
 }
 
 func mustObjectIDFromHex(hex string) primitive.ObjectID {
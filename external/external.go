@@ -39,7 +39,7 @@ func (eh *Handler) EmailSubscription(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	SubDoc, _ := utils.GetMongoDBDoc(NewsletterCollection, bson.M{"email": NewSubscription.Email})
+	SubDoc, _ := utils.GetMongoDBDoc(r.Context(), NewsletterCollection, bson.M{"email": NewSubscription.Email})
 	if SubDoc != nil {
 		logger.Info("%s already subscribed for newsletter", NewSubscription.Email)
 		utils.GetSuccess("User already subscribed for newsletter", subRes{status: true}, w)
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"zuri.chat/zccore/utils"
+)
+
+// ContentTypes refuses requests that change data with a body that is not JSON, so handlers
+// never try to decode a form or plain text as JSON. Routes on the exempt list, such as file
+// uploads, take other bodies and are not checked.
+type ContentTypes struct {
+	mode   string
+	exempt map[string]bool
+}
+
+// NewContentTypes checks content types as strictly as mode asks, one of utils.ContentTypeOff,
+// utils.ContentTypeLenient or utils.ContentTypeStrict. Routes are exempted by their path
+// template, e.g. "/organizations/{id}/logo".
+func NewContentTypes(mode string, exemptRoutes []string) *ContentTypes {
+	ct := &ContentTypes{mode: mode, exempt: make(map[string]bool, len(exemptRoutes))}
+
+	for _, route := range exemptRoutes {
+		ct.exempt[route] = true
+	}
+
+	return ct
+}
+
+// Middleware answers requests that change data with a 415 when their body is not JSON.
+func (ct *ContentTypes) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct.mode == utils.ContentTypeOff || isReadMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil && ct.exempt[template] {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		if err := utils.CheckJSONContentType(r, ct.mode); err != nil {
+			utils.GetError(err, http.StatusUnsupportedMediaType, w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"zuri.chat/zccore/utils"
+)
+
+func TestContentTypes(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := mux.NewRouter()
+	r.Use(NewContentTypes(utils.ContentTypeStrict, []string{"/organizations/{id}/logo"}).Middleware)
+	r.HandleFunc("/organizations/{id}", ok).Methods("GET", "PATCH")
+	r.HandleFunc("/organizations/{id}/logo", ok).Methods("PATCH")
+
+	do := func(method, path, contentType string) int {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(`{"name": "zuri"}`))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("test JSON writes pass", func(t *testing.T) {
+		if code := do("PATCH", "/organizations/123", "application/json"); code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("test other writes are refused", func(t *testing.T) {
+		for _, contentType := range []string{"text/plain", "multipart/form-data; boundary=x", ""} {
+			if code := do("PATCH", "/organizations/123", contentType); code != http.StatusUnsupportedMediaType {
+				t.Errorf("%q: expected status %d, got %d", contentType, http.StatusUnsupportedMediaType, code)
+			}
+		}
+	})
+
+	t.Run("test reads and exempt routes pass", func(t *testing.T) {
+		if code := do("GET", "/organizations/123", "text/plain"); code != http.StatusOK {
+			t.Errorf("expected read to pass with status %d, got %d", http.StatusOK, code)
+		}
+
+		if code := do("PATCH", "/organizations/123/logo", "multipart/form-data; boundary=x"); code != http.StatusOK {
+			t.Errorf("expected exempt route to pass with status %d, got %d", http.StatusOK, code)
+		}
+	})
+}
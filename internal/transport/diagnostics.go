@@ -0,0 +1,38 @@
+package http
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	AdminTokenHeader   = "X-Admin-Token"
+	diagnosticsTimeout = 5 * time.Second
+)
+
+// DiagnosticsHandler reports database latency, runtime and connection pool statistics.
+// It is only served to requests carrying the admin token, and not at all when no token is configured.
+func DiagnosticsHandler(token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		given := r.Header.Get(AdminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			utils.GetError(errors.New("invalid admin token"), http.StatusUnauthorized, w)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), diagnosticsTimeout)
+		defer cancel()
+
+		utils.GetSuccess("diagnostics retrieved successfully", utils.GetDiagnostics(ctx), w)
+	}
+}
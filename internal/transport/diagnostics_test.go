@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDiagnosticsHandler(t *testing.T) {
+	const token = "s3cret-admin-token"
+
+	get := func(handler http.HandlerFunc, given string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/debug/diagnostics", nil)
+		if given != "" {
+			req.Header.Set(AdminTokenHeader, given)
+		}
+
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+
+		return rr
+	}
+
+	t.Run("test disabled without a configured token", func(t *testing.T) {
+		if rr := get(DiagnosticsHandler(""), ""); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("test rejects missing or wrong token", func(t *testing.T) {
+		for _, given := range []string{"", "wrong-token"} {
+			if rr := get(DiagnosticsHandler(token), given); rr.Code != http.StatusUnauthorized {
+				t.Errorf("token %q: expected status %d, got %d", given, http.StatusUnauthorized, rr.Code)
+			}
+		}
+	})
+
+	t.Run("test reports diagnostics with the admin token", func(t *testing.T) {
+		rr := get(DiagnosticsHandler(token), token)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		var res struct {
+			Data map[string]json.RawMessage `json:"data"`
+		}
+
+		if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, key := range []string{"db_latency_ms", "goroutines", "memory", "pool", "slow_queries"} {
+			if _, ok := res.Data[key]; !ok {
+				t.Errorf("diagnostics missing %q", key)
+			}
+		}
+
+		var pool map[string]interface{}
+		if err := json.Unmarshal(res.Data["pool"], &pool); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, key := range []string{"open", "in_use", "max_size", "utilization"} {
+			if _, ok := pool[key]; !ok {
+				t.Errorf("pool stats missing %q", key)
+			}
+		}
+	})
+}
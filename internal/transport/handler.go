@@ -1,7 +1,9 @@
 package http
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	socketio "github.com/googollee/go-socket.io"
 	"github.com/gorilla/mux"
@@ -9,11 +11,13 @@ import (
 	gqlHandler "github.com/graphql-go/handler"
 
 	"zuri.chat/zccore/agora"
+	"zuri.chat/zccore/audit"
 	"zuri.chat/zccore/auth"
 	"zuri.chat/zccore/blog"
 	"zuri.chat/zccore/contact"
 	"zuri.chat/zccore/data"
 	"zuri.chat/zccore/external"
+	"zuri.chat/zccore/logger"
 	"zuri.chat/zccore/marketplace"
 	"zuri.chat/zccore/organizations"
 	"zuri.chat/zccore/plugin"
@@ -27,6 +31,7 @@ import (
 type Handler struct {
 	Router   *mux.Router
 	SocketIO *socketio.Server
+	Timeouts *RouteTimeouts
 }
 
 func NewHandler(server *socketio.Server) *Handler {
@@ -35,14 +40,40 @@ func NewHandler(server *socketio.Server) *Handler {
 	}
 }
 
-func (h *Handler) SetupRoutes() {
+func (h *Handler) SetupRoutes() error {
 	h.Router = mux.NewRouter().StrictSlash(true)
 
 	// Load handlers(this to reduce dependency circle issue, might reverse if not working)
-	configs := utils.NewConfigurations()
+	configs, err := utils.LoadConfigurations()
+	if err != nil {
+		return err
+	}
+
+	if err := logger.Configure(configs.LogLevel, configs.LogFormat); err != nil {
+		logger.Warn("could not configure logging, keeping the defaults: %v", err)
+	}
+
+	if err := utils.SetWriteConcerns(configs.WriteConcerns); err != nil {
+		logger.Warn("could not set write concerns, keeping the connection's: %v", err)
+	}
+
 	mailService := service.NewZcMailService(configs)
 
 	orgs := organizations.NewOrganizationHandler(configs, mailService)
+	go organizations.NewDigestScheduler(mailService).Run(context.Background())
+	go organizations.MigrateJoinDates()
+	go organizations.MigrateOrganizationSchemas(configs)
+	go organizations.EnsureMemberSearchIndex(configs.SearchCollationStrength)
+	go organizations.EnsureWorkspaceURLIndex()
+	marketplace.SetPagination(configs.Pagination)
+	go organizations.RunRoleSweeper(context.Background())
+	go organizations.NewExpiryReminderScheduler(mailService, configs.ExpiryReminderDays).Run(context.Background())
+	go organizations.RunInviteSweeper(context.Background())
+	go organizations.RunWebhookBatcher(context.Background())
+	go organizations.RunUserPurgeSweeper(context.Background(), configs.UserRetention)
+	go audit.RunAuditLogSweeper(context.Background(), configs.AuditLogRetention)
+	go utils.RunUsageFlusher(context.Background())
+	go organizations.NewExportScheduler(organizations.HTTPExportDestination{Client: &http.Client{Timeout: time.Minute}}).Run(context.Background())
 	exts := external.NewExternalHandler(configs, mailService)
 	reps := report.NewReportHandler(configs, mailService)
 	au := auth.NewAuthHandler(configs, mailService)
@@ -56,6 +87,31 @@ func (h *Handler) SetupRoutes() {
 	ps := plugin.NewMongoService(client)
 	ph := plugin.NewHandler(ps)
 
+	// Database and application time of each request, for spotting DB-bound endpoints
+	if configs.ServerTiming {
+		h.Router.Use(utils.ServerTimingMiddleware)
+	}
+
+	// Responses in camelCase for clients that ask, without changing what is stored
+	h.Router.Use(utils.JSONCaseMiddleware(configs.JSONFieldCase))
+
+	// Maintenance mode pauses writes during deploys while reads carry on
+	maintenance := NewMaintenance(configs.MaintenanceMode, configs.MaintenanceAllowRoutes)
+	h.Router.Use(maintenance.Middleware)
+
+	// Organizations can restrict which IP ranges reach them
+	h.Router.Use(utils.NewOrgIPAllowlist(configs.TrustedProxies).Middleware)
+
+	// Rate limits per organization and route, with ceilings by plan
+	h.Router.Use(utils.NewOrgRateLimiter(configs.RateLimits, time.Minute).Middleware)
+
+	// Requests are cut off after their route's timeout, so exports get longer than lookups
+	h.Timeouts = NewRouteTimeouts(configs.RequestTimeout, configs.RouteTimeouts)
+	h.Router.Use(h.Timeouts.Middleware)
+
+	// Requests that change data must send JSON, apart from uploads and the like
+	h.Router.Use(NewContentTypes(configs.ContentTypeEnforcement, configs.ContentTypeExemptRoutes).Middleware)
+
 	// Setup and init
 	h.Router.HandleFunc("/", VersionHandler)
 	h.Router.HandleFunc("/loadapp/{appid}", LoadApp).Methods("GET")
@@ -78,28 +134,56 @@ func (h *Handler) SetupRoutes() {
 	h.Router.HandleFunc("/auth/logout/other-sessions", au.LogOutOtherSessions).Methods(http.MethodPost)
 	h.Router.HandleFunc("/auth/verify-token", au.IsAuthenticated(au.VerifyTokenHandler)).Methods(http.MethodGet, http.MethodPost)
 	h.Router.HandleFunc("/auth/confirm-password", au.IsAuthenticated(au.ConfirmUserPassword)).Methods(http.MethodPost)
+	h.Router.HandleFunc("/auth/impersonate", au.IsAuthenticated(au.IsAuthorized(au.Impersonate, "zuri_admin"))).Methods(http.MethodPost)
 	h.Router.HandleFunc("/auth/social-login/{provider}/{access_token}", au.SocialAuth).Methods(http.MethodGet)
 
 	h.Router.HandleFunc("/account/verify-account", au.VerifyAccount).Methods(http.MethodPost)
 	h.Router.HandleFunc("/account/request-password-reset-code", au.RequestResetPasswordCode).Methods(http.MethodPost)
 	h.Router.HandleFunc("/account/verify-reset-password", au.VerifyPasswordResetCode).Methods(http.MethodPost)
 	h.Router.HandleFunc("/account/update-password/{verification_code:[0-9]+}", au.UpdatePassword).Methods(http.MethodPost)
+	h.Router.HandleFunc("/account/change-email", au.IsAuthenticated(au.ChangeEmail)).Methods(http.MethodPost)
+	h.Router.HandleFunc("/account/confirm-email-change", au.ConfirmEmailChange).Methods(http.MethodPost)
+	h.Router.HandleFunc("/account/sessions", au.IsAuthenticated(au.ListSessions)).Methods(http.MethodGet)
+	h.Router.HandleFunc("/account/sessions/others", au.IsAuthenticated(au.RevokeAllOtherSessions)).Methods(http.MethodDelete)
+	h.Router.HandleFunc("/account/sessions/{session_id}", au.IsAuthenticated(au.RevokeSession)).Methods(http.MethodDelete)
 
 	// Organization
 	h.Router.HandleFunc("/organizations", au.IsAuthenticated(orgs.Create)).Methods("POST")
 	h.Router.HandleFunc("/organizations", au.IsAuthenticated(orgs.GetOrganizations)).Methods("GET")
-	h.Router.HandleFunc("/organizations/{id}", au.IsAuthenticated(orgs.GetOrganization)).Methods("GET")
+	h.Router.HandleFunc("/organizations/tags", au.IsAuthenticated(au.IsAuthorized(orgs.BulkTagOrganizations, "zuri_admin"))).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/deactivate", au.IsAuthenticated(au.IsAuthorized(orgs.BulkDeactivateOrganizations, "zuri_admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/activate", au.IsAuthenticated(au.IsAuthorized(orgs.BulkActivateOrganizations, "zuri_admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/waitlist", au.IsAuthenticated(au.IsAuthorized(orgs.ListWaitlist, "zuri_admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/waitlist/{email}/allow", au.IsAuthenticated(au.IsAuthorized(orgs.AllowWaitlistedUser, "zuri_admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/import", au.IsAuthenticated(au.IsAuthorized(orgs.ImportOrganization, "zuri_admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/membership-report", au.IsAuthenticated(au.IsAuthorized(orgs.ExportMembershipReport, "zuri_admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/deleted", au.IsAuthenticated(au.IsAuthorized(orgs.ListDeletedOrganizations, "zuri_admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/pending", au.IsAuthenticated(au.IsAuthorized(orgs.CreatePendingOrganization, "zuri_admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/claim", au.IsAuthenticated(orgs.ClaimPendingOrganization)).Methods("POST")
+	h.Router.HandleFunc("/organizations/workspace-url-conflicts", au.IsAuthenticated(au.IsAuthorized(orgs.GetWorkspaceURLConflicts, "zuri_admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/workspace-url-conflicts/resolve", au.IsAuthenticated(au.IsAuthorized(orgs.ResolveWorkspaceURLConflicts, "zuri_admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}", au.IsAuthenticatedOrAPIKey(orgs.GetOrganization)).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}", au.IsAuthenticated(orgs.HeadOrganization)).Methods("HEAD")
 	h.Router.HandleFunc("/organizations/{id}", au.IsAuthenticated(au.IsAuthorized(orgs.DeleteOrganization, "admin"))).Methods("DELETE")
 	h.Router.HandleFunc("/organizations/url/{url}", orgs.GetOrganizationByURL).Methods("GET")
+	h.Router.HandleFunc("/organizations/url/{url}/availability", au.IsAuthenticated(orgs.CheckWorkspaceURL)).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/merge", au.IsAuthenticated(au.IsAuthorized(orgs.MergeOrganizations, "zuri_admin"))).Methods("POST")
 
 	h.Router.HandleFunc("/organizations/{id}/url", au.IsAuthenticated(orgs.UpdateURL)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/name", au.IsAuthenticated(orgs.UpdateName)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/logo", au.IsAuthenticated(orgs.UpdateLogo)).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/public", orgs.GetPublicOrganization).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/public", au.IsAuthenticated(au.IsAuthorized(orgs.UpdatePublicProfile, "admin"))).Methods("PATCH")
 
 	h.Router.HandleFunc("/organizations/{id}/settings", au.IsAuthenticated(orgs.UpdateOrganizationSettings)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/permission", au.IsAuthenticated(orgs.UpdateOrganizationPermission)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/auth", au.IsAuthenticated(orgs.UpdateOrganizationAuthentication)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/change-owner", au.IsAuthenticated(au.IsAuthorized(orgs.TransferOwnership, "owner"))).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/owners", au.IsAuthenticated(au.IsAuthorized(orgs.GetOwners, "member"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/approval-requests", au.IsAuthenticated(au.IsAuthorized(orgs.GetApprovalRequests, "owner"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/approval-requests/{request_id}/approve", au.IsAuthenticated(au.IsAuthorized(orgs.ApproveRequest, "owner"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/owners/{mem_id}", au.IsAuthenticated(au.IsAuthorized(orgs.AddOwner, "owner"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/owners/{mem_id}", au.IsAuthenticated(au.IsAuthorized(orgs.RemoveOwner, "owner"))).Methods("DELETE")
 
 	h.Router.HandleFunc("/organizations/{id}/prefixes", au.IsAuthenticated(orgs.UpdateOrganizationPrefixes)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/slackbotresponses", au.IsAuthenticated(orgs.UpdateSlackBotResponses)).Methods("PATCH")
@@ -107,36 +191,93 @@ func (h *Handler) SetupRoutes() {
 
 	// Organization: Guest Invites
 	h.Router.HandleFunc("/organizations/{id}/send-invite", au.IsAuthenticated(au.IsAuthorized(orgs.SendInvite, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/import-members", au.IsAuthenticated(au.IsAuthorized(orgs.ImportMembersCSV, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/invites/{invite_id}/resend", au.IsAuthenticated(au.IsAuthorized(orgs.ResendInvite, "admin"))).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/invite-stats", au.IsAuthenticated(au.IsAuthorized(orgs.InviteStats, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/usage", au.IsAuthenticated(au.IsAuthorized(orgs.GetUsageSummary, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/ip-allowlist", au.IsAuthenticated(au.IsAuthorized(orgs.UpdateIPAllowlist, "admin"))).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/invite-expiry", au.IsAuthenticated(au.IsAuthorized(orgs.UpdateInviteExpiry, "admin"))).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/invites/{uuid}", orgs.CheckGuestStatus).Methods(http.MethodGet)
 	h.Router.HandleFunc("/organizations/guests/{uuid}", orgs.GuestToOrganization).Methods(http.MethodPost)
 
+	// Organization: Teams
+	h.Router.HandleFunc("/organizations/{id}/teams", au.IsAuthenticated(au.IsAuthorized(orgs.CreateTeam, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/teams", au.IsAuthenticated(au.IsAuthorized(orgs.ListTeams, "member"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/teams/{team_id}", au.IsAuthenticated(au.IsAuthorized(orgs.GetTeam, "member"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/teams/{team_id}", au.IsAuthenticated(au.IsAuthorized(orgs.DeleteTeam, "admin"))).Methods("DELETE")
+	h.Router.HandleFunc("/organizations/{id}/teams/{team_id}/members", au.IsAuthenticated(au.IsAuthorized(orgs.AddTeamMembers, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/teams/{team_id}/members/{mem_id}", au.IsAuthenticated(au.IsAuthorized(orgs.RemoveTeamMember, "admin"))).Methods("DELETE")
+
+	// Organization: Webhooks
+	h.Router.HandleFunc("/organizations/{id}/api-keys", au.IsAuthenticated(au.IsAuthorized(orgs.CreateAPIKey, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/api-keys", au.IsAuthenticated(au.IsAuthorized(orgs.GetAPIKeys, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/api-keys/{key_id}", au.IsAuthenticated(au.IsAuthorized(orgs.RevokeAPIKey, "admin"))).Methods("DELETE")
+	h.Router.HandleFunc("/organizations/{id}/webhooks", au.IsAuthenticated(au.IsAuthorized(orgs.AddWebhook, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/webhooks", au.IsAuthenticated(au.IsAuthorized(orgs.GetWebhooks, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/webhooks/pause", au.IsAuthenticated(au.IsAuthorized(orgs.PauseWebhooks, "admin"))).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/webhooks/{webhook_id}/rotate-secret", au.IsAuthenticated(au.IsAuthorized(orgs.RotateWebhookSecret, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/webhooks/{webhook_id}/verify", au.IsAuthenticated(au.IsAuthorized(orgs.VerifyWebhook, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/webhooks/deliveries", au.IsAuthenticated(au.IsAuthorized(orgs.GetWebhookDeliveries, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/webhooks/deliveries/replay", au.IsAuthenticated(au.IsAuthorized(orgs.ReplayWebhookRange, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/webhooks/deliveries/{delivery_id}/replay", au.IsAuthenticated(au.IsAuthorized(orgs.ReplayWebhookDelivery, "admin"))).Methods("POST")
+
+	// Organization: Announcements
+	h.Router.HandleFunc("/organizations/{id}/announcements", au.IsAuthenticated(au.IsAuthorized(orgs.CreateAnnouncement, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/announcements", au.IsAuthenticated(au.IsAuthorized(orgs.GetActiveAnnouncements, "member"))).Methods("GET")
+
+	h.Router.HandleFunc("/organizations/{id}/digest", au.IsAuthenticated(au.IsAuthorized(orgs.UpdateDigestSettings, "admin"))).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/diff", au.IsAuthenticated(au.IsAuthorized(orgs.GetOrganizationDiff, "zuri_admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/watch", au.IsAuthenticated(au.IsAuthorized(orgs.WatchOrganization, "member"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/domains", au.IsAuthenticated(au.IsAuthorized(orgs.AddAllowedDomain, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/domains/{domain}/verify", au.IsAuthenticated(au.IsAuthorized(orgs.VerifyAllowedDomain, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/working-hours", au.IsAuthenticated(au.IsAuthorized(orgs.UpdateWorkingHours, "admin"))).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/storage", au.IsAuthenticated(au.IsAuthorized(orgs.GetStorageUsage, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/onboarding", au.IsAuthenticated(au.IsAuthorized(orgs.GetOnboardingStatus, "member"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/clone", au.IsAuthenticated(au.IsAuthorized(orgs.CloneOrganization, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/export", au.IsAuthenticated(au.IsAuthorized(orgs.ExportOrganization, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/export-schedule", au.IsAuthenticated(au.IsAuthorized(orgs.GetExportSchedule, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/export-schedule", au.IsAuthenticated(au.IsAuthorized(orgs.UpdateExportSchedule, "admin"))).Methods("PUT")
+	h.Router.HandleFunc("/organizations/{id}/export-schedule", au.IsAuthenticated(au.IsAuthorized(orgs.DeleteExportSchedule, "admin"))).Methods("DELETE")
+
 	h.Router.HandleFunc("/organizations/{id}/plugins", au.IsAuthenticated(orgs.AddOrganizationPlugin)).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/plugins", au.IsAuthenticated(orgs.GetOrganizationPlugins)).Methods("GET")
 	h.Router.HandleFunc("/organizations/{id}/plugins/{plugin_id}", au.IsAuthenticated(orgs.GetOrganizationPlugin)).Methods("GET")
 	h.Router.HandleFunc("/organizations/{id}/plugins/{plugin_id}", au.IsAuthenticated(orgs.RemoveOrganizationPlugin)).Methods("DELETE")
+	h.Router.HandleFunc("/organizations/{id}/plugins/{plugin_id}/config", au.IsAuthenticated(au.IsAuthorized(orgs.GetPluginConfig, "member"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/plugins/{plugin_id}/config", au.IsAuthenticated(au.IsAuthorized(orgs.UpdatePluginConfig, "admin"))).Methods("PATCH")
 
 	h.Router.HandleFunc("/organizations/{id}/members", au.IsAuthenticated(au.IsAuthorized(orgs.CreateMember, "admin"))).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/members", orgs.GetMembers).Methods("GET")
 	h.Router.HandleFunc("/organizations/{id}/members/multiple", au.IsAuthenticated(orgs.GetmultipleMembers)).Methods("GET")
-	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}", au.IsAuthenticated(orgs.GetMember)).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/members/inactive", au.IsAuthenticated(au.IsAuthorized(orgs.GetInactiveMembers, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/members/deactivate-invited", au.IsAuthenticated(au.IsAuthorized(orgs.DeactivateMembersInvitedBy, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/heartbeat", au.IsAuthenticated(au.IsAuthorized(orgs.Heartbeat, "member"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}", au.IsAuthenticatedOrAPIKey(orgs.GetMember)).Methods("GET")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}", au.IsAuthenticated(au.IsAuthorized(orgs.DeactivateMember, "admin"))).Methods("DELETE")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/reactivate", au.IsAuthenticated(au.IsAuthorized(orgs.ReactivateMember, "admin"))).Methods("POST")
 
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/status", au.IsAuthenticated(orgs.UpdateMemberStatus)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/status/remove-history/{history_index}", au.IsAuthenticated(orgs.RemoveStatusHistory)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/photo/{action}", au.IsAuthenticated(orgs.UpdateProfilePicture)).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/email-visibility", au.IsAuthenticated(orgs.UpdateEmailVisibility)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/profile", au.IsAuthenticated(orgs.UpdateProfile)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/uploadfile", au.IsAuthenticated(orgs.UploadFile)).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/presence", au.IsAuthenticated(orgs.TogglePresence)).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/settings", au.IsAuthenticated(orgs.UpdateMemberSettings)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/role", au.IsAuthenticated(au.IsAuthorized(orgs.UpdateMemberRole, "admin"))).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/temporary-role", au.IsAuthenticated(au.IsAuthorized(orgs.GrantTemporaryRole, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/temporary-role", au.IsAuthenticated(au.IsAuthorized(orgs.RevokeTemporaryRole, "admin"))).Methods("DELETE")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/notes", au.IsAuthenticated(au.IsAuthorized(orgs.AddMemberNote, "admin"))).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/notes", au.IsAuthenticated(au.IsAuthorized(orgs.ListMemberNotes, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/notes/{note_id}", au.IsAuthenticated(au.IsAuthorized(orgs.DeleteMemberNote, "admin"))).Methods("DELETE")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/settings/notification", au.IsAuthenticated(orgs.UpdateNotification)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/settings/theme", au.IsAuthenticated(orgs.UpdateUserTheme)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/settings/message-media", au.IsAuthenticated(orgs.UpdateMemberMessageAndMediaSettings)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/settings/accessibility", au.IsAuthenticated(orgs.UpdateMemberAccessibilitySettings)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/settings/languages-and-region", au.IsAuthenticated(orgs.UpdateLanguagesAndRegions)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/settings/advanced", au.IsAuthenticated(orgs.UpdateMemberAdvancedSettings)).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/notification-preferences", au.IsAuthenticated(orgs.GetNotificationPreferences)).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/members/{mem_id}/notification-preferences", au.IsAuthenticated(orgs.UpdateNotificationPreferences)).Methods("PATCH")
 
 	h.Router.HandleFunc("/organizations/{id}/reports", au.IsAuthenticated(reps.AddReport)).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/reports", au.IsAuthenticated(reps.GetReports)).Methods("GET")
@@ -144,10 +285,15 @@ func (h *Handler) SetupRoutes() {
 
 	h.Router.HandleFunc("/organizations/{id}/billing/settings", au.IsAuthenticated(orgs.UpdateBillingSettings)).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/billing/contact", au.IsAuthenticated(orgs.UpdateBillingContact)).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/billing/invoice-contact", au.IsAuthenticated(au.IsAuthorized(orgs.GetInvoiceContact, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/billing/invoice-contact", au.IsAuthenticated(au.IsAuthorized(orgs.UpdateInvoiceContact, "admin"))).Methods("PATCH")
 
 	//organization: payment
 	h.Router.HandleFunc("/organizations/{id}/add-token", au.IsAuthenticated(orgs.AddToken)).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/token-transactions", au.IsAuthenticated(orgs.GetTokenTransaction)).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/plan", au.IsAuthenticated(au.IsAuthorized(orgs.UpdatePlan, "zuri_admin"))).Methods("PATCH")
+	h.Router.HandleFunc("/organizations/{id}/plan-history", au.IsAuthenticated(au.IsAuthorized(orgs.GetPlanHistory, "admin"))).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/feature-flags", au.IsAuthenticated(au.IsAuthorized(orgs.UpdateFeatureFlags, "admin"))).Methods("PATCH")
 	h.Router.HandleFunc("/organizations/{id}/upgrade-to-pro", au.IsAuthenticated(orgs.UpgradeToPro)).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/charge-tokens", au.IsAuthenticated(orgs.ChargeTokens)).Methods("POST")
 	h.Router.HandleFunc("/organizations/{id}/checkout-session", au.IsAuthenticated(orgs.CreateCheckoutSession)).Methods("POST")
@@ -182,10 +328,18 @@ func (h *Handler) SetupRoutes() {
 	h.Router.HandleFunc("/users/{user_id}", au.IsAuthenticated(au.IsAuthorized(us.GetUser, "zuri_admin"))).Methods("GET")
 	h.Router.HandleFunc("/users/{user_id}", au.IsAuthenticated(au.IsAuthorized(us.DeleteUser, "zuri_admin"))).Methods("DELETE")
 	h.Router.HandleFunc("/users", au.IsAuthenticated(au.IsAuthorized(us.GetUsers, "zuri_admin"))).Methods("GET")
+	h.Router.HandleFunc("/users/{user_id}/avatar", au.IsAuthenticated(us.GetUserAvatar)).Methods("GET")
+	h.Router.HandleFunc("/users/{user_id}/restore", au.IsAuthenticated(au.IsAuthorized(us.RestoreUser, "zuri_admin"))).Methods("POST")
+	h.Router.HandleFunc("/users/{user_id}/anonymize", au.IsAuthenticated(au.IsAuthorized(orgs.EraseUser, "zuri_admin"))).Methods("POST")
 	h.Router.HandleFunc("/users/{email}/organizations", au.IsAuthenticated(us.GetUserOrganizations)).Methods("GET")
+	h.Router.HandleFunc("/organizations/{id}/pin", au.IsAuthenticated(orgs.PinOrganization)).Methods("POST")
+	h.Router.HandleFunc("/organizations/{id}/pin", au.IsAuthenticated(orgs.UnpinOrganization)).Methods("DELETE")
 
 	h.Router.HandleFunc("/guests/invite", us.CreateUserFromUUID).Methods("POST")
 
+	// Audit log
+	h.Router.HandleFunc("/audit-logs/export", au.IsAuthenticated(au.IsAuthorized(audit.ExportAuditLog, "zuri_admin"))).Methods("GET")
+
 	// Contact Us
 	h.Router.HandleFunc("/contact", au.OptionalAuthentication(contact.MailUs)).Methods("POST")
 
@@ -226,9 +380,15 @@ func (h *Handler) SetupRoutes() {
 		utils.GetSuccess("Server is live", nil, w)
 	})
 
+	// Diagnostics, for admins triaging incidents
+	h.Router.HandleFunc("/debug/diagnostics", DiagnosticsHandler(configs.DiagnosticsToken)).Methods("GET")
+	h.Router.HandleFunc("/debug/maintenance", MaintenanceHandler(maintenance, configs.DiagnosticsToken)).Methods("PATCH")
+
 	// Home
 	http.Handle("/", h.Router)
 
 	// Docs
 	h.Router.PathPrefix("/").Handler(http.StripPrefix("/docs", http.RedirectHandler("https://docs.zuri.chat/", http.StatusMovedPermanently)))
+
+	return nil
 }
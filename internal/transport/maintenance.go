@@ -0,0 +1,119 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	maintenanceRoute      = "/debug/maintenance"
+	maintenanceRetryAfter = 5 * time.Minute
+)
+
+var errUnderMaintenance = errors.New("zuri chat is under maintenance, changes are paused for a few minutes. Please try again shortly")
+
+// Maintenance rejects requests that change data while it is enabled. Reads carry on as usual,
+// and routes on the allow list, such as logging in, are never blocked.
+type Maintenance struct {
+	enabled int32
+	allow   map[string]bool
+}
+
+// NewMaintenance sets up maintenance mode, enabled from the start if enabled is true. Routes
+// are allowed by their path template, e.g. "/organizations/{id}". The route toggling
+// maintenance mode is always allowed.
+func NewMaintenance(enabled bool, allowRoutes []string) *Maintenance {
+	m := &Maintenance{allow: map[string]bool{maintenanceRoute: true}}
+
+	for _, route := range allowRoutes {
+		m.allow[route] = true
+	}
+
+	m.SetEnabled(enabled)
+
+	return m
+}
+
+func (m *Maintenance) Enabled() bool {
+	return atomic.LoadInt32(&m.enabled) == 1
+}
+
+func (m *Maintenance) SetEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+
+	atomic.StoreInt32(&m.enabled, v)
+}
+
+func isReadMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
+
+// Middleware answers requests that change data with a 503 while maintenance mode is on.
+func (m *Maintenance) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Enabled() || isReadMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil && m.allow[template] {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		w.Header().Set("Retry-After", strconv.Itoa(int(maintenanceRetryAfter.Seconds())))
+		utils.GetError(errUnderMaintenance, http.StatusServiceUnavailable, w)
+	})
+}
+
+// MaintenanceHandler turns maintenance mode on or off. Like diagnostics, it needs the admin
+// token and is not served at all when no token is configured.
+func MaintenanceHandler(m *Maintenance, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		given := r.Header.Get(AdminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			utils.GetError(errors.New("invalid admin token"), http.StatusUnauthorized, w)
+			return
+		}
+
+		var body struct {
+			Enabled *bool `json:"enabled"`
+		}
+
+		if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+			utils.GetError(err, http.StatusUnprocessableEntity, w)
+			return
+		}
+
+		if body.Enabled == nil {
+			utils.GetError(errors.New("enabled is required"), http.StatusBadRequest, w)
+			return
+		}
+
+		m.SetEnabled(*body.Enabled)
+
+		utils.GetSuccess("maintenance mode updated successfully", utils.M{"enabled": m.Enabled()}, w)
+	}
+}
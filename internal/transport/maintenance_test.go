@@ -0,0 +1,72 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMaintenance(t *testing.T) {
+	const token = "s3cret-admin-token"
+
+	maintenance := NewMaintenance(true, []string{"/auth/login"})
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := mux.NewRouter()
+	r.Use(maintenance.Middleware)
+	r.HandleFunc("/organizations/{id}", ok).Methods("GET", "PATCH", "DELETE")
+	r.HandleFunc("/organizations", ok).Methods("POST")
+	r.HandleFunc("/auth/login", ok).Methods("POST")
+	r.HandleFunc("/debug/maintenance", MaintenanceHandler(maintenance, token)).Methods("PATCH")
+
+	do := func(method, path string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(body))
+		req.Header.Set(AdminTokenHeader, token)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("test writes are blocked", func(t *testing.T) {
+		for _, req := range []struct{ method, path string }{
+			{"POST", "/organizations"},
+			{"PATCH", "/organizations/123"},
+			{"DELETE", "/organizations/123"},
+		} {
+			rr := do(req.method, req.path, nil)
+			if rr.Code != http.StatusServiceUnavailable {
+				t.Errorf("%s %s: expected status %d, got %d", req.method, req.path, http.StatusServiceUnavailable, rr.Code)
+			}
+
+			if rr.Header().Get("Retry-After") == "" {
+				t.Errorf("%s %s: expected a Retry-After header", req.method, req.path)
+			}
+		}
+	})
+
+	t.Run("test reads and allowed routes pass", func(t *testing.T) {
+		if rr := do("GET", "/organizations/123", nil); rr.Code != http.StatusOK {
+			t.Errorf("expected read to pass with status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		if rr := do("POST", "/auth/login", nil); rr.Code != http.StatusOK {
+			t.Errorf("expected allowed route to pass with status %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("test maintenance mode can be turned off", func(t *testing.T) {
+		if rr := do("PATCH", "/debug/maintenance", []byte(`{"enabled": false}`)); rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		if rr := do("POST", "/organizations", nil); rr.Code != http.StatusOK {
+			t.Errorf("expected writes to pass after maintenance, got status %d", rr.Code)
+		}
+	})
+}
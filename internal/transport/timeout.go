@@ -0,0 +1,214 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"zuri.chat/zccore/utils"
+)
+
+var errRequestTimeout = errors.New("the request took too long, please try again")
+
+// RouteTimeouts bounds how long each request can take. Routes are looked up by method and path
+// template first, e.g. "GET /organizations/{id}", then by path template alone, and fall back to
+// a default, so slow routes such as exports get more time without every route getting it.
+// Routes with a timeout of zero, such as the socket connection, are not bounded.
+type RouteTimeouts struct {
+	fallback time.Duration
+	routes   map[string]time.Duration
+}
+
+func NewRouteTimeouts(fallback time.Duration, routes map[string]time.Duration) *RouteTimeouts {
+	return &RouteTimeouts{fallback: fallback, routes: routes}
+}
+
+// timeout returns the timeout of the route a request matched.
+func (rt *RouteTimeouts) timeout(r *http.Request) time.Duration {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			if d, ok := rt.routes[r.Method+" "+template]; ok {
+				return d
+			}
+
+			if d, ok := rt.routes[template]; ok {
+				return d
+			}
+		}
+	}
+
+	return rt.fallback
+}
+
+// Longest is the longest any request can take, for sizing the server's own timeouts.
+func (rt *RouteTimeouts) Longest() time.Duration {
+	longest := rt.fallback
+
+	for _, d := range rt.routes {
+		if d > longest {
+			longest = d
+		}
+	}
+
+	return longest
+}
+
+// unbounded reports whether any route is left unbounded.
+func (rt *RouteTimeouts) unbounded() bool {
+	if rt.fallback <= 0 {
+		return true
+	}
+
+	for _, d := range rt.routes {
+		if d <= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Server returns a server for handler whose write timeout leaves routes time to answer their
+// own timeouts. The write timeout applies to every connection, so a server with unbounded
+// routes, such as the organization watch stream, has none and leaves bounding its other
+// routes to Middleware.
+func (rt *RouteTimeouts) Server(addr string, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Handler:     handler,
+		Addr:        addr,
+		ReadTimeout: 15 * time.Second,
+	}
+
+	if !rt.unbounded() {
+		srv.WriteTimeout = rt.Longest() + 5*time.Second
+	}
+
+	return srv
+}
+
+// Middleware cancels the context of requests that outlast their route's timeout and answers
+// them with a 503. A response that has already started is cut short instead.
+func (rt *RouteTimeouts) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := rt.timeout(r)
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{ctx: ctx, w: w, h: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			// headers of a handler that wrote nothing else still go out
+			if !tw.wroteHeader {
+				for k, vv := range tw.h {
+					w.Header()[k] = vv
+				}
+			}
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			tw.timedOut = true
+
+			if !tw.wroteHeader && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				utils.GetError(errRequestTimeout, http.StatusServiceUnavailable, w)
+			}
+		}
+	})
+}
+
+// timeoutWriter passes a handler's response through until its request times out, after which
+// writes are dropped. Headers are kept apart until written so the handler never races the
+// timeout response.
+type timeoutWriter struct {
+	ctx context.Context
+	w   http.ResponseWriter
+	h   http.Header
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+// expired reports whether the request is over, even if the middleware is yet to notice.
+// Must be called with tw.mu held.
+func (tw *timeoutWriter) expired() bool {
+	return tw.timedOut || tw.ctx.Err() != nil
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.writeHeader(code)
+}
+
+// must be called with tw.mu held.
+func (tw *timeoutWriter) writeHeader(code int) {
+	if tw.expired() || tw.wroteHeader {
+		return
+	}
+
+	tw.wroteHeader = true
+
+	dst := tw.w.Header()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	tw.writeHeader(http.StatusOK)
+
+	return tw.w.Write(b)
+}
+
+// Flush lets streamed responses, such as exports, through as they are written.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if f, ok := tw.w.(http.Flusher); ok && !tw.expired() {
+		tw.writeHeader(http.StatusOK)
+		f.Flush()
+	}
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"zuri.chat/zccore/utils"
+)
+
+func TestRouteTimeouts(t *testing.T) {
+	timeouts := NewRouteTimeouts(time.Second, map[string]time.Duration{
+		"GET /organizations/{id}":    20 * time.Millisecond,
+		"/organizations/{id}/export": 500 * time.Millisecond,
+	})
+
+	cancelled := make(chan error, 1)
+
+	// slow takes 100ms, or gives up when its context is cancelled
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			w.Write([]byte("done"))
+		case <-r.Context().Done():
+			cancelled <- r.Context().Err()
+		}
+	}
+
+	r := mux.NewRouter()
+	r.Use(timeouts.Middleware)
+	r.HandleFunc("/organizations/{id}", slow).Methods("GET", "PATCH")
+	r.HandleFunc("/organizations/{id}/export", slow).Methods("GET")
+	r.HandleFunc("/organizations/{id}/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		<-r.Context().Done()
+		w.Write([]byte("never sent"))
+	}).Methods("GET")
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("test slow handlers hit their route's timeout", func(t *testing.T) {
+		if rr := do("GET", "/organizations/123"); rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+		}
+
+		select {
+		case err := <-cancelled:
+			if err != context.DeadlineExceeded {
+				t.Errorf("expected the deadline to cancel the context, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Error("expected the handler's context to be cancelled")
+		}
+	})
+
+	t.Run("test routes fall back to the path and the default", func(t *testing.T) {
+		for _, req := range []struct{ method, path string }{
+			{"GET", "/organizations/123/export"},
+			{"PATCH", "/organizations/123"},
+		} {
+			if rr := do(req.method, req.path); rr.Code != http.StatusOK || rr.Body.String() != "done" {
+				t.Errorf("%s %s: expected the handler to finish, got %d %q", req.method, req.path, rr.Code, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("test started responses are cut short", func(t *testing.T) {
+		timeouts.routes["/organizations/{id}/stream"] = 20 * time.Millisecond
+
+		rr := do("GET", "/organizations/123/stream")
+		if rr.Code != http.StatusOK || rr.Body.String() != "partial" {
+			t.Errorf("expected the partial response, got %d %q", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("test routes without a timeout are not bounded", func(t *testing.T) {
+		timeouts.routes["GET /organizations/{id}"] = 0
+
+		if rr := do("GET", "/organizations/123"); rr.Code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+	})
+
+	t.Run("test longest covers every route", func(t *testing.T) {
+		if longest := timeouts.Longest(); longest != time.Second {
+			t.Errorf("expected %v, got %v", time.Second, longest)
+		}
+	})
+}
+
+func TestWatchOutlivesDefaultTimeout(t *testing.T) {
+	timeouts := NewRouteTimeouts(20*time.Millisecond, utils.NewConfigurations().RouteTimeouts)
+
+	r := mux.NewRouter()
+	r.Use(timeouts.Middleware)
+	r.HandleFunc("/organizations/{id}/watch", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			select {
+			case <-time.After(10 * time.Millisecond):
+				w.Write([]byte("event\n"))
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}).Methods("GET")
+
+	req, _ := http.NewRequest("GET", "/organizations/123/watch", nil)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || strings.Count(rr.Body.String(), "event") != 5 {
+		t.Errorf("expected all 5 events to be streamed, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServerKeepsWatchOpen(t *testing.T) {
+	timeouts := NewRouteTimeouts(20*time.Millisecond, utils.NewConfigurations().RouteTimeouts)
+
+	r := mux.NewRouter()
+	r.Use(timeouts.Middleware)
+	r.HandleFunc("/organizations/{id}/watch", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			time.Sleep(10 * time.Millisecond)
+			w.Write([]byte("event\n"))
+			w.(http.Flusher).Flush()
+		}
+	}).Methods("GET")
+
+	srv := timeouts.Server("", r)
+	if srv.WriteTimeout != 0 {
+		t.Fatalf("expected no write timeout with the watch stream unbounded, got %v", srv.WriteTimeout)
+	}
+
+	ts := httptest.NewUnstartedServer(r)
+	ts.Config = srv
+	ts.Start()
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/organizations/123/watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil || strings.Count(string(body), "event") != 5 {
+		t.Errorf("expected all 5 events to be streamed, got %q, %v", body, err)
+	}
+
+	t.Run("test bounded servers leave routes time to time out", func(t *testing.T) {
+		bounded := NewRouteTimeouts(time.Second, map[string]time.Duration{"/organizations/{id}/export": time.Minute})
+
+		if got := bounded.Server("", r).WriteTimeout; got != time.Minute+5*time.Second {
+			t.Errorf("expected write timeout %v, got %v", time.Minute+5*time.Second, got)
+		}
+	})
+}
+
+// TestRouteTimeoutsCancelDBWork needs a database in CLUSTER_URL.
+func TestRouteTimeoutsCancelDBWork(t *testing.T) {
+	clusterURL := os.Getenv("CLUSTER_URL")
+	if clusterURL == "" {
+		t.Skip("CLUSTER_URL is not set")
+	}
+
+	if err := utils.ConnectToDB(clusterURL); err != nil {
+		t.Fatal(err)
+	}
+
+	queried := make(chan error, 1)
+
+	timeouts := NewRouteTimeouts(20*time.Millisecond, nil)
+
+	r := mux.NewRouter()
+	r.Use(timeouts.Middleware)
+	r.HandleFunc("/organizations/{id}", func(w http.ResponseWriter, r *http.Request) {
+		// the handler is still busy when its time is up, so its next query must not run
+		time.Sleep(50 * time.Millisecond)
+
+		_, err := utils.GetMongoDBDocs(r.Context(), "organizations", bson.M{})
+		queried <- err
+	}).Methods("GET")
+
+	req, _ := http.NewRequest("GET", "/organizations/123", nil)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+
+	select {
+	case err := <-queried:
+		if !mongo.IsTimeout(err) {
+			t.Errorf("expected the query to be cancelled by the deadline, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("expected the handler to finish its query")
+	}
+}
@@ -2,33 +2,80 @@ package logger
 
 import (
 	"fmt"
+	"os"
+	"strings"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	// JSONFormat logs one JSON object per line, for log collectors
+	JSONFormat = "json"
+	// TextFormat logs tab separated lines, for reading in a terminal
+	TextFormat = "text"
+)
+
 var log *zap.Logger
 
 func init() {
 	var err error
 
-	config := zap.NewProductionConfig()
+	log, err = newLogger(zapcore.InfoLevel, JSONFormat, zapcore.Lock(os.Stderr))
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+// newLogger builds a logger writing entries at the level and above to out in the format.
+func newLogger(level zapcore.Level, format string, out zapcore.WriteSyncer) (*zap.Logger, error) {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.TimeKey = "timestamp"
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	encoderConfig.StacktraceKey = ""
-	config.EncoderConfig = encoderConfig
 
-	log, err = config.Build(zap.AddCallerSkip(1))
+	var encoder zapcore.Encoder
+
+	switch format {
+	case JSONFormat:
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	case TextFormat:
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use %s or %s", format, JSONFormat, TextFormat)
+	}
+
+	return zap.New(zapcore.NewCore(encoder, out, level), zap.AddCaller(), zap.AddCallerSkip(1)), nil
+}
+
+// Configure sets the lowest level logged, one of debug, info, warn or error, and the format
+// logs are written in, json or text. The logger is left as it was when either is unknown.
+func Configure(level, format string) error {
+	var l zapcore.Level
+	if err := l.UnmarshalText([]byte(strings.ToLower(level))); err != nil {
+		return fmt.Errorf("unknown log level %q, use debug, info, warn or error", level)
+	}
 
+	logger, err := newLogger(l, strings.ToLower(format), zapcore.Lock(os.Stderr))
 	if err != nil {
-		panic(err)
+		return err
 	}
+
+	log = logger
+
+	return nil
 }
 
 func Info(message string, args ...interface{}) {
 	log.Info(fmt.Sprintf(message, args...))
 }
 
+func Warn(message string, args ...interface{}) {
+	log.Warn(fmt.Sprintf(message, args...))
+}
+
 func Error(message string, args ...interface{}) {
 	log.Error(fmt.Sprintf(message, args...))
 }
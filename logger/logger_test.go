@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLevelFiltering(t *testing.T) {
+	var out bytes.Buffer
+
+	logger, err := newLogger(zapcore.InfoLevel, JSONFormat, zapcore.AddSync(&out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(previous *zap.Logger) { log = previous }(log)
+
+	log = logger
+
+	Debug("debug %d", 1)
+	Info("info %d", 2)
+	Warn("warn %d", 3)
+
+	logged := out.String()
+
+	if strings.Contains(logged, "debug 1") {
+		t.Errorf("expected debug to be suppressed at info, got %s", logged)
+	}
+
+	if !strings.Contains(logged, "info 2") || !strings.Contains(logged, `"level":"warn"`) {
+		t.Errorf("expected info and warn to be logged, got %s", logged)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	defer func(previous *zap.Logger) { log = previous }(log)
+
+	if err := Configure("verbose", JSONFormat); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+
+	if err := Configure("debug", "xml"); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+
+	if err := Configure("WARN", TextFormat); err != nil {
+		t.Errorf("expected warn in text to be accepted, got %v", err)
+	}
+}
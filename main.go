@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"time"
 
@@ -56,18 +55,15 @@ func (app *App) Run() error {
 
 	// transporter
 	handler := transportHttp.NewHandler(Server)
-	handler.SetupRoutes()
+	if err := handler.SetupRoutes(); err != nil {
+		return err
+	}
 
 	c := cors.AllowAll()
 
 	h := transportHttp.RequestDurationMiddleware(handler.Router)
 
-	srv := &http.Server{
-		Handler:      handlers.LoggingHandler(os.Stdout, c.Handler(h)),
-		Addr:         ":" + app.Port,
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
-	}
+	srv := handler.Timeouts.Server(":"+app.Port, handlers.LoggingHandler(os.Stdout, c.Handler(h)))
 
 	//nolint:errcheck //CODEI8: ignore error check
 	go Server.Serve()
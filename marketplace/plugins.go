@@ -3,7 +3,6 @@ package marketplace
 import (
 	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,6 +13,14 @@ import (
 	"zuri.chat/zccore/utils"
 )
 
+// pagination sizes the pages of plugin lists, see SetPagination.
+var pagination = utils.Pagination{DefaultPerPage: 10, MaxPerPage: 100}
+
+// SetPagination sets the default and largest page sizes of plugin lists.
+func SetPagination(p utils.Pagination) {
+	pagination = p
+}
+
 // GetAllPlugins returns all approved plugins available in the database.
 func GetAllPlugins(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
@@ -23,7 +30,7 @@ func GetAllPlugins(w http.ResponseWriter, r *http.Request) {
 	filter := bson.M{"approved": true}
 
 	if limStr != "" || pgStr != "" {
-		limit, page := getLimitandPage(limStr, pgStr)
+		limit, page := pagination.PageParams(limStr, pgStr)
 		opts.SetLimit(int64(limit)).SetSkip(int64((limit * page) - limit))
 
 		resp["page"], resp["limit"] = page, limit
@@ -102,7 +109,7 @@ func RemovePlugin(w http.ResponseWriter, r *http.Request) {
 
 	update := bson.M{"approved": false}
 
-	if _, err = utils.UpdateOneMongoDBDoc(plugin.PluginCollectionName, pluginID, update); err != nil {
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), plugin.PluginCollectionName, pluginID, update); err != nil {
 		utils.GetError(errors.New("plugin removal failed"), http.StatusBadRequest, w)
 		return
 	}
@@ -154,7 +161,7 @@ func Search(w http.ResponseWriter, r *http.Request) {
 	opts := options.Find()
 
 	if query.Get("limit") != "" || query.Get("page") != "" {
-		limit, page := getLimitandPage(query.Get("limit"), query.Get("page"))
+		limit, page := pagination.PageParams(query.Get("limit"), query.Get("page"))
 
 		opts.SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
 			SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
@@ -165,7 +172,7 @@ func Search(w http.ResponseWriter, r *http.Request) {
 		resp["total"] = utils.CountCollection(r.Context(), "plugins", filter)
 	}
 
-	docs, err := utils.GetMongoDBDocs("plugins", filter, opts)
+	docs, err := utils.GetMongoDBDocs(r.Context(), "plugins", filter, opts)
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
@@ -176,18 +183,3 @@ func Search(w http.ResponseWriter, r *http.Request) {
 
 	utils.GetSuccess("success", resp, w)
 }
-
-func getLimitandPage(l, p string) (limit, page int) {
-	limit, _ = strconv.Atoi(l)
-	page, _ = strconv.Atoi(p)
-
-	if page < 1 {
-		page = 1
-	}
-
-	if limit < 1 {
-		limit = 10
-	}
-
-	return
-}
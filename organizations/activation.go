@@ -0,0 +1,166 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// most organizations BulkDeactivateOrganizations and BulkActivateOrganizations take at once
+const maxBulkActivationOrganizations = 1000
+
+var errOrganizationDeactivated = errors.New("organization is deactivated")
+
+// BulkActivationRequest lists the organizations to deactivate or reactivate.
+type BulkActivationRequest struct {
+	OrganizationIDs []string `json:"organization_ids"`
+}
+
+// Deactivate a list of organizations. They are kept as they are, but cannot be fetched until
+// they are reactivated. Organizations already deactivated are skipped.
+func (oh *OrganizationHandler) BulkDeactivateOrganizations(w http.ResponseWriter, r *http.Request) {
+	setOrganizationsActive(w, r, false)
+}
+
+// Reactivate a list of deactivated organizations, so they can be used again. Organizations that
+// are not deactivated are skipped.
+func (oh *OrganizationHandler) BulkActivateOrganizations(w http.ResponseWriter, r *http.Request) {
+	setOrganizationsActive(w, r, true)
+}
+
+// setOrganizationsActive deactivates or reactivates the organizations in the request, and
+// records each one it changes in the audit log.
+func setOrganizationsActive(w http.ResponseWriter, r *http.Request, active bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	verb, state, action := "deactivated", "deactivated", audit.OrganizationDeactivated
+	if active {
+		verb, state, action = "reactivated", "active", audit.OrganizationReactivated
+	}
+
+	var body BulkActivationRequest
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if len(body.OrganizationIDs) == 0 {
+		utils.GetError(errors.New("organization_ids is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	if len(body.OrganizationIDs) > maxBulkActivationOrganizations {
+		utils.GetError(fmt.Errorf("at most %d organizations can be %s at once", maxBulkActivationOrganizations, verb), http.StatusBadRequest, w)
+		return
+	}
+
+	result := utils.NewBulkResult()
+	requested := make(map[string]string, len(body.OrganizationIDs))
+	orgIDs := make([]primitive.ObjectID, 0, len(body.OrganizationIDs))
+
+	for _, id := range body.OrganizationIDs {
+		pOrgID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			result.Fail(id, "invalid organization id", nil)
+			continue
+		}
+
+		if _, ok := requested[pOrgID.Hex()]; ok {
+			result.Skip(id, "organization appears earlier in the list", nil)
+			continue
+		}
+
+		requested[pOrgID.Hex()] = id
+		orgIDs = append(orgIDs, pOrgID)
+	}
+
+	docs, err := utils.GetMongoDBDocs(r.Context(), OrganizationCollectionName, bson.M{"_id": bson.M{"$in": orgIDs}, "deleted": bson.M{"$ne": true}},
+		options.Find().SetProjection(bson.M{"deactivated": 1}))
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	deactivated := make(map[string]bool, len(docs))
+
+	for _, doc := range docs {
+		var org Organization
+		if err = utils.BsonToStruct(doc, &org); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		deactivated[org.ID] = org.Deactivated
+	}
+
+	changed := make([]primitive.ObjectID, 0, len(orgIDs))
+
+	for _, pOrgID := range orgIDs {
+		id := requested[pOrgID.Hex()]
+
+		isDeactivated, ok := deactivated[pOrgID.Hex()]
+		if !ok {
+			result.Fail(id, "organization not found", nil)
+			continue
+		}
+
+		if isDeactivated != active {
+			result.Skip(id, "organization is already "+state, nil)
+			continue
+		}
+
+		changed = append(changed, pOrgID)
+	}
+
+	if len(changed) > 0 {
+		now := utils.NowUTC()
+
+		// the filter on the current state leaves out organizations changed since they were read
+		filter := bson.M{"_id": bson.M{"$in": changed}, "deactivated": bson.M{"$ne": true}}
+		update := bson.M{"$set": bson.M{"deactivated": true, "deactivated_at": now}}
+
+		if active {
+			filter["deactivated"] = true
+			update = bson.M{"$unset": bson.M{"deactivated": "", "deactivated_at": ""}}
+		}
+
+		if _, err = utils.GetCollection(OrganizationCollectionName).UpdateMany(r.Context(), filter, update); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		loggedInUser, _ := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+
+		for _, pOrgID := range changed {
+			result.Succeed(requested[pOrgID.Hex()], utils.M{verb: true})
+
+			entry := &audit.Log{
+				OrgID:      pOrgID.Hex(),
+				Action:     action,
+				TargetType: "organization",
+				TargetID:   pOrgID.Hex(),
+				CreatedAt:  now,
+			}
+
+			if loggedInUser != nil {
+				entry.Actor, entry.ImpersonatedBy = loggedInUser.Email, loggedInUser.ImpersonatedBy
+			}
+
+			// the organization has already changed, so a missing entry is logged rather than failed
+			if err = audit.Record(entry); err != nil {
+				logger.Error("could not record that organization %s was %s: %v", pOrgID.Hex(), verb, err)
+			}
+		}
+	}
+
+	utils.GetBulkResult(fmt.Sprintf("organizations %s successfully", verb), result, w)
+}
@@ -0,0 +1,95 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/utils"
+)
+
+func TestBulkActivateOrganizations(t *testing.T) {
+	orgIDs := make([]string, 3)
+
+	for i := range orgIDs {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		orgIDs[i] = orgID
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/deactivate", orgs.BulkDeactivateOrganizations).Methods("POST")
+	r.HandleFunc("/organizations/activate", orgs.BulkActivateOrganizations).Methods("POST")
+	r.HandleFunc("/organizations/{id}", orgs.GetOrganization).Methods("GET")
+
+	bulk := func(t *testing.T, path string, ids []string, expectedCode int) map[string]interface{} {
+		requestBody, _ := json.Marshal(BulkActivationRequest{OrganizationIDs: ids})
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, expectedCode)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	assertResult := func(t *testing.T, data map[string]interface{}, succeeded, failed, skipped int) {
+		t.Helper()
+
+		for section, want := range map[string]int{"succeeded": succeeded, "failed": failed, "skipped": skipped} {
+			if items, _ := data[section].([]interface{}); len(items) != want {
+				t.Errorf("expected %d %s, got %v", want, section, data[section])
+			}
+		}
+	}
+
+	assertAccessible := func(t *testing.T, orgID string, expectedCode int) {
+		t.Helper()
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s", orgID), nil)
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, expectedCode)
+	}
+
+	t.Run("test deactivated organizations cannot be fetched", func(t *testing.T) {
+		assertResult(t, bulk(t, "/organizations/deactivate", orgIDs[:2], http.StatusOK), 2, 0, 0)
+
+		for _, orgID := range orgIDs[:2] {
+			assertAccessible(t, orgID, http.StatusForbidden)
+		}
+
+		assertAccessible(t, orgIDs[2], http.StatusOK)
+	})
+
+	t.Run("test reactivating several organizations", func(t *testing.T) {
+		data := bulk(t, "/organizations/activate", append([]string{"not-an-id"}, orgIDs...), http.StatusMultiStatus)
+		assertResult(t, data, 2, 1, 1)
+
+		if item := data["skipped"].([]interface{})[0].(map[string]interface{}); item["item"] != orgIDs[2] {
+			t.Errorf("expected the active organization %s to be skipped, got %v", orgIDs[2], item)
+		}
+
+		for _, orgID := range orgIDs {
+			assertAccessible(t, orgID, http.StatusOK)
+		}
+
+		for _, orgID := range orgIDs[:2] {
+			filter := bson.M{"target_id": orgID, "action": audit.OrganizationReactivated}
+			if n := utils.CountCollection(context.TODO(), audit.AuditLogCollectionName, filter); n != 1 {
+				t.Errorf("expected the reactivation of %s to be audited once, got %d entries", orgID, n)
+			}
+		}
+	})
+
+	t.Run("test reactivating again changes nothing", func(t *testing.T) {
+		assertResult(t, bulk(t, "/organizations/activate", orgIDs[:2], http.StatusOK), 0, 0, 2)
+	})
+}
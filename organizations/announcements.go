@@ -0,0 +1,160 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const CreateOrganizationAnnouncement = "CreateOrganizationAnnouncement"
+
+type announcementBody struct {
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Notify    bool      `json:"notify"`
+}
+
+// activeAnnouncements filters out the announcements that have expired by now.
+func activeAnnouncements(announcements []Announcement, now time.Time) []Announcement {
+	active := []Announcement{}
+
+	for _, a := range announcements {
+		if a.ExpiresAt.After(now) {
+			active = append(active, a)
+		}
+	}
+
+	return active
+}
+
+// emails an announcement to the members of an organization who have not opted out of
+// announcements. It is run in the background, as large organizations take longer to email than
+// a request can.
+func (oh *OrganizationHandler) notifyAnnouncement(org *Organization, announcement *Announcement) {
+	orgID := org.ID
+
+	memberDocs, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "deleted": bson.M{"$ne": true}})
+	if err != nil {
+		logger.Error("could not fetch members of %s for announcement: %v", orgID, err)
+		return
+	}
+
+	for _, doc := range memberDocs {
+		var member Member
+		if err := utils.BsonToStruct(doc, &member); err != nil {
+			continue
+		}
+
+		if !member.NotificationPreferences.Allows(NotifyAnnouncements) {
+			continue
+		}
+
+		subject := fmt.Sprintf("%s: %s", org.Name, announcement.Title)
+		msger := org.sentFor(oh.mailService.NewCustomMail([]string{member.Email}, subject, announcement.Body))
+
+		if err := oh.mailService.SendMail(msger); err != nil {
+			logger.Error("Error occurred while sending mail: %s", err.Error())
+		}
+	}
+}
+
+// Post an announcement to every member of an organization. Members are emailed in the
+// background when asked to be.
+func (oh *OrganizationHandler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body announcementBody
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if strings.TrimSpace(body.Title) == "" || strings.TrimSpace(body.Body) == "" {
+		utils.GetError(errors.New("announcement title and body are required"), http.StatusBadRequest, w)
+		return
+	}
+
+	now := utils.NowUTC()
+	if !body.ExpiresAt.After(now) {
+		utils.GetError(errors.New("announcement expiry must be in the future"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	announcement := Announcement{
+		ID:        primitive.NewObjectID().Hex(),
+		Title:     body.Title,
+		Body:      body.Body,
+		CreatedBy: loggedInUser.Email,
+		CreatedAt: now,
+		ExpiresAt: body.ExpiresAt,
+	}
+
+	if _, err = utils.GenericUpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, pOrgID, bson.M{"$push": bson.M{"announcements": announcement}}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// publish update to subscriber
+	eventChannel := fmt.Sprintf("organizations_%s", orgID)
+	event := utils.Event{Identifier: announcement.ID, Type: "Organization", Event: CreateOrganizationAnnouncement, Channel: eventChannel, Payload: make(map[string]interface{})}
+
+	go utils.Emitter(event)
+
+	if body.Notify && oh.mailService != nil {
+		go oh.notifyAnnouncement(org, &announcement)
+	}
+
+	utils.GetSuccess("announcement created successfully", announcement, w)
+}
+
+// Get the announcements of an organization that have not yet expired.
+func (oh *OrganizationHandler) GetActiveAnnouncements(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("announcements retrieved successfully", activeAnnouncements(org.Announcements, utils.NowUTC()), w)
+}
@@ -0,0 +1,97 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+func TestAnnouncements(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, member := "announcer@gmail.com", "listener@gmail.com"
+
+	if _, err = setUpMember(orgID, admin, AdminRole); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = setUpMember(orgID, member, MemberRole); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, email := range []string{admin, member} {
+		if err = setUpUser(email, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mailer := newMockMailService()
+	handler := NewOrganizationHandler(configs, mailer)
+	au := auth.NewAuthHandler(configs, nil)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/announcements", au.IsAuthorized(handler.CreateAnnouncement, "admin")).Methods("POST")
+	r.HandleFunc("/organizations/{id}/announcements", handler.GetActiveAnnouncements).Methods("GET")
+
+	expiresAt := time.Now().Add(24 * time.Hour).Format(time.RFC3339)
+
+	t.Run("test admin can create announcement", func(t *testing.T) {
+		requestBody := []byte(fmt.Sprintf(`{"title": "Town hall", "body": "Friday at noon", "expires_at": %q, "notify": true}`, expiresAt))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/announcements", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, withUser(req, admin))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if !mailer.awaitMailTo(member) {
+			t.Errorf("announcement mail not sent to %s", member)
+		}
+	})
+
+	t.Run("test member cannot create announcement", func(t *testing.T) {
+		requestBody := []byte(fmt.Sprintf(`{"title": "Hijack", "body": "Not allowed", "expires_at": %q}`, expiresAt))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/announcements", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, withUser(req, member))
+		assertStatusCode(t, response.Code, http.StatusUnauthorized)
+	})
+
+	t.Run("test expired announcements are not returned", func(t *testing.T) {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+		expired := Announcement{
+			ID:        primitive.NewObjectID().Hex(),
+			Title:     "Old news",
+			Body:      "Already over",
+			CreatedAt: time.Now().Add(-48 * time.Hour),
+			ExpiresAt: time.Now().Add(-time.Hour),
+		}
+
+		if _, err := utils.GenericUpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, pOrgID, bson.M{"$push": bson.M{"announcements": expired}}); err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/announcements", orgID), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, member))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		announcements := parseResponse(response)["data"].([]interface{})
+		if len(announcements) != 1 {
+			t.Fatalf("expected 1 active announcement, got %d", len(announcements))
+		}
+
+		if title := announcements[0].(map[string]interface{})["title"]; title != "Town hall" {
+			t.Errorf("expected the active announcement, got %v", title)
+		}
+	})
+}
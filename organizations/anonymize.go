@@ -0,0 +1,204 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+const userPurgeInterval = time.Hour
+
+const (
+	anonymizedName        = "Deleted"
+	anonymizedLastName    = "User"
+	anonymizedEmailDomain = "anonymized.invalid"
+)
+
+// anonymizedEmail is unique per user so the unique index on user emails still holds.
+func anonymizedEmail(userID string) string {
+	return fmt.Sprintf("deleted-%s@%s", userID, anonymizedEmailDomain)
+}
+
+// AnonymizeUser scrubs a user's personal data from their user document and from every
+// organization they are a member of. Document ids are left untouched, so memberships,
+// messages and other references to the user keep pointing at the same records.
+func AnonymizeUser(userID string) error {
+	pUserID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user id")
+	}
+
+	userDoc, _ := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"_id": pUserID})
+	if userDoc == nil {
+		return fmt.Errorf("user %s not found", userID)
+	}
+
+	email, _ := userDoc["email"].(string)
+	tombstone := anonymizedEmail(userID)
+
+	userUpdate := bson.M{
+		"first_name": anonymizedName,
+		"last_name":  anonymizedLastName,
+		"email":      tombstone,
+		"phone":      "",
+		"social":     nil,
+		"avatar_url": "",
+		"updated_at": utils.NowUTC(),
+	}
+
+	// a pending email change holds the new address and the token confirming it
+	if _, err = utils.GenericUpdateOneMongoDBDoc(context.TODO(), UserCollectionName, pUserID, bson.M{"$set": userUpdate, "$unset": bson.M{"email_change": ""}}); err != nil {
+		return err
+	}
+
+	memberUpdate := bson.M{
+		"email":        tombstone,
+		"first_name":   anonymizedName,
+		"last_name":    anonymizedLastName,
+		"user_name":    tombstone,
+		"display_name": fmt.Sprintf("%s %s", anonymizedName, anonymizedLastName),
+		"image_url":    "",
+		"bio":          "",
+		"pronouns":     "",
+		"phone":        "",
+		"socials":      nil,
+		"status":       Status{},
+	}
+
+	if _, err = utils.UpdateManyMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{"email": email}, memberUpdate); err != nil {
+		return err
+	}
+
+	_, err = utils.UpdateManyMongoDBDocs(context.TODO(), OrganizationCollectionName, bson.M{"creator_email": email}, bson.M{"creator_email": tombstone})
+
+	return err
+}
+
+// PurgeDeletedUsers anonymizes users deleted longer than the retention window ago, after which
+// they can no longer be restored. It returns how many users were purged.
+func PurgeDeletedUsers(now time.Time, retention time.Duration) (int, error) {
+	filter := bson.M{
+		"deactivated":    true,
+		"deactivated_at": bson.M{"$lte": now.Add(-retention)},
+		"purged_at":      bson.M{"$exists": false},
+	}
+
+	cursor, err := utils.GetCollection(UserCollectionName).Find(context.TODO(), filter)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted []user.User
+	if err = cursor.All(context.TODO(), &deleted); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+
+	for _, u := range deleted {
+		pUserID, _ := primitive.ObjectIDFromHex(u.ID)
+
+		// mark the user first, so a restore racing the purge cannot bring back a scrubbed user
+		res, err := utils.GetCollection(UserCollectionName).UpdateOne(context.TODO(),
+			bson.M{"_id": pUserID, "deactivated": true, "purged_at": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"purged_at": now}})
+		if err != nil {
+			logger.Error("could not purge deleted user %s: %v", u.ID, err)
+			continue
+		}
+
+		if res.ModifiedCount == 0 {
+			continue
+		}
+
+		if err = AnonymizeUser(u.ID); err != nil {
+			logger.Error("could not anonymize deleted user %s: %v", u.ID, err)
+			continue
+		}
+
+		purged++
+
+		entry := &audit.Log{
+			Actor:      "system",
+			Action:     audit.UserAnonymized,
+			TargetType: "user",
+			TargetID:   u.ID,
+			Data:       map[string]interface{}{"reason": "retention expired"},
+		}
+
+		if err := audit.Record(entry); err != nil {
+			logger.Error("could not record purge of user %s: %v", u.ID, err)
+		}
+	}
+
+	return purged, nil
+}
+
+// RunUserPurgeSweeper purges deleted users past the retention window every hour until the
+// context is cancelled.
+func RunUserPurgeSweeper(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(userPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			purged, err := PurgeDeletedUsers(now, retention)
+			if err != nil {
+				logger.Error("could not purge deleted users: %v", err)
+				continue
+			}
+
+			if purged > 0 {
+				logger.Info("%d deleted users purged", purged)
+			}
+		}
+	}
+}
+
+// Erase a user's personal data across all organizations. The erasure is recorded in the audit log.
+func (oh *OrganizationHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value("user").(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	userID := mux.Vars(r)["user_id"]
+
+	if err := AnonymizeUser(userID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	entry := &audit.Log{
+		Actor:      loggedInUser.Email,
+		Action:     audit.UserAnonymized,
+		TargetType: "user",
+		TargetID:   userID,
+
+		ImpersonatedBy: loggedInUser.ImpersonatedBy,
+	}
+
+	if err := audit.Record(entry); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("user anonymized successfully", nil, w)
+}
@@ -0,0 +1,147 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+func TestAnonymizeUser(t *testing.T) {
+	email := "forgetme@gmail.com"
+
+	detail, _ := utils.StructToMap(user.User{FirstName: "Ada", LastName: "Obi", Email: email, Phone: "08012345678", AvatarURL: "https://example.com/ada.png"})
+	detail["email_change"] = bson.M{"email": "newforgetme@gmail.com", "token": utils.GenUUID()}
+
+	res, err := utils.CreateMongoDBDoc(context.TODO(), UserCollectionName, detail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := res.InsertedID.(primitive.ObjectID).Hex()
+
+	memID, err := setUpMember(defaultOrgID, email, MemberRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/users/{user_id}/anonymize", orgs.EraseUser).Methods("POST")
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/users/%s/anonymize", userID), nil)
+
+	response := getHTTPResponse(t, r, withUser(req, defaultUser))
+	assertStatusCode(t, response.Code, http.StatusOK)
+
+	t.Run("test user document is scrubbed", func(t *testing.T) {
+		userDoc, _ := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"_id": res.InsertedID})
+		if userDoc == nil {
+			t.Fatal("user document was removed")
+		}
+
+		if userDoc["email"] == email || userDoc["first_name"] == "Ada" || userDoc["phone"] != "" || userDoc["avatar_url"] != "" {
+			t.Errorf("user still holds personal data: %v", userDoc)
+		}
+
+		if _, ok := userDoc["email_change"]; ok {
+			t.Errorf("user still holds a pending email change: %v", userDoc["email_change"])
+		}
+	})
+
+	t.Run("test membership survives without personal data", func(t *testing.T) {
+		pMemID, _ := primitive.ObjectIDFromHex(memID)
+
+		memberDoc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"_id": pMemID})
+		if memberDoc == nil {
+			t.Fatal("membership was removed")
+		}
+
+		if memberDoc["org_id"] != defaultOrgID {
+			t.Errorf("membership lost its organization reference: %v", memberDoc["org_id"])
+		}
+
+		if memberDoc["email"] != anonymizedEmail(userID) || memberDoc["user_name"] == "forgetme" {
+			t.Errorf("member still holds personal data: %v", memberDoc)
+		}
+
+		if remaining := utils.CountCollection(context.TODO(), MemberCollectionName, bson.M{"email": email}); remaining != 0 {
+			t.Errorf("expected no members with %s, found %d", email, remaining)
+		}
+	})
+
+	t.Run("test erasure is audit logged", func(t *testing.T) {
+		entry, _ := utils.GetMongoDBDoc(context.TODO(), audit.AuditLogCollectionName, bson.M{"action": audit.UserAnonymized, "target_id": userID})
+		if entry == nil {
+			t.Fatal("erasure was not recorded in the audit log")
+		}
+
+		if entry["actor"] != defaultUser {
+			t.Errorf("expected actor %s, got %v", defaultUser, entry["actor"])
+		}
+	})
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	now := utils.NowUTC()
+	retention := 30 * 24 * time.Hour
+
+	deleteUser := func(email string, deletedAt time.Time) string {
+		detail, _ := utils.StructToMap(user.User{FirstName: "Ada", Email: email})
+
+		res, err := utils.CreateMongoDBDoc(context.TODO(), UserCollectionName, detail)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		userID := res.InsertedID.(primitive.ObjectID).Hex()
+
+		if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), UserCollectionName, userID, bson.M{"deactivated": true, "deactivated_at": deletedAt}); err != nil {
+			t.Fatal(err)
+		}
+
+		return userID
+	}
+
+	expiredID := deleteUser("purgeme@gmail.com", now.Add(-retention-time.Hour))
+	recentID := deleteUser("keepme@gmail.com", now.Add(-time.Hour))
+
+	purged, err := PurgeDeletedUsers(now, retention)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if purged != 1 {
+		t.Errorf("expected 1 user purged, got %d", purged)
+	}
+
+	t.Run("test users past the retention window are anonymized", func(t *testing.T) {
+		pUserID, _ := primitive.ObjectIDFromHex(expiredID)
+
+		userDoc, _ := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"_id": pUserID})
+		if userDoc["email"] != anonymizedEmail(expiredID) || userDoc["purged_at"] == nil {
+			t.Errorf("expected the user to be purged, got %v", userDoc)
+		}
+	})
+
+	t.Run("test users within the retention window are kept", func(t *testing.T) {
+		pUserID, _ := primitive.ObjectIDFromHex(recentID)
+
+		userDoc, _ := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"_id": pUserID})
+		if userDoc["email"] != "keepme@gmail.com" || userDoc["purged_at"] != nil {
+			t.Errorf("expected the user to be kept, got %v", userDoc)
+		}
+	})
+
+	t.Run("test purged users are not purged again", func(t *testing.T) {
+		if purged, _ := PurgeDeletedUsers(now, retention); purged != 0 {
+			t.Errorf("expected no users purged, got %d", purged)
+		}
+	})
+}
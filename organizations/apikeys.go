@@ -0,0 +1,141 @@
+package organizations
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	apiKeyLength      = 32
+	maxAPIKeyNameSize = 100
+)
+
+var errAPIKeyName = errors.New("api key name is required and must be at most 100 characters")
+
+func newAPIKey() (string, error) {
+	b := make([]byte, apiKeyLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// CreateAPIKey issues an API key integrations can call the organization's routes with, see
+// auth.IsAPIKeyAuthenticated. The key is only ever shown in this response.
+func (oh *OrganizationHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameSize {
+		utils.GetError(errAPIKeyName, http.StatusBadRequest, w)
+		return
+	}
+
+	if org, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": pOrgID}); org == nil {
+		utils.GetError(organizationNotFound(orgID), http.StatusNotFound, w)
+		return
+	}
+
+	key, err := newAPIKey()
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	apiKey := auth.APIKey{OrgID: orgID, Name: name, KeyHash: auth.HashAPIKey(key), CreatedAt: utils.NowUTC()}
+
+	save, err := utils.GetCollection(auth.APIKeyCollectionName).InsertOne(r.Context(), apiKey)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	apiKey.ID = save.InsertedID.(primitive.ObjectID).Hex()
+
+	utils.GetCreated("api key created, it is not shown again", fmt.Sprintf("/organizations/%s/api-keys/%s", orgID, apiKey.ID), utils.M{"api_key": apiKey, "key": key}, w)
+}
+
+// GetAPIKeys lists an organization's API keys, newest first, including revoked ones.
+func (oh *OrganizationHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	docs, err := utils.GetMongoDBDocs(r.Context(), auth.APIKeyCollectionName, bson.M{"org_id": orgID}, options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	keys := make([]auth.APIKey, 0, len(docs))
+
+	for _, doc := range docs {
+		var key auth.APIKey
+		if err := utils.BsonToStruct(doc, &key); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		keys = append(keys, key)
+	}
+
+	utils.GetSuccess("api keys retrieved successfully", keys, w)
+}
+
+// RevokeAPIKey stops an API key of the organization from being accepted.
+func (oh *OrganizationHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, keyID := mux.Vars(r)["id"], mux.Vars(r)["key_id"]
+
+	pKeyID, err := primitive.ObjectIDFromHex(keyID)
+	if err != nil {
+		utils.GetError(errors.New("invalid api key id"), http.StatusBadRequest, w)
+		return
+	}
+
+	update, err := utils.GetCollection(auth.APIKeyCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": pKeyID, "org_id": orgID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": utils.NowUTC()}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if update.MatchedCount == 0 {
+		utils.GetError(errors.New("api key not found or already revoked"), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("api key revoked", utils.M{"id": keyID}, w)
+}
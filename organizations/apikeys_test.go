@@ -0,0 +1,95 @@
+package organizations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"zuri.chat/zccore/auth"
+)
+
+func TestAPIKeys(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	au := auth.NewAuthHandler(configs, nil)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}", au.IsAuthenticatedOrAPIKey(orgs.GetOrganization)).Methods("GET")
+	r.HandleFunc("/organizations/{id}", orgs.DeleteOrganization).Methods("DELETE")
+	r.HandleFunc("/organizations/{id}/api-keys", orgs.CreateAPIKey).Methods("POST")
+	r.HandleFunc("/organizations/{id}/api-keys", orgs.GetAPIKeys).Methods("GET")
+	r.HandleFunc("/organizations/{id}/api-keys/{key_id}", orgs.RevokeAPIKey).Methods("DELETE")
+
+	// create issues an API key and returns it with its id.
+	create := func(t *testing.T) (key, keyID string) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/api-keys", orgID), bytes.NewBufferString(`{"name": "integration"}`))
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		return data["key"].(string), data["api_key"].(map[string]interface{})["id"].(string)
+	}
+
+	// get fetches the organization with an API key and checks the status.
+	get := func(t *testing.T, key string, code int) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s", orgID), nil)
+		req.Header.Set(auth.APIKeyHeader, key)
+
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, code)
+	}
+
+	revoked, revokedID := create(t)
+	key, _ := create(t)
+
+	t.Run("test issued keys open the organization", func(t *testing.T) {
+		get(t, key, http.StatusOK)
+	})
+
+	t.Run("test revoked keys are refused", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s/api-keys/%s", orgID, revokedID), nil)
+		assertStatusCode(t, getHTTPResponse(t, r, withUser(req, defaultUser)).Code, http.StatusOK)
+
+		get(t, revoked, http.StatusUnauthorized)
+	})
+
+	t.Run("test keys are listed without their hash", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/api-keys", orgID), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		keys := parseResponse(response)["data"].([]interface{})
+		if len(keys) != 2 {
+			t.Fatalf("expected 2 keys, got %d", len(keys))
+		}
+
+		for _, k := range keys {
+			if _, ok := k.(map[string]interface{})["key_hash"]; ok {
+				t.Error("expected key hashes not to be listed")
+			}
+		}
+	})
+
+	t.Run("test keys of deleted organizations are refused", func(t *testing.T) {
+		del := func(token string) map[string]interface{} {
+			req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s?confirmation_token=%s", orgID, token), nil)
+
+			response := getHTTPResponse(t, r, withUser(req, defaultUser))
+			assertStatusCode(t, response.Code, http.StatusOK)
+
+			data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+			return data
+		}
+
+		del(del("")["confirmation_token"].(string))
+
+		get(t, key, http.StatusUnauthorized)
+	})
+}
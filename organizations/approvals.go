@@ -0,0 +1,248 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// Destructive actions that need a second owner's approval in organizations with several owners.
+const (
+	ApprovalDeleteOrganization = "delete_organization"
+	ApprovalTransferOwnership  = "transfer_ownership"
+)
+
+const (
+	ApprovalPending  = "pending"
+	ApprovalApproved = "approved"
+	ApprovalExecuted = "executed"
+	ApprovalExpired  = "expired"
+	ApprovalFailed   = "failed"
+)
+
+var (
+	errApprovalNotOwner     = errors.New("only owners of this organization can ask for or approve this")
+	errApprovalSameOwner    = errors.New("the request must be approved by another owner")
+	errApprovalExpired      = errors.New("the request has expired, ask for it again")
+	errApprovalNotFound     = errors.New("approval request not found")
+	errApprovalAlreadyTaken = errors.New("the request has already been approved")
+)
+
+// ApprovalRequest is a destructive action an owner of an organization asked for, waiting for
+// another owner to approve it. It is carried out once approved, and lapses when not approved
+// before it expires.
+type ApprovalRequest struct {
+	ID             string            `json:"_id,omitempty" bson:"_id,omitempty"`
+	OrgID          string            `json:"org_id" bson:"org_id"`
+	Action         string            `json:"action" bson:"action"`
+	Params         map[string]string `json:"params,omitempty" bson:"params,omitempty"`
+	RequestedBy    string            `json:"requested_by" bson:"requested_by"`
+	RequesterEmail string            `json:"requester_email" bson:"requester_email"`
+	Status         string            `json:"status" bson:"status"`
+	CreatedAt      time.Time         `json:"created_at" bson:"created_at"`
+	ExpiresAt      time.Time         `json:"expires_at" bson:"expires_at"`
+	ApprovedBy     string            `json:"approved_by,omitempty" bson:"approved_by,omitempty"`
+	ApprovedAt     *time.Time        `json:"approved_at,omitempty" bson:"approved_at,omitempty"`
+	Error          string            `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// needsOwnerApproval reports whether destructive actions on an organization need a second
+// owner's approval, which is when it has more than one owner. Its owners must be populated.
+func needsOwnerApproval(org *Organization) bool {
+	return len(org.Owners) > 1
+}
+
+// ownerMemberID returns the member id of the logged in user when they own the organization.
+func ownerMemberID(r *http.Request, org *Organization) (string, string, error) {
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		return "", "", errApprovalNotOwner
+	}
+
+	email := strings.ToLower(loggedInUser.Email)
+
+	memberDoc, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, activeMemberFilter(org.ID, email))
+	if memberDoc == nil {
+		return "", "", errApprovalNotOwner
+	}
+
+	memberID, _ := memberDoc["_id"].(primitive.ObjectID)
+	if !isOwner(org, memberID.Hex()) {
+		return "", "", errApprovalNotOwner
+	}
+
+	return memberID.Hex(), email, nil
+}
+
+// requestOwnerApproval records a destructive action for another owner of the organization to
+// approve, instead of carrying it out.
+func (oh *OrganizationHandler) requestOwnerApproval(w http.ResponseWriter, r *http.Request, org *Organization, action string, params map[string]string) {
+	memberID, email, err := ownerMemberID(r, org)
+	if err != nil {
+		utils.GetError(err, http.StatusForbidden, w)
+		return
+	}
+
+	now := utils.NowUTC()
+	request := ApprovalRequest{
+		OrgID:          org.ID,
+		Action:         action,
+		Params:         params,
+		RequestedBy:    memberID,
+		RequesterEmail: email,
+		Status:         ApprovalPending,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(oh.configs.OwnerApprovalWindow),
+	}
+
+	res, err := utils.GetCollection(ApprovalRequestCollectionName).InsertOne(r.Context(), request)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	request.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+	utils.GetSuccess("another owner must approve this before it is carried out", utils.M{
+		"pending_approval": true,
+		"request":          request,
+	}, w)
+}
+
+// executeApproval carries out an approved request.
+func executeApproval(ctx context.Context, request *ApprovalRequest) error {
+	switch request.Action {
+	case ApprovalDeleteOrganization:
+		pOrgID, _ := primitive.ObjectIDFromHex(request.OrgID)
+
+		deleted, err := deleteOrganization(ctx, pOrgID, bson.M{"_id": pOrgID})
+		if err == nil && !deleted {
+			err = errors.New("organization no longer exists")
+		}
+
+		return err
+	case ApprovalTransferOwnership:
+		return transferOwnership(request.OrgID, request.Params["member_id"], request.RequesterEmail)
+	default:
+		return fmt.Errorf("unknown action %s", request.Action)
+	}
+}
+
+// List the approval requests of an organization still waiting for an owner, newest first.
+func (oh *OrganizationHandler) GetApprovalRequests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+	filter := bson.M{"org_id": orgID, "status": ApprovalPending, "expires_at": bson.M{"$gt": utils.NowUTC()}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	requests, err := utils.GetMongoDBDocs(r.Context(), ApprovalRequestCollectionName, filter, opts)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("approval requests retrieved successfully", requests, w)
+}
+
+// Approve a destructive action another owner of the organization asked for, which carries it
+// out. Requests that were not approved in time expire and must be asked for again.
+func (oh *OrganizationHandler) ApproveRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID := vars["id"]
+
+	requestID, err := primitive.ObjectIDFromHex(vars["request_id"])
+	if err != nil {
+		utils.GetError(errApprovalNotFound, http.StatusNotFound, w)
+		return
+	}
+
+	org, err := fetchOrganizationWithOwners(r.Context(), orgID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var request ApprovalRequest
+
+	coll := utils.GetCollection(ApprovalRequestCollectionName)
+	if err = coll.FindOne(r.Context(), bson.M{"_id": requestID, "org_id": orgID}).Decode(&request); err != nil {
+		utils.GetError(errApprovalNotFound, http.StatusNotFound, w)
+		return
+	}
+
+	if request.Status != ApprovalPending {
+		utils.GetError(fmt.Errorf("the request is already %s", request.Status), http.StatusConflict, w)
+		return
+	}
+
+	now := utils.NowUTC()
+
+	if !now.Before(request.ExpiresAt) {
+		if _, err := coll.UpdateOne(r.Context(), bson.M{"_id": requestID, "status": ApprovalPending},
+			bson.M{"$set": bson.M{"status": ApprovalExpired}}); err != nil {
+			logger.Error("could not expire approval request %s: %v", request.ID, err)
+		}
+
+		utils.GetError(errApprovalExpired, http.StatusGone, w)
+
+		return
+	}
+
+	approverID, _, err := ownerMemberID(r, org)
+	if err != nil {
+		utils.GetError(err, http.StatusForbidden, w)
+		return
+	}
+
+	if approverID == request.RequestedBy {
+		utils.GetError(errApprovalSameOwner, http.StatusForbidden, w)
+		return
+	}
+
+	// claiming the request first keeps two owners approving at once from carrying it out twice
+	claim, err := coll.UpdateOne(r.Context(),
+		bson.M{"_id": requestID, "status": ApprovalPending, "expires_at": bson.M{"$gt": now}},
+		bson.M{"$set": bson.M{"status": ApprovalApproved, "approved_by": approverID, "approved_at": now}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if claim.ModifiedCount == 0 {
+		utils.GetError(errApprovalAlreadyTaken, http.StatusConflict, w)
+		return
+	}
+
+	request.Status, request.ApprovedBy, request.ApprovedAt = ApprovalExecuted, approverID, &now
+
+	set := bson.M{"status": ApprovalExecuted}
+	if err = executeApproval(r.Context(), &request); err != nil {
+		request.Status, request.Error = ApprovalFailed, err.Error()
+		set = bson.M{"status": ApprovalFailed, "error": err.Error()}
+	}
+
+	if _, uerr := coll.UpdateOne(r.Context(), bson.M{"_id": requestID}, bson.M{"$set": set}); uerr != nil {
+		logger.Error("could not record outcome of approval request %s: %v", request.ID, uerr)
+	}
+
+	if err != nil {
+		utils.GetError(fmt.Errorf("the request was approved but could not be carried out: %v", err), http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("request approved and carried out", request, w)
+}
@@ -0,0 +1,151 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestOwnerApproval(t *testing.T) {
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}", orgs.DeleteOrganization).Methods("DELETE")
+	r.HandleFunc("/organizations/{id}/approval-requests", orgs.GetApprovalRequests).Methods("GET")
+	r.HandleFunc("/organizations/{id}/approval-requests/{request_id}/approve", orgs.ApproveRequest).Methods("POST")
+
+	// setUpOwners creates an organization owned by the given number of members, and their emails.
+	setUpOwners := func(t *testing.T, count int) (string, []string) {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		emails := make([]string, count)
+
+		for i := range emails {
+			emails[i] = fmt.Sprintf("owner.%d.%s@gmail.com", i, utils.GenUUID())
+			if _, err := setUpMember(orgID, emails[i], OwnerRole); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return orgID, emails
+	}
+
+	// deleteAs confirms the deletion of an organization as the given owner.
+	deleteAs := func(t *testing.T, orgID, email string) map[string]interface{} {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s", orgID), nil)
+		response := getHTTPResponse(t, r, withUser(req, email))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		token := parseResponse(response)["data"].(map[string]interface{})["confirmation_token"].(string)
+
+		req, _ = http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s?confirmation_token=%s", orgID, token), nil)
+		response = getHTTPResponse(t, r, withUser(req, email))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	approveAs := func(t *testing.T, orgID, requestID, email string, code int) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/approval-requests/%s/approve", orgID, requestID), nil)
+		assertStatusCode(t, getHTTPResponse(t, r, withUser(req, email)).Code, code)
+	}
+
+	exists := func(orgID string) bool {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), OrganizationCollectionName, bson.M{"_id": pOrgID})
+
+		return doc != nil
+	}
+
+	requestStatus := func(requestID string) string {
+		pRequestID, _ := primitive.ObjectIDFromHex(requestID)
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), ApprovalRequestCollectionName, bson.M{"_id": pRequestID})
+
+		status, _ := doc["status"].(string)
+
+		return status
+	}
+
+	t.Run("test deleting waits for a second owner's approval", func(t *testing.T) {
+		orgID, owners := setUpOwners(t, 2)
+
+		data := deleteAs(t, orgID, owners[0])
+		if data["pending_approval"] != true {
+			t.Fatalf("expected the deletion to wait for approval, got %v", data)
+		}
+
+		requestID := data["request"].(map[string]interface{})["_id"].(string)
+
+		if !exists(orgID) {
+			t.Fatal("expected the organization to be kept until approved")
+		}
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/approval-requests", orgID), nil)
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if pending := parseResponse(response)["data"].([]interface{}); len(pending) != 1 {
+			t.Errorf("expected 1 pending request, got %d", len(pending))
+		}
+
+		approveAs(t, orgID, requestID, owners[0], http.StatusForbidden)
+		approveAs(t, orgID, requestID, defaultUser, http.StatusForbidden)
+
+		approveAs(t, orgID, requestID, owners[1], http.StatusOK)
+
+		if exists(orgID) {
+			t.Error("expected the organization to be deleted once approved")
+		}
+
+		if status := requestStatus(requestID); status != ApprovalExecuted {
+			t.Errorf("expected the request to be %s, got %s", ApprovalExecuted, status)
+		}
+	})
+
+	t.Run("test requests not approved in time expire", func(t *testing.T) {
+		orgID, owners := setUpOwners(t, 2)
+
+		requestID := deleteAs(t, orgID, owners[0])["request"].(map[string]interface{})["_id"].(string)
+
+		pRequestID, _ := primitive.ObjectIDFromHex(requestID)
+		if _, err := utils.GetCollection(ApprovalRequestCollectionName).UpdateOne(context.TODO(),
+			bson.M{"_id": pRequestID}, bson.M{"$set": bson.M{"expires_at": utils.NowUTC().Add(-time.Minute)}}); err != nil {
+			t.Fatal(err)
+		}
+
+		approveAs(t, orgID, requestID, owners[1], http.StatusGone)
+
+		if !exists(orgID) {
+			t.Error("expected the organization to be kept when the request expired")
+		}
+
+		if status := requestStatus(requestID); status != ApprovalExpired {
+			t.Errorf("expected the request to be %s, got %s", ApprovalExpired, status)
+		}
+	})
+
+	t.Run("test organizations with one owner skip approval", func(t *testing.T) {
+		orgID, owners := setUpOwners(t, 1)
+
+		if data := deleteAs(t, orgID, owners[0]); data != nil {
+			t.Errorf("expected the organization to be deleted straight away, got %v", data)
+		}
+
+		if exists(orgID) {
+			t.Error("expected the organization to be deleted")
+		}
+
+		if utils.CountCollection(context.TODO(), ApprovalRequestCollectionName, bson.M{"org_id": orgID}) != 0 {
+			t.Error("expected no approval request")
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const maxBillingAddressLength = 500
+
+// BillingEmail is where invoices and payment notices for the organization are sent: the
+// billing contact when one is set, otherwise the creator of the organization.
+func (o *Organization) BillingEmail() string {
+	if o.BillingContactEmail != "" {
+		return o.BillingContactEmail
+	}
+
+	return o.CreatorEmail
+}
+
+// emails the billing contact of an organization that a payment could not be made.
+func (oh *OrganizationHandler) notifyPaymentFailed(org *Organization, description string, reason error) {
+	subject := fmt.Sprintf("%s: payment failed", org.Name)
+	body := fmt.Sprintf("We could not complete a payment for %s.\n\n%s\n\nReason: %v", org.Name, description, reason)
+
+	msger := oh.mailService.NewCustomMail([]string{org.BillingEmail()}, subject, body)
+
+	if err := oh.mailService.SendMail(msger); err != nil {
+		logger.Error("Error occurred while sending mail: %s", err.Error())
+	}
+}
+
+// decryptBillingAddress replaces the billing address of org, which is stored encrypted when
+// field encryption keys are configured, with its plaintext.
+func (oh *OrganizationHandler) decryptBillingAddress(org *Organization) error {
+	address, err := utils.DecryptField(org.BillingAddress, oh.configs.FieldEncryptionKeys)
+	if err != nil {
+		return fmt.Errorf("could not read billing address: %w", err)
+	}
+
+	org.BillingAddress = address
+
+	return nil
+}
+
+// Get the billing contact email and address of an organization.
+func (oh *OrganizationHandler) GetInvoiceContact(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	if err = oh.decryptBillingAddress(org); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("billing contact retrieved successfully", utils.M{
+		"billing_contact_email": org.BillingContactEmail,
+		"billing_address":       org.BillingAddress,
+		"invoice_email":         org.BillingEmail(),
+	}, w)
+}
+
+// Set the billing contact email and address of an organization. An empty email sends
+// invoices back to the creator of the organization. Only owners and admins can do this.
+func (oh *OrganizationHandler) UpdateInvoiceContact(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		BillingContactEmail *string `json:"billing_contact_email"`
+		BillingAddress      *string `json:"billing_address"`
+	}
+
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	update := bson.M{}
+
+	if body.BillingContactEmail != nil {
+		email := strings.ToLower(strings.TrimSpace(*body.BillingContactEmail))
+		if email != "" && !utils.IsValidEmail(email) {
+			utils.GetError(errors.New("invalid billing contact email"), http.StatusBadRequest, w)
+			return
+		}
+
+		update["billing_contact_email"] = email
+	}
+
+	if body.BillingAddress != nil {
+		address := strings.TrimSpace(*body.BillingAddress)
+		if len([]rune(address)) > maxBillingAddressLength {
+			utils.GetError(fmt.Errorf("billing address cannot be longer than %d characters", maxBillingAddressLength), http.StatusBadRequest, w)
+			return
+		}
+
+		if update["billing_address"], err = utils.EncryptField(address, oh.configs.FieldEncryptionKeys); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	if len(update) == 0 {
+		utils.GetError(errors.New("billing_contact_email or billing_address is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	res, err := utils.UpdateOneMongoDBDocWithWriteConcern(r.Context(), OrganizationCollectionName, utils.WriteConcern(utils.WriteCritical), orgID, update)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if err = oh.decryptBillingAddress(org); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("billing contact updated successfully", utils.M{
+		"billing_contact_email": org.BillingContactEmail,
+		"billing_address":       org.BillingAddress,
+		"invoice_email":         org.BillingEmail(),
+	}, w)
+}
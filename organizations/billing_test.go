@@ -0,0 +1,134 @@
+package organizations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"zuri.chat/zccore/auth"
+)
+
+func TestBillingEmail(t *testing.T) {
+	org := &Organization{CreatorEmail: "owner@gmail.com"}
+
+	if got := org.BillingEmail(); got != org.CreatorEmail {
+		t.Errorf("expected invoices to go to the creator %s, got %s", org.CreatorEmail, got)
+	}
+
+	org.BillingContactEmail = "finance@gmail.com"
+
+	if got := org.BillingEmail(); got != org.BillingContactEmail {
+		t.Errorf("expected invoices to go to the billing contact %s, got %s", org.BillingContactEmail, got)
+	}
+}
+
+func TestInvoiceContact(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, member, finance := "billingadmin@gmail.com", "billingmember@gmail.com", "finance@gmail.com"
+
+	if _, err = setUpMember(orgID, admin, AdminRole); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = setUpMember(orgID, member, MemberRole); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, email := range []string{admin, member} {
+		if err = setUpUser(email, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mailer := newMockMailService()
+	handler := NewOrganizationHandler(configs, mailer)
+	au := auth.NewAuthHandler(configs, nil)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/billing/invoice-contact", au.IsAuthorized(handler.GetInvoiceContact, "admin")).Methods("GET")
+	r.HandleFunc("/organizations/{id}/billing/invoice-contact", au.IsAuthorized(handler.UpdateInvoiceContact, "admin")).Methods("PATCH")
+	r.HandleFunc("/organizations/{id}/upgrade-to-pro", handler.UpgradeToPro).Methods("POST")
+	r.HandleFunc("/organizations/{id}", handler.GetOrganization).Methods("GET")
+
+	contactURL := fmt.Sprintf("/organizations/%s/billing/invoice-contact", orgID)
+
+	update := func(email, body string) *http.Request {
+		req, _ := http.NewRequest("PATCH", contactURL, bytes.NewBufferString(body))
+		return withUser(req, email)
+	}
+
+	invoiceEmail := func() interface{} {
+		req, _ := http.NewRequest("GET", contactURL, nil)
+		response := getHTTPResponse(t, r, withUser(req, admin))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		return parseResponse(response)["data"].(map[string]interface{})["invoice_email"]
+	}
+
+	t.Run("test invoices go to the creator by default", func(t *testing.T) {
+		if got := invoiceEmail(); got != defaultUser {
+			t.Errorf("expected invoice email %s, got %v", defaultUser, got)
+		}
+	})
+
+	t.Run("test invalid billing contact email is rejected", func(t *testing.T) {
+		response := getHTTPResponse(t, r, update(admin, `{"billing_contact_email": "not-an-email"}`))
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("test member cannot set billing contact", func(t *testing.T) {
+		response := getHTTPResponse(t, r, update(member, fmt.Sprintf(`{"billing_contact_email": %q}`, member)))
+		assertStatusCode(t, response.Code, http.StatusUnauthorized)
+	})
+
+	t.Run("test payment failure notice goes to the billing contact", func(t *testing.T) {
+		body := fmt.Sprintf(`{"billing_contact_email": %q, "billing_address": "1 Finance Way, Lagos"}`, finance)
+
+		response := getHTTPResponse(t, r, update(admin, body))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if got := invoiceEmail(); got != finance {
+			t.Errorf("expected invoice email %s, got %v", finance, got)
+		}
+
+		// the organization has no tokens to pay for its members
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/upgrade-to-pro", orgID), nil)
+		response = getHTTPResponse(t, r, withUser(req, admin))
+		assertStatusCode(t, response.Code, http.StatusExpectationFailed)
+
+		if !mailer.sentTo(finance) {
+			t.Errorf("payment failure notice not sent to %s", finance)
+		}
+
+		if mailer.sentTo(defaultUser) {
+			t.Errorf("payment failure notice sent to the creator %s", defaultUser)
+		}
+	})
+
+	t.Run("test billing contact is not served with the organization", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/organizations/"+orgID, nil)
+		response := getHTTPResponse(t, r, withUser(req, member))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		org, _ := parseResponse(response)["data"].(map[string]interface{})
+		for _, field := range []string{"billing_contact_email", "billing_address"} {
+			if _, ok := org[field]; ok {
+				t.Errorf("expected %s to be left out, got %v", field, org[field])
+			}
+		}
+	})
+
+	t.Run("test clearing billing contact falls back to the creator", func(t *testing.T) {
+		response := getHTTPResponse(t, r, update(admin, `{"billing_contact_email": ""}`))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if got := invoiceEmail(); got != defaultUser {
+			t.Errorf("expected invoice email %s, got %v", defaultUser, got)
+		}
+	})
+}
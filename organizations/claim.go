@@ -0,0 +1,187 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+// claimTokenLifetime is how long a pending organization can be claimed.
+const claimTokenLifetime = 7 * 24 * time.Hour
+
+var errClaimExpired = errors.New("claim token has expired, ask for a new one")
+
+// PendingOrganizationRequest describes an organization created for a customer to claim.
+type PendingOrganizationRequest struct {
+	Name   string `json:"name"`
+	Locale string `json:"locale"`
+}
+
+// ClaimRequest redeems the claim token of a pending organization.
+type ClaimRequest struct {
+	Token string `json:"token"`
+}
+
+// Create an organization with no owner for a customer to claim. The claim token is only ever
+// returned here. The organization is left out of listings until it is claimed, see
+// ClaimPendingOrganization. Only Zuri admins can do this.
+func (oh *OrganizationHandler) CreatePendingOrganization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body PendingOrganizationRequest
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+	if name == "" {
+		name = DefaultOrganizationName
+	}
+
+	locale, err := oh.organizationLocale(body.Locale)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	defaultFlags, err := oh.planFeatureFlags(FreeVersion)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	claim := &OrganizationClaim{
+		Token:     utils.GenUUID(),
+		CreatedBy: loggedInUser.Email,
+		ExpiresAt: utils.NowUTC().Add(claimTokenLifetime),
+	}
+
+	org := Organization{
+		Name:                 name,
+		WorkspaceURL:         utils.GenWorkspaceURL(name),
+		CreatedAt:            utils.NowUTC(),
+		Plugins:              map[string]interface{}{},
+		Tags:                 []string{},
+		FeatureFlags:         reconcileFeatureFlags(defaultFlags, nil),
+		FeatureFlagOverrides: map[string]bool{},
+		SchemaVersion:        CurrentSchemaVersion,
+		Tokens:               100,
+		Version:              FreeVersion,
+		Locale:               locale,
+		Pending:              true,
+		Claim:                claim,
+	}
+
+	save, err := utils.GetCollection(OrganizationCollectionName).InsertOne(r.Context(), org)
+	if err != nil {
+		utils.GetWriteError(err, w)
+		return
+	}
+
+	utils.GetSuccess("pending organization created", utils.M{
+		"organization_id": save.InsertedID,
+		"claim_token":     claim.Token,
+		"expires_at":      claim.ExpiresAt,
+	}, w)
+}
+
+// Redeem the claim token of a pending organization. The logged in user, who must have verified
+// their email, becomes its creator and first owner. Expired tokens get a 410.
+func (oh *OrganizationHandler) ClaimPendingOrganization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body ClaimRequest
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if body.Token == "" {
+		utils.GetError(errors.New("token is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	claimant, err := auth.FetchUserByEmail(bson.M{"email": strings.ToLower(loggedInUser.Email)})
+	if err != nil {
+		utils.GetError(errors.New("user with this email does not exist"), http.StatusBadRequest, w)
+		return
+	}
+
+	if !claimant.IsVerified {
+		utils.GetError(errors.New("verify your email before claiming an organization"), http.StatusForbidden, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"pending": true, "claim.token": body.Token})
+	if err != nil {
+		utils.GetError(errors.New("claim token not found"), http.StatusNotFound, w)
+		return
+	}
+
+	now := utils.NowUTC()
+	if !now.Before(org.Claim.ExpiresAt) {
+		utils.GetError(errClaimExpired, http.StatusGone, w)
+		return
+	}
+
+	pOrgID, _ := primitive.ObjectIDFromHex(org.ID)
+
+	// only one claim can redeem the token, even when two race
+	res, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": pOrgID, "pending": true, "claim.token": body.Token, "claim.expires_at": bson.M{"$gt": now}},
+		bson.M{
+			"$set":   bson.M{"creator_email": claimant.Email, "creator_id": claimant.ID},
+			"$unset": bson.M{"pending": "", "claim": ""},
+		})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(errors.New("organization has already been claimed"), http.StatusConflict, w)
+		return
+	}
+
+	owner := NewMember(claimant.Email, strings.Split(claimant.Email, "@")[0], org.ID, OwnerRole)
+
+	ownerRes, err := utils.GetCollection(MemberCollectionName).InsertOne(r.Context(), owner)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	owners := []string{ownerRes.InsertedID.(primitive.ObjectID).Hex()}
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, org.ID, bson.M{"owners": owners}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if _, err = utils.GetCollection(UserCollectionName).UpdateOne(r.Context(),
+		bson.M{"email": claimant.Email},
+		bson.M{"$addToSet": bson.M{"workspaces": org.ID}}); err != nil {
+		utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("organization claimed successfully", utils.M{"organization_id": org.ID}, w)
+}
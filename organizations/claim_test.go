@@ -0,0 +1,115 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+func TestClaimPendingOrganization(t *testing.T) {
+	claimer, unverified := "claimer@gmail.com", "unverifiedclaimer@gmail.com"
+
+	if err := setUpUser(claimer, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	detail, _ := utils.StructToMap(user.User{Email: unverified})
+	if _, err := utils.CreateMongoDBDoc(context.TODO(), UserCollectionName, detail); err != nil && !utils.IsDuplicateKeyError(err) {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations", orgs.GetOrganizations).Methods("GET")
+	r.HandleFunc("/organizations/pending", orgs.CreatePendingOrganization).Methods("POST")
+	r.HandleFunc("/organizations/claim", orgs.ClaimPendingOrganization).Methods("POST")
+
+	createPending := func() (string, string) {
+		req, _ := http.NewRequest("POST", "/organizations/pending", bytes.NewBufferString(`{"name": "Partner Workspace"}`))
+
+		response := getHTTPResponse(t, r, withUser(req, "claimadmin@gmail.com"))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		return data["organization_id"].(string), data["claim_token"].(string)
+	}
+
+	claim := func(email, token string, expectedCode int) {
+		req, _ := http.NewRequest("POST", "/organizations/claim", bytes.NewBufferString(fmt.Sprintf(`{"token": %q}`, token)))
+
+		response := getHTTPResponse(t, r, withUser(req, email))
+		assertStatusCode(t, response.Code, expectedCode)
+	}
+
+	listed := func(orgID string) bool {
+		req, _ := http.NewRequest("GET", "/organizations", nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		for _, item := range parseResponse(response)["data"].([]interface{}) {
+			if item.(map[string]interface{})["_id"] == orgID {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	t.Run("test claiming makes the user owner", func(t *testing.T) {
+		orgID, token := createPending()
+
+		if listed(orgID) {
+			t.Errorf("expected pending organization %s not to be listed", orgID)
+		}
+
+		claim(unverified, token, http.StatusForbidden)
+		claim(claimer, token, http.StatusOK)
+
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if org.Pending || org.Claim != nil || org.CreatorEmail != claimer || len(org.Owners) != 1 {
+			t.Errorf("expected the organization to be claimed by %s, got %+v", claimer, org)
+		}
+
+		owner, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": claimer})
+		if owner == nil || owner["role"] != OwnerRole {
+			t.Errorf("expected %s to own the organization, got %v", claimer, owner)
+		}
+
+		if !listed(orgID) {
+			t.Errorf("expected claimed organization %s to be listed", orgID)
+		}
+
+		claim(claimer, token, http.StatusNotFound)
+	})
+
+	t.Run("test expired claim tokens are gone", func(t *testing.T) {
+		orgID, token := createPending()
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		expired := bson.M{"$set": bson.M{"claim.expires_at": time.Now().Add(-time.Minute)}}
+		if _, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(context.TODO(), bson.M{"_id": pOrgID}, expired); err != nil {
+			t.Fatal(err)
+		}
+
+		claim(claimer, token, http.StatusGone)
+	})
+
+	t.Run("test unknown claim tokens are not found", func(t *testing.T) {
+		claim(claimer, utils.GenUUID(), http.StatusNotFound)
+	})
+}
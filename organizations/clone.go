@@ -0,0 +1,181 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+// cloneMember copies a member's role and profile into a new organization. Their join date is
+// reset, and owners join as admins because ownership is not carried over.
+func cloneMember(m *Member, orgID string) Member {
+	role := m.Role
+	if role == OwnerRole {
+		role = AdminRole
+	}
+
+	clone := NewMember(m.Email, m.UserName, orgID, role)
+
+	clone.FirstName = m.FirstName
+	clone.LastName = m.LastName
+	clone.DisplayName = m.DisplayName
+	clone.ImageURL = m.ImageURL
+	clone.Bio = m.Bio
+	clone.Pronouns = m.Pronouns
+	clone.Phone = m.Phone
+	clone.TimeZone = m.TimeZone
+	clone.Socials = m.Socials
+	clone.Language = m.Language
+
+	if m.Settings != nil {
+		clone.Settings = m.Settings
+	}
+
+	if m.NotificationPreferences != nil {
+		clone.NotificationPreferences = m.NotificationPreferences
+	}
+
+	return clone
+}
+
+// Create a new organization from the settings of an existing one. The caller owns the clone.
+// With include_members=true the members of the organization are copied over with their roles.
+func (oh *OrganizationHandler) CloneOrganization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value("user").(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	orgID := mux.Vars(r)["id"]
+	includeMembers := r.URL.Query().Get("include_members") == "true"
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	source, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	creator, err := auth.FetchUserByEmail(bson.M{"email": strings.ToLower(loggedInUser.Email)})
+	if err != nil {
+		utils.GetError(errors.New("user with this email does not exist"), http.StatusBadRequest, w)
+		return
+	}
+
+	defaultFlags, err := oh.planFeatureFlags(FreeVersion)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	var members []Member
+
+	if includeMembers {
+		memberDocs, err := utils.GetMongoDBDocs(r.Context(), MemberCollectionName, bson.M{
+			"org_id":  orgID,
+			"deleted": bson.M{"$ne": true},
+			"email":   bson.M{"$ne": creator.Email},
+		})
+		if err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		if limit := oh.configs.CloneMemberLimit; len(memberDocs) > limit {
+			utils.GetError(fmt.Errorf("organization has more than %d members to clone", limit), http.StatusRequestEntityTooLarge, w)
+			return
+		}
+
+		for _, doc := range memberDocs {
+			var m Member
+			if err := utils.BsonToStruct(doc, &m); err != nil {
+				utils.GetError(err, http.StatusInternalServerError, w)
+				return
+			}
+
+			members = append(members, m)
+		}
+	}
+
+	clone := Organization{
+		Name:         source.Name,
+		CreatorEmail: creator.Email,
+		CreatorID:    creator.ID,
+		Plugins:      map[string]interface{}{},
+		Settings:     source.Settings,
+		Customize:    source.Customize,
+		LogoURL:      source.LogoURL,
+		WorkspaceURL: utils.GenWorkspaceURL(source.Name),
+		CreatedAt:    utils.NowUTC(),
+		Tokens:       100,
+		Version:      FreeVersion,
+		FeatureFlags: reconcileFeatureFlags(defaultFlags, nil),
+	}
+
+	save, err := utils.GetCollection(OrganizationCollectionName).InsertOne(r.Context(), clone)
+	if err != nil {
+		utils.GetWriteError(err, w)
+		return
+	}
+
+	cloneID := save.InsertedID.(primitive.ObjectID).Hex()
+
+	owner := NewMember(creator.Email, strings.Split(creator.Email, "@")[0], cloneID, OwnerRole)
+
+	ownerRes, err := utils.GetCollection(MemberCollectionName).InsertOne(r.Context(), owner)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	owners := []string{ownerRes.InsertedID.(primitive.ObjectID).Hex()}
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, cloneID, bson.M{"owners": owners}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	emails := []string{creator.Email}
+
+	if len(members) > 0 {
+		docs := make([]interface{}, len(members))
+		for i := range members {
+			docs[i] = cloneMember(&members[i], cloneID)
+			emails = append(emails, members[i].Email)
+		}
+
+		if _, err = utils.GetCollection(MemberCollectionName).InsertMany(r.Context(), docs); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	// add the clone to the workspaces of everyone in it
+	_, err = utils.GetCollection(UserCollectionName).UpdateMany(context.TODO(),
+		bson.M{"email": bson.M{"$in": emails}},
+		bson.M{"$addToSet": bson.M{"workspaces": cloneID}})
+	if err != nil {
+		utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("organization cloned successfully", utils.M{
+		"organization_id": cloneID,
+		"members":         len(members) + 1,
+	}, w)
+}
@@ -0,0 +1,120 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestCloneOrganization(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, bson.M{"logo_url": "https://zuri.chat/logo.png"}); err != nil {
+		t.Fatal(err)
+	}
+
+	roles := map[string]string{
+		"cloneadmin@gmail.com":  AdminRole,
+		"clonemember@gmail.com": MemberRole,
+		"cloneowner@gmail.com":  OwnerRole,
+	}
+
+	for email, role := range roles {
+		if _, err = setUpMember(orgID, email, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deletedID, err := setUpMember(orgID, "clonedeleted@gmail.com", MemberRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, deletedID, bson.M{"deleted": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/clone", orgs.CloneOrganization).Methods("POST")
+
+	clone := func(query string) (*Organization, map[string]string) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/clone%s", orgID, query), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		cloneID := parseResponse(response)["data"].(map[string]interface{})["organization_id"].(string)
+
+		org, err := fetchOrganizationWithOwners(context.TODO(), cloneID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		memberDocs, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{"org_id": cloneID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		members := make(map[string]string)
+
+		for _, doc := range memberDocs {
+			members[doc["email"].(string)] = doc["role"].(string)
+
+			if joined := doc["joined_at"].(primitive.DateTime).Time(); time.Since(joined) > time.Minute {
+				t.Errorf("expected %v to join the clone now, joined at %v", doc["email"], joined)
+			}
+		}
+
+		return org, members
+	}
+
+	t.Run("test clone without members", func(t *testing.T) {
+		org, members := clone("")
+
+		if len(members) != 1 || members[defaultUser] != OwnerRole {
+			t.Errorf("expected only %s as owner, got %v", defaultUser, members)
+		}
+
+		if org.ID == orgID || org.LogoURL != "https://zuri.chat/logo.png" || len(org.Owners) != 1 {
+			t.Errorf("expected a new organization with the same logo and one owner, got %+v", org)
+		}
+	})
+
+	t.Run("test clone with members", func(t *testing.T) {
+		_, members := clone("?include_members=true")
+
+		expected := map[string]string{
+			defaultUser:             OwnerRole,
+			"cloneadmin@gmail.com":  AdminRole,
+			"clonemember@gmail.com": MemberRole,
+			// ownership is not carried over
+			"cloneowner@gmail.com": AdminRole,
+		}
+
+		if fmt.Sprint(members) != fmt.Sprint(expected) {
+			t.Errorf("expected members %v, got %v", expected, members)
+		}
+	})
+
+	t.Run("test clone with too many members", func(t *testing.T) {
+		limited := *configs
+		limited.CloneMemberLimit = 2
+
+		r := getRouter()
+		r.HandleFunc("/organizations/{id}/clone", NewOrganizationHandler(&limited, nil).CloneOrganization).Methods("POST")
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/clone?include_members=true", orgID), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusRequestEntityTooLarge)
+	})
+}
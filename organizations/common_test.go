@@ -8,12 +8,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/service"
 	"zuri.chat/zccore/user"
 	"zuri.chat/zccore/utils"
 )
@@ -71,6 +75,15 @@ func TestMain(m *testing.M) {
 	utils.GetDefaultMongoClient().Database(os.Getenv("DB_NAME")).Drop(ctx)
 	fmt.Printf("\n\n")
 
+	// dropping the database also drops the unique index on user emails
+	if err = utils.CreateUniqueIndex(UserCollectionName, "email", 1); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if err = utils.CreateCompoundUniqueIndex(TeamCollectionName, "org_id", "name_key"); err != nil {
+		log.Fatal(err.Error())
+	}
+
 	err = setUpUserAccount()
 	if err != nil {
 		log.Fatal(err.Error())
@@ -97,14 +110,13 @@ func setUpUserAccount() error{
 		IsVerified: true,
 	}
 
-	result, _ := utils.GetMongoDBDoc(UserCollectionName, bson.M{"email": user.Email})
-	if result != nil {
+	detail, _ := utils.StructToMap(user)
+
+	_, err := utils.CreateMongoDBDoc(context.TODO(), UserCollectionName, detail)
+	if utils.IsDuplicateKeyError(err) {
 		return fmt.Errorf("user %s exists", user.Email)
 	}
 
-	detail, _ := utils.StructToMap(user)
-	_, err := utils.CreateMongoDBDoc(UserCollectionName, detail)
-
 	if err != nil {
 		return err
 	}
@@ -120,7 +132,7 @@ func setUpOrganization() (string, error) {
 	}
 
 	detail, _ := utils.StructToMap(newOrg)
-	save, err := utils.CreateMongoDBDoc(OrganizationCollectionName, detail)
+	save, err := utils.CreateMongoDBDoc(context.TODO(), OrganizationCollectionName, detail)
 	if err != nil {
 		return "",err
 	}
@@ -130,10 +142,132 @@ func setUpOrganization() (string, error) {
 
 	newMember := NewMember(defaultUser, "testuser", id, OwnerRole)
 	memDetail, _ := utils.StructToMap(newMember)
-	_, err = utils.CreateMongoDBDoc(OrganizationCollectionName, memDetail)
+	_, err = utils.CreateMongoDBDoc(context.TODO(), OrganizationCollectionName, memDetail)
 	if err != nil {
 		return "",err
 	}
 
 	return id, nil
-}
\ No newline at end of file
+}
+
+// mockMailService records mails instead of sending them. Mails sent in the background are
+// waited for with awaitMailTo.
+type mockMailService struct {
+	mu     sync.Mutex
+	drafts map[*service.Mail][]string
+	sent   [][]string
+	mails  []*service.Mail
+}
+
+func newMockMailService() *mockMailService {
+	return &mockMailService{drafts: make(map[*service.Mail][]string)}
+}
+
+func (m *mockMailService) LoadTemplate(mailReq *service.Mail) (string, error) {
+	return "", nil
+}
+
+func (m *mockMailService) SendMail(mailReq *service.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, m.drafts[mailReq])
+	m.mails = append(m.mails, mailReq)
+
+	return nil
+}
+
+func (m *mockMailService) NewCustomMail(to []string, subject, mailBody string) *service.Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mail := &service.Mail{}
+	m.drafts[mail] = to
+
+	return mail
+}
+
+func (m *mockMailService) NewMail(to []string, subject string, mailType service.MailType, data map[string]interface{}) *service.Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mail := &service.Mail{}
+	m.drafts[mail] = to
+
+	return mail
+}
+
+// lastMailTo returns the last mail sent to the given address, or nil.
+func (m *mockMailService) lastMailTo(email string) *service.Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		for _, addr := range m.sent[i] {
+			if addr == email {
+				return m.mails[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// sentTo reports whether a mail was sent to the given address.
+func (m *mockMailService) sentTo(email string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, to := range m.sent {
+		for _, addr := range to {
+			if addr == email {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// awaitMailTo reports whether a mail is sent to the given address within a second.
+func (m *mockMailService) awaitMailTo(email string) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if m.sentTo(email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setUpMember adds a member with the given email and role to an organization and returns its id.
+func setUpMember(orgID, email, role string) (string, error) {
+	newMember := NewMember(email, strings.Split(email, "@")[0], orgID, role)
+
+	res, err := utils.GetCollection(MemberCollectionName).InsertOne(context.TODO(), newMember)
+	if err != nil {
+		return "", err
+	}
+
+	return res.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// setUpUser adds a verified account with the given role, "admin" for a Zuri admin, who can act
+// on organizations they are not in.
+func setUpUser(email, role string) error {
+	newUser := user.User{Email: email, IsVerified: true, Role: role}
+
+	detail, _ := utils.StructToMap(newUser)
+
+	if _, err := utils.CreateMongoDBDoc(context.TODO(), UserCollectionName, detail); err != nil && !utils.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	return nil
+}
+
+// withUser attaches a logged in user to the request context, as auth.IsAuthenticated does.
+func withUser(req *http.Request, email string) *http.Request {
+	ctx := context.WithValue(req.Context(), auth.UserContext, &auth.AuthUser{Email: email})
+	return req.WithContext(ctx)
+}
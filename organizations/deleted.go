@@ -0,0 +1,51 @@
+package organizations
+
+import (
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/utils"
+)
+
+// deletedOrganizationFields are the fields of soft-deleted organizations listed to Zuri admins.
+// There is no purge date among them, as nothing purges soft-deleted organizations: unlike
+// deleted users, see PurgeDeletedUsers, they are kept until removed by hand.
+var deletedOrganizationFields = bson.M{
+	"name":          1,
+	"workspace_url": 1,
+	"creator_email": 1,
+	"merged_into":   1,
+	"deleted_at":    1,
+}
+
+// List soft-deleted organizations, most recently deleted first, a page at a time with ?limit=
+// and ?page=. Organizations are soft deleted when merged into another one; other listings
+// leave them out. They are never purged, so none is listed with a purge date.
+func (oh *OrganizationHandler) ListDeletedOrganizations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	limit, page := oh.configs.Pagination.PageParams(query.Get("limit"), query.Get("page"))
+
+	filter := bson.M{"deleted": true}
+
+	opts := options.Find().
+		SetProjection(deletedOrganizationFields).
+		SetSort(bson.D{{Key: "deleted_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64((page - 1) * limit))
+
+	docs, err := utils.GetMongoDBDocs(r.Context(), OrganizationCollectionName, filter, opts)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("deleted organizations retrieved successfully", utils.M{
+		"organizations": docs,
+		"page":          page,
+		"limit":         limit,
+		"total":         utils.CountCollection(r.Context(), OrganizationCollectionName, filter),
+	}, w)
+}
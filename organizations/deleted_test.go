@@ -0,0 +1,98 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+func TestListDeletedOrganizations(t *testing.T) {
+	deletedID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	liveID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := bson.M{"deleted": true, "deleted_at": utils.NowUTC(), "merged_into": liveID}
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, deletedID, update); err != nil {
+		t.Fatal(err)
+	}
+
+	zuriAdmin := fmt.Sprintf("zuriadmin.%s@gmail.com", utils.GenUUID())
+	if err = setUpUser(zuriAdmin, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = setUpUser(defaultUser, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	au := auth.NewAuthHandler(configs, nil)
+
+	r := getRouter()
+	r.HandleFunc("/organizations", orgs.GetOrganizations).Methods("GET")
+	r.HandleFunc("/organizations/deleted", au.IsAuthorized(orgs.ListDeletedOrganizations, "zuri_admin")).Methods("GET")
+
+	// listedIDs lists organizations as the given user and returns their ids.
+	listedIDs := func(t *testing.T, url, email string) map[string]bson.M {
+		t.Helper()
+
+		req, _ := http.NewRequest("GET", url, nil)
+
+		response := getHTTPResponse(t, r, withUser(req, email))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data := parseResponse(response)["data"]
+		if page, ok := data.(map[string]interface{}); ok {
+			data = page["organizations"]
+		}
+
+		ids := map[string]bson.M{}
+
+		for _, item := range data.([]interface{}) {
+			org, _ := item.(map[string]interface{})
+			ids[fmt.Sprint(org["_id"])] = org
+		}
+
+		return ids
+	}
+
+	t.Run("test only deleted organizations are listed", func(t *testing.T) {
+		ids := listedIDs(t, "/organizations/deleted?limit=100", zuriAdmin)
+
+		org, ok := ids[deletedID]
+		if !ok {
+			t.Fatalf("expected deleted organization %s to be listed", deletedID)
+		}
+
+		if org["deleted_at"] == nil || org["merged_into"] != liveID {
+			t.Errorf("expected when and into what the organization was merged, got %v", org)
+		}
+
+		if _, ok := ids[liveID]; ok {
+			t.Errorf("expected organization %s to be left out", liveID)
+		}
+	})
+
+	t.Run("test normal listings hide deleted organizations", func(t *testing.T) {
+		if _, ok := listedIDs(t, "/organizations", defaultUser)[deletedID]; ok {
+			t.Errorf("expected deleted organization %s to be left out", deletedID)
+		}
+	})
+
+	t.Run("test only zuri admins list deleted organizations", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/organizations/deleted", nil)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusUnauthorized)
+	})
+}
@@ -0,0 +1,101 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// deletionTokenLifetime is how long a confirmation token to delete an organization lasts.
+const deletionTokenLifetime = 5 * time.Minute
+
+var errInvalidDeletionToken = errors.New("confirmation token is invalid or has expired, request a new one")
+
+// requestDeletion saves a new confirmation token to delete the organization, replacing any
+// earlier one, and responds with it and a summary of what will be deleted.
+func (oh *OrganizationHandler) requestDeletion(w http.ResponseWriter, r *http.Request, pOrgID primitive.ObjectID) {
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", pOrgID.Hex()), http.StatusNotFound, w)
+		return
+	}
+
+	var requestedBy string
+	if loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser); ok {
+		requestedBy = loggedInUser.Email
+	}
+
+	confirmation := DeletionConfirmation{
+		Token:       utils.GenUUID(),
+		RequestedBy: requestedBy,
+		ExpiresAt:   utils.NowUTC().Add(deletionTokenLifetime),
+	}
+
+	if _, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, pOrgID.Hex(), bson.M{"deletion_confirmation": confirmation}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	plugins := make([]string, 0, len(org.Plugins))
+	for pluginID := range org.Plugins {
+		plugins = append(plugins, pluginID)
+	}
+
+	sort.Strings(plugins)
+
+	memberCount := utils.CountCollection(r.Context(), MemberCollectionName, bson.M{"org_id": pOrgID.Hex(), "deleted": bson.M{"$ne": true}})
+
+	utils.GetSuccess("confirm to delete the organization", utils.M{
+		"confirmation_token": confirmation.Token,
+		"expires_at":         confirmation.ExpiresAt,
+		"summary": utils.M{
+			"name":         org.Name,
+			"member_count": memberCount,
+			"plugins":      plugins,
+		},
+	}, w)
+}
+
+// deleteOrganization deletes the organization when it matches filter, and disables what it
+// leaves behind. It reports whether it was deleted.
+func deleteOrganization(ctx context.Context, pOrgID primitive.ObjectID, filter bson.M) (bool, error) {
+	response, err := utils.GetCollection(OrganizationCollectionName).DeleteOne(ctx, filter)
+	if err != nil || response.DeletedCount == 0 {
+		return false, err
+	}
+
+	disableOrganizationRelations(pOrgID.Hex())
+
+	return true, nil
+}
+
+// disableOrganizationRelations stops what a deleted organization leaves behind in other
+// collections from being used: its open invites expire, its API keys are revoked, and its
+// webhook deliveries still waiting to be sent fail instead. Webhooks themselves live on the
+// organization and go with it.
+func disableOrganizationRelations(orgID string) {
+	if _, err := utils.UpdateManyMongoDBDocs(context.TODO(), OrganizationInviteCollectionName,
+		bson.M{"org_id": orgID, "expired": bson.M{"$ne": true}}, bson.M{"expired": true}); err != nil {
+		logger.Error("could not expire invites of deleted organization %s: %v", orgID, err)
+	}
+
+	if _, err := utils.UpdateManyMongoDBDocs(context.TODO(), auth.APIKeyCollectionName,
+		bson.M{"org_id": orgID, "revoked_at": nil}, bson.M{"revoked_at": utils.NowUTC()}); err != nil {
+		logger.Error("could not revoke API keys of deleted organization %s: %v", orgID, err)
+	}
+
+	pending := bson.M{"org_id": orgID, "status": bson.M{"$in": []string{WebhookDeliveryQueued, WebhookDeliveryBatched}}}
+	if _, err := utils.UpdateManyMongoDBDocs(context.TODO(), WebhookDeliveryCollectionName, pending,
+		bson.M{"status": WebhookDeliveryFailed, "error": "organization was deleted"}); err != nil {
+		logger.Error("could not cancel webhook deliveries of deleted organization %s: %v", orgID, err)
+	}
+}
@@ -0,0 +1,114 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestDeleteOrganizationConfirmation(t *testing.T) {
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}", orgs.DeleteOrganization).Methods("DELETE")
+
+	// del sends a delete request for the organization with the token, if any.
+	del := func(t *testing.T, orgID, token string, code int) map[string]interface{} {
+		path := fmt.Sprintf("/organizations/%s", orgID)
+		if token != "" {
+			path += "?confirmation_token=" + token
+		}
+
+		req, _ := http.NewRequest("DELETE", path, nil)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, code)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	exists := func(orgID string) bool {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), OrganizationCollectionName, bson.M{"_id": pOrgID})
+
+		return doc != nil
+	}
+
+	t.Run("test the first call only returns a token and summary", func(t *testing.T) {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := setUpMember(orgID, "deletesummary@gmail.com", MemberRole); err != nil {
+			t.Fatal(err)
+		}
+
+		data := del(t, orgID, "", http.StatusOK)
+
+		if data["confirmation_token"] == "" {
+			t.Error("expected a confirmation token")
+		}
+
+		summary := data["summary"].(map[string]interface{})
+		if summary["member_count"] != float64(1) {
+			t.Errorf("expected a member count of 1, got %v", summary["member_count"])
+		}
+
+		if !exists(orgID) {
+			t.Fatal("expected the organization not to be deleted without confirmation")
+		}
+
+		t.Run("test a wrong token does not delete", func(t *testing.T) {
+			del(t, orgID, utils.GenUUID(), http.StatusBadRequest)
+
+			if !exists(orgID) {
+				t.Error("expected the organization not to be deleted")
+			}
+		})
+
+		inviteUUID := utils.GenUUID()
+		if _, err := utils.CreateMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": inviteUUID, "email": "deleteinvite@gmail.com", "org_id": orgID}); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run("test replaying the token deletes", func(t *testing.T) {
+			del(t, orgID, data["confirmation_token"].(string), http.StatusOK)
+
+			if exists(orgID) {
+				t.Error("expected the organization to be deleted")
+			}
+
+			invite, _ := utils.GetMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": inviteUUID})
+			if !inviteExpired(invite, utils.NowUTC()) {
+				t.Error("expected the invites of the deleted organization to expire")
+			}
+		})
+	})
+
+	t.Run("test expired tokens do not delete", func(t *testing.T) {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token := del(t, orgID, "", http.StatusOK)["confirmation_token"].(string)
+
+		expired := bson.M{"deletion_confirmation.expires_at": time.Now().Add(-time.Second)}
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, expired); err != nil {
+			t.Fatal(err)
+		}
+
+		del(t, orgID, token, http.StatusBadRequest)
+
+		if !exists(orgID) {
+			t.Error("expected the organization not to be deleted with an expired token")
+		}
+	})
+}
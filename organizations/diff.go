@@ -0,0 +1,132 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// FieldChange is how a field of an organization changed between two points in time.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// recordOrganizationChange keeps a change to a field of an organization in the audit log, with
+// its value before and after. A change that could not be recorded is logged rather than failed,
+// as it has already been made.
+func recordOrganizationChange(r *http.Request, orgID, field string, before, after interface{}) {
+	entry := &audit.Log{
+		OrgID:      orgID,
+		Action:     audit.OrganizationUpdated,
+		TargetType: "organization",
+		TargetID:   orgID,
+		Data:       map[string]interface{}{"field": field, "before": before, "after": after},
+	}
+
+	if user, ok := r.Context().Value("user").(*auth.AuthUser); ok {
+		entry.Actor, entry.ImpersonatedBy = user.Email, user.ImpersonatedBy
+	}
+
+	if err := audit.Record(entry); err != nil {
+		logger.Error("could not record the change to %s of organization %s: %v", field, orgID, err)
+	}
+}
+
+// diffOrganizationChanges replays organization.updated entries, oldest first, into the change to
+// each field they touch: from its value before the first entry to its value after the last.
+// Fields changed and then changed back are left out.
+func diffOrganizationChanges(entries []audit.Log) map[string]FieldChange {
+	diff := make(map[string]FieldChange)
+
+	for _, entry := range entries {
+		field, _ := entry.Data["field"].(string)
+		if field == "" {
+			continue
+		}
+
+		change, seen := diff[field]
+		if !seen {
+			change.From = entry.Data["before"]
+		}
+
+		change.To = entry.Data["after"]
+		diff[field] = change
+	}
+
+	for field, change := range diff {
+		if reflect.DeepEqual(change.From, change.To) {
+			delete(diff, field)
+		}
+	}
+
+	return diff
+}
+
+// Get what changed in an organization between two points in time, ?from= and ?to= (now by
+// default) as RFC 3339 times, by replaying its audit log. Only changes recorded with their
+// before and after values are seen, currently those to its name and workspace url.
+func (oh *OrganizationHandler) GetOrganizationDiff(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		utils.GetError(errors.New("from must be an RFC 3339 time"), http.StatusBadRequest, w)
+		return
+	}
+
+	to := utils.NowUTC()
+
+	if s := r.URL.Query().Get("to"); s != "" {
+		if to, err = time.Parse(time.RFC3339, s); err != nil {
+			utils.GetError(errors.New("to must be an RFC 3339 time"), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		utils.GetError(errors.New("from must be before to"), http.StatusBadRequest, w)
+		return
+	}
+
+	filter := bson.M{
+		"org_id":     orgID,
+		"action":     audit.OrganizationUpdated,
+		"created_at": bson.M{"$gt": from, "$lte": to},
+	}
+	oldestFirst := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := utils.GetCollection(audit.AuditLogCollectionName).Find(r.Context(), filter, oldestFirst)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	var entries []audit.Log
+	if err := cursor.All(r.Context(), &entries); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("organization diff retrieved successfully", utils.M{
+		"from":    from,
+		"to":      to,
+		"changes": diffOrganizationChanges(entries),
+	}, w)
+}
@@ -0,0 +1,94 @@
+package organizations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/utils"
+)
+
+func TestGetOrganizationDiff(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/name", orgs.UpdateName).Methods("PATCH")
+	r.HandleFunc("/organizations/{id}/diff", orgs.GetOrganizationDiff).Methods("GET")
+
+	// checkpoint returns a time between audit log entries, which are stored to the millisecond.
+	checkpoint := func() time.Time {
+		time.Sleep(5 * time.Millisecond)
+		defer time.Sleep(5 * time.Millisecond)
+
+		return time.Now()
+	}
+
+	rename := func(t *testing.T, name string) {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/name", orgID), bytes.NewBufferString(fmt.Sprintf(`{"organization_name": %q}`, name)))
+		assertStatusCode(t, getHTTPResponse(t, r, withUser(req, defaultUser)).Code, http.StatusOK)
+	}
+
+	diff := func(t *testing.T, from, to time.Time) map[string]interface{} {
+		query := url.Values{"from": {from.Format(time.RFC3339Nano)}, "to": {to.Format(time.RFC3339Nano)}}
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/diff?%s", orgID, query.Encode()), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		return parseResponse(response)["data"].(map[string]interface{})["changes"].(map[string]interface{})
+	}
+
+	start := checkpoint()
+	rename(t, "Zuri Chat Two")
+	between := checkpoint()
+	rename(t, "Zuri Chat Three")
+	end := checkpoint()
+
+	t.Run("test a diff across two edits", func(t *testing.T) {
+		changes := diff(t, start, end)
+
+		name, _ := changes["name"].(map[string]interface{})
+		if len(changes) != 1 || name["from"] != "Zuri Chat" || name["to"] != "Zuri Chat Three" {
+			t.Errorf("expected the name to change from Zuri Chat to Zuri Chat Three, got %v", changes)
+		}
+	})
+
+	t.Run("test a diff from between the edits", func(t *testing.T) {
+		name, _ := diff(t, between, end)["name"].(map[string]interface{})
+		if name["from"] != "Zuri Chat Two" || name["to"] != "Zuri Chat Three" {
+			t.Errorf("expected the name to change from Zuri Chat Two to Zuri Chat Three, got %v", name)
+		}
+	})
+
+	t.Run("test nothing changed", func(t *testing.T) {
+		if changes := diff(t, end, checkpoint()); len(changes) != 0 {
+			t.Errorf("expected an empty diff, got %v", changes)
+		}
+	})
+
+	t.Run("test changes undone are left out", func(t *testing.T) {
+		changes := diffOrganizationChanges([]audit.Log{
+			{Data: utils.M{"field": "name", "before": "Zuri", "after": "Zuri Two"}},
+			{Data: utils.M{"field": "workspace_url", "before": "zuri", "after": "zuri-two"}},
+			{Data: utils.M{"field": "name", "before": "Zuri Two", "after": "Zuri"}},
+		})
+
+		if _, ok := changes["name"]; ok || len(changes) != 1 {
+			t.Errorf("expected only the workspace url to change, got %v", changes)
+		}
+	})
+
+	t.Run("test from must be before to", func(t *testing.T) {
+		query := url.Values{"from": {end.Format(time.RFC3339Nano)}, "to": {start.Format(time.RFC3339Nano)}}
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/diff?%s", orgID, query.Encode()), nil)
+
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,243 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/service"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	digestCheckInterval = 5 * time.Minute
+	digestPeriod        = 24 * time.Hour
+)
+
+// DigestScheduler emails each organization with digests enabled a summary of the past day,
+// once a day from the configured hour in the organization's own time zone.
+type DigestScheduler struct {
+	mailService service.MailService
+	now         func() time.Time
+	interval    time.Duration
+}
+
+func NewDigestScheduler(mail service.MailService) *DigestScheduler {
+	return &DigestScheduler{mailService: mail, now: time.Now, interval: digestCheckInterval}
+}
+
+// Run checks for due digests until the context is cancelled.
+func (s *DigestScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunOnce()
+		}
+	}
+}
+
+// RunOnce sends every digest that is due now and returns how many were sent.
+func (s *DigestScheduler) RunOnce() int {
+	now := s.now()
+
+	orgDocs, err := utils.GetMongoDBDocs(context.TODO(), OrganizationCollectionName, bson.M{"digest.enabled": true})
+	if err != nil {
+		logger.Error("could not fetch organizations for digests: %v", err)
+		return 0
+	}
+
+	sent := 0
+
+	for _, doc := range orgDocs {
+		var org Organization
+		if err := utils.BsonToStruct(doc, &org); err != nil {
+			continue
+		}
+
+		due, err := org.Digest.due(now)
+		if err != nil {
+			logger.Error("digest for organization %s: %v", org.ID, err)
+			continue
+		}
+
+		if !due || !claimDigest(&org, now) {
+			continue
+		}
+
+		s.sendDigest(&org, now)
+		sent++
+	}
+
+	return sent
+}
+
+// due reports whether a digest should go out at the given instant. Working in the
+// organization's location means the target hour follows daylight saving changes, and
+// because a digest is due from the hour onwards, a skipped hour does not skip the day.
+func (d *DigestSettings) due(now time.Time) (bool, error) {
+	loc, err := time.LoadLocation(d.TimeZone)
+	if err != nil {
+		return false, err
+	}
+
+	local := now.In(loc)
+	if local.Hour() < d.Hour {
+		return false, nil
+	}
+
+	if d.LastSentAt.IsZero() {
+		return true, nil
+	}
+
+	ly, lm, ld := d.LastSentAt.In(loc).Date()
+	y, m, day := local.Date()
+
+	return ly != y || lm != m || ld != day, nil
+}
+
+// claimDigest marks an organization's digest as sent. The update only matches the last sent time
+// read earlier, so when several instances run the scheduler just one of them sends the digest.
+func claimDigest(org *Organization, now time.Time) bool {
+	pOrgID, err := primitive.ObjectIDFromHex(org.ID)
+	if err != nil {
+		return false
+	}
+
+	filter := bson.M{"_id": pOrgID, "digest.last_sent_at": org.Digest.LastSentAt}
+	if org.Digest.LastSentAt.IsZero() {
+		// digests that have never been sent may not have the field at all
+		filter["digest.last_sent_at"] = bson.M{"$in": []interface{}{time.Time{}, nil}}
+	}
+
+	update := bson.M{"$set": bson.M{"digest.last_sent_at": now}}
+
+	res, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		logger.Error("could not claim digest for organization %s: %v", org.ID, err)
+		return false
+	}
+
+	return res.ModifiedCount == 1
+}
+
+// builds the digest body from the members who joined and announcements posted since the last digest.
+func assembleDigest(org *Organization, since time.Time) (string, error) {
+	memberDocs, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{
+		"org_id":    org.ID,
+		"joined_at": bson.M{"$gt": since},
+		"deleted":   bson.M{"$ne": true},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "<h2>What happened in %s</h2>", html.EscapeString(org.Name))
+	fmt.Fprintf(&b, "<h3>New members (%d)</h3><ul>", len(memberDocs))
+
+	for _, doc := range memberDocs {
+		fmt.Fprintf(&b, "<li>%s</li>", html.EscapeString(fmt.Sprint(doc["email"])))
+	}
+
+	b.WriteString("</ul><h3>Announcements</h3><ul>")
+
+	for _, a := range org.Announcements {
+		if a.CreatedAt.After(since) {
+			fmt.Fprintf(&b, "<li><b>%s</b>: %s</li>", html.EscapeString(a.Title), html.EscapeString(a.Body))
+		}
+	}
+
+	b.WriteString("</ul>")
+
+	return b.String(), nil
+}
+
+// emails the digest to the owners and admins of an organization.
+func (s *DigestScheduler) sendDigest(org *Organization, now time.Time) {
+	since := org.Digest.LastSentAt
+	if since.IsZero() {
+		since = now.Add(-digestPeriod)
+	}
+
+	body, err := assembleDigest(org, since)
+	if err != nil {
+		logger.Error("could not assemble digest for organization %s: %v", org.ID, err)
+		return
+	}
+
+	recipients, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{
+		"org_id":  org.ID,
+		"role":    bson.M{"$in": []string{OwnerRole, AdminRole}},
+		"deleted": bson.M{"$ne": true},
+	})
+	if err != nil {
+		logger.Error("could not fetch digest recipients for organization %s: %v", org.ID, err)
+		return
+	}
+
+	subject := fmt.Sprintf("Your daily %s digest", org.Name)
+
+	for _, doc := range recipients {
+		email, _ := doc["email"].(string)
+
+		msger := s.mailService.NewCustomMail([]string{email}, subject, body)
+		if err := s.mailService.SendMail(msger); err != nil {
+			logger.Error("Error occurred while sending mail: %s", err.Error())
+		}
+	}
+}
+
+// Update an organization's daily digest settings.
+func (oh *OrganizationHandler) UpdateDigestSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var settings DigestSettings
+	if err := utils.ParseJSONFromRequest(r, &settings); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if _, err := time.LoadLocation(settings.TimeZone); err != nil || settings.TimeZone == "" {
+		utils.GetError(errors.New("invalid time zone"), http.StatusBadRequest, w)
+		return
+	}
+
+	if settings.Hour < 0 || settings.Hour > 23 {
+		utils.GetError(errors.New("digest hour must be between 0 and 23"), http.StatusBadRequest, w)
+		return
+	}
+
+	update := bson.M{
+		"digest.enabled":   settings.Enabled,
+		"digest.time_zone": settings.TimeZone,
+		"digest.hour":      settings.Hour,
+	}
+
+	if _, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, update); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("digest settings updated successfully", settings, w)
+}
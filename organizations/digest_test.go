@@ -0,0 +1,85 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+// sets up an organization with digests enabled and an owner to receive them.
+func setUpDigestOrganization(t *testing.T, owner, timeZone string, hour int) string {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = setUpMember(orgID, owner, OwnerRole); err != nil {
+		t.Fatal(err)
+	}
+
+	update := bson.M{"digest": DigestSettings{Enabled: true, TimeZone: timeZone, Hour: hour}}
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, update); err != nil {
+		t.Fatal(err)
+	}
+
+	return orgID
+}
+
+func TestDigestScheduler(t *testing.T) {
+	newYork, tokyo := "digestny@gmail.com", "digesttokyo@gmail.com"
+
+	setUpDigestOrganization(t, newYork, "America/New_York", 9)
+	setUpDigestOrganization(t, tokyo, "Asia/Tokyo", 23)
+
+	t.Run("test digest is not due before the local hour", func(t *testing.T) {
+		mailer := newMockMailService()
+
+		// 13:30 UTC is 08:30 in New York during standard time
+		winter := time.Date(2021, time.January, 15, 13, 30, 0, 0, time.UTC)
+		scheduler := &DigestScheduler{mailService: mailer, now: func() time.Time { return winter }}
+		scheduler.RunOnce()
+
+		if mailer.sentTo(newYork) {
+			t.Error("digest sent before 09:00 New York time")
+		}
+	})
+
+	t.Run("test digest fires at the local hour", func(t *testing.T) {
+		mailer := newMockMailService()
+
+		// 13:30 UTC is 09:30 in New York during daylight saving time, and 22:30 in Tokyo
+		summer := time.Date(2021, time.July, 1, 13, 30, 0, 0, time.UTC)
+		scheduler := &DigestScheduler{mailService: mailer, now: func() time.Time { return summer }}
+		scheduler.RunOnce()
+
+		if !mailer.sentTo(newYork) {
+			t.Error("digest not sent at 09:30 New York time")
+		}
+
+		if mailer.sentTo(tokyo) {
+			t.Error("digest sent before 23:00 Tokyo time")
+		}
+
+		mailer.sent = nil
+		scheduler.RunOnce()
+
+		if mailer.sentTo(newYork) {
+			t.Error("digest sent twice on the same local day")
+		}
+	})
+
+	t.Run("test digest settings resolve daylight saving", func(t *testing.T) {
+		settings := DigestSettings{TimeZone: "Europe/London", Hour: 9}
+
+		// 08:30 UTC is 09:30 in London in summer but 08:30 in winter
+		summer, _ := settings.due(time.Date(2021, time.June, 1, 8, 30, 0, 0, time.UTC))
+		winter, _ := settings.due(time.Date(2021, time.December, 1, 8, 30, 0, 0, time.UTC))
+
+		if !summer || winter {
+			t.Errorf("expected digest due in summer only, got summer=%v winter=%v", summer, winter)
+		}
+	})
+}
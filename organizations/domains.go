@@ -0,0 +1,207 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+// DomainVerificationPrefix starts the value of the TXT record proving an organization owns an
+// email domain, followed by the domain's verification token.
+const DomainVerificationPrefix = "zuri-chat-verification="
+
+// lookupTXT resolves the TXT records of a domain, and is swapped out in tests.
+var lookupTXT = net.LookupTXT
+
+var errDomainRecordNotFound = errors.New("the verification TXT record was not found on the domain")
+
+// normalizeEmailDomain lower-cases a domain, dropping any leading @, and checks it could be the
+// domain of an email address.
+func normalizeEmailDomain(domain string) (string, error) {
+	domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")
+
+	if !strings.Contains(domain, ".") || !utils.IsValidEmail("user@"+domain) {
+		return "", fmt.Errorf("%q is not a valid email domain", domain)
+	}
+
+	return domain, nil
+}
+
+// domainTXTRecord is the DNS record an organization publishes to verify a domain.
+func domainTXTRecord(d AllowedDomain) utils.M {
+	return utils.M{"name": d.Domain, "type": "TXT", "value": DomainVerificationPrefix + d.VerificationToken}
+}
+
+// findAllowedDomain returns the organization's entry for a domain, or nil.
+func (o *Organization) findAllowedDomain(domain string) *AllowedDomain {
+	for i := range o.AllowedDomains {
+		if o.AllowedDomains[i].Domain == domain {
+			return &o.AllowedDomains[i]
+		}
+	}
+
+	return nil
+}
+
+// AllowsEmailDomain reports whether users with the email may join the organization by its
+// domain. Domains that are not verified yet are ignored.
+func (o *Organization) AllowsEmailDomain(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+
+	d := o.findAllowedDomain(strings.ToLower(email[at+1:]))
+
+	return d != nil && d.Verified
+}
+
+// hasDomainRecord reports whether one of the domain's TXT records carries its verification token.
+func hasDomainRecord(d AllowedDomain) (bool, error) {
+	records, err := lookupTXT(d.Domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	want := DomainVerificationPrefix + d.VerificationToken
+	for _, record := range records {
+		if strings.TrimSpace(record) == want {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Add an email domain an organization allows users from. It is stored unverified, and does not
+// count until the TXT record returned is published and VerifyAllowedDomain is called.
+func (oh *OrganizationHandler) AddAllowedDomain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	var body struct {
+		Domain string `json:"domain"`
+	}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	domain, err := normalizeEmailDomain(body.Domain)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid id"), http.StatusBadRequest, w)
+		return
+	}
+
+	allowed := AllowedDomain{Domain: domain, VerificationToken: utils.GenUUID()}
+
+	// the domain filter keeps a domain from being added twice by concurrent requests
+	res, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": pOrgID, "allowed_domains.domain": bson.M{"$ne": domain}},
+		bson.M{"$push": bson.M{"allowed_domains": allowed}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		if err := ValidateOrg(r.Context(), orgID); err != nil {
+			utils.GetError(err, http.StatusNotFound, w)
+			return
+		}
+
+		utils.GetError(fmt.Errorf("%s has already been added", domain), http.StatusConflict, w)
+
+		return
+	}
+
+	location := fmt.Sprintf("/organizations/%s/domains/%s", orgID, domain)
+
+	utils.GetCreated("domain added, publish the TXT record to verify it", location, utils.M{
+		"domain":     allowed,
+		"txt_record": domainTXTRecord(allowed),
+	}, w)
+}
+
+// Verify an organization owns one of its allowed email domains by looking up the TXT record it
+// was asked to publish. A domain can only be verified by one organization.
+func (oh *OrganizationHandler) VerifyAllowedDomain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, domain := mux.Vars(r)["id"], strings.ToLower(mux.Vars(r)["domain"])
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(errors.New("organization not found"), http.StatusNotFound, w)
+		return
+	}
+
+	allowed := org.findAllowedDomain(domain)
+	if allowed == nil {
+		utils.GetError(fmt.Errorf("%s has not been added to the organization", domain), http.StatusNotFound, w)
+		return
+	}
+
+	if allowed.Verified {
+		utils.GetSuccess("domain already verified", allowed, w)
+		return
+	}
+
+	found, err := hasDomainRecord(*allowed)
+	if err != nil {
+		utils.GetError(fmt.Errorf("could not look up the domain: %v", err), http.StatusBadGateway, w)
+		return
+	}
+
+	if !found {
+		utils.GetError(errDomainRecordNotFound, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	taken := utils.CountCollection(r.Context(), OrganizationCollectionName, bson.M{
+		"_id":             bson.M{"$ne": pOrgID},
+		"allowed_domains": bson.M{"$elemMatch": bson.M{"domain": domain, "verified": true}},
+	})
+	if taken > 0 {
+		utils.GetError(fmt.Errorf("%s is verified by another organization", domain), http.StatusConflict, w)
+		return
+	}
+
+	now := utils.NowUTC()
+	allowed.Verified, allowed.VerifiedAt = true, &now
+
+	if _, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": pOrgID, "allowed_domains.domain": domain},
+		bson.M{"$set": bson.M{"allowed_domains.$.verified": true, "allowed_domains.$.verified_at": now}}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("domain verified successfully", allowed, w)
+}
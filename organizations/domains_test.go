@@ -0,0 +1,120 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestAllowedDomainVerification(t *testing.T) {
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/domains", orgs.AddAllowedDomain).Methods("POST")
+	r.HandleFunc("/organizations/{id}/domains/{domain}/verify", orgs.VerifyAllowedDomain).Methods("POST")
+
+	// records are the TXT records lookupTXT answers with for every domain
+	var records []string
+
+	defer func(lookup func(string) ([]string, error)) { lookupTXT = lookup }(lookupTXT)
+	lookupTXT = func(string) ([]string, error) { return records, nil }
+
+	// addDomain adds a domain to the organization and returns the TXT record to publish.
+	addDomain := func(t *testing.T, orgID, domain string) string {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/domains", orgID), bytes.NewBufferString(fmt.Sprintf(`{"domain": %q}`, domain)))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		return data["txt_record"].(map[string]interface{})["value"].(string)
+	}
+
+	verify := func(t *testing.T, orgID, domain string, code int) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/domains/%s/verify", orgID, domain), nil)
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, code)
+	}
+
+	fetch := func(t *testing.T, orgID string) *Organization {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return org
+	}
+
+	t.Run("test unverified domains are stored but inactive", func(t *testing.T) {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		domain := fmt.Sprintf("%s.example.com", utils.GenUUID())
+		addDomain(t, orgID, domain)
+
+		records = []string{"v=spf1 -all", DomainVerificationPrefix + "someone-elses-token"}
+		verify(t, orgID, domain, http.StatusUnprocessableEntity)
+
+		org := fetch(t, orgID)
+		if d := org.findAllowedDomain(domain); d == nil || d.Verified {
+			t.Fatalf("expected %s to be stored unverified, got %v", domain, d)
+		}
+
+		if org.AllowsEmailDomain("someone@" + domain) {
+			t.Error("expected an unverified domain not to allow its users")
+		}
+	})
+
+	t.Run("test verified domains are active", func(t *testing.T) {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		domain := fmt.Sprintf("%s.example.com", utils.GenUUID())
+		records = []string{addDomain(t, orgID, "@"+domain)}
+
+		verify(t, orgID, domain, http.StatusOK)
+
+		org := fetch(t, orgID)
+		if !org.AllowsEmailDomain("Someone@" + domain) {
+			t.Error("expected a verified domain to allow its users")
+		}
+
+		if org.AllowsEmailDomain("someone@other." + domain) {
+			t.Error("expected subdomains not to be allowed")
+		}
+
+		other, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		records = []string{addDomain(t, other, domain)}
+		verify(t, other, domain, http.StatusConflict)
+	})
+
+	t.Run("test domains are added once", func(t *testing.T) {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		domain := fmt.Sprintf("%s.example.com", utils.GenUUID())
+		addDomain(t, orgID, domain)
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/domains", orgID), bytes.NewBufferString(fmt.Sprintf(`{"domain": %q}`, domain)))
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusConflict)
+
+		req, _ = http.NewRequest("POST", fmt.Sprintf("/organizations/%s/domains", orgID), bytes.NewBufferString(`{"domain": "localhost"}`))
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,88 @@
+package organizations
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+// organizationNotFound is the error for an organization that does not exist, or that the
+// caller is not told exists.
+func organizationNotFound(orgID string) error {
+	return fmt.Errorf("organization %s not found", orgID)
+}
+
+// hidesOrganization reports whether the caller is to be told an organization does not exist,
+// whether or not it does. With HideOrganizationExistence set, that is every caller who is
+// neither a member of it nor a Zuri admin, so missing and forbidden organizations look alike.
+// Members and Zuri admins are told precisely what is wrong.
+func (oh *OrganizationHandler) hidesOrganization(r *http.Request, orgID string) bool {
+	if !oh.configs.HideOrganizationExistence {
+		return false
+	}
+
+	// integrations of the organization know it exists
+	if apiKey, ok := r.Context().Value(auth.APIKeyContext).(*auth.APIKey); ok && apiKey.OrgID == orgID {
+		return false
+	}
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok || loggedInUser == nil || loggedInUser.Email == "" {
+		return true
+	}
+
+	email := strings.ToLower(loggedInUser.Email)
+
+	if member, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"org_id": orgID, "email": email, "deleted": bson.M{"$ne": true}}); member != nil {
+		return false
+	}
+
+	zuriAdmin, _ := utils.GetMongoDBDoc(r.Context(), UserCollectionName, bson.M{"email": email, "role": "admin"})
+
+	return zuriAdmin == nil
+}
+
+// listableOrganizations narrows filter, a filter on organizations, to those the caller may be
+// told exist when listing them, so listings cannot be used to find out what hidesOrganization
+// hides. With HideOrganizationExistence set, that is the organizations the caller is a member
+// of, unless they are a Zuri admin.
+func (oh *OrganizationHandler) listableOrganizations(r *http.Request, filter bson.M) error {
+	if !oh.configs.HideOrganizationExistence {
+		return nil
+	}
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok || loggedInUser == nil || loggedInUser.Email == "" {
+		filter["_id"] = bson.M{"$in": []primitive.ObjectID{}}
+		return nil
+	}
+
+	email := strings.ToLower(loggedInUser.Email)
+
+	if zuriAdmin, _ := utils.GetMongoDBDoc(r.Context(), UserCollectionName, bson.M{"email": email, "role": "admin"}); zuriAdmin != nil {
+		return nil
+	}
+
+	members, err := utils.GetMongoDBDocs(r.Context(), MemberCollectionName, bson.M{"email": email, "deleted": bson.M{"$ne": true}})
+	if err != nil {
+		return err
+	}
+
+	orgIDs := make([]primitive.ObjectID, 0, len(members))
+
+	for _, member := range members {
+		orgID, _ := member["org_id"].(string)
+		if pOrgID, err := primitive.ObjectIDFromHex(orgID); err == nil {
+			orgIDs = append(orgIDs, pOrgID)
+		}
+	}
+
+	filter["_id"] = bson.M{"$in": orgIDs}
+
+	return nil
+}
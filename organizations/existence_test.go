@@ -0,0 +1,108 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestHideOrganizationExistence(t *testing.T) {
+	defer func(hide bool) { configs.HideOrganizationExistence = hide }(configs.HideOrganizationExistence)
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deactivatedID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, deactivatedID, bson.M{"deactivated": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	suffix := utils.GenUUID()
+	member := fmt.Sprintf("insider.%s@gmail.com", suffix)
+	outsider := fmt.Sprintf("outsider.%s@gmail.com", suffix)
+
+	for _, id := range []string{orgID, deactivatedID} {
+		if _, err = setUpMember(id, member, MemberRole); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	missingID := primitive.NewObjectID().Hex()
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}", orgs.GetOrganization).Methods("GET")
+	r.HandleFunc("/organizations/{id}/members", orgs.GetMembers).Methods("GET")
+	r.HandleFunc("/organizations", orgs.GetOrganizations).Methods("GET")
+
+	get := func(t *testing.T, path, as string, code int) map[string]interface{} {
+		t.Helper()
+
+		req, _ := http.NewRequest("GET", path, nil)
+
+		response := getHTTPResponse(t, r, withUser(req, as))
+		assertStatusCode(t, response.Code, code)
+
+		return parseResponse(response)
+	}
+
+	lists := func(t *testing.T, as, id string) bool {
+		t.Helper()
+
+		data, _ := get(t, "/organizations", as, http.StatusOK)["data"].([]interface{})
+		for _, item := range data {
+			if org, _ := item.(map[string]interface{}); org["_id"] == id {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	t.Run("test precise mode tells missing and forbidden apart", func(t *testing.T) {
+		configs.HideOrganizationExistence = false
+
+		get(t, "/organizations/"+missingID, outsider, http.StatusNotFound)
+		get(t, "/organizations/"+deactivatedID, outsider, http.StatusForbidden)
+		get(t, "/organizations/"+orgID, outsider, http.StatusOK)
+		get(t, fmt.Sprintf("/organizations/%s/members", orgID), outsider, http.StatusOK)
+	})
+
+	t.Run("test outsiders cannot tell existing organizations from missing ones", func(t *testing.T) {
+		configs.HideOrganizationExistence = true
+
+		for _, id := range []string{missingID, deactivatedID, orgID} {
+			if got := get(t, "/organizations/"+id, outsider, http.StatusNotFound)["message"]; got != organizationNotFound(id).Error() {
+				t.Errorf("expected organization %s to be reported missing, got %v", id, got)
+			}
+
+			get(t, fmt.Sprintf("/organizations/%s/members", id), outsider, http.StatusNotFound)
+		}
+
+		if lists(t, outsider, orgID) {
+			t.Errorf("expected organization %s to be left out of the outsider's listing", orgID)
+		}
+	})
+
+	t.Run("test members still get precise codes", func(t *testing.T) {
+		configs.HideOrganizationExistence = true
+
+		get(t, "/organizations/"+orgID, member, http.StatusOK)
+		get(t, "/organizations/"+deactivatedID, member, http.StatusForbidden)
+		get(t, fmt.Sprintf("/organizations/%s/members", orgID), member, http.StatusOK)
+
+		if !lists(t, member, orgID) {
+			t.Errorf("expected organization %s to be listed for its member", orgID)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/service"
+	"zuri.chat/zccore/utils"
+)
+
+const expiryReminderInterval = time.Hour
+
+// ExpiryReminderScheduler reminds members that their temporary role is about to expire, along
+// with whoever granted it, a set number of days before it does. Each member is reminded once at
+// each of the days.
+type ExpiryReminderScheduler struct {
+	mailService service.MailService
+	days        []int
+	now         func() time.Time
+	interval    time.Duration
+}
+
+func NewExpiryReminderScheduler(mail service.MailService, days []int) *ExpiryReminderScheduler {
+	return &ExpiryReminderScheduler{mailService: mail, days: days, now: time.Now, interval: expiryReminderInterval}
+}
+
+// Run sends due reminders until the context is cancelled.
+func (s *ExpiryReminderScheduler) Run(ctx context.Context) {
+	if len(s.days) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunOnce()
+		}
+	}
+}
+
+// RunOnce sends every reminder that is due now and returns how many members were reminded.
+func (s *ExpiryReminderScheduler) RunOnce() int {
+	now := s.now()
+
+	furthest := 0
+	for _, days := range s.days {
+		if days > furthest {
+			furthest = days
+		}
+	}
+
+	memberDocs, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{
+		"temporary_role_expires_at": bson.M{"$gt": now, "$lte": now.Add(time.Duration(furthest) * 24 * time.Hour)},
+		"deleted":                   bson.M{"$ne": true},
+	})
+	if err != nil {
+		logger.Error("could not fetch members for expiry reminders: %v", err)
+		return 0
+	}
+
+	reminded := 0
+
+	for _, doc := range memberDocs {
+		var member Member
+		if err := utils.BsonToStruct(doc, &member); err != nil {
+			continue
+		}
+
+		due := s.dueReminders(&member, now)
+		if len(due) == 0 || !claimExpiryReminders(&member, due) {
+			continue
+		}
+
+		s.sendExpiryReminder(&member, now)
+		reminded++
+	}
+
+	return reminded
+}
+
+// dueReminders are the days before the member's temporary role expires that have been reached
+// without a reminder. A role granted for less time than several of them is reminded about once.
+func (s *ExpiryReminderScheduler) dueReminders(member *Member, now time.Time) []int {
+	if member.TemporaryRoleExpiresAt == nil {
+		return nil
+	}
+
+	sent := make(map[int]bool, len(member.ExpiryRemindersSent))
+	for _, days := range member.ExpiryRemindersSent {
+		sent[days] = true
+	}
+
+	left := member.TemporaryRoleExpiresAt.Sub(now)
+	due := []int{}
+
+	for _, days := range s.days {
+		if !sent[days] && left <= time.Duration(days)*24*time.Hour {
+			due = append(due, days)
+		}
+	}
+
+	return due
+}
+
+// claimExpiryReminders records the reminders as sent. The update only matches a member not yet
+// reminded at any of them on the same grant, so when several instances run the scheduler just
+// one of them sends the reminder.
+func claimExpiryReminders(member *Member, due []int) bool {
+	memberID, err := primitive.ObjectIDFromHex(member.ID)
+	if err != nil {
+		return false
+	}
+
+	filter := bson.M{
+		"_id":                       memberID,
+		"temporary_role_expires_at": member.TemporaryRoleExpiresAt,
+		"expiry_reminders_sent":     bson.M{"$nin": due},
+	}
+	update := bson.M{"$addToSet": bson.M{"expiry_reminders_sent": bson.M{"$each": due}}}
+
+	res, err := utils.GetCollection(MemberCollectionName).UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		logger.Error("could not claim expiry reminder for member %s: %v", member.ID, err)
+		return false
+	}
+
+	return res.ModifiedCount == 1
+}
+
+// emails the member and whoever granted their temporary role, unless they turned expiry reminders off.
+func (s *ExpiryReminderScheduler) sendExpiryReminder(member *Member, now time.Time) {
+	pOrgID, err := primitive.ObjectIDFromHex(member.OrgID)
+	if err != nil {
+		return
+	}
+
+	org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+	if err != nil {
+		logger.Error("could not fetch organization %s for expiry reminder: %v", member.OrgID, err)
+		return
+	}
+
+	expiresAt := member.TemporaryRoleExpiresAt.UTC()
+	left := expiresAt.Sub(now).Round(time.Hour)
+
+	subject := fmt.Sprintf("A temporary role in %s ends soon", org.Name)
+
+	send := func(email, text string) {
+		msger := s.mailService.NewCustomMail([]string{email}, subject, fmt.Sprintf("<p>%s</p>", html.EscapeString(text)))
+		if err := s.mailService.SendMail(org.sentFor(msger)); err != nil {
+			logger.Error("Error occurred while sending mail: %s", err.Error())
+		}
+	}
+
+	if member.NotificationPreferences.Allows(NotifyExpiryReminders) {
+		send(member.Email, fmt.Sprintf("Your %s role in %s ends on %s, in about %s.",
+			member.TemporaryRole, org.Name, expiresAt.Format(time.RFC1123), left))
+	}
+
+	if granter := member.TemporaryRoleGrantedBy; granter != "" && granter != member.Email &&
+		memberAllowsEmail(context.TODO(), member.OrgID, granter, NotifyExpiryReminders) {
+		send(granter, fmt.Sprintf("The %s role you granted %s in %s ends on %s, in about %s.",
+			member.TemporaryRole, member.Email, org.Name, expiresAt.Format(time.RFC1123), left))
+	}
+}
@@ -0,0 +1,111 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+func TestExpiryReminderScheduler(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	granter := fmt.Sprintf("granter.%s@gmail.com", utils.GenUUID())
+	if _, err = setUpMember(orgID, granter, AdminRole); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+
+	// setUpTemporaryRole adds a member holding a temporary admin role that expires after the given time.
+	setUpTemporaryRole := func(t *testing.T, expiresIn time.Duration) string {
+		email := fmt.Sprintf("temporary.%s@gmail.com", utils.GenUUID())
+
+		memberID, err := setUpMember(orgID, email, MemberRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		update := bson.M{
+			"temporary_role":            AdminRole,
+			"temporary_role_expires_at": now.Add(expiresIn),
+			"temporary_role_granted_by": granter,
+		}
+		if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, memberID, update); err != nil {
+			t.Fatal(err)
+		}
+
+		return email
+	}
+
+	runAt := func(mailer *mockMailService, at time.Time) {
+		scheduler := &ExpiryReminderScheduler{mailService: mailer, days: []int{7, 1}, now: func() time.Time { return at }}
+		scheduler.RunOnce()
+	}
+
+	t.Run("test reminder fires within the window, once", func(t *testing.T) {
+		holder := setUpTemporaryRole(t, 5*24*time.Hour)
+
+		mailer := newMockMailService()
+		runAt(mailer, now)
+
+		if !mailer.sentTo(holder) || !mailer.sentTo(granter) {
+			t.Fatal("expected the holder and granter to be reminded 5 days before expiry")
+		}
+
+		mailer = newMockMailService()
+		runAt(mailer, now.Add(time.Hour))
+
+		if mailer.sentTo(holder) {
+			t.Error("expected the 7 day reminder to be sent once")
+		}
+
+		// the 1 day reminder is its own threshold
+		mailer = newMockMailService()
+		runAt(mailer, now.Add(4*24*time.Hour+time.Hour))
+
+		if !mailer.sentTo(holder) {
+			t.Error("expected a reminder 1 day before expiry")
+		}
+	})
+
+	t.Run("test no reminder outside the window", func(t *testing.T) {
+		holder := setUpTemporaryRole(t, 10*24*time.Hour)
+
+		mailer := newMockMailService()
+		runAt(mailer, now)
+
+		if mailer.sentTo(holder) {
+			t.Error("expected no reminder 10 days before expiry")
+		}
+	})
+
+	t.Run("test members who turned reminders off are not emailed", func(t *testing.T) {
+		holder := setUpTemporaryRole(t, 12*time.Hour)
+
+		prefs := DefaultNotificationPreferences()
+		prefs.ExpiryReminders = false
+
+		if _, err := utils.GetCollection(MemberCollectionName).UpdateOne(context.TODO(),
+			activeMemberFilter(orgID, holder), bson.M{"$set": bson.M{"notification_preferences": prefs}}); err != nil {
+			t.Fatal(err)
+		}
+
+		mailer := newMockMailService()
+		runAt(mailer, now)
+
+		if mailer.sentTo(holder) {
+			t.Error("expected no reminder to a member who turned them off")
+		}
+
+		if !mailer.sentTo(granter) {
+			t.Error("expected the granter to still be reminded")
+		}
+	})
+}
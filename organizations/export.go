@@ -0,0 +1,245 @@
+package organizations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	ExportJSON   = "json"
+	ExportNDJSON = "ndjson"
+	ExportCSV    = "csv"
+)
+
+// exportMediaTypes maps the media types accepted for exports to the format they select.
+var exportMediaTypes = map[string]string{
+	"application/json":     ExportJSON,
+	"application/*":        ExportJSON,
+	"*/*":                  ExportJSON,
+	"application/x-ndjson": ExportNDJSON,
+	"application/ndjson":   ExportNDJSON,
+	"text/csv":             ExportCSV,
+}
+
+// exportContentTypes is the media type of each export format.
+var exportContentTypes = map[string]string{
+	ExportJSON:   "application/json",
+	ExportNDJSON: "application/x-ndjson",
+	ExportCSV:    "text/csv",
+}
+
+// types of the records in an NDJSON export
+const (
+	exportOrganizationRecord = "organization"
+	exportMemberRecord       = "member"
+	exportManifestRecord     = "manifest"
+)
+
+var memberCSVHeader = []string{"id", "email", "user_name", "first_name", "last_name", "display_name", "role", "joined_at", "deleted"}
+
+var errUnsupportedExportFormat = errors.New("unsupported export format, use json, ndjson or csv")
+
+// exportFormat picks the format of an export. The format query parameter takes precedence
+// over the Accept header, whose media types are tried in the order they are listed.
+func exportFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		switch format = strings.ToLower(format); format {
+		case ExportJSON, ExportNDJSON, ExportCSV:
+			return format, nil
+		}
+
+		return "", errUnsupportedExportFormat
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return ExportJSON, nil
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		if format, ok := exportMediaTypes[mediaType]; ok {
+			return format, nil
+		}
+	}
+
+	return "", errUnsupportedExportFormat
+}
+
+// Export an organization and its members as JSON, NDJSON or CSV. CSV exports hold the members only.
+func (oh *OrganizationHandler) ExportOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["id"]
+
+	w.Header().Set("Vary", "Accept")
+
+	format, err := exportFormat(r)
+	if err != nil {
+		utils.GetError(err, http.StatusNotAcceptable, w)
+		return
+	}
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(errors.New("organization does not exist"), http.StatusNotFound, w)
+		return
+	}
+
+	cursor, err := utils.GetCollection(MemberCollectionName).Find(r.Context(), bson.M{"org_id": orgID})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	next := exportedMembers(r.Context(), cursor)
+
+	switch format {
+	case ExportNDJSON:
+		w.Header().Set("Content-Type", exportContentTypes[ExportNDJSON])
+		w.Header().Set("Content-Disposition", exportDisposition(org, "ndjson"))
+		w.WriteHeader(http.StatusOK)
+
+		exportNDJSON(w, org, next)
+	case ExportCSV:
+		w.Header().Set("Content-Type", exportContentTypes[ExportCSV])
+		w.Header().Set("Content-Disposition", exportDisposition(org, "csv"))
+		w.WriteHeader(http.StatusOK)
+
+		exportCSV(w, org, next)
+	default:
+		members := []Member{}
+		for member, ok := next(); ok; member, ok = next() {
+			members = append(members, *member)
+		}
+
+		utils.GetSuccess("organization exported successfully", utils.M{"organization": org, "members": members}, w)
+	}
+}
+
+// exportedMembers reads members from a cursor one at a time, so large organizations are
+// streamed rather than held in memory.
+func exportedMembers(ctx context.Context, cursor *mongo.Cursor) func() (*Member, bool) {
+	return func() (*Member, bool) {
+		for cursor.Next(ctx) {
+			var member Member
+			if err := cursor.Decode(&member); err != nil {
+				logger.Error("could not export member %v: %v", cursor.Current.Lookup("_id"), err)
+				continue
+			}
+
+			return &member, true
+		}
+
+		return nil, false
+	}
+}
+
+// ExportManifest closes an NDJSON export. Its checksum covers every line before it, so an export
+// cut short or changed on the way is caught before it is imported.
+type ExportManifest struct {
+	SchemaVersion int       `json:"schema_version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	OrgID         string    `json:"org_id"`
+	Records       int       `json:"records"`
+	SHA256        string    `json:"sha256"`
+}
+
+// writes one JSON record per line: the organization first, then each member, then the manifest.
+// Exports that fail part way have no manifest.
+func exportNDJSON(w io.Writer, org *Organization, next func() (*Member, bool)) {
+	flusher, _ := w.(http.Flusher)
+	sum := sha256.New()
+	enc := json.NewEncoder(io.MultiWriter(w, sum))
+
+	if err := enc.Encode(utils.M{"type": exportOrganizationRecord, "data": org}); err != nil {
+		return
+	}
+
+	records := 1
+
+	for member, ok := next(); ok; member, ok = next() {
+		if err := enc.Encode(utils.M{"type": exportMemberRecord, "data": member}); err != nil {
+			return
+		}
+
+		records++
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	// organizations from before versioning are version 1
+	version := org.SchemaVersion
+	if version < 1 {
+		version = 1
+	}
+
+	_ = json.NewEncoder(w).Encode(utils.M{"type": exportManifestRecord, "data": ExportManifest{
+		SchemaVersion: version,
+		ExportedAt:    utils.NowUTC(),
+		OrgID:         org.ID,
+		Records:       records,
+		SHA256:        hex.EncodeToString(sum.Sum(nil)),
+	}})
+}
+
+// writes the members of an organization as CSV rows under a header row.
+func exportCSV(w io.Writer, org *Organization, next func() (*Member, bool)) {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	if err := cw.Write(memberCSVHeader); err != nil {
+		return
+	}
+
+	for member, ok := next(); ok; member, ok = next() {
+		row := []string{
+			member.ID,
+			member.Email,
+			member.UserName,
+			member.FirstName,
+			member.LastName,
+			member.DisplayName,
+			member.Role,
+			member.JoinedAt.UTC().Format(time.RFC3339),
+			strconv.FormatBool(member.Deleted),
+		}
+
+		if err := cw.Write(row); err != nil {
+			return
+		}
+	}
+}
+
+func exportDisposition(org *Organization, ext string) string {
+	return fmt.Sprintf(`attachment; filename="organization-%s.%s"`, org.ID, ext)
+}
@@ -0,0 +1,108 @@
+package organizations
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestExportOrganization(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emails := []string{"exportfirst@gmail.com", "exportsecond@gmail.com"}
+	for _, email := range emails {
+		if _, err = setUpMember(orgID, email, MemberRole); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/export", orgs.ExportOrganization).Methods("GET")
+
+	export := func(query, accept string) *http.Response {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/export%s", orgID, query), nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+
+		return getHTTPResponse(t, r, req).Result()
+	}
+
+	t.Run("test json export", func(t *testing.T) {
+		res := export("", "")
+		assertStatusCode(t, res.StatusCode, http.StatusOK)
+
+		var body struct {
+			Data struct {
+				Organization Organization `json:"organization"`
+				Members      []Member     `json:"members"`
+			} `json:"data"`
+		}
+
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if body.Data.Organization.ID != orgID || len(body.Data.Members) != len(emails) {
+			t.Errorf("expected organization %s with %d members, got %s with %d", orgID, len(emails), body.Data.Organization.ID, len(body.Data.Members))
+		}
+	})
+
+	t.Run("test ndjson export has one record per line", func(t *testing.T) {
+		res := export("", "application/x-ndjson")
+		assertStatusCode(t, res.StatusCode, http.StatusOK)
+
+		if ct := res.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Errorf("expected content type application/x-ndjson, got %s", ct)
+		}
+
+		var types []string
+
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			var record struct {
+				Type string          `json:"type"`
+				Data json.RawMessage `json:"data"`
+			}
+
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("line %q is not a JSON record: %v", scanner.Text(), err)
+			}
+
+			types = append(types, record.Type)
+		}
+
+		if fmt.Sprint(types) != "[organization member member manifest]" {
+			t.Errorf("expected the organization followed by its members and the manifest, got %v", types)
+		}
+	})
+
+	t.Run("test csv export of members", func(t *testing.T) {
+		res := export("?format=csv", "application/json")
+		assertStatusCode(t, res.StatusCode, http.StatusOK)
+
+		rows, err := csv.NewReader(res.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rows) != len(emails)+1 || fmt.Sprint(rows[0]) != fmt.Sprint(memberCSVHeader) {
+			t.Fatalf("expected a header and %d member rows, got %v", len(emails), rows)
+		}
+
+		if rows[1][1] != emails[0] || rows[2][1] != emails[1] {
+			t.Errorf("expected member emails %v, got %v", emails, rows[1:])
+		}
+	})
+
+	t.Run("test unknown formats are not acceptable", func(t *testing.T) {
+		assertStatusCode(t, export("?format=xml", "").StatusCode, http.StatusNotAcceptable)
+		assertStatusCode(t, export("", "application/xml").StatusCode, http.StatusNotAcceptable)
+	})
+}
@@ -0,0 +1,395 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	ExportDaily  = "daily"
+	ExportWeekly = "weekly"
+
+	ExportSucceeded = "succeeded"
+	ExportRetrying  = "retrying"
+	ExportFailed    = "failed"
+)
+
+const (
+	exportCheckInterval   = 5 * time.Minute
+	exportDeliveryTimeout = time.Minute
+
+	// a claimed export is not picked up again for this long, in case the instance running it dies
+	exportRunLease = 30 * time.Minute
+
+	// failed exports are retried after this long, doubling each time, until they have been
+	// tried maxExportAttempts times
+	exportRetryDelay  = 10 * time.Minute
+	maxExportAttempts = 5
+)
+
+var exportPeriods = map[string]time.Duration{
+	ExportDaily:  24 * time.Hour,
+	ExportWeekly: 7 * 24 * time.Hour,
+}
+
+// ExportDestination receives scheduled exports.
+type ExportDestination interface {
+	Deliver(ctx context.Context, destinationURL, contentType string, body []byte) error
+}
+
+// HTTPExportDestination uploads exports with a PUT to the destination URL, such as a signed URL
+// to the organization's own storage.
+type HTTPExportDestination struct {
+	Client *http.Client
+}
+
+func (d HTTPExportDestination) Deliver(ctx context.Context, destinationURL, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, destinationURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("destination responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// maskDestination drops the query of a destination URL, where signed URLs keep their signature.
+func maskDestination(destinationURL string) string {
+	u, err := url.Parse(destinationURL)
+	if err != nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s://%s%s", u.Scheme, u.Host, u.Path)
+}
+
+// ExportScheduler delivers the exports organizations have scheduled when they are due, retrying
+// those that fail.
+type ExportScheduler struct {
+	destination ExportDestination
+	now         func() time.Time
+	interval    time.Duration
+}
+
+func NewExportScheduler(destination ExportDestination) *ExportScheduler {
+	return &ExportScheduler{destination: destination, now: utils.NowUTC, interval: exportCheckInterval}
+}
+
+// Run checks for due exports until the context is cancelled.
+func (s *ExportScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RunOnce(ctx)
+		}
+	}
+}
+
+// RunOnce runs every export that is due now and returns how many were delivered.
+func (s *ExportScheduler) RunOnce(ctx context.Context) int {
+	now := s.now()
+
+	orgDocs, err := utils.GetMongoDBDocs(ctx, OrganizationCollectionName, bson.M{"export_schedule.next_run_at": bson.M{"$lte": now}})
+	if err != nil {
+		logger.Error("could not fetch organizations for scheduled exports: %v", err)
+		return 0
+	}
+
+	delivered := 0
+
+	for _, doc := range orgDocs {
+		var org Organization
+		if err := utils.BsonToStruct(doc, &org); err != nil || org.ExportSchedule == nil {
+			continue
+		}
+
+		if !claimExport(&org, now) {
+			continue
+		}
+
+		if s.runExport(ctx, &org, now) {
+			delivered++
+		}
+	}
+
+	return delivered
+}
+
+// claimExport pushes an organization's next export back while it runs. The update only matches
+// the next run read earlier, so when several instances run the scheduler just one of them runs
+// the export.
+func claimExport(org *Organization, now time.Time) bool {
+	pOrgID, err := primitive.ObjectIDFromHex(org.ID)
+	if err != nil {
+		return false
+	}
+
+	res, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(context.TODO(),
+		bson.M{"_id": pOrgID, "export_schedule.next_run_at": org.ExportSchedule.NextRunAt},
+		bson.M{"$set": bson.M{"export_schedule.next_run_at": now.Add(exportRunLease)}})
+	if err != nil {
+		logger.Error("could not claim export of organization %s: %v", org.ID, err)
+		return false
+	}
+
+	return res.ModifiedCount == 1
+}
+
+// renderExport writes an export of an organization and its members in the given format.
+func renderExport(ctx context.Context, org *Organization, format string) ([]byte, error) {
+	cursor, err := utils.GetCollection(MemberCollectionName).Find(ctx, bson.M{"org_id": org.ID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	next := exportedMembers(ctx, cursor)
+
+	var buf bytes.Buffer
+
+	switch format {
+	case ExportNDJSON:
+		exportNDJSON(&buf, org, next)
+	case ExportCSV:
+		exportCSV(&buf, org, next)
+	default:
+		members := []Member{}
+		for member, ok := next(); ok; member, ok = next() {
+			members = append(members, *member)
+		}
+
+		if err := json.NewEncoder(&buf).Encode(utils.M{"organization": org, "members": members}); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), ctx.Err()
+}
+
+// runExport delivers a scheduled export and records how it went on the schedule and in the audit
+// log. It reports whether the export was delivered.
+func (s *ExportScheduler) runExport(ctx context.Context, org *Organization, now time.Time) bool {
+	schedule := org.ExportSchedule
+
+	ctx, cancel := context.WithTimeout(ctx, exportDeliveryTimeout)
+	defer cancel()
+
+	body, err := renderExport(ctx, org, schedule.Format)
+
+	// exports do not use the organization's storage, but none larger than it may hold is sent
+	if err == nil && int64(len(body)) > org.EffectiveStorageQuota() {
+		err = errStorageQuotaExceeded
+	}
+
+	if err == nil {
+		err = s.destination.Deliver(ctx, schedule.DestinationURL, exportContentTypes[schedule.Format], body)
+	}
+
+	set := bson.M{
+		"export_schedule.last_run_at":     now,
+		"export_schedule.last_size_bytes": int64(len(body)),
+	}
+
+	entry := &audit.Log{
+		OrgID:      org.ID,
+		Actor:      "system",
+		Action:     audit.OrganizationExportDelivered,
+		TargetType: "organization",
+		TargetID:   org.ID,
+		Data: map[string]interface{}{
+			"format":      schedule.Format,
+			"destination": maskDestination(schedule.DestinationURL),
+			"size_bytes":  len(body),
+		},
+		CreatedAt: now,
+	}
+
+	switch attempts := schedule.FailedAttempts + 1; {
+	case err == nil:
+		set["export_schedule.last_status"] = ExportSucceeded
+		set["export_schedule.last_error"] = ""
+		set["export_schedule.failed_attempts"] = 0
+		set["export_schedule.next_run_at"] = now.Add(exportPeriods[schedule.Frequency])
+	case attempts < maxExportAttempts && !errors.Is(err, errStorageQuotaExceeded):
+		set["export_schedule.last_status"] = ExportRetrying
+		set["export_schedule.last_error"] = err.Error()
+		set["export_schedule.failed_attempts"] = attempts
+		set["export_schedule.next_run_at"] = now.Add(exportRetryDelay << (attempts - 1))
+	default:
+		// the export is given up on, and the next one is tried as usual
+		set["export_schedule.last_status"] = ExportFailed
+		set["export_schedule.last_error"] = err.Error()
+		set["export_schedule.failed_attempts"] = 0
+		set["export_schedule.next_run_at"] = now.Add(exportPeriods[schedule.Frequency])
+	}
+
+	if err != nil {
+		entry.Action = audit.OrganizationExportFailed
+		entry.Data["error"] = err.Error()
+		entry.Data["attempt"] = schedule.FailedAttempts + 1
+
+		logger.Error("scheduled export of organization %s failed: %v", org.ID, err)
+	}
+
+	if _, err := utils.UpdateOneMongoDBDoc(ctx, OrganizationCollectionName, org.ID, set); err != nil {
+		logger.Error("could not record export of organization %s: %v", org.ID, err)
+	}
+
+	if err := audit.Record(entry); err != nil {
+		logger.Error("could not audit export of organization %s: %v", org.ID, err)
+	}
+
+	return err == nil
+}
+
+// exportScheduleView is an export schedule as it is served, with the destination masked.
+func exportScheduleView(schedule *ExportSchedule) ExportSchedule {
+	view := *schedule
+	view.DestinationURL = maskDestination(schedule.DestinationURL)
+
+	return view
+}
+
+// Set up a recurring export of an organization, delivered daily or weekly to a destination URL.
+// The first export is delivered at the next check.
+func (oh *OrganizationHandler) UpdateExportSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		Frequency      string `json:"frequency"`
+		Format         string `json:"format"`
+		DestinationURL string `json:"destination_url"`
+	}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	frequency := strings.ToLower(body.Frequency)
+	if _, ok := exportPeriods[frequency]; !ok {
+		utils.GetError(errors.New("frequency must be daily or weekly"), http.StatusBadRequest, w)
+		return
+	}
+
+	format := strings.ToLower(body.Format)
+	if format == "" {
+		format = ExportJSON
+	}
+
+	if _, ok := exportContentTypes[format]; !ok {
+		utils.GetError(errUnsupportedExportFormat, http.StatusBadRequest, w)
+		return
+	}
+
+	destination, err := url.Parse(body.DestinationURL)
+	if err != nil || destination.Scheme != "https" || destination.Host == "" {
+		utils.GetError(errors.New("destination_url must be an https URL"), http.StatusBadRequest, w)
+		return
+	}
+
+	schedule := &ExportSchedule{
+		Frequency:      frequency,
+		Format:         format,
+		DestinationURL: destination.String(),
+		NextRunAt:      utils.NowUTC(),
+	}
+
+	if _, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, bson.M{"export_schedule": schedule}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("export schedule updated successfully", exportScheduleView(schedule), w)
+}
+
+// Get the export schedule of an organization and how its last export went.
+func (oh *OrganizationHandler) GetExportSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	if org.ExportSchedule == nil {
+		utils.GetError(errors.New("organization has no export schedule"), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("export schedule retrieved successfully", exportScheduleView(org.ExportSchedule), w)
+}
+
+// Stop the recurring export of an organization.
+func (oh *OrganizationHandler) DeleteExportSchedule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	res, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": pOrgID, "export_schedule": bson.M{"$exists": true}},
+		bson.M{"$unset": bson.M{"export_schedule": ""}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(errors.New("organization has no export schedule"), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("export schedule deleted successfully", nil, w)
+}
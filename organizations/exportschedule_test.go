@@ -0,0 +1,165 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/utils"
+)
+
+// mockExportDestination records the exports delivered to it, and fails while err is set.
+type mockExportDestination struct {
+	deliveries []mockExportDelivery
+	err        error
+}
+
+type mockExportDelivery struct {
+	url, contentType string
+	body             []byte
+}
+
+func (d *mockExportDestination) Deliver(ctx context.Context, destinationURL, contentType string, body []byte) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	d.deliveries = append(d.deliveries, mockExportDelivery{destinationURL, contentType, body})
+
+	return nil
+}
+
+func TestScheduledExports(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = setUpMember(orgID, "exported@gmail.com", MemberRole); err != nil {
+		t.Fatal(err)
+	}
+
+	const destinationURL = "https://storage.example.com/exports/org.csv?signature=s3cret"
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/export-schedule", orgs.UpdateExportSchedule).Methods("PUT")
+	r.HandleFunc("/organizations/{id}/export-schedule", orgs.GetExportSchedule).Methods("GET")
+
+	// status returns the export schedule of the organization as it is served.
+	status := func(t *testing.T) map[string]interface{} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/export-schedule", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		return parseResponse(response)["data"].(map[string]interface{})
+	}
+
+	requestBody := []byte(fmt.Sprintf(`{"frequency": "daily", "format": "csv", "destination_url": %q}`, destinationURL))
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/organizations/%s/export-schedule", orgID), bytes.NewBuffer(requestBody))
+
+	response := getHTTPResponse(t, r, req)
+	assertStatusCode(t, response.Code, http.StatusOK)
+
+	destination := &mockExportDestination{}
+	scheduler := NewExportScheduler(destination)
+
+	now := utils.NowUTC().Add(time.Minute)
+	scheduler.now = func() time.Time { return now }
+
+	t.Run("test a due schedule delivers an export", func(t *testing.T) {
+		if delivered := scheduler.RunOnce(context.TODO()); delivered != 1 {
+			t.Fatalf("expected 1 export to be delivered, got %d", delivered)
+		}
+
+		delivery := destination.deliveries[0]
+		if delivery.url != destinationURL || delivery.contentType != "text/csv" {
+			t.Errorf("unexpected delivery to %s as %s", delivery.url, delivery.contentType)
+		}
+
+		if !strings.Contains(string(delivery.body), "exported@gmail.com") {
+			t.Errorf("expected the export to hold the members, got %q", delivery.body)
+		}
+
+		data := status(t)
+		if data["last_status"] != ExportSucceeded {
+			t.Errorf("expected the last export to have succeeded, got %v", data["last_status"])
+		}
+
+		if data["destination_url"] != "https://storage.example.com/exports/org.csv" {
+			t.Errorf("expected the destination to be served without its signature, got %v", data["destination_url"])
+		}
+
+		if n := utils.CountCollection(context.TODO(), audit.AuditLogCollectionName, bson.M{"org_id": orgID, "action": audit.OrganizationExportDelivered}); n != 1 {
+			t.Errorf("expected the export to be audited once, got %d", n)
+		}
+	})
+
+	t.Run("test a schedule is not run again until it is due", func(t *testing.T) {
+		if delivered := scheduler.RunOnce(context.TODO()); delivered != 0 {
+			t.Errorf("expected no export before the next day, got %d", delivered)
+		}
+	})
+
+	t.Run("test failed exports are retried", func(t *testing.T) {
+		destination.err = errors.New("destination unavailable")
+		now = now.Add(exportPeriods[ExportDaily])
+
+		scheduler.RunOnce(context.TODO())
+
+		data := status(t)
+		if data["last_status"] != ExportRetrying || data["failed_attempts"] != float64(1) || data["last_error"] != "destination unavailable" {
+			t.Errorf("expected the export to be retried after one failure, got %v", data)
+		}
+
+		// the retry is delivered once the destination is back
+		destination.err = nil
+		now = now.Add(exportRetryDelay)
+
+		if delivered := scheduler.RunOnce(context.TODO()); delivered != 1 {
+			t.Fatalf("expected the retry to be delivered, got %d", delivered)
+		}
+
+		if data := status(t); data["last_status"] != ExportSucceeded || data["failed_attempts"] != float64(0) {
+			t.Errorf("expected the retry to have succeeded, got %v", data)
+		}
+
+		if n := utils.CountCollection(context.TODO(), audit.AuditLogCollectionName, bson.M{"org_id": orgID, "action": audit.OrganizationExportFailed}); n != 1 {
+			t.Errorf("expected the failed export to be audited once, got %d", n)
+		}
+	})
+
+	t.Run("test exports larger than the storage quota are not delivered", func(t *testing.T) {
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, bson.M{"storage_quota": 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		now = now.Add(exportPeriods[ExportDaily])
+		before := len(destination.deliveries)
+
+		scheduler.RunOnce(context.TODO())
+
+		if len(destination.deliveries) != before {
+			t.Error("expected the export not to be delivered")
+		}
+
+		if data := status(t); data["last_status"] != ExportFailed {
+			t.Errorf("expected the export to have failed without retrying, got %v", data["last_status"])
+		}
+	})
+
+	t.Run("test destinations must be https", func(t *testing.T) {
+		requestBody := []byte(`{"frequency": "weekly", "destination_url": "http://storage.example.com/exports"}`)
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/organizations/%s/export-schedule", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+}
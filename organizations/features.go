@@ -0,0 +1,192 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+// reconcileFeatureFlags works out an organization's feature flags on a plan. Flags missing from
+// the plan's defaults are not available on it and are off. Available flags take the plan default
+// unless an admin has overridden them. Overrides of unavailable flags are kept, so they apply
+// again if the organization moves back to a plan that has the feature.
+func reconcileFeatureFlags(defaults, overrides map[string]bool) map[string]bool {
+	flags := make(map[string]bool, len(defaults))
+
+	for flag, enabled := range defaults {
+		if override, ok := overrides[flag]; ok {
+			enabled = override
+		}
+
+		flags[flag] = enabled
+	}
+
+	for flag := range overrides {
+		if _, available := defaults[flag]; !available {
+			flags[flag] = false
+		}
+	}
+
+	return flags
+}
+
+func (oh *OrganizationHandler) planFeatureFlags(plan string) (map[string]bool, error) {
+	// organizations created before plans were recorded are on the free plan
+	if plan == "" {
+		plan = FreeVersion
+	}
+
+	defaults, ok := oh.configs.FeatureFlags[plan]
+	if !ok {
+		return nil, fmt.Errorf("no feature flags are configured for the %s plan", plan)
+	}
+
+	return defaults, nil
+}
+
+// changePlan moves an organization to a plan and reconciles its feature flags with the plan.
+// The change is added to the organization's plan history under actor.
+func (oh *OrganizationHandler) changePlan(org *Organization, plan, actor string) (map[string]bool, error) {
+	defaults, err := oh.planFeatureFlags(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := reconcileFeatureFlags(defaults, org.FeatureFlagOverrides)
+
+	if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, org.ID, bson.M{"version": plan, "feature_flags": flags}); err != nil {
+		return nil, err
+	}
+
+	if current := org.currentPlan(); current != plan {
+		recordPlanChange(org.ID, current, plan, actor)
+	}
+
+	org.Version, org.FeatureFlags = plan, flags
+
+	return flags, nil
+}
+
+// Move an organization to another plan without billing it. Feature flags follow the new plan,
+// and moves to a plan the organization's usage does not fit are refused with what to reduce.
+func (oh *OrganizationHandler) UpdatePlan(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		Plan string `json:"plan"`
+	}
+
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	switch body.Plan {
+	case FreeVersion, ProVersion, EnterpriseVersion:
+	default:
+		utils.GetError(errors.New("plan must be one of free, pro or enterprise"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	// moving down a plan waits until the organization's usage fits it
+	memberCount := utils.CountCollection(r.Context(), MemberCollectionName, bson.M{"org_id": orgID, "deleted": bson.M{"$ne": true}})
+	if violations := planViolations(org, body.Plan, memberCount); org.currentPlan() != body.Plan && len(violations) > 0 {
+		utils.GetError(planChangeError(body.Plan, violations), http.StatusConflict, w)
+		return
+	}
+
+	var actor string
+	if loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser); ok {
+		actor = loggedInUser.Email
+	}
+
+	flags, err := oh.changePlan(org, body.Plan, actor)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("organization plan updated successfully", utils.M{"version": body.Plan, "feature_flags": flags}, w)
+}
+
+// Override feature flags of an organization. A null value drops the override, so the
+// flag goes back to its plan default. Flags that are not on the plan cannot be enabled.
+func (oh *OrganizationHandler) UpdateFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var changes map[string]*bool
+	if err = utils.ParseJSONFromRequest(r, &changes); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	defaults, err := oh.planFeatureFlags(org.Version)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	overrides := make(map[string]bool, len(org.FeatureFlagOverrides))
+	for flag, enabled := range org.FeatureFlagOverrides {
+		overrides[flag] = enabled
+	}
+
+	for flag, enabled := range changes {
+		if enabled == nil {
+			delete(overrides, flag)
+			continue
+		}
+
+		if _, available := defaults[flag]; !available {
+			utils.GetError(fmt.Errorf("%s is not available on the %s plan", flag, org.Version), http.StatusForbidden, w)
+			return
+		}
+
+		overrides[flag] = *enabled
+	}
+
+	flags := reconcileFeatureFlags(defaults, overrides)
+
+	update := bson.M{"feature_flags": flags, "feature_flag_overrides": overrides}
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, update); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("feature flags updated successfully", flags, w)
+}
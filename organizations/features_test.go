@@ -0,0 +1,109 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReconcileFeatureFlags(t *testing.T) {
+	defaults := map[string]bool{"announcements": true, "digest": false}
+
+	tests := []struct {
+		name      string
+		overrides map[string]bool
+		expected  map[string]bool
+	}{
+		{"plan defaults", nil, map[string]bool{"announcements": true, "digest": false}},
+		{"override an available flag", map[string]bool{"digest": true}, map[string]bool{"announcements": true, "digest": true}},
+		{"unavailable flags are off", map[string]bool{"webhooks": true}, map[string]bool{"announcements": true, "digest": false, "webhooks": false}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if flags := reconcileFeatureFlags(defaults, tc.overrides); !reflect.DeepEqual(flags, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, flags)
+			}
+		})
+	}
+}
+
+func TestFeatureFlagsFollowPlan(t *testing.T) {
+	requestBody := []byte(fmt.Sprintf(`{"creator_email": "%s"}`, defaultUser))
+	req, _ := http.NewRequest("POST", "/organizations", bytes.NewBuffer(requestBody))
+
+	response := httptest.NewRecorder()
+	orgs.Create(response, withUser(req, defaultUser))
+	assertStatusCode(t, response.Code, http.StatusCreated)
+
+	orgID := parseResponse(response)["data"].(map[string]interface{})["organization_id"].(string)
+
+	flags := func() map[string]bool {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return org.FeatureFlags
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/plan", orgs.UpdatePlan).Methods("PATCH")
+	r.HandleFunc("/organizations/{id}/feature-flags", orgs.UpdateFeatureFlags).Methods("PATCH")
+
+	patch := func(path, body string) int {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/%s", orgID, path), bytes.NewBufferString(body))
+		return getHTTPResponse(t, r, req).Code
+	}
+
+	t.Run("test new organizations get the free plan flags", func(t *testing.T) {
+		if got := flags(); !reflect.DeepEqual(got, configs.FeatureFlags[FreeVersion]) {
+			t.Errorf("expected %v, got %v", configs.FeatureFlags[FreeVersion], got)
+		}
+	})
+
+	t.Run("test pro features cannot be enabled on free", func(t *testing.T) {
+		assertStatusCode(t, patch("feature-flags", `{"webhooks": true}`), http.StatusForbidden)
+	})
+
+	t.Run("test upgrading enables the plan's features", func(t *testing.T) {
+		assertStatusCode(t, patch("plan", `{"plan": "pro"}`), http.StatusOK)
+
+		if got := flags(); !reflect.DeepEqual(got, configs.FeatureFlags[ProVersion]) {
+			t.Errorf("expected %v, got %v", configs.FeatureFlags[ProVersion], got)
+		}
+	})
+
+	t.Run("test overrides survive plan changes", func(t *testing.T) {
+		assertStatusCode(t, patch("feature-flags", `{"announcements": false, "webhooks": true}`), http.StatusOK)
+		assertStatusCode(t, patch("plan", `{"plan": "free"}`), http.StatusOK)
+
+		got := flags()
+		if got["announcements"] || got["webhooks"] {
+			t.Errorf("expected the announcements override to hold and webhooks to be off on free, got %v", got)
+		}
+
+		assertStatusCode(t, patch("plan", `{"plan": "pro"}`), http.StatusOK)
+
+		if got = flags(); got["announcements"] || !got["webhooks"] || !got["digest"] {
+			t.Errorf("expected overrides and pro defaults after upgrading again, got %v", got)
+		}
+	})
+
+	t.Run("test clearing an override restores the plan default", func(t *testing.T) {
+		assertStatusCode(t, patch("feature-flags", `{"announcements": null}`), http.StatusOK)
+
+		if !flags()["announcements"] {
+			t.Error("expected announcements to be back on")
+		}
+	})
+}
@@ -0,0 +1,376 @@
+package organizations
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	maxImportFileSize = 1 << 20
+	maxImportRows     = 1000
+
+	// room for the multipart framing around the uploaded file
+	importFormOverhead = 64 << 10
+)
+
+var errTooManyImportRows = fmt.Errorf("file has more than %d rows", maxImportRows)
+
+const (
+	ImportInvited = "invited"
+	// rows for members already in the organization, whose role is set to the row's
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+	// rows for someone earlier in the file, or a member who already has the role
+	ImportSkipped = "skipped"
+	// rows for emails with a pending invite, which is left as it is unless the import resends it
+	ImportAlreadyInvited = "already_invited"
+	// rows whose pending invite was sent again with a new token
+	ImportResent = "resent"
+)
+
+// ImportRow is the outcome of one row of a member import.
+type ImportRow struct {
+	Line     int
+	Email    string
+	Role     string
+	Status   string
+	InviteID interface{}
+	Error    string
+
+	// set on rows for members already in the organization
+	MemberID     string
+	PreviousRole string
+}
+
+// importResult reports the rows of a member import by email, or by line when a row has no email.
+func importResult(rows []*ImportRow) *utils.BulkResult {
+	result := utils.NewBulkResult()
+
+	for _, row := range rows {
+		item := row.Email
+		if item == "" {
+			item = fmt.Sprintf("line %d", row.Line)
+		}
+
+		data := utils.M{"line": row.Line, "role": row.Role, "status": row.Status}
+
+		switch row.Status {
+		case ImportInvited, ImportResent:
+			data["invite_id"] = row.InviteID
+			result.Succeed(item, data)
+		case ImportAlreadyInvited:
+			data["invite_id"] = row.InviteID
+			result.Skip(item, row.Error, data)
+		case ImportUpdated:
+			data["member_id"], data["previous_role"] = row.MemberID, row.PreviousRole
+			result.Succeed(item, data)
+		case ImportSkipped:
+			result.Skip(item, row.Error, data)
+		default:
+			result.Fail(item, row.Error, data)
+		}
+	}
+
+	return result
+}
+
+// parseImportCSV reads the rows of a member import, one email and an optional role per line.
+// A header row is skipped. Rows that cannot be read are returned as failed rather than
+// failing the whole file.
+func parseImportCSV(scanner *bufio.Scanner) ([]*ImportRow, error) {
+	var rows []*ImportRow
+
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		record, err := csv.NewReader(strings.NewReader(text)).Read()
+		if err != nil {
+			rows = append(rows, &ImportRow{Line: line, Status: ImportFailed, Error: "malformed row"})
+			continue
+		}
+
+		email := strings.ToLower(strings.TrimSpace(record[0]))
+		if len(rows) == 0 && email == "email" {
+			continue
+		}
+
+		row := &ImportRow{Line: line, Email: email, Role: MemberRole}
+		if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+			row.Role = strings.ToLower(strings.TrimSpace(record[1]))
+		}
+
+		if len(record) > 2 {
+			row.Status, row.Error = ImportFailed, "expected an email and an optional role"
+		}
+
+		rows = append(rows, row)
+
+		if len(rows) > maxImportRows {
+			return nil, errTooManyImportRows
+		}
+	}
+
+	return rows, scanner.Err()
+}
+
+// validates a parsed row, marking it failed with the reason when it cannot be imported, or
+// skipped when there is no need to. Rows for members already in the organization get the
+// member's id and role, and update the role rather than invite them.
+func validateImportRow(ctx context.Context, row *ImportRow, orgID string, seen map[string]bool) {
+	if row.Status == ImportFailed {
+		return
+	}
+
+	switch _, validRole := Roles[row.Role]; {
+	case !utils.IsValidEmail(row.Email):
+		row.Status, row.Error = ImportFailed, "invalid email address"
+	case !validRole:
+		row.Status, row.Error = ImportFailed, fmt.Sprintf("invalid role %q", row.Role)
+	case seen[row.Email]:
+		row.Status, row.Error = ImportSkipped, "email appears earlier in the file"
+	}
+
+	seen[row.Email] = true
+
+	if row.Status != "" {
+		return
+	}
+
+	memberDoc, _ := utils.GetMongoDBDoc(ctx, MemberCollectionName, bson.M{"org_id": orgID, "email": row.Email})
+
+	switch {
+	case memberDoc == nil && row.Role == OwnerRole:
+		row.Status, row.Error = ImportFailed, "owners cannot be invited, add them as owners once they join"
+	case memberDoc == nil:
+		return
+	case memberDoc["deleted"] == true:
+		row.Status, row.Error = ImportSkipped, "user was removed from this organization"
+	case memberDoc["role"] == row.Role:
+		row.Status, row.Error = ImportSkipped, fmt.Sprintf("member is already %s", row.Role)
+	default:
+		row.MemberID = memberDoc["_id"].(primitive.ObjectID).Hex()
+		row.PreviousRole, _ = memberDoc["role"].(string)
+	}
+}
+
+// demotesOwner reports whether a row takes the owner role away from a member.
+func demotesOwner(org *Organization, row *ImportRow) bool {
+	return row.MemberID != "" && row.Role != OwnerRole && (row.PreviousRole == OwnerRole || isOwner(org, row.MemberID))
+}
+
+// keepAnOwner fails every row demoting an owner when the file as a whole, counting the owners
+// it adds, would leave the organization without one.
+func keepAnOwner(org *Organization, rows []*ImportRow) {
+	owners := len(org.Owners)
+
+	var demotions []*ImportRow
+
+	for _, row := range rows {
+		switch {
+		case row.Status != "" || row.MemberID == "":
+			continue
+		case demotesOwner(org, row):
+			owners--
+
+			demotions = append(demotions, row)
+		case row.Role == OwnerRole && !isOwner(org, row.MemberID):
+			owners++
+		}
+	}
+
+	if owners > 0 {
+		return
+	}
+
+	for _, row := range demotions {
+		row.Status, row.Error = ImportFailed, errLastOwner.Error()
+	}
+}
+
+// importRole sets the role of a member already in the organization, keeping the owners set in
+// line with it as UpdateMemberRole does, and marks the row updated or failed.
+func importRole(ctx context.Context, org *Organization, row *ImportRow) {
+	var err error
+
+	if row.Role == OwnerRole {
+		err = addOwner(org.ID, row.MemberID)
+	} else if demotesOwner(org, row) {
+		err = removeOwner(org.ID, row.MemberID)
+	}
+
+	if err == nil {
+		_, err = utils.UpdateOneMongoDBDoc(ctx, MemberCollectionName, row.MemberID, bson.M{"role": row.Role})
+	}
+
+	if err != nil {
+		row.Status, row.Error = ImportFailed, err.Error()
+		return
+	}
+
+	row.Status = ImportUpdated
+
+	eventChannel := fmt.Sprintf("organizations_%s", org.ID)
+	event := utils.Event{Identifier: row.MemberID, Type: "User", Event: UpdateOrganizationMemberRole, Channel: eventChannel, Payload: make(map[string]interface{})}
+
+	go utils.Emitter(event)
+}
+
+// importPendingInvite handles a row for an email that already has a pending invite, which is
+// reported rather than sent a second one. With resend the invite gets a new token and is
+// emailed again instead, unless it was sent too recently.
+func (oh *OrganizationHandler) importPendingInvite(ctx context.Context, org *Organization, row *ImportRow, invite *Invite, resend bool, inviterEmail string, expiresAt time.Time) {
+	row.InviteID = invite.ID
+
+	if !resend {
+		row.Status, row.Error = ImportAlreadyInvited, "email already has a pending invite"
+		return
+	}
+
+	if oh.inviteResendWait(invite, utils.NowUTC()) > 0 {
+		row.Status, row.Error = ImportAlreadyInvited, errInviteSentRecently.Error()
+		return
+	}
+
+	if err := oh.rotateInvite(ctx, org, invite, inviterEmail, expiresAt); err != nil {
+		row.Status, row.Error = ImportFailed, err.Error()
+		return
+	}
+
+	row.Status = ImportResent
+}
+
+// Invite the members listed in an uploaded CSV file, or set the role of those already in the
+// organization. Each row holds an email and an optional role; the response reports every row
+// as invited, updated, failed or skipped. Emails with a pending invite are reported as
+// already_invited, or sent it again with a new token when resend=true. The file cannot leave
+// the organization without an owner.
+func (oh *OrganizationHandler) ImportMembersCSV(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value("user").(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(errors.New("organization does not exist"), http.StatusNotFound, w)
+		return
+	}
+
+	if _, err = organizationOwners(r.Context(), org); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize+importFormOverhead)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		utils.GetError(fmt.Errorf("could not read the uploaded file: %v", err), http.StatusBadRequest, w)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxImportFileSize {
+		utils.GetError(fmt.Errorf("file is larger than %d bytes", maxImportFileSize), http.StatusRequestEntityTooLarge, w)
+		return
+	}
+
+	resend, _ := strconv.ParseBool(r.FormValue("resend"))
+
+	rows, err := parseImportCSV(bufio.NewScanner(file))
+	if errors.Is(err, errTooManyImportRows) {
+		utils.GetError(err, http.StatusRequestEntityTooLarge, w)
+		return
+	}
+
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	lifetime, err := oh.inviteLifetime(org.InviteExpiryHours, 0)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	expiresAt := utils.NowUTC().Add(lifetime)
+
+	seen := make(map[string]bool)
+
+	for _, row := range rows {
+		validateImportRow(r.Context(), row, orgID, seen)
+	}
+
+	keepAnOwner(org, rows)
+
+	// owners are demoted last, once the owners the file adds are in
+	var demotions []*ImportRow
+
+	for _, row := range rows {
+		switch {
+		case row.Status != "":
+			continue
+		case demotesOwner(org, row):
+			demotions = append(demotions, row)
+		case row.MemberID != "":
+			importRole(r.Context(), org, row)
+		default:
+			pending, err := pendingInvite(r.Context(), orgID, row.Email, utils.NowUTC())
+			if err != nil {
+				row.Status, row.Error = ImportFailed, err.Error()
+				continue
+			}
+
+			if pending != nil {
+				oh.importPendingInvite(r.Context(), org, row, pending, resend, loggedInUser.Email, expiresAt)
+				continue
+			}
+
+			invite, err := oh.inviteGuest(org, row.Email, row.Role, loggedInUser.Email, expiresAt)
+			if err != nil {
+				row.Status, row.Error = ImportFailed, err.Error()
+				continue
+			}
+
+			row.Status, row.InviteID = ImportInvited, invite.ID
+		}
+	}
+
+	for _, row := range demotions {
+		importRole(r.Context(), org, row)
+	}
+
+	utils.GetBulkResult("members import result", importResult(rows), w)
+}
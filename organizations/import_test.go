@@ -0,0 +1,335 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+// csvUpload builds an import request body holding the given CSV file.
+func csvUpload(t *testing.T, content string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", "members.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part.Write([]byte(content))
+	writer.Close()
+
+	return body, writer.FormDataContentType()
+}
+
+func TestImportMembersCSV(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = setUpMember(orgID, "importexisting@gmail.com", MemberRole); err != nil {
+		t.Fatal(err)
+	}
+
+	mailer := newMockMailService()
+	handler := NewOrganizationHandler(configs, mailer)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/import-members", handler.ImportMembersCSV).Methods("POST")
+
+	file := "email,role\n" +
+		"importfirst@gmail.com\n" +
+		"importadmin@gmail.com,admin\n" +
+		"not-an-email,member\n" +
+		"importbadrole@gmail.com,captain\n" +
+		"\n" +
+		"importfirst@gmail.com,guest\n" +
+		"importexisting@gmail.com\n" +
+		"\"importquote@gmail.com,member\n" +
+		"importowner@gmail.com,owner\n"
+
+	body, contentType := csvUpload(t, file)
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/import-members", orgID), body)
+	req.Header.Set("Content-Type", contentType)
+
+	response := getHTTPResponse(t, r, withUser(req, defaultUser))
+	assertStatusCode(t, response.Code, http.StatusMultiStatus)
+
+	data := parseResponse(response)["data"].(map[string]interface{})
+
+	// line numbers count the header and the blank line
+	expected := map[float64]string{
+		2:  "succeeded",
+		3:  "succeeded",
+		4:  "failed",
+		5:  "failed",
+		7:  "skipped",
+		8:  "skipped",
+		9:  "failed",
+		10: "failed",
+	}
+
+	got := map[float64]string{}
+
+	for _, section := range []string{"succeeded", "failed", "skipped"} {
+		for _, item := range data[section].([]interface{}) {
+			item := item.(map[string]interface{})
+			got[item["data"].(map[string]interface{})["line"].(float64)] = section
+		}
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d rows, got %d: %v", len(expected), len(got), data)
+	}
+
+	for line, section := range expected {
+		if got[line] != section {
+			t.Errorf("line %v: expected %s, got %s", line, section, got[line])
+		}
+	}
+
+	invite, _ := utils.GetMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"org_id": orgID, "email": "importadmin@gmail.com"})
+	if invite == nil || invite["role"] != AdminRole {
+		t.Errorf("expected an admin invite for importadmin@gmail.com, got %v", invite)
+	}
+
+	if !mailer.sentTo("importfirst@gmail.com") || mailer.sentTo("not-an-email") {
+		t.Error("expected invites to be mailed to valid rows only")
+	}
+
+	t.Run("test too many rows", func(t *testing.T) {
+		var b bytes.Buffer
+		for i := 0; i <= maxImportRows; i++ {
+			fmt.Fprintf(&b, "bulk%d@gmail.com\n", i)
+		}
+
+		body, contentType := csvUpload(t, b.String())
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/import-members", orgID), body)
+		req.Header.Set("Content-Type", contentType)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusRequestEntityTooLarge)
+	})
+}
+
+func TestImportMembersCSVUpdatesRoles(t *testing.T) {
+	mailer := newMockMailService()
+	handler := NewOrganizationHandler(configs, mailer)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/import-members", handler.ImportMembersCSV).Methods("POST")
+
+	// importCSV imports the file into the organization and returns the status of each row by line.
+	importCSV := func(t *testing.T, orgID, file string) map[float64]string {
+		body, contentType := csvUpload(t, file)
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/import-members", orgID), body)
+		req.Header.Set("Content-Type", contentType)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		statuses := map[float64]string{}
+
+		for _, section := range []string{"succeeded", "failed", "skipped"} {
+			for _, item := range data[section].([]interface{}) {
+				row := item.(map[string]interface{})["data"].(map[string]interface{})
+				statuses[row["line"].(float64)] = row["status"].(string)
+			}
+		}
+
+		return statuses
+	}
+
+	roleOf := func(orgID, email string) interface{} {
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": email})
+		return doc["role"]
+	}
+
+	// setUpMembers adds members with the given roles by email to a new organization.
+	setUpMembers := func(t *testing.T, roles map[string]string) string {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for email, role := range roles {
+			if _, err = setUpMember(orgID, email, role); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return orgID
+	}
+
+	t.Run("test new members are invited and existing ones updated", func(t *testing.T) {
+		orgID := setUpMembers(t, map[string]string{
+			"upsertowner@gmail.com":  OwnerRole,
+			"upsertmember@gmail.com": MemberRole,
+			"upsertadmin@gmail.com":  AdminRole,
+			"upsertsame@gmail.com":   AdminRole,
+		})
+
+		statuses := importCSV(t, orgID, "email,role\n"+
+			"upsertnew@gmail.com,admin\n"+
+			"upsertmember@gmail.com,admin\n"+
+			"upsertadmin@gmail.com,guest\n"+
+			"upsertsame@gmail.com,admin\n"+
+			"upsertmember@gmail.com,owner\n")
+
+		expected := map[float64]string{2: ImportInvited, 3: ImportUpdated, 4: ImportUpdated, 5: ImportSkipped, 6: ImportSkipped}
+		for line, status := range expected {
+			if statuses[line] != status {
+				t.Errorf("line %v: expected %s, got %s", line, status, statuses[line])
+			}
+		}
+
+		if role := roleOf(orgID, "upsertmember@gmail.com"); role != AdminRole {
+			t.Errorf("expected upsertmember@gmail.com to be an admin, got %v", role)
+		}
+
+		if role := roleOf(orgID, "upsertadmin@gmail.com"); role != GuestRole {
+			t.Errorf("expected upsertadmin@gmail.com to be a guest, got %v", role)
+		}
+
+		if invite, _ := utils.GetMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"org_id": orgID, "email": "upsertmember@gmail.com"}); invite != nil {
+			t.Error("expected existing members not to be invited again")
+		}
+
+		if !mailer.sentTo("upsertnew@gmail.com") {
+			t.Error("expected the new member to be invited")
+		}
+	})
+
+	t.Run("test the file cannot remove the last owner", func(t *testing.T) {
+		orgID := setUpMembers(t, map[string]string{"lastowner@gmail.com": OwnerRole})
+
+		statuses := importCSV(t, orgID, "lastowner@gmail.com,admin\n")
+
+		if statuses[1] != ImportFailed {
+			t.Errorf("expected demoting the last owner to fail, got %s", statuses[1])
+		}
+
+		if role := roleOf(orgID, "lastowner@gmail.com"); role != OwnerRole {
+			t.Errorf("expected the last owner to stay an owner, got %v", role)
+		}
+	})
+
+	t.Run("test the owner can be handed over within the file", func(t *testing.T) {
+		orgID := setUpMembers(t, map[string]string{
+			"outgoingowner@gmail.com": OwnerRole,
+			"incomingowner@gmail.com": AdminRole,
+		})
+
+		// the demotion comes first in the file, but the new owner is added before it is applied
+		statuses := importCSV(t, orgID, "outgoingowner@gmail.com,admin\nincomingowner@gmail.com,owner\n")
+
+		if statuses[1] != ImportUpdated || statuses[2] != ImportUpdated {
+			t.Fatalf("expected both rows to be updated, got %v", statuses)
+		}
+
+		org, err := fetchOrganizationWithOwners(context.TODO(), orgID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(org.Owners) != 1 || roleOf(orgID, "incomingowner@gmail.com") != OwnerRole {
+			t.Errorf("expected incomingowner@gmail.com to be the only owner, got %v", org.Owners)
+		}
+	})
+}
+
+func TestImportMembersCSVPendingInvites(t *testing.T) {
+	defer func(interval time.Duration) { configs.InviteResendInterval = interval }(configs.InviteResendInterval)
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/import-members", orgs.ImportMembersCSV).Methods("POST")
+
+	// importCSV imports the file and returns the status of its only row.
+	importCSV := func(t *testing.T, query, file string) string {
+		body, contentType := csvUpload(t, file)
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/import-members%s", orgID, query), body)
+		req.Header.Set("Content-Type", contentType)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		for _, section := range []string{"succeeded", "failed", "skipped"} {
+			for _, item := range data[section].([]interface{}) {
+				return item.(map[string]interface{})["data"].(map[string]interface{})["status"].(string)
+			}
+		}
+
+		t.Fatalf("expected a row in %v", data)
+
+		return ""
+	}
+
+	// invites returns the tokens of the invites to the email.
+	invites := func(t *testing.T, email string) []string {
+		docs, err := utils.GetMongoDBDocs(context.TODO(), OrganizationInviteCollectionName, bson.M{"org_id": orgID, "email": email})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tokens := []string{}
+		for _, doc := range docs {
+			tokens = append(tokens, doc["uuid"].(string))
+		}
+
+		return tokens
+	}
+
+	email := fmt.Sprintf("pending.%s@gmail.com", utils.GenUUID())
+
+	if status := importCSV(t, "", email+"\n"); status != ImportInvited {
+		t.Fatalf("expected the first import to invite %s, got %s", email, status)
+	}
+
+	first := invites(t, email)
+
+	t.Run("test pending invites are not sent again", func(t *testing.T) {
+		if status := importCSV(t, "", strings.ToUpper(email)+"\n"); status != ImportAlreadyInvited {
+			t.Errorf("expected the row to be reported already invited, got %s", status)
+		}
+
+		if got := invites(t, email); len(got) != 1 || got[0] != first[0] {
+			t.Errorf("expected the one invite to be left as it was, got %v", got)
+		}
+	})
+
+	t.Run("test resend rotates the pending invite", func(t *testing.T) {
+		configs.InviteResendInterval = time.Hour
+
+		if status := importCSV(t, "?resend=true", email+"\n"); status != ImportAlreadyInvited {
+			t.Errorf("expected an invite sent moments ago not to be resent, got %s", status)
+		}
+
+		configs.InviteResendInterval = 0
+
+		if status := importCSV(t, "?resend=true", email+"\n"); status != ImportResent {
+			t.Errorf("expected the invite to be resent, got %s", status)
+		}
+
+		if got := invites(t, email); len(got) != 1 || got[0] == first[0] {
+			t.Errorf("expected the one invite to get a new token, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// largest NDJSON export ImportOrganization takes
+const maxImportArchiveSize = 64 << 20
+
+var (
+	errArchiveNoManifest = errors.New("archive has no manifest, it may have been cut short")
+	errArchiveChecksum   = errors.New("archive checksum does not match its manifest, it may be corrupted")
+)
+
+// exportArchive is an NDJSON export read back, once its checksum is verified.
+type exportArchive struct {
+	Manifest     ExportManifest
+	Organization Organization
+	Members      []Member
+}
+
+type exportRecord struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// parseExportArchive verifies an NDJSON export against the checksum in its manifest and reads
+// the organization and members in it.
+func parseExportArchive(body []byte) (*exportArchive, error) {
+	body = bytes.TrimRight(body, "\n")
+
+	// the manifest is the last line, and the checksum covers the lines before it
+	content, manifestLine := []byte{}, body
+	if i := bytes.LastIndexByte(body, '\n'); i >= 0 {
+		content, manifestLine = body[:i+1], body[i+1:]
+	}
+
+	var record exportRecord
+	if err := json.Unmarshal(manifestLine, &record); err != nil || record.Type != exportManifestRecord {
+		return nil, errArchiveNoManifest
+	}
+
+	archive := &exportArchive{}
+	if err := json.Unmarshal(record.Data, &archive.Manifest); err != nil {
+		return nil, errArchiveNoManifest
+	}
+
+	sum := sha256.Sum256(content)
+	if hex.EncodeToString(sum[:]) != archive.Manifest.SHA256 {
+		return nil, errArchiveChecksum
+	}
+
+	if archive.Manifest.SchemaVersion > CurrentSchemaVersion {
+		return nil, fmt.Errorf("archive has schema version %d, newer than %d", archive.Manifest.SchemaVersion, CurrentSchemaVersion)
+	}
+
+	lines := bytes.Split(bytes.TrimRight(content, "\n"), []byte("\n"))
+	if len(lines) != archive.Manifest.Records {
+		return nil, fmt.Errorf("archive has %d records, its manifest says %d", len(lines), archive.Manifest.Records)
+	}
+
+	for i, line := range lines {
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("record %d: %v", i+1, err)
+		}
+
+		var err error
+
+		switch {
+		case i == 0 && record.Type == exportOrganizationRecord:
+			err = json.Unmarshal(record.Data, &archive.Organization)
+		case i > 0 && record.Type == exportMemberRecord:
+			var member Member
+			if err = json.Unmarshal(record.Data, &member); err == nil {
+				archive.Members = append(archive.Members, member)
+			}
+		default:
+			err = fmt.Errorf("unexpected %q record", record.Type)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %v", i+1, err)
+		}
+	}
+
+	if archive.Organization.ID != archive.Manifest.OrgID {
+		return nil, errors.New("archive organization does not match its manifest")
+	}
+
+	for _, member := range archive.Members {
+		if member.OrgID != archive.Manifest.OrgID {
+			return nil, fmt.Errorf("member %s is not in the archived organization", member.ID)
+		}
+	}
+
+	return archive, nil
+}
+
+// importDoc turns an archived record into a document with its original object id.
+func importDoc(v interface{}, id string) (bson.M, error) {
+	pID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id %q", id)
+	}
+
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bson.M
+	if err = bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+
+	doc["_id"] = pID
+
+	return doc, nil
+}
+
+// Restore an organization and its members from an NDJSON export, with the ids they had. The
+// archive is checked against the checksum in its manifest first, and corrupted or truncated
+// archives are refused. Webhooks are left out, as their secrets are never exported.
+func (oh *OrganizationHandler) ImportOrganization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxImportArchiveSize))
+	if err != nil {
+		utils.GetError(fmt.Errorf("archive is larger than %d bytes", maxImportArchiveSize), http.StatusRequestEntityTooLarge, w)
+		return
+	}
+
+	archive, err := parseExportArchive(body)
+	if err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	org := archive.Organization
+	org.Webhooks = nil
+	org.SchemaVersion = archive.Manifest.SchemaVersion
+
+	orgDoc, err := importDoc(org, org.ID)
+	if err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	members := make([]interface{}, 0, len(archive.Members))
+
+	for i := range archive.Members {
+		doc, err := importDoc(archive.Members[i], archive.Members[i].ID)
+		if err != nil {
+			utils.GetError(err, http.StatusUnprocessableEntity, w)
+			return
+		}
+
+		members = append(members, doc)
+	}
+
+	coll := utils.GetCollectionWithWriteConcern(OrganizationCollectionName, utils.WriteConcern(utils.WriteCritical))
+
+	if _, err = coll.InsertOne(r.Context(), orgDoc); err != nil {
+		if utils.IsDuplicateKeyError(err) {
+			utils.GetError(fmt.Errorf("organization %s already exists", org.ID), http.StatusConflict, w)
+			return
+		}
+
+		utils.GetError(err, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	if len(members) > 0 {
+		if _, err = utils.GetCollection(MemberCollectionName).InsertMany(r.Context(), members); err != nil {
+			// leave nothing half imported behind
+			if _, derr := coll.DeleteOne(context.TODO(), bson.M{"_id": orgDoc["_id"]}); derr != nil {
+				logger.Error("could not remove partly imported organization %s: %v", org.ID, derr)
+			}
+
+			if _, derr := utils.GetCollection(MemberCollectionName).DeleteMany(context.TODO(), bson.M{"org_id": org.ID}); derr != nil {
+				logger.Error("could not remove partly imported members of %s: %v", org.ID, derr)
+			}
+
+			utils.GetWriteError(err, w)
+
+			return
+		}
+	}
+
+	utils.GetCreated("organization imported successfully", fmt.Sprintf("/organizations/%s", org.ID), utils.M{
+		"organization_id": org.ID,
+		"members":         len(members),
+		"schema_version":  archive.Manifest.SchemaVersion,
+		"exported_at":     archive.Manifest.ExportedAt,
+	}, w)
+}
@@ -0,0 +1,110 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestImportOrganization(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emails := []string{"archivefirst@gmail.com", "archivesecond@gmail.com"}
+	for _, email := range emails {
+		if _, err = setUpMember(orgID, email, MemberRole); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/export", orgs.ExportOrganization).Methods("GET")
+	r.HandleFunc("/organizations/import", orgs.ImportOrganization).Methods("POST")
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/export?format=ndjson", orgID), nil)
+
+	response := getHTTPResponse(t, r, req)
+	assertStatusCode(t, response.Code, http.StatusOK)
+
+	archive := response.Body.Bytes()
+
+	importArchive := func(t *testing.T, body []byte, code int) map[string]interface{} {
+		req, _ := http.NewRequest("POST", "/organizations/import", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, code)
+
+		return parseResponse(response)
+	}
+
+	t.Run("test the manifest covers the archive", func(t *testing.T) {
+		parsed, err := parseExportArchive(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed.Manifest.SchemaVersion < 1 || parsed.Manifest.ExportedAt.IsZero() {
+			t.Errorf("expected the manifest to carry the schema version and export time, got %+v", parsed.Manifest)
+		}
+
+		if parsed.Organization.ID != orgID || len(parsed.Members) != len(emails) {
+			t.Errorf("expected organization %s with %d members, got %s with %d", orgID, len(emails), parsed.Organization.ID, len(parsed.Members))
+		}
+	})
+
+	t.Run("test tampered and truncated archives are refused", func(t *testing.T) {
+		tampered := bytes.Replace(archive, []byte(emails[0]), []byte("intruder@gmail.com"), 1)
+		if bytes.Equal(tampered, archive) {
+			t.Fatal("expected the archive to hold the member's email")
+		}
+
+		if got := importArchive(t, tampered, http.StatusUnprocessableEntity)["message"]; got != errArchiveChecksum.Error() {
+			t.Errorf("expected a checksum error, got %v", got)
+		}
+
+		truncated := archive[:bytes.LastIndexByte(bytes.TrimRight(archive, "\n"), '\n')+1]
+		if got := importArchive(t, truncated, http.StatusUnprocessableEntity)["message"]; got != errArchiveNoManifest.Error() {
+			t.Errorf("expected a missing manifest error, got %v", got)
+		}
+
+		if n := utils.CountCollection(context.TODO(), MemberCollectionName, bson.M{"email": "intruder@gmail.com"}); n != 0 {
+			t.Error("expected nothing from a tampered archive to be imported")
+		}
+	})
+
+	t.Run("test an intact archive restores the organization", func(t *testing.T) {
+		importArchive(t, archive, http.StatusConflict)
+
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		if _, err := utils.GetCollection(OrganizationCollectionName).DeleteOne(context.TODO(), bson.M{"_id": pOrgID}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := utils.GetCollection(MemberCollectionName).DeleteMany(context.TODO(), bson.M{"org_id": orgID}); err != nil {
+			t.Fatal(err)
+		}
+
+		data := importArchive(t, archive, http.StatusCreated)["data"].(map[string]interface{})
+		if data["organization_id"] != orgID || data["members"] != float64(len(emails)) {
+			t.Errorf("expected %s to be imported with %d members, got %v", orgID, len(emails), data)
+		}
+
+		if _, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID}); err != nil {
+			t.Errorf("expected the organization to be restored: %v", err)
+		}
+
+		if n := utils.CountCollection(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID}); n != int64(len(emails)) {
+			t.Errorf("expected %d members to be restored, got %d", len(emails), n)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+// activityWindow is how long a recorded heartbeat stands before a new one is written, so
+// members sending them every few seconds do not cause a write each time.
+const activityWindow = 5 * time.Minute
+
+// inactiveMembersFilter matches the members of an organization whose time in field, last_login_at
+// or last_active_at, is before the cutoff, including those who have none.
+func inactiveMembersFilter(orgID, field string, cutoff time.Time) bson.M {
+	return bson.M{
+		"org_id":  orgID,
+		"deleted": bson.M{"$ne": true},
+		"$or": []bson.M{
+			{field: nil},
+			{field: bson.M{"$lt": cutoff}},
+		},
+	}
+}
+
+// RecordMemberActivity sets last_active_at on the member with the given email in an
+// organization, unless activity was recorded within the last few minutes. It reports whether
+// the activity was written.
+func RecordMemberActivity(ctx context.Context, orgID, email string, now time.Time) (bool, error) {
+	filter := bson.M{
+		"org_id":  orgID,
+		"email":   email,
+		"deleted": bson.M{"$ne": true},
+		"$or": []bson.M{
+			{"last_active_at": nil},
+			{"last_active_at": bson.M{"$lte": now.Add(-activityWindow)}},
+		},
+	}
+
+	res, err := utils.GetCollection(MemberCollectionName).UpdateOne(ctx, filter, bson.M{"$set": bson.M{"last_active_at": now}})
+	if err != nil {
+		return false, err
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+// Record that the logged in member is active in an organization. Clients call this every so
+// often while the organization is open.
+func (oh *OrganizationHandler) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("user not logged in"), http.StatusUnauthorized, w)
+		return
+	}
+
+	recorded, err := RecordMemberActivity(r.Context(), orgID, loggedInUser.Email, utils.NowUTC())
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("activity recorded", utils.M{"recorded": recorded}, w)
+}
+
+// List the members of an organization who have not logged in recently, longest inactive
+// first. The days query parameter overrides the configured window, and by=activity lists
+// members by their last activity in this organization rather than their last login.
+func (oh *OrganizationHandler) GetInactiveMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	window := oh.configs.InactiveMemberWindow
+
+	if days := r.URL.Query().Get("days"); days != "" {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			utils.GetError(errors.New("days must be a positive number"), http.StatusBadRequest, w)
+			return
+		}
+
+		window = time.Duration(n) * 24 * time.Hour
+	}
+
+	field := "last_login_at"
+
+	switch by := r.URL.Query().Get("by"); by {
+	case "", "login":
+	case "activity":
+		field = "last_active_at"
+	default:
+		utils.GetError(fmt.Errorf("unknown by %q, use login or activity", by), http.StatusBadRequest, w)
+		return
+	}
+
+	cutoff := utils.NowUTC().Add(-window)
+	opts := options.Find().SetSort(bson.D{{Key: field, Value: 1}, {Key: "_id", Value: 1}})
+
+	members, err := utils.GetMongoDBDocs(r.Context(), MemberCollectionName, inactiveMembersFilter(orgID, field, cutoff), opts)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("inactive members retrieved successfully", utils.M{
+		"inactive_since": cutoff,
+		"members":        members,
+	}, w)
+}
@@ -0,0 +1,179 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"zuri.chat/zccore/utils"
+)
+
+func TestGetInactiveMembers(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logins := map[string]time.Duration{
+		"recentlogin@gmail.com": 36 * time.Hour,
+		"oldlogin@gmail.com":    configs.InactiveMemberWindow + 24*time.Hour,
+	}
+
+	for email, ago := range logins {
+		memberID, err := setUpMember(orgID, email, MemberRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, memberID, map[string]interface{}{"last_login_at": time.Now().Add(-ago)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := setUpMember(orgID, "neverloggedin@gmail.com", MemberRole); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members/inactive", orgs.GetInactiveMembers).Methods("GET")
+
+	// inactive returns the emails of the members listed as inactive for the query.
+	inactive := func(t *testing.T, query string) map[string]bool {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members/inactive%s", orgID, query), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		emails := make(map[string]bool)
+		for _, member := range data["members"].([]interface{}) {
+			emails[member.(map[string]interface{})["email"].(string)] = true
+		}
+
+		return emails
+	}
+
+	t.Run("test members outside the configured window are inactive", func(t *testing.T) {
+		emails := inactive(t, "")
+
+		for email, want := range map[string]bool{
+			"oldlogin@gmail.com":      true,
+			"neverloggedin@gmail.com": true,
+			"recentlogin@gmail.com":   false,
+		} {
+			if emails[email] != want {
+				t.Errorf("expected %s inactive to be %v", email, want)
+			}
+		}
+	})
+
+	t.Run("test the window can be narrowed", func(t *testing.T) {
+		if emails := inactive(t, "?days=1"); !emails["recentlogin@gmail.com"] {
+			t.Error("expected a member who last logged in 36 hours ago to be inactive over one day")
+		}
+	})
+
+	t.Run("test invalid days are rejected", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members/inactive?days=none", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+}
+
+func TestRecordMemberActivity(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherOrgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const email = "heartbeat@gmail.com"
+
+	for _, id := range []string{orgID, otherOrgID} {
+		if _, err := setUpMember(id, email, MemberRole); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := setUpMember(orgID, "idle@gmail.com", MemberRole); err != nil {
+		t.Fatal(err)
+	}
+
+	now := utils.NowUTC()
+
+	t.Run("test activity writes are throttled", func(t *testing.T) {
+		for _, heartbeat := range []struct {
+			at   time.Time
+			want bool
+		}{
+			{now, true},
+			{now.Add(time.Minute), false},
+			{now.Add(activityWindow), true},
+		} {
+			recorded, err := RecordMemberActivity(context.TODO(), orgID, email, heartbeat.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if recorded != heartbeat.want {
+				t.Errorf("heartbeat at %v: expected recorded %v, got %v", heartbeat.at, heartbeat.want, recorded)
+			}
+		}
+	})
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/heartbeat", orgs.Heartbeat).Methods("POST")
+	r.HandleFunc("/organizations/{id}/members/inactive", orgs.GetInactiveMembers).Methods("GET")
+
+	// inactive returns the emails of the members inactive in an organization over the last day.
+	inactive := func(t *testing.T, orgID string) map[string]bool {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members/inactive?by=activity&days=1", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		emails := make(map[string]bool)
+		for _, member := range parseResponse(response)["data"].(map[string]interface{})["members"].([]interface{}) {
+			emails[member.(map[string]interface{})["email"].(string)] = true
+		}
+
+		return emails
+	}
+
+	t.Run("test activity is tracked per organization", func(t *testing.T) {
+		emails := inactive(t, orgID)
+		if emails[email] || !emails["idle@gmail.com"] {
+			t.Errorf("expected only idle@gmail.com to be inactive in org %s, got %v", orgID, emails)
+		}
+
+		if emails := inactive(t, otherOrgID); !emails[email] {
+			t.Errorf("expected %s to be inactive in org %s, where they sent no heartbeat", email, otherOrgID)
+		}
+	})
+
+	t.Run("test heartbeat records the logged in member", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/heartbeat", otherOrgID), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, email))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if emails := inactive(t, otherOrgID); emails[email] {
+			t.Errorf("expected %s to be active in org %s after a heartbeat", email, otherOrgID)
+		}
+	})
+
+	t.Run("test unknown by is rejected", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members/inactive?by=mood", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+}
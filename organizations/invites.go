@@ -0,0 +1,328 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const inviteSweepInterval = 10 * time.Minute
+
+var (
+	errInviteExpired  = errors.New("invite has expired, ask the organization for a new one")
+	errInviteChanged  = errors.New("invite was accepted or resent in the meantime")
+	errInviteAccepted = errors.New("invite has already been used, ask the organization for a new one")
+
+	errInviteSentRecently = errors.New("invite was sent recently, try again later")
+)
+
+// inviteExpired reports whether an invite document has expired by now. It does not wait for
+// the sweeper to mark the invite expired.
+func inviteExpired(invite map[string]interface{}, now time.Time) bool {
+	if expired, _ := invite["expired"].(bool); expired {
+		return true
+	}
+
+	expiresAt := utils.DocTime(invite, "expires_at")
+
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+// inviteSentAt is when an invite document was last sent, or created for invites saved before
+// sends were recorded.
+func inviteSentAt(invite map[string]interface{}) time.Time {
+	if sentAt := utils.DocTime(invite, "last_sent_at"); !sentAt.IsZero() {
+		return sentAt
+	}
+
+	if id, ok := invite["_id"].(primitive.ObjectID); ok {
+		return id.Timestamp()
+	}
+
+	return time.Time{}
+}
+
+// inviteExpiryHours reads the default invite lifetime an organization document has set, which
+// may be stored as either integer width.
+func inviteExpiryHours(org map[string]interface{}) int {
+	switch hours := org["invite_expiry_hours"].(type) {
+	case int32:
+		return int(hours)
+	case int64:
+		return int(hours)
+	}
+
+	return 0
+}
+
+// inviteLifetime works out how long new invites last: the requested hours when given, else
+// the organization's default, else the configured default. No invite lasts longer than the
+// configured maximum.
+func (oh *OrganizationHandler) inviteLifetime(orgDefaultHours, requestedHours int) (time.Duration, error) {
+	maxLifetime := oh.configs.InviteMaxExpiry
+
+	if requestedHours < 0 {
+		return 0, errors.New("expires_in_hours cannot be negative")
+	}
+
+	if requested := time.Duration(requestedHours) * time.Hour; requested > maxLifetime {
+		return 0, fmt.Errorf("invites cannot last longer than %d hours", int(maxLifetime.Hours()))
+	} else if requested > 0 {
+		return requested, nil
+	}
+
+	lifetime := oh.configs.InviteExpiry
+	if orgDefaultHours > 0 {
+		lifetime = time.Duration(orgDefaultHours) * time.Hour
+	}
+
+	// the maximum may have been lowered since the organization set its default
+	if lifetime > maxLifetime {
+		lifetime = maxLifetime
+	}
+
+	return lifetime, nil
+}
+
+// ExpireInvites marks the invites that have run out by now as expired and returns how many were.
+func ExpireInvites(now time.Time) (int, error) {
+	res, err := utils.GetCollection(OrganizationInviteCollectionName).UpdateMany(context.TODO(),
+		bson.M{
+			"has_accepted": false,
+			"expired":      bson.M{"$ne": true},
+			"expires_at":   bson.M{"$lte": now},
+		},
+		bson.M{"$set": bson.M{"expired": true}})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.ModifiedCount), nil
+}
+
+// RunInviteSweeper marks expired invites every ten minutes until the context is cancelled.
+func RunInviteSweeper(ctx context.Context) {
+	ticker := time.NewTicker(inviteSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expired, err := ExpireInvites(now)
+			if err != nil {
+				logger.Error("could not expire invites: %v", err)
+				continue
+			}
+
+			if expired > 0 {
+				logger.Info("%d organization invites expired", expired)
+			}
+		}
+	}
+}
+
+// Set how long invites to an organization last. Zero goes back to the configured default.
+func (oh *OrganizationHandler) UpdateInviteExpiry(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if _, err := primitive.ObjectIDFromHex(orgID); err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		Hours int `json:"hours"`
+	}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	lifetime, err := oh.inviteLifetime(0, body.Hours)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	res, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, bson.M{"invite_expiry_hours": body.Hours})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("invite expiry updated successfully", utils.M{
+		"invite_expiry_hours": int(lifetime.Hours()),
+	}, w)
+}
+
+// Resend an invite that has not been accepted, for when the email went missing. The invite gets
+// a new token, so the old link stops working, and a fresh expiry. Invites can only be resent once
+// the configured interval has passed since they were last sent.
+func (oh *OrganizationHandler) ResendInvite(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value("user").(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	orgID, inviteID := mux.Vars(r)["id"], mux.Vars(r)["invite_id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	pInviteID, err := primitive.ObjectIDFromHex(inviteID)
+	if err != nil {
+		utils.GetError(errors.New("invalid invite id"), http.StatusBadRequest, w)
+		return
+	}
+
+	orgDoc, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": pOrgID})
+	if orgDoc == nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	var org Organization
+	if err := utils.BsonToStruct(orgDoc, &org); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	inviteDoc, _ := utils.GetMongoDBDoc(r.Context(), OrganizationInviteCollectionName, bson.M{"_id": pInviteID, "org_id": orgID})
+	if inviteDoc == nil {
+		utils.GetError(fmt.Errorf("invite %s not found", inviteID), http.StatusNotFound, w)
+		return
+	}
+
+	var invite Invite
+	if err := utils.BsonToStruct(inviteDoc, &invite); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if invite.HasAccepted {
+		utils.GetError(errors.New("invite has already been accepted"), http.StatusConflict, w)
+		return
+	}
+
+	now := utils.NowUTC()
+
+	if wait := oh.inviteResendWait(&invite, now); wait > 0 {
+		w.Header().Set("Retry-After", fmt.Sprint(int(wait.Seconds())+1))
+		utils.GetError(errInviteSentRecently, http.StatusTooManyRequests, w)
+
+		return
+	}
+
+	lifetime, err := oh.inviteLifetime(inviteExpiryHours(orgDoc), 0)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if err = oh.rotateInvite(r.Context(), &org, &invite, loggedInUser.Email, now.Add(lifetime)); errors.Is(err, errInviteChanged) {
+		utils.GetError(err, http.StatusConflict, w)
+		return
+	} else if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("invite resent successfully", invite, w)
+}
+
+// inviteResendWait is how long is left before an invite can be resent, or zero when it can be now.
+func (oh *OrganizationHandler) inviteResendWait(invite *Invite, now time.Time) time.Duration {
+	if invite.LastSentAt == nil {
+		return 0
+	}
+
+	if wait := invite.LastSentAt.Add(oh.configs.InviteResendInterval).Sub(now); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+// rotateInvite gives an invite that has not been accepted a new token and expiry, and emails
+// it again. The old link stops working.
+func (oh *OrganizationHandler) rotateInvite(ctx context.Context, org *Organization, invite *Invite, inviterEmail string, expiresAt time.Time) error {
+	pInviteID, err := primitive.ObjectIDFromHex(invite.ID)
+	if err != nil {
+		return err
+	}
+
+	now := utils.NowUTC()
+
+	oldUUID := invite.UUID
+	invite.UUID, invite.ExpiresAt, invite.Expired, invite.LastSentAt = utils.GenUUID(), expiresAt, false, &now
+
+	// matching the old token makes sure an invite accepted or resent in the meantime is left alone
+	res, err := utils.GetCollection(OrganizationInviteCollectionName).UpdateOne(ctx,
+		bson.M{"_id": pInviteID, "uuid": oldUUID, "has_accepted": false},
+		bson.M{"$set": bson.M{"uuid": invite.UUID, "expires_at": invite.ExpiresAt, "expired": false, "last_sent_at": now}})
+	if err != nil {
+		return err
+	}
+
+	if res.ModifiedCount == 0 {
+		return errInviteChanged
+	}
+
+	oh.sendInviteMail(org, invite, inviterEmail)
+
+	return nil
+}
+
+// pendingInvite finds an invite to an organization for an email that has been neither accepted
+// nor let expire, if there is one.
+func pendingInvite(ctx context.Context, orgID, email string, now time.Time) (*Invite, error) {
+	docs, err := utils.GetMongoDBDocs(ctx, OrganizationInviteCollectionName, bson.M{
+		"org_id":       orgID,
+		"email":        primitive.Regex{Pattern: "^" + regexp.QuoteMeta(email) + "$", Options: "i"},
+		"has_accepted": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		if inviteExpired(doc, now) {
+			continue
+		}
+
+		var invite Invite
+		if err = utils.BsonToStruct(doc, &invite); err != nil {
+			return nil, err
+		}
+
+		return &invite, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,295 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestInviteExpiry(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewOrganizationHandler(configs, newMockMailService())
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/send-invite", handler.SendInvite).Methods("POST")
+	r.HandleFunc("/organizations/{id}/invite-expiry", handler.UpdateInviteExpiry).Methods("PATCH")
+	r.HandleFunc("/organizations/guests/{uuid}", handler.GuestToOrganization).Methods("POST")
+
+	// invite sends an invite with the given body and returns the response.
+	invite := func(body string) map[string]interface{} {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/send-invite", orgID), bytes.NewBufferString(body))
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		if location := response.Header().Get("Location"); !strings.HasPrefix(location, "/organizations/invites/") {
+			t.Errorf("expected the location of the invite, got %q", location)
+		}
+
+		return parseResponse(response)["data"].(map[string]interface{})
+	}
+
+	// assertExpiresIn checks an invite expires about lifetime from now.
+	assertExpiresIn := func(t *testing.T, data map[string]interface{}, lifetime time.Duration) {
+		t.Helper()
+
+		invited := data["succeeded"].([]interface{})[0].(map[string]interface{})
+
+		expiresAt, err := time.Parse(time.RFC3339, invited["data"].(map[string]interface{})["expires_at"].(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := time.Now().Add(lifetime); expiresAt.Sub(want) > time.Minute || want.Sub(expiresAt) > time.Minute {
+			t.Errorf("expected invite to expire around %v, got %v", want, expiresAt)
+		}
+	}
+
+	t.Run("test invites use the configured expiry by default", func(t *testing.T) {
+		data := invite(`{"emails": ["defaultexpiry@gmail.com"]}`)
+		assertExpiresIn(t, data, configs.InviteExpiry)
+	})
+
+	t.Run("test invites can ask for their own expiry", func(t *testing.T) {
+		data := invite(`{"emails": ["customexpiry@gmail.com"], "expires_in_hours": 2}`)
+		assertExpiresIn(t, data, 2*time.Hour)
+	})
+
+	t.Run("test organization default expiry is used", func(t *testing.T) {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/invite-expiry", orgID), bytes.NewBufferString(`{"hours": 24}`))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data := invite(`{"emails": ["orgexpiry@gmail.com"]}`)
+		assertExpiresIn(t, data, 24*time.Hour)
+	})
+
+	t.Run("test each email is reported", func(t *testing.T) {
+		body := `{"emails": ["bulkinvite@gmail.com", "not-an-email", "BulkInvite@gmail.com"]}`
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/send-invite", orgID), bytes.NewBufferString(body))
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusMultiStatus)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		for section, want := range map[string]string{"succeeded": "bulkinvite@gmail.com", "failed": "not-an-email", "skipped": "BulkInvite@gmail.com"} {
+			items, _ := data[section].([]interface{})
+			if len(items) != 1 || items[0].(map[string]interface{})["item"] != want {
+				t.Errorf("expected %s to be %s, got %v", want, section, items)
+			}
+		}
+	})
+
+	t.Run("test expiry over the maximum is rejected", func(t *testing.T) {
+		overMax := int(configs.InviteMaxExpiry.Hours()) + 1
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/send-invite", orgID),
+			bytes.NewBufferString(fmt.Sprintf(`{"emails": ["overmax@gmail.com"], "expires_in_hours": %d}`, overMax)))
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+
+		req, _ = http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/invite-expiry", orgID),
+			bytes.NewBufferString(fmt.Sprintf(`{"hours": %d}`, overMax)))
+
+		response = getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("test expired invites cannot be accepted", func(t *testing.T) {
+		uuid := utils.GenUUID()
+		expired := Invite{OrgID: orgID, UUID: uuid, Email: "lateguest@gmail.com", ExpiresAt: time.Now().Add(-time.Hour)}
+
+		if _, err := utils.GetCollection(OrganizationInviteCollectionName).InsertOne(context.TODO(), expired); err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/guests/%s", uuid), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusGone)
+
+		count, err := ExpireInvites(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if count < 1 {
+			t.Errorf("expected the invite to be swept, %d invites were", count)
+		}
+
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": uuid})
+		if doc["expired"] != true {
+			t.Errorf("expected invite to be marked expired")
+		}
+	})
+}
+
+func TestAcceptInvite(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/guests/{uuid}", orgs.GuestToOrganization).Methods("POST")
+
+	// accept invites email to the organization and accepts the invite, sending body along.
+	accept := func(t *testing.T, email, body string) map[string]interface{} {
+		inviteUUID := utils.GenUUID()
+		if _, err := utils.CreateMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": inviteUUID, "email": email, "org_id": orgID}); err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/guests/%s", inviteUUID), bytes.NewBufferString(body))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if member, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": email}); member == nil {
+			t.Errorf("expected %s to be a member", email)
+		}
+
+		return parseResponse(response)["data"].(map[string]interface{})
+	}
+
+	t.Run("test existing users join as they are", func(t *testing.T) {
+		email := "existingguest." + utils.GenUUID() + "@gmail.com"
+		if err := setUpUser(email, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if data := accept(t, email, ""); data["provisioned"] != false {
+			t.Errorf("expected no account to be provisioned, got %v", data)
+		}
+
+		if n := utils.CountCollection(context.TODO(), UserCollectionName, bson.M{"email": email}); n != 1 {
+			t.Errorf("expected 1 account, found %d", n)
+		}
+	})
+
+	t.Run("test guests without an account get one for the invited email only", func(t *testing.T) {
+		email := "newguest." + utils.GenUUID() + "@gmail.com"
+		other := "someoneelse." + utils.GenUUID() + "@gmail.com"
+
+		if data := accept(t, email, fmt.Sprintf(`{"email": %q}`, other)); data["provisioned"] != true {
+			t.Errorf("expected an account to be provisioned, got %v", data)
+		}
+
+		doc, err := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"email": email})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if doc["isverified"] != true || doc["provisioned"] != true {
+			t.Errorf("expected a verified, provisioned account, got %v", doc)
+		}
+
+		if n := utils.CountCollection(context.TODO(), UserCollectionName, bson.M{"email": other}); n != 0 {
+			t.Errorf("expected no account for an email the invite was not sent to, found %d", n)
+		}
+	})
+}
+
+func TestResendInvite(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resending := *configs
+	resending.InviteResendInterval = time.Hour
+
+	mail := newMockMailService()
+	handler := NewOrganizationHandler(&resending, mail)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/invites/{invite_id}/resend", handler.ResendInvite).Methods("POST")
+
+	// setUpInvite saves an invite last sent at the given time.
+	setUpInvite := func(t *testing.T, accepted bool, sentAt time.Time) *Invite {
+		invite := &Invite{
+			OrgID: orgID, UUID: utils.GenUUID(), Email: "resent." + utils.GenUUID() + "@gmail.com",
+			HasAccepted: accepted, ExpiresAt: time.Now().Add(time.Hour), LastSentAt: &sentAt,
+		}
+
+		res, err := utils.GetCollection(OrganizationInviteCollectionName).InsertOne(context.TODO(), invite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		invite.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+		return invite
+	}
+
+	resend := func(t *testing.T, invite *Invite, code int) map[string]interface{} {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/invites/%s/resend", orgID, invite.ID), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, code)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	t.Run("test resending rotates the token", func(t *testing.T) {
+		invite := setUpInvite(t, false, time.Now().Add(-2*time.Hour))
+
+		data := resend(t, invite, http.StatusOK)
+
+		uuid, _ := data["uuid"].(string)
+		if uuid == "" || uuid == invite.UUID {
+			t.Fatalf("expected a new token, got %q", uuid)
+		}
+
+		if n := utils.CountCollection(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": invite.UUID}); n != 0 {
+			t.Error("expected the old token to stop working")
+		}
+
+		if n := utils.CountCollection(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": uuid, "expires_at": bson.M{"$gt": time.Now().Add(2 * time.Hour)}}); n != 1 {
+			t.Error("expected the new token to get a fresh expiry")
+		}
+
+		if !mail.sentTo(invite.Email) {
+			t.Error("expected the invite to be emailed again")
+		}
+
+		resend(t, invite, http.StatusTooManyRequests)
+	})
+
+	t.Run("test invites sent recently are not resent", func(t *testing.T) {
+		invite := setUpInvite(t, false, time.Now().Add(-time.Minute))
+
+		resend(t, invite, http.StatusTooManyRequests)
+
+		if mail.sentTo(invite.Email) {
+			t.Error("expected no email to be sent")
+		}
+	})
+
+	t.Run("test accepted invites are not resent", func(t *testing.T) {
+		invite := setUpInvite(t, true, time.Now().Add(-2*time.Hour))
+
+		resend(t, invite, http.StatusConflict)
+
+		if n := utils.CountCollection(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": invite.UUID}); n != 1 {
+			t.Error("expected the accepted invite to keep its token")
+		}
+	})
+}
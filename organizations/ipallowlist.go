@@ -0,0 +1,73 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+const maxIPAllowlistRanges = 100
+
+// IPAllowlistRequest replaces the IP ranges an organization can be reached from.
+type IPAllowlistRequest struct {
+	IPAllowlist []string `json:"ip_allowlist"`
+}
+
+// Set the IP ranges an organization can be reached from, see utils.OrgIPAllowlist. An empty
+// allowlist lifts the restriction. The allowlist must include the address of whoever sets it,
+// so admins cannot lock themselves out.
+func (oh *OrganizationHandler) UpdateIPAllowlist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if _, err := primitive.ObjectIDFromHex(orgID); err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body IPAllowlistRequest
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if len(body.IPAllowlist) > maxIPAllowlistRanges {
+		utils.GetError(fmt.Errorf("an allowlist can have at most %d ranges", maxIPAllowlistRanges), http.StatusBadRequest, w)
+		return
+	}
+
+	ipNets, err := utils.ParseCIDRs(body.IPAllowlist)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if !utils.IPAllowed(utils.ClientIP(r, oh.configs.TrustedProxies), ipNets) {
+		utils.GetError(errors.New("the allowlist must include your current IP address"), http.StatusBadRequest, w)
+		return
+	}
+
+	allowlist := make([]string, 0, len(ipNets))
+	for _, ipNet := range ipNets {
+		allowlist = append(allowlist, ipNet.String())
+	}
+
+	res, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, bson.M{"ip_allowlist": allowlist})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("ip allowlist updated", utils.M{"ip_allowlist": allowlist}, w)
+}
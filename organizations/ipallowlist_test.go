@@ -0,0 +1,56 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUpdateIPAllowlist(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/ip-allowlist", orgs.UpdateIPAllowlist).Methods("PATCH")
+
+	update := func(body string, expectedCode int) {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/ip-allowlist", orgID), bytes.NewBufferString(body))
+		req.RemoteAddr = "198.51.100.20:4000"
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, expectedCode)
+	}
+
+	t.Run("test invalid ranges are rejected", func(t *testing.T) {
+		update(`{"ip_allowlist": ["198.51.100.0/33"]}`, http.StatusBadRequest)
+	})
+
+	t.Run("test allowlists locking out the caller are rejected", func(t *testing.T) {
+		update(`{"ip_allowlist": ["203.0.113.0/24"]}`, http.StatusBadRequest)
+	})
+
+	t.Run("test allowlists are saved normalized", func(t *testing.T) {
+		update(`{"ip_allowlist": ["198.51.100.7/24", "203.0.113.7"]}`, http.StatusOK)
+
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), map[string]interface{}{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(org.IPAllowlist) != 2 || org.IPAllowlist[0] != "198.51.100.0/24" || org.IPAllowlist[1] != "203.0.113.7/32" {
+			t.Errorf("expected the normalized allowlist, got %v", org.IPAllowlist)
+		}
+	})
+
+	t.Run("test an empty allowlist lifts the restriction", func(t *testing.T) {
+		update(`{"ip_allowlist": []}`, http.StatusOK)
+	})
+}
@@ -0,0 +1,20 @@
+package organizations
+
+import "zuri.chat/zccore/utils"
+
+// organizationLocale validates the locale an organization asks for, or gives it the configured
+// default when it asks for none.
+func (oh *OrganizationHandler) organizationLocale(locale string) (string, error) {
+	if locale == "" {
+		return oh.configs.DefaultLocale, nil
+	}
+
+	return utils.ValidateLocale(locale, oh.configs.SupportedLocales)
+}
+
+// docLocale reads the locale of an organization document. Organizations created before locales
+// have none, and get English.
+func docLocale(org map[string]interface{}) string {
+	locale, _ := org["locale"].(string)
+	return locale
+}
@@ -0,0 +1,94 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestOrganizationLocale(t *testing.T) {
+	// locale reads the locale stored for an organization.
+	locale := func(orgID string) string {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return org.Locale
+	}
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/organizations", bytes.NewBufferString(body))
+		response := httptest.NewRecorder()
+		orgs.Create(response, withUser(req, defaultUser))
+
+		return response
+	}
+
+	t.Run("test organizations get the default locale", func(t *testing.T) {
+		response := create(fmt.Sprintf(`{"creator_email": %q}`, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		orgID := parseResponse(response)["data"].(map[string]interface{})["organization_id"].(string)
+		if got := locale(orgID); got != configs.DefaultLocale {
+			t.Errorf("expected locale %q, got %q", configs.DefaultLocale, got)
+		}
+	})
+
+	t.Run("test locale can be chosen at creation", func(t *testing.T) {
+		response := create(fmt.Sprintf(`{"creator_email": %q, "locale": "FR"}`, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		orgID := parseResponse(response)["data"].(map[string]interface{})["organization_id"].(string)
+		if got := locale(orgID); got != "fr" {
+			t.Errorf("expected locale fr, got %q", got)
+		}
+	})
+
+	t.Run("test unsupported locale is rejected at creation", func(t *testing.T) {
+		before := utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{})
+
+		response := create(fmt.Sprintf(`{"creator_email": %q, "locale": "xx"}`, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+
+		if after := utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{}); after != before {
+			t.Errorf("expected no organization to be created, organizations went from %d to %d", before, after)
+		}
+	})
+
+	t.Run("test locale can be changed in settings", func(t *testing.T) {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := getRouter()
+		r.HandleFunc("/organizations/{id}/settings", orgs.UpdateOrganizationSettings).Methods("PATCH")
+
+		update := func(body string) int {
+			req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/settings", orgID), bytes.NewBufferString(body))
+			return getHTTPResponse(t, r, req).Code
+		}
+
+		assertStatusCode(t, update(`{"workspacelanguage": "Spanish", "locale": "es"}`), http.StatusOK)
+
+		if got := locale(orgID); got != "es" {
+			t.Errorf("expected locale es, got %q", got)
+		}
+
+		assertStatusCode(t, update(`{"workspacelanguage": "Klingon", "locale": "tlh"}`), http.StatusBadRequest)
+
+		if got := locale(orgID); got != "es" {
+			t.Errorf("expected locale to stay es, got %q", got)
+		}
+	})
+}
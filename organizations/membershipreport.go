@@ -0,0 +1,175 @@
+package organizations
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// OtherRole counts members in membership reports whose role is none of the known ones.
+const OtherRole = "other"
+
+// reportedRoles are the roles membership reports count members by, in the order of their columns.
+var reportedRoles = []string{OwnerRole, AdminRole, MemberRole, GuestRole, OtherRole}
+
+var membershipCSVHeader = append(append([]string{"org_id", "name", "workspace_url"}, reportedRoles...), "total")
+
+// MembershipCount is how many active members an organization has in each role.
+type MembershipCount struct {
+	OrgID        string         `json:"org_id" bson:"_id"`
+	Name         string         `json:"name" bson:"name"`
+	WorkspaceURL string         `json:"workspace_url" bson:"workspace_url"`
+	Roles        map[string]int `json:"roles" bson:"-"`
+	Total        int            `json:"total" bson:"-"`
+
+	// members by role as the pipeline groups them, see tally
+	Counts []struct {
+		Role  string `bson:"_id"`
+		Count int    `bson:"count"`
+	} `json:"-" bson:"counts"`
+}
+
+// membershipPipeline counts the active members of each organization that is not deleted by role.
+func membershipPipeline() mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deleted": bson.M{"$ne": true}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$project", Value: bson.M{"_id": bson.M{"$toString": "$_id"}, "name": 1, "workspace_url": 1}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": MemberCollectionName,
+			"let":  bson.M{"org_id": "$_id"},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$eq": bson.A{"$org_id", "$$org_id"}}, "deleted": bson.M{"$ne": true}}}},
+				{{Key: "$group", Value: bson.M{"_id": "$role", "count": bson.M{"$sum": 1}}}},
+			},
+			"as": "counts",
+		}}},
+	}
+}
+
+// tally sums the counts by role into the reported roles.
+func (m *MembershipCount) tally() {
+	m.Roles = make(map[string]int, len(reportedRoles))
+	for _, role := range reportedRoles {
+		m.Roles[role] = 0
+	}
+
+	for _, c := range m.Counts {
+		role := c.Role
+		if _, ok := m.Roles[role]; !ok {
+			role = OtherRole
+		}
+
+		m.Roles[role] += c.Count
+		m.Total += c.Count
+	}
+}
+
+// membershipCounts reads the counts of each organization from a cursor one at a time.
+func membershipCounts(ctx context.Context, cursor *mongo.Cursor) func() (*MembershipCount, bool) {
+	return func() (*MembershipCount, bool) {
+		for cursor.Next(ctx) {
+			var count MembershipCount
+			if err := cursor.Decode(&count); err != nil {
+				logger.Error("could not report members of organization %v: %v", cursor.Current.Lookup("_id"), err)
+				continue
+			}
+
+			count.tally()
+
+			return &count, true
+		}
+
+		return nil, false
+	}
+}
+
+// Report how many active members each organization has in each role, across all organizations
+// that are not deleted, as JSON, NDJSON or CSV. NDJSON and CSV are streamed.
+func (oh *OrganizationHandler) ExportMembershipReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Vary", "Accept")
+
+	format, err := exportFormat(r)
+	if err != nil {
+		utils.GetError(err, http.StatusNotAcceptable, w)
+		return
+	}
+
+	cursor, err := utils.GetCollection(OrganizationCollectionName).Aggregate(r.Context(), membershipPipeline())
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+	defer cursor.Close(context.TODO())
+
+	next := membershipCounts(r.Context(), cursor)
+	disposition := fmt.Sprintf("attachment; filename=membership-report-%s", utils.NowUTC().Format("20060102"))
+
+	switch format {
+	case ExportNDJSON:
+		w.Header().Set("Content-Type", exportContentTypes[ExportNDJSON])
+		w.Header().Set("Content-Disposition", disposition+".ndjson")
+		w.WriteHeader(http.StatusOK)
+
+		reportNDJSON(w, next)
+	case ExportCSV:
+		w.Header().Set("Content-Type", exportContentTypes[ExportCSV])
+		w.Header().Set("Content-Disposition", disposition+".csv")
+		w.WriteHeader(http.StatusOK)
+
+		reportCSV(w, next)
+	default:
+		counts := []MembershipCount{}
+		for count, ok := next(); ok; count, ok = next() {
+			counts = append(counts, *count)
+		}
+
+		utils.GetSuccess("membership report generated successfully", counts, w)
+	}
+}
+
+// writes the counts of one organization per line.
+func reportNDJSON(w io.Writer, next func() (*MembershipCount, bool)) {
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	for count, ok := next(); ok; count, ok = next() {
+		if err := enc.Encode(count); err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// writes the counts of one organization per row under a header row.
+func reportCSV(w io.Writer, next func() (*MembershipCount, bool)) {
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	if err := cw.Write(membershipCSVHeader); err != nil {
+		return
+	}
+
+	for count, ok := next(); ok; count, ok = next() {
+		row := []string{count.OrgID, count.Name, count.WorkspaceURL}
+		for _, role := range reportedRoles {
+			row = append(row, strconv.Itoa(count.Roles[role]))
+		}
+
+		if err := cw.Write(append(row, strconv.Itoa(count.Total))); err != nil {
+			return
+		}
+	}
+}
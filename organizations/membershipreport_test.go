@@ -0,0 +1,131 @@
+package organizations
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+func TestExportMembershipReport(t *testing.T) {
+	r := getRouter()
+	r.HandleFunc("/organizations/membership-report", orgs.ExportMembershipReport).Methods("GET")
+
+	// setUpMembers adds members with the given roles to an organization and returns their ids.
+	setUpMembers := func(t *testing.T, orgID string, roles ...string) []string {
+		ids := make([]string, len(roles))
+
+		for i, role := range roles {
+			id, err := setUpMember(orgID, fmt.Sprintf("report.%s@gmail.com", utils.GenUUID()), role)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids[i] = id
+		}
+
+		return ids
+	}
+
+	first, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setUpMembers(t, first, OwnerRole, AdminRole, MemberRole, MemberRole)
+	removed := setUpMembers(t, second, OwnerRole, GuestRole, MemberRole)
+	setUpMembers(t, deleted, OwnerRole)
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, removed[2], bson.M{"deleted": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, deleted, bson.M{"deleted": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test counts by role are reported as NDJSON", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/organizations/membership-report?format=ndjson", nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		reported := map[string]MembershipCount{}
+
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			var count MembershipCount
+			if err := json.Unmarshal(scanner.Bytes(), &count); err != nil {
+				t.Fatal(err)
+			}
+
+			reported[count.OrgID] = count
+		}
+
+		want := map[string]map[string]int{
+			first:  {OwnerRole: 1, AdminRole: 1, MemberRole: 2, GuestRole: 0},
+			second: {OwnerRole: 1, AdminRole: 0, MemberRole: 0, GuestRole: 1},
+		}
+
+		for orgID, roles := range want {
+			count, ok := reported[orgID]
+			if !ok {
+				t.Fatalf("expected organization %s to be reported", orgID)
+			}
+
+			for role, n := range roles {
+				if count.Roles[role] != n {
+					t.Errorf("expected %d %s in %s, got %d", n, role, orgID, count.Roles[role])
+				}
+			}
+		}
+
+		// setUpOrganization stores a member document in the organizations collection, not members
+		if reported[first].Total != 4 || reported[second].Total != 2 {
+			t.Errorf("expected totals of 4 and 2, got %d and %d", reported[first].Total, reported[second].Total)
+		}
+
+		if _, ok := reported[deleted]; ok {
+			t.Error("expected deleted organizations to be left out")
+		}
+	})
+
+	t.Run("test the report is available as CSV", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/organizations/membership-report", nil)
+		req.Header.Set("Accept", "text/csv")
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		rows, err := csv.NewReader(response.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(rows) < 3 || len(rows[0]) != len(membershipCSVHeader) {
+			t.Fatalf("expected a header and a row per organization, got %v", rows)
+		}
+
+		for _, row := range rows[1:] {
+			if row[0] == first && row[len(row)-1] != "4" {
+				t.Errorf("expected %s to total 4 members, got %v", first, row)
+			}
+		}
+	})
+}
@@ -0,0 +1,305 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+// maxMergeHops bounds how many merges followMerge follows, in case organizations merged into
+// each other were later merged again.
+const maxMergeHops = 10
+
+// MergeRequest names the organization to merge into the one in the url.
+type MergeRequest struct {
+	SourceID string `json:"source_id"`
+}
+
+// mergedRole is the role of someone in both organizations of a merge: the higher of the two.
+// Ownership stays with the target, so owners of the source count as admins.
+func mergedRole(targetRole, sourceRole string) string {
+	if sourceRole == OwnerRole {
+		sourceRole = AdminRole
+	}
+
+	if auth.RoleRanks[sourceRole] > auth.RoleRanks[targetRole] {
+		return sourceRole
+	}
+
+	return targetRole
+}
+
+// followMerge returns the organization a merged organization was merged into, so links to the
+// merged one still resolve, and any other organization as it is.
+func followMerge(ctx context.Context, doc bson.M) (bson.M, error) {
+	for i := 0; i < maxMergeHops; i++ {
+		into, _ := doc["merged_into"].(string)
+		if into == "" {
+			return doc, nil
+		}
+
+		pOrgID, err := primitive.ObjectIDFromHex(into)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc, err = utils.GetMongoDBDoc(ctx, OrganizationCollectionName, bson.M{"_id": pOrgID}); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("too many merged organizations to follow")
+}
+
+// activeMembers returns the members of an organization who have not left it, by email.
+func activeMembers(ctx context.Context, orgID string) (map[string]Member, error) {
+	docs, err := utils.GetMongoDBDocs(ctx, MemberCollectionName, bson.M{"org_id": orgID, "deleted": bson.M{"$ne": true}})
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]Member, len(docs))
+
+	for _, doc := range docs {
+		var m Member
+		if err := utils.BsonToStruct(doc, &m); err != nil {
+			return nil, err
+		}
+
+		members[strings.ToLower(m.Email)] = m
+	}
+
+	return members, nil
+}
+
+// Merge another organization into this one. Members of the source join this organization,
+// and anyone in both keeps the higher of their two roles. The tags and plugins of the source
+// are added, then the source is soft deleted and links to it resolve to this organization.
+// Only Zuri admins can do this.
+func (oh *OrganizationHandler) MergeOrganizations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	targetID := mux.Vars(r)["id"]
+
+	pTargetID, err := primitive.ObjectIDFromHex(targetID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body MergeRequest
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	sourceID := body.SourceID
+
+	pSourceID, err := primitive.ObjectIDFromHex(sourceID)
+	if err != nil {
+		utils.GetError(errors.New("invalid source organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	if sourceID == targetID {
+		utils.GetError(errors.New("an organization cannot be merged into itself"), http.StatusBadRequest, w)
+		return
+	}
+
+	notMerged := bson.M{"$exists": false}
+
+	target, err := FetchOrganization(r.Context(), bson.M{"_id": pTargetID, "merged_into": notMerged})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", targetID), http.StatusNotFound, w)
+		return
+	}
+
+	source, err := FetchOrganization(r.Context(), bson.M{"_id": pSourceID, "merged_into": notMerged})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", sourceID), http.StatusNotFound, w)
+		return
+	}
+
+	sourceMembers, err := activeMembers(r.Context(), sourceID)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	targetMembers, err := activeMembers(r.Context(), targetID)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// everything up to marking the source as merged can safely be repeated if a merge fails
+	var newMembers []interface{}
+
+	emails := make([]string, 0, len(sourceMembers))
+	promoted := 0
+
+	for email := range sourceMembers {
+		m := sourceMembers[email]
+		emails = append(emails, m.Email)
+
+		existing, ok := targetMembers[email]
+		if !ok {
+			newMembers = append(newMembers, cloneMember(&m, targetID))
+			continue
+		}
+
+		if role := mergedRole(existing.Role, m.Role); role != existing.Role {
+			if _, err = utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, existing.ID, bson.M{"role": role}); err != nil {
+				utils.GetError(err, http.StatusInternalServerError, w)
+				return
+			}
+
+			promoted++
+		}
+	}
+
+	if len(newMembers) > 0 {
+		if _, err = utils.GetCollection(MemberCollectionName).InsertMany(r.Context(), newMembers); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	set := bson.M{}
+
+	addedPlugins, sharedPlugins := []string{}, []string{}
+
+	for pluginID, plugin := range source.Plugins {
+		if _, ok := target.Plugins[pluginID]; ok {
+			sharedPlugins = append(sharedPlugins, pluginID)
+			continue
+		}
+
+		set["plugins."+pluginID] = plugin
+
+		if config, ok := source.PluginConfig[pluginID]; ok {
+			set["plugin_config."+pluginID] = config
+		}
+
+		addedPlugins = append(addedPlugins, pluginID)
+	}
+
+	sort.Strings(addedPlugins)
+
+	update := bson.M{}
+
+	// organizations created before tags were added have no list to add to
+	if len(source.Tags) > 0 && target.Tags == nil {
+		set["tags"] = source.Tags
+	} else if len(source.Tags) > 0 {
+		update["$addToSet"] = bson.M{"tags": bson.M{"$each": source.Tags}}
+	}
+
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+
+	if len(update) > 0 {
+		if _, err = utils.GetCollection(OrganizationCollectionName).UpdateOne(r.Context(), bson.M{"_id": pTargetID}, update); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	if _, err = utils.GetCollection(UserCollectionName).UpdateMany(r.Context(),
+		bson.M{"email": bson.M{"$in": emails}},
+		bson.M{"$addToSet": bson.M{"workspaces": targetID}}); err != nil {
+		utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
+		return
+	}
+
+	now := utils.NowUTC()
+
+	res, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": pSourceID, "merged_into": notMerged},
+		bson.M{"$set": bson.M{"merged_into": targetID, "deleted": true, "deleted_at": now}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(fmt.Errorf("organization %s has already been merged", sourceID), http.StatusConflict, w)
+		return
+	}
+
+	if _, err = utils.GetCollection(MemberCollectionName).UpdateMany(r.Context(),
+		bson.M{"org_id": sourceID, "deleted": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"deleted": true, "deleted_at": now}}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if _, err = utils.GetCollection(UserCollectionName).UpdateMany(r.Context(),
+		bson.M{"workspaces": sourceID},
+		bson.M{"$pull": bson.M{"workspaces": sourceID}}); err != nil {
+		utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
+		return
+	}
+
+	// plugins both organizations had lose the install of the source
+	if len(sharedPlugins) > 0 {
+		pluginIDs := make([]primitive.ObjectID, 0, len(sharedPlugins))
+
+		for _, pluginID := range sharedPlugins {
+			if pID, err := primitive.ObjectIDFromHex(pluginID); err == nil {
+				pluginIDs = append(pluginIDs, pID)
+			}
+		}
+
+		if _, err = utils.GetCollection(PluginCollectionName).UpdateMany(r.Context(),
+			bson.M{"_id": bson.M{"$in": pluginIDs}},
+			bson.M{"$inc": bson.M{"install_count": -1}}); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	entry := &audit.Log{
+		OrgID:      targetID,
+		Actor:      loggedInUser.Email,
+		Action:     audit.OrganizationMerged,
+		TargetType: "organization",
+		TargetID:   sourceID,
+		Data: map[string]interface{}{
+			"members_added":    len(newMembers),
+			"members_promoted": promoted,
+			"plugins_added":    addedPlugins,
+		},
+		CreatedAt:      now,
+		ImpersonatedBy: loggedInUser.ImpersonatedBy,
+	}
+
+	if err = audit.Record(entry); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("organizations merged successfully", utils.M{
+		"organization_id":  targetID,
+		"members_added":    len(newMembers),
+		"members_promoted": promoted,
+		"plugins_added":    addedPlugins,
+	}, w)
+}
@@ -0,0 +1,162 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestMergedRole(t *testing.T) {
+	tests := []struct {
+		target, source, want string
+	}{
+		{MemberRole, AdminRole, AdminRole},
+		{AdminRole, MemberRole, AdminRole},
+		{GuestRole, MemberRole, MemberRole},
+		{MemberRole, OwnerRole, AdminRole},
+		{OwnerRole, AdminRole, OwnerRole},
+	}
+
+	for _, tc := range tests {
+		if got := mergedRole(tc.target, tc.source); got != tc.want {
+			t.Errorf("target %s and source %s: expected %s, got %s", tc.target, tc.source, tc.want, got)
+		}
+	}
+}
+
+func TestMergeOrganizations(t *testing.T) {
+	targetID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sourceID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	members := []struct {
+		orgID, email, role string
+	}{
+		{targetID, "mergeowner@gmail.com", OwnerRole},
+		{targetID, "mergeboth@gmail.com", MemberRole},
+		{targetID, "mergeadmin@gmail.com", AdminRole},
+		{sourceID, "mergeboth@gmail.com", AdminRole},
+		{sourceID, "MergeAdmin@gmail.com", GuestRole},
+		{sourceID, "mergesourceowner@gmail.com", OwnerRole},
+		{sourceID, "mergeguest@gmail.com", GuestRole},
+	}
+
+	for _, m := range members {
+		if _, err = setUpMember(m.orgID, m.email, m.role); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sourceUpdate := map[string]interface{}{
+		"tags":    []string{"beta"},
+		"plugins": map[string]interface{}{"61695d8bb2cc8a9af4833d46": map[string]interface{}{"plugin_id": "61695d8bb2cc8a9af4833d46"}},
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, sourceID, sourceUpdate); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, targetID, map[string]interface{}{"plugins": map[string]interface{}{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}", orgs.GetOrganization).Methods("GET")
+	r.HandleFunc("/organizations/{id}/merge", orgs.MergeOrganizations).Methods("POST")
+
+	merge := func(sourceID string, expectedCode int) map[string]interface{} {
+		requestBody := []byte(fmt.Sprintf(`{"source_id": %q}`, sourceID))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/merge", targetID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, withUser(req, "mergezuriadmin@gmail.com"))
+		assertStatusCode(t, response.Code, expectedCode)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	t.Run("test an organization cannot be merged into itself", func(t *testing.T) {
+		merge(targetID, http.StatusBadRequest)
+	})
+
+	t.Run("test members are combined", func(t *testing.T) {
+		data := merge(sourceID, http.StatusOK)
+
+		if data["members_added"] != float64(2) || data["members_promoted"] != float64(1) {
+			t.Errorf("expected 2 members added and 1 promoted, got %v", data)
+		}
+
+		got, err := activeMembers(context.TODO(), targetID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]string{
+			"mergeowner@gmail.com":       OwnerRole,
+			"mergeboth@gmail.com":        AdminRole,
+			"mergeadmin@gmail.com":       AdminRole,
+			"mergesourceowner@gmail.com": AdminRole,
+			"mergeguest@gmail.com":       GuestRole,
+		}
+
+		if len(got) != len(want) {
+			t.Errorf("expected %d members, got %d", len(want), len(got))
+		}
+
+		for email, role := range want {
+			if got[email].Role != role {
+				t.Errorf("expected %s to be %s, got %q", email, role, got[email].Role)
+			}
+		}
+
+		left, _ := activeMembers(context.TODO(), sourceID)
+		if len(left) != 0 {
+			t.Errorf("expected the members of the source to be removed, got %d", len(left))
+		}
+	})
+
+	t.Run("test tags and plugins are combined", func(t *testing.T) {
+		pTargetID, _ := primitive.ObjectIDFromHex(targetID)
+
+		target, err := FetchOrganization(context.TODO(), map[string]interface{}{"_id": pTargetID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(target.Tags) != 1 || target.Tags[0] != "beta" {
+			t.Errorf("expected the target to be tagged beta, got %v", target.Tags)
+		}
+
+		if _, ok := target.Plugins["61695d8bb2cc8a9af4833d46"]; !ok {
+			t.Errorf("expected the plugin of the source to be installed, got %v", target.Plugins)
+		}
+	})
+
+	t.Run("test links to the source resolve to the target", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s", sourceID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+		if data["_id"] != targetID {
+			t.Errorf("expected organization %s, got %v", targetID, data["_id"])
+		}
+	})
+
+	t.Run("test a merged organization cannot be merged again", func(t *testing.T) {
+		merge(sourceID, http.StatusNotFound)
+	})
+}
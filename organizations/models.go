@@ -17,6 +17,14 @@ const (
 	CardCollectionName               = "cards"
 	UserCollectionName               = "users"
 	PluginCollectionName             = "plugins"
+	WebhookDeliveryCollectionName    = "webhook_deliveries"
+	StoredFileCollectionName         = "stored_files"
+	MemberNoteCollectionName         = "member_notes"
+	TeamCollectionName               = "teams"
+	PlanHistoryCollectionName        = "plan_history"
+	ApprovalRequestCollectionName    = "approval_requests"
+	URLReservationCollectionName     = "workspace_url_reservations"
+	WaitlistCollectionName           = "organization_waitlist"
 )
 
 const (
@@ -55,10 +63,14 @@ var Roles = map[string]string{
 }
 
 const (
-	FreeVersion = "free"
-	ProVersion  = "pro"
+	FreeVersion       = "free"
+	ProVersion        = "pro"
+	EnterpriseVersion = "enterprise"
 )
 
+// DefaultOrganizationName is the name organizations are created with.
+const DefaultOrganizationName = "Zuri Chat"
+
 const ProSubscriptionRate = 10
 const StatusHistoryLimit = 6
 
@@ -85,17 +97,275 @@ type Organization struct {
 	CreatorEmail string `json:"creator_email" bson:"creator_email"`
 	CreatorID    string `json:"creator_id" bson:"creator_id"`
 	// Plugins      []map[string]interface{} `json:"plugins" bson:"plugins"`
-	Plugins      map[string]interface{} `json:"plugins" bson:"plugins"`
-	Admins       []string               `json:"admins" bson:"admins"`
-	Settings     OrganizationPreference `json:"settings" bson:"settings"`
-	Customize    Customize              `json:"customize" bson:"customize"`
-	LogoURL      string                 `json:"logo_url" bson:"logo_url"`
-	WorkspaceURL string                 `json:"workspace_url" bson:"workspace_url"`
-	CreatedAt    time.Time              `json:"created_at" bson:"created_at"`
-	UpdatedAt    time.Time              `json:"updated_at" bson:"updated_at"`
-	Tokens       float64                `json:"tokens" bson:"tokens"`
-	Version      string                 `json:"version" bson:"version"`
-	Billing      Billing                `json:"billing" bson:"billing"`
+	Plugins       map[string]interface{} `json:"plugins" bson:"plugins"`
+	Admins        []string               `json:"admins" bson:"admins"`
+	Owners        []string               `json:"owners" bson:"owners"`
+	StorageUsed   int64                  `json:"storage_used" bson:"storage_used"`
+	StorageQuota  int64                  `json:"storage_quota" bson:"storage_quota"`
+	Digest        DigestSettings         `json:"digest" bson:"digest"`
+	Settings      OrganizationPreference `json:"settings" bson:"settings"`
+	Customize     Customize              `json:"customize" bson:"customize"`
+	LogoURL       string                 `json:"logo_url" bson:"logo_url"`
+	WorkspaceURL  string                 `json:"workspace_url" bson:"workspace_url"`
+	CreatedAt     time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at" bson:"updated_at"`
+	Tokens        float64                `json:"tokens" bson:"tokens"`
+	Version       string                 `json:"version" bson:"version"`
+	Billing       Billing                `json:"billing" bson:"billing"`
+	Webhooks      []Webhook              `json:"webhooks" bson:"webhooks"`
+	WebhookPaused bool                   `json:"webhook_paused" bson:"webhook_paused"`
+	FeatureFlags  map[string]bool        `json:"feature_flags" bson:"feature_flags"`
+	// flags an admin has set, which take precedence over plan defaults
+	FeatureFlagOverrides map[string]bool `json:"feature_flag_overrides" bson:"feature_flag_overrides"`
+	Announcements        []Announcement  `json:"announcements" bson:"announcements"`
+	// where invoices and payment notices go instead of the creator, when set. Served by
+	// GetInvoiceContact only, the address being stored encrypted
+	BillingContactEmail string `json:"-" bson:"billing_contact_email"`
+	BillingAddress      string `json:"-" bson:"billing_address"`
+
+	Onboarding Onboarding `json:"onboarding" bson:"onboarding"`
+
+	// how long invites to the organization last, when not the configured default
+	InviteExpiryHours int `json:"invite_expiry_hours" bson:"invite_expiry_hours"`
+
+	// configuration of installed plugins by plugin id, served by GetPluginConfig so secrets stay masked
+	PluginConfig map[string]PluginConfig `json:"-" bson:"plugin_config,omitempty"`
+
+	// pending request to delete the organization, see DeleteOrganization
+	DeletionConfirmation *DeletionConfirmation `json:"-" bson:"deletion_confirmation,omitempty"`
+
+	// language of emails sent for the organization, one of the supported locales
+	Locale string `json:"locale" bson:"locale"`
+
+	// display name and reply-to address of emails sent on the organization's behalf, which are
+	// still sent from our address. See validateEmailSender
+	EmailFromName string `json:"email_from_name,omitempty" bson:"email_from_name,omitempty"`
+	EmailReplyTo  string `json:"email_reply_to,omitempty" bson:"email_reply_to,omitempty"`
+
+	// hours the organization works each weekday in its time zone, see utils.IsWithinWorkingHours.
+	// Organizations without them are always working
+	WorkingHours *utils.WorkingHours `json:"working_hours,omitempty" bson:"working_hours,omitempty"`
+
+	// email domains whose users may join the organization. A domain only counts once its
+	// ownership is verified, see VerifyAllowedDomain
+	AllowedDomains []AllowedDomain `json:"allowed_domains,omitempty" bson:"allowed_domains,omitempty"`
+
+	// labels Zuri admins give organizations to manage them in groups, such as "beta"
+	Tags []string `json:"tags" bson:"tags"`
+
+	// version of the document's layout, see CurrentSchemaVersion
+	SchemaVersion int `json:"schema_version" bson:"schema_version"`
+
+	// recurring export of the organization, served by GetExportSchedule so the destination stays masked
+	ExportSchedule *ExportSchedule `json:"-" bson:"export_schedule,omitempty"`
+
+	// the organization this one was merged into, see MergeOrganizations. Links to a merged
+	// organization resolve to it
+	MergedInto string     `json:"-" bson:"merged_into,omitempty"`
+	Deleted    bool       `json:"-" bson:"deleted,omitempty"`
+	DeletedAt  *time.Time `json:"-" bson:"deleted_at,omitempty"`
+
+	// deactivated organizations are kept but cannot be used until a Zuri admin reactivates them,
+	// see BulkDeactivateOrganizations
+	Deactivated   bool       `json:"deactivated,omitempty" bson:"deactivated,omitempty"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty" bson:"deactivated_at,omitempty"`
+
+	// public organizations are served to anyone by GetPublicOrganization, with only their
+	// public profile
+	Public            bool   `json:"public" bson:"public"`
+	PublicDescription string `json:"public_description,omitempty" bson:"public_description,omitempty"`
+
+	// set on organizations created for a customer to claim, see ClaimPendingOrganization
+	Pending bool               `json:"-" bson:"pending,omitempty"`
+	Claim   *OrganizationClaim `json:"-" bson:"claim,omitempty"`
+
+	// CIDR ranges the organization can be reached from, anywhere when empty
+	IPAllowlist []string `json:"ip_allowlist" bson:"ip_allowlist,omitempty"`
+}
+
+// ExportSchedule delivers an export of the organization to a destination every day or week.
+type ExportSchedule struct {
+	Frequency      string    `json:"frequency" bson:"frequency"`
+	Format         string    `json:"format" bson:"format"`
+	DestinationURL string    `json:"destination_url" bson:"destination_url"`
+	NextRunAt      time.Time `json:"next_run_at" bson:"next_run_at"`
+
+	LastRunAt     *time.Time `json:"last_run_at,omitempty" bson:"last_run_at,omitempty"`
+	LastStatus    string     `json:"last_status,omitempty" bson:"last_status,omitempty"`
+	LastError     string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	LastSizeBytes int64      `json:"last_size_bytes,omitempty" bson:"last_size_bytes,omitempty"`
+	// failed attempts at the current export, which is retried until it reaches maxExportAttempts
+	FailedAttempts int `json:"failed_attempts" bson:"failed_attempts"`
+}
+
+// DeletionConfirmation is the token that must be sent back to delete an organization.
+type DeletionConfirmation struct {
+	Token       string    `bson:"token"`
+	RequestedBy string    `bson:"requested_by"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// AllowedDomain is an email domain an organization allows users from, proven to be theirs by
+// publishing VerificationToken in a DNS TXT record on the domain.
+type AllowedDomain struct {
+	Domain            string     `json:"domain" bson:"domain"`
+	VerificationToken string     `json:"verification_token" bson:"verification_token"`
+	Verified          bool       `json:"verified" bson:"verified"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty" bson:"verified_at,omitempty"`
+}
+
+// OrganizationClaim is the token a verified user redeems to become the owner of a pending organization.
+type OrganizationClaim struct {
+	Token     string    `bson:"token"`
+	CreatedBy string    `bson:"created_by"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// URLReservation holds a workspace url for the user who checked it was available, so that
+// only they can create an organization with it until it expires. See reserveWorkspaceURL.
+type URLReservation struct {
+	URL        string    `json:"workspace_url" bson:"_id"`
+	ReservedBy string    `json:"reserved_by" bson:"reserved_by"`
+	ExpiresAt  time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+const (
+	WaitlistWaiting = "waiting"
+	WaitlistAllowed = "allowed"
+)
+
+// WaitlistEntry is someone waiting to be allowed to create organizations, see waitlistGate.
+type WaitlistEntry struct {
+	Email     string     `json:"email" bson:"_id"`
+	Status    string     `json:"status" bson:"status"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	AllowedAt *time.Time `json:"allowed_at,omitempty" bson:"allowed_at,omitempty"`
+	AllowedBy string     `json:"allowed_by,omitempty" bson:"allowed_by,omitempty"`
+}
+
+// Onboarding tracks the first steps an organization takes. Each step is set by the handler
+// for the action and never unset.
+type Onboarding struct {
+	InvitedMembers  bool `json:"invited_members" bson:"invited_members"`
+	InstalledPlugin bool `json:"installed_plugin" bson:"installed_plugin"`
+	SetLogo         bool `json:"set_logo" bson:"set_logo"`
+}
+
+// DigestSettings controls the daily activity digest emailed to an organization's owners and admins.
+// Hour is the local hour, in TimeZone, from which the digest is due each day.
+type DigestSettings struct {
+	Enabled    bool      `json:"enabled" bson:"enabled"`
+	TimeZone   string    `json:"time_zone" bson:"time_zone"`
+	Hour       int       `json:"hour" bson:"hour"`
+	LastSentAt time.Time `json:"last_sent_at" bson:"last_sent_at"`
+}
+
+// StoredFile records the size of a file uploaded by an organization, so that
+// deleting or replacing it can be credited back to the organization's storage.
+type StoredFile struct {
+	ID        string    `json:"_id,omitempty" bson:"_id,omitempty"`
+	OrgID     string    `json:"org_id" bson:"org_id"`
+	URL       string    `json:"url" bson:"url"`
+	Size      int64     `json:"size" bson:"size"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Announcement is a message posted by an owner or admin for every member of an organization.
+type Announcement struct {
+	ID        string    `json:"id" bson:"id"`
+	Title     string    `json:"title" bson:"title"`
+	Body      string    `json:"body" bson:"body"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+// Webhook is an endpoint that receives organization events.
+type Webhook struct {
+	ID        string    `json:"id" bson:"id"`
+	URL       string    `json:"url" bson:"url"`
+	Secret    string    `json:"-" bson:"secret"`
+	Events    []string  `json:"events" bson:"events"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+
+	// the secret replaced by the last rotation, still accepted until it expires
+	PreviousSecret          string     `json:"-" bson:"previous_secret,omitempty"`
+	PreviousSecretExpiresAt *time.Time `json:"previous_secret_expires_at,omitempty" bson:"previous_secret_expires_at,omitempty"`
+
+	// conditions an event's payload must all meet to be delivered, on top of Events
+	Conditions []WebhookCondition `json:"conditions,omitempty" bson:"conditions,omitempty"`
+
+	// events raised within this many seconds of each other are sent together in one POST,
+	// see FlushWebhookBatches. Each event is sent on its own when zero
+	BatchWindowSeconds int `json:"batch_window_seconds,omitempty" bson:"batch_window_seconds,omitempty"`
+
+	// set while the URL has not answered a verification challenge, see verifyWebhookURL.
+	// Webhooks registered before verification have neither field and keep receiving events
+	Unverified bool       `json:"unverified,omitempty" bson:"unverified,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty" bson:"verified_at,omitempty"`
+}
+
+// WebhookCondition matches events whose payload has Field, a dotted path such as "data.role",
+// equal to Equals or to one of In. Exactly one of Equals and In is set.
+type WebhookCondition struct {
+	Field  string        `json:"field" bson:"field"`
+	Equals interface{}   `json:"equals,omitempty" bson:"equals,omitempty"`
+	In     []interface{} `json:"in,omitempty" bson:"in,omitempty"`
+}
+
+const (
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+	// held back while an organization's webhooks are paused
+	WebhookDeliveryQueued  = "queued"
+	WebhookDeliverySending = "sending"
+	// waiting to be sent with the other events of its batch
+	WebhookDeliveryBatched = "batched"
+)
+
+// WebhookDelivery records a single attempt at delivering an event to a webhook.
+type WebhookDelivery struct {
+	ID           string    `json:"_id,omitempty" bson:"_id,omitempty"`
+	OrgID        string    `json:"org_id" bson:"org_id"`
+	WebhookID    string    `json:"webhook_id" bson:"webhook_id"`
+	URL          string    `json:"url" bson:"url"`
+	Event        string    `json:"event" bson:"event"`
+	Payload      string    `json:"payload" bson:"payload"`
+	Signature    string    `json:"signature" bson:"signature"`
+	Status       string    `json:"status" bson:"status"`
+	ResponseCode int       `json:"response_code" bson:"response_code"`
+	Error        string    `json:"error,omitempty" bson:"error,omitempty"`
+	ReplayOf     string    `json:"replay_of,omitempty" bson:"replay_of,omitempty"`
+	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+
+	// events sent in a batch point at the delivery of the batch, which counts them
+	BatchID   string `json:"batch_id,omitempty" bson:"batch_id,omitempty"`
+	BatchSize int    `json:"batch_size,omitempty" bson:"batch_size,omitempty"`
+}
+
+// MemberNote is a private note an owner or admin keeps about a member. Members never see notes about themselves.
+type MemberNote struct {
+	ID        string     `json:"_id,omitempty" bson:"_id,omitempty"`
+	OrgID     string     `json:"org_id" bson:"org_id"`
+	MemberID  string     `json:"member_id" bson:"member_id"`
+	Body      string     `json:"body" bson:"body"`
+	Author    string     `json:"author" bson:"author"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	Deleted   bool       `json:"-" bson:"deleted"`
+	DeletedBy string     `json:"-" bson:"deleted_by,omitempty"`
+	DeletedAt *time.Time `json:"-" bson:"deleted_at,omitempty"`
+}
+
+// Team groups members of an organization. A member can be in any number of teams, and
+// deleting a team leaves its members in the organization.
+type Team struct {
+	ID        string    `json:"_id,omitempty" bson:"_id,omitempty"`
+	OrgID     string    `json:"org_id" bson:"org_id"`
+	Name      string    `json:"name" bson:"name"`
+	NameKey   string    `json:"-" bson:"name_key"` // lower-cased name, unique within the organization
+	MemberIDs []string  `json:"member_ids" bson:"member_ids"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 }
 
 type Billing struct {
@@ -139,11 +409,14 @@ type Invite struct {
 	OrgID       string `json:"org_id" bson:"org_id"`
 	UUID        string `json:"uuid" bson:"uuid"`
 	Email       string `json:"email" bson:"email"`
+	Role        string `json:"role,omitempty" bson:"role,omitempty"`
 	HasAccepted bool   `json:"has_accepted" bson:"has_accepted"`
-}
-type SendInviteResponse struct {
-	InvalidEmails []interface{}
-	InviteIDs     []interface{}
+	// invites saved before expiry was added have no expiry and do not expire
+	ExpiresAt time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	Expired   bool      `json:"expired" bson:"expired"`
+	InvitedBy string    `json:"invited_by,omitempty" bson:"invited_by,omitempty"`
+	// when the invite was last emailed, see ResendInvite
+	LastSentAt *time.Time `json:"last_sent_at,omitempty" bson:"last_sent_at,omitempty"`
 }
 
 type OrgPluginBody struct {
@@ -151,6 +424,14 @@ type OrgPluginBody struct {
 	UserID   string `json:"user_id"`
 }
 
+// PluginConfig is how an organization has configured one of its plugins. Secrets are stored
+// encrypted and are never sent back.
+type PluginConfig struct {
+	Values    map[string]interface{} `json:"values" bson:"values,omitempty"`
+	Secrets   map[string]string      `json:"-" bson:"secrets,omitempty"`
+	UpdatedAt time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
 type InstalledPlugin struct {
 	// ID          string                 `json:"id" bson:"_id"`
 	PluginID    string                 `json:"plugin_id" bson:"plugin_id"`
@@ -163,6 +444,8 @@ type InstalledPlugin struct {
 
 type SendInviteBody struct {
 	Emails []string `json:"emails" bson:"emails"`
+	// how long the invites last, instead of the organization's default
+	ExpiresInHours int `json:"expires_in_hours" bson:"expires_in_hours"`
 }
 
 type OrganizationAdmin struct {
@@ -232,8 +515,47 @@ type Member struct {
 	DeletedAt   time.Time `json:"deleted_at" bson:"deleted_at"`
 	Socials     []Social  `json:"socials" bson:"socials"`
 	Language    string    `json:"language" bson:"language"`
+
+	NotificationPreferences *NotificationPreferences `json:"notification_preferences" bson:"notification_preferences"`
+
+	// a role held on top of Role until it expires
+	TemporaryRole          string     `json:"temporary_role,omitempty" bson:"temporary_role,omitempty"`
+	TemporaryRoleExpiresAt *time.Time `json:"temporary_role_expires_at,omitempty" bson:"temporary_role_expires_at,omitempty"`
+	TemporaryRoleGrantedBy string     `json:"temporary_role_granted_by,omitempty" bson:"temporary_role_granted_by,omitempty"`
+
+	// days before the temporary role expires the member has been reminded at, see ExpiryReminderScheduler
+	ExpiryRemindersSent []int `json:"-" bson:"expiry_reminders_sent,omitempty"`
+
+	// copied from the user on login, see auth.RecordLastLogin
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" bson:"last_login_at,omitempty"`
+
+	// last heartbeat from the member in this organization, see RecordMemberActivity
+	LastActiveAt *time.Time `json:"last_active_at,omitempty" bson:"last_active_at,omitempty"`
+
+	// email of whoever invited or added the member; members who joined before it was recorded have none
+	InvitedBy string `json:"invited_by,omitempty" bson:"invited_by,omitempty"`
+
+	// who sees the member's email in member listings, see EmailVisibleToEveryone
+	EmailVisibility string `json:"email_visibility,omitempty" bson:"email_visibility,omitempty"`
 }
 
+// NotificationPreferences controls which organization events are emailed to a member.
+type NotificationPreferences struct {
+	Mentions      bool `json:"mentions" bson:"mentions"`
+	Invites       bool `json:"invites" bson:"invites"`
+	Announcements bool `json:"announcements" bson:"announcements"`
+
+	// reminders that a temporary role is about to expire, to its holder and whoever granted it
+	ExpiryReminders bool `json:"expiry_reminders" bson:"expiry_reminders"`
+}
+
+const (
+	NotifyMentions        = "mentions"
+	NotifyInvites         = "invites"
+	NotifyAnnouncements   = "announcements"
+	NotifyExpiryReminders = "expiry_reminders"
+)
+
 type Profile struct {
 	ID          string   `json:"id" bson:"_id"`
 	FirstName   string   `json:"first_name" bson:"first_name"`
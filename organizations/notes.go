@@ -0,0 +1,170 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+const maxMemberNoteLength = 2000
+
+var errOwnMemberNotes = errors.New("notes about yourself are not visible to you")
+
+// memberNoteAccess checks that the member the notes are about is someone other than the logged
+// in admin. Owners and admins cannot read notes about themselves.
+func memberNoteAccess(r *http.Request, orgID, memID string) (*auth.AuthUser, int, error) {
+	memberIDhex, err := primitive.ObjectIDFromHex(memID)
+	if err != nil {
+		return nil, http.StatusBadRequest, errors.New("invalid member id")
+	}
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		return nil, http.StatusBadRequest, errors.New("invalid user")
+	}
+
+	memberDoc, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": memberIDhex, "org_id": orgID})
+	if memberDoc == nil {
+		return nil, http.StatusNotFound, fmt.Errorf("member %s not found", memID)
+	}
+
+	if email, _ := memberDoc["email"].(string); strings.EqualFold(email, loggedInUser.Email) {
+		return nil, http.StatusForbidden, errOwnMemberNotes
+	}
+
+	return loggedInUser, 0, nil
+}
+
+// Add a private note about a member. Notes cannot be edited, only added and deleted.
+func (oh *OrganizationHandler) AddMemberNote(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, memID := mux.Vars(r)["id"], mux.Vars(r)["mem_id"]
+
+	loggedInUser, status, err := memberNoteAccess(r, orgID, memID)
+	if err != nil {
+		utils.GetError(err, status, w)
+		return
+	}
+
+	var body struct {
+		Body string `json:"body"`
+	}
+
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	body.Body = strings.TrimSpace(body.Body)
+
+	if body.Body == "" {
+		utils.GetError(errors.New("note body is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	if len([]rune(body.Body)) > maxMemberNoteLength {
+		utils.GetError(fmt.Errorf("note cannot be longer than %d characters", maxMemberNoteLength), http.StatusBadRequest, w)
+		return
+	}
+
+	note := MemberNote{
+		OrgID:     orgID,
+		MemberID:  memID,
+		Body:      body.Body,
+		Author:    strings.ToLower(loggedInUser.Email),
+		CreatedAt: utils.NowUTC(),
+	}
+
+	res, err := utils.GetCollection(MemberNoteCollectionName).InsertOne(r.Context(), note)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	note.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+	utils.GetSuccess("note added successfully", note, w)
+}
+
+// List the notes about a member, oldest first. Deleted notes are left out.
+func (oh *OrganizationHandler) ListMemberNotes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, memID := mux.Vars(r)["id"], mux.Vars(r)["mem_id"]
+
+	if _, status, err := memberNoteAccess(r, orgID, memID); err != nil {
+		utils.GetError(err, status, w)
+		return
+	}
+
+	filter := bson.M{"org_id": orgID, "member_id": memID, "deleted": bson.M{"$ne": true}}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	docs, err := utils.GetMongoDBDocs(r.Context(), MemberNoteCollectionName, filter, opts)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	notes := []MemberNote{}
+
+	for _, doc := range docs {
+		var note MemberNote
+		if err := utils.BsonToStruct(doc, &note); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		notes = append(notes, note)
+	}
+
+	utils.GetSuccess("notes retrieved successfully", notes, w)
+}
+
+// Delete a note about a member. The note is kept with who deleted it, but no longer listed.
+func (oh *OrganizationHandler) DeleteMemberNote(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, memID := mux.Vars(r)["id"], mux.Vars(r)["mem_id"]
+
+	loggedInUser, status, err := memberNoteAccess(r, orgID, memID)
+	if err != nil {
+		utils.GetError(err, status, w)
+		return
+	}
+
+	noteID, err := primitive.ObjectIDFromHex(mux.Vars(r)["note_id"])
+	if err != nil {
+		utils.GetError(errors.New("invalid note id"), http.StatusBadRequest, w)
+		return
+	}
+
+	filter := bson.M{"_id": noteID, "org_id": orgID, "member_id": memID, "deleted": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{
+		"deleted":    true,
+		"deleted_by": strings.ToLower(loggedInUser.Email),
+		"deleted_at": utils.NowUTC(),
+	}}
+
+	res, err := utils.GetCollection(MemberNoteCollectionName).UpdateOne(r.Context(), filter, update)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(errors.New("note not found"), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("note deleted successfully", nil, w)
+}
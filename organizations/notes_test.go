@@ -0,0 +1,127 @@
+package organizations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"zuri.chat/zccore/auth"
+)
+
+func TestMemberNotes(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, otherAdmin, member := "notetaker@gmail.com", "reviewer@gmail.com", "noted@gmail.com"
+
+	adminID, err := setUpMember(orgID, admin, AdminRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = setUpMember(orgID, otherAdmin, AdminRole); err != nil {
+		t.Fatal(err)
+	}
+
+	memberID, err := setUpMember(orgID, member, MemberRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, email := range []string{admin, otherAdmin, member} {
+		if err = setUpUser(email, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	au := auth.NewAuthHandler(configs, nil)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members/{mem_id}/notes", au.IsAuthorized(orgs.AddMemberNote, "admin")).Methods("POST")
+	r.HandleFunc("/organizations/{id}/members/{mem_id}/notes", au.IsAuthorized(orgs.ListMemberNotes, "admin")).Methods("GET")
+	r.HandleFunc("/organizations/{id}/members/{mem_id}/notes/{note_id}", au.IsAuthorized(orgs.DeleteMemberNote, "admin")).Methods("DELETE")
+
+	notesURL := func(memID string) string {
+		return fmt.Sprintf("/organizations/%s/members/%s/notes", orgID, memID)
+	}
+
+	addNote := func(memID, author, body string) *http.Request {
+		req, _ := http.NewRequest("POST", notesURL(memID), bytes.NewBufferString(fmt.Sprintf(`{"body": %q}`, body)))
+		return withUser(req, author)
+	}
+
+	t.Run("test admin can add and list notes", func(t *testing.T) {
+		response := getHTTPResponse(t, r, addNote(memberID, admin, "VIP"))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		note := parseResponse(response)["data"].(map[string]interface{})
+		if note["author"] != admin {
+			t.Errorf("expected note author %s, got %v", admin, note["author"])
+		}
+
+		response = getHTTPResponse(t, r, addNote(memberID, otherAdmin, "trial user"))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		req, _ := http.NewRequest("GET", notesURL(memberID), nil)
+		response = getHTTPResponse(t, r, withUser(req, admin))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		notes := parseResponse(response)["data"].([]interface{})
+		if len(notes) != 2 {
+			t.Fatalf("expected 2 notes, got %d", len(notes))
+		}
+
+		if body := notes[0].(map[string]interface{})["body"]; body != "VIP" {
+			t.Errorf("expected the oldest note first, got %v", body)
+		}
+	})
+
+	t.Run("test empty note is rejected", func(t *testing.T) {
+		response := getHTTPResponse(t, r, addNote(memberID, admin, "  "))
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("test member cannot see notes about themselves", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", notesURL(memberID), nil)
+		response := getHTTPResponse(t, r, withUser(req, member))
+		assertStatusCode(t, response.Code, http.StatusUnauthorized)
+
+		response = getHTTPResponse(t, r, addNote(memberID, member, "I am a VIP"))
+		assertStatusCode(t, response.Code, http.StatusUnauthorized)
+	})
+
+	t.Run("test admin cannot see notes about themselves", func(t *testing.T) {
+		response := getHTTPResponse(t, r, addNote(adminID, otherAdmin, "needs training"))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		req, _ := http.NewRequest("GET", notesURL(adminID), nil)
+		response = getHTTPResponse(t, r, withUser(req, admin))
+		assertStatusCode(t, response.Code, http.StatusForbidden)
+		assertResponseMessage(t, parseResponse(response)["message"].(string), errOwnMemberNotes.Error())
+	})
+
+	t.Run("test deleted notes are not listed", func(t *testing.T) {
+		response := getHTTPResponse(t, r, addNote(memberID, admin, "to be removed"))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		noteID := parseResponse(response)["data"].(map[string]interface{})["_id"].(string)
+
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/%s", notesURL(memberID), noteID), nil)
+		response = getHTTPResponse(t, r, withUser(req, admin))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		req, _ = http.NewRequest("DELETE", fmt.Sprintf("%s/%s", notesURL(memberID), noteID), nil)
+		response = getHTTPResponse(t, r, withUser(req, admin))
+		assertStatusCode(t, response.Code, http.StatusNotFound)
+
+		req, _ = http.NewRequest("GET", notesURL(memberID), nil)
+		response = getHTTPResponse(t, r, withUser(req, admin))
+
+		if notes := parseResponse(response)["data"].([]interface{}); len(notes) != 2 {
+			t.Errorf("expected 2 notes after deleting one, got %d", len(notes))
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+// DefaultNotificationPreferences returns the preferences every member starts with: all emails on.
+func DefaultNotificationPreferences() *NotificationPreferences {
+	return &NotificationPreferences{
+		Mentions:        true,
+		Invites:         true,
+		Announcements:   true,
+		ExpiryReminders: true,
+	}
+}
+
+// UnmarshalBSON starts from the defaults, so preferences saved before a kind of email existed
+// have it on rather than off.
+func (p *NotificationPreferences) UnmarshalBSON(data []byte) error {
+	type plain NotificationPreferences
+
+	prefs := plain(*DefaultNotificationPreferences())
+	if err := bson.Unmarshal(data, &prefs); err != nil {
+		return err
+	}
+
+	*p = NotificationPreferences(prefs)
+
+	return nil
+}
+
+// Allows reports whether emails of the given kind may be sent.
+// A nil preference set predates this feature and falls back to the defaults.
+func (p *NotificationPreferences) Allows(kind string) bool {
+	if p == nil {
+		p = DefaultNotificationPreferences()
+	}
+
+	switch kind {
+	case NotifyMentions:
+		return p.Mentions
+	case NotifyInvites:
+		return p.Invites
+	case NotifyAnnouncements:
+		return p.Announcements
+	case NotifyExpiryReminders:
+		return p.ExpiryReminders
+	default:
+		return true
+	}
+}
+
+// merge applies a partial update to the preferences, leaving unspecified fields untouched.
+func (p *NotificationPreferences) merge(update map[string]bool) error {
+	for kind, enabled := range update {
+		switch kind {
+		case NotifyMentions:
+			p.Mentions = enabled
+		case NotifyInvites:
+			p.Invites = enabled
+		case NotifyAnnouncements:
+			p.Announcements = enabled
+		case NotifyExpiryReminders:
+			p.ExpiryReminders = enabled
+		default:
+			return fmt.Errorf("unknown notification preference: %s", kind)
+		}
+	}
+
+	return nil
+}
+
+// checks whether the member with the given email in an organization wants emails of a kind.
+// people who are not members of the organization have no preferences, so they always get mails.
+func memberAllowsEmail(ctx context.Context, orgID, email, kind string) bool {
+	memberDoc, _ := utils.GetMongoDBDoc(ctx, MemberCollectionName, bson.M{"org_id": orgID, "email": email})
+	if memberDoc == nil {
+		return true
+	}
+
+	var member Member
+	if err := utils.BsonToStruct(memberDoc, &member); err != nil {
+		return true
+	}
+
+	return member.NotificationPreferences.Allows(kind)
+}
+
+// fetches a member's notification preferences, resolving missing preferences to the defaults.
+func fetchNotificationPreferences(ctx context.Context, orgID, memberID string) (*NotificationPreferences, error) {
+	pMemID, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return nil, errors.New("invalid Member id")
+	}
+
+	memberDoc, err := utils.GetMongoDBDoc(ctx, MemberCollectionName, bson.M{"_id": pMemID, "org_id": orgID})
+	if err != nil {
+		return nil, errors.New("member does not exist")
+	}
+
+	var member Member
+	if err = utils.BsonToStruct(memberDoc, &member); err != nil {
+		return nil, err
+	}
+
+	if member.NotificationPreferences == nil {
+		return DefaultNotificationPreferences(), nil
+	}
+
+	return member.NotificationPreferences, nil
+}
+
+var errNotPreferencesManager = errors.New("only the member or an admin can manage their notification preferences")
+
+// authorizeNotificationPreferences checks the caller may see and change a member's notification
+// preferences. On failure it returns the status code to respond with.
+func authorizeNotificationPreferences(r *http.Request, orgID, memberID string) (int, error) {
+	pMemID, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return http.StatusBadRequest, errors.New("invalid Member id")
+	}
+
+	memberDoc, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": pMemID, "org_id": orgID})
+	if memberDoc == nil {
+		return http.StatusBadRequest, errors.New("member does not exist")
+	}
+
+	if email, _ := memberDoc["email"].(string); !viewerOf(r, orgID).manages(email) {
+		return http.StatusForbidden, errNotPreferencesManager
+	}
+
+	return http.StatusOK, nil
+}
+
+// Get a member's email notification preferences. Only the member and admins can see them.
+func (oh *OrganizationHandler) GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, memberID := vars["id"], vars["mem_id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if status, err := authorizeNotificationPreferences(r, orgID, memberID); err != nil {
+		utils.GetError(err, status, w)
+		return
+	}
+
+	prefs, err := fetchNotificationPreferences(r.Context(), orgID, memberID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	utils.GetSuccess("notification preferences retrieved successfully", prefs, w)
+}
+
+// Update a member's email notification preferences. Only the supplied preferences are changed,
+// and only the member and admins can change them.
+func (oh *OrganizationHandler) UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, memberID := vars["id"], vars["mem_id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if status, err := authorizeNotificationPreferences(r, orgID, memberID); err != nil {
+		utils.GetError(err, status, w)
+		return
+	}
+
+	update := make(map[string]bool)
+	if err := utils.ParseJSONFromRequest(r, &update); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	prefs, err := fetchNotificationPreferences(r.Context(), orgID, memberID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if err = prefs.merge(update); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	prefsMap, err := utils.StructToMap(prefs)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, bson.M{"notification_preferences": prefsMap}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// publish update to subscriber
+	eventChannel := fmt.Sprintf("organizations_%s", orgID)
+	event := utils.Event{Identifier: memberID, Type: "User", Event: UpdateOrganizationMemberSettings, Channel: eventChannel, Payload: make(map[string]interface{})}
+
+	go utils.Emitter(event)
+
+	utils.GetSuccess("notification preferences updated successfully", prefs, w)
+}
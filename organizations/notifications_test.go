@@ -0,0 +1,133 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestNotificationPreferences(t *testing.T) {
+	t.Run("test new members get all notifications by default", func(t *testing.T) {
+		prefs := NewMember("default@gmail.com", "default", defaultOrgID, MemberRole).NotificationPreferences
+
+		for _, kind := range []string{NotifyMentions, NotifyInvites, NotifyAnnouncements} {
+			if !prefs.Allows(kind) {
+				t.Errorf("expected %s to be enabled by default", kind)
+			}
+		}
+	})
+
+	t.Run("test update merges with existing preferences", func(t *testing.T) {
+		memID, err := setUpMember(defaultOrgID, "prefsmerge@gmail.com", MemberRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := getRouter()
+		r.HandleFunc("/organizations/{id}/members/{mem_id}/notification-preferences", orgs.UpdateNotificationPreferences).Methods("PATCH")
+
+		requestBody := []byte(`{"mentions": false}`)
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/members/%s/notification-preferences", defaultOrgID, memID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, withUser(req, "prefsmerge@gmail.com"))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		prefs, err := fetchNotificationPreferences(context.TODO(), defaultOrgID, memID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if prefs.Mentions || !prefs.Invites || !prefs.Announcements {
+			t.Errorf("expected only mentions to be disabled, got %+v", prefs)
+		}
+	})
+
+	t.Run("test unknown preference is rejected", func(t *testing.T) {
+		memID, err := setUpMember(defaultOrgID, "prefsunknown@gmail.com", MemberRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := getRouter()
+		r.HandleFunc("/organizations/{id}/members/{mem_id}/notification-preferences", orgs.UpdateNotificationPreferences).Methods("PATCH")
+
+		requestBody := []byte(`{"carrier_pigeons": false}`)
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/members/%s/notification-preferences", defaultOrgID, memID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, withUser(req, "prefsunknown@gmail.com"))
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+
+	t.Run("test disabled invites suppress invite email", func(t *testing.T) {
+		mailer := newMockMailService()
+		handler := NewOrganizationHandler(configs, mailer)
+
+		optedOut, optedIn := "invitesoff@gmail.com", "inviteson@gmail.com"
+
+		memID, err := setUpMember(defaultOrgID, optedOut, GuestRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		r := getRouter()
+		r.HandleFunc("/organizations/{id}/members/{mem_id}/notification-preferences", handler.UpdateNotificationPreferences).Methods("PATCH")
+		r.HandleFunc("/organizations/{id}/send-invite", handler.SendInvite).Methods("POST")
+
+		requestBody := []byte(`{"invites": false}`)
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/members/%s/notification-preferences", defaultOrgID, memID), bytes.NewBuffer(requestBody))
+		assertStatusCode(t, getHTTPResponse(t, r, withUser(req, optedOut)).Code, http.StatusOK)
+
+		requestBody = []byte(fmt.Sprintf(`{"emails": [%q, %q]}`, optedOut, optedIn))
+		req, _ = http.NewRequest("POST", fmt.Sprintf("/organizations/%s/send-invite", defaultOrgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if mailer.sentTo(optedOut) {
+			t.Errorf("invite mail sent to %s despite disabled preference", optedOut)
+		}
+
+		if !mailer.sentTo(optedIn) {
+			t.Errorf("invite mail not sent to %s", optedIn)
+		}
+	})
+	t.Run("test only the member and admins manage preferences", func(t *testing.T) {
+		owner, other, admin := "prefsowner@gmail.com", "prefsother@gmail.com", "prefsadmin@gmail.com"
+
+		memID, err := setUpMember(defaultOrgID, owner, MemberRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = setUpMember(defaultOrgID, other, MemberRole); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = setUpMember(defaultOrgID, admin, AdminRole); err != nil {
+			t.Fatal(err)
+		}
+
+		r := getRouter()
+		r.HandleFunc("/organizations/{id}/members/{mem_id}/notification-preferences", orgs.GetNotificationPreferences).Methods("GET")
+		r.HandleFunc("/organizations/{id}/members/{mem_id}/notification-preferences", orgs.UpdateNotificationPreferences).Methods("PATCH")
+
+		url := fmt.Sprintf("/organizations/%s/members/%s/notification-preferences", defaultOrgID, memID)
+
+		for _, tc := range []struct {
+			as   string
+			code int
+		}{
+			{other, http.StatusForbidden},
+			{owner, http.StatusOK},
+			{admin, http.StatusOK},
+		} {
+			req, _ := http.NewRequest("GET", url, nil)
+			assertStatusCode(t, getHTTPResponse(t, r, withUser(req, tc.as)).Code, tc.code)
+
+			req, _ = http.NewRequest("PATCH", url, bytes.NewBufferString(`{"announcements": false}`))
+			assertStatusCode(t, getHTTPResponse(t, r, withUser(req, tc.as)).Code, tc.code)
+		}
+	})
+}
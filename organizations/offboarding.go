@@ -0,0 +1,111 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// Deactivate all active members of an organization invited by the given user, for when someone
+// who invited people leaves. Owners are never deactivated this way, and are reported as skipped.
+// Members who joined before inviters were recorded are not matched.
+func (oh *OrganizationHandler) DeactivateMembersInvitedBy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	var body struct {
+		InvitedBy string `json:"invited_by"`
+	}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	invitedBy := strings.ToLower(strings.TrimSpace(body.InvitedBy))
+	if !utils.IsValidEmail(invitedBy) {
+		utils.GetError(errors.New("invited_by must be the email of the inviter"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := fetchOrganizationWithOwners(r.Context(), orgID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	ownerIDs := []primitive.ObjectID{}
+
+	for _, id := range org.Owners {
+		if pID, err := primitive.ObjectIDFromHex(id); err == nil {
+			ownerIDs = append(ownerIDs, pID)
+		}
+	}
+
+	filter := bson.M{"org_id": orgID, "invited_by": invitedBy, "deleted": bson.M{"$ne": true}}
+
+	// owners are left alone whether they are in the owners set or only hold the owner role
+	owners := bson.M{"$or": []bson.M{{"_id": bson.M{"$in": ownerIDs}}, {"role": OwnerRole}}}
+	skipped := utils.CountCollection(r.Context(), MemberCollectionName, bson.M{"$and": []bson.M{filter, owners}})
+
+	filter["_id"] = bson.M{"$nin": ownerIDs}
+	filter["role"] = bson.M{"$ne": OwnerRole}
+
+	docs, err := utils.GetMongoDBDocs(r.Context(), MemberCollectionName, filter)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	memberIDs := make([]primitive.ObjectID, 0, len(docs))
+	for _, doc := range docs {
+		memberIDs = append(memberIDs, doc["_id"].(primitive.ObjectID))
+	}
+
+	deactivated := int64(0)
+
+	if len(memberIDs) > 0 {
+		// matching on the ids found keeps members invited in the meantime out of the events sent
+		filter["_id"] = bson.M{"$in": memberIDs}
+
+		res, err := utils.GetCollection(MemberCollectionName).UpdateMany(r.Context(), filter,
+			bson.M{"$set": bson.M{"deleted": true, "deleted_at": utils.NowUTC()}})
+		if err != nil {
+			utils.GetError(fmt.Errorf("an error occurred: %s", err), http.StatusInternalServerError, w)
+			return
+		}
+
+		deactivated = res.ModifiedCount
+	}
+
+	utils.GetSuccess("successfully deactivated members", utils.M{
+		"deactivated":    deactivated,
+		"skipped_owners": skipped,
+	}, w)
+
+	go announceDeactivations(orgID, memberIDs)
+}
+
+// announceDeactivations sends out the events DeactivateMember does for each member deactivated.
+func announceDeactivations(orgID string, memberIDs []primitive.ObjectID) {
+	eventChannel := fmt.Sprintf("organizations_%s", orgID)
+
+	for _, id := range memberIDs {
+		memberID := id.Hex()
+
+		utils.Emitter(utils.Event{Identifier: memberID, Type: "User", Event: DeactivateOrganizationMember, Channel: eventChannel, Payload: make(map[string]interface{})})
+		DispatchWebhookEvent(orgID, DeactivateOrganizationMember, utils.M{"member_id": memberID})
+
+		if err := AddSyncMessage(orgID, "leave_organization", EnterLeaveMessage{OrganizationID: orgID, MemberID: memberID}); err != nil {
+			logger.Error("sync error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestDeactivateMembersInvitedBy(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaver := fmt.Sprintf("leaver.%s@gmail.com", utils.GenUUID())
+	invitee := fmt.Sprintf("invitee.%s@gmail.com", utils.GenUUID())
+
+	if err := setUpUser(invitee, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members", orgs.CreateMember).Methods("POST")
+	r.HandleFunc("/organizations/{id}/members/deactivate-invited", orgs.DeactivateMembersInvitedBy).Methods("POST")
+
+	// invitedMember adds a member with the given role to the organization as invited by leaver.
+	invitedMember := func(t *testing.T, role string) string {
+		memberID, err := setUpMember(orgID, fmt.Sprintf("invited.%s@gmail.com", utils.GenUUID()), role)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, memberID, bson.M{"invited_by": leaver}); err != nil {
+			t.Fatal(err)
+		}
+
+		return memberID
+	}
+
+	deactivated := func(memberID string) bool {
+		pMemberID, _ := primitive.ObjectIDFromHex(memberID)
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"_id": pMemberID})
+
+		return doc["deleted"] == true
+	}
+
+	t.Run("test members added record who invited them", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/members", orgID), bytes.NewBufferString(fmt.Sprintf(`{"user_email": %q}`, invitee)))
+
+		response := getHTTPResponse(t, r, withUser(req, leaver))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, activeMemberFilter(orgID, invitee))
+		if doc["invited_by"] != leaver {
+			t.Errorf("expected the member to be invited by %s, got %v", leaver, doc["invited_by"])
+		}
+	})
+
+	t.Run("test members invited by the user are deactivated, owners are not", func(t *testing.T) {
+		invited := []string{invitedMember(t, MemberRole), invitedMember(t, AdminRole)}
+		owner := invitedMember(t, OwnerRole)
+
+		other, err := setUpMember(orgID, fmt.Sprintf("other.%s@gmail.com", utils.GenUUID()), MemberRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/members/deactivate-invited", orgID), bytes.NewBufferString(fmt.Sprintf(`{"invited_by": %q}`, leaver)))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		// the invitee added in the previous test is deactivated along with the others
+		if data["deactivated"] != float64(len(invited)+1) || data["skipped_owners"] != float64(1) {
+			t.Errorf("expected %d members deactivated and 1 owner skipped, got %v", len(invited)+1, data)
+		}
+
+		for _, memberID := range invited {
+			if !deactivated(memberID) {
+				t.Errorf("expected member %s to be deactivated", memberID)
+			}
+		}
+
+		if deactivated(owner) {
+			t.Error("expected the owner to be kept")
+		}
+
+		if deactivated(other) {
+			t.Error("expected members invited by someone else to be kept")
+		}
+
+		if n := utils.CountCollection(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "invited_by": leaver, "deleted": bson.M{"$ne": true}}); n != 1 {
+			t.Errorf("expected only the owner to be left active, found %d members", n)
+		}
+	})
+
+	t.Run("test the inviter must be an email", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/members/deactivate-invited", orgID), bytes.NewBufferString(`{"invited_by": "nobody"}`))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+}
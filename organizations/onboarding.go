@@ -0,0 +1,85 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	OnboardingInvitedMembers  = "invited_members"
+	OnboardingInstalledPlugin = "installed_plugin"
+	OnboardingSetLogo         = "set_logo"
+)
+
+// steps returns every onboarding step by name, with whether it is done.
+func (o Onboarding) steps() map[string]bool {
+	return map[string]bool{
+		OnboardingInvitedMembers:  o.InvitedMembers,
+		OnboardingInstalledPlugin: o.InstalledPlugin,
+		OnboardingSetLogo:         o.SetLogo,
+	}
+}
+
+// PercentComplete is the share of onboarding steps the organization has done, from 0 to 100.
+func (o Onboarding) PercentComplete() int {
+	steps := o.steps()
+	done := 0
+
+	for _, complete := range steps {
+		if complete {
+			done++
+		}
+	}
+
+	return done * 100 / len(steps)
+}
+
+// completeOnboardingStep marks an onboarding step of an organization done. Steps stay done, so
+// repeating the action that completes one changes nothing.
+func completeOnboardingStep(orgID, step string) {
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return
+	}
+
+	field := "onboarding." + step
+
+	_, err = utils.GetCollection(OrganizationCollectionName).UpdateOne(context.TODO(),
+		bson.M{"_id": pOrgID, field: bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{field: true}})
+	if err != nil {
+		logger.Error("could not complete onboarding step %s of %s: %v", step, orgID, err)
+	}
+}
+
+// Get the onboarding steps of an organization and how much of it is complete.
+func (oh *OrganizationHandler) GetOnboardingStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("onboarding status retrieved successfully", utils.M{
+		"steps":            org.Onboarding.steps(),
+		"percent_complete": org.Onboarding.PercentComplete(),
+	}, w)
+}
@@ -0,0 +1,124 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestOnboarding(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	adminID, err := setUpMember(orgID, "onboarder@gmail.com", AdminRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewOrganizationHandler(configs, newMockMailService())
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/onboarding", handler.GetOnboardingStatus).Methods("GET")
+	r.HandleFunc("/organizations/{id}/send-invite", handler.SendInvite).Methods("POST")
+	r.HandleFunc("/organizations/{id}/plugins", handler.AddOrganizationPlugin).Methods("POST")
+
+	// status returns the onboarding steps of the organization and the percentage complete.
+	status := func() (map[string]interface{}, float64) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/onboarding", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		return data["steps"].(map[string]interface{}), data["percent_complete"].(float64)
+	}
+
+	invite := func(email string) {
+		requestBody := []byte(fmt.Sprintf(`{"emails": [%q]}`, email))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/send-invite", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusCreated)
+	}
+
+	t.Run("test new organization has no steps complete", func(t *testing.T) {
+		steps, percent := status()
+
+		for step, done := range steps {
+			if done.(bool) {
+				t.Errorf("expected step %s not to be complete", step)
+			}
+		}
+
+		if percent != 0 {
+			t.Errorf("expected 0%% complete, got %v%%", percent)
+		}
+	})
+
+	t.Run("test inviting members completes its step", func(t *testing.T) {
+		invite("firstinvite@gmail.com")
+
+		steps, percent := status()
+		if steps[OnboardingInvitedMembers] != true {
+			t.Errorf("expected step %s to be complete", OnboardingInvitedMembers)
+		}
+
+		if percent != 33 {
+			t.Errorf("expected 33%% complete, got %v%%", percent)
+		}
+
+		// repeating the action leaves the step as it is
+		invite("secondinvite@gmail.com")
+
+		if steps, again := status(); again != percent || steps[OnboardingInvitedMembers] != true {
+			t.Errorf("expected onboarding to stay at %v%%, got %v%%", percent, again)
+		}
+	})
+
+	t.Run("test installing a plugin completes its step", func(t *testing.T) {
+		res, err := utils.GetCollection(PluginCollectionName).InsertOne(context.TODO(), bson.M{"name": "onboarding plugin", "install_count": 0})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pluginID := res.InsertedID.(primitive.ObjectID).Hex()
+
+		requestBody := []byte(fmt.Sprintf(`{"plugin_id": %q, "user_id": %q}`, pluginID, adminID))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/plugins", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		steps, percent := status()
+		if steps[OnboardingInstalledPlugin] != true {
+			t.Errorf("expected step %s to be complete", OnboardingInstalledPlugin)
+		}
+
+		if percent != 66 {
+			t.Errorf("expected 66%% complete, got %v%%", percent)
+		}
+	})
+
+	t.Run("test setting a logo completes its step", func(t *testing.T) {
+		// uploading a logo needs the file service, so the step is completed as UpdateLogo does
+		completeOnboardingStep(orgID, OnboardingSetLogo)
+
+		steps, percent := status()
+		if steps[OnboardingSetLogo] != true {
+			t.Errorf("expected step %s to be complete", OnboardingSetLogo)
+		}
+
+		if percent != 100 {
+			t.Errorf("expected 100%% complete, got %v%%", percent)
+		}
+	})
+}
@@ -1,9 +1,13 @@
 package organizations
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
@@ -14,6 +18,8 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"zuri.chat/zccore/auth"
 	"zuri.chat/zccore/logger"
 	"zuri.chat/zccore/service"
@@ -25,34 +31,101 @@ import (
 func (oh *OrganizationHandler) GetOrganization(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	org, code, err := oh.lookupOrganization(r)
+	if err != nil {
+		utils.GetError(err, code, w)
+		return
+	}
+
+	etag, err := organizationETag(org)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+
+	utils.GetSuccess("organization retrieved successfully", org, w)
+}
+
+// Check an organization exists without fetching it. Responds like GetOrganization, with the
+// same ETag, but never with a body.
+func (oh *OrganizationHandler) HeadOrganization(w http.ResponseWriter, r *http.Request) {
+	org, code, err := oh.lookupOrganization(r)
+	if err != nil {
+		w.WriteHeader(code)
+		return
+	}
+
+	etag, err := organizationETag(org)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(http.StatusOK)
+}
+
+// lookupOrganization finds the organization in the request path, following merges, as it is
+// served by GetOrganization. On failure it returns the status code to respond with.
+func (oh *OrganizationHandler) lookupOrganization(r *http.Request) (*Organization, int, error) {
 	orgID := mux.Vars(r)["id"]
 	objID, err := primitive.ObjectIDFromHex(orgID)
 
 	if err != nil {
-		utils.GetError(errors.New("invalid id"), http.StatusBadRequest, w)
-		return
+		return nil, http.StatusBadRequest, errors.New("invalid id")
 	}
 
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDocWithReadPref(r.Context(), OrganizationCollectionName, oh.readPreference(), bson.M{"_id": objID})
+	if save != nil {
+		save, _ = followMerge(r.Context(), save)
+	}
 
 	if save == nil {
-		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
-		return
+		return nil, http.StatusNotFound, organizationNotFound(orgID)
 	}
 
 	var org Organization
 	// convert bson to struct
 	bsonBytes, _ := bson.Marshal(save)
 
-	err = bson.Unmarshal(bsonBytes, &org)
-	if err != nil {
-		utils.GetError(err, http.StatusInternalServerError, w)
-		return
+	if err = bson.Unmarshal(bsonBytes, &org); err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// callers kept from knowing whether it exists are told the same as when it does not
+	if oh.hidesOrganization(r, org.ID) {
+		return nil, http.StatusNotFound, organizationNotFound(orgID)
+	}
+
+	if org.Deactivated {
+		return nil, http.StatusForbidden, errOrganizationDeactivated
 	}
 
 	org.Plugins = org.OrgPlugins()
 
-	utils.GetSuccess("organization retrieved successfully", org, w)
+	return &org, http.StatusOK, nil
+}
+
+// readPreference is where read-only handlers read from, see utils.ReadPreference. Handlers that
+// read back what they have just written use the primary instead.
+func (oh *OrganizationHandler) readPreference() *readpref.ReadPref {
+	return utils.ReadPreference(oh.configs.ReadFromSecondaries)
+}
+
+// organizationETag identifies a version of an organization as served, either in full or as its
+// public profile, so it changes whenever any field of the response does.
+func organizationETag(org interface{}) (string, error) {
+	orgJSON, err := json.Marshal(org)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(orgJSON)
+
+	return fmt.Sprintf("%q", hex.EncodeToString(sum[:16])), nil
 }
 
 // Get an organization by url.
@@ -60,7 +133,10 @@ func (oh *OrganizationHandler) GetOrganizationByURL(w http.ResponseWriter, r *ht
 	w.Header().Set("Content-Type", "application/json")
 
 	orgURL := mux.Vars(r)["url"]
-	data, err := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"workspace_url": orgURL})
+	data, err := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"workspace_url": orgURL})
+	if data != nil {
+		data, err = followMerge(r.Context(), data)
+	}
 
 	if data == nil {
 		logger.Error("workspace with url %s doesn't exist!", orgURL)
@@ -82,61 +158,133 @@ func (oh *OrganizationHandler) GetOrganizationByURL(w http.ResponseWriter, r *ht
 		return
 	}
 
+	if org.Deactivated {
+		utils.GetError(errOrganizationDeactivated, http.StatusForbidden, w)
+		return
+	}
+
 	org.Plugins = org.OrgPlugins()
 
 	utils.GetSuccess("organization retrieved successfully", org, w)
 }
 
+// createOrganizationFields are the fields a request creating an organization can set. Everything
+// else, such as its plan, owners or tokens, is set by Create and dropped from the request.
+var createOrganizationFields = []string{"creator_email", "name", "locale", "workspace_url"}
+
 // Create an organization record.
 func (oh *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	var newOrg Organization
 
-	if r.Body == nil {
-		utils.GetError(fmt.Errorf("missing body request"), http.StatusBadRequest, w)
+	ignored, err := utils.ParseAllowedJSONFromRequest(r, &newOrg, createOrganizationFields...)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
-	err := json.NewDecoder(r.Body).Decode(&newOrg)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if status, err := resolveCreator(r, &newOrg); err != nil {
+		utils.GetError(err, status, w)
 		return
 	}
 
-	// validate that email is not empty and it meets the format
-	if !utils.IsValidEmail(newOrg.CreatorEmail) {
-		utils.GetError(fmt.Errorf("invalid email format : %s", newOrg.CreatorEmail), http.StatusBadRequest, w)
+	// the name and workspace url asked for, before they are replaced by the defaults
+	requestedName, requestedURL := newOrg.Name, newOrg.WorkspaceURL
+
+	userDoc, warnings, err := prepareOrganization(r.Context(), &newOrg)
+
+	provisionCreator := errors.Is(err, errCreatorNotFound) && oh.configs.ProvisionMissingCreator
+	if err != nil && !provisionCreator {
+		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
-	// generate workspace url
-	newOrg.Name = "Zuri Chat"
-	newOrg.WorkspaceURL = utils.GenWorkspaceURL(newOrg.Name)
+	if provisionCreator {
+		warnings = append(warnings, fmt.Sprintf("%s has no account, an unverified one is created for them", newOrg.CreatorEmail))
+	}
 
-	userEmail := strings.ToLower(newOrg.CreatorEmail)
-	userName := strings.Split(userEmail, "@")[0]
+	if len(ignored) > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s cannot be set when creating an organization and are ignored", strings.Join(ignored, ", ")))
+	}
 
-	// get creator id
-	creator, _ := auth.FetchUserByEmail(bson.M{"email": userEmail})
-	creatorID := creator.ID
+	if newOrg.Locale, err = oh.organizationLocale(newOrg.Locale); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
 
-	userDoc, _ := utils.GetMongoDBDoc(UserCollectionName, bson.M{"email": newOrg.CreatorEmail})
-	if userDoc == nil {
-		utils.GetError(errors.New("user with this email does not exist"), http.StatusBadRequest, w)
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	// workspace urls are reserved by the logged in user, who resolveCreator made sure there is
+	reserver := strings.ToLower(r.Context().Value(auth.UserContext).(*auth.AuthUser).Email)
 
+	// while organization creation is waitlisted, a dry run still validates without joining it
+	if !dryRun {
+		allowed, position, err := oh.waitlistGate(r.Context(), reserver, r.URL.Query().Get("invite_code"))
+		if err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		if !allowed {
+			respondWaitlisted(w, reserver, position)
+			return
+		}
+	}
+
+	if requestedURL != "" {
+		if status, err := oh.claimWorkspaceURL(r.Context(), &newOrg, requestedURL, reserver, dryRun); err != nil {
+			utils.GetError(err, status, w)
+			return
+		}
+	}
+
+	defaultFlags, err := oh.planFeatureFlags(newOrg.Version)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
-	newOrg.CreatorID = creatorID
-	newOrg.CreatorEmail = userEmail
-	newOrg.CreatedAt = time.Now()
+	newOrg.FeatureFlags = reconcileFeatureFlags(defaultFlags, nil)
 
-	newOrg.Plugins = map[string]interface{}{}
+	// organizations named like one the creator already has are returned for them to confirm
+	// it is not a duplicate, without stopping the organization being created
+	similar, err := oh.similarOrganizations(r.Context(), newOrg.CreatorEmail, requestedName)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
 
-	// initialize organization with 100 free tokens
-	newOrg.Tokens = 100
-	newOrg.Version = FreeVersion
+	// a dry run stops once the organization is validated, so nothing is written
+	if dryRun {
+		utils.GetSuccess("organization is valid, nothing was created", utils.M{
+			"would_create": utils.M{
+				"name":          newOrg.Name,
+				"workspace_url": newOrg.WorkspaceURL,
+				"creator_email": newOrg.CreatorEmail,
+				"version":       newOrg.Version,
+				"locale":        newOrg.Locale,
+				"tokens":        newOrg.Tokens,
+				"feature_flags": newOrg.FeatureFlags,
+			},
+			"warnings":              warnings,
+			"similar_organizations": similar,
+		}, w)
+
+		return
+	}
+
+	if provisionCreator {
+		if userDoc, err = oh.provisionUser(r.Context(), newOrg.CreatorEmail, newOrg.Locale); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		newOrg.CreatorID = userDoc["_id"].(primitive.ObjectID).Hex()
+	}
+
+	creatorID := newOrg.CreatorID
+	userName := strings.Split(newOrg.CreatorEmail, "@")[0]
 
 	// convert to map object
 	var inInterface map[string]interface{}
@@ -150,15 +298,21 @@ func (oh *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// save organization
-	save, err := utils.CreateMongoDBDoc(OrganizationCollectionName, inInterface)
+	save, err := utils.CreateMongoDBDoc(r.Context(), OrganizationCollectionName, inInterface)
 	if err != nil {
-		utils.GetError(err, http.StatusInternalServerError, w)
+		utils.GetWriteError(err, w)
 		return
 	}
 
 	iid := save.InsertedID
 	iiid := iid.(primitive.ObjectID).Hex()
 
+	if requestedURL != "" {
+		if err = releaseWorkspaceURL(r.Context(), newOrg.WorkspaceURL, reserver); err != nil {
+			logger.Error("could not release reservation of workspace url %s: %v", newOrg.WorkspaceURL, err)
+		}
+	}
+
 	// Adding user as a member
 	var userObj user.User
 	if err = mapstructure.Decode(userDoc, &userObj); err != nil {
@@ -170,7 +324,16 @@ func (oh *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// add new member to member collection
 	coll := utils.GetCollection(MemberCollectionName)
-	if _, err = coll.InsertOne(r.Context(), newMember); err != nil {
+
+	memberRes, err := coll.InsertOne(r.Context(), newMember)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// the creator is the first owner
+	owners := []string{memberRes.InsertedID.(primitive.ObjectID).Hex()}
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, iiid, bson.M{"owners": owners}); err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
@@ -181,43 +344,288 @@ func (oh *OrganizationHandler) Create(w http.ResponseWriter, r *http.Request) {
 	userObj.Organizations = append(userObj.Organizations, iiid)
 
 	updateFields["workspaces"] = userObj.Organizations
-	_, ee := utils.UpdateOneMongoDBDoc(UserCollectionName, creatorID, updateFields)
+	_, ee := utils.UpdateOneMongoDBDoc(r.Context(), UserCollectionName, creatorID, updateFields)
 
 	if ee != nil {
 		utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
 		return
 	}
 
-	utils.GetSuccess("organization created", utils.M{"organization_id": save.InsertedID}, w)
+	utils.GetCreated("organization created", fmt.Sprintf("/organizations/%s", iiid), utils.M{
+		"organization_id":       save.InsertedID,
+		"similar_organizations": similar,
+		"ignored_fields":        ignored,
+	}, w)
+}
+
+// resolveCreator makes the logged in user the creator of a new organization. A creator_email
+// in the request must be theirs, unless they are a Zuri admin creating the organization for
+// someone else. It returns the status to respond with when the creator is not allowed.
+func resolveCreator(r *http.Request, newOrg *Organization) (int, error) {
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		return http.StatusUnauthorized, errors.New("user not logged in")
+	}
+
+	if newOrg.CreatorEmail == "" {
+		newOrg.CreatorEmail = loggedInUser.Email
+		return http.StatusOK, nil
+	}
+
+	if strings.EqualFold(newOrg.CreatorEmail, loggedInUser.Email) {
+		return http.StatusOK, nil
+	}
+
+	if caller, err := auth.FetchUserByEmail(bson.M{"email": strings.ToLower(loggedInUser.Email)}); err == nil && caller.Role == "admin" {
+		return http.StatusOK, nil
+	}
+
+	return http.StatusForbidden, errors.New("organizations can only be created for yourself")
+}
+
+// prepareOrganization validates a new organization and fills in the fields set on creation.
+// It returns the creator's user document and warnings about parts of the request that are ignored.
+func prepareOrganization(ctx context.Context, newOrg *Organization) (bson.M, []string, error) {
+	// validate that email is not empty and it meets the format
+	if !utils.IsValidEmail(newOrg.CreatorEmail) {
+		return nil, nil, fmt.Errorf("invalid email format : %s", newOrg.CreatorEmail)
+	}
+
+	warnings := []string{}
+
+	// every organization starts with the default name and is renamed afterwards
+	if newOrg.Name != "" && newOrg.Name != DefaultOrganizationName {
+		warnings = append(warnings, fmt.Sprintf("name %q is ignored, organizations are created as %q", newOrg.Name, DefaultOrganizationName))
+	}
+
+	// generate workspace url
+	newOrg.Name = DefaultOrganizationName
+	newOrg.WorkspaceURL = utils.GenWorkspaceURL(newOrg.Name)
+
+	userEmail := strings.ToLower(newOrg.CreatorEmail)
+
+	// get creator id
+	creator, _ := auth.FetchUserByEmail(bson.M{"email": userEmail})
+
+	userDoc, _ := utils.GetMongoDBDoc(ctx, UserCollectionName, bson.M{"email": newOrg.CreatorEmail})
+
+	newOrg.CreatorID = creator.ID
+	newOrg.CreatorEmail = userEmail
+	newOrg.CreatedAt = utils.NowUTC()
+
+	newOrg.Plugins = map[string]interface{}{}
+	newOrg.Tags = []string{}
+	newOrg.FeatureFlagOverrides = map[string]bool{}
+	newOrg.SchemaVersion = CurrentSchemaVersion
+
+	// initialize organization with 100 free tokens
+	newOrg.Tokens = 100
+	newOrg.Version = FreeVersion
+
+	// the organization is still filled in, in case the creator is provisioned
+	if userDoc == nil {
+		return nil, warnings, errCreatorNotFound
+	}
+
+	return userDoc, warnings, nil
+}
+
+// Organization listings are lean by default: the fields below, and the members of each
+// organization, are left out unless named in ?expand=, such as ?expand=members,plugins.
+// Lean fields are projected out so they are not read at all, and members are only looked up
+// when asked for.
+var leanOrganizationFields = []string{"plugins", "announcements"}
+
+// privateOrganizationFields are never listed, whatever is expanded. Listings are served as
+// stored rather than as an Organization, so these are the fields it keeps out of its JSON,
+// along with webhooks, whose secrets are kept out of theirs. Webhooks are served by
+// GetWebhooks instead.
+var privateOrganizationFields = []string{
+	"webhooks", "plugin_config", "deletion_confirmation", "export_schedule", "billing_address",
+	"billing_contact_email", "merged_into", "deleted", "deleted_at", "pending", "claim",
 }
 
-// Get all organization records.
+// privateMemberFields are the fields of members that are never listed with their organization.
+var privateMemberFields = []string{"expiry_reminders_sent"}
+
+const expandMembers = "members"
+
+// sortableOrganizationFields are the fields organization listings can be sorted by with ?sort=,
+// such as ?sort=-updated_at,name.
+var sortableOrganizationFields = []string{"name", "workspace_url", "created_at", "updated_at"}
+
+// parseExpand returns the fields named in the expand query parameter of a listing.
+func parseExpand(r *http.Request) (map[string]bool, error) {
+	expand := map[string]bool{}
+
+	for _, field := range strings.Split(r.URL.Query().Get("expand"), ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+
+		known := field == expandMembers
+		for _, lean := range leanOrganizationFields {
+			known = known || field == lean
+		}
+
+		if !known {
+			return nil, fmt.Errorf("%s cannot be expanded", field)
+		}
+
+		expand[field] = true
+	}
+
+	return expand, nil
+}
+
+// attachMembers adds the members of each organization to it, as a members list.
+func attachMembers(ctx context.Context, orgs []bson.M) error {
+	orgIDs := make([]string, 0, len(orgs))
+	members := make(map[string][]bson.M, len(orgs))
+
+	for _, org := range orgs {
+		if pOrgID, ok := org["_id"].(primitive.ObjectID); ok {
+			orgIDs = append(orgIDs, pOrgID.Hex())
+		}
+	}
+
+	projection := bson.M{}
+	for _, field := range privateMemberFields {
+		projection[field] = 0
+	}
+
+	docs, err := utils.GetMongoDBDocs(ctx, MemberCollectionName, bson.M{"org_id": bson.M{"$in": orgIDs}, "deleted": bson.M{"$ne": true}}, options.Find().SetProjection(projection))
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range docs {
+		orgID, _ := doc["org_id"].(string)
+		members[orgID] = append(members[orgID], doc)
+	}
+
+	for _, org := range orgs {
+		pOrgID, _ := org["_id"].(primitive.ObjectID)
+
+		org[expandMembers] = []bson.M{}
+		if m, ok := members[pOrgID.Hex()]; ok {
+			org[expandMembers] = m
+		}
+	}
+
+	return nil
+}
+
+// Get all organization records, lean unless fields are expanded. See leanOrganizationFields,
+// and sortableOrganizationFields for sorting.
 func (oh *OrganizationHandler) GetOrganizations(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	save, err := utils.GetMongoDBDocs(OrganizationCollectionName, nil)
+	expand, err := parseExpand(r)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	sort, err := utils.ParseSort(r.URL.Query().Get("sort"), sortableOrganizationFields...)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	projection := bson.M{}
+
+	for _, field := range leanOrganizationFields {
+		if !expand[field] {
+			projection[field] = 0
+		}
+	}
+
+	for _, field := range privateOrganizationFields {
+		projection[field] = 0
+	}
+
+	// pending organizations are listed once they are claimed, and deleted ones are listed
+	// apart, see ListDeletedOrganizations
+	filter := bson.M{"pending": bson.M{"$ne": true}, "deleted": bson.M{"$ne": true}}
+
+	if err = oh.listableOrganizations(r, filter); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	opts := options.Find().SetProjection(projection)
+	if sort != nil {
+		opts.SetSort(sort)
+	}
+
+	save, err := utils.GetMongoDBDocsWithReadPref(r.Context(), OrganizationCollectionName, oh.readPreference(), filter, opts)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
+	if expand[expandMembers] {
+		if err = attachMembers(r.Context(), save); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
 	utils.GetSuccess("organizations retrieved successfully", save, w)
 }
 
-// Delete an organization record.
+// Delete an organization record. The first call returns a confirmation token and what will be
+// deleted, and the organization is deleted when the token is sent back as confirmation_token.
 func (oh *OrganizationHandler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	orgID := mux.Vars(r)["id"]
-	response, err := utils.DeleteOneMongoDBDoc(OrganizationCollectionName, orgID)
 
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
 	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	// the first call only asks for confirmation, the organization is deleted when it is given
+	token := r.URL.Query().Get("confirmation_token")
+	if token == "" {
+		oh.requestDeletion(w, r, pOrgID)
+		return
+	}
+
+	confirmed := bson.M{
+		"_id":                              pOrgID,
+		"deletion_confirmation.token":      token,
+		"deletion_confirmation.expires_at": bson.M{"$gt": utils.NowUTC()},
+	}
+
+	org, err := FetchOrganization(r.Context(), confirmed)
+	if err != nil {
+		utils.GetError(errInvalidDeletionToken, http.StatusBadRequest, w)
+		return
+	}
+
+	if _, err = organizationOwners(r.Context(), org); err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
-	if response.DeletedCount == 0 {
-		utils.GetError(errors.New("operation failed"), http.StatusInternalServerError, w)
+	// organizations with several owners are only deleted once another owner approves
+	if needsOwnerApproval(org) {
+		oh.requestOwnerApproval(w, r, org, ApprovalDeleteOrganization, nil)
+		return
+	}
+
+	deleted, err := deleteOrganization(r.Context(), pOrgID, confirmed)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if !deleted {
+		utils.GetError(errInvalidDeletionToken, http.StatusBadRequest, w)
 		return
 	}
 
@@ -258,7 +666,7 @@ func (oh *OrganizationHandler) TransferOwnership(w http.ResponseWriter, r *http.
 	}
 
 	// Checks if organization exists in the database
-	orgDoc, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": orgIDHex})
+	orgDoc, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": orgIDHex})
 	if orgDoc == nil {
 		utils.GetError(errors.New("organization does not exist"), http.StatusBadRequest, w)
 		return
@@ -280,7 +688,7 @@ func (oh *OrganizationHandler) TransferOwnership(w http.ResponseWriter, r *http.
 	}
 
 	// fetches the details of the proposed new owner patterned after member's struct
-	orgMember, err := FetchMember(bson.M{"org_id": orgID, "email": email})
+	orgMember, err := FetchMember(r.Context(), bson.M{"org_id": orgID, "email": email})
 
 	if err != nil {
 		utils.GetError(errors.New("user not a member of this work space"), http.StatusBadRequest, w)
@@ -296,49 +704,71 @@ func (oh *OrganizationHandler) TransferOwnership(w http.ResponseWriter, r *http.
 	// member ID of the proposed new owner
 	memberID := orgMember.ID
 
-	// upgrades status from member to owner
-	updateRes, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, bson.M{"role": OwnerRole})
-
+	// organizations predating co-owners get their owners set before any role changes
+	org, err := fetchOrganizationWithOwners(r.Context(), orgID)
 	if err != nil {
-		utils.GetError(errors.New("operation failed"), http.StatusInternalServerError, w)
-		return
-	}
-
-	if updateRes.ModifiedCount == 0 {
-		utils.GetError(errors.New("could not upgrade member's role"), http.StatusInternalServerError, w)
+		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
-	// fetches details of the former owner so we can get keys to downgrade status to member
 	// checks like isOwner and memberExists are not made since auth.IsAuthorized function already
 	// this user pass marks
-
 	loggedInUser, ok := r.Context().Value("user").(*auth.AuthUser)
 	if !ok {
 		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
 		return
 	}
 
-	formerOwner, _ := FetchMember(bson.M{"org_id": orgID, "email": loggedInUser.Email})
+	// organizations with several owners only change hands once another owner approves
+	if needsOwnerApproval(org) {
+		oh.requestOwnerApproval(w, r, org, ApprovalTransferOwnership, map[string]string{"email": email, "member_id": memberID})
+		return
+	}
+
+	if err = transferOwnership(orgID, memberID, loggedInUser.Email); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// and we are done!!!
+	utils.GetSuccess("workspace owner changed successfully", nil, w)
+}
+
+// transferOwnership makes a member the owner of an organization in place of the owner with
+// the given email, who keeps admin rights.
+func transferOwnership(orgID, memberID, formerOwnerEmail string) error {
+	// upgrades status from member to owner
+	updateRes, err := utils.UpdateOneMongoDBDocWithWriteConcern(context.TODO(), MemberCollectionName, utils.WriteConcern(utils.WriteCritical), memberID, bson.M{"role": OwnerRole})
+	if err != nil {
+		return errors.New("operation failed")
+	}
+
+	if updateRes.ModifiedCount == 0 {
+		return errors.New("could not upgrade member's role")
+	}
+
+	// fetches details of the former owner so we can get keys to downgrade status to member
+	formerOwner, _ := FetchMember(context.TODO(), bson.M{"org_id": orgID, "email": formerOwnerEmail})
 
 	// ID of former owner
 	formerOwnerID := formerOwner.ID
 
 	// role downgraded from owner to member
-	update, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, formerOwnerID, bson.M{"role": AdminRole})
-
+	update, err := utils.UpdateOneMongoDBDocWithWriteConcern(context.TODO(), MemberCollectionName, utils.WriteConcern(utils.WriteCritical), formerOwnerID, bson.M{"role": AdminRole})
 	if err != nil {
-		utils.GetError(errors.New("operation failed"), http.StatusInternalServerError, w)
-		return
+		return errors.New("operation failed")
 	}
 
 	if update.ModifiedCount == 0 {
-		utils.GetError(errors.New("could not downgrade owner's role"), http.StatusInternalServerError, w)
-		return
+		return errors.New("could not downgrade owner's role")
 	}
 
-	// and we are done!!!
-	utils.GetSuccess("workspace owner changed successfully", nil, w)
+	// keep the owners set in step with the roles
+	if err = addOwner(orgID, memberID); err != nil {
+		return err
+	}
+
+	return removeOwner(orgID, formerOwnerID)
 }
 
 // Update organization logo.
@@ -348,21 +778,37 @@ func (oh *OrganizationHandler) UpdateLogo(w http.ResponseWriter, r *http.Request
 	orgID := mux.Vars(r)["id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
+	size, ok := reserveUploadStorage(w, r, orgID, "image")
+	if !ok {
+		return
+	}
+
 	uploadPath := "logo/" + orgID
 
 	imgURL, err := service.ProfileImageUpload(uploadPath, logoWidth, logoHeight, r)
 	if err != nil {
+		cancelReservation(orgID, size)
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if err = recordStoredFile(orgID, imgURL, size); err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, bson.M{"logo_url": imgURL})
+	pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+	if org, ferr := FetchOrganization(r.Context(), bson.M{"_id": pOrgID}); ferr == nil {
+		discardStoredFile(orgID, org.LogoURL)
+	}
+
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, bson.M{"logo_url": imgURL})
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
@@ -374,6 +820,8 @@ func (oh *OrganizationHandler) UpdateLogo(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	completeOnboardingStep(orgID, OnboardingSetLogo)
+
 	eventChannel := fmt.Sprintf("organizations_%s", orgID)
 	event := utils.Event{Identifier: orgID, Type: "Organization", Event: UpdateOrganizationLogo, Channel: eventChannel, Payload: make(map[string]interface{})}
 
@@ -382,7 +830,8 @@ func (oh *OrganizationHandler) UpdateLogo(w http.ResponseWriter, r *http.Request
 	utils.GetSuccess("Logo updated successfully", imgURL, w)
 }
 
-// Send invite to a list of emails.
+// Send invite to a list of emails. Each email is reported as invited, failed or skipped when it
+// repeats an earlier one. Sending a single invite responds with a 201 pointing at it.
 func (oh *OrganizationHandler) SendInvite(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -408,69 +857,117 @@ func (oh *OrganizationHandler) SendInvite(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	org, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": orgID})
+	org, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": orgID})
 	if org == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
 		return
 	}
 
-	var invalidEmails []interface{}
+	lifetime, err := oh.inviteLifetime(inviteExpiryHours(org), guests.ExpiresInHours)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var inviter Organization
+	if err = utils.BsonToStruct(org, &inviter); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	expiresAt := utils.NowUTC().Add(lifetime)
 
-	inviteIDs := make([]interface{}, len(guests.Emails))
+	result := utils.NewBulkResult()
+	seen := make(map[string]bool)
+	invites := []*Invite{}
 
 	for _, email := range guests.Emails {
-		// Check the validity of email send
 		if !utils.IsValidEmail(email) {
-			// If Email is invalid append to list to invalid emails
-			invalidEmails = append(invalidEmails, email)
+			result.Fail(email, "invalid email address", nil)
 			continue
 		}
-		// Generate new UUI for invite and
-		uuid := utils.GenUUID()
-
-		newInvite := Invite{OrgID: sOrgID, UUID: uuid, Email: email, HasAccepted: false}
 
-		var invInterface map[string]interface{}
+		if seen[strings.ToLower(email)] {
+			result.Skip(email, "email appears earlier in the list", nil)
+			continue
+		}
 
-		inrec, _ := json.Marshal(newInvite)
-		err = json.Unmarshal(inrec, &invInterface)
+		seen[strings.ToLower(email)] = true
 
+		invite, err := oh.inviteGuest(&inviter, email, "", loggedInUser.Email, expiresAt)
 		if err != nil {
-			utils.GetError(err, http.StatusInternalServerError, w)
-			return
+			result.Fail(email, err.Error(), nil)
+			continue
 		}
 
-		// Save newly generated uuid and associated info in the database
-		save, err := utils.CreateMongoDBDoc(OrganizationInviteCollectionName, invInterface)
-		if err != nil {
-			utils.GetError(err, http.StatusInternalServerError, w)
+		invites = append(invites, invite)
+		result.Succeed(email, utils.M{"invite_id": invite.ID, "expires_at": expiresAt})
+	}
 
-			return
-		}
+	// a single invite is a new resource clients can follow, several are reported item by item
+	if len(invites) == 1 && len(result.Failed) == 0 {
+		utils.GetCreated("Organization invite operation result", inviteLocation(invites[0]), result, w)
+		return
+	}
 
-		// Append new invite to array of generated invites
-		inviteIDs = append(inviteIDs, save.InsertedID)
-
-		// Parse data for customising email template
-		
-		inviteLink := fmt.Sprintf("%s/%s", os.Getenv("INVITE_DOMAIN"), uuid)
-		orgName := fmt.Sprintf("%v", org["name"])
-
-		msger := oh.mailService.NewMail(
-			[]string{email}, "Zuri Chat Workspace Invite", service.WorkSpaceInvite, map[string]interface{}{
-				"Username":   loggedInUser.Email,
-				"OrgName":    orgName,
-				"InviteLink": inviteLink,
-			})
-		// error with sending main
-		if err := oh.mailService.SendMail(msger); err != nil {
-			logger.Error("Error occurred while sending mail: %s", err.Error())
-		}
+	utils.GetBulkResult("Organization invite operation result", result, w)
+}
+
+// inviteLocation is the canonical URL of an invite.
+func inviteLocation(invite *Invite) string {
+	return fmt.Sprintf("/organizations/invites/%s", invite.UUID)
+}
+
+// inviteGuest saves an invite to an organization and emails the invite link in the organization's
+// locale. Guests join with the given role when they accept, or as members when it is empty, until
+// the invite expires.
+func (oh *OrganizationHandler) inviteGuest(org *Organization, email, role, inviterEmail string, expiresAt time.Time) (*Invite, error) {
+	orgID := org.ID
+
+	// Generate new UUI for invite and
+	uuid := utils.GenUUID()
+	now := utils.NowUTC()
+
+	newInvite := Invite{OrgID: orgID, UUID: uuid, Email: email, Role: role, HasAccepted: false, ExpiresAt: expiresAt, InvitedBy: strings.ToLower(inviterEmail), LastSentAt: &now}
+
+	// Save newly generated uuid and associated info in the database
+	save, err := utils.GetCollection(OrganizationInviteCollectionName).InsertOne(context.TODO(), newInvite)
+	if err != nil {
+		return nil, err
 	}
 
-	response := SendInviteResponse{InvalidEmails: invalidEmails, InviteIDs: inviteIDs}
+	newInvite.ID = save.InsertedID.(primitive.ObjectID).Hex()
 
-	utils.GetSuccess("Organization invite operation result", response, w)
+	completeOnboardingStep(orgID, OnboardingInvitedMembers)
+
+	oh.sendInviteMail(org, &newInvite, inviterEmail)
+
+	return &newInvite, nil
+}
+
+// sendInviteMail emails the link to an invite in the organization's locale, unless the invitee
+// is already a member who turned invite emails off.
+func (oh *OrganizationHandler) sendInviteMail(org *Organization, invite *Invite, inviterEmail string) {
+	// respect the invitee's notification preferences if they are already known to the organization
+	if !memberAllowsEmail(context.TODO(), org.ID, invite.Email, NotifyInvites) {
+		return
+	}
+
+	// Parse data for customising email template
+	inviteLink := fmt.Sprintf("%s/%s", os.Getenv("INVITE_DOMAIN"), invite.UUID)
+
+	msger := oh.mailService.NewMail(
+		[]string{invite.Email}, "Zuri Chat Workspace Invite", service.WorkSpaceInvite, map[string]interface{}{
+			"Username":   inviterEmail,
+			"OrgName":    org.Name,
+			"InviteLink": inviteLink,
+		}).WithLocale(org.Locale)
+	msger = org.sentFor(msger)
+
+	// error with sending main
+	if err := oh.mailService.SendMail(msger); err != nil {
+		logger.Error("Error occurred while sending mail: %s", err.Error())
+	}
 }
 
 // Get invite records of an organization.
@@ -479,9 +976,10 @@ func (oh *OrganizationHandler) InviteStats(w http.ResponseWriter, r *http.Reques
 
 	orgID := mux.Vars(r)["id"]
 
-	invites, err := utils.GetMongoDBDocs(OrganizationInviteCollectionName, bson.M{"org_id": orgID})
+	invites, err := utils.GetMongoDBDocsWithReadPref(r.Context(), OrganizationInviteCollectionName, oh.readPreference(), bson.M{"org_id": orgID})
 	if err != nil {
 		utils.GetError(err, http.StatusNotFound, w)
+		return
 	}
 
 	utils.GetSuccess("successful", invites, w)
@@ -505,21 +1003,29 @@ func (oh *OrganizationHandler) UpgradeToPro(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err = SubscriptionBilling(orgID, float64(ProSubscriptionRate)); err != nil {
-		utils.GetError(err, http.StatusExpectationFailed, w)
-	}
+	pOrgID, _ := primitive.ObjectIDFromHex(orgID)
 
-	updateData := make(map[string]interface{})
-	updateData["version"] = ProVersion
-
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, updateData)
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
-	if update.ModifiedCount == 0 {
-		utils.GetError(errors.New("operation failed"), http.StatusInternalServerError, w)
+	if err = SubscriptionBilling(orgID, float64(ProSubscriptionRate)); err != nil {
+		oh.notifyPaymentFailed(org, "Pro version subscription", err)
+
+		utils.GetError(err, http.StatusExpectationFailed, w)
+		return
+	}
+
+	var actor string
+	if loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser); ok {
+		actor = loggedInUser.Email
+	}
+
+	// pro features are switched on along with the plan
+	if _, err = oh.changePlan(org, ProVersion, actor); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
@@ -532,7 +1038,7 @@ func IsProVersion(orgID string) (bool, error) {
 		return false, err
 	}
 
-	organization, err := FetchOrganization(bson.M{"_id": OrgIDFromHex})
+	organization, err := FetchOrganization(context.TODO(), bson.M{"_id": OrgIDFromHex})
 	if err != nil {
 		return false, err
 	}
@@ -568,20 +1074,46 @@ func (oh *OrganizationHandler) UpdateBillingContact(w http.ResponseWriter, r *ht
 	updateBilling(w, r, payload)
 }
 
-// Update an organization settings.
+// Update an organization settings. The locale of the organization can be changed along with them.
 func (oh *OrganizationHandler) UpdateOrganizationSettings(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	orgID := mux.Vars(r)["id"]
 
-	var orgSettings OrgSettings
+	var orgSettings struct {
+		OrgSettings
+		Locale        *string `json:"locale"`
+		EmailFromName *string `json:"email_from_name"`
+		EmailReplyTo  *string `json:"email_reply_to"`
+	}
+
+	if r.Body == nil {
+		utils.GetError(errors.New("missing body request"), http.StatusUnprocessableEntity, w)
+		return
+	}
 
-	err := utils.ParseJSONFromRequest(r, &orgSettings)
+	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		utils.GetError(err, http.StatusUnprocessableEntity, w)
 		return
 	}
 
+	var patch map[string]interface{}
+	if err = json.Unmarshal(body, &patch); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if errs := validateSettingsPatch(patch, organizationSettingsSchema); errs != nil {
+		utils.GetDetailedError("invalid organization settings", http.StatusUnprocessableEntity, errs, w)
+		return
+	}
+
+	if err = json.Unmarshal(body, &orgSettings); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
 	objID, err := primitive.ObjectIDFromHex(orgID)
 
 	if err != nil {
@@ -592,7 +1124,7 @@ func (oh *OrganizationHandler) UpdateOrganizationSettings(w http.ResponseWriter,
 	validate := validator.New()
 
 	// get previous settings
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -617,7 +1149,7 @@ func (oh *OrganizationHandler) UpdateOrganizationSettings(w http.ResponseWriter,
 	}
 	// adds new settings with existing settings
 	orgPref := OrganizationPreference{
-		orgSettings,
+		orgSettings.OrgSettings,
 		org.Settings.Permissions,
 		org.Settings.Authentication,
 	}
@@ -625,7 +1157,38 @@ func (oh *OrganizationHandler) UpdateOrganizationSettings(w http.ResponseWriter,
 	orgFilter := make(map[string]interface{})
 	orgFilter["settings"] = orgPref
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	if orgSettings.Locale != nil {
+		locale, err := utils.ValidateLocale(*orgSettings.Locale, oh.configs.SupportedLocales)
+		if err != nil {
+			utils.GetError(err, http.StatusBadRequest, w)
+			return
+		}
+
+		orgFilter["locale"] = locale
+	}
+
+	// emails sent on the organization's behalf can carry its name and reply-to address, and go
+	// back to ours when they are cleared
+	if orgSettings.EmailFromName != nil || orgSettings.EmailReplyTo != nil {
+		fromName, replyTo := org.EmailFromName, org.EmailReplyTo
+
+		if orgSettings.EmailFromName != nil {
+			fromName = strings.TrimSpace(*orgSettings.EmailFromName)
+		}
+
+		if orgSettings.EmailReplyTo != nil {
+			replyTo = strings.ToLower(strings.TrimSpace(*orgSettings.EmailReplyTo))
+		}
+
+		if err := validateEmailSender(fromName, replyTo); err != nil {
+			utils.GetError(err, http.StatusBadRequest, w)
+			return
+		}
+
+		orgFilter["email_from_name"], orgFilter["email_reply_to"] = fromName, replyTo
+	}
+
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -662,7 +1225,7 @@ func (oh *OrganizationHandler) UpdateOrganizationPermission(w http.ResponseWrite
 	validate := validator.New()
 
 	// get previous settings
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
 		return
@@ -695,7 +1258,7 @@ func (oh *OrganizationHandler) UpdateOrganizationPermission(w http.ResponseWrite
 	orgFilter := make(map[string]interface{})
 	orgFilter["settings"] = orgPref
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -733,7 +1296,7 @@ func (oh *OrganizationHandler) UpdateOrganizationAuthentication(w http.ResponseW
 	validate := validator.New()
 
 	// get previous settings
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -766,7 +1329,7 @@ func (oh *OrganizationHandler) UpdateOrganizationAuthentication(w http.ResponseW
 	orgFilter := make(map[string]interface{})
 	orgFilter["settings"] = orgPref
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -804,7 +1367,7 @@ func (oh *OrganizationHandler) UpdateOrganizationPrefixes(w http.ResponseWriter,
 	validate := validator.New()
 
 	// get previous settings
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -837,7 +1400,7 @@ func (oh *OrganizationHandler) UpdateOrganizationPrefixes(w http.ResponseWriter,
 	orgFilter := make(map[string]interface{})
 	orgFilter["customize"] = orgPref
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -875,7 +1438,7 @@ func (oh *OrganizationHandler) UpdateSlackBotResponses(w http.ResponseWriter, r
 	validate := validator.New()
 
 	// get previous responses
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -908,7 +1471,7 @@ func (oh *OrganizationHandler) UpdateSlackBotResponses(w http.ResponseWriter, r
 	orgFilter := make(map[string]interface{})
 	orgFilter["customize"] = orgPref
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -945,7 +1508,7 @@ func (oh *OrganizationHandler) AddSlackCustomEmoji(w http.ResponseWriter, r *htt
 	validate := validator.New()
 
 	// get previous responses
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -978,7 +1541,7 @@ func (oh *OrganizationHandler) AddSlackCustomEmoji(w http.ResponseWriter, r *htt
 	orgFilter := make(map[string]interface{})
 	orgFilter["customize"] = orgPref
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
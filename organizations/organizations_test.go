@@ -2,12 +2,15 @@ package organizations
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"zuri.chat/zccore/auth"
 	"zuri.chat/zccore/utils"
 )
@@ -37,10 +40,11 @@ func TestCreateOrganization(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
+		// without a creator_email, the organization is created for the logged in user
 		response := httptest.NewRecorder()
-		orgs.Create(response, req)
+		orgs.Create(response, withUser(req, "badmailformat.xyz"))
 		assertStatusCode(t, response.Code, http.StatusBadRequest)
-		assertResponseMessage(t, parseResponse(response)["message"].(string), "invalid email format : ")
+		assertResponseMessage(t, parseResponse(response)["message"].(string), "invalid email format : badmailformat.xyz")
 	})
 
 	t.Run("test for bad email format", func(t *testing.T) {
@@ -51,7 +55,7 @@ func TestCreateOrganization(t *testing.T) {
 			t.Fatal(err)
 		}
 		response := httptest.NewRecorder()
-		orgs.Create(response, req)
+		orgs.Create(response, withUser(req, "badmailformat.xyz"))
 		assertStatusCode(t, response.Code, http.StatusBadRequest)
 		assertResponseMessage(t, parseResponse(response)["message"].(string), "invalid email format : badmailformat.xyz")
 	})
@@ -64,7 +68,7 @@ func TestCreateOrganization(t *testing.T) {
 			t.Fatal(err)
 		}
 		response := httptest.NewRecorder()
-		orgs.Create(response, req)
+		orgs.Create(response, withUser(req, "notuser@gmail.com"))
 		assertStatusCode(t, response.Code, http.StatusBadRequest)
 		assertResponseMessage(t, parseResponse(response)["message"].(string), "user with this email does not exist")
 	})
@@ -81,15 +85,19 @@ func TestCreateOrganization(t *testing.T) {
 			t.Fatal(err)
 		}
 		response := httptest.NewRecorder()
-		orgs.Create(response, req)
-		assertStatusCode(t, response.Code, http.StatusOK)
+		orgs.Create(response, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, http.StatusCreated)
 
 		// assert that the created org owner is a member of the org
 		res := parseResponse(response)
 		data := res["data"].(map[string]interface{})
 		orgID := data["organization_id"].(string)
 
-		memDoc, _ := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"org_id": orgID, "email": defaultUser})
+		if location := response.Header().Get("Location"); location != "/organizations/"+orgID {
+			t.Errorf("expected location /organizations/%s, got %q", orgID, location)
+		}
+
+		memDoc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": defaultUser})
 		if memDoc == nil{
 			t.Errorf("user %s not found in org %s", defaultUser, orgID)
 		}
@@ -131,6 +139,170 @@ func TestGetOrganization(t *testing.T) {
 	})
 }
 
+func TestHeadOrganization(t *testing.T) {
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}", orgs.GetOrganization).Methods("GET")
+	r.HandleFunc("/organizations/{id}", orgs.HeadOrganization).Methods("HEAD")
+
+	head := func(orgID string, expectedCode int) string {
+		req, _ := http.NewRequest("HEAD", fmt.Sprintf("/organizations/%s", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, expectedCode)
+
+		if response.Body.Len() != 0 {
+			t.Errorf("expected no body, got %q", response.Body.String())
+		}
+
+		return response.Header().Get("ETag")
+	}
+
+	t.Run("test existing org has the etag of a get", func(t *testing.T) {
+		etag := head(defaultOrgID, http.StatusOK)
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s", defaultOrgID), nil)
+		response := getHTTPResponse(t, r, req)
+
+		if etag == "" || etag != response.Header().Get("ETag") {
+			t.Errorf("expected etag %q to match the get, got %q", response.Header().Get("ETag"), etag)
+		}
+	})
+
+	t.Run("test missing org is not found", func(t *testing.T) {
+		head("61695d8bb2cc8a9af4833d46", http.StatusNotFound)
+	})
+
+	t.Run("test invalid id fails", func(t *testing.T) {
+		head("12345", http.StatusBadRequest)
+	})
+}
+
+func TestGetOrganizations(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = setUpMember(orgID, "listingmember@gmail.com", MemberRole); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins := map[string]interface{}{"plugins": map[string]interface{}{"61695d8bb2cc8a9af4833d46": map[string]interface{}{}}}
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, plugins); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations", orgs.GetOrganizations).Methods("GET")
+
+	listed := func(t *testing.T, url string) map[string]interface{} {
+		t.Helper()
+
+		req, _ := http.NewRequest("GET", url, nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data, _ := parseResponse(response)["data"].([]interface{})
+		for _, item := range data {
+			if org, _ := item.(map[string]interface{}); org["_id"] == orgID {
+				return org
+			}
+		}
+
+		t.Fatalf("expected organization %s to be listed", orgID)
+
+		return nil
+	}
+
+	t.Run("test listings are lean by default", func(t *testing.T) {
+		org := listed(t, "/organizations")
+
+		for _, field := range append(leanOrganizationFields, expandMembers) {
+			if _, ok := org[field]; ok {
+				t.Errorf("expected %s to be left out, got %v", field, org[field])
+			}
+		}
+
+		if org["creator_email"] != defaultUser {
+			t.Errorf("expected other fields to be listed, got %v", org)
+		}
+	})
+
+	t.Run("test expanded fields are listed", func(t *testing.T) {
+		org := listed(t, "/organizations?expand=members,plugins")
+
+		if members, _ := org["members"].([]interface{}); len(members) != 1 {
+			t.Errorf("expected 1 member, got %v", org["members"])
+		}
+
+		if p, _ := org["plugins"].(map[string]interface{}); len(p) != 1 {
+			t.Errorf("expected 1 plugin, got %v", org["plugins"])
+		}
+
+		if _, ok := org["webhooks"]; ok {
+			t.Errorf("expected webhooks to stay left out, got %v", org["webhooks"])
+		}
+	})
+
+	t.Run("test unknown fields cannot be expanded", func(t *testing.T) {
+		for _, field := range []string{"cards", "webhooks"} {
+			req, _ := http.NewRequest("GET", "/organizations?expand="+field, nil)
+
+			response := getHTTPResponse(t, r, req)
+			assertStatusCode(t, response.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("test private fields are never listed", func(t *testing.T) {
+		private := bson.M{
+			"webhooks":              []bson.M{{"id": utils.GenUUID(), "secret": "whsec"}},
+			"billing_address":       "1 Private Road",
+			"deletion_confirmation": bson.M{"token": "delete-me"},
+			"plugin_config":         bson.M{"61695d8bb2cc8a9af4833d46": bson.M{"api_key": "secret"}},
+		}
+		if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, private); err != nil {
+			t.Fatal(err)
+		}
+
+		org := listed(t, "/organizations?expand=members,plugins,announcements")
+
+		for _, field := range privateOrganizationFields {
+			if _, ok := org[field]; ok {
+				t.Errorf("expected %s to be left out, got %v", field, org[field])
+			}
+		}
+	})
+
+	t.Run("test listings are sorted by several fields", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/organizations?sort=-created_at,name", nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data, _ := parseResponse(response)["data"].([]interface{})
+
+		for i := 1; i < len(data); i++ {
+			prev, _ := data[i-1].(map[string]interface{})
+			org, _ := data[i].(map[string]interface{})
+
+			prevCreated, _ := time.Parse(time.RFC3339Nano, fmt.Sprint(prev["created_at"]))
+			created, _ := time.Parse(time.RFC3339Nano, fmt.Sprint(org["created_at"]))
+
+			if created.After(prevCreated) || (created.Equal(prevCreated) && fmt.Sprint(org["name"]) < fmt.Sprint(prev["name"])) {
+				t.Fatalf("expected newest first then by name, got %v before %v", prev["_id"], org["_id"])
+			}
+		}
+	})
+
+	t.Run("test unknown fields cannot be sorted by", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/organizations?sort=-billing", nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+}
+
 func TestGetOrganizationByURL(t *testing.T) {
 	t.Run("test for invalid url fails", func(t *testing.T) {
 		r := getRouter()
@@ -161,7 +333,7 @@ func TestDeleteOrganization(t *testing.T) {
 
 		response := getHTTPResponse(t, r, req)
 
-		assertStatusCode(t, response.Code, http.StatusInternalServerError)
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
 	})
 	
 	t.Run("test can delete organization", func(t *testing.T) {
@@ -177,6 +349,13 @@ func TestDeleteOrganization(t *testing.T) {
 		response := getHTTPResponse(t, r, req)
 
 		assertStatusCode(t, response.Code, http.StatusOK)
+
+		token := parseResponse(response)["data"].(map[string]interface{})["confirmation_token"].(string)
+		req, _ = http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s?confirmation_token=%s", id, token), nil)
+
+		response = getHTTPResponse(t, r, req)
+
+		assertStatusCode(t, response.Code, http.StatusOK)
 	})
 }
 
@@ -211,4 +390,247 @@ func TestUpdateURL(t *testing.T) {
 	})
 
 	// test that update is successful
-}
\ No newline at end of file
+}
+func TestCreateOrganizationDryRun(t *testing.T) {
+	tests := []struct {
+		name string
+		user string
+		body string
+	}{
+		{"test bad email format", "badmailformat.xyz", `{"creator_email": "badmailformat.xyz"}`},
+		{"test non existent user", "notuser@gmail.com", `{"creator_email": "notuser@gmail.com"}`},
+		{"test another user", defaultUser, `{"creator_email": "notuser@gmail.com"}`},
+		{"test valid organization", defaultUser, fmt.Sprintf(`{"creator_email": "%s", "name": "Acme"}`, defaultUser)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			before := utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{})
+
+			req, _ := http.NewRequest("POST", "/organizations?dry_run=true", bytes.NewBufferString(tc.body))
+			dryRun := httptest.NewRecorder()
+			orgs.Create(dryRun, withUser(req, tc.user))
+
+			after := utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{})
+			if after != before {
+				t.Fatalf("expected a dry run to create nothing, organizations went from %d to %d", before, after)
+			}
+
+			req, _ = http.NewRequest("POST", "/organizations", bytes.NewBufferString(tc.body))
+			create := httptest.NewRecorder()
+			orgs.Create(create, withUser(req, tc.user))
+
+			dryRunRes, createRes := parseResponse(dryRun), parseResponse(create)
+
+			// a dry run fails exactly when a real create does, and creates nothing when it would succeed
+			if create.Code != http.StatusCreated {
+				assertStatusCode(t, dryRun.Code, create.Code)
+				assertResponseMessage(t, dryRunRes["message"].(string), createRes["message"].(string))
+				return
+			}
+
+			assertStatusCode(t, dryRun.Code, http.StatusOK)
+
+			if location := dryRun.Header().Get("Location"); location != "" {
+				t.Errorf("expected no location for a dry run, got %q", location)
+			}
+
+			data := dryRunRes["data"].(map[string]interface{})
+			wouldCreate := data["would_create"].(map[string]interface{})
+
+			if wouldCreate["name"] != DefaultOrganizationName || wouldCreate["workspace_url"] == "" {
+				t.Errorf("unexpected would_create payload %v", wouldCreate)
+			}
+
+			if warnings, _ := data["warnings"].([]interface{}); len(warnings) != 1 {
+				t.Errorf("expected a warning about the ignored name, got %v", data["warnings"])
+			}
+		})
+	}
+}
+
+func TestCreateOrganizationProvisionsCreator(t *testing.T) {
+	email := fmt.Sprintf("provisioned%d@gmail.com", utils.NowUTC().UnixNano())
+	requestBody := fmt.Sprintf(`{"creator_email": %q}`, email)
+
+	// provisioning flows create organizations for people who are not logged in themselves
+	const zuriAdmin = "provisioner@zuri.chat"
+	if err := setUpUser(zuriAdmin, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test missing creator is rejected by default", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/organizations", bytes.NewBufferString(requestBody))
+		response := httptest.NewRecorder()
+		NewOrganizationHandler(configs, newMockMailService()).Create(response, withUser(req, zuriAdmin))
+
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+		assertResponseMessage(t, parseResponse(response)["message"].(string), "user with this email does not exist")
+
+		if userDoc, _ := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"email": email}); userDoc != nil {
+			t.Errorf("expected no account to be created for %s", email)
+		}
+	})
+
+	t.Run("test missing creator is provisioned when allowed", func(t *testing.T) {
+		provisioning := *configs
+		provisioning.ProvisionMissingCreator = true
+
+		mail := newMockMailService()
+
+		req, _ := http.NewRequest("POST", "/organizations", bytes.NewBufferString(requestBody))
+		response := httptest.NewRecorder()
+		NewOrganizationHandler(&provisioning, mail).Create(response, withUser(req, zuriAdmin))
+
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		orgID := parseResponse(response)["data"].(map[string]interface{})["organization_id"].(string)
+
+		user, err := auth.FetchUserByEmail(bson.M{"email": email})
+		if err != nil {
+			t.Fatalf("expected an account to be created for %s: %v", email, err)
+		}
+
+		if !user.Provisioned || user.IsVerified {
+			t.Errorf("expected a provisioned, unverified account, got provisioned %v verified %v", user.Provisioned, user.IsVerified)
+		}
+
+		if len(user.Organizations) != 1 || user.Organizations[0] != orgID {
+			t.Errorf("expected org %s in the workspaces of %s, got %v", orgID, email, user.Organizations)
+		}
+
+		if memDoc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": email, "role": OwnerRole}); memDoc == nil {
+			t.Errorf("expected %s to own org %s", email, orgID)
+		}
+
+		if len(mail.sent) != 1 || len(mail.sent[0]) != 1 || mail.sent[0][0] != email {
+			t.Errorf("expected a verification mail to %s, got %v", email, mail.sent)
+		}
+	})
+}
+
+func TestCreateOrganizationCreator(t *testing.T) {
+	const (
+		otherUser = "othercreator@gmail.com"
+		zuriAdmin = "creatoradmin@zuri.chat"
+	)
+
+	if err := setUpUser(otherUser, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setUpUser(zuriAdmin, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(req *http.Request) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		orgs.Create(response, req)
+
+		return response
+	}
+
+	newRequest := func(body string) *http.Request {
+		req, _ := http.NewRequest("POST", "/organizations", bytes.NewBufferString(body))
+		return req
+	}
+
+	// creator returns the creator email of the organization created by a response.
+	creator := func(t *testing.T, response *httptest.ResponseRecorder) string {
+		orgID := parseResponse(response)["data"].(map[string]interface{})["organization_id"].(string)
+
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return org.CreatorEmail
+	}
+
+	t.Run("test matching creator email", func(t *testing.T) {
+		response := create(withUser(newRequest(fmt.Sprintf(`{"creator_email": %q}`, "TestUser@gmail.com")), defaultUser))
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		if got := creator(t, response); got != defaultUser {
+			t.Errorf("expected creator %s, got %s", defaultUser, got)
+		}
+	})
+
+	t.Run("test omitted creator email is the logged in user", func(t *testing.T) {
+		response := create(withUser(newRequest(`{}`), defaultUser))
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		if got := creator(t, response); got != defaultUser {
+			t.Errorf("expected creator %s, got %s", defaultUser, got)
+		}
+	})
+
+	t.Run("test mismatching creator email is forbidden", func(t *testing.T) {
+		before := utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{})
+
+		response := create(withUser(newRequest(fmt.Sprintf(`{"creator_email": %q}`, otherUser)), defaultUser))
+		assertStatusCode(t, response.Code, http.StatusForbidden)
+
+		if after := utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{}); after != before {
+			t.Errorf("expected no organization to be created, organizations went from %d to %d", before, after)
+		}
+	})
+
+	t.Run("test zuri admins can create organizations for others", func(t *testing.T) {
+		response := create(withUser(newRequest(fmt.Sprintf(`{"creator_email": %q}`, otherUser)), zuriAdmin))
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		if got := creator(t, response); got != otherUser {
+			t.Errorf("expected creator %s, got %s", otherUser, got)
+		}
+	})
+
+	t.Run("test creating an organization needs a logged in user", func(t *testing.T) {
+		response := create(newRequest(fmt.Sprintf(`{"creator_email": %q}`, defaultUser)))
+		assertStatusCode(t, response.Code, http.StatusUnauthorized)
+	})
+}
+
+func TestCreateOrganizationIgnoresProtectedFields(t *testing.T) {
+	requestBody := fmt.Sprintf(`{
+		"creator_email": %q,
+		"version": %q,
+		"plan": %q,
+		"tokens": 1000000,
+		"owners": ["someone"],
+		"tags": ["vip"]
+	}`, defaultUser, EnterpriseVersion, EnterpriseVersion)
+
+	req, _ := http.NewRequest("POST", "/organizations", bytes.NewBufferString(requestBody))
+	response := httptest.NewRecorder()
+	orgs.Create(response, withUser(req, defaultUser))
+
+	assertStatusCode(t, response.Code, http.StatusCreated)
+
+	data := parseResponse(response)["data"].(map[string]interface{})
+
+	if ignored := fmt.Sprint(data["ignored_fields"]); ignored != "[owners plan tags tokens version]" {
+		t.Errorf("expected the protected fields to be reported as ignored, got %s", ignored)
+	}
+
+	pOrgID, _ := primitive.ObjectIDFromHex(data["organization_id"].(string))
+
+	doc, err := utils.GetMongoDBDoc(context.TODO(), OrganizationCollectionName, bson.M{"_id": pOrgID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc["version"] != FreeVersion || doc["plan"] != nil {
+		t.Errorf("expected the organization on the free plan, got version %v plan %v", doc["version"], doc["plan"])
+	}
+
+	if doc["tokens"] != float64(100) {
+		t.Errorf("expected the usual 100 tokens, got %v", doc["tokens"])
+	}
+
+	if owners, _ := doc["owners"].(primitive.A); len(owners) != 1 || owners[0] == "someone" {
+		t.Errorf("expected the creator to be the only owner, got %v", doc["owners"])
+	}
+}
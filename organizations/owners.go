@@ -0,0 +1,208 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+var errLastOwner = errors.New("an organization must keep at least one owner")
+
+// organizationOwners returns the member ids of an organization's owners.
+// Organizations created before co-owners were supported have no owners set; their owners are
+// the members holding the owner role, falling back to the creator. The set is saved on first use.
+func organizationOwners(ctx context.Context, org *Organization) ([]string, error) {
+	if len(org.Owners) > 0 {
+		return org.Owners, nil
+	}
+
+	owners := []string{}
+
+	ownerDocs, err := utils.GetMongoDBDocs(ctx, MemberCollectionName, bson.M{"org_id": org.ID, "role": OwnerRole, "deleted": bson.M{"$ne": true}})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ownerDocs) == 0 {
+		ownerDocs, _ = utils.GetMongoDBDocs(ctx, MemberCollectionName, bson.M{"org_id": org.ID, "email": org.CreatorEmail})
+	}
+
+	for _, doc := range ownerDocs {
+		owners = append(owners, doc["_id"].(primitive.ObjectID).Hex())
+	}
+
+	if len(owners) == 0 {
+		return owners, nil
+	}
+
+	if _, err := utils.UpdateOneMongoDBDoc(ctx, OrganizationCollectionName, org.ID, bson.M{"owners": owners}); err != nil {
+		return nil, err
+	}
+
+	org.Owners = owners
+
+	return owners, nil
+}
+
+// adds a member to the owners set of an organization. Callers update the member's role.
+func addOwner(orgID, memberID string) error {
+	pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+	_, err := utils.GenericUpdateOneMongoDBDocWithWriteConcern(context.TODO(), OrganizationCollectionName, utils.WriteConcern(utils.WriteCritical), pOrgID, bson.M{"$addToSet": bson.M{"owners": memberID}})
+
+	return err
+}
+
+// removes a member from the owners set of an organization. Callers update the member's role.
+// The removal only matches while another owner remains, so concurrent removals cannot empty the set.
+func removeOwner(orgID, memberID string) error {
+	pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+	filter := bson.M{"_id": pOrgID, "owners": memberID, "owners.1": bson.M{"$exists": true}}
+	update := bson.M{"$pull": bson.M{"owners": memberID}}
+
+	res, err := utils.GetCollectionWithWriteConcern(OrganizationCollectionName, utils.WriteConcern(utils.WriteCritical)).UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+
+	if res.ModifiedCount == 0 {
+		return errLastOwner
+	}
+
+	return nil
+}
+
+// fetches an organization and makes sure its owners set is populated.
+func fetchOrganizationWithOwners(ctx context.Context, orgID string) (*Organization, error) {
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil, errors.New("invalid organization id")
+	}
+
+	org, err := FetchOrganization(ctx, bson.M{"_id": pOrgID})
+	if err != nil {
+		return nil, errors.New("organization does not exist")
+	}
+
+	if _, err = organizationOwners(ctx, org); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func isOwner(org *Organization, memberID string) bool {
+	for _, id := range org.Owners {
+		if id == memberID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get the owners of an organization.
+func (oh *OrganizationHandler) GetOwners(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	org, err := fetchOrganizationWithOwners(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	utils.GetSuccess("organization owners retrieved successfully", org.Owners, w)
+}
+
+// Make a member a co-owner of an organization.
+func (oh *OrganizationHandler) AddOwner(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, memberID := vars["id"], vars["mem_id"]
+
+	org, err := fetchOrganizationWithOwners(r.Context(), orgID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if err = ValidateMember(r.Context(), orgID, memberID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if isOwner(org, memberID) {
+		utils.GetError(errors.New("this member already owns this organization"), http.StatusBadRequest, w)
+		return
+	}
+
+	if err = addOwner(orgID, memberID); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if _, err = utils.UpdateOneMongoDBDocWithWriteConcern(r.Context(), MemberCollectionName, utils.WriteConcern(utils.WriteCritical), memberID, bson.M{"role": OwnerRole}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// publish update to subscriber
+	eventChannel := fmt.Sprintf("organizations_%s", orgID)
+	event := utils.Event{Identifier: memberID, Type: "User", Event: UpdateOrganizationMemberRole, Channel: eventChannel, Payload: make(map[string]interface{})}
+
+	go utils.Emitter(event)
+
+	utils.GetSuccess("owner added successfully", nil, w)
+}
+
+// Remove a member from the owners of an organization. The last owner cannot be removed.
+func (oh *OrganizationHandler) RemoveOwner(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, memberID := vars["id"], vars["mem_id"]
+
+	org, err := fetchOrganizationWithOwners(r.Context(), orgID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if !isOwner(org, memberID) {
+		utils.GetError(errors.New("member is not an owner of this organization"), http.StatusBadRequest, w)
+		return
+	}
+
+	err = removeOwner(orgID, memberID)
+	if errors.Is(err, errLastOwner) {
+		utils.GetError(err, http.StatusConflict, w)
+		return
+	}
+
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// former owners keep admin rights, as when ownership is transferred
+	if _, err = utils.UpdateOneMongoDBDocWithWriteConcern(r.Context(), MemberCollectionName, utils.WriteConcern(utils.WriteCritical), memberID, bson.M{"role": AdminRole}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// publish update to subscriber
+	eventChannel := fmt.Sprintf("organizations_%s", orgID)
+	event := utils.Event{Identifier: memberID, Type: "User", Event: UpdateOrganizationMemberRole, Channel: eventChannel, Payload: make(map[string]interface{})}
+
+	go utils.Emitter(event)
+
+	utils.GetSuccess("owner removed successfully", nil, w)
+}
@@ -0,0 +1,78 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestOwners(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an owner from before co-owners, recorded only by role
+	firstOwner, err := setUpMember(orgID, "firstowner@gmail.com", OwnerRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secondOwner, err := setUpMember(orgID, "secondowner@gmail.com", AdminRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/owners/{mem_id}", orgs.AddOwner).Methods("POST")
+	r.HandleFunc("/organizations/{id}/owners/{mem_id}", orgs.RemoveOwner).Methods("DELETE")
+
+	t.Run("test adding a second owner", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/owners/%s", orgID, secondOwner), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		org, err := fetchOrganizationWithOwners(context.TODO(), orgID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !isOwner(org, firstOwner) || !isOwner(org, secondOwner) {
+			t.Errorf("expected both members to be owners, got %v", org.Owners)
+		}
+
+		pMemID, _ := primitive.ObjectIDFromHex(secondOwner)
+		if memberDoc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"_id": pMemID}); memberDoc["role"] != OwnerRole {
+			t.Errorf("expected role %s, got %v", OwnerRole, memberDoc["role"])
+		}
+	})
+
+	t.Run("test removing an owner while another remains", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s/owners/%s", orgID, firstOwner), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+	})
+
+	t.Run("test the last owner cannot be removed", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s/owners/%s", orgID, secondOwner), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusConflict)
+
+		org, err := fetchOrganizationWithOwners(context.TODO(), orgID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(org.Owners) != 1 || !isOwner(org, secondOwner) {
+			t.Errorf("expected %s to remain the only owner, got %v", secondOwner, org.Owners)
+		}
+	})
+}
@@ -1,12 +1,12 @@
 package organizations
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strconv"
-	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/stripe/stripe-go/v72"
@@ -62,7 +62,7 @@ func IncrementToken(orgID, description string, tokenAmount float64) error {
 		return err
 	}
 
-	organization, err := FetchOrganization(bson.M{"_id": OrgIDFromHex})
+	organization, err := FetchOrganization(context.TODO(), bson.M{"_id": OrgIDFromHex})
 	if err != nil {
 		return err
 	}
@@ -72,7 +72,7 @@ func IncrementToken(orgID, description string, tokenAmount float64) error {
 	updateData := make(map[string]interface{})
 	updateData["tokens"] = organization.Tokens
 
-	if _, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, updateData); err != nil {
+	if _, err := utils.UpdateOneMongoDBDocWithWriteConcern(context.TODO(), OrganizationCollectionName, utils.WriteConcern(utils.WriteCritical), orgID, updateData); err != nil {
 		return err
 	}
 
@@ -86,7 +86,7 @@ func DeductToken(orgID, description string, tokenAmount float64) error {
 		return err
 	}
 
-	organization, err := FetchOrganization(bson.M{"_id": OrgIDFromHex})
+	organization, err := FetchOrganization(context.TODO(), bson.M{"_id": OrgIDFromHex})
 	if err != nil {
 		return err
 	}
@@ -104,7 +104,7 @@ func DeductToken(orgID, description string, tokenAmount float64) error {
 }
 
 func SubscriptionBilling(orgID string, proVersionRate float64) error {
-	orgMembers, err := utils.GetMongoDBDocs(MemberCollectionName, bson.M{"org_id": orgID})
+	orgMembers, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID})
 	if err != nil {
 		return err
 	}
@@ -129,8 +129,12 @@ func SendTokenBillingEmail(orgID, description string, amount float64) error {
 		return err
 	}
 
-	org, _ := FetchOrganization(bson.M{"_id": OrgIDFromHex})
-	orgMail := org.CreatorEmail
+	org, err := FetchOrganization(context.TODO(), bson.M{"_id": OrgIDFromHex})
+	if err != nil {
+		return err
+	}
+
+	orgMail := org.BillingEmail()
 	balance := org.Tokens
 	name := org.Name
 
@@ -145,7 +149,7 @@ func SendTokenBillingEmail(orgID, description string, amount float64) error {
 			"Balance":     balance,
 			"Name":        name,
 		},
-	)
+	).WithLocale(org.Locale)
 
 	if err := ms.SendMail(billingMail); err != nil {
 		return err
@@ -166,7 +170,7 @@ func (oh *OrganizationHandler) AddToken(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	org, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	org, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if org == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -189,7 +193,7 @@ func (oh *OrganizationHandler) AddToken(w http.ResponseWriter, r *http.Request)
 
 	orgFilter["tokens"] = org["tokens"].(float64) + (tokens * 0.2)
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	update, err := utils.UpdateOneMongoDBDocWithWriteConcern(r.Context(), OrganizationCollectionName, utils.WriteConcern(utils.WriteCritical), orgID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -203,11 +207,11 @@ func (oh *OrganizationHandler) AddToken(w http.ResponseWriter, r *http.Request)
 	transaction.OrgID = orgID
 	transaction.TransactionID = utils.GenUUID()
 	transaction.Type = "Purchase"
-	transaction.Time = time.Now()
+	transaction.Time = utils.NowUTC()
 	transaction.Token = tokens * 0.2
 	detail, _ := utils.StructToMap(transaction)
 
-	res, err := utils.CreateMongoDBDoc(TokenTransactionCollectionName, detail)
+	res, err := utils.CreateMongoDBDocWithWriteConcern(r.Context(), TokenTransactionCollectionName, utils.WriteConcern(utils.WriteCritical), detail)
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
@@ -228,7 +232,7 @@ func (oh *OrganizationHandler) GetTokenTransaction(w http.ResponseWriter, r *htt
 
 	orgID := mux.Vars(r)["id"]
 
-	save, _ := utils.GetMongoDBDocs(TokenTransactionCollectionName, bson.M{"org_id": orgID})
+	save, _ := utils.GetMongoDBDocs(r.Context(), TokenTransactionCollectionName, bson.M{"org_id": orgID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization transaction %s not found", orgID), http.StatusNotFound, w)
@@ -279,7 +283,7 @@ func (oh *OrganizationHandler) CreateCheckoutSession(w http.ResponseWriter, r *h
 		return
 	}
 
-	org, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	org, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if org == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -343,7 +347,7 @@ func (oh *OrganizationHandler) AddCard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Checks if organization exists in the database
-	orgDoc, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": orgIDHex})
+	orgDoc, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": orgIDHex})
 	if orgDoc == nil {
 		utils.GetError(errors.New("organization does not exist"), http.StatusBadRequest, w)
 		return
@@ -357,7 +361,7 @@ func (oh *OrganizationHandler) AddCard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	member, _ := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": objID})
+	member, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": objID})
 
 	if member == nil {
 		utils.GetError(fmt.Errorf("member %s not found", MemberID), http.StatusNotFound, w)
@@ -385,7 +389,7 @@ func (oh *OrganizationHandler) AddCard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := utils.CreateMongoDBDoc(CardCollectionName, card)
+	res, err := utils.CreateMongoDBDoc(r.Context(), CardCollectionName, card)
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
@@ -408,7 +412,7 @@ func (oh *OrganizationHandler) DeleteCard(w http.ResponseWriter, r *http.Request
 	}
 
 	// Checks if organization exists in the database
-	orgDoc, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": orgIDHex})
+	orgDoc, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": orgIDHex})
 	if orgDoc == nil {
 		utils.GetError(errors.New("organization does not exist"), http.StatusBadRequest, w)
 		return
@@ -422,7 +426,7 @@ func (oh *OrganizationHandler) DeleteCard(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	member, _ := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": objID})
+	member, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": objID})
 
 	if member == nil {
 		utils.GetError(fmt.Errorf("member %s not found", MemberID), http.StatusNotFound, w)
@@ -430,7 +434,7 @@ func (oh *OrganizationHandler) DeleteCard(w http.ResponseWriter, r *http.Request
 	}
 
 	MemberCard := mux.Vars(r)["card_id"]
-	res, err := utils.DeleteOneMongoDBDoc(CardCollectionName, MemberCard)
+	res, err := utils.DeleteOneMongoDBDoc(r.Context(), CardCollectionName, MemberCard)
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
@@ -0,0 +1,75 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+var errPinNotMember = errors.New("only organizations you are a member of can be pinned")
+
+// Pin an organization for the logged in user, so it is listed first among their organizations.
+// Only organizations they are a member of can be pinned.
+func (oh *OrganizationHandler) PinOrganization(w http.ResponseWriter, r *http.Request) {
+	oh.setPinned(w, r, true)
+}
+
+// Unpin an organization for the logged in user.
+func (oh *OrganizationHandler) UnpinOrganization(w http.ResponseWriter, r *http.Request) {
+	oh.setPinned(w, r, false)
+}
+
+func (oh *OrganizationHandler) setPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	email := strings.ToLower(loggedInUser.Email)
+
+	// pins are dropped whether or not the user is still a member, or the organization still exists
+	update := bson.M{"$pull": bson.M{"pinned_organizations": orgID}}
+
+	if pinned {
+		if err := ValidateOrg(r.Context(), orgID); err != nil {
+			utils.GetError(err, http.StatusBadRequest, w)
+			return
+		}
+
+		members := utils.CountCollection(r.Context(), MemberCollectionName, bson.M{"org_id": orgID, "email": email, "deleted": false})
+		if members == 0 {
+			utils.GetError(errPinNotMember, http.StatusForbidden, w)
+			return
+		}
+
+		update = bson.M{"$addToSet": bson.M{"pinned_organizations": orgID}}
+	}
+
+	res, err := utils.GetCollection(UserCollectionName).UpdateOne(r.Context(), bson.M{"email": email}, update)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(errors.New("user not found"), http.StatusNotFound, w)
+		return
+	}
+
+	if pinned {
+		utils.GetSuccess("organization pinned", utils.M{"pinned": true}, w)
+		return
+	}
+
+	utils.GetSuccess("organization unpinned", utils.M{"pinned": false}, w)
+}
@@ -0,0 +1,100 @@
+package organizations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+func TestPinOrganization(t *testing.T) {
+	email := fmt.Sprintf("pins.%s@gmail.com", utils.GenUUID())
+	if err := setUpUser(email, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	orgIDs := make([]string, 3)
+
+	for i := range orgIDs {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := setUpMember(orgID, email, "member"); err != nil {
+			t.Fatal(err)
+		}
+
+		orgIDs[i] = orgID
+	}
+
+	outsiderOrgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	us := user.NewUserHandler(configs, newMockMailService())
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/pin", orgs.PinOrganization).Methods("POST")
+	r.HandleFunc("/organizations/{id}/pin", orgs.UnpinOrganization).Methods("DELETE")
+	r.HandleFunc("/users/{email}/organizations", us.GetUserOrganizations).Methods("GET")
+
+	pin := func(method, orgID string) int {
+		req, _ := http.NewRequest(method, fmt.Sprintf("/organizations/%s/pin", orgID), nil)
+		return getHTTPResponse(t, r, withUser(req, email)).Code
+	}
+
+	listed := func() (ids []string, pinned []bool) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/users/%s/organizations", email), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, email))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		for _, org := range parseResponse(response)["data"].([]interface{}) {
+			org := org.(map[string]interface{})
+			ids = append(ids, org["id"].(string))
+			pinned = append(pinned, org["pinned"].(bool))
+		}
+
+		return ids, pinned
+	}
+
+	t.Run("test pinned organizations are listed first in pin order", func(t *testing.T) {
+		for _, orgID := range []string{orgIDs[2], orgIDs[0]} {
+			if code := pin("POST", orgID); code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+			}
+		}
+
+		ids, pinned := listed()
+
+		expected := []string{orgIDs[2], orgIDs[0], orgIDs[1]}
+		if fmt.Sprint(ids) != fmt.Sprint(expected) {
+			t.Errorf("expected organizations in order %v, got %v", expected, ids)
+		}
+
+		if fmt.Sprint(pinned) != fmt.Sprint([]bool{true, true, false}) {
+			t.Errorf("expected the first two to be pinned, got %v", pinned)
+		}
+	})
+
+	t.Run("test unpinned organizations go back among the others", func(t *testing.T) {
+		if code := pin("DELETE", orgIDs[2]); code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+		}
+
+		ids, pinned := listed()
+		if ids[0] != orgIDs[0] || !pinned[0] || pinned[1] || pinned[2] {
+			t.Errorf("expected only %s pinned and first, got %v %v", orgIDs[0], ids, pinned)
+		}
+	})
+
+	t.Run("test organizations the user is not in cannot be pinned", func(t *testing.T) {
+		if code := pin("POST", outsiderOrgID); code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, code)
+		}
+	})
+}
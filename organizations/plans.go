@@ -0,0 +1,123 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// DefaultMemberLimits is how many members an organization can have on each plan. Plans
+// missing from it, such as enterprise, take any number.
+var DefaultMemberLimits = map[string]int64{
+	FreeVersion: 100,
+	ProVersion:  1000,
+}
+
+// currentPlan is the plan an organization is on. Organizations created before plans were
+// recorded are on the free plan.
+func (o *Organization) currentPlan() string {
+	if o.Version == "" {
+		return FreeVersion
+	}
+
+	return o.Version
+}
+
+// memberLimit is the most members an organization can have, the lower of its plan's limit and
+// the cap set for the deployment, and which of the two it is. A limit of 0 is no limit.
+func (oh *OrganizationHandler) memberLimit(org *Organization) (limit int64, setBy string) {
+	plan := org.currentPlan()
+	limit, setBy = DefaultMemberLimits[plan], fmt.Sprintf("the %s plan", plan)
+
+	if deploymentCap := oh.configs.MaxMembersPerOrg; deploymentCap > 0 && (limit == 0 || deploymentCap < limit) {
+		limit, setBy = deploymentCap, "this deployment"
+	}
+
+	return limit, setBy
+}
+
+// checkMemberLimit returns an error naming the cap hit when an organization has no room for
+// another member.
+func (oh *OrganizationHandler) checkMemberLimit(ctx context.Context, org *Organization, orgID string) error {
+	limit, setBy := oh.memberLimit(org)
+	if limit == 0 {
+		return nil
+	}
+
+	if count := utils.CountCollection(ctx, MemberCollectionName, bson.M{"org_id": orgID, "deleted": bson.M{"$ne": true}}); count >= limit {
+		return fmt.Errorf("organization has reached its limit of %d members, set by %s", limit, setBy)
+	}
+
+	return nil
+}
+
+// PlanChange is an entry in an organization's plan history.
+type PlanChange struct {
+	ID          string    `json:"_id,omitempty" bson:"_id,omitempty"`
+	OrgID       string    `json:"org_id" bson:"org_id"`
+	OldPlan     string    `json:"old_plan" bson:"old_plan"`
+	NewPlan     string    `json:"new_plan" bson:"new_plan"`
+	Actor       string    `json:"actor" bson:"actor"`
+	EffectiveAt time.Time `json:"effective_at" bson:"effective_at"`
+}
+
+// planViolations lists what an organization must reduce before its usage fits a plan, empty
+// when it already does. Storage only counts against a plan's default quota, as organizations
+// with a quota of their own keep it on every plan.
+func planViolations(org *Organization, plan string, memberCount int64) []string {
+	violations := []string{}
+
+	if limit, ok := DefaultMemberLimits[plan]; ok && memberCount > limit {
+		violations = append(violations, fmt.Sprintf("members: %d of at most %d, remove %d", memberCount, limit, memberCount-limit))
+	}
+
+	if quota, ok := DefaultStorageQuotas[plan]; ok && org.StorageQuota == 0 && org.StorageUsed > quota {
+		violations = append(violations, fmt.Sprintf("storage: %d of at most %d bytes, free up %d", org.StorageUsed, quota, org.StorageUsed-quota))
+	}
+
+	return violations
+}
+
+// recordPlanChange appends a change of plan to the organization's plan history.
+func recordPlanChange(orgID, oldPlan, newPlan, actor string) {
+	change := PlanChange{
+		OrgID:       orgID,
+		OldPlan:     oldPlan,
+		NewPlan:     newPlan,
+		Actor:       actor,
+		EffectiveAt: utils.NowUTC(),
+	}
+
+	if _, err := utils.GetCollection(PlanHistoryCollectionName).InsertOne(context.TODO(), change); err != nil {
+		logger.Error("could not record plan change of %s from %s to %s: %v", orgID, oldPlan, newPlan, err)
+	}
+}
+
+// List an organization's plan changes, newest first.
+func (oh *OrganizationHandler) GetPlanHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+	opts := options.Find().SetSort(bson.D{{Key: "effective_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	history, err := utils.GetMongoDBDocs(r.Context(), PlanHistoryCollectionName, bson.M{"org_id": orgID}, opts)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("plan history retrieved successfully", history, w)
+}
+
+// planChangeError explains why an organization cannot move to a plan yet.
+func planChangeError(plan string, violations []string) error {
+	return fmt.Errorf("usage exceeds the %s plan, reduce first: %s", plan, strings.Join(violations, "; "))
+}
@@ -0,0 +1,156 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestPlanHistory(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/plan", orgs.UpdatePlan).Methods("PATCH")
+	r.HandleFunc("/organizations/{id}/plan-history", orgs.GetPlanHistory).Methods("GET")
+
+	updatePlan := func(plan string, expectedCode int) map[string]interface{} {
+		body := bytes.NewBufferString(fmt.Sprintf(`{"plan": %q}`, plan))
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/plan", orgID), body)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, expectedCode)
+
+		return parseResponse(response)
+	}
+
+	history := func() []interface{} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/plan-history", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		entries, _ := parseResponse(response)["data"].([]interface{})
+
+		return entries
+	}
+
+	t.Run("test upgrades are recorded", func(t *testing.T) {
+		updatePlan(ProVersion, http.StatusOK)
+
+		entries := history()
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 plan change, got %d", len(entries))
+		}
+
+		change := entries[0].(map[string]interface{})
+		if change["old_plan"] != FreeVersion || change["new_plan"] != ProVersion || change["actor"] != defaultUser || change["effective_at"] == nil {
+			t.Errorf("unexpected plan change %v", change)
+		}
+	})
+
+	t.Run("test downgrades the usage does not fit are blocked", func(t *testing.T) {
+		limit := DefaultMemberLimits[FreeVersion]
+		DefaultMemberLimits[FreeVersion] = 1
+
+		defer func() { DefaultMemberLimits[FreeVersion] = limit }()
+
+		for _, email := range []string{"planmember1@gmail.com", "planmember2@gmail.com"} {
+			if _, err := setUpMember(orgID, email, MemberRole); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		res := updatePlan(FreeVersion, http.StatusConflict)
+		if message, _ := res["message"].(string); !strings.Contains(message, "members: 2 of at most 1, remove 1") {
+			t.Errorf("expected the message to say what to reduce, got %q", message)
+		}
+
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), map[string]interface{}{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if org.Version != ProVersion {
+			t.Errorf("expected the organization to stay on %s, got %s", ProVersion, org.Version)
+		}
+
+		if entries := history(); len(entries) != 1 {
+			t.Errorf("expected the blocked downgrade not to be recorded, got %d plan changes", len(entries))
+		}
+	})
+}
+
+func TestMaxMembersPerOrg(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, bson.M{"version": ProVersion}); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(cap int64) { configs.MaxMembersPerOrg = cap }(configs.MaxMembersPerOrg)
+
+	configs.MaxMembersPerOrg = 2
+
+	for i := 0; i < 2; i++ {
+		if _, err = setUpMember(orgID, fmt.Sprintf("capped.%d.%s@gmail.com", i, utils.GenUUID()), MemberRole); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	email := fmt.Sprintf("capped.%s@gmail.com", utils.GenUUID())
+	if err = setUpUser(email, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members", orgs.CreateMember).Methods("POST")
+
+	addMember := func() map[string]interface{} {
+		body := bytes.NewBufferString(fmt.Sprintf(`{"user_email": %q}`, email))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/members", orgID), body)
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		parsed := parseResponse(response)
+		parsed["code"] = response.Code
+
+		return parsed
+	}
+
+	t.Run("test the deployment cap overrides a higher plan limit", func(t *testing.T) {
+		response := addMember()
+		if response["code"] != http.StatusForbidden {
+			t.Fatalf("expected status %d, got %v", http.StatusForbidden, response["code"])
+		}
+
+		if message, _ := response["message"].(string); !strings.Contains(message, "limit of 2") || !strings.Contains(message, "this deployment") {
+			t.Errorf("expected the error to name the deployment cap, got %q", message)
+		}
+	})
+
+	t.Run("test the plan limit applies when it is lower", func(t *testing.T) {
+		configs.MaxMembersPerOrg = 5000
+
+		if limit, setBy := orgs.memberLimit(&Organization{Version: ProVersion}); limit != DefaultMemberLimits[ProVersion] || setBy != "the pro plan" {
+			t.Errorf("expected the pro plan's limit, got %d set by %s", limit, setBy)
+		}
+
+		if response := addMember(); response["code"] != http.StatusOK {
+			t.Errorf("expected status %d, got %v: %v", http.StatusOK, response["code"], response["message"])
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package organizations
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+const maskedSecret = "********"
+
+var errPluginNotInstalled = errors.New("plugin is not installed in this organization")
+
+// PluginConfigUpdate is merged into a plugin's configuration. A null value removes the key.
+type PluginConfigUpdate struct {
+	Values  map[string]interface{} `json:"values"`
+	Secrets map[string]*string     `json:"secrets"`
+}
+
+// validConfigKey reports whether a key can be stored as a field name.
+func validConfigKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "$") && !strings.Contains(key, ".")
+}
+
+func (oh *OrganizationHandler) encryptSecret(value string) string {
+	return base64.StdEncoding.EncodeToString(utils.GCMEncrypt([]byte(value), oh.configs.SecretKey))
+}
+
+func (oh *OrganizationHandler) decryptSecret(value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := utils.GCMDecrypt(data, oh.configs.SecretKey)
+
+	return string(plain), err
+}
+
+// maskedPluginConfig returns a plugin configuration as it is sent back, with every secret
+// listed by key but masked.
+func maskedPluginConfig(config PluginConfig) utils.M {
+	values := config.Values
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	secrets := make(map[string]string, len(config.Secrets))
+	for key := range config.Secrets {
+		secrets[key] = maskedSecret
+	}
+
+	return utils.M{"values": values, "secrets": secrets, "updated_at": config.UpdatedAt}
+}
+
+// fetchInstalledPluginOrg fetches the organization from the request and checks the plugin
+// from the request is installed in it, writing the error response when either fails.
+func fetchInstalledPluginOrg(w http.ResponseWriter, r *http.Request) (*Organization, string, bool) {
+	orgID := mux.Vars(r)["id"]
+	pluginID := mux.Vars(r)["plugin_id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return nil, "", false
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return nil, "", false
+	}
+
+	if _, ok := org.Plugins[pluginID]; !ok {
+		utils.GetError(errPluginNotInstalled, http.StatusNotFound, w)
+		return nil, "", false
+	}
+
+	return org, pluginID, true
+}
+
+// Get how an organization has configured one of its plugins. Secret values are masked.
+func (oh *OrganizationHandler) GetPluginConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	org, pluginID, ok := fetchInstalledPluginOrg(w, r)
+	if !ok {
+		return
+	}
+
+	utils.GetSuccess("plugin config retrieved successfully", maskedPluginConfig(org.PluginConfig[pluginID]), w)
+}
+
+// Merge values and secrets into the configuration of a plugin installed in an organization.
+// Keys left out are kept, and keys set to null are removed.
+func (oh *OrganizationHandler) UpdatePluginConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var body PluginConfigUpdate
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if len(body.Values) == 0 && len(body.Secrets) == 0 {
+		utils.GetError(errors.New("values or secrets are required"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, pluginID, ok := fetchInstalledPluginOrg(w, r)
+	if !ok {
+		return
+	}
+
+	prefix := "plugin_config." + pluginID
+	set := bson.M{prefix + ".updated_at": utils.NowUTC()}
+	unset := bson.M{}
+
+	for key, value := range body.Values {
+		if !validConfigKey(key) {
+			utils.GetError(fmt.Errorf("invalid config key %q", key), http.StatusBadRequest, w)
+			return
+		}
+
+		if value == nil {
+			unset[prefix+".values."+key] = ""
+		} else {
+			set[prefix+".values."+key] = value
+		}
+	}
+
+	for key, value := range body.Secrets {
+		if !validConfigKey(key) {
+			utils.GetError(fmt.Errorf("invalid secret key %q", key), http.StatusBadRequest, w)
+			return
+		}
+
+		if value == nil {
+			unset[prefix+".secrets."+key] = ""
+		} else {
+			set[prefix+".secrets."+key] = oh.encryptSecret(*value)
+		}
+	}
+
+	pOrgID, _ := primitive.ObjectIDFromHex(org.ID)
+	update := bson.M{"$set": set}
+
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	if _, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(context.TODO(), bson.M{"_id": pOrgID}, update); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	updated, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("plugin config updated successfully", maskedPluginConfig(updated.PluginConfig[pluginID]), w)
+}
@@ -0,0 +1,113 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestPluginConfig(t *testing.T) {
+	const pluginID = "6169d8b54bfde011fe582e65"
+
+	var orgIDs []string
+
+	for i := 0; i < 2; i++ {
+		res, err := utils.CreateMongoDBDoc(context.TODO(), OrganizationCollectionName, map[string]interface{}{
+			"name":    fmt.Sprintf("Plugin Config Org %d", i),
+			"plugins": map[string]interface{}{pluginID: map[string]interface{}{"plugin_id": pluginID}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		orgIDs = append(orgIDs, res.InsertedID.(primitive.ObjectID).Hex())
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/plugins/{plugin_id}/config", orgs.GetPluginConfig).Methods("GET")
+	r.HandleFunc("/organizations/{id}/plugins/{plugin_id}/config", orgs.UpdatePluginConfig).Methods("PATCH")
+
+	update := func(t *testing.T, orgID, plugin, body string, code int) map[string]interface{} {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/plugins/%s/config", orgID, plugin), bytes.NewBufferString(body))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, code)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	get := func(t *testing.T, orgID string) map[string]interface{} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/plugins/%s/config", orgID, pluginID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		return parseResponse(response)["data"].(map[string]interface{})
+	}
+
+	t.Run("test config is kept apart between organizations", func(t *testing.T) {
+		update(t, orgIDs[0], pluginID, `{"values": {"channel": "general"}}`, http.StatusOK)
+		update(t, orgIDs[1], pluginID, `{"values": {"channel": "random"}}`, http.StatusOK)
+
+		for i, want := range []string{"general", "random"} {
+			values := get(t, orgIDs[i])["values"].(map[string]interface{})
+			if values["channel"] != want {
+				t.Errorf("expected organization %d channel %q, got %v", i, want, values["channel"])
+			}
+		}
+	})
+
+	t.Run("test updates are merged", func(t *testing.T) {
+		data := update(t, orgIDs[0], pluginID, `{"values": {"limit": 5}}`, http.StatusOK)
+
+		values := data["values"].(map[string]interface{})
+		if values["channel"] != "general" || values["limit"] != float64(5) {
+			t.Errorf("expected both values to be kept, got %v", values)
+		}
+
+		data = update(t, orgIDs[0], pluginID, `{"values": {"limit": null}}`, http.StatusOK)
+		if _, ok := data["values"].(map[string]interface{})["limit"]; ok {
+			t.Error("expected limit to be removed")
+		}
+	})
+
+	t.Run("test secrets are stored encrypted and masked", func(t *testing.T) {
+		const secret = "sk_live_123456"
+
+		data := update(t, orgIDs[0], pluginID, fmt.Sprintf(`{"secrets": {"api_key": %q}}`, secret), http.StatusOK)
+		if data["secrets"].(map[string]interface{})["api_key"] != maskedSecret {
+			t.Errorf("expected the secret to be masked, got %v", data["secrets"])
+		}
+
+		pOrgID, _ := primitive.ObjectIDFromHex(orgIDs[0])
+
+		org, err := FetchOrganization(context.TODO(), map[string]interface{}{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stored := org.PluginConfig[pluginID].Secrets["api_key"]
+		if stored == secret {
+			t.Fatal("expected the secret not to be stored as given")
+		}
+
+		if plain, err := orgs.decryptSecret(stored); err != nil || plain != secret {
+			t.Errorf("expected the stored secret to decrypt to %q, got %q (%v)", secret, plain, err)
+		}
+
+		if _, ok := get(t, orgIDs[1])["secrets"].(map[string]interface{})["api_key"]; ok {
+			t.Error("expected the other organization not to have the secret")
+		}
+	})
+
+	t.Run("test plugins that are not installed cannot be configured", func(t *testing.T) {
+		update(t, orgIDs[0], "6169d8b54bfde011fe582e66", `{"values": {"channel": "general"}}`, http.StatusNotFound)
+	})
+}
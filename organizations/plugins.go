@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
-	"time"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
@@ -42,7 +41,7 @@ func (oh *OrganizationHandler) AddOrganizationPlugin(w http.ResponseWriter, r *h
 		return
 	}
 
-	plugin, _ := utils.GetMongoDBDoc(PluginCollectionName, bson.M{"_id": pluginID})
+	plugin, _ := utils.GetMongoDBDoc(r.Context(), PluginCollectionName, bson.M{"_id": pluginID})
 
 	if plugin == nil {
 		utils.GetError(errors.New("operation failed"), http.StatusBadRequest, w)
@@ -57,7 +56,7 @@ func (oh *OrganizationHandler) AddOrganizationPlugin(w http.ResponseWriter, r *h
 		return
 	}
 
-	user, _ := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": creatorID, "org_id": OrgID})
+	user, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": creatorID, "org_id": OrgID})
 	if user == nil {
 		utils.GetError(errors.New("member doesn't exist in the organization"), http.StatusBadRequest, w)
 		return
@@ -81,7 +80,7 @@ func (oh *OrganizationHandler) AddOrganizationPlugin(w http.ResponseWriter, r *h
 		return
 	}
 
-	p, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": pOrgID},
+	p, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": pOrgID},
 		options.FindOne().SetProjection(bson.D{{Key: PluginCollectionName, Value: 1}, {Key: "_id", Value: 0}}))
 
 	plugins := make(map[string]interface{})
@@ -103,7 +102,7 @@ func (oh *OrganizationHandler) AddOrganizationPlugin(w http.ResponseWriter, r *h
 		Plugin:      plugin,
 		AddedBy:     userName,
 		ApprovedBy:  userName,
-		InstalledAt: time.Now(),
+		InstalledAt: utils.NowUTC(),
 	}
 
 	var pluginMap map[string]interface{}
@@ -130,7 +129,7 @@ func (oh *OrganizationHandler) AddOrganizationPlugin(w http.ResponseWriter, r *h
 
 		addPlugin := bson.M{"plugins": plugins}
 
-		save, err = utils.UpdateOneMongoDBDoc(OrganizationCollectionName, OrgID, addPlugin)
+		save, err = utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, OrgID, addPlugin)
 	}()
 
 	wg.Wait()
@@ -147,7 +146,7 @@ func (oh *OrganizationHandler) AddOrganizationPlugin(w http.ResponseWriter, r *h
 	go func() {
 		defer wg.Done()
 
-		increaseCount, err = utils.IncrementOneMongoDBDocField(PluginCollectionName, orgPlugin.PluginID, "install_count")
+		increaseCount, err = utils.IncrementOneMongoDBDocField(r.Context(), PluginCollectionName, orgPlugin.PluginID, "install_count")
 	}()
 
 	wg.Wait()
@@ -157,6 +156,8 @@ func (oh *OrganizationHandler) AddOrganizationPlugin(w http.ResponseWriter, r *h
 		return
 	}
 
+	completeOnboardingStep(OrgID, OnboardingInstalledPlugin)
+
 	data := map[string]interface{}{
 		"plugin_id": orgPlugin.PluginID,
 	}
@@ -176,7 +177,7 @@ func (oh *OrganizationHandler) GetOrganizationPlugins(w http.ResponseWriter, r *
 		return
 	}
 
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -211,7 +212,7 @@ func (oh *OrganizationHandler) GetOrganizationPlugin(w http.ResponseWriter, r *h
 		return
 	}
 
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -267,7 +268,7 @@ func (oh *OrganizationHandler) RemoveOrganizationPlugin(w http.ResponseWriter, r
 		return
 	}
 
-	user, _ := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": creatorID, "org_id": orgID})
+	user, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": creatorID, "org_id": orgID})
 	if user == nil {
 		utils.GetError(errors.New("member doesn't exist in the organization"), http.StatusBadRequest, w)
 		return
@@ -292,7 +293,7 @@ func (oh *OrganizationHandler) RemoveOrganizationPlugin(w http.ResponseWriter, r
 		return
 	}
 
-	save, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+	save, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 
 	if save == nil {
 		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
@@ -329,7 +330,7 @@ func (oh *OrganizationHandler) RemoveOrganizationPlugin(w http.ResponseWriter, r
 	updatedPlugins := make(map[string]interface{})
 	updatedPlugins["plugins"] = plugins
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, updatedPlugins)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, updatedPlugins)
 
 	if err != nil || update.ModifiedCount != 1 {
 		logger.Error("plugin failed to uninstall")
@@ -0,0 +1,100 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/service"
+	"zuri.chat/zccore/user"
+	"zuri.chat/zccore/utils"
+)
+
+// provisionedVerificationLifetime is how long the verification code sent to a provisioned user
+// lasts. They did not sign up themselves, so they get longer than the sign up code allows.
+const provisionedVerificationLifetime = 24 * time.Hour
+
+var errCreatorNotFound = errors.New("user with this email does not exist")
+
+// provisionUser creates an unverified account for email, marked as provisioned, and sends it a
+// verification code like signing up does, in the given locale. It returns the new user document.
+func (oh *OrganizationHandler) provisionUser(ctx context.Context, email, locale string) (bson.M, error) {
+	_, code := utils.RandomGen(6, "d")
+
+	now := utils.NowUTC()
+	newUser := user.User{
+		Email:       email,
+		CreatedAt:   now,
+		IsVerified:  false,
+		Provisioned: true,
+		Timezone:    "Africa/Lagos",
+		EmailVerification: &user.UserEmailVerification{
+			Token:     code,
+			ExpiredAt: now.Add(provisionedVerificationLifetime),
+		},
+	}
+
+	detail, err := utils.StructToMap(newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	// another request may have provisioned the same creator, in which case that account is used
+	// and its verification code has already been sent
+	_, err = utils.GetCollection(UserCollectionName).InsertOne(ctx, detail)
+	created := err == nil
+
+	if err != nil && !utils.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	userDoc, err := utils.GetMongoDBDoc(ctx, UserCollectionName, bson.M{"email": email})
+	if err != nil {
+		return nil, err
+	}
+
+	if !created {
+		return userDoc, nil
+	}
+
+	msger := oh.mailService.NewMail(
+		[]string{email}, "Account Confirmation", service.MailConfirmation, map[string]interface{}{
+			"Username": email,
+			"Code":     code,
+		}).WithLocale(locale)
+
+	if err := oh.mailService.SendMail(msger); err != nil {
+		logger.Error("could not send verification mail to provisioned user %s: %v", email, err)
+	}
+
+	return userDoc, nil
+}
+
+// provisionInvitedUser creates a verified account for an invited guest who has none. Following
+// the invite sent to email proves they own it, so no verification code is sent; they choose a
+// password by resetting it. It returns the new user, or the existing one if the guest signed up
+// in the meantime.
+func provisionInvitedUser(ctx context.Context, email string) (*user.User, error) {
+	newUser := user.User{
+		Email:       strings.ToLower(email),
+		CreatedAt:   utils.NowUTC(),
+		IsVerified:  true,
+		Provisioned: true,
+		Timezone:    "Africa/Lagos",
+	}
+
+	detail, err := utils.StructToMap(newUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = utils.GetCollection(UserCollectionName).InsertOne(ctx, detail); err != nil && !utils.IsDuplicateKeyError(err) {
+		return nil, err
+	}
+
+	return auth.FetchUserByEmail(bson.M{"email": newUser.Email})
+}
@@ -0,0 +1,146 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/utils"
+)
+
+// longest public description an organization can set
+const maxPublicDescriptionLength = 500
+
+// PublicOrganization is all of an organization anyone may see, once it is made public. Fields
+// are only ever added here deliberately, so nothing private is served by accident.
+type PublicOrganization struct {
+	ID           string `json:"_id" bson:"_id"`
+	Name         string `json:"name" bson:"name"`
+	LogoURL      string `json:"logo_url" bson:"logo_url"`
+	WorkspaceURL string `json:"workspace_url" bson:"workspace_url"`
+	Description  string `json:"description" bson:"public_description"`
+}
+
+// publicOrganizationProjection reads the fields of PublicOrganization, and no others.
+var publicOrganizationProjection = bson.M{"name": 1, "logo_url": 1, "workspace_url": 1, "public_description": 1}
+
+// Get the public profile of an organization, for pages anyone can see. The response can be
+// cached by CDNs for the configured time, and organizations that are not public, or that are
+// deleted, deactivated or not yet claimed, are not found.
+func (oh *OrganizationHandler) GetPublicOrganization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid id"), http.StatusBadRequest, w)
+		return
+	}
+
+	doc, _ := utils.GetMongoDBDocWithReadPref(r.Context(), OrganizationCollectionName, oh.readPreference(), bson.M{
+		"_id":         pOrgID,
+		"public":      true,
+		"deleted":     bson.M{"$ne": true},
+		"deactivated": bson.M{"$ne": true},
+		"pending":     bson.M{"$ne": true},
+		"merged_into": bson.M{"$in": []interface{}{nil, ""}},
+	}, options.FindOne().SetProjection(publicOrganizationProjection))
+
+	if doc == nil {
+		utils.GetError(organizationNotFound(orgID), http.StatusNotFound, w)
+		return
+	}
+
+	var org PublicOrganization
+	if err = utils.BsonToStruct(doc, &org); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	etag, err := organizationETag(&org)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(oh.configs.PublicOrganizationMaxAge.Seconds())))
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	utils.GetSuccess("organization retrieved successfully", org, w)
+}
+
+// Make an organization public or private, and set the description on its public profile.
+// Fields left out of the request are left as they are.
+func (oh *OrganizationHandler) UpdatePublicProfile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		Public      *bool   `json:"public"`
+		Description *string `json:"public_description"`
+	}
+
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	update := bson.M{}
+
+	if body.Public != nil {
+		update["public"] = *body.Public
+	}
+
+	if body.Description != nil {
+		description := strings.TrimSpace(*body.Description)
+		if utf8.RuneCountInString(description) > maxPublicDescriptionLength {
+			utils.GetError(fmt.Errorf("public_description cannot be longer than %d characters", maxPublicDescriptionLength), http.StatusBadRequest, w)
+			return
+		}
+
+		update["public_description"] = description
+	}
+
+	if len(update) == 0 {
+		utils.GetError(errors.New("nothing to update"), http.StatusBadRequest, w)
+		return
+	}
+
+	before, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": pOrgID}, options.FindOne().SetProjection(bson.M{"public": 1, "public_description": 1}))
+	if before == nil {
+		utils.GetError(organizationNotFound(orgID), http.StatusNotFound, w)
+		return
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, update); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	for key, value := range update {
+		if before[key] != value {
+			recordOrganizationChange(r, orgID, key, before[key], value)
+		}
+	}
+
+	utils.GetSuccess("public profile updated successfully", update, w)
+}
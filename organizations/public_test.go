@@ -0,0 +1,90 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+func TestGetPublicOrganization(t *testing.T) {
+	defer func(maxAge time.Duration) { configs.PublicOrganizationMaxAge = maxAge }(configs.PublicOrganizationMaxAge)
+
+	configs.PublicOrganizationMaxAge = 10 * time.Minute
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fields that are never public
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, bson.M{
+		"billing_contact_email": "billing@gmail.com",
+		"tags":                  []string{"beta"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/public", orgs.GetPublicOrganization).Methods("GET")
+	r.HandleFunc("/organizations/{id}/public", orgs.UpdatePublicProfile).Methods("PATCH")
+
+	path := fmt.Sprintf("/organizations/%s/public", orgID)
+
+	setPublic := func(t *testing.T, body string) {
+		req, _ := http.NewRequest("PATCH", path, bytes.NewBufferString(body))
+		assertStatusCode(t, getHTTPResponse(t, r, withUser(req, defaultUser)).Code, http.StatusOK)
+	}
+
+	t.Run("test private organizations are not found", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", path, nil)
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusNotFound)
+	})
+
+	t.Run("test only public fields are served with cache headers", func(t *testing.T) {
+		setPublic(t, `{"public": true, "public_description": "  We build things.  "}`)
+
+		req, _ := http.NewRequest("GET", path, nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if got := response.Header().Get("Cache-Control"); got != "public, max-age=600" {
+			t.Errorf("expected the response to be cacheable for 600 seconds, got %q", got)
+		}
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+
+		public := map[string]bool{"_id": true, "name": true, "logo_url": true, "workspace_url": true, "description": true}
+		for key := range data {
+			if !public[key] {
+				t.Errorf("expected only public fields, got %s", key)
+			}
+		}
+
+		if data["_id"] != orgID || data["description"] != "We build things." {
+			t.Errorf("expected the public profile of %s, got %v", orgID, data)
+		}
+
+		etag := response.Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("expected an ETag")
+		}
+
+		req, _ = http.NewRequest("GET", path, nil)
+		req.Header.Set("If-None-Match", etag)
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusNotModified)
+	})
+
+	t.Run("test organizations made private are not found again", func(t *testing.T) {
+		setPublic(t, `{"public": false}`)
+
+		req, _ := http.NewRequest("GET", path, nil)
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusNotFound)
+	})
+}
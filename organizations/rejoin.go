@@ -0,0 +1,78 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"zuri.chat/zccore/utils"
+)
+
+// activeMemberFilter matches the member of an organization with the given email, unless they
+// have been removed.
+func activeMemberFilter(orgID, email string) bson.M {
+	return bson.M{"org_id": orgID, "email": email, "deleted": bson.M{"$ne": true}}
+}
+
+var errInviteBeforeRemoval = errors.New("invite was sent before you were removed from this organization, ask for a new one")
+
+// rejoinRemovedMember brings back the removed member of an organization with the given email,
+// so someone added again keeps their join date, notes and settings. The role is changed when
+// given, and so is who invited them. It reports whether there was a removed member to bring back.
+//
+// Members rejoining by invite pass when it was sent, and are only brought back by invites sent
+// since they were removed, see errInviteBeforeRemoval. Admins adding them pass the zero time.
+func rejoinRemovedMember(ctx context.Context, orgID, email, role, invitedBy string, invitedAt time.Time) (primitive.ObjectID, bool, error) {
+	set := bson.M{"deleted": false, "deleted_at": time.Time{}}
+	if role != "" {
+		set["role"] = role
+	}
+
+	if invitedBy != "" {
+		set["invited_by"] = invitedBy
+	}
+
+	var member struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+
+	filter := bson.M{"org_id": orgID, "email": email, "deleted": true}
+	if !invitedAt.IsZero() {
+		filter["deleted_at"] = bson.M{"$lt": invitedAt}
+	}
+
+	err := utils.GetCollection(MemberCollectionName).FindOneAndUpdate(ctx, filter, bson.M{"$set": set}).Decode(&member)
+
+	if err == mongo.ErrNoDocuments {
+		if invitedAt.IsZero() {
+			return primitive.NilObjectID, false, nil
+		}
+
+		// there may still be a member removed after the invite was sent
+		if stale, _ := utils.GetMongoDBDoc(ctx, MemberCollectionName, bson.M{"org_id": orgID, "email": email, "deleted": true}); stale != nil {
+			return primitive.NilObjectID, false, errInviteBeforeRemoval
+		}
+
+		return primitive.NilObjectID, false, nil
+	}
+
+	if err != nil {
+		return primitive.NilObjectID, false, err
+	}
+
+	return member.ID, true, nil
+}
+
+// hasOrganization reports whether a user's organization ids include the organization.
+func hasOrganization(orgIDs []string, orgID string) bool {
+	for _, id := range orgIDs {
+		if id == orgID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,187 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestRemoveThenRejoin(t *testing.T) {
+	const email = "rejoiner@gmail.com"
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.CreateMongoDBDoc(context.TODO(), UserCollectionName, map[string]interface{}{"email": email}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members", orgs.CreateMember).Methods("POST")
+	r.HandleFunc("/organizations/{id}/members", orgs.GetMembers).Methods("GET")
+	r.HandleFunc("/organizations/{id}/members/{mem_id}", orgs.DeactivateMember).Methods("DELETE")
+
+	// add adds the user to the organization and returns the response data.
+	add := func(t *testing.T) map[string]interface{} {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/members", orgID), bytes.NewBufferString(fmt.Sprintf(`{"user_email": %q}`, email)))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		return parseResponse(response)["data"].(map[string]interface{})
+	}
+
+	// listed reports whether the user is in the organization's member listing.
+	listed := func(t *testing.T) bool {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		for _, member := range parseResponse(response)["data"].([]interface{}) {
+			if member.(map[string]interface{})["email"] == email {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	memberID := add(t)["member_id"].(string)
+
+	pMemberID, _ := primitive.ObjectIDFromHex(memberID)
+
+	joined, err := FetchMember(context.TODO(), bson.M{"_id": pMemberID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	note := MemberNote{OrgID: orgID, MemberID: memberID, Body: "Led the launch", Author: defaultUser, CreatedAt: time.Now()}
+	if _, err = utils.GetCollection(MemberNoteCollectionName).InsertOne(context.TODO(), note); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test removed members are not listed", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s/members/%s", orgID, memberID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if listed(t) {
+			t.Error("expected the removed member not to be listed")
+		}
+	})
+
+	t.Run("test rejoining keeps the member history", func(t *testing.T) {
+		data := add(t)
+
+		if data["member_id"] != memberID || data["rejoined"] != true {
+			t.Fatalf("expected member %s to rejoin, got %v", memberID, data)
+		}
+
+		if !listed(t) {
+			t.Error("expected the rejoined member to be listed")
+		}
+
+		rejoined, err := FetchMember(context.TODO(), bson.M{"_id": pMemberID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !rejoined.JoinedAt.Equal(joined.JoinedAt) {
+			t.Errorf("expected join date %v to be kept, got %v", joined.JoinedAt, rejoined.JoinedAt)
+		}
+
+		if n := utils.CountCollection(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": email}); n != 1 {
+			t.Errorf("expected one member entry, got %d", n)
+		}
+
+		if n := utils.CountCollection(context.TODO(), MemberNoteCollectionName, bson.M{"member_id": memberID}); n != 1 {
+			t.Errorf("expected the note to be kept, got %d notes", n)
+		}
+	})
+
+	t.Run("test active members cannot be added again", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/members", orgID), bytes.NewBufferString(fmt.Sprintf(`{"user_email": %q}`, email)))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusBadRequest)
+	})
+}
+
+func TestRemovedMemberCannotReplayInvite(t *testing.T) {
+	email := "replayer." + utils.GenUUID() + "@gmail.com"
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = setUpUser(email, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/guests/{uuid}", orgs.GuestToOrganization).Methods("POST")
+	r.HandleFunc("/organizations/{id}/members/{mem_id}", orgs.DeactivateMember).Methods("DELETE")
+
+	// invite saves an invite for the user sent at sentAt and returns its uuid.
+	invite := func(t *testing.T, sentAt time.Time) string {
+		inviteUUID := utils.GenUUID()
+		if _, err := utils.CreateMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": inviteUUID, "email": email, "org_id": orgID, "last_sent_at": sentAt}); err != nil {
+			t.Fatal(err)
+		}
+
+		return inviteUUID
+	}
+
+	accept := func(t *testing.T, inviteUUID string, code int) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/guests/%s", inviteUUID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, code)
+	}
+
+	accepted := invite(t, time.Now())
+	unused := invite(t, time.Now())
+
+	accept(t, accepted, http.StatusOK)
+
+	member, err := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": email})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s/members/%s", orgID, member["_id"].(primitive.ObjectID).Hex()), nil)
+	assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusOK)
+
+	t.Run("test the accepted invite cannot be used again", func(t *testing.T) {
+		accept(t, accepted, http.StatusGone)
+	})
+
+	t.Run("test invites sent before the removal cannot bring the member back", func(t *testing.T) {
+		accept(t, unused, http.StatusForbidden)
+
+		// the refused invite is left unused
+		if doc, _ := utils.GetMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": unused}); doc["has_accepted"] == true {
+			t.Error("expected the refused invite not to be used up")
+		}
+	})
+
+	t.Run("test a new invite brings the member back", func(t *testing.T) {
+		accept(t, invite(t, time.Now().Add(time.Second)), http.StatusOK)
+
+		if n := utils.CountCollection(context.TODO(), MemberCollectionName, activeMemberFilter(orgID, email)); n != 1 {
+			t.Errorf("expected the member to be back, found %d active entries", n)
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	roleSweepInterval        = time.Minute
+	maxTemporaryRoleDuration = 30 * 24 * time.Hour
+)
+
+// temporaryRoleFields are the member fields a temporary role is kept in, unset when it ends.
+var temporaryRoleFields = bson.M{
+	"temporary_role":            "",
+	"temporary_role_expires_at": "",
+	"temporary_role_granted_by": "",
+	"expiry_reminders_sent":     "",
+}
+
+// memberRoleFilter matches members holding any of the given roles, or errors on a role that
+// does not exist. Temporary roles are not matched, members are listed by their base role.
+func memberRoleFilter(roles []string) (bson.M, error) {
+	in := make([]string, 0, len(roles))
+
+	for _, role := range roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if _, ok := Roles[role]; !ok {
+			return nil, fmt.Errorf("invalid role %q", role)
+		}
+
+		in = append(in, role)
+	}
+
+	return bson.M{"$in": in}, nil
+}
+
+// RevertExpiredRoles clears the temporary roles that have expired by now, putting their members
+// back on their base role, and returns how many were cleared. Permission checks already ignore
+// expired roles; this keeps the member documents in line with them.
+func RevertExpiredRoles(now time.Time) (int, error) {
+	memberDocs, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{
+		"temporary_role_expires_at": bson.M{"$lte": now},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+
+	for _, doc := range memberDocs {
+		var member Member
+		if err := utils.BsonToStruct(doc, &member); err != nil {
+			continue
+		}
+
+		memberID, _ := primitive.ObjectIDFromHex(member.ID)
+
+		// a grant renewed since the members were read is left alone
+		res, err := utils.GetCollection(MemberCollectionName).UpdateOne(context.TODO(),
+			bson.M{"_id": memberID, "temporary_role_expires_at": member.TemporaryRoleExpiresAt},
+			bson.M{"$unset": temporaryRoleFields})
+		if err != nil {
+			logger.Error("could not revert temporary role of member %s: %v", member.ID, err)
+			continue
+		}
+
+		if res.ModifiedCount == 0 {
+			continue
+		}
+
+		reverted++
+
+		logger.Info("temporary %s role of member %s in %s expired, reverted to %s", member.TemporaryRole, member.ID, member.OrgID, member.Role)
+
+		entry := &audit.Log{
+			OrgID:      member.OrgID,
+			Actor:      "system",
+			Action:     audit.MemberTemporaryRoleExpired,
+			TargetType: "member",
+			TargetID:   member.ID,
+			Data:       map[string]interface{}{"temporary_role": member.TemporaryRole, "role": member.Role},
+		}
+
+		if err := audit.Record(entry); err != nil {
+			logger.Error("could not record expiry of temporary role of member %s: %v", member.ID, err)
+		}
+	}
+
+	return reverted, nil
+}
+
+// RunRoleSweeper reverts expired temporary roles every minute until the context is cancelled.
+func RunRoleSweeper(ctx context.Context) {
+	ticker := time.NewTicker(roleSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := RevertExpiredRoles(now); err != nil {
+				logger.Error("could not revert expired temporary roles: %v", err)
+			}
+		}
+	}
+}
+
+// Grant a member a higher role until a deadline, after which they are back on their own role.
+func (oh *OrganizationHandler) GrantTemporaryRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, memID := mux.Vars(r)["id"], mux.Vars(r)["mem_id"]
+
+	memberIDhex, err := primitive.ObjectIDFromHex(memID)
+	if err != nil {
+		utils.GetError(errors.New("invalid member id"), http.StatusBadRequest, w)
+		return
+	}
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		Role      string    `json:"role"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	role := strings.ToLower(body.Role)
+
+	if _, ok := auth.RoleRanks[role]; !ok {
+		utils.GetError(errors.New("role is not valid"), http.StatusBadRequest, w)
+		return
+	}
+
+	if role == OwnerRole {
+		utils.GetError(errors.New("ownership cannot be granted temporarily"), http.StatusBadRequest, w)
+		return
+	}
+
+	now := utils.NowUTC()
+
+	if !body.ExpiresAt.After(now) {
+		utils.GetError(errors.New("expires_at must be in the future"), http.StatusBadRequest, w)
+		return
+	}
+
+	if body.ExpiresAt.Sub(now) > maxTemporaryRoleDuration {
+		utils.GetError(fmt.Errorf("temporary roles cannot last longer than %d days", maxTemporaryRoleDuration/(24*time.Hour)), http.StatusBadRequest, w)
+		return
+	}
+
+	member, err := FetchMember(r.Context(), bson.M{"_id": memberIDhex, "org_id": orgID, "deleted": bson.M{"$ne": true}})
+	if err != nil {
+		utils.GetError(errors.New("user not a member of this work space"), http.StatusNotFound, w)
+		return
+	}
+
+	if auth.RoleRanks[role] <= auth.RoleRanks[member.Role] {
+		utils.GetError(fmt.Errorf("member is already %s, a temporary role must be higher", member.Role), http.StatusBadRequest, w)
+		return
+	}
+
+	// a new grant is reminded about afresh, see ExpiryReminderScheduler
+	update := bson.M{
+		"temporary_role":            role,
+		"temporary_role_expires_at": body.ExpiresAt,
+		"temporary_role_granted_by": strings.ToLower(loggedInUser.Email),
+		"expiry_reminders_sent":     []int{},
+	}
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memID, update); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	entry := &audit.Log{
+		OrgID:      orgID,
+		Actor:      loggedInUser.Email,
+		Action:     audit.MemberTemporaryRoleGranted,
+		TargetType: "member",
+		TargetID:   memID,
+		Data:       map[string]interface{}{"temporary_role": role, "expires_at": body.ExpiresAt},
+
+		ImpersonatedBy: loggedInUser.ImpersonatedBy,
+	}
+
+	if err = audit.Record(entry); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("temporary role granted successfully", utils.M{
+		"role":                      member.Role,
+		"temporary_role":            role,
+		"temporary_role_expires_at": body.ExpiresAt,
+	}, w)
+}
+
+// End a member's temporary role before it expires.
+func (oh *OrganizationHandler) RevokeTemporaryRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, memID := mux.Vars(r)["id"], mux.Vars(r)["mem_id"]
+
+	memberIDhex, err := primitive.ObjectIDFromHex(memID)
+	if err != nil {
+		utils.GetError(errors.New("invalid member id"), http.StatusBadRequest, w)
+		return
+	}
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	res, err := utils.GetCollection(MemberCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": memberIDhex, "org_id": orgID, "temporary_role": bson.M{"$exists": true}},
+		bson.M{"$unset": temporaryRoleFields})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.ModifiedCount == 0 {
+		utils.GetError(errors.New("member has no temporary role"), http.StatusNotFound, w)
+		return
+	}
+
+	entry := &audit.Log{
+		OrgID:      orgID,
+		Actor:      loggedInUser.Email,
+		Action:     audit.MemberTemporaryRoleRevoked,
+		TargetType: "member",
+		TargetID:   memID,
+
+		ImpersonatedBy: loggedInUser.ImpersonatedBy,
+	}
+
+	if err = audit.Record(entry); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("temporary role revoked successfully", nil, w)
+}
@@ -0,0 +1,207 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+func TestTemporaryRoles(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, consultant := "grantor@gmail.com", "consultant@gmail.com"
+
+	if _, err = setUpMember(orgID, admin, AdminRole); err != nil {
+		t.Fatal(err)
+	}
+
+	consultantID, err := setUpMember(orgID, consultant, MemberRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = setUpUser(consultant, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	au := auth.NewAuthHandler(configs, nil)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members/{mem_id}/temporary-role", orgs.GrantTemporaryRole).Methods("POST")
+	r.HandleFunc("/organizations/{id}/members/{mem_id}/temporary-role", orgs.RevokeTemporaryRole).Methods("DELETE")
+	r.HandleFunc("/organizations/{id}/announcements", au.IsAuthorized(orgs.CreateAnnouncement, "admin")).Methods("POST")
+
+	roleURL := fmt.Sprintf("/organizations/%s/members/%s/temporary-role", orgID, consultantID)
+
+	grant := func(role string, expiresAt time.Time) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"role": %q, "expires_at": %q}`, role, expiresAt.Format(time.RFC3339))
+		req, _ := http.NewRequest("POST", roleURL, bytes.NewBufferString(body))
+
+		return getHTTPResponse(t, r, withUser(req, admin))
+	}
+
+	// announcements can only be posted by owners and admins
+	announce := func() int {
+		body := fmt.Sprintf(`{"title": "Audit", "body": "Starting today", "expires_at": %q}`, time.Now().Add(time.Hour).Format(time.RFC3339))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/announcements", orgID), bytes.NewBufferString(body))
+
+		return getHTTPResponse(t, r, withUser(req, consultant)).Code
+	}
+
+	// sets the temporary role of the consultant directly, as if it had been granted earlier
+	setTemporaryRole := func(role string, expiresAt time.Time) {
+		update := bson.M{"temporary_role": role, "temporary_role_expires_at": expiresAt}
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, consultantID, update); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("test invalid grants are rejected", func(t *testing.T) {
+		assertStatusCode(t, grant(OwnerRole, time.Now().Add(time.Hour)).Code, http.StatusBadRequest)
+		assertStatusCode(t, grant(GuestRole, time.Now().Add(time.Hour)).Code, http.StatusBadRequest)
+		assertStatusCode(t, grant(AdminRole, time.Now().Add(-time.Hour)).Code, http.StatusBadRequest)
+		assertStatusCode(t, grant(AdminRole, time.Now().Add(maxTemporaryRoleDuration+time.Hour)).Code, http.StatusBadRequest)
+	})
+
+	t.Run("test temporary role gives access until it expires", func(t *testing.T) {
+		assertStatusCode(t, announce(), http.StatusUnauthorized)
+
+		response := grant(AdminRole, time.Now().Add(time.Hour))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		assertStatusCode(t, announce(), http.StatusOK)
+
+		// expired, but not yet swept
+		setTemporaryRole(AdminRole, time.Now().Add(-time.Minute))
+
+		assertStatusCode(t, announce(), http.StatusUnauthorized)
+	})
+
+	t.Run("test sweeper reverts expired roles", func(t *testing.T) {
+		setTemporaryRole(AdminRole, time.Now().Add(-time.Minute))
+
+		reverted, err := RevertExpiredRoles(time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if reverted != 1 {
+			t.Errorf("expected 1 reverted role, got %d", reverted)
+		}
+
+		memberID, _ := primitive.ObjectIDFromHex(consultantID)
+
+		member, err := FetchMember(context.TODO(), bson.M{"_id": memberID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if member.TemporaryRole != "" || member.TemporaryRoleExpiresAt != nil || member.Role != MemberRole {
+			t.Errorf("expected member role only, got role %s and temporary role %q", member.Role, member.TemporaryRole)
+		}
+
+		entries := utils.CountCollection(context.TODO(), audit.AuditLogCollectionName, bson.M{
+			"action":    audit.MemberTemporaryRoleExpired,
+			"target_id": consultantID,
+		})
+		if entries != 1 {
+			t.Errorf("expected 1 audit log entry for the expiry, got %d", entries)
+		}
+
+		if reverted, _ = RevertExpiredRoles(time.Now()); reverted != 0 {
+			t.Errorf("expected nothing left to revert, got %d", reverted)
+		}
+	})
+
+	t.Run("test sweeper leaves active roles alone", func(t *testing.T) {
+		setTemporaryRole(AdminRole, time.Now().Add(time.Hour))
+
+		if reverted, _ := RevertExpiredRoles(time.Now()); reverted != 0 {
+			t.Errorf("expected no reverted roles, got %d", reverted)
+		}
+
+		req, _ := http.NewRequest("DELETE", roleURL, nil)
+		response := getHTTPResponse(t, r, withUser(req, admin))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		assertStatusCode(t, announce(), http.StatusUnauthorized)
+	})
+}
+
+func TestGetMembersByRole(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	roles := map[string]string{
+		"roleowner@gmail.com":  OwnerRole,
+		"roleadmin@gmail.com":  AdminRole,
+		"roleadmin2@gmail.com": AdminRole,
+		"rolemember@gmail.com": MemberRole,
+		"roleguest@gmail.com":  GuestRole,
+	}
+
+	for email, role := range roles {
+		if _, err := setUpMember(orgID, email, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members", orgs.GetMembers).Methods("GET")
+
+	tests := []struct {
+		name       string
+		query      string
+		statusCode int
+		emails     []string
+	}{
+		{"test owners and admins only", "role=owner&role=admin", http.StatusOK, []string{"roleowner@gmail.com", "roleadmin@gmail.com", "roleadmin2@gmail.com"}},
+		{"test roles combine with search", "role=admin&query=roleadmin2", http.StatusOK, []string{"roleadmin2@gmail.com"}},
+		{"test invalid role", "role=superuser", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members?%s", orgID, tc.query), nil)
+
+			response := getHTTPResponse(t, r, req)
+			assertStatusCode(t, response.Code, tc.statusCode)
+
+			if tc.emails == nil {
+				return
+			}
+
+			members, _ := parseResponse(response)["data"].([]interface{})
+
+			got := make(map[string]bool)
+			for _, m := range members {
+				got[m.(map[string]interface{})["email"].(string)] = true
+			}
+
+			if len(got) != len(tc.emails) {
+				t.Errorf("expected %d members, got %v", len(tc.emails), got)
+			}
+
+			for _, email := range tc.emails {
+				if !got[email] {
+					t.Errorf("expected %s to be listed", email)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package organizations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// CurrentSchemaVersion is the schema version of organizations created now. Older documents are
+// brought up to it by the migrations below.
+const CurrentSchemaVersion = 2
+
+// schemaMigration upgrades organization documents from the version before it. upgrade returns
+// the update to apply to a document, e.g. $set for new fields and $rename for renamed ones, and
+// must leave fields a document already has alone.
+type schemaMigration struct {
+	version     int
+	description string
+	upgrade     func(org bson.M, configs *utils.Configurations) bson.M
+}
+
+var schemaMigrations = []schemaMigration{
+	{2, "default tags, locale, plugins and feature flag overrides", upgradeToV2},
+}
+
+// upgradeToV2 fills in the fields organizations created before them lack, or have as null.
+func upgradeToV2(org bson.M, configs *utils.Configurations) bson.M {
+	set := bson.M{}
+
+	if org["tags"] == nil {
+		set["tags"] = bson.A{}
+	}
+
+	if locale, _ := org["locale"].(string); locale == "" {
+		set["locale"] = configs.DefaultLocale
+	}
+
+	if org["plugins"] == nil {
+		set["plugins"] = bson.M{}
+	}
+
+	if org["feature_flag_overrides"] == nil {
+		set["feature_flag_overrides"] = bson.M{}
+	}
+
+	return bson.M{"$set": set}
+}
+
+// docSchemaVersion reads the schema version of an organization document. Documents from before
+// versioning have none, and are version 1.
+func docSchemaVersion(org bson.M) int {
+	switch version := org["schema_version"].(type) {
+	case int32:
+		if version > 1 {
+			return int(version)
+		}
+	case int64:
+		if version > 1 {
+			return int(version)
+		}
+	}
+
+	return 1
+}
+
+// schemaVersionFilter matches documents still at version, so a migration is applied once even
+// when two runners reach the same document.
+func schemaVersionFilter(version int) interface{} {
+	if version == 1 {
+		return bson.M{"$in": bson.A{nil, 0, 1}}
+	}
+
+	return version
+}
+
+// upgradeOrganization applies the migrations an organization document is missing, in order. It
+// returns the version the document is left at.
+func upgradeOrganization(ctx context.Context, org bson.M, configs *utils.Configurations) (int, error) {
+	version := docSchemaVersion(org)
+
+	for _, migration := range schemaMigrations {
+		if migration.version <= version {
+			continue
+		}
+
+		update := migration.upgrade(org, configs)
+
+		set, _ := update["$set"].(bson.M)
+		if set == nil {
+			set = bson.M{}
+			update["$set"] = set
+		}
+
+		set["schema_version"] = migration.version
+
+		res, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(ctx,
+			bson.M{"_id": org["_id"], "schema_version": schemaVersionFilter(version)}, update)
+		if err != nil {
+			return version, err
+		}
+
+		// another runner has migrated the document since it was read
+		if res.MatchedCount == 0 {
+			return version, nil
+		}
+
+		version = migration.version
+	}
+
+	return version, nil
+}
+
+// UpgradeOrganizations migrates every organization document older than the current schema. It
+// can be run any number of times, and returns how many documents it migrated.
+func UpgradeOrganizations(ctx context.Context, configs *utils.Configurations) (int, error) {
+	orgs, err := utils.GetMongoDBDocs(ctx, OrganizationCollectionName, bson.M{
+		"$or": []bson.M{
+			{"schema_version": nil},
+			{"schema_version": bson.M{"$lt": CurrentSchemaVersion}},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+
+	for _, org := range orgs {
+		from := docSchemaVersion(org)
+
+		to, err := upgradeOrganization(ctx, org, configs)
+		if err != nil {
+			return migrated, err
+		}
+
+		if to > from {
+			logger.Debug("migrated organization %v from schema version %d to %d", org["_id"], from, to)
+			migrated++
+		}
+	}
+
+	return migrated, nil
+}
+
+// MigrateOrganizationSchemas runs the organization migrations and logs the outcome. It is run
+// at startup.
+func MigrateOrganizationSchemas(configs *utils.Configurations) {
+	n, err := UpgradeOrganizations(context.Background(), configs)
+	if err != nil {
+		logger.Error("could not migrate organization schemas: %v", err)
+		return
+	}
+
+	if n > 0 {
+		logger.Info("migrated %d organizations to schema version %d", n, CurrentSchemaVersion)
+	}
+}
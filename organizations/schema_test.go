@@ -0,0 +1,98 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestUpgradeOrganizations(t *testing.T) {
+	// a version 1 document, from before tags, locales and schema versions
+	res, err := utils.GetCollection(OrganizationCollectionName).InsertOne(context.TODO(), bson.M{
+		"name":                   "Legacy Org",
+		"creator_email":          defaultUser,
+		"workspace_url":          "legacy-org.zurichat.com",
+		"plugins":                nil,
+		"tags":                   nil,
+		"feature_flag_overrides": bson.M{"threads": true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	legacyID := res.InsertedID.(primitive.ObjectID)
+
+	// a document already at the current version is left alone
+	currentID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, currentID, bson.M{"schema_version": CurrentSchemaVersion, "locale": "fr"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("test v1 document is migrated to v2", func(t *testing.T) {
+		if _, err := UpgradeOrganizations(context.TODO(), configs); err != nil {
+			t.Fatal(err)
+		}
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": legacyID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if org.SchemaVersion != 2 {
+			t.Errorf("expected schema version 2, got %d", org.SchemaVersion)
+		}
+
+		if org.Tags == nil || len(org.Tags) != 0 {
+			t.Errorf("expected empty tags, got %v", org.Tags)
+		}
+
+		if org.Plugins == nil {
+			t.Error("expected plugins to be set")
+		}
+
+		if org.Locale != configs.DefaultLocale {
+			t.Errorf("expected the default locale %q, got %q", configs.DefaultLocale, org.Locale)
+		}
+
+		// fields the document already had are kept
+		if !org.FeatureFlagOverrides["threads"] || org.Name != "Legacy Org" {
+			t.Errorf("expected existing fields to be kept, got name %q and overrides %v", org.Name, org.FeatureFlagOverrides)
+		}
+
+		pCurrentID, _ := primitive.ObjectIDFromHex(currentID)
+
+		current, err := FetchOrganization(context.TODO(), bson.M{"_id": pCurrentID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if current.Locale != "fr" {
+			t.Errorf("expected a current document to be left alone, got locale %q", current.Locale)
+		}
+	})
+
+	t.Run("test migrating again changes nothing", func(t *testing.T) {
+		migrated, err := UpgradeOrganizations(context.TODO(), configs)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if migrated != 0 {
+			t.Errorf("expected no documents to be migrated again, got %d", migrated)
+		}
+
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), OrganizationCollectionName, bson.M{"_id": legacyID})
+
+		version, err := upgradeOrganization(context.TODO(), doc, configs)
+		if err != nil || version != CurrentSchemaVersion {
+			t.Errorf("expected upgrading a current document to leave it at %d, got %d (%v)", CurrentSchemaVersion, version, err)
+		}
+	})
+}
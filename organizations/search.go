@@ -0,0 +1,15 @@
+package organizations
+
+import (
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// EnsureMemberSearchIndex creates the index member searches use under the configured search
+// collation, and logs when it cannot. Without it they still work, scanning the members. It is
+// run at startup.
+func EnsureMemberSearchIndex(strength int) {
+	if err := utils.CreateCollatedIndex(MemberCollectionName, strength, "org_id", "deleted"); err != nil {
+		logger.Error("could not create member search index, searches will scan members: %v", err)
+	}
+}
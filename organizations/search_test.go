@@ -0,0 +1,77 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+func TestMemberSearchIgnoresAccents(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for email, firstName := range map[string]string{"zoe@gmail.com": "Zoë", "rene@gmail.com": "René", "renata@gmail.com": "Renata"} {
+		memberID, err := setUpMember(orgID, email, MemberRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, memberID, bson.M{"first_name": firstName}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// search returns the first names of the members found for query at the collation strength.
+	search := func(t *testing.T, query string, strength int) []string {
+		searching := *configs
+		searching.SearchCollationStrength = strength
+
+		r := getRouter()
+		r.HandleFunc("/organizations/{id}/members", NewOrganizationHandler(&searching, nil).GetMembers).Methods("GET")
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members?query=%s", orgID, url.QueryEscape(query)), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		names := []string{}
+		for _, member := range parseResponse(response)["data"].([]interface{}) {
+			names = append(names, member.(map[string]interface{})["first_name"].(string))
+		}
+
+		return names
+	}
+
+	t.Run("test accents are ignored at strength 1", func(t *testing.T) {
+		if names := search(t, "zoe", utils.CollationIgnoreAccents); len(names) != 1 || names[0] != "Zoë" {
+			t.Errorf("expected zoe to find Zoë, got %v", names)
+		}
+
+		if names := search(t, "RENÉ", utils.CollationIgnoreAccents); len(names) != 1 || names[0] != "René" {
+			t.Errorf("expected RENÉ to find René only, got %v", names)
+		}
+	})
+
+	t.Run("test accents count at strength 2", func(t *testing.T) {
+		if names := search(t, "zoe", utils.CollationIgnoreCase); len(names) != 0 {
+			t.Errorf("expected zoe not to find Zoë, got %v", names)
+		}
+
+		if names := search(t, "ZOË", utils.CollationIgnoreCase); len(names) != 1 {
+			t.Errorf("expected ZOË to find Zoë, got %v", names)
+		}
+	})
+
+	t.Run("test accents count without a collation", func(t *testing.T) {
+		if names := search(t, "rene", 0); len(names) != 0 {
+			t.Errorf("expected rene not to find René, got %v", names)
+		}
+	})
+}
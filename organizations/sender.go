@@ -0,0 +1,38 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"zuri.chat/zccore/service"
+	"zuri.chat/zccore/utils"
+)
+
+// maxEmailFromNameLength is how long the display name of an organization's emails can be.
+const maxEmailFromNameLength = 64
+
+// validateEmailSender checks the display name and reply-to address an organization's emails
+// are sent with. Either can be empty, for our own name and no reply-to.
+func validateEmailSender(fromName, replyTo string) error {
+	if len([]rune(fromName)) > maxEmailFromNameLength {
+		return fmt.Errorf("email_from_name can be at most %d characters", maxEmailFromNameLength)
+	}
+
+	// names are put in mail headers, where these would end or break them
+	if strings.ContainsAny(fromName, `<>"`) || strings.IndexFunc(fromName, unicode.IsControl) >= 0 {
+		return errors.New(`email_from_name cannot contain <, >, " or control characters`)
+	}
+
+	if replyTo != "" && !utils.IsValidEmail(replyTo) {
+		return errors.New("email_reply_to must be a valid email address")
+	}
+
+	return nil
+}
+
+// sentFor sets the sender of a mail sent on behalf of the organization.
+func (o *Organization) sentFor(mail *service.Mail) *service.Mail {
+	return mail.WithSender(o.EmailFromName, o.EmailReplyTo)
+}
@@ -0,0 +1,71 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/service"
+	"zuri.chat/zccore/utils"
+)
+
+func TestEmailSender(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mailer := newMockMailService()
+	handler := NewOrganizationHandler(configs, mailer)
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/settings", handler.UpdateOrganizationSettings).Methods("PATCH")
+	r.HandleFunc("/organizations/{id}/send-invite", handler.SendInvite).Methods("POST")
+
+	// invite sends an invite to a new address and returns the sender of the mail sent.
+	invite := func(t *testing.T) (string, string) {
+		email := fmt.Sprintf("sender.%s@gmail.com", utils.GenUUID())
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/send-invite", orgID), bytes.NewBufferString(fmt.Sprintf(`{"emails": [%q]}`, email)))
+		assertStatusCode(t, getHTTPResponse(t, r, withUser(req, defaultUser)).Code, http.StatusCreated)
+
+		mail := mailer.lastMailTo(email)
+		if mail == nil {
+			t.Fatalf("expected an invite mail to %s", email)
+		}
+
+		return mail.Sender()
+	}
+
+	t.Run("test invites come from us by default", func(t *testing.T) {
+		if name, replyTo := invite(t); name != service.DefaultSenderName || replyTo != "" {
+			t.Errorf("expected %s and no reply-to, got %q %q", service.DefaultSenderName, name, replyTo)
+		}
+	})
+
+	t.Run("test invites use the organization's sender", func(t *testing.T) {
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, bson.M{"email_from_name": "Acme People Team", "email_reply_to": "people@acme.com"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if name, replyTo := invite(t); name != "Acme People Team" || replyTo != "people@acme.com" {
+			t.Errorf("expected Acme People Team replying to people@acme.com, got %q %q", name, replyTo)
+		}
+	})
+
+	t.Run("test invalid senders are rejected", func(t *testing.T) {
+		for _, body := range []string{
+			`{"email_reply_to": "not-an-email"}`,
+			`{"email_from_name": "Acme <ceo@acme.com>"}`,
+			`{"email_from_name": "Acme\r\nBcc: everyone@acme.com"}`,
+		} {
+			req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/settings", orgID), bytes.NewBufferString(body))
+
+			response := getHTTPResponse(t, r, req)
+			assertStatusCode(t, response.Code, http.StatusBadRequest)
+		}
+	})
+}
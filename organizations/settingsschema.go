@@ -0,0 +1,101 @@
+package organizations
+
+import "fmt"
+
+// kinds of values an organization setting can hold, as they decode from JSON.
+const (
+	settingString     = "string"
+	settingBool       = "boolean"
+	settingStringList = "array of strings"
+	settingObject     = "object"
+)
+
+// settingField describes a known organization setting: the kind of value it holds and the value
+// organizations start with.
+type settingField struct {
+	Kind    string
+	Default interface{}
+}
+
+// organizationSettingsSchema lists the keys UpdateOrganizationSettings accepts. Any other key is
+// rejected, so clients cannot store settings nothing reads.
+var organizationSettingsSchema = map[string]settingField{
+	"workspaceicon":      {Kind: settingString, Default: ""},
+	"workspacename":      {Kind: settingString, Default: ""},
+	"workspacelanguage":  {Kind: settingString, Default: ""},
+	"defaultchannels":    {Kind: settingStringList, Default: []string{}},
+	"showdisplayname":    {Kind: settingBool, Default: false},
+	"displayemail":       {Kind: settingBool, Default: false},
+	"displaypronouns":    {Kind: settingBool, Default: false},
+	"notifyofnewusers":   {Kind: settingBool, Default: false},
+	"deleteorganization": {Kind: settingObject, Default: map[string]interface{}{}},
+
+	// kept on the organization rather than in its settings, see UpdateOrganizationSettings
+	"locale":          {Kind: settingString, Default: ""},
+	"email_from_name": {Kind: settingString, Default: ""},
+	"email_reply_to":  {Kind: settingString, Default: ""},
+}
+
+// holds reports whether a value decoded from JSON is of the field's kind. Null is taken as the
+// setting not being given.
+func (f settingField) holds(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	switch f.Kind {
+	case settingString:
+		_, ok := value.(string)
+		return ok
+	case settingBool:
+		_, ok := value.(bool)
+		return ok
+	case settingObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case settingStringList:
+		list, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// validateSettingsPatch checks a patch of settings against a schema, returning an error by key
+// for unknown keys and values of the wrong kind, or nil when the patch is valid.
+func validateSettingsPatch(patch map[string]interface{}, schema map[string]settingField) map[string]string {
+	var errs map[string]string
+
+	for key, value := range patch {
+		field, known := schema[key]
+
+		var msg string
+
+		switch {
+		case !known:
+			msg = "unknown setting"
+		case !field.holds(value):
+			msg = fmt.Sprintf("expected %s", field.Kind)
+		default:
+			continue
+		}
+
+		if errs == nil {
+			errs = map[string]string{}
+		}
+
+		errs[key] = msg
+	}
+
+	return errs
+}
@@ -0,0 +1,101 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateSettingsPatch(t *testing.T) {
+	patch := map[string]interface{}{
+		"workspacelanguage":  "French",
+		"showdisplayname":    true,
+		"defaultchannels":    []interface{}{"general", "random"},
+		"deleteorganization": map[string]interface{}{"owners_only": true},
+		"locale":             nil,
+	}
+
+	if errs := validateSettingsPatch(patch, organizationSettingsSchema); errs != nil {
+		t.Errorf("expected a valid patch, got %v", errs)
+	}
+
+	patch = map[string]interface{}{
+		"showdisplayname": "yes",
+		"defaultchannels": []interface{}{"general", 2.0},
+		"favourite_color": "blue",
+		"workspaceicon":   "icon.png",
+	}
+
+	want := map[string]string{
+		"showdisplayname": "expected boolean",
+		"defaultchannels": "expected array of strings",
+		"favourite_color": "unknown setting",
+	}
+
+	errs := validateSettingsPatch(patch, organizationSettingsSchema)
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+
+	for key, msg := range want {
+		if errs[key] != msg {
+			t.Errorf("expected %s to be rejected with %q, got %q", key, msg, errs[key])
+		}
+	}
+}
+
+func TestUpdateOrganizationSettingsSchema(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/settings", orgs.UpdateOrganizationSettings).Methods("PATCH")
+
+	update := func(t *testing.T, body string, code int) map[string]interface{} {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/settings", orgID), bytes.NewBufferString(body))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, code)
+
+		return parseResponse(response)
+	}
+
+	pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+	t.Run("test a valid patch is saved", func(t *testing.T) {
+		update(t, `{"workspacelanguage": "French", "displayemail": true, "defaultchannels": ["general"]}`, http.StatusOK)
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if settings := org.Settings.Settings; settings.WorkspaceLanguage != "French" || !settings.DisplayEmail || len(settings.DefaultChannels) != 1 {
+			t.Errorf("expected the patch to be saved, got %+v", settings)
+		}
+	})
+
+	t.Run("test wrong types and unknown keys are rejected by field", func(t *testing.T) {
+		data, _ := update(t, `{"displayemail": "no", "theme_color": "red"}`, http.StatusUnprocessableEntity)["data"].(map[string]interface{})
+
+		if data["displayemail"] != "expected boolean" || data["theme_color"] != "unknown setting" {
+			t.Errorf("expected errors for displayemail and theme_color, got %v", data)
+		}
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !org.Settings.Settings.DisplayEmail {
+			t.Error("expected a rejected patch to leave the settings as they were")
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package organizations
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/utils"
+)
+
+// similarOrganizations returns the organizations created by creatorEmail whose names are at
+// least as alike to name as the configured threshold, the most alike first, so a new
+// organization that looks like a duplicate can be pointed out. It never stops one being created.
+func (oh *OrganizationHandler) similarOrganizations(ctx context.Context, creatorEmail, name string) ([]utils.M, error) {
+	threshold := oh.configs.OrgNameSimilarityThreshold
+	similar := []utils.M{}
+
+	if threshold <= 0 || name == "" {
+		return similar, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"name": 1, "workspace_url": 1})
+
+	docs, err := utils.GetMongoDBDocs(ctx, OrganizationCollectionName, bson.M{"creator_email": creatorEmail}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		existing, _ := doc["name"].(string)
+
+		similarity := utils.NameSimilarity(name, existing)
+		if similarity < threshold {
+			continue
+		}
+
+		var id string
+		if pOrgID, ok := doc["_id"].(primitive.ObjectID); ok {
+			id = pOrgID.Hex()
+		}
+
+		similar = append(similar, utils.M{
+			"_id":           id,
+			"name":          existing,
+			"workspace_url": doc["workspace_url"],
+			"similarity":    similarity,
+		})
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i]["similarity"].(float64) > similar[j]["similarity"].(float64)
+	})
+
+	return similar, nil
+}
@@ -0,0 +1,60 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"zuri.chat/zccore/utils"
+)
+
+func TestCreateOrganizationSimilarNames(t *testing.T) {
+	creator := "similar." + utils.GenUUID() + "@gmail.com"
+
+	if err := setUpUser(creator, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	detail, _ := utils.StructToMap(Organization{Name: "Acme Corp", WorkspaceURL: "acme-corp", CreatorEmail: creator})
+	if _, err := utils.CreateMongoDBDoc(context.TODO(), OrganizationCollectionName, detail); err != nil {
+		t.Fatal(err)
+	}
+
+	// similar creates an organization named name and returns the organizations it was said to resemble.
+	similar := func(t *testing.T, name string) []interface{} {
+		body := bytes.NewBufferString(fmt.Sprintf(`{"creator_email": %q, "name": %q}`, creator, name))
+		req, _ := http.NewRequest("POST", "/organizations", body)
+
+		response := httptest.NewRecorder()
+		orgs.Create(response, withUser(req, creator))
+
+		assertStatusCode(t, response.Code, http.StatusCreated)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+		candidates, _ := data["similar_organizations"].([]interface{})
+
+		return candidates
+	}
+
+	t.Run("test near duplicates are pointed out but still created", func(t *testing.T) {
+		for _, name := range []string{"acme  corp", "Acme Corpp"} {
+			candidates := similar(t, name)
+			if len(candidates) != 1 {
+				t.Fatalf("%q: expected 1 similar organization, got %v", name, candidates)
+			}
+
+			if candidate := candidates[0].(map[string]interface{}); candidate["name"] != "Acme Corp" {
+				t.Errorf("%q: expected Acme Corp, got %v", name, candidate)
+			}
+		}
+	})
+
+	t.Run("test dissimilar names are not", func(t *testing.T) {
+		if candidates := similar(t, "Globex Industries"); len(candidates) != 0 {
+			t.Errorf("expected no similar organizations, got %v", candidates)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const maxUploadMemory = 32 << 20
+
+var errStorageQuotaExceeded = errors.New("organization storage quota exceeded")
+
+// DefaultStorageQuotas is the storage, in bytes, an organization gets on each plan
+// unless it has been given a quota of its own.
+var DefaultStorageQuotas = map[string]int64{
+	FreeVersion: 5 << 30,
+	ProVersion:  100 << 30,
+}
+
+// EffectiveStorageQuota returns the organization's own quota, or the default for its plan.
+func (o *Organization) EffectiveStorageQuota() int64 {
+	if o.StorageQuota > 0 {
+		return o.StorageQuota
+	}
+
+	if quota, ok := DefaultStorageQuotas[o.Version]; ok {
+		return quota
+	}
+
+	return DefaultStorageQuotas[FreeVersion]
+}
+
+// uploadSize returns the total size of the files sent in a multipart form field.
+func uploadSize(r *http.Request, field string) (int64, error) {
+	if err := r.ParseMultipartForm(maxUploadMemory); err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, fh := range r.MultipartForm.File[field] {
+		size += fh.Size
+	}
+
+	return size, nil
+}
+
+// reserveStorage adds size bytes to an organization's storage usage if that keeps it within quota.
+// The quota is checked in the update filter, so concurrent uploads cannot overshoot it together.
+func reserveStorage(ctx context.Context, orgID string, size int64) error {
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return errors.New("invalid organization id")
+	}
+
+	org, err := FetchOrganization(ctx, bson.M{"_id": pOrgID})
+	if err != nil {
+		return errors.New("organization does not exist")
+	}
+
+	limit := org.EffectiveStorageQuota() - size
+	if limit < 0 {
+		return errStorageQuotaExceeded
+	}
+
+	filter := bson.M{"_id": pOrgID, "$or": []bson.M{
+		{"storage_used": bson.M{"$lte": limit}},
+		{"storage_used": bson.M{"$exists": false}},
+	}}
+
+	res, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"storage_used": size}})
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errStorageQuotaExceeded
+	}
+
+	return nil
+}
+
+// releaseStorage gives size bytes back to an organization.
+func releaseStorage(orgID string, size int64) error {
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return errors.New("invalid organization id")
+	}
+
+	_, err = utils.GenericUpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, pOrgID, bson.M{"$inc": bson.M{"storage_used": -size}})
+
+	return err
+}
+
+// records an uploaded file so its size can be released when it is deleted.
+func recordStoredFile(orgID, url string, size int64) error {
+	file := StoredFile{OrgID: orgID, URL: url, Size: size, CreatedAt: utils.NowUTC()}
+	if _, err := utils.GetCollection(StoredFileCollectionName).InsertOne(context.TODO(), file); err != nil {
+		return err
+	}
+
+	utils.RecordUsageEvent(orgID, UsageStorageBytes, size)
+	utils.RecordUsageEvent(orgID, UsageFilesStored, 1)
+
+	return nil
+}
+
+// releaseStoredFile credits a deleted file's size back to its organization.
+// Files uploaded before storage was tracked are not recorded and release nothing.
+func releaseStoredFile(orgID, url string) error {
+	if url == "" {
+		return nil
+	}
+
+	doc, _ := utils.GetMongoDBDoc(context.TODO(), StoredFileCollectionName, bson.M{"org_id": orgID, "url": url})
+	if doc == nil {
+		return nil
+	}
+
+	var file StoredFile
+	if err := utils.BsonToStruct(doc, &file); err != nil {
+		return err
+	}
+
+	if _, err := utils.DeleteOneMongoDBDoc(context.TODO(), StoredFileCollectionName, file.ID); err != nil {
+		return err
+	}
+
+	// storage is metered as what is held, so deleted files are taken off
+	utils.RecordUsageEvent(orgID, UsageStorageBytes, -file.Size)
+	utils.RecordUsageEvent(orgID, UsageFilesStored, -1)
+
+	return releaseStorage(orgID, file.Size)
+}
+
+// gives back storage reserved for an upload that did not go through. Storage is given back even
+// when the request has timed out, so it is not released on the request context.
+func cancelReservation(orgID string, size int64) {
+	if err := releaseStorage(orgID, size); err != nil {
+		logger.Error("could not release %d bytes of storage for %s: %v", size, orgID, err)
+	}
+}
+
+// credits back a file that has been deleted or replaced, whether or not the request is still
+// going.
+func discardStoredFile(orgID, url string) {
+	if err := releaseStoredFile(orgID, url); err != nil {
+		logger.Error("could not release storage of %s for %s: %v", url, orgID, err)
+	}
+}
+
+// reserves storage for the files in a multipart field, writing the error response on failure.
+func reserveUploadStorage(w http.ResponseWriter, r *http.Request, orgID, field string) (int64, bool) {
+	size, err := uploadSize(r, field)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return 0, false
+	}
+
+	err = reserveStorage(r.Context(), orgID, size)
+	if errors.Is(err, errStorageQuotaExceeded) {
+		utils.GetError(err, http.StatusInsufficientStorage, w)
+		return 0, false
+	}
+
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return 0, false
+	}
+
+	return size, true
+}
+
+// Get how much storage an organization uses and how much it may use.
+func (oh *OrganizationHandler) GetStorageUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(errors.New("organization does not exist"), http.StatusNotFound, w)
+		return
+	}
+
+	quota := org.EffectiveStorageQuota()
+
+	utils.GetSuccess("storage usage retrieved successfully", utils.M{
+		"storage_used":      org.StorageUsed,
+		"storage_quota":     quota,
+		"storage_available": quota - org.StorageUsed,
+	}, w)
+}
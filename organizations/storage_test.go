@@ -0,0 +1,108 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+// multipartFile builds an upload request body holding a single PDF of the given size.
+func multipartFile(t *testing.T, field string, size int) (*bytes.Buffer, string) {
+	content := append([]byte("%PDF-1.4\n"), bytes.Repeat([]byte("0"), size-9)...)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile(field, "report.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part.Write(content)
+	writer.Close()
+
+	return body, writer.FormDataContentType()
+}
+
+func TestStorageQuota(t *testing.T) {
+	defer os.RemoveAll("files")
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, bson.M{"storage_quota": 1024}); err != nil {
+		t.Fatal(err)
+	}
+
+	memID, err := setUpMember(orgID, "uploader@gmail.com", MemberRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members/{mem_id}/uploadfile", orgs.UploadFile).Methods("POST")
+
+	storageUsed := func() int64 {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return org.StorageUsed
+	}
+
+	t.Run("test upload under quota is counted", func(t *testing.T) {
+		body, contentType := multipartFile(t, "file", 600)
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/members/%s/uploadfile", orgID, memID), body)
+		req.Header.Set("Content-Type", contentType)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if used := storageUsed(); used != 600 {
+			t.Errorf("expected 600 bytes used, got %d", used)
+		}
+	})
+
+	t.Run("test upload over quota is rejected", func(t *testing.T) {
+		body, contentType := multipartFile(t, "file", 600)
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/members/%s/uploadfile", orgID, memID), body)
+		req.Header.Set("Content-Type", contentType)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusInsufficientStorage)
+
+		if used := storageUsed(); used != 600 {
+			t.Errorf("rejected upload changed usage to %d bytes", used)
+		}
+	})
+
+	t.Run("test deleting a file releases its storage", func(t *testing.T) {
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), StoredFileCollectionName, bson.M{"org_id": orgID})
+		if doc == nil {
+			t.Fatal("uploaded file was not recorded")
+		}
+
+		if err := releaseStoredFile(orgID, doc["url"].(string)); err != nil {
+			t.Fatal(err)
+		}
+
+		if used := storageUsed(); used != 0 {
+			t.Errorf("expected no storage used after deletion, got %d", used)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package organizations
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -105,7 +106,7 @@ func HandlePingPlugin(plgd string, ch chan error, wg *sync.WaitGroup) {
 		return
 	}
 
-	pluginDetails, _ := utils.GetMongoDBDoc(pluginp.PluginCollectionName, bson.M{"_id": ppID})
+	pluginDetails, _ := utils.GetMongoDBDoc(context.TODO(), pluginp.PluginCollectionName, bson.M{"_id": ppID})
 	if pluginDetails == nil {
 		ch <- fmt.Errorf("plugin not found")
 		return
@@ -149,7 +150,7 @@ func HandleAddingMessage(pluginid, event string, message interface{}, ch chan er
 		return
 	}
 
-	pluginDetails, _ := utils.GetMongoDBDoc(pluginp.PluginCollectionName, bson.M{"_id": ppID})
+	pluginDetails, _ := utils.GetMongoDBDoc(context.TODO(), pluginp.PluginCollectionName, bson.M{"_id": ppID})
 	if pluginDetails == nil {
 		ch <- fmt.Errorf("plugin not found")
 		return
@@ -174,7 +175,7 @@ func HandleAddingMessage(pluginid, event string, message interface{}, ch chan er
 	plugin.Queue = append(plugin.Queue, newMessage)
 
 	updateFields["queue"], updateFields["queuepid"] = plugin.Queue, newID
-	_, ee := utils.UpdateOneMongoDBDoc(pluginp.PluginCollectionName, pluginid, updateFields)
+	_, ee := utils.UpdateOneMongoDBDoc(context.TODO(), pluginp.PluginCollectionName, pluginid, updateFields)
 
 	if ee != nil {
 		ch <- ee
@@ -194,7 +195,7 @@ func GetInstalledPlugins(organizationID string) ([]string, error) {
 		return nil, err
 	}
 
-	orgDetails, _ := utils.GetMongoDBDoc(collection, bson.M{"_id": objID})
+	orgDetails, _ := utils.GetMongoDBDoc(context.TODO(), collection, bson.M{"_id": objID})
 	if orgDetails == nil {
 		return nil, fmt.Errorf("organization Does not exist")
 	}
@@ -0,0 +1,191 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/utils"
+)
+
+const maxBulkTagOrganizations = 1000
+
+// BulkTagRequest adds and removes tags on many organizations at once.
+type BulkTagRequest struct {
+	OrganizationIDs []string `json:"organization_ids"`
+	Add             []string `json:"add"`
+	Remove          []string `json:"remove"`
+}
+
+// normalizeTags trims and lower-cases tags, dropping empty and repeated ones.
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	normalized := []string{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+// tagChanges returns which of add an organization with tags lacks, and which of remove it has.
+func tagChanges(tags, add, remove []string) (added, removed []string) {
+	has := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		has[tag] = true
+	}
+
+	added, removed = []string{}, []string{}
+
+	for _, tag := range add {
+		if !has[tag] {
+			added = append(added, tag)
+		}
+	}
+
+	for _, tag := range remove {
+		if has[tag] {
+			removed = append(removed, tag)
+		}
+	}
+
+	return added, removed
+}
+
+// Add and remove tags on a list of organizations. Each organization is reported with the tags
+// it gained and lost, or skipped when it already had the requested tags.
+func (oh *OrganizationHandler) BulkTagOrganizations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var body BulkTagRequest
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if len(body.OrganizationIDs) == 0 {
+		utils.GetError(errors.New("organization_ids is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	if len(body.OrganizationIDs) > maxBulkTagOrganizations {
+		utils.GetError(fmt.Errorf("at most %d organizations can be tagged at once", maxBulkTagOrganizations), http.StatusBadRequest, w)
+		return
+	}
+
+	add, remove := normalizeTags(body.Add), normalizeTags(body.Remove)
+
+	if len(add) == 0 && len(remove) == 0 {
+		utils.GetError(errors.New("no tags to add or remove"), http.StatusBadRequest, w)
+		return
+	}
+
+	for _, tag := range remove {
+		for _, added := range add {
+			if tag == added {
+				utils.GetError(fmt.Errorf("tag %q cannot be both added and removed", tag), http.StatusBadRequest, w)
+				return
+			}
+		}
+	}
+
+	result := utils.NewBulkResult()
+	requested := make(map[string]string, len(body.OrganizationIDs))
+	orgIDs := make([]primitive.ObjectID, 0, len(body.OrganizationIDs))
+
+	for _, id := range body.OrganizationIDs {
+		pOrgID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			result.Fail(id, "invalid organization id", nil)
+			continue
+		}
+
+		if _, ok := requested[pOrgID.Hex()]; ok {
+			result.Skip(id, "organization appears earlier in the list", nil)
+			continue
+		}
+
+		requested[pOrgID.Hex()] = id
+		orgIDs = append(orgIDs, pOrgID)
+	}
+
+	coll := utils.GetCollection(OrganizationCollectionName)
+
+	docs, err := utils.GetMongoDBDocs(r.Context(), OrganizationCollectionName, bson.M{"_id": bson.M{"$in": orgIDs}},
+		options.Find().SetProjection(bson.M{"tags": 1}))
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	current := make(map[string][]string, len(docs))
+
+	for _, doc := range docs {
+		var org Organization
+		if err = utils.BsonToStruct(doc, &org); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		current[org.ID] = org.Tags
+	}
+
+	changed := make([]primitive.ObjectID, 0, len(orgIDs))
+
+	for _, pOrgID := range orgIDs {
+		id := requested[pOrgID.Hex()]
+
+		tags, ok := current[pOrgID.Hex()]
+		if !ok {
+			result.Fail(id, "organization not found", nil)
+			continue
+		}
+
+		added, removed := tagChanges(tags, add, remove)
+		if len(added) == 0 && len(removed) == 0 {
+			result.Skip(id, "organization already has the requested tags", nil)
+			continue
+		}
+
+		changed = append(changed, pOrgID)
+		result.Succeed(id, utils.M{"added": added, "removed": removed})
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": changed}}
+
+	// organizations that were never tagged may have no tags array, which $addToSet and $pull need
+	untagged := bson.M{"_id": bson.M{"$in": changed}, "tags": nil}
+	if _, err = coll.UpdateMany(r.Context(), untagged, bson.M{"$set": bson.M{"tags": []string{}}}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	// mongo cannot add to and pull from the same array in one update, so there is one for each
+	if len(add) > 0 {
+		if _, err = coll.UpdateMany(r.Context(), filter, bson.M{"$addToSet": bson.M{"tags": bson.M{"$each": add}}}); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	if len(remove) > 0 {
+		if _, err = coll.UpdateMany(r.Context(), filter, bson.M{"$pull": bson.M{"tags": bson.M{"$in": remove}}}); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	utils.GetBulkResult("organization tags updated successfully", result, w)
+}
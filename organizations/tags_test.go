@@ -0,0 +1,113 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBulkTagOrganizations(t *testing.T) {
+	orgIDs := make([]string, 3)
+
+	for i := range orgIDs {
+		orgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		orgIDs[i] = orgID
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/tags", orgs.BulkTagOrganizations).Methods("PATCH")
+
+	bulkTag := func(body BulkTagRequest, expectedCode int) map[string]interface{} {
+		requestBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest("PATCH", "/organizations/tags", bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, expectedCode)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	assertResult := func(t *testing.T, data map[string]interface{}, succeeded, failed, skipped int) {
+		t.Helper()
+
+		for section, want := range map[string]int{"succeeded": succeeded, "failed": failed, "skipped": skipped} {
+			if items, _ := data[section].([]interface{}); len(items) != want {
+				t.Errorf("expected %d %s, got %v", want, section, data[section])
+			}
+		}
+	}
+
+	tags := func(orgID string) []string {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return org.Tags
+	}
+
+	t.Run("test tagging several organizations", func(t *testing.T) {
+		data := bulkTag(BulkTagRequest{OrganizationIDs: orgIDs[:2], Add: []string{"Beta", "cohort-1", " beta "}}, http.StatusOK)
+		assertResult(t, data, 2, 0, 0)
+
+		for _, orgID := range orgIDs[:2] {
+			if got := tags(orgID); len(got) != 2 || got[0] != "beta" || got[1] != "cohort-1" {
+				t.Errorf("expected org %s to be tagged beta and cohort-1, got %v", orgID, got)
+			}
+		}
+
+		if got := tags(orgIDs[2]); len(got) != 0 {
+			t.Errorf("expected org %s to stay untagged, got %v", orgIDs[2], got)
+		}
+	})
+
+	t.Run("test only organizations without a tag are modified", func(t *testing.T) {
+		data := bulkTag(BulkTagRequest{OrganizationIDs: orgIDs, Add: []string{"beta"}}, http.StatusOK)
+		assertResult(t, data, 1, 0, 2)
+
+		if item := data["succeeded"].([]interface{})[0].(map[string]interface{}); item["item"] != orgIDs[2] {
+			t.Errorf("expected org %s to be tagged, got %v", orgIDs[2], item)
+		}
+	})
+
+	t.Run("test removing tags", func(t *testing.T) {
+		data := bulkTag(BulkTagRequest{OrganizationIDs: orgIDs, Remove: []string{"cohort-1"}}, http.StatusOK)
+		assertResult(t, data, 2, 0, 1)
+
+		if got := tags(orgIDs[0]); len(got) != 1 || got[0] != "beta" {
+			t.Errorf("expected org %s to keep only beta, got %v", orgIDs[0], got)
+		}
+	})
+
+	t.Run("test organizations that cannot be tagged are reported", func(t *testing.T) {
+		ids := []string{orgIDs[0], "12345", "61695d8bb2cc8a9af4833d46", orgIDs[0]}
+
+		data := bulkTag(BulkTagRequest{OrganizationIDs: ids, Add: []string{"gamma"}}, http.StatusMultiStatus)
+		assertResult(t, data, 1, 2, 1)
+
+		for _, item := range data["failed"].([]interface{}) {
+			if item.(map[string]interface{})["reason"] == "" {
+				t.Errorf("expected a reason for %v", item)
+			}
+		}
+	})
+
+	t.Run("test invalid requests are rejected", func(t *testing.T) {
+		bulkTag(BulkTagRequest{Add: []string{"beta"}}, http.StatusBadRequest)
+		bulkTag(BulkTagRequest{OrganizationIDs: orgIDs, Add: []string{"  "}}, http.StatusBadRequest)
+		bulkTag(BulkTagRequest{OrganizationIDs: orgIDs, Add: []string{"beta"}, Remove: []string{"Beta"}}, http.StatusBadRequest)
+	})
+}
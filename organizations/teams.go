@@ -0,0 +1,324 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+const maxTeamNameLength = 80
+
+var errTeamNameTaken = errors.New("a team with this name already exists in the organization")
+
+// teamFilter matches a team of an organization by its id.
+func teamFilter(orgID, teamID string) (bson.M, error) {
+	pTeamID, err := primitive.ObjectIDFromHex(teamID)
+	if err != nil {
+		return nil, errors.New("invalid team id")
+	}
+
+	return bson.M{"_id": pTeamID, "org_id": orgID}, nil
+}
+
+// activeMemberIDs returns which of the given ids are of active members of the organization.
+func activeMemberIDs(ctx context.Context, orgID string, memberIDs []string) (map[string]bool, error) {
+	ids := make([]primitive.ObjectID, 0, len(memberIDs))
+
+	for _, id := range memberIDs {
+		if pID, err := primitive.ObjectIDFromHex(id); err == nil {
+			ids = append(ids, pID)
+		}
+	}
+
+	docs, err := utils.GetMongoDBDocs(ctx, MemberCollectionName, bson.M{
+		"_id":     bson.M{"$in": ids},
+		"org_id":  orgID,
+		"deleted": bson.M{"$ne": true},
+	}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		if id, ok := doc["_id"].(primitive.ObjectID); ok {
+			active[id.Hex()] = true
+		}
+	}
+
+	return active, nil
+}
+
+// Create a team in an organization. Team names are unique within the organization, ignoring case.
+func (oh *OrganizationHandler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		Name string `json:"name"`
+	}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	name := strings.TrimSpace(body.Name)
+
+	if name == "" {
+		utils.GetError(errors.New("team name is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	if len([]rune(name)) > maxTeamNameLength {
+		utils.GetError(fmt.Errorf("team name cannot be longer than %d characters", maxTeamNameLength), http.StatusBadRequest, w)
+		return
+	}
+
+	var createdBy string
+	if loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser); ok {
+		createdBy = loggedInUser.Email
+	}
+
+	team := Team{
+		OrgID:     orgID,
+		Name:      name,
+		NameKey:   strings.ToLower(name),
+		MemberIDs: []string{},
+		CreatedBy: createdBy,
+		CreatedAt: utils.NowUTC(),
+	}
+
+	res, err := utils.GetCollection(TeamCollectionName).InsertOne(r.Context(), team)
+	if utils.IsDuplicateKeyError(err) {
+		utils.GetError(errTeamNameTaken, http.StatusConflict, w)
+		return
+	}
+
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	team.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+	utils.GetCreated("team created successfully", fmt.Sprintf("/organizations/%s/teams/%s", orgID, team.ID), team, w)
+}
+
+// List the teams of an organization by name, with how many active members each has.
+func (oh *OrganizationHandler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	docs, err := utils.GetMongoDBDocs(r.Context(), TeamCollectionName, bson.M{"org_id": orgID}, options.Find().SetSort(bson.D{{Key: "name_key", Value: 1}}))
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	teams := make([]Team, 0, len(docs))
+	allMemberIDs := []string{}
+
+	for _, doc := range docs {
+		var team Team
+		if err := utils.BsonToStruct(doc, &team); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		teams = append(teams, team)
+		allMemberIDs = append(allMemberIDs, team.MemberIDs...)
+	}
+
+	// members removed from the organization stay in their teams in case they come back
+	active, err := activeMemberIDs(r.Context(), orgID, allMemberIDs)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	listed := make([]utils.M, 0, len(teams))
+
+	for i := range teams {
+		listed = append(listed, listedTeam(&teams[i], active))
+	}
+
+	utils.GetSuccess("teams retrieved successfully", listed, w)
+}
+
+// listedTeam is a team as it is listed, with only its members who are still active.
+func listedTeam(team *Team, active map[string]bool) utils.M {
+	memberIDs := []string{}
+
+	for _, id := range team.MemberIDs {
+		if active[id] {
+			memberIDs = append(memberIDs, id)
+		}
+	}
+
+	return utils.M{
+		"_id":          team.ID,
+		"name":         team.Name,
+		"member_ids":   memberIDs,
+		"member_count": len(memberIDs),
+		"created_by":   team.CreatedBy,
+		"created_at":   team.CreatedAt,
+	}
+}
+
+// Get a team of an organization.
+func (oh *OrganizationHandler) GetTeam(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, teamID := mux.Vars(r)["id"], mux.Vars(r)["team_id"]
+
+	filter, err := teamFilter(orgID, teamID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var team Team
+	if err = utils.GetCollection(TeamCollectionName).FindOne(r.Context(), filter).Decode(&team); err != nil {
+		utils.GetError(fmt.Errorf("team %s not found", teamID), http.StatusNotFound, w)
+		return
+	}
+
+	active, err := activeMemberIDs(r.Context(), orgID, team.MemberIDs)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("team retrieved successfully", listedTeam(&team, active), w)
+}
+
+// Delete a team. Its members stay in the organization.
+func (oh *OrganizationHandler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, teamID := mux.Vars(r)["id"], mux.Vars(r)["team_id"]
+
+	filter, err := teamFilter(orgID, teamID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	res, err := utils.GetCollection(TeamCollectionName).DeleteOne(r.Context(), filter)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.DeletedCount == 0 {
+		utils.GetError(fmt.Errorf("team %s not found", teamID), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("team deleted successfully", nil, w)
+}
+
+// Add members of the organization to a team. Members already in the team are left as they are.
+func (oh *OrganizationHandler) AddTeamMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID, teamID := mux.Vars(r)["id"], mux.Vars(r)["team_id"]
+
+	filter, err := teamFilter(orgID, teamID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		MemberIDs []string `json:"member_ids"`
+	}
+
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if len(body.MemberIDs) == 0 {
+		utils.GetError(errors.New("member_ids is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	active, err := activeMemberIDs(r.Context(), orgID, body.MemberIDs)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	for _, id := range body.MemberIDs {
+		if !active[id] {
+			utils.GetError(fmt.Errorf("member %s is not in this organization", id), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	res, err := utils.GetCollection(TeamCollectionName).UpdateOne(r.Context(), filter,
+		bson.M{"$addToSet": bson.M{"member_ids": bson.M{"$each": body.MemberIDs}}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(fmt.Errorf("team %s not found", teamID), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("team members added successfully", nil, w)
+}
+
+// Remove a member from a team. They stay in the organization and their other teams.
+func (oh *OrganizationHandler) RemoveTeamMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, teamID, memID := vars["id"], vars["team_id"], vars["mem_id"]
+
+	filter, err := teamFilter(orgID, teamID)
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	filter["member_ids"] = memID
+
+	res, err := utils.GetCollection(TeamCollectionName).UpdateOne(r.Context(), filter, bson.M{"$pull": bson.M{"member_ids": memID}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if res.MatchedCount == 0 {
+		utils.GetError(fmt.Errorf("member %s is not in team %s", memID, teamID), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("team member removed successfully", nil, w)
+}
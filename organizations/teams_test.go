@@ -0,0 +1,182 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+func TestTeams(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstID, err := setUpMember(orgID, "teamfirst@gmail.com", MemberRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secondID, err := setUpMember(orgID, "teamsecond@gmail.com", MemberRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewOrganizationHandler(configs, newMockMailService())
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/teams", handler.CreateTeam).Methods("POST")
+	r.HandleFunc("/organizations/{id}/teams", handler.ListTeams).Methods("GET")
+	r.HandleFunc("/organizations/{id}/teams/{team_id}", handler.GetTeam).Methods("GET")
+	r.HandleFunc("/organizations/{id}/teams/{team_id}", handler.DeleteTeam).Methods("DELETE")
+	r.HandleFunc("/organizations/{id}/teams/{team_id}/members", handler.AddTeamMembers).Methods("POST")
+	r.HandleFunc("/organizations/{id}/teams/{team_id}/members/{mem_id}", handler.RemoveTeamMember).Methods("DELETE")
+	r.HandleFunc("/organizations/{id}/members/{mem_id}", handler.DeactivateMember).Methods("DELETE")
+
+	createTeam := func(name string, expectedCode int) string {
+		requestBody := []byte(fmt.Sprintf(`{"name": %q}`, name))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/teams", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, withUser(req, defaultUser))
+		assertStatusCode(t, response.Code, expectedCode)
+
+		if expectedCode != http.StatusCreated {
+			return ""
+		}
+
+		teamID := parseResponse(response)["data"].(map[string]interface{})["_id"].(string)
+
+		if location := response.Header().Get("Location"); location != fmt.Sprintf("/organizations/%s/teams/%s", orgID, teamID) {
+			t.Errorf("expected the location of team %s, got %q", teamID, location)
+		}
+
+		return teamID
+	}
+
+	addMembers := func(teamID string, expectedCode int, memberIDs ...string) {
+		var ids bytes.Buffer
+		for i, id := range memberIDs {
+			if i > 0 {
+				ids.WriteString(",")
+			}
+
+			fmt.Fprintf(&ids, "%q", id)
+		}
+
+		requestBody := []byte(fmt.Sprintf(`{"member_ids": [%s]}`, ids.String()))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/teams/%s/members", orgID, teamID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, expectedCode)
+	}
+
+	// memberCounts returns the member count of each team of the organization by name.
+	memberCounts := func() map[string]float64 {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/teams", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		counts := map[string]float64{}
+		for _, team := range parseResponse(response)["data"].([]interface{}) {
+			team := team.(map[string]interface{})
+			counts[team["name"].(string)] = team["member_count"].(float64)
+		}
+
+		return counts
+	}
+
+	designID := createTeam("Design", http.StatusCreated)
+	backendID := createTeam("Backend", http.StatusCreated)
+
+	t.Run("test created teams can be fetched at their location", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/teams/%s", orgID, designID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if name := parseResponse(response)["data"].(map[string]interface{})["name"]; name != "Design" {
+			t.Errorf("expected team Design, got %v", name)
+		}
+	})
+
+	t.Run("test team names are unique within the organization", func(t *testing.T) {
+		createTeam("design ", http.StatusConflict)
+		createTeam("  ", http.StatusBadRequest)
+	})
+
+	t.Run("test a member can be in several teams", func(t *testing.T) {
+		addMembers(designID, http.StatusOK, firstID, secondID)
+		addMembers(backendID, http.StatusOK, firstID)
+
+		// adding a member twice leaves them in the team once
+		addMembers(backendID, http.StatusOK, firstID)
+
+		counts := memberCounts()
+		if counts["Design"] != 2 || counts["Backend"] != 1 {
+			t.Errorf("expected Design to have 2 members and Backend 1, got %v", counts)
+		}
+	})
+
+	t.Run("test only members of the organization can join a team", func(t *testing.T) {
+		otherOrgID, err := setUpOrganization()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		outsiderID, err := setUpMember(otherOrgID, "teamoutsider@gmail.com", MemberRole)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addMembers(backendID, http.StatusBadRequest, outsiderID)
+	})
+
+	t.Run("test removing a member from a team keeps their other teams", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s/teams/%s/members/%s", orgID, backendID, firstID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		counts := memberCounts()
+		if counts["Design"] != 2 || counts["Backend"] != 0 {
+			t.Errorf("expected Design to have 2 members and Backend none, got %v", counts)
+		}
+	})
+
+	t.Run("test deleting a team keeps its members in the organization", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s/teams/%s", orgID, designID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if _, ok := memberCounts()["Design"]; ok {
+			t.Error("expected the deleted team not to be listed")
+		}
+
+		for _, email := range []string{"teamfirst@gmail.com", "teamsecond@gmail.com"} {
+			if _, err := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": email, "deleted": bson.M{"$ne": true}}); err != nil {
+				t.Errorf("expected %s to still be in the organization: %v", email, err)
+			}
+		}
+	})
+
+	t.Run("test removing a member from the organization keeps the team", func(t *testing.T) {
+		addMembers(backendID, http.StatusOK, firstID, secondID)
+
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/organizations/%s/members/%s", orgID, secondID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		counts := memberCounts()
+		if count, ok := counts["Backend"]; !ok || count != 1 {
+			t.Errorf("expected Backend to stay with 1 active member, got %v", counts)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// memberSorts maps the sort query parameter of GetMembers to the order it applies.
+var memberSorts = map[string]bson.D{
+	"tenure":  {{Key: "joined_at", Value: 1}, {Key: "_id", Value: 1}},
+	"-tenure": {{Key: "joined_at", Value: -1}, {Key: "_id", Value: -1}},
+}
+
+// memberSortOptions returns the find options for a members sort, or an error for an unknown sort.
+func memberSortOptions(sort string) (*options.FindOptions, error) {
+	if sort == "" {
+		return options.Find(), nil
+	}
+
+	order, ok := memberSorts[sort]
+	if !ok {
+		return nil, errors.New("invalid sort, use tenure or -tenure")
+	}
+
+	return options.Find().SetSort(order), nil
+}
+
+// missingJoinDate matches members without a usable join date. Members once created through
+// guest invites stored joined_at as a string, which does not sort with real dates.
+var missingJoinDate = bson.M{"$or": []bson.M{
+	{"joined_at": nil},
+	{"joined_at": time.Time{}},
+	{"joined_at": bson.M{"$type": "string"}},
+}}
+
+// BackfillJoinDates sets joined_at on members that have none. The date is taken from the
+// earliest audit entry about the member, from the organization's creation for its owners,
+// and otherwise from when the member document was created. It returns how many were updated.
+func BackfillJoinDates() (int, error) {
+	memberDocs, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, missingJoinDate)
+	if err != nil {
+		return 0, err
+	}
+
+	orgs := make(map[string]*Organization)
+	updated := 0
+
+	for _, doc := range memberDocs {
+		memberID, ok := doc["_id"].(primitive.ObjectID)
+		if !ok {
+			continue
+		}
+
+		orgID, _ := doc["org_id"].(string)
+
+		org, seen := orgs[orgID]
+		if !seen {
+			org, _ = fetchOrganizationWithOwners(context.TODO(), orgID)
+			orgs[orgID] = org
+		}
+
+		joinedAt := inferJoinDate(doc, memberID, org)
+
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, memberID.Hex(), bson.M{"joined_at": joinedAt}); err != nil {
+			return updated, err
+		}
+
+		updated++
+	}
+
+	return updated, nil
+}
+
+func inferJoinDate(doc bson.M, memberID primitive.ObjectID, org *Organization) time.Time {
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	entryDoc, _ := utils.GetMongoDBDoc(context.TODO(), audit.AuditLogCollectionName, bson.M{"target_id": memberID.Hex()}, opts)
+	if entryDoc != nil {
+		var entry audit.Log
+		if err := utils.BsonToStruct(entryDoc, &entry); err == nil && !entry.CreatedAt.IsZero() {
+			return entry.CreatedAt
+		}
+	}
+
+	if org != nil && (doc["role"] == OwnerRole || isOwner(org, memberID.Hex())) && !org.CreatedAt.IsZero() {
+		return org.CreatedAt
+	}
+
+	if s, ok := doc["joined_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil && !t.IsZero() {
+			return t
+		}
+	}
+
+	return memberID.Timestamp()
+}
+
+// MigrateJoinDates runs the join date backfill and logs the outcome. It is run at startup.
+func MigrateJoinDates() {
+	n, err := BackfillJoinDates()
+	if err != nil {
+		logger.Error("could not backfill member join dates: %v", err)
+		return
+	}
+
+	if n > 0 {
+		logger.Info("backfilled join dates of %d members", n)
+	}
+}
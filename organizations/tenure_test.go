@@ -0,0 +1,178 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/utils"
+)
+
+func TestGuestJoinDate(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := "tenureguest@gmail.com"
+	inviteUUID := uuid.New().String()
+
+	if _, err = utils.CreateMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"email": email}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = utils.CreateMongoDBDoc(context.TODO(), OrganizationInviteCollectionName, bson.M{"uuid": inviteUUID, "email": email, "org_id": orgID}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/guests/{uuid}", orgs.GuestToOrganization).Methods("POST")
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/guests/%s", inviteUUID), nil)
+
+	response := getHTTPResponse(t, r, req)
+	assertStatusCode(t, response.Code, http.StatusOK)
+
+	memberDoc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"org_id": orgID, "email": email})
+	if memberDoc == nil {
+		t.Fatal("expected the guest to be a member")
+	}
+
+	if _, ok := memberDoc["joined_at"].(primitive.DateTime); !ok {
+		t.Errorf("expected joined_at to be stored as a date, got %T", memberDoc["joined_at"])
+	}
+}
+
+func TestBackfillJoinDates(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orgCreated := time.Date(2021, time.June, 1, 9, 0, 0, 0, time.UTC)
+
+	if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), OrganizationCollectionName, orgID, bson.M{"created_at": orgCreated, "owners": []string{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	insertMember := func(doc bson.M) string {
+		doc["org_id"] = orgID
+
+		res, err := utils.GetCollection(MemberCollectionName).InsertOne(context.TODO(), doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res.InsertedID.(primitive.ObjectID).Hex()
+	}
+
+	owner := insertMember(bson.M{"email": "backfillowner@gmail.com", "role": OwnerRole})
+	audited := insertMember(bson.M{"email": "backfillaudited@gmail.com", "role": MemberRole})
+	stringDate := insertMember(bson.M{"email": "backfillstring@gmail.com", "role": MemberRole, "joined_at": "2021-07-01T10:00:00Z"})
+
+	auditedAt := time.Date(2021, time.June, 15, 12, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{auditedAt.Add(time.Hour), auditedAt} {
+		if err = audit.Record(&audit.Log{OrgID: orgID, Action: "member.role_updated", TargetType: "member", TargetID: audited, CreatedAt: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = BackfillJoinDates(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]time.Time{
+		owner:      orgCreated,
+		audited:    auditedAt,
+		stringDate: time.Date(2021, time.July, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	for memberID, want := range expected {
+		pMemID, _ := primitive.ObjectIDFromHex(memberID)
+		memberDoc, _ := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"_id": pMemID})
+
+		got, ok := memberDoc["joined_at"].(primitive.DateTime)
+		if !ok || !got.Time().Equal(want) {
+			t.Errorf("member %s: expected joined_at %v, got %v", memberID, want, memberDoc["joined_at"])
+		}
+	}
+
+	n, err := BackfillJoinDates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("expected a second backfill to update nothing, updated %d", n)
+	}
+}
+
+func TestGetMembersByTenure(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emails := []string{"tenuresecond@gmail.com", "tenurefirst@gmail.com", "tenurethird@gmail.com"}
+	joined := []time.Time{
+		time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	for i, email := range emails {
+		member := NewMember(email, email, orgID, MemberRole)
+		member.JoinedAt = joined[i]
+
+		if _, err = utils.GetCollection(MemberCollectionName).InsertOne(context.TODO(), member); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members", orgs.GetMembers).Methods("GET")
+
+	tests := []struct {
+		name       string
+		sort       string
+		statusCode int
+		order      []string
+	}{
+		{"test longest tenure first", "tenure", http.StatusOK, []string{"tenurefirst@gmail.com", "tenuresecond@gmail.com", "tenurethird@gmail.com"}},
+		{"test newest members first", "-tenure", http.StatusOK, []string{"tenurethird@gmail.com", "tenuresecond@gmail.com", "tenurefirst@gmail.com"}},
+		{"test invalid sort", "name", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members?sort=%s", orgID, tc.sort), nil)
+
+			response := getHTTPResponse(t, r, req)
+			assertStatusCode(t, response.Code, tc.statusCode)
+
+			if tc.order == nil {
+				return
+			}
+
+			members, _ := parseResponse(response)["data"].([]interface{})
+
+			var got []string
+			for _, m := range members {
+				if email := m.(map[string]interface{})["email"].(string); strings.HasPrefix(email, "tenure") {
+					got = append(got, email)
+				}
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tc.order) {
+				t.Errorf("expected order %v, got %v", tc.order, got)
+			}
+		})
+	}
+}
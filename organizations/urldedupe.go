@@ -0,0 +1,251 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"zuri.chat/zccore/audit"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// WorkspaceURLConflict is a workspace url shared by several organizations, which keeps it from
+// being made unique. The organization that keeps the url is the oldest one in use, and the others
+// are to be given new urls.
+type WorkspaceURLConflict struct {
+	WorkspaceURL string                 `json:"workspace_url"`
+	KeptBy       string                 `json:"kept_by"`
+	Renamed      []WorkspaceURLRenaming `json:"renamed"`
+}
+
+// WorkspaceURLRenaming is an organization that loses a shared workspace url. NewURL is only set
+// once it has been given a new one.
+type WorkspaceURLRenaming struct {
+	OrganizationID string `json:"organization_id"`
+	Name           string `json:"name"`
+	Deleted        bool   `json:"deleted,omitempty"`
+	Pending        bool   `json:"pending,omitempty"`
+	NewURL         string `json:"new_url,omitempty"`
+}
+
+// inUse reports whether customers can reach the organization at its url.
+func (rn *WorkspaceURLRenaming) inUse() bool {
+	return !rn.Deleted && !rn.Pending
+}
+
+// workspaceURLConflicts finds the workspace urls organizations in coll share. Organizations in
+// use keep a url before deleted and pending ones, and older ones before newer ones.
+// Organizations without a url share the empty one.
+func workspaceURLConflicts(ctx context.Context, coll *mongo.Collection) ([]WorkspaceURLConflict, error) {
+	cursor, err := coll.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$addFields", Value: bson.M{
+			"deleted": bson.M{"$eq": bson.A{"$deleted", true}},
+			"pending": bson.M{"$eq": bson.A{"$pending", true}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{"in_use": bson.M{"$not": bson.A{bson.M{"$or": bson.A{"$deleted", "$pending"}}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "in_use", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$workspace_url",
+			"orgs":  bson.M{"$push": bson.M{"_id": "$_id", "name": "$name", "deleted": "$deleted", "pending": "$pending"}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var shared []struct {
+		URL  interface{} `bson:"_id"`
+		Orgs []struct {
+			ID      primitive.ObjectID `bson:"_id"`
+			Name    string             `bson:"name"`
+			Deleted bool               `bson:"deleted"`
+			Pending bool               `bson:"pending"`
+		} `bson:"orgs"`
+	}
+
+	if err = cursor.All(ctx, &shared); err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]WorkspaceURLConflict, 0, len(shared))
+
+	for _, group := range shared {
+		url, _ := group.URL.(string)
+		conflict := WorkspaceURLConflict{WorkspaceURL: url, KeptBy: group.Orgs[0].ID.Hex()}
+
+		for _, org := range group.Orgs[1:] {
+			conflict.Renamed = append(conflict.Renamed, WorkspaceURLRenaming{
+				OrganizationID: org.ID.Hex(),
+				Name:           org.Name,
+				Deleted:        org.Deleted,
+				Pending:        org.Pending,
+			})
+		}
+
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, nil
+}
+
+// dedupeWorkspaceURLs gives every organization in coll that loses a shared workspace url a new
+// one, so the url can be made unique, and returns the conflicts it resolved with the new urls.
+func dedupeWorkspaceURLs(ctx context.Context, coll *mongo.Collection) ([]WorkspaceURLConflict, error) {
+	conflicts, err := workspaceURLConflicts(ctx, coll)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range conflicts {
+		for j := range conflicts[i].Renamed {
+			renamed := &conflicts[i].Renamed[j]
+			pOrgID, _ := primitive.ObjectIDFromHex(renamed.OrganizationID)
+			url := utils.GenWorkspaceURL(renamed.Name)
+
+			if _, err := coll.UpdateOne(ctx, bson.M{"_id": pOrgID}, bson.M{"$set": bson.M{"workspace_url": url}}); err != nil {
+				return conflicts, err
+			}
+
+			renamed.NewURL = url
+		}
+	}
+
+	return conflicts, nil
+}
+
+// EnsureWorkspaceURLIndex makes workspace urls unique, and logs when it cannot because
+// organizations share one. It is run at startup and never changes an organization's url, those
+// are given new ones by ResolveWorkspaceURLConflicts.
+func EnsureWorkspaceURLIndex() {
+	if err := utils.CreateUniqueIndex(OrganizationCollectionName, "workspace_url", 1); err != nil {
+		logger.Error("could not make workspace urls unique, see GET /organizations/workspace-url-conflicts: %v", err)
+	}
+}
+
+// List the workspace urls organizations share, and which of them would get new urls when the
+// conflicts are resolved. Nothing is changed.
+func (oh *OrganizationHandler) GetWorkspaceURLConflicts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	conflicts, err := workspaceURLConflicts(r.Context(), utils.GetCollection(OrganizationCollectionName))
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("workspace url conflicts retrieved successfully", conflicts, w)
+}
+
+// Resolve the workspace urls organizations share by giving the organizations that lose them new
+// urls, then make workspace urls unique. The owners of organizations in use are told their new
+// url, and every change is recorded in the audit log.
+func (oh *OrganizationHandler) ResolveWorkspaceURLConflicts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	conflicts, err := dedupeWorkspaceURLs(r.Context(), utils.GetCollection(OrganizationCollectionName))
+
+	loggedInUser, _ := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+
+	// urls already changed are recorded and announced even if a later one failed
+	for _, conflict := range conflicts {
+		for _, renamed := range conflict.Renamed {
+			if renamed.NewURL == "" {
+				continue
+			}
+
+			oh.recordWorkspaceURLChange(loggedInUser, conflict.WorkspaceURL, &renamed)
+
+			if renamed.inUse() {
+				go oh.sendWorkspaceURLChangeMail(renamed)
+			}
+		}
+	}
+
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if err = utils.CreateUniqueIndex(OrganizationCollectionName, "workspace_url", 1); err != nil {
+		utils.GetError(fmt.Errorf("could not make workspace urls unique: %w", err), http.StatusConflict, w)
+		return
+	}
+
+	utils.GetSuccess("workspace url conflicts resolved successfully", conflicts, w)
+}
+
+// recordWorkspaceURLChange records in the audit log that an organization was given a new url.
+func (oh *OrganizationHandler) recordWorkspaceURLChange(actor *auth.AuthUser, oldURL string, renamed *WorkspaceURLRenaming) {
+	entry := &audit.Log{
+		OrgID:      renamed.OrganizationID,
+		Action:     audit.OrganizationUpdated,
+		TargetType: "organization",
+		TargetID:   renamed.OrganizationID,
+		Data:       map[string]interface{}{"workspace_url": utils.M{"from": oldURL, "to": renamed.NewURL}},
+		CreatedAt:  utils.NowUTC(),
+	}
+
+	if actor != nil {
+		entry.Actor, entry.ImpersonatedBy = actor.Email, actor.ImpersonatedBy
+	}
+
+	if err := audit.Record(entry); err != nil {
+		logger.Error("could not record the new workspace url of organization %s: %v", renamed.OrganizationID, err)
+	}
+}
+
+// sendWorkspaceURLChangeMail tells the owners of an organization that it has a new url.
+func (oh *OrganizationHandler) sendWorkspaceURLChangeMail(renamed WorkspaceURLRenaming) {
+	pOrgID, _ := primitive.ObjectIDFromHex(renamed.OrganizationID)
+
+	org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+	if err != nil {
+		logger.Error("could not fetch organization %s to announce its new url: %v", renamed.OrganizationID, err)
+		return
+	}
+
+	owners, err := organizationOwners(context.TODO(), org)
+	if err != nil {
+		logger.Error("could not fetch the owners of organization %s to announce its new url: %v", org.ID, err)
+		return
+	}
+
+	ownerIDs := make([]primitive.ObjectID, 0, len(owners))
+
+	for _, id := range owners {
+		if pMemberID, err := primitive.ObjectIDFromHex(id); err == nil {
+			ownerIDs = append(ownerIDs, pMemberID)
+		}
+	}
+
+	docs, err := utils.GetMongoDBDocs(context.TODO(), MemberCollectionName, bson.M{"_id": bson.M{"$in": ownerIDs}, "deleted": bson.M{"$ne": true}})
+	if err != nil {
+		logger.Error("could not fetch the owners of organization %s to announce its new url: %v", org.ID, err)
+		return
+	}
+
+	subject := fmt.Sprintf("%s has a new workspace url", org.Name)
+	body := fmt.Sprintf("<p>%s shared its workspace url with an older workspace, so it can now be reached at %s.</p>",
+		html.EscapeString(org.Name), html.EscapeString(renamed.NewURL))
+
+	for _, doc := range docs {
+		email, _ := doc["email"].(string)
+		if email == "" {
+			continue
+		}
+
+		msger := org.sentFor(oh.mailService.NewCustomMail([]string{email}, subject, body))
+		if err := oh.mailService.SendMail(msger); err != nil {
+			logger.Error("Error occurred while sending mail: %s", err.Error())
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/utils"
+)
+
+func TestDedupeWorkspaceURLs(t *testing.T) {
+	coll := utils.GetCollection("workspace_url_dedupe_test")
+	defer coll.Drop(context.TODO())
+
+	url := "shared-" + utils.GenUUID() + ".zurichat.com"
+
+	orgs := []bson.M{
+		{"name": "Deleted", "deleted": true},
+		{"name": "Pending", "pending": true},
+		{"name": "First"},
+		{"name": "Second"},
+	}
+
+	var ids []primitive.ObjectID
+
+	for _, org := range orgs {
+		id := primitive.NewObjectID()
+		org["_id"], org["workspace_url"] = id, url
+
+		if _, err := coll.InsertOne(context.TODO(), org); err != nil {
+			t.Fatal(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	urlOf := func(t *testing.T, id primitive.ObjectID) interface{} {
+		t.Helper()
+
+		var org bson.M
+		if err := coll.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&org); err != nil {
+			t.Fatal(err)
+		}
+
+		return org["workspace_url"]
+	}
+
+	conflicts, err := workspaceURLConflicts(context.TODO(), coll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conflicts) != 1 || conflicts[0].KeptBy != ids[2].Hex() || len(conflicts[0].Renamed) != 3 {
+		t.Fatalf("expected the oldest organization in use to keep the url from the 3 others, got %+v", conflicts)
+	}
+
+	for _, id := range ids {
+		if got := urlOf(t, id); got != url {
+			t.Errorf("expected listing conflicts to leave %s at %s, got %v", id.Hex(), url, got)
+		}
+	}
+
+	conflicts, err = dedupeWorkspaceURLs(context.TODO(), coll)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict to be resolved, got %+v", conflicts)
+	}
+
+	for _, renamed := range conflicts[0].Renamed {
+		if renamed.NewURL == "" || renamed.NewURL == url {
+			t.Errorf("expected %s to get a new url, got %q", renamed.OrganizationID, renamed.NewURL)
+		}
+	}
+
+	if got := urlOf(t, ids[2]); got != url {
+		t.Errorf("expected the oldest organization in use to keep %s, got %v", url, got)
+	}
+
+	// every url is unique now, so the index can be made
+	if _, err = coll.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys:    bson.M{"workspace_url": 1},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		t.Errorf("expected the workspace urls to be unique, got %v", err)
+	}
+}
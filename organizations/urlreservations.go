@@ -0,0 +1,154 @@
+package organizations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+var (
+	errWorkspaceURLTaken    = errors.New("workspace url is already taken")
+	errWorkspaceURLReserved = errors.New("workspace url is reserved by someone else")
+
+	// dot separated labels of lower-case letters, digits and inner hyphens, as in hostnames
+	workspaceURLPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+)
+
+// normalizeWorkspaceURL lower-cases a requested workspace url and checks it could be a hostname.
+func normalizeWorkspaceURL(url string) (string, error) {
+	url = strings.ToLower(strings.TrimSpace(url))
+
+	if len(url) > 253 || !workspaceURLPattern.MatchString(url) {
+		return "", errors.New("workspace url may only have letters, digits, hyphens and dots")
+	}
+
+	return url, nil
+}
+
+// workspaceURLAvailable checks no organization has the workspace url, and that it is not
+// reserved by anyone but the given user.
+func workspaceURLAvailable(ctx context.Context, url, email string, now time.Time) error {
+	if utils.CountCollection(ctx, OrganizationCollectionName, bson.M{"workspace_url": url}) > 0 {
+		return errWorkspaceURLTaken
+	}
+
+	reserved := bson.M{"_id": url, "reserved_by": bson.M{"$ne": email}, "expires_at": bson.M{"$gt": now}}
+	if utils.CountCollection(ctx, URLReservationCollectionName, reserved) > 0 {
+		return errWorkspaceURLReserved
+	}
+
+	return nil
+}
+
+// reserveWorkspaceURL holds the workspace url for the user until the ttl is up, renewing a
+// reservation they already have. The reservation is keyed by the url, so when another user
+// holds it the upsert fails on the duplicate key rather than taking it over.
+func reserveWorkspaceURL(ctx context.Context, url, email string, now time.Time, ttl time.Duration) (*URLReservation, error) {
+	if err := workspaceURLAvailable(ctx, url, email, now); err != nil {
+		return nil, err
+	}
+
+	reservation := &URLReservation{URL: url, ReservedBy: email, ExpiresAt: now.Add(ttl)}
+
+	// matches a reservation of the user's or one that has expired
+	filter := bson.M{"_id": url, "$or": []bson.M{{"reserved_by": email}, {"expires_at": bson.M{"$lte": now}}}}
+	update := bson.M{"$set": bson.M{"reserved_by": email, "expires_at": reservation.ExpiresAt}}
+
+	_, err := utils.GetCollection(URLReservationCollectionName).UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if utils.IsDuplicateKeyError(err) {
+		return nil, errWorkspaceURLReserved
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// claimWorkspaceURL gives a new organization the workspace url asked for, if it is free or
+// reserved by the user creating it. Unless it is a dry run, the url is held for them while the
+// organization is created, so a create racing this one cannot take it. It returns the status to
+// respond with when the url cannot be had.
+func (oh *OrganizationHandler) claimWorkspaceURL(ctx context.Context, org *Organization, url, email string, dryRun bool) (int, error) {
+	url, err := normalizeWorkspaceURL(url)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	now := utils.NowUTC()
+
+	if dryRun {
+		err = workspaceURLAvailable(ctx, url, email, now)
+	} else {
+		_, err = reserveWorkspaceURL(ctx, url, email, now, oh.configs.URLReservationTTL)
+	}
+
+	switch {
+	case errors.Is(err, errWorkspaceURLTaken), errors.Is(err, errWorkspaceURLReserved):
+		return http.StatusConflict, err
+	case err != nil:
+		return http.StatusInternalServerError, err
+	}
+
+	org.WorkspaceURL = url
+
+	return http.StatusOK, nil
+}
+
+// releaseWorkspaceURL drops the user's reservation of a workspace url once it is in use.
+func releaseWorkspaceURL(ctx context.Context, url, email string) error {
+	_, err := utils.GetCollection(URLReservationCollectionName).DeleteOne(ctx, bson.M{"_id": url, "reserved_by": email})
+	return err
+}
+
+// Check whether a workspace url is free for the logged in user. With ?reserve=true a free url
+// is also held for them for a while, so only they can create an organization with it.
+func (oh *OrganizationHandler) CheckWorkspaceURL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("user not logged in"), http.StatusUnauthorized, w)
+		return
+	}
+
+	url, err := normalizeWorkspaceURL(mux.Vars(r)["url"])
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	email := strings.ToLower(loggedInUser.Email)
+	now := utils.NowUTC()
+	var reservation *URLReservation
+
+	if r.URL.Query().Get("reserve") == "true" {
+		reservation, err = reserveWorkspaceURL(r.Context(), url, email, now, oh.configs.URLReservationTTL)
+	} else {
+		err = workspaceURLAvailable(r.Context(), url, email, now)
+	}
+
+	result := utils.M{"workspace_url": url, "available": true}
+
+	switch {
+	case errors.Is(err, errWorkspaceURLTaken), errors.Is(err, errWorkspaceURLReserved):
+		result["available"], result["reason"] = false, err.Error()
+	case err != nil:
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	case reservation != nil:
+		result["reserved_until"] = reservation.ExpiresAt
+	}
+
+	utils.GetSuccess("workspace url checked", result, w)
+}
@@ -0,0 +1,104 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+func TestWorkspaceURLReservations(t *testing.T) {
+	holder := fmt.Sprintf("holder.%s@gmail.com", utils.GenUUID())
+	sniper := fmt.Sprintf("sniper.%s@gmail.com", utils.GenUUID())
+
+	for _, email := range []string{holder, sniper} {
+		if err := setUpUser(email, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations", orgs.Create).Methods("POST")
+	r.HandleFunc("/organizations/url/{url}/availability", orgs.CheckWorkspaceURL).Methods("GET")
+
+	reserve := func(t *testing.T, url, email string) map[string]interface{} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/url/%s/availability?reserve=true", url), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, email))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		return parseResponse(response)["data"].(map[string]interface{})
+	}
+
+	// create returns the status of creating an organization with the workspace url as the user.
+	create := func(t *testing.T, url, email string) int {
+		body := fmt.Sprintf(`{"creator_email": %q, "workspace_url": %q}`, email, url)
+		req, _ := http.NewRequest("POST", "/organizations", bytes.NewBufferString(body))
+
+		return getHTTPResponse(t, r, withUser(req, email)).Code
+	}
+
+	t.Run("test reserve then create", func(t *testing.T) {
+		url := fmt.Sprintf("popular-%s.zurichat.com", utils.GenUUID())
+
+		if data := reserve(t, url, holder); data["available"] != true || data["reserved_until"] == nil {
+			t.Fatalf("expected the url to be reserved, got %v", data)
+		}
+
+		assertStatusCode(t, create(t, url, holder), http.StatusCreated)
+
+		if utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{"workspace_url": url}) != 1 {
+			t.Error("expected the organization to have the reserved url")
+		}
+
+		if utils.CountCollection(context.TODO(), URLReservationCollectionName, bson.M{"_id": url}) != 0 {
+			t.Error("expected the reservation to be released once used")
+		}
+
+		if data := reserve(t, url, sniper); data["available"] != false {
+			t.Errorf("expected a url in use to be unavailable, got %v", data)
+		}
+	})
+
+	t.Run("test reservation stops another user's create", func(t *testing.T) {
+		url := fmt.Sprintf("contested-%s.zurichat.com", utils.GenUUID())
+
+		reserve(t, url, holder)
+
+		if data := reserve(t, url, sniper); data["available"] != false {
+			t.Errorf("expected the url to be held for its holder, got %v", data)
+		}
+
+		assertStatusCode(t, create(t, url, sniper), http.StatusConflict)
+
+		if utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{"workspace_url": url}) != 0 {
+			t.Error("expected no organization with the reserved url")
+		}
+	})
+
+	t.Run("test expired reservations free the url", func(t *testing.T) {
+		url := fmt.Sprintf("lapsed-%s.zurichat.com", utils.GenUUID())
+
+		reserve(t, url, holder)
+
+		if _, err := utils.GetCollection(URLReservationCollectionName).UpdateOne(context.TODO(),
+			bson.M{"_id": url}, bson.M{"$set": bson.M{"expires_at": utils.NowUTC().Add(-time.Minute)}}); err != nil {
+			t.Fatal(err)
+		}
+
+		assertStatusCode(t, create(t, url, sniper), http.StatusCreated)
+
+		if utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{"workspace_url": url, "creator_email": sniper}) != 1 {
+			t.Error("expected the organization to have the url once the reservation expired")
+		}
+	})
+
+	t.Run("test invalid urls are rejected", func(t *testing.T) {
+		assertStatusCode(t, create(t, "not a url!", holder), http.StatusBadRequest)
+	})
+}
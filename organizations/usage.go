@@ -0,0 +1,91 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"zuri.chat/zccore/utils"
+)
+
+// Metrics metered for usage billing.
+const (
+	UsageStorageBytes = "storage_bytes"
+	UsageFilesStored  = "files_stored"
+)
+
+const usageDateLayout = "2006-01-02"
+
+// parseUsageTime reads a usage range bound given as a date or an RFC 3339 time. A date given
+// as the end of the range includes the whole day.
+func parseUsageTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(usageDateLayout, value); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	return t.UTC(), err
+}
+
+// Get the usage of an organization totalled by metric. The from and to query parameters bound
+// the range, which defaults to the current month so far.
+func (oh *OrganizationHandler) GetUsageSummary(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	now := utils.NowUTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+
+	var err error
+
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = parseUsageTime(v, false); err != nil {
+			utils.GetError(fmt.Errorf("invalid from, use %s or RFC 3339", usageDateLayout), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = parseUsageTime(v, true); err != nil {
+			utils.GetError(fmt.Errorf("invalid to, use %s or RFC 3339", usageDateLayout), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	if !from.Before(to) {
+		utils.GetError(errors.New("from must be before to"), http.StatusBadRequest, w)
+		return
+	}
+
+	// events still waiting to be written belong in the summary
+	if err = utils.FlushUsageEvents(r.Context()); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	summaries, err := utils.SummarizeUsage(r.Context(), orgID, from, to)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("usage summary retrieved successfully", utils.M{
+		"from":    from,
+		"to":      to,
+		"metrics": summaries,
+	}, w)
+}
@@ -0,0 +1,86 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"zuri.chat/zccore/utils"
+)
+
+func TestUsageSummary(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherOrgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	utils.RecordUsageEvent(orgID, UsageStorageBytes, 2048)
+	utils.RecordUsageEvent(orgID, UsageStorageBytes, 1024)
+	utils.RecordUsageEvent(orgID, UsageFilesStored, 2)
+	utils.RecordUsageEvent(otherOrgID, UsageStorageBytes, 4096)
+
+	// an event from last year, outside the default range
+	old := utils.UsageEvent{OrgID: orgID, Metric: UsageStorageBytes, Quantity: 8192, CreatedAt: utils.NowUTC().AddDate(-1, 0, 0)}
+	if _, err = utils.GetCollection(utils.UsageEventCollectionName).InsertOne(context.TODO(), old); err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/usage", orgs.GetUsageSummary).Methods("GET")
+
+	// summary returns the totals of each metric for the query.
+	summary := func(t *testing.T, query string) map[string]float64 {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/usage%s", orgID, query), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		totals := make(map[string]float64)
+
+		metrics := parseResponse(response)["data"].(map[string]interface{})["metrics"].([]interface{})
+		for _, m := range metrics {
+			metric := m.(map[string]interface{})
+			totals[metric["metric"].(string)] = metric["quantity"].(float64)
+		}
+
+		return totals
+	}
+
+	t.Run("test recorded events are summed by metric", func(t *testing.T) {
+		totals := summary(t, "")
+
+		if totals[UsageStorageBytes] != 3072 {
+			t.Errorf("expected %s of 3072, got %v", UsageStorageBytes, totals[UsageStorageBytes])
+		}
+
+		if totals[UsageFilesStored] != 2 {
+			t.Errorf("expected %s of 2, got %v", UsageFilesStored, totals[UsageFilesStored])
+		}
+	})
+
+	t.Run("test the range can reach back", func(t *testing.T) {
+		from := utils.NowUTC().AddDate(-2, 0, 0).Format(usageDateLayout)
+		to := utils.NowUTC().Format(usageDateLayout)
+
+		totals := summary(t, fmt.Sprintf("?from=%s&to=%s", from, to))
+		if totals[UsageStorageBytes] != 3072+8192 {
+			t.Errorf("expected %s of %d, got %v", UsageStorageBytes, 3072+8192, totals[UsageStorageBytes])
+		}
+	})
+
+	t.Run("test invalid ranges are rejected", func(t *testing.T) {
+		for _, query := range []string{"?from=yesterday", fmt.Sprintf("?from=%s&to=2020-01-01", time.Now().Format(usageDateLayout))} {
+			req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/usage%s", orgID, query), nil)
+
+			response := getHTTPResponse(t, r, req)
+			assertStatusCode(t, response.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -1,9 +1,9 @@
 package organizations
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -15,6 +15,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
 	"zuri.chat/zccore/service"
 	"zuri.chat/zccore/utils"
 )
@@ -26,6 +27,11 @@ func (oh *OrganizationHandler) GetMember(w http.ResponseWriter, r *http.Request)
 	orgID := mux.Vars(r)["id"]
 	memID := mux.Vars(r)["mem_id"]
 
+	if oh.hidesOrganization(r, orgID) {
+		utils.GetError(organizationNotFound(orgID), http.StatusNotFound, w)
+		return
+	}
+
 	memberIDhex, err := primitive.ObjectIDFromHex(memID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
@@ -33,13 +39,13 @@ func (oh *OrganizationHandler) GetMember(w http.ResponseWriter, r *http.Request)
 	}
 
 	// check that org_id is valid
-	err = ValidateOrg(orgID)
+	err = ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
-	orgMember, err := utils.GetMongoDBDoc(MemberCollectionName, bson.M{
+	orgMember, err := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{
 		"org_id":  orgID,
 		"_id":     memberIDhex,
 		"deleted": bson.M{"$ne": true},
@@ -58,6 +64,8 @@ func (oh *OrganizationHandler) GetMember(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	viewerOf(r, orgID).redactMember(&member)
+
 	utils.GetSuccess("Member retrieved successfully", member, w)
 }
 
@@ -68,13 +76,18 @@ func (oh *OrganizationHandler) GetmultipleMembers(w http.ResponseWriter, r *http
 	pp := MemberIDS{}
 	orgID := mux.Vars(r)["id"]
 
+	if oh.hidesOrganization(r, orgID) {
+		utils.GetError(organizationNotFound(orgID), http.StatusNotFound, w)
+		return
+	}
+
 	if err := utils.ParseJSONFromRequest(r, &pp); err != nil {
 		utils.GetError(err, http.StatusUnprocessableEntity, w)
 		return
 	}
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -97,7 +110,7 @@ func (oh *OrganizationHandler) GetmultipleMembers(w http.ResponseWriter, r *http
 	wrkchan := make(chan HandleMemberSearchResponse, nw)
 
 	for _, memberID := range pp.IDList {
-		go HandleMemberSearch(orgID, memberID, wrkchan, &wg)
+		go HandleMemberSearch(r.Context(), orgID, memberID, wrkchan, &wg)
 	}
 
 	go func() {
@@ -105,8 +118,11 @@ func (oh *OrganizationHandler) GetmultipleMembers(w http.ResponseWriter, r *http
 		wg.Wait()
 	}()
 
+	viewer := viewerOf(r, orgID)
+
 	for n := range wrkchan {
 		if n.Err == nil {
+			viewer.redactMember(&n.Memberinfo)
 			members = append(members, n.Memberinfo)
 		}
 	}
@@ -120,8 +136,13 @@ func (oh *OrganizationHandler) GetMembers(w http.ResponseWriter, r *http.Request
 
 	orgID := mux.Vars(r)["id"]
 
+	if oh.hidesOrganization(r, orgID) {
+		utils.GetError(organizationNotFound(orgID), http.StatusNotFound, w)
+		return
+	}
+
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -130,6 +151,9 @@ func (oh *OrganizationHandler) GetMembers(w http.ResponseWriter, r *http.Request
 	// query allows you to be able to browse people given the right query param
 	query := r.URL.Query().Get("query")
 
+	// members who hide their email are left out of listings by it, and have it redacted
+	viewer := viewerOf(r, orgID)
+
 	var filter map[string]interface{}
 
 	filter = bson.M{
@@ -140,6 +164,12 @@ func (oh *OrganizationHandler) GetMembers(w http.ResponseWriter, r *http.Request
 	// set filter based on query presence
 	regex := bson.M{"$regex": primitive.Regex{Pattern: query, Options: "i"}}
 
+	// deployments can search ignoring accents, or compare with a collation that ignores case
+	collation := utils.SearchCollation(oh.configs.SearchCollationStrength)
+	if collation != nil {
+		regex = bson.M{"$regex": primitive.Regex{Pattern: utils.SearchPattern(query, oh.configs.SearchCollationStrength), Options: "i"}}
+	}
+
 	if query != "" {
 		filter = bson.M{
 			"org_id":  orgID,
@@ -147,19 +177,45 @@ func (oh *OrganizationHandler) GetMembers(w http.ResponseWriter, r *http.Request
 			"$or": []bson.M{
 				{"first_name": regex},
 				{"last_name": regex},
-				{"email": query},
+				viewer.emailSearchFilter(query),
 				{"display_name": regex},
 			},
 		}
 	}
 
-	orgMembers, err := utils.GetMongoDBDocs(MemberCollectionName, filter)
+	// role=admin&role=owner lists only members with one of the roles
+	if roles := r.URL.Query()["role"]; len(roles) > 0 {
+		roleFilter, err := memberRoleFilter(roles)
+		if err != nil {
+			utils.GetError(err, http.StatusBadRequest, w)
+			return
+		}
+
+		filter["role"] = roleFilter
+	}
+
+	// sort=tenure lists the longest-standing members first, sort=-tenure the newest
+	opts, err := memberSortOptions(r.URL.Query().Get("sort"))
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if collation != nil {
+		opts.SetCollation(collation)
+	}
+
+	orgMembers, err := utils.GetMongoDBDocs(r.Context(), MemberCollectionName, filter, opts)
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
+	for _, member := range orgMembers {
+		viewer.redactMemberDoc(member)
+	}
+
 	utils.GetSuccess("Members retrieved successfully", orgMembers, w)
 }
 
@@ -196,9 +252,9 @@ func (oh *OrganizationHandler) CreateMember(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	userDoc, _ := utils.GetMongoDBDoc(UserCollectionName, bson.M{"email": newUserEmail})
+	userDoc, _ := utils.GetMongoDBDoc(r.Context(), UserCollectionName, bson.M{"email": newUserEmail})
 	if userDoc == nil {
-		fmt.Printf("user with email %s doesn't exist! Register User to Proceed", newUserEmail)
+		logger.Debug("user with email %s doesn't exist, cannot add them as a member", newUserEmail)
 		utils.GetError(errors.New("user with email "+newUserEmail+" doesn't exist! Register User to Proceed"), http.StatusBadRequest, w)
 
 		return
@@ -222,18 +278,18 @@ func (oh *OrganizationHandler) CreateMember(w http.ResponseWriter, r *http.Reque
 	user, _ := auth.FetchUserByEmail(bson.M{"email": strings.ToLower(newUserEmail)})
 
 	// get organization
-	orgDoc, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": orgID})
+	orgDoc, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": orgID})
 	if orgDoc == nil {
-		fmt.Printf("organization with id %s doesn't exist!", orgID.String())
+		logger.Debug("organization with id %s doesn't exist", sOrgID)
 		utils.GetError(errors.New("organization with id "+sOrgID+" doesn't exist!"), http.StatusBadRequest, w)
 
 		return
 	}
 
 	// check that member isn't already in the organization
-	memDoc, _ := utils.GetMongoDBDocs(MemberCollectionName, bson.M{"org_id": sOrgID, "email": newUserEmail})
+	memDoc, _ := utils.GetMongoDBDocs(r.Context(), MemberCollectionName, activeMemberFilter(sOrgID, newUserEmail))
 	if memDoc != nil {
-		fmt.Printf("organization %s has member with email %s!", orgID.String(), newUserEmail)
+		logger.Debug("organization %s already has member with email %s", sOrgID, newUserEmail)
 		utils.GetError(errors.New("user is already in this organization"), http.StatusBadRequest, w)
 
 		return
@@ -248,45 +304,71 @@ func (oh *OrganizationHandler) CreateMember(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	newMember := NewMember(user.Email, newUserName, orgID.Hex(), MemberRole)
+	if err = oh.checkMemberLimit(r.Context(), &org, sOrgID); err != nil {
+		utils.GetError(err, http.StatusForbidden, w)
+		return
+	}
 
-	coll := utils.GetCollection(MemberCollectionName)
+	// the admin adding the member is recorded as having invited them
+	invitedBy := ""
+	if loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser); ok {
+		invitedBy = strings.ToLower(loggedInUser.Email)
+	}
 
-	res, err := coll.InsertOne(r.Context(), newMember)
+	// members removed before are brought back rather than added again
+	memberID, rejoined, err := rejoinRemovedMember(r.Context(), sOrgID, user.Email, MemberRole, invitedBy, time.Time{})
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
+	if !rejoined {
+		newMember := NewMember(user.Email, newUserName, orgID.Hex(), MemberRole)
+		newMember.InvitedBy = invitedBy
+
+		res, err := utils.GetCollection(MemberCollectionName).InsertOne(r.Context(), newMember)
+		if err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		memberID = res.InsertedID.(primitive.ObjectID)
+	}
+
 	// update user organizations collection
-	updateFields := make(map[string]interface{})
+	if !hasOrganization(user.Organizations, sOrgID) {
+		updateFields := make(map[string]interface{})
 
-	user.Organizations = append(user.Organizations, sOrgID)
+		user.Organizations = append(user.Organizations, sOrgID)
 
-	updateFields["Organizations"] = user.Organizations
+		updateFields["Organizations"] = user.Organizations
 
-	_, eerr := utils.UpdateOneMongoDBDoc(UserCollectionName, user.ID, updateFields)
-	if eerr != nil {
-		utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
-		return
+		_, eerr := utils.UpdateOneMongoDBDoc(r.Context(), UserCollectionName, user.ID, updateFields)
+		if eerr != nil {
+			utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
+			return
+		}
 	}
 
 	// publish update to subscriber
 	eventChannel := fmt.Sprintf("organizations_%s", orgID)
-	event := utils.Event{Identifier: res.InsertedID, Type: "User", Event: CreateOrganizationMember, Channel: eventChannel, Payload: make(map[string]interface{})}
+	event := utils.Event{Identifier: memberID, Type: "User", Event: CreateOrganizationMember, Channel: eventChannel, Payload: make(map[string]interface{})}
 
 	go utils.Emitter(event)
+	go DispatchWebhookEvent(sOrgID, CreateOrganizationMember, utils.M{"member_id": memberID})
 
-	utils.GetSuccess("Member created successfully", utils.M{"member_id": res.InsertedID}, w)
+	completeOnboardingStep(sOrgID, OnboardingInvitedMembers)
+
+	utils.GetSuccess("Member created successfully", utils.M{"member_id": memberID, "rejoined": rejoined}, w)
 
 	enterOrgMessage := EnterLeaveMessage{
 		OrganizationID: sOrgID,
-		MemberID:       res.InsertedID.(primitive.ObjectID).Hex(),
+		MemberID:       memberID.Hex(),
 	}
 
 	eee := AddSyncMessage(sOrgID, "enter_organization", enterOrgMessage)
 	if eee != nil {
-		log.Printf("sync error: %v", eee)
+		logger.Error("sync error: %v", eee)
 	}
 }
 
@@ -298,22 +380,28 @@ func (oh *OrganizationHandler) UpdateProfilePicture(w http.ResponseWriter, r *ht
 	memberID := mux.Vars(r)["mem_id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
 	// check that member_id is valid
-	err = ValidateMember(orgID, memberID)
+	err = ValidateMember(r.Context(), orgID, memberID)
 
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
+	pMemID, _ := primitive.ObjectIDFromHex(memberID)
+	memberDoc, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": pMemID})
+	oldImageURL, _ := memberDoc["image_url"].(string)
+
 	if mux.Vars(r)["action"] == "delete" {
-		result, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, bson.M{"image_url": ""})
+		discardStoredFile(orgID, oldImageURL)
+
+		result, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, bson.M{"image_url": ""})
 
 		if err != nil {
 			utils.GetError(err, http.StatusInternalServerError, w)
@@ -327,14 +415,27 @@ func (oh *OrganizationHandler) UpdateProfilePicture(w http.ResponseWriter, r *ht
 
 		utils.GetSuccess("image deleted successfully", "", w)
 	} else {
+		size, ok := reserveUploadStorage(w, r, orgID, "image")
+		if !ok {
+			return
+		}
+
 		uploadPath := "profile_image/" + orgID + "/" + memberID
 		imgURL, err := service.ProfileImageUpload(uploadPath, imageWidth, imageHeight, r)
 		if err != nil {
+			cancelReservation(orgID, size)
 			utils.GetError(err, http.StatusInternalServerError, w)
 			return
 		}
 
-		result, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, bson.M{"image_url": imgURL})
+		if err = recordStoredFile(orgID, imgURL, size); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		discardStoredFile(orgID, oldImageURL)
+
+		result, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, bson.M{"image_url": imgURL})
 
 		if err != nil {
 			utils.GetError(err, http.StatusInternalServerError, w)
@@ -359,28 +460,42 @@ func (oh *OrganizationHandler) UploadFile(w http.ResponseWriter, r *http.Request
 	memberID := mux.Vars(r)["mem_id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
 	// check that member_id is valid
-	err = ValidateMember(orgID, memberID)
+	err = ValidateMember(r.Context(), orgID, memberID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
+	size, ok := reserveUploadStorage(w, r, orgID, "file")
+	if !ok {
+		return
+	}
+
 	uploadPath := "fileupload/" + orgID + "/" + memberID
 
 	fileURL, err := service.MultipleFileUpload(uploadPath, r)
 
 	if err != nil {
+		cancelReservation(orgID, size)
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
+	// uploaded files come back in the order they were sent
+	for i, fh := range r.MultipartForm.File["file"] {
+		if err = recordStoredFile(orgID, fileURL[i].FileURL, fh.Size); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
 	// publish update to subscriber
 	eventChannel := fmt.Sprintf("organizations_%s", orgID)
 	event := utils.Event{Identifier: memberID, Type: "User", Event: UpdateOrganizationMemberFiles, Channel: eventChannel, Payload: make(map[string]interface{})}
@@ -399,14 +514,14 @@ func (oh *OrganizationHandler) UpdateMemberStatus(w http.ResponseWriter, r *http
 	memberID := mux.Vars(r)["mem_id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
 	// check that member_id is valid
-	err = ValidateMember(orgID, memberID)
+	err = ValidateMember(r.Context(), orgID, memberID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -473,7 +588,7 @@ func (oh *OrganizationHandler) UpdateMemberStatus(w http.ResponseWriter, r *http
 		return
 	}
 
-	memberRec, err := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": pmemberID})
+	memberRec, err := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": pmemberID})
 	if err != nil {
 		utils.GetError(err, http.StatusUnprocessableEntity, w)
 		return
@@ -523,7 +638,7 @@ func (oh *OrganizationHandler) UpdateMemberStatus(w http.ResponseWriter, r *http
 	memberStatus["status"] = statusUpdate
 
 	// updates member status
-	result, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, memberStatus)
+	result, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, memberStatus)
 	if err != nil {
 		utils.GetError(err, http.StatusUnprocessableEntity, w)
 		return
@@ -563,14 +678,14 @@ func (oh *OrganizationHandler) RemoveStatusHistory(w http.ResponseWriter, r *htt
 	}
 
 	// check that org_id is valid
-	err = ValidateOrg(orgID)
+	err = ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
 	// check that member_id is valid
-	err = ValidateMember(orgID, memberID)
+	err = ValidateMember(r.Context(), orgID, memberID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -583,7 +698,7 @@ func (oh *OrganizationHandler) RemoveStatusHistory(w http.ResponseWriter, r *htt
 	}
 
 	// get member and then status
-	memberRec, err := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": pmemberID})
+	memberRec, err := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": pmemberID})
 	if err != nil {
 		utils.GetError(err, http.StatusUnprocessableEntity, w)
 		return
@@ -616,7 +731,7 @@ func (oh *OrganizationHandler) RemoveStatusHistory(w http.ResponseWriter, r *htt
 	memberStatus["status"] = statusUpdate
 
 	// updates member status
-	result, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, memberStatus)
+	result, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, memberStatus)
 	if err != nil {
 		utils.GetError(err, http.StatusUnprocessableEntity, w)
 		return
@@ -638,21 +753,21 @@ func (oh *OrganizationHandler) DeactivateMember(w http.ResponseWriter, r *http.R
 	orgID, memberID := vars["id"], vars["mem_id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
 	// check that member_id is valid
-	err = ValidateMember(orgID, memberID)
+	err = ValidateMember(r.Context(), orgID, memberID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
-	deleteUpdate := bson.M{"deleted": true, "deleted_at": time.Now()}
-	res, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, deleteUpdate)
+	deleteUpdate := bson.M{"deleted": true, "deleted_at": utils.NowUTC()}
+	res, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, deleteUpdate)
 
 	if err != nil {
 		utils.GetError(fmt.Errorf("an error occurred: %s", err), http.StatusInternalServerError, w)
@@ -668,6 +783,7 @@ func (oh *OrganizationHandler) DeactivateMember(w http.ResponseWriter, r *http.R
 	event := utils.Event{Identifier: memberID, Type: "User", Event: DeactivateOrganizationMember, Channel: eventChannel, Payload: make(map[string]interface{})}
 
 	go utils.Emitter(event)
+	go DispatchWebhookEvent(orgID, DeactivateOrganizationMember, utils.M{"member_id": memberID})
 
 	utils.GetSuccess("successfully deactivated member", nil, w)
 
@@ -678,7 +794,7 @@ func (oh *OrganizationHandler) DeactivateMember(w http.ResponseWriter, r *http.R
 	eee := AddSyncMessage(orgID, "leave_organization", enterOrgMessage)
 
 	if eee != nil {
-		log.Printf("sync error: %v", eee)
+		logger.Error("sync error: %v", eee)
 	}
 }
 
@@ -690,14 +806,14 @@ func (oh *OrganizationHandler) UpdateProfile(w http.ResponseWriter, r *http.Requ
 	memberID := mux.Vars(r)["mem_id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
 	// check that member_id is valid
-	err = ValidateMember(orgID, memberID)
+	err = ValidateMember(r.Context(), orgID, memberID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -726,7 +842,7 @@ func (oh *OrganizationHandler) UpdateProfile(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Fetch and update the MemberDoc from collection
-	update, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, mProfile)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, mProfile)
 	if err != nil {
 		utils.GetError(err, http.StatusUnprocessableEntity, w)
 		return
@@ -754,7 +870,7 @@ func (oh *OrganizationHandler) TogglePresence(w http.ResponseWriter, r *http.Req
 	memID := mux.Vars(r)["mem_id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -767,9 +883,9 @@ func (oh *OrganizationHandler) TogglePresence(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	memberDoc, _ := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": pMemID, "org_id": orgID})
+	memberDoc, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": pMemID, "org_id": orgID})
 	if memberDoc == nil {
-		fmt.Printf("member with id %s doesn't exist!", memID)
+		logger.Debug("member with id %s doesn't exist in organization %s", memID, orgID)
 		utils.GetError(errors.New("member with id doesn't exist"), http.StatusBadRequest, w)
 
 		return
@@ -784,7 +900,7 @@ func (oh *OrganizationHandler) TogglePresence(w http.ResponseWriter, r *http.Req
 	}
 
 	// update the presence field of the member
-	update, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memID, orgFilter)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -886,7 +1002,7 @@ func (oh *OrganizationHandler) ReactivateMember(w http.ResponseWriter, r *http.R
 	orgID, memberID := vars["id"], vars["mem_id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -899,9 +1015,9 @@ func (oh *OrganizationHandler) ReactivateMember(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	memberDoc, _ := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": pMemID, "org_id": orgID})
+	memberDoc, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": pMemID, "org_id": orgID})
 	if memberDoc == nil {
-		fmt.Printf("member with id %s doesn't exist!", memberID)
+		logger.Debug("member with id %s doesn't exist in organization %s", memberID, orgID)
 		utils.GetError(errors.New("member with id doesn't exist"), http.StatusBadRequest, w)
 
 		return
@@ -913,7 +1029,7 @@ func (oh *OrganizationHandler) ReactivateMember(w http.ResponseWriter, r *http.R
 	}
 
 	ActivatedMember := bson.M{"deleted": false, "deleted_at": time.Time{}}
-	res, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, ActivatedMember)
+	res, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, ActivatedMember)
 
 	if err != nil {
 		utils.GetError(fmt.Errorf("an error occurred: %s", err), http.StatusInternalServerError, w)
@@ -929,6 +1045,7 @@ func (oh *OrganizationHandler) ReactivateMember(w http.ResponseWriter, r *http.R
 	event := utils.Event{Identifier: memberID, Type: "User", Event: ReactivateOrganizationMember, Channel: eventChannel, Payload: make(map[string]interface{})}
 
 	go utils.Emitter(event)
+	go DispatchWebhookEvent(orgID, ReactivateOrganizationMember, utils.M{"member_id": memberID})
 
 	utils.GetSuccess("successfully reactivated member", nil, w)
 }
@@ -947,15 +1064,25 @@ func (oh *OrganizationHandler) CheckGuestStatus(w http.ResponseWriter, r *http.R
 	}
 
 	// 1. Query organization invites collection for uuid
-	res, err := utils.GetMongoDBDoc(OrganizationInviteCollectionName, bson.M{"uuid": guestUUID})
+	res, err := utils.GetMongoDBDoc(r.Context(), OrganizationInviteCollectionName, bson.M{"uuid": guestUUID})
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
+	if inviteExpired(res, utils.NowUTC()) {
+		utils.GetError(errInviteExpired, http.StatusGone, w)
+		return
+	}
+
+	if accepted, _ := res["has_accepted"].(bool); accepted {
+		utils.GetError(errInviteAccepted, http.StatusGone, w)
+		return
+	}
+
 	// 2. Check if email already is registered in zurichat (return 403 user already exist)
 	guestEmail := res["email"]
-	_, err = utils.GetMongoDBDoc(UserCollectionName, bson.M{"email": guestEmail})
+	_, err = utils.GetMongoDBDoc(r.Context(), UserCollectionName, bson.M{"email": guestEmail})
 
 	if err != nil {
 		utils.GetError(
@@ -972,6 +1099,7 @@ func (oh *OrganizationHandler) CheckGuestStatus(w http.ResponseWriter, r *http.R
 }
 
 // Add accepted guest as member to organization without requiring admin or workspace owner rights.
+// Guests without an account are given a verified one for the email the invite was sent to.
 func (oh *OrganizationHandler) GuestToOrganization(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -983,12 +1111,47 @@ func (oh *OrganizationHandler) GuestToOrganization(w http.ResponseWriter, r *htt
 		return
 	}
 
-	res, err := utils.GetMongoDBDoc(OrganizationInviteCollectionName, bson.M{"uuid": gUUID})
+	res, err := utils.GetMongoDBDoc(r.Context(), OrganizationInviteCollectionName, bson.M{"uuid": gUUID})
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
+	if inviteExpired(res, utils.NowUTC()) {
+		utils.GetError(errInviteExpired, http.StatusGone, w)
+		return
+	}
+
+	// invites are used up once accepted, so an old link cannot bring back someone removed since
+	inviteID := res["_id"].(primitive.ObjectID)
+
+	claim, err := utils.GetCollection(OrganizationInviteCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": inviteID, "has_accepted": bson.M{"$ne": true}},
+		bson.M{"$set": bson.M{"has_accepted": true, "accepted_at": utils.NowUTC()}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if claim.ModifiedCount == 0 {
+		utils.GetError(errInviteAccepted, http.StatusGone, w)
+		return
+	}
+
+	// the invite can be used again if the guest could not be added
+	joined := false
+
+	defer func() {
+		if joined {
+			return
+		}
+
+		if _, err := utils.GetCollection(OrganizationInviteCollectionName).UpdateOne(context.TODO(),
+			bson.M{"_id": inviteID}, bson.M{"$set": bson.M{"has_accepted": false}, "$unset": bson.M{"accepted_at": ""}}); err != nil {
+			logger.Error("could not release invite %s: %v", inviteID.Hex(), err)
+		}
+	}()
+
 	// // TODO 0: Check that organization exists
 	orgID, ok := res["org_id"].(string)
 	if !ok {
@@ -1003,7 +1166,7 @@ func (oh *OrganizationHandler) GuestToOrganization(w http.ResponseWriter, r *htt
 		return
 	}
 
-	orgDoc, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": validOrgID})
+	orgDoc, _ := utils.GetMongoDBDoc(r.Context(), OrganizationCollectionName, bson.M{"_id": validOrgID})
 	if orgDoc == nil {
 		utils.GetError(errors.New("organization with id "+orgID+" doesn't exist!"), http.StatusBadRequest, w)
 		return
@@ -1021,47 +1184,69 @@ func (oh *OrganizationHandler) GuestToOrganization(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// TODO 3: Check that guest is (now) registered on zurichat
-	user, err := auth.FetchUserByEmail(bson.M{"email": email})
-	if err != nil {
-		utils.GetError(errors.New("user with "+email+" does not exist. register to proceed"), http.StatusBadRequest, w)
+	version, _ := orgDoc["version"].(string)
+	if err = oh.checkMemberLimit(r.Context(), &Organization{Version: version}, orgID); err != nil {
+		utils.GetError(err, http.StatusForbidden, w)
 		return
 	}
 
+	// guests without an account get one for the email they were invited at, and nothing else
+	provisioned := false
+
+	user, err := auth.FetchUserByEmail(bson.M{"email": strings.ToLower(email)})
+	if err != nil {
+		if user, err = provisionInvitedUser(r.Context(), email); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		provisioned = true
+	}
+
 	// TODO 4: Check that guest does not already exist (as a member) in organization
-	memDoc, err := utils.GetMongoDBDocs(MemberCollectionName, bson.M{"org_id": orgID, "email": user.Email})
+	memDoc, err := utils.GetMongoDBDocs(r.Context(), MemberCollectionName, activeMemberFilter(orgID, user.Email))
 	if memDoc != nil && err == nil {
 		utils.GetError(errors.New("user is already in this organization"), http.StatusBadRequest, w)
 		return
 	}
 
 	// TODO 5: Create a member profile for the guest
-	setting := new(Settings)
+	// the member is inserted as is, so joined_at is stored as a date rather than a string
 	username := strings.Split(user.Email, "@")[0]
-
-	memberStruct := Member{
-		Email:    user.Email,
-		UserName: username,
-		OrgID:    validOrgID.Hex(),
-		Role:     "member",
-		Presence: "true",
-		JoinedAt: time.Now(),
-		Settings: setting,
-		Deleted:  false,
+	// invites may carry the role the guest joins with
+	role, _ := res["role"].(string)
+	if _, ok := Roles[role]; !ok || role == OwnerRole {
+		role = MemberRole
 	}
 
-	data, err := utils.StructToMap(memberStruct)
-	if err != nil {
-		utils.GetError(err, http.StatusInternalServerError, w)
+	invitedBy, _ := res["invited_by"].(string)
+
+	// guests who were members before are brought back with their history, but only by an
+	// invite sent since they were removed
+	memberID, rejoined, err := rejoinRemovedMember(r.Context(), orgID, user.Email, role, invitedBy, inviteSentAt(res))
+	if errors.Is(err, errInviteBeforeRemoval) {
+		utils.GetError(err, http.StatusForbidden, w)
 		return
 	}
 
-	resp, err := utils.CreateMongoDBDoc(MemberCollectionName, data)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
+	if !rejoined {
+		memberStruct := NewMember(user.Email, username, validOrgID.Hex(), role)
+		memberStruct.InvitedBy = invitedBy
+
+		resp, err := utils.GetCollection(MemberCollectionName).InsertOne(r.Context(), memberStruct)
+		if err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+
+		memberID = resp.InsertedID.(primitive.ObjectID)
+	}
+
 	// TODO 6: Add member to organization
 	organizationStruct := new(Organization)
 	err = mapstructure.Decode(orgDoc, &organizationStruct)
@@ -1072,27 +1257,22 @@ func (oh *OrganizationHandler) GuestToOrganization(w http.ResponseWriter, r *htt
 	}
 
 	// update user organizations collection
-	updateFields := make(map[string]interface{})
+	if !hasOrganization(user.Organizations, validOrgID.Hex()) {
+		updateFields := make(map[string]interface{})
 
-	user.Organizations = append(user.Organizations, validOrgID.Hex())
+		user.Organizations = append(user.Organizations, validOrgID.Hex())
 
-	updateFields["Organizations"] = user.Organizations
-	_, err = utils.UpdateOneMongoDBDoc(UserCollectionName, user.ID, updateFields)
+		updateFields["Organizations"] = user.Organizations
+		_, err = utils.UpdateOneMongoDBDoc(r.Context(), UserCollectionName, user.ID, updateFields)
 
-	if err != nil {
-		utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
-		return
-	}
-	// update invite status
-	inviteID := res["_id"].(primitive.ObjectID).Hex()
-
-	_, err = utils.UpdateOneMongoDBDoc(OrganizationInviteCollectionName, inviteID, bson.M{"has_accepted": true})
-	if err != nil {
-		utils.GetError(errors.New("invite update failed"), http.StatusInternalServerError, w)
-		return
+		if err != nil {
+			utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, w)
+			return
+		}
 	}
+	joined = true
 
-	utils.GetSuccess("Member created successfully", utils.M{"member_id": resp.InsertedID, "organization_id": orgID}, w)
+	utils.GetSuccess("Member created successfully", utils.M{"member_id": memberID, "organization_id": orgID, "provisioned": provisioned}, w)
 }
 
 // Update a member's role.
@@ -1102,14 +1282,14 @@ func (oh *OrganizationHandler) UpdateMemberRole(w http.ResponseWriter, r *http.R
 	vars := mux.Vars(r)
 	orgID, memberID := vars["id"], vars["mem_id"]
 
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
 	// check that member_id is valid
-	err = ValidateMember(orgID, memberID)
+	err = ValidateMember(r.Context(), orgID, memberID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -1129,7 +1309,7 @@ func (oh *OrganizationHandler) UpdateMemberRole(w http.ResponseWriter, r *http.R
 
 	memID, _ := primitive.ObjectIDFromHex(memberID)
 
-	orgMember, err := FetchMember(bson.M{"org_id": orgID, "_id": memID})
+	orgMember, err := FetchMember(r.Context(), bson.M{"org_id": orgID, "_id": memID})
 
 	if err != nil {
 		utils.GetError(errors.New("user not a member of this work space"), http.StatusBadRequest, w)
@@ -1146,7 +1326,31 @@ func (oh *OrganizationHandler) UpdateMemberRole(w http.ResponseWriter, r *http.R
 	// ID of the user whose role is being updated
 	memberIDHex := orgMember.ID
 
-	updateRes, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberIDHex, bson.M{"role": role})
+	// the owners set follows role changes, and the last owner cannot be demoted
+	if orgMember.Role == OwnerRole || role == OwnerRole {
+		if _, err = fetchOrganizationWithOwners(r.Context(), orgID); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	if role == OwnerRole {
+		err = addOwner(orgID, memberIDHex)
+	} else if orgMember.Role == OwnerRole {
+		err = removeOwner(orgID, memberIDHex)
+	}
+
+	if errors.Is(err, errLastOwner) {
+		utils.GetError(err, http.StatusConflict, w)
+		return
+	}
+
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	updateRes, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberIDHex, bson.M{"role": role})
 
 	if err != nil {
 		utils.GetError(errors.New("operation failed"), http.StatusInternalServerError, w)
@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
 	"sync"
@@ -15,8 +14,11 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/logger"
 	"zuri.chat/zccore/service"
 	"zuri.chat/zccore/utils"
 )
@@ -27,7 +29,7 @@ func NewOrganizationHandler(c *utils.Configurations, mail service.MailService) *
 
 // gets the details of a member in a workspace using parameters such as email, username etc
 // returns parameters based on the member struct.
-func FetchMember(filter map[string]interface{}) (*Member, error) {
+func FetchMember(ctx context.Context, filter map[string]interface{}) (*Member, error) {
 	member := &Member{}
 	memberCollection, err := utils.GetMongoDBCollection(os.Getenv("DB_NAME"), MemberCollectionName)
 
@@ -35,7 +37,7 @@ func FetchMember(filter map[string]interface{}) (*Member, error) {
 		return nil, err
 	}
 
-	result := memberCollection.FindOne(context.TODO(), filter)
+	result := memberCollection.FindOne(ctx, filter)
 
 	err = mapstructure.Decode(result, &member)
 
@@ -47,7 +49,7 @@ func FetchMember(filter map[string]interface{}) (*Member, error) {
 }
 
 // check that an organization exist.
-func ValidateOrg(orgID string) error {
+func ValidateOrg(ctx context.Context, orgID string) error {
 	// check that org_id is valid
 	pOrgID, err := primitive.ObjectIDFromHex(orgID)
 	if err != nil {
@@ -55,9 +57,9 @@ func ValidateOrg(orgID string) error {
 	}
 
 	// check that org exists
-	orgDoc, _ := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": pOrgID})
+	orgDoc, _ := utils.GetMongoDBDoc(ctx, OrganizationCollectionName, bson.M{"_id": pOrgID})
 	if orgDoc == nil {
-		fmt.Printf("org with id %s doesn't exist!", orgID)
+		logger.Debug("organization with id %s doesn't exist", orgID)
 		return errors.New("organization does not exist")
 	}
 
@@ -65,7 +67,7 @@ func ValidateOrg(orgID string) error {
 }
 
 // check that a member belongs in the an organization.
-func ValidateMember(orgID, memberID string) error {
+func ValidateMember(ctx context.Context, orgID, memberID string) error {
 	// check that org_id is valid
 	pMemID, err := primitive.ObjectIDFromHex(memberID)
 	if err != nil {
@@ -73,9 +75,9 @@ func ValidateMember(orgID, memberID string) error {
 	}
 
 	// check that member exists
-	memberDoc, _ := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": pMemID, "org_id": orgID})
+	memberDoc, _ := utils.GetMongoDBDoc(ctx, MemberCollectionName, bson.M{"_id": pMemID, "org_id": orgID})
 	if memberDoc == nil {
-		fmt.Printf("member with id %s doesn't exist!", memberID)
+		logger.Debug("member with id %s doesn't exist in organization %s", memberID, orgID)
 		return errors.New("member does not exist")
 	}
 
@@ -90,9 +92,11 @@ func NewMember(email, userName, orgID, role string) Member {
 		OrgID:    orgID,
 		Role:     role,
 		Presence: "true",
-		JoinedAt: time.Now(),
+		JoinedAt: utils.NowUTC(),
 		Deleted:  false,
 		Settings: new(Settings),
+
+		NotificationPreferences: DefaultNotificationPreferences(),
 	}
 }
 
@@ -148,9 +152,9 @@ func ClearStatus(memberID string, duration int64) {
 
 	pmemberID, _ := primitive.ObjectIDFromHex(memberID)
 
-	memberRec, err := utils.GetMongoDBDoc(MemberCollectionName, bson.M{"_id": pmemberID})
+	memberRec, err := utils.GetMongoDBDoc(context.TODO(), MemberCollectionName, bson.M{"_id": pmemberID})
 	if err != nil {
-		log.Println("error while trying to get member")
+		logger.Error("could not get member %s to clear their status: %v", memberID, err)
 		return
 	}
 
@@ -160,7 +164,7 @@ func ClearStatus(memberID string, duration int64) {
 	bsonBytes, _ := bson.Marshal(memberRec["status"])
 
 	if err = bson.Unmarshal(bsonBytes, &prevStatus); err != nil {
-		log.Println(err)
+		logger.Error("could not read status of member %s: %v", memberID, err)
 		return
 	}
 
@@ -169,21 +173,21 @@ func ClearStatus(memberID string, duration int64) {
 	memberStatus := make(map[string]interface{})
 	memberStatus["status"] = update
 
-	result, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, memberStatus)
+	result, err := utils.UpdateOneMongoDBDoc(context.TODO(), MemberCollectionName, memberID, memberStatus)
 	if err != nil {
-		log.Println(err)
+		logger.Error("could not clear status of member %s: %v", memberID, err)
 		return
 	}
 
 	if result.ModifiedCount == 0 {
-		log.Println(err)
+		logger.Warn("status of member %s was already clear", memberID)
 		return
 	}
 
-	log.Printf("%s status cleared successfully. Duration: %d", memberID, duration)
+	logger.Info("%s status cleared successfully. Duration: %d", memberID, duration)
 }
 
-func FetchOrganization(filter map[string]interface{}) (*Organization, error) {
+func FetchOrganization(ctx context.Context, filter map[string]interface{}) (*Organization, error) {
 	organization := &Organization{}
 	orgCollection, err := utils.GetMongoDBCollection(os.Getenv("DB_NAME"), OrganizationCollectionName)
 
@@ -191,7 +195,7 @@ func FetchOrganization(filter map[string]interface{}) (*Organization, error) {
 		return organization, err
 	}
 
-	result := orgCollection.FindOne(context.TODO(), filter)
+	result := orgCollection.FindOne(ctx, filter)
 	err = result.Decode(&organization)
 
 	return organization, err
@@ -206,7 +210,7 @@ func OrganizationUpdate(w http.ResponseWriter, r *http.Request, updateParam upda
 	w.Header().Set("Content-Type", "application/json")
 
 	orgID := mux.Vars(r)["id"]
-	_, err := primitive.ObjectIDFromHex(orgID)
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
 
 	if err != nil {
 		utils.GetError(errors.New("invalid id"), http.StatusBadRequest, w)
@@ -218,20 +222,27 @@ func OrganizationUpdate(w http.ResponseWriter, r *http.Request, updateParam upda
 		return
 	}
 
-	orgFilter := make(map[string]interface{})
-	orgFilter[updateParam.orgFilterKey] = RequestData[updateParam.requestDataKey]
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	key, value := updateParam.orgFilterKey, RequestData[updateParam.requestDataKey]
 
-	if err != nil {
-		utils.GetError(err, http.StatusInternalServerError, w)
+	// the field's value before the update is kept in the audit log, see GetOrganizationDiff
+	var before bson.M
+
+	err = utils.GetCollection(OrganizationCollectionName).FindOneAndUpdate(r.Context(),
+		bson.M{"_id": pOrgID}, bson.M{"$set": bson.M{key: value}},
+		options.FindOneAndUpdate().SetProjection(bson.M{key: 1}).SetReturnDocument(options.Before)).Decode(&before)
+
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		utils.GetWriteError(err, w)
 		return
 	}
 
-	if update.ModifiedCount == 0 {
+	if err != nil || before[key] == value {
 		utils.GetError(errors.New("operation failed"), http.StatusInternalServerError, w)
 		return
 	}
 
+	recordOrganizationChange(r, orgID, key, before[key], value)
+
 	eventChannel := fmt.Sprintf("organizations_%s", orgID)
 	event := utils.Event{Identifier: orgID, Type: "Organization", Event: updateParam.eventKey, Channel: eventChannel, Payload: make(map[string]interface{})}
 
@@ -240,7 +251,7 @@ func OrganizationUpdate(w http.ResponseWriter, r *http.Request, updateParam upda
 	utils.GetSuccess(fmt.Sprintf("%s updated successfully", updateParam.successMessage), nil, w)
 }
 
-func HandleMemberSearch(orgID, memberID string, ch chan HandleMemberSearchResponse, wg *sync.WaitGroup) {
+func HandleMemberSearch(ctx context.Context, orgID, memberID string, ch chan HandleMemberSearchResponse, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	memberIDhex, err := primitive.ObjectIDFromHex(memberID)
@@ -251,7 +262,7 @@ func HandleMemberSearch(orgID, memberID string, ch chan HandleMemberSearchRespon
 		return
 	}
 
-	orgMember, err := utils.GetMongoDBDoc(MemberCollectionName, bson.M{
+	orgMember, err := utils.GetMongoDBDoc(ctx, MemberCollectionName, bson.M{
 		"org_id":  orgID,
 		"_id":     memberIDhex,
 		"deleted": bson.M{"$ne": true},
@@ -299,14 +310,14 @@ func updateMemberSettings(w http.ResponseWriter, r *http.Request, settingsPayloa
 	orgID, memberID := vars["id"], vars["mem_id"]
 
 	// check that org_id is valid
-	err := ValidateOrg(orgID)
+	err := ValidateOrg(r.Context(), orgID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
 	}
 
 	// check that member_id is valid
-	err = ValidateMember(orgID, memberID)
+	err = ValidateMember(r.Context(), orgID, memberID)
 	if err != nil {
 		utils.GetError(err, http.StatusBadRequest, w)
 		return
@@ -334,7 +345,7 @@ func updateMemberSettings(w http.ResponseWriter, r *http.Request, settingsPayloa
 	memberSettings[settingsPayload.field] = settingsMap
 
 	// fetch and update the document
-	update, err := utils.UpdateOneMongoDBDoc(MemberCollectionName, memberID, memberSettings)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, memberSettings)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
@@ -378,7 +389,7 @@ func updateBilling(w http.ResponseWriter, r *http.Request, settingsPayload setti
 		return
 	}
 
-	member, err := FetchMember(bson.M{"org_id": orgID, "email": loggedInUser.Email})
+	member, err := FetchMember(r.Context(), bson.M{"org_id": orgID, "email": loggedInUser.Email})
 	if err != nil {
 		utils.GetError(errors.New("access denied"), http.StatusNotFound, w)
 		return
@@ -387,7 +398,7 @@ func updateBilling(w http.ResponseWriter, r *http.Request, settingsPayload setti
 	orgFilter := make(map[string]interface{})
 	orgFilter[settingsPayload.field] = settingsPayload.settings
 
-	update, err := utils.UpdateOneMongoDBDoc(OrganizationCollectionName, orgID, orgFilter)
+	update, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, orgFilter)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
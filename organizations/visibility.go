@@ -0,0 +1,137 @@
+package organizations
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+// who other members of an organization can see a member's email. Members who never chose are
+// visible to everyone.
+const (
+	EmailVisibleToEveryone = "everyone"
+	EmailVisibleToAdmins   = "admins"
+	EmailVisibleToNone     = "none"
+)
+
+// memberViewer is whoever is looking at members of an organization.
+type memberViewer struct {
+	email string
+	admin bool
+}
+
+// viewerOf finds who is looking at the members of an organization. Anonymous viewers, and
+// those who are not its admins or owners, see no more than members let everyone see.
+func viewerOf(r *http.Request, orgID string) memberViewer {
+	loggedInUser, ok := r.Context().Value(auth.UserContext).(*auth.AuthUser)
+	if !ok || loggedInUser == nil {
+		return memberViewer{}
+	}
+
+	viewer := memberViewer{email: strings.ToLower(loggedInUser.Email)}
+
+	member, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"org_id": orgID, "email": viewer.email, "deleted": bson.M{"$ne": true}})
+	if member != nil {
+		viewer.admin = auth.RoleRanks[auth.EffectiveRole(member, utils.NowUTC())] >= auth.RoleRanks[AdminRole]
+	}
+
+	return viewer
+}
+
+// seesEmail reports whether the viewer may see the email of a member with the given visibility.
+// Admins and the member themselves always can.
+func (v memberViewer) seesEmail(memberEmail, visibility string) bool {
+	if v.manages(memberEmail) {
+		return true
+	}
+
+	return visibility != EmailVisibleToAdmins && visibility != EmailVisibleToNone
+}
+
+// manages reports whether the viewer may change a member's own settings, being the member
+// themselves or an admin.
+func (v memberViewer) manages(memberEmail string) bool {
+	return v.admin || (v.email != "" && strings.EqualFold(v.email, memberEmail))
+}
+
+// redactMemberDoc clears the email of a member document the viewer may not see it on.
+func (v memberViewer) redactMemberDoc(doc bson.M) {
+	email, _ := doc["email"].(string)
+	visibility, _ := doc["email_visibility"].(string)
+
+	if !v.seesEmail(email, visibility) {
+		doc["email"] = ""
+	}
+}
+
+// redactMember clears the email of a member the viewer may not see it on.
+func (v memberViewer) redactMember(member *Member) {
+	if !v.seesEmail(member.Email, member.EmailVisibility) {
+		member.Email = ""
+	}
+}
+
+// emailSearchFilter matches members by email, leaving out those who hide it from the viewer.
+func (v memberViewer) emailSearchFilter(email string) bson.M {
+	if v.seesEmail(email, EmailVisibleToNone) {
+		return bson.M{"email": email}
+	}
+
+	return bson.M{"email": email, "email_visibility": bson.M{"$nin": []string{EmailVisibleToAdmins, EmailVisibleToNone}}}
+}
+
+// Set who can see a member's email: everyone, admins or none. Only the member and the admins of
+// the organization can change it, and they always see it themselves.
+func (oh *OrganizationHandler) UpdateEmailVisibility(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, memberID := vars["id"], vars["mem_id"]
+
+	pMemberID, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		utils.GetError(errors.New("invalid member id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		EmailVisibility string `json:"email_visibility"`
+	}
+
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	switch body.EmailVisibility {
+	case EmailVisibleToEveryone, EmailVisibleToAdmins, EmailVisibleToNone:
+	default:
+		utils.GetError(errors.New("email_visibility must be everyone, admins or none"), http.StatusBadRequest, w)
+		return
+	}
+
+	member, _ := utils.GetMongoDBDoc(r.Context(), MemberCollectionName, bson.M{"_id": pMemberID, "org_id": orgID, "deleted": bson.M{"$ne": true}})
+	if member == nil {
+		utils.GetError(errors.New("member not found"), http.StatusNotFound, w)
+		return
+	}
+
+	viewer := viewerOf(r, orgID)
+	if email, _ := member["email"].(string); !viewer.seesEmail(email, EmailVisibleToNone) {
+		utils.GetError(errors.New("only the member or an admin can change who sees their email"), http.StatusForbidden, w)
+		return
+	}
+
+	if _, err = utils.UpdateOneMongoDBDoc(r.Context(), MemberCollectionName, memberID, bson.M{"email_visibility": body.EmailVisibility}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("email visibility updated successfully", utils.M{"email_visibility": body.EmailVisibility}, w)
+}
@@ -0,0 +1,108 @@
+package organizations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"zuri.chat/zccore/utils"
+)
+
+func TestMemberEmailVisibility(t *testing.T) {
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	suffix := utils.GenUUID()
+	private := fmt.Sprintf("private.%s@gmail.com", suffix)
+	viewer := fmt.Sprintf("viewer.%s@gmail.com", suffix)
+	admin := fmt.Sprintf("admin.%s@gmail.com", suffix)
+
+	privateID, err := setUpMember(orgID, private, MemberRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for email, role := range map[string]string{viewer: MemberRole, admin: AdminRole} {
+		if _, err = setUpMember(orgID, email, role); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/members", orgs.GetMembers).Methods("GET")
+	r.HandleFunc("/organizations/{id}/members/{mem_id}", orgs.GetMember).Methods("GET")
+	r.HandleFunc("/organizations/{id}/members/{mem_id}/email-visibility", orgs.UpdateEmailVisibility).Methods("PATCH")
+
+	setVisibility := func(t *testing.T, as, visibility string, code int) {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/members/%s/email-visibility", orgID, privateID),
+			bytes.NewBufferString(fmt.Sprintf(`{"email_visibility": %q}`, visibility)))
+		assertStatusCode(t, getHTTPResponse(t, r, withUser(req, as)).Code, code)
+	}
+
+	// listedEmail is the email of the private member in the listing as seen by as.
+	listedEmail := func(t *testing.T, as string) interface{} {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members", orgID), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, as))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		for _, m := range parseResponse(response)["data"].([]interface{}) {
+			if member := m.(map[string]interface{}); member["_id"] == privateID {
+				return member["email"]
+			}
+		}
+
+		t.Fatalf("expected member %s to be listed", privateID)
+
+		return nil
+	}
+
+	t.Run("test only the member or an admin can change it", func(t *testing.T) {
+		setVisibility(t, viewer, EmailVisibleToNone, http.StatusForbidden)
+		setVisibility(t, private, "friends", http.StatusBadRequest)
+		setVisibility(t, private, EmailVisibleToAdmins, http.StatusOK)
+	})
+
+	t.Run("test the email is redacted for other members", func(t *testing.T) {
+		if email := listedEmail(t, viewer); email != "" {
+			t.Errorf("expected the email to be redacted, got %v", email)
+		}
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members/%s", orgID, privateID), nil)
+
+		response := getHTTPResponse(t, r, withUser(req, viewer))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if email := parseResponse(response)["data"].(map[string]interface{})["email"]; email != "" {
+			t.Errorf("expected the email to be redacted from the member, got %v", email)
+		}
+
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/organizations/%s/members?query=%s", orgID, private), nil)
+
+		response = getHTTPResponse(t, r, withUser(req, viewer))
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		if found, _ := parseResponse(response)["data"].([]interface{}); len(found) != 0 {
+			t.Errorf("expected searching by a hidden email to find no one, got %v", found)
+		}
+	})
+
+	t.Run("test admins and the member see it", func(t *testing.T) {
+		if email := listedEmail(t, admin); email != private {
+			t.Errorf("expected an admin to see %s, got %v", private, email)
+		}
+
+		if email := listedEmail(t, private); email != private {
+			t.Errorf("expected the member to see their own email, got %v", email)
+		}
+
+		setVisibility(t, admin, EmailVisibleToNone, http.StatusOK)
+
+		if email := listedEmail(t, admin); email != private {
+			t.Errorf("expected an admin to still see %s, got %v", private, email)
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package organizations
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/auth"
+	"zuri.chat/zccore/utils"
+)
+
+// validWaitlistCode reports whether code is one of the configured invite codes.
+func (oh *OrganizationHandler) validWaitlistCode(code string) bool {
+	if code == "" {
+		return false
+	}
+
+	for _, valid := range oh.configs.WaitlistInviteCodes {
+		if subtle.ConstantTimeCompare([]byte(code), []byte(valid)) == 1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// waitlistGate decides whether someone may create an organization while the waitlist is on.
+// Those who may not are put on the waitlist, once, and get their position in it.
+func (oh *OrganizationHandler) waitlistGate(ctx context.Context, email, code string) (bool, int64, error) {
+	if !oh.configs.OrgCreationWaitlist || oh.validWaitlistCode(code) {
+		return true, 0, nil
+	}
+
+	var entry WaitlistEntry
+
+	err := utils.GetCollection(WaitlistCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": email},
+		bson.M{"$setOnInsert": bson.M{"status": WaitlistWaiting, "created_at": utils.NowUTC()}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&entry)
+
+	// a concurrent request added them first, so they are on the list now
+	if utils.IsDuplicateKeyError(err) {
+		return oh.waitlistGate(ctx, email, code)
+	}
+
+	if err != nil {
+		return false, 0, err
+	}
+
+	if entry.Status == WaitlistAllowed {
+		return true, 0, nil
+	}
+
+	ahead := utils.CountCollection(ctx, WaitlistCollectionName, bson.M{
+		"status": WaitlistWaiting,
+		"$or": []bson.M{
+			{"created_at": bson.M{"$lt": entry.CreatedAt}},
+			{"created_at": entry.CreatedAt, "_id": bson.M{"$lt": email}},
+		},
+	})
+
+	return false, ahead + 1, nil
+}
+
+// respondWaitlisted tells someone the organization they asked for was not created as they are
+// on the waitlist, at the given position.
+func respondWaitlisted(w http.ResponseWriter, email string, position int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	_ = json.NewEncoder(w).Encode(utils.SuccessResponse{
+		StatusCode: http.StatusAccepted,
+		Message:    "organization creation is waitlisted, you will be let in soon",
+		Data:       utils.M{"waitlisted": true, "email": email, "position": position},
+	})
+}
+
+// List the waitlist for creating organizations, oldest first, a page at a time with ?limit=
+// and ?page=. ?status= picks waiting or allowed entries, waiting by default.
+func (oh *OrganizationHandler) ListWaitlist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	limit, page := oh.configs.Pagination.PageParams(query.Get("limit"), query.Get("page"))
+
+	status := query.Get("status")
+	if status == "" {
+		status = WaitlistWaiting
+	}
+
+	if status != WaitlistWaiting && status != WaitlistAllowed {
+		utils.GetError(errors.New("status must be waiting or allowed"), http.StatusBadRequest, w)
+		return
+	}
+
+	filter := bson.M{"status": status}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64((page - 1) * limit))
+
+	docs, err := utils.GetMongoDBDocs(r.Context(), WaitlistCollectionName, filter, opts)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("waitlist retrieved successfully", utils.M{
+		"entries": docs,
+		"page":    page,
+		"limit":   limit,
+		"total":   utils.CountCollection(r.Context(), WaitlistCollectionName, filter),
+	}, w)
+}
+
+// Allow someone on the waitlist to create organizations. People can be allowed before they
+// join the waitlist.
+func (oh *OrganizationHandler) AllowWaitlistedUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	loggedInUser, ok := r.Context().Value("user").(*auth.AuthUser)
+	if !ok {
+		utils.GetError(errors.New("invalid user"), http.StatusBadRequest, w)
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(mux.Vars(r)["email"]))
+	if !utils.IsValidEmail(email) {
+		utils.GetError(errors.New("invalid email address"), http.StatusBadRequest, w)
+		return
+	}
+
+	now := utils.NowUTC()
+
+	var entry WaitlistEntry
+
+	err := utils.GetCollection(WaitlistCollectionName).FindOneAndUpdate(r.Context(),
+		bson.M{"_id": email},
+		bson.M{
+			"$set":         bson.M{"status": WaitlistAllowed, "allowed_at": now, "allowed_by": strings.ToLower(loggedInUser.Email)},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&entry)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("user allowed to create organizations", entry, w)
+}
@@ -0,0 +1,98 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+func TestOrganizationCreationWaitlist(t *testing.T) {
+	waitlisting := *configs
+	waitlisting.OrgCreationWaitlist = true
+	waitlisting.WaitlistInviteCodes = []string{"launch-friends"}
+
+	handler := NewOrganizationHandler(&waitlisting, newMockMailService())
+
+	r := getRouter()
+	r.HandleFunc("/organizations", handler.Create).Methods("POST")
+	r.HandleFunc("/organizations/waitlist/{email}/allow", handler.AllowWaitlistedUser).Methods("POST")
+
+	// setUpCreator adds an account that can create organizations.
+	setUpCreator := func(t *testing.T) string {
+		email := fmt.Sprintf("waitlisted.%s@gmail.com", utils.GenUUID())
+		if err := setUpUser(email, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		return email
+	}
+
+	create := func(t *testing.T, email, query string, code int) map[string]interface{} {
+		req, _ := http.NewRequest("POST", "/organizations"+query, bytes.NewBufferString(fmt.Sprintf(`{"creator_email": %q}`, email)))
+
+		response := getHTTPResponse(t, r, withUser(req, email))
+		assertStatusCode(t, response.Code, code)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	created := func(email string) int64 {
+		return utils.CountCollection(context.TODO(), OrganizationCollectionName, bson.M{"creator_email": email})
+	}
+
+	t.Run("test creators are waitlisted with a position", func(t *testing.T) {
+		first, second := setUpCreator(t), setUpCreator(t)
+
+		data := create(t, first, "", http.StatusAccepted)
+		if data["waitlisted"] != true {
+			t.Fatalf("expected to be waitlisted, got %v", data)
+		}
+
+		position := data["position"].(float64)
+
+		if again := create(t, first, "", http.StatusAccepted); again["position"] != position {
+			t.Errorf("expected asking again to keep position %v, got %v", position, again["position"])
+		}
+
+		if next := create(t, second, "", http.StatusAccepted); next["position"] != position+1 {
+			t.Errorf("expected the next creator to be behind at %v, got %v", position+1, next["position"])
+		}
+
+		if created(first) != 0 {
+			t.Error("expected no organization to be created")
+		}
+	})
+
+	t.Run("test invite codes bypass the waitlist", func(t *testing.T) {
+		email := setUpCreator(t)
+
+		create(t, email, "?invite_code=wrong-code", http.StatusAccepted)
+		create(t, email, "?invite_code=launch-friends", http.StatusCreated)
+
+		if created(email) != 1 {
+			t.Error("expected the organization to be created")
+		}
+	})
+
+	t.Run("test allowed creators are let through", func(t *testing.T) {
+		email := setUpCreator(t)
+
+		create(t, email, "", http.StatusAccepted)
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/waitlist/%s/allow", email), nil)
+		assertStatusCode(t, getHTTPResponse(t, r, withUser(req, defaultUser)).Code, http.StatusOK)
+
+		create(t, email, "", http.StatusCreated)
+
+		if created(email) != 1 {
+			t.Error("expected the organization to be created")
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package organizations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// organizationChange is the part of a change stream event on an organization WatchOrganization
+// reads.
+type organizationChange struct {
+	OperationType     string `bson:"operationType"`
+	UpdateDescription struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+// parseWatchedFields reads the comma-separated fields a watcher subscribes to. No fields means
+// every change.
+func parseWatchedFields(fields string) ([]string, error) {
+	if strings.TrimSpace(fields) == "" {
+		return nil, nil
+	}
+
+	watched := []string{}
+
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" || strings.HasPrefix(field, "$") {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+
+		watched = append(watched, field)
+	}
+
+	return watched, nil
+}
+
+// fieldWatched reports whether a changed field is one of the watched ones. Fields match their
+// nested fields both ways, so watching "settings" sees "settings.theme" change, and watching
+// "settings.theme" sees "settings" replaced.
+func fieldWatched(changed string, watched []string) bool {
+	for _, field := range watched {
+		if changed == field || strings.HasPrefix(changed, field+".") || strings.HasPrefix(field, changed+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// changedFields lists the fields an update set or removed that are watched, and whether the
+// change should be sent at all. Updates touching no watched field are dropped, other operations
+// replace or remove the whole organization and are always sent.
+func (c *organizationChange) changedFields(watched []string) ([]string, bool) {
+	changed := []string{}
+
+	for field := range c.UpdateDescription.UpdatedFields {
+		changed = append(changed, field)
+	}
+
+	changed = append(changed, c.UpdateDescription.RemovedFields...)
+	sort.Strings(changed)
+
+	if c.OperationType != "update" || len(watched) == 0 {
+		return changed, true
+	}
+
+	kept := []string{}
+
+	for _, field := range changed {
+		if fieldWatched(field, watched) {
+			kept = append(kept, field)
+		}
+	}
+
+	return kept, len(kept) > 0
+}
+
+// Stream changes to an organization as server-sent events, optionally only those touching the
+// comma-separated fields given. Events name the fields changed rather than carry their values,
+// as some are never shown to members, so clients fetch the organization again to see them.
+// Change streams need MongoDB to run as a replica set.
+func (oh *OrganizationHandler) WatchOrganization(w http.ResponseWriter, r *http.Request) {
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid id"), http.StatusBadRequest, w)
+		return
+	}
+
+	watched, err := parseWatchedFields(r.URL.Query().Get("fields"))
+	if err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.GetError(errors.New("streaming is not supported"), http.StatusInternalServerError, w)
+		return
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"documentKey._id": pOrgID}}}}
+
+	stream, err := utils.GetCollection(OrganizationCollectionName).Watch(r.Context(), pipeline)
+	if err != nil {
+		utils.GetError(fmt.Errorf("could not watch the organization: %v", err), http.StatusInternalServerError, w)
+		return
+	}
+	defer stream.Close(r.Context())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for stream.Next(r.Context()) {
+		var change organizationChange
+		if err := stream.Decode(&change); err != nil {
+			logger.Error("could not decode a change to organization %s: %v", orgID, err)
+			continue
+		}
+
+		fields, send := change.changedFields(watched)
+		if !send {
+			continue
+		}
+
+		data, err := json.Marshal(utils.M{"operation": change.OperationType, "fields": fields})
+		if err != nil {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", change.OperationType, data); err != nil {
+			return
+		}
+
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,69 @@
+package organizations
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestOrganizationChangeFields(t *testing.T) {
+	update := func(updated bson.M, removed ...string) *organizationChange {
+		change := &organizationChange{OperationType: "update"}
+		change.UpdateDescription.UpdatedFields = updated
+		change.UpdateDescription.RemovedFields = removed
+
+		return change
+	}
+
+	t.Run("test changes to unwatched fields are not sent", func(t *testing.T) {
+		change := update(bson.M{"name": "Zuri", "updated_at": "now"})
+
+		if fields, send := change.changedFields([]string{"logo_url"}); send {
+			t.Errorf("expected the change not to be sent, got %v", fields)
+		}
+	})
+
+	t.Run("test only watched fields are named", func(t *testing.T) {
+		change := update(bson.M{"logo_url": "logo.png", "updated_at": "now"}, "workspace_url")
+
+		fields, send := change.changedFields([]string{"logo_url", "workspace_url"})
+		if !send || !reflect.DeepEqual(fields, []string{"logo_url", "workspace_url"}) {
+			t.Errorf("expected logo_url and workspace_url to be sent, got %v", fields)
+		}
+	})
+
+	t.Run("test nested fields match their parents", func(t *testing.T) {
+		if fields, _ := update(bson.M{"settings.theme": "dark"}).changedFields([]string{"settings"}); len(fields) != 1 {
+			t.Errorf("expected watching settings to see settings.theme, got %v", fields)
+		}
+
+		if fields, _ := update(bson.M{"settings": bson.M{}}).changedFields([]string{"settings.theme"}); len(fields) != 1 {
+			t.Errorf("expected watching settings.theme to see settings replaced, got %v", fields)
+		}
+
+		if _, send := update(bson.M{"settings_version": 2}).changedFields([]string{"settings"}); send {
+			t.Error("expected fields only sharing a prefix not to match")
+		}
+	})
+
+	t.Run("test every change is sent without a filter", func(t *testing.T) {
+		if _, send := update(bson.M{"name": "Zuri"}).changedFields(nil); !send {
+			t.Error("expected the change to be sent")
+		}
+
+		if _, send := (&organizationChange{OperationType: "delete"}).changedFields([]string{"logo_url"}); !send {
+			t.Error("expected deletions to always be sent")
+		}
+	})
+
+	t.Run("test fields are parsed", func(t *testing.T) {
+		if fields, err := parseWatchedFields(" logo_url, name "); err != nil || !reflect.DeepEqual(fields, []string{"logo_url", "name"}) {
+			t.Errorf("expected logo_url and name, got %v, %v", fields, err)
+		}
+
+		if _, err := parseWatchedFields("logo_url,,name"); err == nil {
+			t.Error("expected empty fields to be rejected")
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package organizations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	// the event of a batched delivery, whose body is a JSON array of event payloads
+	WebhookBatchEvent = "batch"
+
+	maxWebhookBatchWindow     = 60 * time.Second
+	maxWebhookBatchSize       = 100
+	webhookBatchCheckInterval = time.Second
+)
+
+func validateWebhookBatchWindow(seconds int) error {
+	if seconds < 0 || time.Duration(seconds)*time.Second > maxWebhookBatchWindow {
+		return fmt.Errorf("batch_window_seconds must be between 0 and %d", int(maxWebhookBatchWindow.Seconds()))
+	}
+
+	return nil
+}
+
+// batchWindow is how long events wait to be sent together, zero when the webhook doesn't batch.
+func (wh *Webhook) batchWindow() time.Duration {
+	return time.Duration(wh.BatchWindowSeconds) * time.Second
+}
+
+// holds a payload back to be sent with the other events of its batch by FlushWebhookBatches.
+func batchWebhook(orgID string, hook *Webhook, event string, payload []byte) error {
+	delivery := newWebhookDelivery(orgID, hook, event, payload)
+	delivery.Status = WebhookDeliveryBatched
+
+	return recordWebhookDelivery(delivery)
+}
+
+type webhookBatchKey struct {
+	orgID, webhookID string
+}
+
+// FlushWebhookBatches sends every batch whose oldest event has waited out its webhook's batch
+// window, and returns how many batches were sent. Batches of paused organizations wait until
+// deliveries resume.
+func FlushWebhookBatches(now time.Time) (int, error) {
+	oldestFirst := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := utils.GetCollection(WebhookDeliveryCollectionName).Find(context.TODO(), bson.M{"status": WebhookDeliveryBatched}, oldestFirst)
+	if err != nil {
+		return 0, err
+	}
+
+	var pending []WebhookDelivery
+	if err = cursor.All(context.TODO(), &pending); err != nil {
+		return 0, err
+	}
+
+	oldest := make(map[webhookBatchKey]time.Time)
+	keys := []webhookBatchKey{}
+
+	for _, delivery := range pending {
+		key := webhookBatchKey{delivery.OrgID, delivery.WebhookID}
+		if _, ok := oldest[key]; !ok {
+			oldest[key] = delivery.CreatedAt
+			keys = append(keys, key)
+		}
+	}
+
+	orgs := make(map[string]*Organization)
+	sent := 0
+
+	for _, key := range keys {
+		org, ok := orgs[key.orgID]
+		if !ok {
+			pOrgID, _ := primitive.ObjectIDFromHex(key.orgID)
+
+			if org, err = FetchOrganization(context.TODO(), bson.M{"_id": pOrgID}); err != nil {
+				logger.Error("could not flush webhook batches of %s: %v", key.orgID, err)
+				continue
+			}
+
+			orgs[key.orgID] = org
+		}
+
+		if org.WebhookPaused {
+			continue
+		}
+
+		hook := org.webhook(key.webhookID)
+		if hook != nil && now.Sub(oldest[key]) < hook.batchWindow() {
+			continue
+		}
+
+		if err := sendWebhookBatch(key.orgID, key.webhookID, hook); err != nil {
+			logger.Error("could not send webhook batch to %s: %v", key.webhookID, err)
+			continue
+		}
+
+		sent++
+	}
+
+	return sent, nil
+}
+
+// sendWebhookBatch claims the oldest batched events of a webhook and sends them in one POST, in
+// the order they were raised. The events are claimed before they are sent, so concurrent
+// flushes never send one twice.
+func sendWebhookBatch(orgID, webhookID string, hook *Webhook) error {
+	coll := utils.GetCollection(WebhookDeliveryCollectionName)
+	oldestFirst := bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+	filter := bson.M{"org_id": orgID, "webhook_id": webhookID, "status": WebhookDeliveryBatched}
+
+	cursor, err := coll.Find(context.TODO(), filter, options.Find().SetSort(oldestFirst).SetLimit(maxWebhookBatchSize).SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+
+	var next []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+
+	if err = cursor.All(context.TODO(), &next); err != nil {
+		return err
+	}
+
+	if len(next) == 0 {
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(next))
+	for _, delivery := range next {
+		ids = append(ids, delivery.ID)
+	}
+
+	claimID := primitive.NewObjectID().Hex()
+
+	_, err = coll.UpdateMany(context.TODO(),
+		bson.M{"_id": bson.M{"$in": ids}, "status": WebhookDeliveryBatched},
+		bson.M{"$set": bson.M{"status": WebhookDeliverySending, "batch_id": claimID}})
+	if err != nil {
+		return err
+	}
+
+	if cursor, err = coll.Find(context.TODO(), bson.M{"batch_id": claimID}, options.Find().SetSort(oldestFirst)); err != nil {
+		return err
+	}
+
+	var claimed []WebhookDelivery
+	if err = cursor.All(context.TODO(), &claimed); err != nil {
+		return err
+	}
+
+	if len(claimed) == 0 {
+		return nil
+	}
+
+	if hook == nil {
+		_, err = utils.UpdateManyMongoDBDocs(context.TODO(), WebhookDeliveryCollectionName, bson.M{"batch_id": claimID},
+			bson.M{"status": WebhookDeliveryFailed, "error": "webhook for this delivery no longer exists"})
+
+		return err
+	}
+
+	payloads := make([]json.RawMessage, 0, len(claimed))
+	for _, delivery := range claimed {
+		payloads = append(payloads, json.RawMessage(delivery.Payload))
+	}
+
+	body, err := json.Marshal(payloads)
+	if err != nil {
+		return err
+	}
+
+	batch := newWebhookDelivery(orgID, hook, WebhookBatchEvent, body)
+	batch.BatchSize = len(claimed)
+
+	sendWebhook(hook, batch)
+
+	if err = recordWebhookDelivery(batch); err != nil {
+		return err
+	}
+
+	_, err = utils.UpdateManyMongoDBDocs(context.TODO(), WebhookDeliveryCollectionName, bson.M{"batch_id": claimID}, bson.M{
+		"batch_id":      batch.ID,
+		"status":        batch.Status,
+		"response_code": batch.ResponseCode,
+		"error":         batch.Error,
+	})
+
+	return err
+}
+
+// RunWebhookBatcher sends webhook batches as their windows close until the context is cancelled.
+func RunWebhookBatcher(ctx context.Context) {
+	ticker := time.NewTicker(webhookBatchCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := FlushWebhookBatches(now); err != nil {
+				logger.Error("could not flush webhook batches: %v", err)
+			}
+		}
+	}
+}
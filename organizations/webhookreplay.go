@@ -0,0 +1,160 @@
+package organizations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/time/rate"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+// most deliveries one replay of a time range can queue
+const maxWebhookReplay = 1000
+
+// webhookReplayRange picks the logged deliveries of an organization to replay.
+type webhookReplayRange struct {
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Events    []string  `json:"events"`
+	WebhookID string    `json:"webhook_id"`
+}
+
+// validate fills in the end of the range and checks it.
+func (rr *webhookReplayRange) validate(now time.Time) error {
+	if rr.From.IsZero() {
+		return errors.New("from is required")
+	}
+
+	if rr.To.IsZero() {
+		rr.To = now
+	}
+
+	if !rr.From.Before(rr.To) {
+		return errors.New("from must be before to")
+	}
+
+	return nil
+}
+
+// filter matches the events that were sent, or failed to be, in the range. Replays and the
+// deliveries of batches are left out, as the events they carry are replayed on their own.
+func (rr *webhookReplayRange) filter(orgID string) bson.M {
+	filter := bson.M{
+		"org_id":     orgID,
+		"created_at": bson.M{"$gte": rr.From, "$lt": rr.To},
+		"status":     bson.M{"$in": []string{WebhookDeliverySucceeded, WebhookDeliveryFailed}},
+		"replay_of":  bson.M{"$exists": false},
+		"event":      bson.M{"$ne": WebhookBatchEvent},
+	}
+
+	if len(rr.Events) > 0 {
+		filter["event"] = bson.M{"$in": rr.Events, "$ne": WebhookBatchEvent}
+	}
+
+	if rr.WebhookID != "" {
+		filter["webhook_id"] = rr.WebhookID
+	}
+
+	return filter
+}
+
+// Re-send the deliveries of an organization logged in a time range, optionally only of some
+// events or one webhook. Replays are queued in the order the events were raised and sent with
+// fresh signatures, a few a second, unless the organization's webhooks are paused.
+func (oh *OrganizationHandler) ReplayWebhookRange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body webhookReplayRange
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if err = body.validate(utils.NowUTC()); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	oldestFirst := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(maxWebhookReplay + 1)
+
+	cursor, err := utils.GetCollection(WebhookDeliveryCollectionName).Find(r.Context(), body.filter(orgID), oldestFirst)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	var deliveries []WebhookDelivery
+	if err = cursor.All(r.Context(), &deliveries); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if len(deliveries) > maxWebhookReplay {
+		utils.GetError(fmt.Errorf("more than %d deliveries in range, narrow it down", maxWebhookReplay), http.StatusBadRequest, w)
+		return
+	}
+
+	replays := make([]interface{}, 0, len(deliveries))
+
+	for i := range deliveries {
+		hook := org.webhook(deliveries[i].WebhookID)
+		if hook == nil {
+			continue
+		}
+
+		replay := newWebhookDelivery(orgID, hook, deliveries[i].Event, []byte(deliveries[i].Payload))
+		replay.ReplayOf = deliveries[i].ID
+		replay.Status = WebhookDeliveryQueued
+
+		replays = append(replays, replay)
+	}
+
+	if len(replays) > 0 {
+		if _, err = utils.GetCollection(WebhookDeliveryCollectionName).InsertMany(r.Context(), replays); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+
+	if len(replays) > 0 && !org.WebhookPaused {
+		var limiter *rate.Limiter
+		if perSecond := oh.configs.WebhookReplayPerSecond; perSecond > 0 {
+			limiter = rate.NewLimiter(rate.Limit(perSecond), 1)
+		}
+
+		// sending can outlast the request, so replays are released in the background
+		go func() {
+			if _, err := releaseQueuedWebhooks(orgID, limiter); err != nil {
+				logger.Error("could not release webhook replays of %s: %v", orgID, err)
+			}
+		}()
+	}
+
+	utils.GetSuccess("webhook deliveries queued for replay", utils.M{
+		"queued":  len(replays),
+		"skipped": len(deliveries) - len(replays),
+	}, w)
+}
@@ -0,0 +1,180 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/utils"
+)
+
+func TestReplayWebhookRange(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+
+	defer server.Close()
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/webhooks", orgs.AddWebhook).Methods("POST")
+	r.HandleFunc("/organizations/{id}/webhooks/pause", orgs.PauseWebhooks).Methods("PATCH")
+	r.HandleFunc("/organizations/{id}/webhooks/deliveries/replay", orgs.ReplayWebhookRange).Methods("POST")
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks", orgID), bytes.NewBufferString(fmt.Sprintf(`{"url": %q}`, server.URL)))
+	assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusOK)
+
+	pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+	org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hook := &org.Webhooks[0]
+	start := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	// logDelivery records a past delivery of an event raised minutes after start.
+	logDelivery := func(t *testing.T, event string, minutes int, edit func(*WebhookDelivery)) {
+		payload := fmt.Sprintf(`{"event": %q, "org_id": %q, "data": {"minute": %d}}`, event, orgID, minutes)
+
+		delivery := newWebhookDelivery(orgID, hook, event, []byte(payload))
+		delivery.Status = WebhookDeliverySucceeded
+		delivery.CreatedAt = start.Add(time.Duration(minutes) * time.Minute)
+
+		if edit != nil {
+			edit(delivery)
+		}
+
+		if err := recordWebhookDelivery(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// logged out of order, to be replayed in the order they were raised
+	logDelivery(t, CreateOrganizationMember, 20, nil)
+	logDelivery(t, DeactivateOrganizationMember, 5, func(d *WebhookDelivery) { d.Status = WebhookDeliveryFailed })
+	logDelivery(t, UpdateOrganizationMemberRole, 10, nil)
+
+	// outside the range, not of the events asked for, replays and batches are not replayed
+	logDelivery(t, CreateOrganizationMember, 90, nil)
+	logDelivery(t, CreateOrganizationMember, -5, nil)
+	logDelivery(t, UpdateOrganizationName, 15, nil)
+	logDelivery(t, CreateOrganizationMember, 25, func(d *WebhookDelivery) { d.ReplayOf = primitive.NewObjectID().Hex() })
+	logDelivery(t, WebhookBatchEvent, 30, func(d *WebhookDelivery) { d.BatchSize = 2 })
+
+	// logged for a webhook since removed
+	logDelivery(t, CreateOrganizationMember, 35, func(d *WebhookDelivery) { d.WebhookID = utils.GenUUID() })
+
+	setPaused := func(paused bool) {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/webhooks/pause", orgID), bytes.NewBufferString(fmt.Sprintf(`{"paused": %t}`, paused)))
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusOK)
+	}
+
+	replay := func(t *testing.T, body string, code int) map[string]interface{} {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks/deliveries/replay", orgID), bytes.NewBufferString(body))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, code)
+
+		data, _ := parseResponse(response)["data"].(map[string]interface{})
+
+		return data
+	}
+
+	events := []string{DeactivateOrganizationMember, UpdateOrganizationMemberRole, CreateOrganizationMember}
+
+	t.Run("test the range is validated", func(t *testing.T) {
+		replay(t, `{}`, http.StatusBadRequest)
+		replay(t, fmt.Sprintf(`{"from": %q, "to": %q}`, start.Format(time.RFC3339), start.Add(-time.Hour).Format(time.RFC3339)), http.StatusBadRequest)
+	})
+
+	t.Run("test matching deliveries are queued in the order they were raised", func(t *testing.T) {
+		setPaused(true)
+
+		body, _ := json.Marshal(utils.M{
+			"from":   start,
+			"to":     start.Add(time.Hour),
+			"events": append(events, WebhookBatchEvent),
+		})
+
+		data := replay(t, string(body), http.StatusOK)
+		if data["queued"] != float64(len(events)) || data["skipped"] != float64(1) {
+			t.Fatalf("expected %d queued and 1 skipped, got %v", len(events), data)
+		}
+
+		opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+		cursor, err := utils.GetCollection(WebhookDeliveryCollectionName).Find(context.TODO(), bson.M{"org_id": orgID, "status": WebhookDeliveryQueued}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var queued []WebhookDelivery
+		if err := cursor.All(context.TODO(), &queued); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(queued) != len(events) {
+			t.Fatalf("expected %d queued replays, got %d", len(events), len(queued))
+		}
+
+		for i, delivery := range queued {
+			if delivery.Event != events[i] || delivery.ReplayOf == "" {
+				t.Errorf("replay %d: expected a replay of %s, got %s replaying %q", i, events[i], delivery.Event, delivery.ReplayOf)
+			}
+		}
+	})
+
+	t.Run("test replays are sent in order with fresh signatures", func(t *testing.T) {
+		setPaused(false)
+
+		received := func() int {
+			receiver.mu.Lock()
+			defer receiver.mu.Unlock()
+
+			return len(receiver.bodies)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for received() < len(events) && time.Now().Before(deadline) {
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+
+		if len(receiver.bodies) != len(events) {
+			t.Fatalf("expected %d replays to be sent, got %d", len(events), len(receiver.bodies))
+		}
+
+		for i, body := range receiver.bodies {
+			var payload struct {
+				Event string `json:"event"`
+			}
+
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Fatal(err)
+			}
+
+			if payload.Event != events[i] {
+				t.Errorf("replay %d: expected event %s, got %s", i, events[i], payload.Event)
+			}
+
+			if receiver.signatures[i] != signWebhookPayload(hook.Secret, body) {
+				t.Errorf("replay %d: expected a signature with the current secret", i)
+			}
+		}
+	})
+}
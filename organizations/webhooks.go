@@ -0,0 +1,695 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/time/rate"
+	"zuri.chat/zccore/logger"
+	"zuri.chat/zccore/utils"
+)
+
+const (
+	WebhookSignatureHeader         = "X-Zuri-Signature"
+	WebhookPreviousSignatureHeader = "X-Zuri-Signature-Previous"
+	WebhookEventHeader             = "X-Zuri-Event"
+	webhookSecretLength            = 32
+
+	// how long the secret replaced by a rotation keeps being accepted
+	webhookSecretRotationWindow = 24 * time.Hour
+
+	maxWebhookConditions = 10
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+type webhookBody struct {
+	URL                string             `json:"url"`
+	Events             []string           `json:"events"`
+	Conditions         []WebhookCondition `json:"conditions"`
+	BatchWindowSeconds int                `json:"batch_window_seconds"`
+}
+
+// signs a webhook payload with the webhook's secret so receivers can verify its origin.
+func signWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// previousSecret returns the secret replaced by the last rotation while it is still accepted.
+func (wh *Webhook) previousSecret(now time.Time) string {
+	if wh.PreviousSecret == "" || wh.PreviousSecretExpiresAt == nil || !now.Before(*wh.PreviousSecretExpiresAt) {
+		return ""
+	}
+
+	return wh.PreviousSecret
+}
+
+// VerifySignature reports whether a payload was signed by the webhook. During a rotation window
+// signatures made with either the current or the previous secret are accepted.
+func (wh *Webhook) VerifySignature(payload []byte, signature string, now time.Time) bool {
+	secrets := []string{wh.Secret}
+	if previous := wh.previousSecret(now); previous != "" {
+		secrets = append(secrets, previous)
+	}
+
+	for _, secret := range secrets {
+		if hmac.Equal([]byte(signWebhookPayload(secret, payload)), []byte(signature)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, webhookSecretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// checks whether a webhook is subscribed to an event; a webhook without events receives everything.
+func (wh *Webhook) subscribedTo(event string) bool {
+	if len(wh.Events) == 0 {
+		return true
+	}
+
+	for _, e := range wh.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// conditions compare payload fields with strings, numbers and booleans only.
+func isWebhookScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, float64, bool:
+		return true
+	}
+
+	return false
+}
+
+// validateWebhookConditions rejects conditions that cannot be evaluated against a payload.
+func validateWebhookConditions(conditions []WebhookCondition) error {
+	if len(conditions) > maxWebhookConditions {
+		return fmt.Errorf("a webhook can have at most %d conditions", maxWebhookConditions)
+	}
+
+	for i, c := range conditions {
+		if c.Field == "" || strings.HasPrefix(c.Field, ".") || strings.HasSuffix(c.Field, ".") || strings.Contains(c.Field, "..") {
+			return fmt.Errorf("condition %d: invalid field %q", i, c.Field)
+		}
+
+		switch {
+		case c.Equals != nil && c.In != nil:
+			return fmt.Errorf("condition %d: set only one of equals and in", i)
+		case c.Equals != nil:
+			if !isWebhookScalar(c.Equals) {
+				return fmt.Errorf("condition %d: equals must be a string, number or boolean", i)
+			}
+		case len(c.In) > 0:
+			for _, value := range c.In {
+				if !isWebhookScalar(value) {
+					return fmt.Errorf("condition %d: in must only hold strings, numbers and booleans", i)
+				}
+			}
+		default:
+			return fmt.Errorf("condition %d: equals or in is required", i)
+		}
+	}
+
+	return nil
+}
+
+// payloadField returns the value at a dotted path of a decoded payload.
+func payloadField(payload map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = payload
+
+	for _, key := range strings.Split(path, ".") {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		if value, ok = fields[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return value, true
+}
+
+// matches reports whether a decoded event payload meets all the webhook's conditions.
+func (wh *Webhook) matches(payload map[string]interface{}) bool {
+	for _, c := range wh.Conditions {
+		value, ok := payloadField(payload, c.Field)
+		if !ok {
+			return false
+		}
+
+		if c.Equals != nil {
+			if value != c.Equals {
+				return false
+			}
+
+			continue
+		}
+
+		found := false
+
+		for _, v := range c.In {
+			if value == v {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// signs and sends a delivery's payload to a webhook, setting the outcome on the delivery.
+func sendWebhook(hook *Webhook, delivery *WebhookDelivery) {
+	delivery.Signature = signWebhookPayload(hook.Secret, []byte(delivery.Payload))
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookEventHeader, delivery.Event)
+		req.Header.Set(WebhookSignatureHeader, delivery.Signature)
+
+		// receivers still on the previous secret can verify until the rotation window ends
+		if previous := hook.previousSecret(utils.NowUTC()); previous != "" {
+			req.Header.Set(WebhookPreviousSignatureHeader, signWebhookPayload(previous, []byte(delivery.Payload)))
+		}
+
+		var resp *http.Response
+
+		if resp, err = webhookClient.Do(req); err == nil {
+			delivery.ResponseCode = resp.StatusCode
+			resp.Body.Close()
+		}
+	}
+
+	switch {
+	case err != nil:
+		delivery.Status = WebhookDeliveryFailed
+		delivery.Error = err.Error()
+	case delivery.ResponseCode >= http.StatusBadRequest:
+		delivery.Status = WebhookDeliveryFailed
+	default:
+		delivery.Status = WebhookDeliverySucceeded
+	}
+}
+
+func newWebhookDelivery(orgID string, hook *Webhook, event string, payload []byte) *WebhookDelivery {
+	return &WebhookDelivery{
+		OrgID:     orgID,
+		WebhookID: hook.ID,
+		URL:       hook.URL,
+		Event:     event,
+		Payload:   string(payload),
+		CreatedAt: utils.NowUTC(),
+	}
+}
+
+// sends a signed payload to a webhook and records the attempt in the webhook deliveries collection.
+func deliverWebhook(orgID string, hook *Webhook, event string, payload []byte, replayOf string) (*WebhookDelivery, error) {
+	delivery := newWebhookDelivery(orgID, hook, event, payload)
+	delivery.ReplayOf = replayOf
+
+	sendWebhook(hook, delivery)
+
+	return delivery, recordWebhookDelivery(delivery)
+}
+
+// queues a payload for a webhook of a paused organization. It is sent when deliveries resume.
+func queueWebhook(orgID string, hook *Webhook, event string, payload []byte) error {
+	delivery := newWebhookDelivery(orgID, hook, event, payload)
+	delivery.Status = WebhookDeliveryQueued
+
+	return recordWebhookDelivery(delivery)
+}
+
+func recordWebhookDelivery(delivery *WebhookDelivery) error {
+	res, err := utils.GetCollection(WebhookDeliveryCollectionName).InsertOne(context.TODO(), delivery)
+	if err != nil {
+		return err
+	}
+
+	delivery.ID = res.InsertedID.(primitive.ObjectID).Hex()
+
+	return nil
+}
+
+// DispatchWebhookEvent delivers an event to every verified webhook of an organization subscribed to it.
+func DispatchWebhookEvent(orgID, event string, data interface{}) {
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return
+	}
+
+	org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+	if err != nil || len(org.Webhooks) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(utils.M{
+		"event":     event,
+		"org_id":    orgID,
+		"data":      data,
+		"timestamp": utils.NowUTC(),
+	})
+	if err != nil {
+		logger.Error("webhook payload for %s could not be encoded: %v", event, err)
+		return
+	}
+
+	// conditions are evaluated against the payload as receivers see it
+	var fields map[string]interface{}
+	if err = json.Unmarshal(payload, &fields); err != nil {
+		logger.Error("webhook payload for %s could not be decoded: %v", event, err)
+		return
+	}
+
+	for i := range org.Webhooks {
+		hook := &org.Webhooks[i]
+		if hook.Unverified || !hook.subscribedTo(event) || !hook.matches(fields) {
+			continue
+		}
+
+		if org.WebhookPaused {
+			if err := queueWebhook(orgID, hook, event, payload); err != nil {
+				logger.Error("webhook delivery to %s could not be queued: %v", hook.URL, err)
+			}
+
+			continue
+		}
+
+		if hook.batchWindow() > 0 {
+			if err := batchWebhook(orgID, hook, event, payload); err != nil {
+				logger.Error("webhook delivery to %s could not be batched: %v", hook.URL, err)
+			}
+
+			continue
+		}
+
+		if _, err := deliverWebhook(orgID, hook, event, payload, ""); err != nil {
+			logger.Error("webhook delivery to %s could not be logged: %v", hook.URL, err)
+		}
+	}
+}
+
+// ReleaseQueuedWebhooks sends the deliveries queued while an organization's webhooks were paused,
+// oldest first, and returns how many were sent. Each queued delivery is claimed before it is sent,
+// so concurrent releases never send one twice. Releasing stops if the webhooks are paused again.
+func ReleaseQueuedWebhooks(orgID string) (int, error) {
+	return releaseQueuedWebhooks(orgID, nil)
+}
+
+// releaseQueuedWebhooks sends an organization's queued deliveries as fast as limiter allows,
+// or as fast as they can be sent when it is nil.
+func releaseQueuedWebhooks(orgID string, limiter *rate.Limiter) (int, error) {
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return 0, errors.New("invalid organization id")
+	}
+
+	coll := utils.GetCollection(WebhookDeliveryCollectionName)
+	oldestFirst := options.FindOneAndUpdate().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	sent := 0
+
+	for {
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			return sent, err
+		}
+
+		if org.WebhookPaused {
+			return sent, nil
+		}
+
+		if limiter != nil {
+			if err = limiter.Wait(context.TODO()); err != nil {
+				return sent, err
+			}
+		}
+
+		var delivery WebhookDelivery
+
+		claim := bson.M{"$set": bson.M{"status": WebhookDeliverySending}}
+
+		err = coll.FindOneAndUpdate(context.TODO(), bson.M{"org_id": orgID, "status": WebhookDeliveryQueued}, claim, oldestFirst).Decode(&delivery)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return sent, nil
+		}
+
+		if err != nil {
+			return sent, err
+		}
+
+		var update bson.M
+
+		if hook := org.webhook(delivery.WebhookID); hook != nil {
+			sendWebhook(hook, &delivery)
+			update = bson.M{"status": delivery.Status, "signature": delivery.Signature, "response_code": delivery.ResponseCode, "error": delivery.Error}
+		} else {
+			update = bson.M{"status": WebhookDeliveryFailed, "error": "webhook for this delivery no longer exists"}
+		}
+
+		if _, err = utils.UpdateOneMongoDBDoc(context.TODO(), WebhookDeliveryCollectionName, delivery.ID, update); err != nil {
+			return sent, err
+		}
+
+		sent++
+	}
+}
+
+// webhook returns the organization's webhook with the given id, or nil.
+func (o *Organization) webhook(id string) *Webhook {
+	for i := range o.Webhooks {
+		if o.Webhooks[i].ID == id {
+			return &o.Webhooks[i]
+		}
+	}
+
+	return nil
+}
+
+// Register a webhook for an organization. Its URL is sent a verification challenge first, and
+// is sent no events unless it answers. The signing secret is only ever returned here.
+func (oh *OrganizationHandler) AddWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var body webhookBody
+	if err = utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if u, perr := url.ParseRequestURI(body.URL); perr != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		utils.GetError(errors.New("invalid webhook url"), http.StatusBadRequest, w)
+		return
+	}
+
+	if err = validateWebhookConditions(body.Conditions); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if err = validateWebhookBatchWindow(body.BatchWindowSeconds); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	hook := Webhook{
+		ID:         primitive.NewObjectID().Hex(),
+		URL:        body.URL,
+		Secret:     secret,
+		Events:     body.Events,
+		Conditions: body.Conditions,
+		CreatedAt:  utils.NowUTC(),
+
+		BatchWindowSeconds: body.BatchWindowSeconds,
+	}
+
+	// the webhook is kept even when the challenge fails, so it can be verified again once fixed
+	verifyErr := verifyWebhook(&hook)
+
+	update, err := utils.GenericUpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, pOrgID, bson.M{"$push": bson.M{"webhooks": hook}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if update.MatchedCount == 0 {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	if verifyErr != nil {
+		utils.GetSuccess("webhook created but not verified, it is sent no events until it is", utils.M{
+			"webhook":            hook,
+			"secret":             secret,
+			"verification_error": verifyErr.Error(),
+		}, w)
+
+		return
+	}
+
+	utils.GetSuccess("webhook created successfully", utils.M{"webhook": hook, "secret": secret}, w)
+}
+
+// Replace the signing secret of a webhook. Payloads are signed with the new secret at once, and
+// the old one is still accepted for a day so receivers can switch over without missing events.
+// The new secret is only ever returned here.
+func (oh *OrganizationHandler) RotateWebhookSecret(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, webhookID := vars["id"], vars["webhook_id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	hook := org.webhook(webhookID)
+	if hook == nil {
+		utils.GetError(fmt.Errorf("webhook %s not found", webhookID), http.StatusNotFound, w)
+		return
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	expiresAt := utils.NowUTC().Add(webhookSecretRotationWindow)
+
+	// matching the secret being replaced keeps a concurrent rotation from being lost
+	update, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": pOrgID, "webhooks": bson.M{"$elemMatch": bson.M{"id": webhookID, "secret": hook.Secret}}},
+		bson.M{"$set": bson.M{
+			"webhooks.$.secret":                     secret,
+			"webhooks.$.previous_secret":            hook.Secret,
+			"webhooks.$.previous_secret_expires_at": expiresAt,
+		}})
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if update.MatchedCount == 0 {
+		utils.GetError(errors.New("webhook secret was rotated at the same time, try again"), http.StatusConflict, w)
+		return
+	}
+
+	utils.GetSuccess("webhook secret rotated successfully", utils.M{
+		"secret":                     secret,
+		"previous_secret_expires_at": expiresAt,
+	}, w)
+}
+
+// Get the webhook deliveries of an organization, optionally filtered by status.
+func (oh *OrganizationHandler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+	filter := bson.M{"org_id": orgID}
+
+	if status := r.URL.Query().Get("status"); status != "" {
+		if status != WebhookDeliverySucceeded && status != WebhookDeliveryFailed && status != WebhookDeliveryQueued {
+			utils.GetError(errors.New("invalid delivery status"), http.StatusBadRequest, w)
+			return
+		}
+
+		filter["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	deliveries, err := utils.GetMongoDBDocs(r.Context(), WebhookDeliveryCollectionName, filter, opts)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("webhook deliveries retrieved successfully", deliveries, w)
+}
+
+// Re-send a past webhook delivery with a fresh signature. The replay is logged as a new delivery.
+func (oh *OrganizationHandler) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, deliveryID := vars["id"], vars["delivery_id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	pDeliveryID, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		utils.GetError(errors.New("invalid delivery id"), http.StatusBadRequest, w)
+		return
+	}
+
+	doc, _ := utils.GetMongoDBDoc(r.Context(), WebhookDeliveryCollectionName, bson.M{"_id": pDeliveryID, "org_id": orgID})
+	if doc == nil {
+		utils.GetError(fmt.Errorf("webhook delivery %s not found", deliveryID), http.StatusNotFound, w)
+		return
+	}
+
+	var delivery WebhookDelivery
+	if err = utils.BsonToStruct(doc, &delivery); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	hook := org.webhook(delivery.WebhookID)
+	if hook == nil {
+		utils.GetError(errors.New("webhook for this delivery no longer exists"), http.StatusNotFound, w)
+		return
+	}
+
+	replay, err := deliverWebhook(orgID, hook, delivery.Event, []byte(delivery.Payload), deliveryID)
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("webhook delivery replayed", replay, w)
+}
+
+// Get the webhooks of an organization, whether their delivery is paused and how many deliveries are queued.
+func (oh *OrganizationHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	queued := utils.CountCollection(r.Context(), WebhookDeliveryCollectionName, bson.M{"org_id": orgID, "status": WebhookDeliveryQueued})
+
+	utils.GetSuccess("webhooks retrieved successfully", utils.M{
+		"webhooks":       org.Webhooks,
+		"webhook_paused": org.WebhookPaused,
+		"queued":         queued,
+	}, w)
+}
+
+// Pause or resume webhook delivery for an organization. Events raised while paused are queued,
+// and resuming sends them in the order they were raised.
+func (oh *OrganizationHandler) PauseWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var body struct {
+		Paused *bool `json:"paused"`
+	}
+
+	if err := utils.ParseJSONFromRequest(r, &body); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if body.Paused == nil {
+		utils.GetError(errors.New("paused is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	if _, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, bson.M{"webhook_paused": *body.Paused}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if *body.Paused {
+		utils.GetSuccess("webhook delivery paused", utils.M{"webhook_paused": true}, w)
+		return
+	}
+
+	// sending can outlast the request, so queued deliveries are released in the background
+	go func() {
+		if _, err := ReleaseQueuedWebhooks(orgID); err != nil {
+			logger.Error("could not release queued webhooks of %s: %v", orgID, err)
+		}
+	}()
+
+	utils.GetSuccess("webhook delivery resumed", utils.M{"webhook_paused": false}, w)
+}
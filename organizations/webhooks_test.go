@@ -0,0 +1,610 @@
+package organizations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+// webhookReceiver is a test endpoint that records what it receives. It answers verification
+// challenges unless ignoreChallenges is set, and does not record them.
+type webhookReceiver struct {
+	mu               sync.Mutex
+	status           int
+	ignoreChallenges bool
+	challenges       int
+	signatures       []string
+	previous         []string
+	bodies           [][]byte
+}
+
+func (wr *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	if r.Header.Get(WebhookEventHeader) == WebhookVerificationEvent {
+		wr.challenges++
+
+		if !wr.ignoreChallenges {
+			var challenge map[string]interface{}
+			json.Unmarshal(body, &challenge)
+			json.NewEncoder(w).Encode(map[string]interface{}{"challenge": challenge["challenge"]})
+		}
+
+		return
+	}
+
+	wr.signatures = append(wr.signatures, r.Header.Get(WebhookSignatureHeader))
+	wr.previous = append(wr.previous, r.Header.Get(WebhookPreviousSignatureHeader))
+	wr.bodies = append(wr.bodies, body)
+
+	w.WriteHeader(wr.status)
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(receiver)
+
+	defer server.Close()
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/webhooks", orgs.AddWebhook).Methods("POST")
+	r.HandleFunc("/organizations/{id}/webhooks/deliveries", orgs.GetWebhookDeliveries).Methods("GET")
+	r.HandleFunc("/organizations/{id}/webhooks/deliveries/{delivery_id}/replay", orgs.ReplayWebhookDelivery).Methods("POST")
+
+	requestBody := []byte(fmt.Sprintf(`{"url": %q, "events": [%q]}`, server.URL, CreateOrganizationMember))
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks", orgID), bytes.NewBuffer(requestBody))
+
+	response := getHTTPResponse(t, r, req)
+	assertStatusCode(t, response.Code, http.StatusOK)
+
+	secret := parseResponse(response)["data"].(map[string]interface{})["secret"].(string)
+
+	var failedID string
+
+	t.Run("test failed delivery is logged", func(t *testing.T) {
+		DispatchWebhookEvent(orgID, CreateOrganizationMember, map[string]interface{}{"member_id": "123"})
+		DispatchWebhookEvent(orgID, DeactivateOrganizationMember, map[string]interface{}{"member_id": "123"})
+
+		if len(receiver.bodies) != 1 {
+			t.Fatalf("expected 1 delivery for the subscribed event, got %d", len(receiver.bodies))
+		}
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/webhooks/deliveries?status=%s", orgID, WebhookDeliveryFailed), nil)
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		deliveries := parseResponse(response)["data"].([]interface{})
+		if len(deliveries) != 1 {
+			t.Fatalf("expected 1 failed delivery, got %d", len(deliveries))
+		}
+
+		delivery := deliveries[0].(map[string]interface{})
+		if delivery["response_code"].(float64) != http.StatusInternalServerError {
+			t.Errorf("expected response code %d, got %v", http.StatusInternalServerError, delivery["response_code"])
+		}
+
+		if delivery["url"] != server.URL || delivery["event"] != CreateOrganizationMember {
+			t.Errorf("delivery not logged with url and event: %v", delivery)
+		}
+
+		failedID = delivery["_id"].(string)
+	})
+
+	t.Run("test replay re-sends a signed delivery", func(t *testing.T) {
+		receiver.status = http.StatusOK
+
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks/deliveries/%s/replay", orgID, failedID), nil)
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		replay := parseResponse(response)["data"].(map[string]interface{})
+		if replay["status"] != WebhookDeliverySucceeded || replay["replay_of"] != failedID {
+			t.Errorf("unexpected replay record: %v", replay)
+		}
+
+		if len(receiver.bodies) != 2 || !bytes.Equal(receiver.bodies[0], receiver.bodies[1]) {
+			t.Fatal("replay did not re-send the original payload")
+		}
+
+		if receiver.signatures[1] != signWebhookPayload(secret, receiver.bodies[1]) {
+			t.Errorf("replay signature %q does not verify", receiver.signatures[1])
+		}
+	})
+
+	t.Run("test unknown delivery cannot be replayed", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks/deliveries/61695d8bb2cc8a9af4833d46/replay", orgID), nil)
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusNotFound)
+	})
+}
+
+func TestWebhookPause(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+
+	defer server.Close()
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/webhooks", orgs.AddWebhook).Methods("POST")
+	r.HandleFunc("/organizations/{id}/webhooks", orgs.GetWebhooks).Methods("GET")
+	r.HandleFunc("/organizations/{id}/webhooks/pause", orgs.PauseWebhooks).Methods("PATCH")
+
+	requestBody := []byte(fmt.Sprintf(`{"url": %q}`, server.URL))
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks", orgID), bytes.NewBuffer(requestBody))
+	assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusOK)
+
+	setPaused := func(paused bool) {
+		req, _ := http.NewRequest("PATCH", fmt.Sprintf("/organizations/%s/webhooks/pause", orgID), bytes.NewBufferString(fmt.Sprintf(`{"paused": %t}`, paused)))
+		assertStatusCode(t, getHTTPResponse(t, r, req).Code, http.StatusOK)
+	}
+
+	received := func() int {
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+
+		return len(receiver.bodies)
+	}
+
+	events := []string{CreateOrganizationMember, UpdateOrganizationMemberRole, DeactivateOrganizationMember}
+
+	t.Run("test deliveries are queued while paused", func(t *testing.T) {
+		setPaused(true)
+
+		for _, event := range events {
+			DispatchWebhookEvent(orgID, event, map[string]interface{}{"member_id": "123"})
+		}
+
+		if n := received(); n != 0 {
+			t.Fatalf("expected no deliveries while paused, got %d", n)
+		}
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/organizations/%s/webhooks", orgID), nil)
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		data := parseResponse(response)["data"].(map[string]interface{})
+		if data["webhook_paused"] != true || data["queued"].(float64) != float64(len(events)) {
+			t.Errorf("expected paused webhooks with %d queued deliveries, got %v", len(events), data)
+		}
+	})
+
+	t.Run("test queued deliveries are sent in order once resumed", func(t *testing.T) {
+		setPaused(false)
+
+		deadline := time.Now().Add(5 * time.Second)
+		for received() < len(events) && time.Now().Before(deadline) {
+			time.Sleep(20 * time.Millisecond)
+		}
+
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+
+		if len(receiver.bodies) != len(events) {
+			t.Fatalf("expected %d deliveries after resuming, got %d", len(events), len(receiver.bodies))
+		}
+
+		for i, body := range receiver.bodies {
+			var payload struct {
+				Event string `json:"event"`
+			}
+
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Fatal(err)
+			}
+
+			if payload.Event != events[i] {
+				t.Errorf("delivery %d: expected event %s, got %s", i, events[i], payload.Event)
+			}
+		}
+	})
+}
+
+func TestWebhookVerifySignature(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	payload := []byte(`{"event": "CreateOrganizationMember"}`)
+
+	hook := &Webhook{Secret: "new-secret", PreviousSecret: "old-secret", PreviousSecretExpiresAt: &expiresAt}
+
+	tests := []struct {
+		name   string
+		secret string
+		at     time.Time
+		want   bool
+	}{
+		{"test current secret verifies", "new-secret", now, true},
+		{"test previous secret verifies during the window", "old-secret", now, true},
+		{"test previous secret is retired after the window", "old-secret", expiresAt, false},
+		{"test current secret verifies after the window", "new-secret", expiresAt, true},
+		{"test other secrets never verify", "some-secret", now, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hook.VerifySignature(payload, signWebhookPayload(tc.secret, payload), tc.at); got != tc.want {
+				t.Errorf("expected verification to be %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRotateWebhookSecret(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+
+	defer server.Close()
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/webhooks", orgs.AddWebhook).Methods("POST")
+	r.HandleFunc("/organizations/{id}/webhooks/{webhook_id}/rotate-secret", orgs.RotateWebhookSecret).Methods("POST")
+
+	requestBody := []byte(fmt.Sprintf(`{"url": %q}`, server.URL))
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks", orgID), bytes.NewBuffer(requestBody))
+
+	response := getHTTPResponse(t, r, req)
+	assertStatusCode(t, response.Code, http.StatusOK)
+
+	data := parseResponse(response)["data"].(map[string]interface{})
+	oldSecret := data["secret"].(string)
+	webhookID := data["webhook"].(map[string]interface{})["id"].(string)
+
+	req, _ = http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks/%s/rotate-secret", orgID, webhookID), nil)
+
+	response = getHTTPResponse(t, r, req)
+	assertStatusCode(t, response.Code, http.StatusOK)
+
+	newSecret := parseResponse(response)["data"].(map[string]interface{})["secret"].(string)
+	if newSecret == oldSecret {
+		t.Fatal("expected a new secret")
+	}
+
+	t.Run("test deliveries are signed with both secrets during the window", func(t *testing.T) {
+		DispatchWebhookEvent(orgID, CreateOrganizationMember, map[string]interface{}{"member_id": "123"})
+
+		if len(receiver.bodies) != 1 {
+			t.Fatalf("expected 1 delivery, got %d", len(receiver.bodies))
+		}
+
+		if receiver.signatures[0] != signWebhookPayload(newSecret, receiver.bodies[0]) {
+			t.Error("expected the delivery to be signed with the new secret")
+		}
+
+		if receiver.previous[0] != signWebhookPayload(oldSecret, receiver.bodies[0]) {
+			t.Error("expected the delivery to carry a signature with the old secret")
+		}
+	})
+
+	t.Run("test the old secret is retired after the window", func(t *testing.T) {
+		pOrgID, _ := primitive.ObjectIDFromHex(orgID)
+
+		_, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(context.TODO(),
+			bson.M{"_id": pOrgID, "webhooks.id": webhookID},
+			bson.M{"$set": bson.M{"webhooks.$.previous_secret_expires_at": time.Now().Add(-time.Second)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		DispatchWebhookEvent(orgID, CreateOrganizationMember, map[string]interface{}{"member_id": "123"})
+
+		if len(receiver.bodies) != 2 {
+			t.Fatalf("expected 2 deliveries, got %d", len(receiver.bodies))
+		}
+
+		if receiver.previous[1] != "" {
+			t.Error("expected no signature with the retired secret")
+		}
+
+		org, err := FetchOrganization(context.TODO(), bson.M{"_id": pOrgID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if org.webhook(webhookID).VerifySignature(receiver.bodies[1], signWebhookPayload(oldSecret, receiver.bodies[1]), time.Now()) {
+			t.Error("expected the retired secret not to verify")
+		}
+	})
+
+	t.Run("test unknown webhooks cannot be rotated", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks/61695d8bb2cc8a9af4833d46/rotate-secret", orgID), nil)
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusNotFound)
+	})
+}
+
+func TestWebhookConditions(t *testing.T) {
+	var payload map[string]interface{}
+	_ = json.Unmarshal([]byte(`{"event": "CreateOrganizationMember", "data": {"role": "admin", "count": 2, "active": true}}`), &payload)
+
+	tests := []struct {
+		name       string
+		conditions []WebhookCondition
+		want       bool
+	}{
+		{"no conditions", nil, true},
+		{"equals matches", []WebhookCondition{{Field: "data.role", Equals: "admin"}}, true},
+		{"equals does not match", []WebhookCondition{{Field: "data.role", Equals: "member"}}, false},
+		{"in matches", []WebhookCondition{{Field: "data.role", In: []interface{}{"owner", "admin"}}}, true},
+		{"in does not match", []WebhookCondition{{Field: "data.role", In: []interface{}{"owner", "guest"}}}, false},
+		{"numbers and booleans match", []WebhookCondition{{Field: "data.count", Equals: float64(2)}, {Field: "data.active", Equals: true}}, true},
+		{"every condition must match", []WebhookCondition{{Field: "data.role", Equals: "admin"}, {Field: "data.count", Equals: float64(3)}}, false},
+		{"missing field does not match", []WebhookCondition{{Field: "data.team", Equals: "design"}}, false},
+		{"path through a value does not match", []WebhookCondition{{Field: "data.role.name", Equals: "admin"}}, false},
+	}
+
+	for _, tc := range tests {
+		hook := Webhook{Conditions: tc.conditions}
+		if got := hook.matches(payload); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestValidateWebhookConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions string
+		wantErr    bool
+	}{
+		{"equals", `[{"field": "data.role", "equals": "admin"}]`, false},
+		{"in", `[{"field": "data.role", "in": ["admin", 1, true]}]`, false},
+		{"missing field", `[{"equals": "admin"}]`, true},
+		{"empty path segment", `[{"field": "data..role", "equals": "admin"}]`, true},
+		{"no operator", `[{"field": "data.role"}]`, true},
+		{"both operators", `[{"field": "data.role", "equals": "admin", "in": ["admin"]}]`, true},
+		{"empty in", `[{"field": "data.role", "in": []}]`, true},
+		{"object value", `[{"field": "data", "equals": {"role": "admin"}}]`, true},
+		{"list in list", `[{"field": "data.role", "in": [["admin"]]}]`, true},
+	}
+
+	for _, tc := range tests {
+		var conditions []WebhookCondition
+		if err := json.Unmarshal([]byte(tc.conditions), &conditions); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := validateWebhookConditions(conditions); (err != nil) != tc.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
+
+func TestWebhookConditionalDelivery(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+
+	defer server.Close()
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/webhooks", orgs.AddWebhook).Methods("POST")
+
+	addWebhook := func(conditions string, expectedCode int) {
+		requestBody := []byte(fmt.Sprintf(`{"url": %q, "events": [%q], "conditions": %s}`, server.URL, CreateOrganizationMember, conditions))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, expectedCode)
+	}
+
+	t.Run("test malformed conditions are rejected", func(t *testing.T) {
+		addWebhook(`[{"field": "data.role", "equals": {"$ne": "admin"}}]`, http.StatusBadRequest)
+	})
+
+	t.Run("test only matching events are delivered", func(t *testing.T) {
+		addWebhook(`[{"field": "data.role", "in": ["admin", "owner"]}]`, http.StatusOK)
+
+		DispatchWebhookEvent(orgID, CreateOrganizationMember, map[string]interface{}{"member_id": "1", "role": "member"})
+		DispatchWebhookEvent(orgID, CreateOrganizationMember, map[string]interface{}{"member_id": "2", "role": "admin"})
+
+		if len(receiver.bodies) != 1 {
+			t.Fatalf("expected 1 delivery for the matching event, got %d", len(receiver.bodies))
+		}
+
+		var delivered map[string]interface{}
+		_ = json.Unmarshal(receiver.bodies[0], &delivered)
+
+		if data, _ := delivered["data"].(map[string]interface{}); data["member_id"] != "2" {
+			t.Errorf("expected the admin's event to be delivered, got %v", delivered)
+		}
+	})
+}
+
+func TestWebhookBatching(t *testing.T) {
+	single, batched := &webhookReceiver{status: http.StatusOK}, &webhookReceiver{status: http.StatusOK}
+	singleServer, batchedServer := httptest.NewServer(single), httptest.NewServer(batched)
+
+	defer singleServer.Close()
+	defer batchedServer.Close()
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/webhooks", orgs.AddWebhook).Methods("POST")
+
+	addWebhook := func(url string, window, expectedCode int) string {
+		requestBody := []byte(fmt.Sprintf(`{"url": %q, "events": [%q], "batch_window_seconds": %d}`, url, CreateOrganizationMember, window))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, expectedCode)
+
+		secret, _ := parseResponse(response)["data"].(map[string]interface{})["secret"].(string)
+
+		return secret
+	}
+
+	addWebhook(batchedServer.URL, 61, http.StatusBadRequest)
+	addWebhook(singleServer.URL, 0, http.StatusOK)
+	secret := addWebhook(batchedServer.URL, 5, http.StatusOK)
+
+	for _, memberID := range []string{"1", "2", "3"} {
+		DispatchWebhookEvent(orgID, CreateOrganizationMember, map[string]interface{}{"member_id": memberID})
+	}
+
+	t.Run("test webhooks without batching get each event", func(t *testing.T) {
+		if len(single.bodies) != 3 {
+			t.Errorf("expected 3 deliveries, got %d", len(single.bodies))
+		}
+	})
+
+	t.Run("test batches wait for their window", func(t *testing.T) {
+		if _, err := FlushWebhookBatches(utils.NowUTC()); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(batched.bodies) != 0 {
+			t.Errorf("expected no deliveries before the window closes, got %d", len(batched.bodies))
+		}
+	})
+
+	t.Run("test batches are sent in one signed post", func(t *testing.T) {
+		if _, err := FlushWebhookBatches(utils.NowUTC().Add(5 * time.Second)); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(batched.bodies) != 1 {
+			t.Fatalf("expected 1 batched delivery, got %d", len(batched.bodies))
+		}
+
+		if batched.signatures[0] != signWebhookPayload(secret, batched.bodies[0]) {
+			t.Errorf("batch signature %q does not verify", batched.signatures[0])
+		}
+
+		var events []map[string]interface{}
+		if err := json.Unmarshal(batched.bodies[0], &events); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(events) != 3 {
+			t.Fatalf("expected 3 events in the batch, got %d", len(events))
+		}
+
+		for i, event := range events {
+			if data, _ := event["data"].(map[string]interface{}); data["member_id"] != fmt.Sprint(i+1) {
+				t.Errorf("expected event %d to be member %d, got %v", i, i+1, data)
+			}
+		}
+
+		batch, _ := utils.GetMongoDBDoc(context.TODO(), WebhookDeliveryCollectionName, bson.M{"org_id": orgID, "event": WebhookBatchEvent})
+		if batch == nil || batch["batch_size"] != int32(3) || batch["status"] != WebhookDeliverySucceeded {
+			t.Fatalf("expected the batch delivery to be logged, got %v", batch)
+		}
+
+		batchID := batch["_id"].(primitive.ObjectID).Hex()
+		if n := utils.CountCollection(context.TODO(), WebhookDeliveryCollectionName, bson.M{"batch_id": batchID, "status": WebhookDeliverySucceeded}); n != 3 {
+			t.Errorf("expected 3 events delivered in batch %s, got %d", batchID, n)
+		}
+	})
+}
+
+func TestWebhookVerification(t *testing.T) {
+	verifying := &webhookReceiver{status: http.StatusOK}
+	silent := &webhookReceiver{status: http.StatusOK, ignoreChallenges: true}
+	verifyingServer, silentServer := httptest.NewServer(verifying), httptest.NewServer(silent)
+
+	defer verifyingServer.Close()
+	defer silentServer.Close()
+
+	orgID, err := setUpOrganization()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := getRouter()
+	r.HandleFunc("/organizations/{id}/webhooks", orgs.AddWebhook).Methods("POST")
+	r.HandleFunc("/organizations/{id}/webhooks/{webhook_id}/verify", orgs.VerifyWebhook).Methods("POST")
+
+	addWebhook := func(url string) map[string]interface{} {
+		requestBody := []byte(fmt.Sprintf(`{"url": %q, "events": [%q]}`, url, CreateOrganizationMember))
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks", orgID), bytes.NewBuffer(requestBody))
+
+		response := getHTTPResponse(t, r, req)
+		assertStatusCode(t, response.Code, http.StatusOK)
+
+		return parseResponse(response)["data"].(map[string]interface{})
+	}
+
+	verified, unverified := addWebhook(verifyingServer.URL), addWebhook(silentServer.URL)
+	silentID := unverified["webhook"].(map[string]interface{})["id"].(string)
+
+	t.Run("test only endpoints that echo the challenge are verified", func(t *testing.T) {
+		if verifying.challenges != 1 || silent.challenges != 1 {
+			t.Fatalf("expected each endpoint to be challenged once, got %d and %d", verifying.challenges, silent.challenges)
+		}
+
+		if hook := verified["webhook"].(map[string]interface{}); hook["unverified"] != nil || hook["verified_at"] == nil {
+			t.Errorf("expected the echoing endpoint to be verified, got %v", hook)
+		}
+
+		if hook := unverified["webhook"].(map[string]interface{}); hook["unverified"] != true || unverified["verification_error"] == nil {
+			t.Errorf("expected the silent endpoint to be unverified with an error, got %v", unverified)
+		}
+	})
+
+	t.Run("test unverified endpoints receive no events", func(t *testing.T) {
+		DispatchWebhookEvent(orgID, CreateOrganizationMember, map[string]interface{}{"member_id": "123"})
+
+		if len(verifying.bodies) != 1 {
+			t.Errorf("expected the verified endpoint to receive 1 event, got %d", len(verifying.bodies))
+		}
+
+		if len(silent.bodies) != 0 {
+			t.Errorf("expected the unverified endpoint to receive no events, got %d", len(silent.bodies))
+		}
+	})
+
+	t.Run("test endpoints can be verified again", func(t *testing.T) {
+		verify := func() *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/organizations/%s/webhooks/%s/verify", orgID, silentID), nil)
+			return getHTTPResponse(t, r, req)
+		}
+
+		assertStatusCode(t, verify().Code, http.StatusUnprocessableEntity)
+
+		silent.ignoreChallenges = false
+		assertStatusCode(t, verify().Code, http.StatusOK)
+
+		DispatchWebhookEvent(orgID, CreateOrganizationMember, map[string]interface{}{"member_id": "456"})
+
+		if len(silent.bodies) != 1 {
+			t.Errorf("expected the re-verified endpoint to receive 1 event, got %d", len(silent.bodies))
+		}
+	})
+}
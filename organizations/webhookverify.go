@@ -0,0 +1,125 @@
+package organizations
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+// WebhookVerificationEvent is the event header of the challenge sent to a webhook URL before it
+// is sent events. The URL must answer with a JSON body echoing the challenge.
+const WebhookVerificationEvent = "webhook.verification"
+
+// the most of a challenge answer that is read
+const maxWebhookChallengeAnswer = 4096
+
+var errWebhookChallengeNotEchoed = errors.New("the challenge was not echoed back")
+
+// verifyWebhookURL sends a signed challenge to a webhook's URL and checks it is echoed back as
+// {"challenge": "..."}, which shows the endpoint is reachable and run by whoever holds the secret.
+func verifyWebhookURL(hook *Webhook) error {
+	challenge := utils.GenUUID()
+
+	payload, err := json.Marshal(utils.M{"event": WebhookVerificationEvent, "challenge": challenge})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, WebhookVerificationEvent)
+	req.Header.Set(WebhookSignatureHeader, signWebhookPayload(hook.Secret, payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("the url answered with status %d", resp.StatusCode)
+	}
+
+	var answer struct {
+		Challenge string `json:"challenge"`
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxWebhookChallengeAnswer)).Decode(&answer); err != nil || answer.Challenge != challenge {
+		return errWebhookChallengeNotEchoed
+	}
+
+	return nil
+}
+
+// verifyWebhook challenges a webhook's URL and sets whether it is verified on the hook.
+func verifyWebhook(hook *Webhook) error {
+	err := verifyWebhookURL(hook)
+	if err != nil {
+		hook.Unverified, hook.VerifiedAt = true, nil
+		return err
+	}
+
+	now := utils.NowUTC()
+	hook.Unverified, hook.VerifiedAt = false, &now
+
+	return nil
+}
+
+// Challenge a webhook's URL again, e.g. after fixing the endpoint or moving it. The webhook is
+// sent events only while its last challenge was answered.
+func (oh *OrganizationHandler) VerifyWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	orgID, webhookID := vars["id"], vars["webhook_id"]
+
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		utils.GetError(errors.New("invalid organization id"), http.StatusBadRequest, w)
+		return
+	}
+
+	org, err := FetchOrganization(r.Context(), bson.M{"_id": pOrgID})
+	if err != nil {
+		utils.GetError(fmt.Errorf("organization %s not found", orgID), http.StatusNotFound, w)
+		return
+	}
+
+	hook := org.webhook(webhookID)
+	if hook == nil {
+		utils.GetError(fmt.Errorf("webhook %s not found", webhookID), http.StatusNotFound, w)
+		return
+	}
+
+	verifyErr := verifyWebhook(hook)
+
+	update := bson.M{"$set": bson.M{"webhooks.$.unverified": true}, "$unset": bson.M{"webhooks.$.verified_at": ""}}
+	if verifyErr == nil {
+		update = bson.M{"$set": bson.M{"webhooks.$.verified_at": hook.VerifiedAt}, "$unset": bson.M{"webhooks.$.unverified": ""}}
+	}
+
+	if _, err := utils.GetCollection(OrganizationCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": pOrgID, "webhooks.id": webhookID}, update); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if verifyErr != nil {
+		utils.GetError(fmt.Errorf("webhook could not be verified: %v", verifyErr), http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	utils.GetSuccess("webhook verified successfully", hook, w)
+}
@@ -0,0 +1,40 @@
+package organizations
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"zuri.chat/zccore/utils"
+)
+
+// Set the hours an organization works each weekday, in its time zone. Notifications and digests
+// can hold off outside of them.
+func (oh *OrganizationHandler) UpdateWorkingHours(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	orgID := mux.Vars(r)["id"]
+
+	if err := ValidateOrg(r.Context(), orgID); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	var hours utils.WorkingHours
+	if err := utils.ParseJSONFromRequest(r, &hours); err != nil {
+		utils.GetError(err, http.StatusUnprocessableEntity, w)
+		return
+	}
+
+	if err := utils.ValidateWorkingHours(&hours); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if _, err := utils.UpdateOneMongoDBDoc(r.Context(), OrganizationCollectionName, orgID, bson.M{"working_hours": hours}); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	utils.GetSuccess("working hours updated successfully", hours, w)
+}
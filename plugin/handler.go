@@ -77,7 +77,13 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	newPlugin.Approved = true
 	newPlugin.ApprovedAt = time.Now().String()
 
-	if err := h.Service.Create(r.Context(), newPlugin); err != nil {
+	// a plugin with the same template url can still be created between the check above and now
+	if err := h.Service.Create(r.Context(), newPlugin); ErrorCode(err) == EDUPLICATE {
+		h.errorResponse(w, http.StatusConflict, ErrorMessage(err))
+		LogError(err)
+
+		return
+	} else if err != nil {
 		h.errorResponse(w, http.StatusInternalServerError, ErrorMessage(err))
 		LogError(err)
 
@@ -63,7 +63,7 @@ func FindPluginByID(ctx context.Context, id string) (*Plugin, error) {
 	}
 
 
-	res, err := utils.GetMongoDBDoc(PluginCollectionName, bson.M{"_id": objID, "deleted": false})
+	res, err := utils.GetMongoDBDoc(ctx, PluginCollectionName, bson.M{"_id": objID, "deleted": false})
 
 	if err != nil {
 		return nil, err
@@ -93,7 +93,7 @@ func FindPlugins(ctx context.Context, filter bson.M, opts ...*options.FindOption
 	ps := []*Plugin{}
 
 
-	cursor, err := utils.GetMongoDBDocs(PluginCollectionName, filter, opts...)
+	cursor, err := utils.GetMongoDBDocs(ctx, PluginCollectionName, filter, opts...)
 
 
 	if err != nil {
@@ -153,7 +153,7 @@ func FindPluginByTemplateURL(ctx context.Context, url string) (*Plugin, error) {
 		bp *Plugin
 	)
 
-	res, err := utils.GetMongoDBDoc(PluginCollectionName, bson.M{"deleted": false, "template_url": url})
+	res, err := utils.GetMongoDBDoc(ctx, PluginCollectionName, bson.M{"deleted": false, "template_url": url})
 
 	if err != nil {
 		return nil, err
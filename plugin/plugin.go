@@ -30,7 +30,7 @@ func SyncUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pluginDetails, _ := utils.GetMongoDBDoc(PluginCollectionName, bson.M{"_id": ppID})
+	pluginDetails, _ := utils.GetMongoDBDoc(r.Context(), PluginCollectionName, bson.M{"_id": ppID})
 
 	if pluginDetails == nil {
 		utils.GetError(errors.WithMessage(fmt.Errorf("plugin not found"), "error processing request"), http.StatusUnprocessableEntity, w)
@@ -59,7 +59,7 @@ func SyncUpdate(w http.ResponseWriter, r *http.Request) {
 	updateFields := make(map[string]interface{})
 
 	updateFields["queue"] = splugin.Queue
-	_, ee := utils.UpdateOneMongoDBDoc(PluginCollectionName, mux.Vars(r)["id"], updateFields)
+	_, ee := utils.UpdateOneMongoDBDoc(r.Context(), PluginCollectionName, mux.Vars(r)["id"], updateFields)
 
 	if ee != nil {
 		utils.GetError(ee, http.StatusInternalServerError, w)
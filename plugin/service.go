@@ -7,6 +7,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"zuri.chat/zccore/utils"
 )
 
 type Service interface {
@@ -27,6 +28,10 @@ func (m *mongoService) Create(ctx context.Context, p *Plugin) error {
 	db := m.database()
 	res, err := db.Collection("plugins").InsertOne(ctx, p)
 	
+	if utils.IsDuplicateKeyError(err) {
+		return Errorf(EDUPLICATE, "plugin exists")
+	}
+
 	if err != nil {
 		return err
 	}
@@ -75,7 +75,7 @@ func Auth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := utils.GetMongoDBDoc(conf.UserDBCollection, bson.M{"email": userEmail})
+	user, err := utils.GetMongoDBDoc(r.Context(), conf.UserDBCollection, bson.M{"email": userEmail})
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
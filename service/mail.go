@@ -6,7 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"mime"
+	netmail "net/mail"
 	"net/smtp"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -58,14 +62,103 @@ type Mail struct {
 	customTmpl bool
 	mtype      MailType
 	data       map[string]interface{}
+	locale     string
+	fromName   string
+	replyTo    string
+}
+
+// DefaultSenderName is the display name of mails not sent on behalf of an organization.
+const DefaultSenderName = "Zuri Chat"
+
+// WithSender sets the display name and reply-to address of a mail sent on behalf of an
+// organization. The mail is still sent from our own address so it gets delivered.
+func (m *Mail) WithSender(name, replyTo string) *Mail {
+	// line breaks would let a name or address add headers of its own
+	strip := strings.NewReplacer("\r", "", "\n", "")
+
+	m.fromName = strings.TrimSpace(strip.Replace(name))
+	m.replyTo = strings.TrimSpace(strip.Replace(replyTo))
+
+	return m
+}
+
+// Sender returns the display name and reply-to address the mail is sent with. The name is
+// DefaultSenderName unless one was set, and the reply-to address is empty unless one was set.
+func (m *Mail) Sender() (name, replyTo string) {
+	if m.fromName == "" {
+		return DefaultSenderName, m.replyTo
+	}
+
+	return m.fromName, m.replyTo
+}
+
+// smtpHeaders are the From and Reply-To headers of the mail sent from address.
+func (m *Mail) smtpHeaders(address string) string {
+	name, replyTo := m.Sender()
+
+	headers := fmt.Sprintf("From: %s <%s>\n", mime.QEncoding.Encode("utf-8", name), address)
+	if replyTo != "" {
+		headers += fmt.Sprintf("Reply-To: %s\n", replyTo)
+	}
+
+	return headers
+}
+
+// mailgunSender is the sender of the mail through Mailgun, whose configured sender carries our
+// display name, such as "Zuri Chat <hello@zuri.chat>". Mails sent on behalf of an organization
+// get its name instead.
+func (m *Mail) mailgunSender(configured string) string {
+	if m.fromName == "" {
+		return configured
+	}
+
+	sender, err := netmail.ParseAddress(configured)
+	if err != nil {
+		return configured
+	}
+
+	sender.Name = m.fromName
+
+	return sender.String()
+}
+
+// WithLocale sets the locale the mail's template is written in. Templates not translated into
+// it are sent in English.
+func (m *Mail) WithLocale(locale string) *Mail {
+	m.locale = locale
+	return m
+}
+
+// localizedTemplate returns the translation of a template into locale, kept in a directory named
+// after the locale next to the English template, e.g. templates/fr/workspace_invite.html. A
+// regional locale such as pt-br falls back to its language, and then to English.
+func localizedTemplate(fileName, locale string) string {
+	locale = strings.ToLower(locale)
+
+	for locale != "" && locale != utils.EnglishLocale {
+		localized := filepath.Join(filepath.Dir(fileName), locale, filepath.Base(fileName))
+		if info, err := os.Stat(localized); err == nil && !info.IsDir() {
+			return localized
+		}
+
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+
+		locale = locale[:i]
+	}
+
+	return fileName
 }
 
 type ZcMailService struct {
 	configs *utils.Configurations
+	limiter *MailRateLimiter
 }
 
 func NewZcMailService(c *utils.Configurations) *ZcMailService {
-	return &ZcMailService{configs: c}
+	return &ZcMailService{configs: c, limiter: NewMailRateLimiter(c.MailRatePerMinute, c.MailPriorityReserve)}
 }
 
 // Gmail smtp setup
@@ -87,7 +180,7 @@ func (ms *ZcMailService) LoadTemplate(mailReq *Mail) (string, error) {
 		return "", errors.New("invalid email type, email template does not exists! ")
 	}
 
-	t, err := template.ParseFiles(templateFileName)
+	t, err := template.ParseFiles(localizedTemplate(templateFileName, mailReq.locale))
 	if err != nil {
 		return "", err
 	}
@@ -122,6 +215,11 @@ func (ms *ZcMailService) SendMail(mailReq *Mail) error {
 		return fmt.Errorf("email %s verification failed", mailReq.to[0])
 	}
 
+	if !ms.limiter.Allow(priorityMailTypes[mailReq.mtype]) {
+		logger.Warn("outbound mail rate limit reached, dropped %q to %v", mailReq.subject, mailReq.to)
+		return ErrMailRateLimited
+	}
+
 	switch esp := strings.ToLower(ms.configs.ESPType); esp {
 	case "sendgrid":
 		// SENDGRID
@@ -148,12 +246,17 @@ func (ms *ZcMailService) SendMail(mailReq *Mail) error {
 		x, a := mailReq.to[0], mailReq.to[1:]
 		reziever := strings.Split(x, "@")
 
-		from := mail.NewEmail("Zuri Chat", ms.configs.SendgridEmail)
+		senderName, replyTo := mailReq.Sender()
+
+		from := mail.NewEmail(senderName, ms.configs.SendgridEmail)
 		to := mail.NewEmail(reziever[0], x)
 
 		content := mail.NewContent("text/html", body)
 
 		m := mail.NewV3MailInit(from, mailReq.subject, to, content)
+		if replyTo != "" {
+			m.SetReplyTo(mail.NewEmail("", replyTo))
+		}
 
 		if len(a) > 0 {
 			tos := make([]*mail.Email, 0)
@@ -197,7 +300,7 @@ func (ms *ZcMailService) SendMail(mailReq *Mail) error {
 			"work.timbu.cloud",
 		)
 
-		from := fmt.Sprintf("From: Zuri Chat <%s>\n", ms.configs.SMTPUsername)
+		from := mailReq.smtpHeaders(ms.configs.SMTPUsername)
 		subject := fmt.Sprintf("Subject: %s\n", mailReq.subject)
 		mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
 		msg := []byte(from + subject + mime + body)
@@ -224,9 +327,13 @@ func (ms *ZcMailService) SendMail(mailReq *Mail) error {
 		}
 
 		mg := mailgun.NewMailgun(ms.configs.MailGunDomain, ms.configs.MailGunKey)
-		message := mg.NewMessage(ms.configs.MailGunSenderEmail, mailReq.subject, "", mailReq.to...)
+		message := mg.NewMessage(mailReq.mailgunSender(ms.configs.MailGunSenderEmail), mailReq.subject, "", mailReq.to...)
 		message.SetHtml(body)
 
+		if _, replyTo := mailReq.Sender(); replyTo != "" {
+			message.SetReplyTo(replyTo)
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*mgTen)
 		defer cancel()
 
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"zuri.chat/zccore/utils"
+)
+
+func TestLoadTemplateLocale(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("workspace_invite.html", "Join {{.OrgName}}")
+	write("fr/workspace_invite.html", "Rejoignez {{.OrgName}}")
+	write("pt/workspace_invite.html", "Junte-se a {{.OrgName}}")
+
+	ms := NewZcMailService(&utils.Configurations{WorkSpaceInviteTemplate: filepath.Join(dir, "workspace_invite.html")})
+
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"", "Join Acme"},
+		{"en", "Join Acme"},
+		{"fr", "Rejoignez Acme"},
+		{"pt-br", "Junte-se a Acme"},
+		{"de", "Join Acme"},
+	}
+
+	for _, tc := range tests {
+		mail := ms.NewMail([]string{"member@gmail.com"}, "Invite", WorkSpaceInvite, map[string]interface{}{"OrgName": "Acme"}).WithLocale(tc.locale)
+
+		body, err := ms.LoadTemplate(mail)
+		if err != nil {
+			t.Fatalf("locale %q: %v", tc.locale, err)
+		}
+
+		if body != tc.want {
+			t.Errorf("locale %q: expected %q, got %q", tc.locale, tc.want, body)
+		}
+	}
+}
+
+func TestMailSender(t *testing.T) {
+	ms := NewZcMailService(&utils.Configurations{})
+
+	plain := ms.NewCustomMail([]string{"member@gmail.com"}, "Hello", "Hi")
+	if name, replyTo := plain.Sender(); name != DefaultSenderName || replyTo != "" {
+		t.Errorf("expected our own name and no reply-to by default, got %q %q", name, replyTo)
+	}
+
+	branded := ms.NewCustomMail([]string{"member@gmail.com"}, "Hello", "Hi").WithSender("Acme\r\nBcc: x@evil.com", "hr@acme.com")
+
+	headers := branded.smtpHeaders("noreply@zuri.chat")
+	if want := "From: AcmeBcc: x@evil.com <noreply@zuri.chat>\nReply-To: hr@acme.com\n"; headers != want {
+		t.Errorf("expected headers %q, got %q", want, headers)
+	}
+
+	if sender := branded.mailgunSender("Zuri Chat <hello@zuri.chat>"); sender != `"AcmeBcc: x@evil.com" <hello@zuri.chat>` {
+		t.Errorf("expected the organization's name on our address, got %q", sender)
+	}
+
+	if sender := plain.mailgunSender("Zuri Chat <hello@zuri.chat>"); sender != "Zuri Chat <hello@zuri.chat>" {
+		t.Errorf("expected the configured sender, got %q", sender)
+	}
+}
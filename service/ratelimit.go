@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrMailRateLimited is returned by SendMail when outbound mail is at its cap and the mail was
+// dropped.
+var ErrMailRateLimited = errors.New("outbound mail rate limit reached, mail not sent")
+
+// priorityMailTypes are the transactional mails people are waiting on. They may use the part of
+// the rate held back from other mails.
+var priorityMailTypes = map[MailType]bool{
+	MailConfirmation: true,
+	PasswordReset:    true,
+}
+
+// MailRateLimiter caps how many mails are sent in any minute, across everything sending mail,
+// to protect the reputation of our sending address. Part of the cap can be kept for priority
+// mails so bulk mail cannot crowd them out.
+type MailRateLimiter struct {
+	perMinute int
+	reserved  int
+
+	mu   sync.Mutex
+	sent []time.Time
+
+	now func() time.Time
+}
+
+// NewMailRateLimiter allows perMinute mails a minute, reserved of which only priority mails may
+// use. Zero per minute does not limit mail.
+func NewMailRateLimiter(perMinute, reserved int) *MailRateLimiter {
+	return &MailRateLimiter{perMinute: perMinute, reserved: reserved, now: time.Now}
+}
+
+// Allow counts a mail about to be sent and reports whether it is within the cap.
+func (l *MailRateLimiter) Allow(priority bool) bool {
+	if l == nil || l.perMinute <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	// forget mails sent over a minute ago
+	recent := 0
+	for recent < len(l.sent) && now.Sub(l.sent[recent]) >= time.Minute {
+		recent++
+	}
+
+	l.sent = l.sent[recent:]
+
+	limit := l.perMinute
+	if !priority {
+		limit -= l.reserved
+	}
+
+	if len(l.sent) >= limit {
+		return false
+	}
+
+	l.sent = append(l.sent, now)
+
+	return true
+}
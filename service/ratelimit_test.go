@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMailRateLimiter(t *testing.T) {
+	now := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	limiter := NewMailRateLimiter(5, 2)
+	limiter.now = func() time.Time { return now }
+
+	// send tries to send count mails and returns how many were allowed.
+	send := func(count int, priority bool) int {
+		allowed := 0
+
+		for i := 0; i < count; i++ {
+			if limiter.Allow(priority) {
+				allowed++
+			}
+		}
+
+		return allowed
+	}
+
+	if allowed := send(10, false); allowed != 3 {
+		t.Errorf("expected bulk mail to stop short of the reserve at 3, got %d", allowed)
+	}
+
+	if allowed := send(10, true); allowed != 2 {
+		t.Errorf("expected priority mail to use the 2 reserved, got %d", allowed)
+	}
+
+	now = now.Add(30 * time.Second)
+
+	if allowed := send(1, true); allowed != 0 {
+		t.Error("expected the cap to hold for the rest of the minute")
+	}
+
+	now = now.Add(30 * time.Second)
+
+	if allowed := send(10, false); allowed != 3 {
+		t.Errorf("expected the cap to reset a minute later, got %d", allowed)
+	}
+
+	if !NewMailRateLimiter(0, 0).Allow(false) {
+		t.Error("expected no cap at zero per minute")
+	}
+}
@@ -41,7 +41,6 @@ const (
 )
 
 var (
-	res              []MultipleTempResponse
 	permissionNumber fs.FileMode = 0777
 	mg32             int64       = 32
 	mg20             int64       = 20
@@ -103,6 +102,8 @@ func MultipleFileUpload(folderName string, r *http.Request) ([]MultipleTempRespo
 		return nil, fmt.Errorf("method not allowed")
 	}
 
+	var res []MultipleTempResponse
+
 	if err := r.ParseMultipartForm(mg32 << mg20); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,104 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+// avatarColors are the backgrounds of generated avatars, all dark enough for white initials.
+var avatarColors = []string{
+	"#1A73E8", "#00796B", "#C2185B", "#7B1FA2", "#5D4037", "#E64A19",
+	"#303F9F", "#0097A7", "#689F38", "#AFB42B", "#F57C00", "#455A64",
+}
+
+// avatarColor picks the background of a generated avatar from the user's email, so it stays
+// the same for them wherever it is drawn.
+func avatarColor(email string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+
+	return avatarColors[h.Sum32()%uint32(len(avatarColors))]
+}
+
+// firstLetter returns the first letter or digit of s in upper case, or "".
+func firstLetter(s string) string {
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return string(unicode.ToUpper(r))
+		}
+	}
+
+	return ""
+}
+
+// avatarInitials are the initials of the user's names, or the first letter of their email
+// when they have no names.
+func avatarInitials(firstName, lastName, email string) string {
+	if initials := firstLetter(firstName) + firstLetter(lastName); initials != "" {
+		return initials
+	}
+
+	if initial := firstLetter(email); initial != "" {
+		return initial
+	}
+
+	return "?"
+}
+
+// defaultAvatar draws the user's initials on their color as an SVG image.
+func defaultAvatar(firstName, lastName, email string) []byte {
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="128" height="128" viewBox="0 0 128 128">`+
+		`<rect width="128" height="128" fill="%s"/>`+
+		`<text x="50%%" y="50%%" dy=".35em" fill="#FFFFFF" font-family="Helvetica, Arial, sans-serif" font-size="52" text-anchor="middle">%s</text>`+
+		`</svg>`, avatarColor(email), html.EscapeString(avatarInitials(firstName, lastName, email))))
+}
+
+// validAvatarURL reports whether an avatar can be loaded from s.
+func validAvatarURL(s string) bool {
+	u, err := url.ParseRequestURI(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Get a user's avatar. Users who set one are redirected to it, the others get their initials
+// on a color of their own as an SVG image.
+func (uh *UserHandler) GetUserAvatar(w http.ResponseWriter, r *http.Request) {
+	objID, err := primitive.ObjectIDFromHex(mux.Vars(r)["user_id"])
+	if err != nil {
+		utils.GetError(errors.New("invalid user id"), http.StatusBadRequest, w)
+		return
+	}
+
+	doc, err := utils.GetMongoDBDoc(r.Context(), UserCollectionName, bson.M{"_id": objID, "deactivated": false})
+	if err != nil || doc == nil {
+		utils.GetError(errors.New("user not found"), http.StatusNotFound, w)
+		return
+	}
+
+	// read field by field, as older users have dates stored as strings
+	field := func(key string) string {
+		s, _ := doc[key].(string)
+		return s
+	}
+
+	if avatarURL := field("avatar_url"); avatarURL != "" {
+		http.Redirect(w, r, avatarURL, http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = w.Write(defaultAvatar(field("first_name"), field("last_name"), field("email")))
+}
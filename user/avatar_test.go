@@ -0,0 +1,117 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/utils"
+)
+
+func TestDefaultAvatar(t *testing.T) {
+	t.Run("test the same email always gets the same avatar", func(t *testing.T) {
+		first := defaultAvatar("Ada", "Lovelace", "ada@zuri.chat")
+
+		for i := 0; i < 5; i++ {
+			if !bytes.Equal(first, defaultAvatar("Ada", "Lovelace", "ada@zuri.chat")) {
+				t.Fatal("expected the same avatar for the same user")
+			}
+		}
+
+		if avatarColor("Ada@Zuri.chat ") != avatarColor("ada@zuri.chat") {
+			t.Error("expected the color not to depend on the case of the email")
+		}
+	})
+
+	t.Run("test colors vary across users", func(t *testing.T) {
+		colors := map[string]bool{}
+		for i := 0; i < 50; i++ {
+			colors[avatarColor(fmt.Sprintf("user%d@zuri.chat", i))] = true
+		}
+
+		if len(colors) < len(avatarColors)/2 {
+			t.Errorf("expected users to be spread over the colors, got %d of them", len(colors))
+		}
+	})
+
+	t.Run("test initials", func(t *testing.T) {
+		cases := []struct{ first, last, email, want string }{
+			{"ada", "lovelace", "ada@zuri.chat", "AL"},
+			{"Émile", "", "emile@zuri.chat", "É"},
+			{"", "", "zoe@zuri.chat", "Z"},
+			{"", "", "", "?"},
+		}
+
+		for _, c := range cases {
+			if got := avatarInitials(c.first, c.last, c.email); got != c.want {
+				t.Errorf("expected initials %q for %q %q %q, got %q", c.want, c.first, c.last, c.email, got)
+			}
+		}
+
+		if svg := string(defaultAvatar("<b>", "", "x@zuri.chat")); strings.Contains(svg, "<b>") {
+			t.Error("expected names to be escaped")
+		}
+	})
+}
+
+func TestGetUserAvatar(t *testing.T) {
+	uh := NewUserHandler(configs, noopMailService{})
+
+	r := mux.NewRouter()
+	r.HandleFunc("/users/{user_id}/avatar", uh.GetUserAvatar).Methods("GET")
+
+	setUp := func(t *testing.T, fields bson.M) string {
+		fields["email"] = fmt.Sprintf("avatar.%s@zuri.chat", utils.GenUUID())
+		fields["deactivated"] = false
+
+		res, err := utils.GetCollection(UserCollectionName).InsertOne(context.TODO(), fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res.InsertedID.(primitive.ObjectID).Hex()
+	}
+
+	get := func(id string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/users/%s/avatar", id), nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	t.Run("test users without an avatar get their initials", func(t *testing.T) {
+		id := setUp(t, bson.M{"first_name": "Grace", "last_name": "Hopper"})
+
+		first, second := get(id), get(id)
+		if first.Code != http.StatusOK || first.Header().Get("Content-Type") != "image/svg+xml" {
+			t.Fatalf("expected an SVG avatar, got %d %s", first.Code, first.Header().Get("Content-Type"))
+		}
+
+		if !strings.Contains(first.Body.String(), ">GH<") || first.Body.String() != second.Body.String() {
+			t.Errorf("expected the same avatar with the initials GH, got %s", first.Body.String())
+		}
+	})
+
+	t.Run("test avatars users set take precedence", func(t *testing.T) {
+		id := setUp(t, bson.M{"first_name": "Grace", "avatar_url": "https://cdn.zuri.chat/grace.png"})
+
+		rr := get(id)
+		if rr.Code != http.StatusFound || rr.Header().Get("Location") != "https://cdn.zuri.chat/grace.png" {
+			t.Errorf("expected a redirect to the avatar set, got %d %s", rr.Code, rr.Header().Get("Location"))
+		}
+	})
+
+	t.Run("test unknown users are not found", func(t *testing.T) {
+		if rr := get(primitive.NewObjectID().Hex()); rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+	})
+}
@@ -71,6 +71,13 @@ type UserPasswordReset struct {
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 }
 
+// UserEmailChange is a change of email waiting on the new address to be verified.
+type UserEmailChange struct {
+	Email     string    `bson:"email" json:"email"`
+	Token     string    `bson:"token" json:"-"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
 type Social struct {
 	ID       string `bson:"provider_id" json:"provider_id"`
 	Provider string `bson:"provider" json:"provider"`
@@ -95,6 +102,27 @@ type User struct {
 	Organizations     []string               `bson:"workspaces" json:"workspaces"` // should contain (organization) workspace ids
 	EmailVerification *UserEmailVerification `bson:"email_verification" json:"email_verification"`
 	PasswordResets    *UserPasswordReset     `bson:"password_resets" json:"password_resets"` // remove the array
+
+	LastLoginAt *time.Time `bson:"last_login_at,omitempty" json:"last_login_at,omitempty"`
+
+	// provisioned users were created on their behalf, e.g. with an organization created in their
+	// name, rather than by signing up
+	Provisioned bool `bson:"provisioned,omitempty" json:"provisioned,omitempty"`
+
+	// deleted users can be restored until the retention window runs out, after which their
+	// personal data is purged
+	PurgedAt *time.Time `bson:"purged_at,omitempty" json:"purged_at,omitempty"`
+
+	// a change of email only takes effect once the new address is verified, the current one
+	// keeps working until then
+	EmailChange *UserEmailChange `bson:"email_change,omitempty" json:"email_change,omitempty"`
+
+	// ids of the organizations the user pinned, in the order they were pinned. Pinned
+	// organizations are listed first
+	PinnedOrganizations []string `bson:"pinned_organizations,omitempty" json:"pinned_organizations,omitempty"`
+
+	// an avatar the user set, users without one get their initials drawn for them
+	AvatarURL string `bson:"avatar_url,omitempty" json:"avatar_url,omitempty"`
 }
 
 // Struct that user can update directly.
@@ -103,6 +131,7 @@ type UserUpdate struct {
 	FirstName string `bson:"first_name" validate:"required,min=2,max=100" json:"first_name"`
 	LastName  string `bson:"last_name" validate:"required,min=2,max=100" json:"last_name"`
 	Phone     string `bson:"phone" validate:"required" json:"phone"`
+	AvatarURL string `bson:"avatar_url" json:"avatar_url"`
 }
 
 //nolint:revive //changing name will break a lot of codes
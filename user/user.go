@@ -1,10 +1,12 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,16 +19,26 @@ import (
 )
 
 var (
-	errEmailNotValid = errors.New("email address is not valid")
-	errHashingFailed = errors.New("failed to hashed password")
+	errEmailNotValid   = errors.New("email address is not valid")
+	errHashingFailed   = errors.New("failed to hashed password")
+	errRetentionPassed = errors.New("user was deleted too long ago to be restored")
 )
 
+// signUpFields are the fields a request creating a user can set. Everything else, such as their
+// role or organizations, is set by Create or later on and dropped from the request.
+var signUpFields = []string{"first_name", "last_name", "email", "password", "phone", "settings"}
+
 // An end point to create new users.
 func (uh *UserHandler) Create(response http.ResponseWriter, request *http.Request) {
 	response.Header().Add("content-type", "application/json")
 
+	if err := utils.CheckJSONContentType(request, uh.configs.ContentTypeEnforcement); err != nil {
+		utils.GetError(err, http.StatusUnsupportedMediaType, response)
+		return
+	}
+
 	var user User
-	if err := utils.ParseJSONFromRequest(request, &user); err != nil {
+	if _, err := utils.ParseAllowedJSONFromRequest(request, &user, signUpFields...); err != nil {
 		utils.GetError(err, http.StatusUnprocessableEntity, response)
 		return
 	}
@@ -42,17 +54,10 @@ func (uh *UserHandler) Create(response http.ResponseWriter, request *http.Reques
 	if err := validate.Struct(user); err != nil {
 		utils.GetError(err, http.StatusBadRequest, response)
 		return
-	}	
-
-	// confirm if user_email exists
-	result, _ := utils.GetMongoDBDoc(UserCollectionName, bson.M{"email": userEmail})
-	if result != nil {
-		utils.GetError(
-			fmt.Errorf("user with email %s exists", userEmail),
-			http.StatusBadRequest,
-			response,
-		)
+	}
 
+	if err := utils.ValidatePassword(user.Password, uh.configs.PasswordPolicy); err != nil {
+		utils.GetError(err, http.StatusBadRequest, response)
 		return
 	}
 
@@ -66,19 +71,30 @@ func (uh *UserHandler) Create(response http.ResponseWriter, request *http.Reques
 	timeLimit := 24
 	_, comfimationToken := utils.RandomGen(randomNumberLimit, "d")
 
-	con := &UserEmailVerification{false, comfimationToken, time.Now().Add(time.Minute * time.Duration(timeLimit))}
+	con := &UserEmailVerification{false, comfimationToken, utils.NowUTC().Add(time.Minute * time.Duration(timeLimit))}
 
 	user.Email = userEmail
-	user.CreatedAt = time.Now()
+	user.CreatedAt = utils.NowUTC()
 	user.Password = hashPassword
 	user.Deactivated = false
 	user.IsVerified = false
 	user.EmailVerification = con
 	user.Social = nil
 	user.Timezone = "Africa/Lagos" // set default timezone
+
+	if user.Phone, err = utils.EncryptField(user.Phone, uh.configs.FieldEncryptionKeys); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, response)
+		return
+	}
+
 	detail, _ := utils.StructToMap(user)
 
-	res, err := utils.CreateMongoDBDoc(UserCollectionName, detail)
+	// the unique index on email rejects duplicates, even when two creates race
+	res, err := utils.CreateMongoDBDoc(request.Context(), UserCollectionName, detail)
+	if utils.IsDuplicateKeyError(err) {
+		utils.GetError(fmt.Errorf("user with email %s exists", userEmail), http.StatusConflict, response)
+		return
+	}
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, response)
@@ -103,27 +119,82 @@ func (uh *UserHandler) Create(response http.ResponseWriter, request *http.Reques
 	utils.GetSuccess("user created", respse, response)
 }
 
-// an endpoint to delete a user record.
+// an endpoint to delete a user record. The user is only deactivated, and can be restored with
+// RestoreUser until the retention window runs out and their personal data is purged.
 func (uh *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	params := mux.Vars(r)
 	userID := params["user_id"]
 
-	deactivateUpdate := bson.M{"deactivated": true, "deactivated_at": time.Now()}
-	deactivate, err := utils.UpdateOneMongoDBDoc(UserCollectionName, userID, deactivateUpdate)
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		utils.GetError(errors.New("invalid user id"), http.StatusBadRequest, w)
+		return
+	}
+
+	now := utils.NowUTC()
+
+	deactivateUpdate := bson.M{"$set": bson.M{"deactivated": true, "deactivated_at": now}}
+	deactivate, err := utils.GetCollection(UserCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": objID, "deactivated": bson.M{"$ne": true}}, deactivateUpdate)
+
+	if err != nil {
+		utils.GetError(err, http.StatusInternalServerError, w)
+		return
+	}
+
+	if deactivate.MatchedCount == 0 {
+		utils.GetError(errors.New("user not found"), http.StatusNotFound, w)
+		return
+	}
+
+	utils.GetSuccess("User Deleted Successfully", utils.M{"purge_after": now.Add(uh.configs.UserRetention)}, w)
+}
+
+// an endpoint to restore a deleted user. Users whose retention window has run out are gone.
+func (uh *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
+	objID, err := primitive.ObjectIDFromHex(mux.Vars(r)["user_id"])
+	if err != nil {
+		utils.GetError(errors.New("invalid user id"), http.StatusBadRequest, w)
+		return
+	}
+
+	var deleted User
+	if err = utils.GetCollection(UserCollectionName).FindOne(r.Context(), bson.M{"_id": objID}).Decode(&deleted); err != nil {
+		utils.GetError(errors.New("user not found"), http.StatusNotFound, w)
+		return
+	}
+
+	if !deleted.Deactivated {
+		utils.GetError(errors.New("user is not deleted"), http.StatusBadRequest, w)
+		return
+	}
+
+	cutoff := utils.NowUTC().Add(-uh.configs.UserRetention)
+
+	if deleted.PurgedAt != nil || !deleted.DeactivatedAt.After(cutoff) {
+		utils.GetError(errRetentionPassed, http.StatusGone, w)
+		return
+	}
+
+	// a purge that started since the user was read wins
+	res, err := utils.GetCollection(UserCollectionName).UpdateOne(r.Context(),
+		bson.M{"_id": objID, "deactivated": true, "purged_at": bson.M{"$exists": false}, "deactivated_at": bson.M{"$gt": cutoff}},
+		bson.M{"$set": bson.M{"deactivated": false, "updated_at": utils.NowUTC()}, "$unset": bson.M{"deactivated_at": ""}})
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
 		return
 	}
 
-	if deactivate.ModifiedCount == 0 {
-		utils.GetError(errors.New("operation failed"), http.StatusInternalServerError, w)
+	if res.MatchedCount == 0 {
+		utils.GetError(errRetentionPassed, http.StatusGone, w)
 		return
 	}
 
-	utils.GetSuccess("User Deleted Successfully", nil, w)
+	utils.GetSuccess("user restored successfully", nil, w)
 }
 
 // endpoint to find user by ID.
@@ -142,7 +213,7 @@ func (uh *UserHandler) GetUser(response http.ResponseWriter, request *http.Reque
 		return
 	}
 
-	res, err := utils.GetMongoDBDoc(UserCollectionName, bson.M{"_id": objID, "deactivated": false})
+	res, err := utils.GetMongoDBDoc(request.Context(), UserCollectionName, bson.M{"_id": objID, "deactivated": false})
 
 	if err != nil {
 		utils.GetError(errors.New("user not found"), http.StatusNotFound, response)
@@ -150,6 +221,12 @@ func (uh *UserHandler) GetUser(response http.ResponseWriter, request *http.Reque
 	}
 
 	DeleteMapProps(res, []string{"password"})
+
+	if err = uh.decryptPhone(res); err != nil {
+		utils.GetError(err, http.StatusInternalServerError, response)
+		return
+	}
+
 	utils.GetSuccess("user retrieved successfully", res, response)
 }
 
@@ -166,7 +243,7 @@ func (uh *UserHandler) UpdateUser(response http.ResponseWriter, request *http.Re
 		return
 	}
 
-	userExist, err := utils.GetMongoDBDoc(UserCollectionName, bson.M{"_id": objID})
+	userExist, err := utils.GetMongoDBDoc(request.Context(), UserCollectionName, bson.M{"_id": objID})
 
 	if err != nil {
 		utils.GetError(errors.New("user does not exist"), http.StatusNotFound, response)
@@ -184,6 +261,11 @@ func (uh *UserHandler) UpdateUser(response http.ResponseWriter, request *http.Re
 		return
 	}
 
+	if user.AvatarURL != "" && !validAvatarURL(user.AvatarURL) {
+		utils.GetError(errors.New("avatar_url must be an http or https URL"), http.StatusBadRequest, response)
+		return
+	}
+
 	userMap, err := utils.StructToMap(user)
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, response)
@@ -203,7 +285,20 @@ func (uh *UserHandler) UpdateUser(response http.ResponseWriter, request *http.Re
 		return
 	}
 
-	_, err = utils.UpdateOneMongoDBDoc(UserCollectionName, userID, updateFields)
+	if user.Phone != "" {
+		if updateFields["phone"], err = utils.EncryptField(user.Phone, uh.configs.FieldEncryptionKeys); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, response)
+			return
+		}
+	}
+
+	_, err = utils.UpdateOneMongoDBDoc(request.Context(), UserCollectionName, userID, updateFields)
+
+	if utils.IsDuplicateKeyError(err) {
+		utils.GetError(utils.DuplicateKeyError(err), http.StatusConflict, response)
+
+		return
+	}
 
 	if err != nil {
 		utils.GetError(errors.New("user update failed"), http.StatusInternalServerError, response)
@@ -220,16 +315,37 @@ func (uh *UserHandler) GetUsers(response http.ResponseWriter, request *http.Requ
 	response.Header().Set("Access-Control-Allow-Headers", "Content-Type,access-control-allow-origin, access-control-allow-headers")
 	response.Header().Set("content-type", "application/json")
 
-	res, _ := utils.GetMongoDBDocs(UserCollectionName, bson.M{"deactivated": false})
+	res, _ := utils.GetMongoDBDocs(request.Context(), UserCollectionName, bson.M{"deactivated": false})
 
 	for _, doc := range res {
 		DeleteMapProps(doc, []string{"password"})
+
+		if err := uh.decryptPhone(doc); err != nil {
+			utils.GetError(err, http.StatusInternalServerError, response)
+			return
+		}
 	}
 
 	utils.GetSuccess("users retrieved successfully", res, response)
 }
 
-// get a user organizations.
+// pinnedOrganizations maps the ids of the organizations a user pinned to their place among the pins.
+func pinnedOrganizations(email string) map[string]int {
+	pins := map[string]int{}
+
+	u := &User{}
+	if err := utils.GetCollection(UserCollectionName).FindOne(context.TODO(), bson.M{"email": email}).Decode(u); err != nil {
+		return pins
+	}
+
+	for i, orgID := range u.PinnedOrganizations {
+		pins[orgID] = i
+	}
+
+	return pins
+}
+
+// get a user organizations, with the ones they pinned first.
 func (uh *UserHandler) GetUserOrganizations(response http.ResponseWriter, request *http.Request) {
 	response.Header().Set("content-type", "application/json")
 
@@ -242,7 +358,9 @@ func (uh *UserHandler) GetUserOrganizations(response http.ResponseWriter, reques
 	}
 
 	// find user email in members collection.
-	result, _ := utils.GetMongoDBDocs(MemberCollectionName, bson.M{"email": userEmail, "deleted": false})
+	result, _ := utils.GetMongoDBDocs(request.Context(), MemberCollectionName, bson.M{"email": userEmail, "deleted": false})
+
+	pins := pinnedOrganizations(userEmail)
 
 	orgs := make([]map[string]interface{}, 0)
 
@@ -258,7 +376,7 @@ func (uh *UserHandler) GetUserOrganizations(response http.ResponseWriter, reques
 		ImageUrlsChannel := make(chan GUOCR)
 
 		go func() {
-			orgMembers, err := utils.GetMongoDBDocs(MemberCollectionName, bson.M{"org_id": orgid})
+			orgMembers, err := utils.GetMongoDBDocs(request.Context(), MemberCollectionName, bson.M{"org_id": orgid})
 			resp := GUOCR{
 				Err:      err,
 				Interger: len(orgMembers),
@@ -277,7 +395,7 @@ func (uh *UserHandler) GetUserOrganizations(response http.ResponseWriter, reques
 			ne, ccfilter := make(map[string]interface{}), make(map[string]interface{})
 			ne["$ne"] = ""
 			ccfilter["image_url"], ccfilter["org_id"] = ne, orgid
-			orgMembersimages, err := utils.GetMongoDBDocs(MemberCollectionName, ccfilter, findOptions)
+			orgMembersimages, err := utils.GetMongoDBDocs(request.Context(), MemberCollectionName, ccfilter, findOptions)
 
 			for _, member := range orgMembersimages {
 				memberImgs = append(memberImgs, member["image_url"])
@@ -291,7 +409,7 @@ func (uh *UserHandler) GetUserOrganizations(response http.ResponseWriter, reques
 		}(imageLimit)
 
 		go func() {
-			orgDetailsrt, err := utils.GetMongoDBDoc(OrganizationCollectionName, bson.M{"_id": objID})
+			orgDetailsrt, err := utils.GetMongoDBDoc(request.Context(), OrganizationCollectionName, bson.M{"_id": objID})
 			resp := GUOCR{
 				Err:  err,
 				Bson: orgDetailsrt,
@@ -311,9 +429,27 @@ func (uh *UserHandler) GetUserOrganizations(response http.ResponseWriter, reques
 
 		basic["imgs"], basic["id"], basic["logo_url"] = basicimagesdata.Interfaces, orgDetails["_id"], orgDetails["logo_url"]
 		basic["name"], basic["workspace_url"] = orgDetails["name"], orgDetails["workspace_url"]
+
+		_, pinned := pins[orgid]
+		basic["pinned"] = pinned
 		orgs = append(orgs, basic)
 	}
 
+	// pinned organizations come first, in the order they were pinned
+	sort.SliceStable(orgs, func(i, j int) bool {
+		idI, _ := orgs[i]["id"].(primitive.ObjectID)
+		idJ, _ := orgs[j]["id"].(primitive.ObjectID)
+
+		pi, iPinned := pins[idI.Hex()]
+		pj, jPinned := pins[idJ.Hex()]
+
+		if iPinned != jPinned {
+			return iPinned
+		}
+
+		return iPinned && pi < pj
+	})
+
 	utils.GetSuccess("user organizations retrieved successfully", orgs, response)
 }
 
@@ -336,13 +472,25 @@ func (uh *UserHandler) CreateUserFromUUID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	if err = utils.ValidatePassword(uRequest.Password, uh.configs.PasswordPolicy); err != nil {
+		utils.GetError(err, http.StatusBadRequest, w)
+		return
+	}
+
 	// Check that UUID exists
-	res, err := utils.GetMongoDBDoc(OrganizationsInvitesCollectionName, bson.M{"uuid": uRequest.UUID})
+	res, err := utils.GetMongoDBDoc(r.Context(), OrganizationsInvitesCollectionName, bson.M{"uuid": uRequest.UUID})
 	if err != nil {
 		utils.GetError(errors.New("uuid does not exist"), http.StatusBadRequest, w)
 		return
 	}
 
+	// invites saved before they had an expiry never expire
+	expired, _ := res["expired"].(bool)
+	if expiresAt := utils.DocTime(res, "expires_at"); expired || (!expiresAt.IsZero() && !utils.NowUTC().Before(expiresAt)) {
+		utils.GetError(errors.New("invite has expired, ask the organization for a new one"), http.StatusGone, w)
+		return
+	}
+
 	// Validate email
 	email, _ := res["email"].(string) // extract email from UUID
 	userEmail := strings.ToLower(email)
@@ -352,23 +500,11 @@ func (uh *UserHandler) CreateUserFromUUID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Check if user_email exists
-	result, _ := utils.GetMongoDBDoc(UserCollectionName, bson.M{"email": userEmail})
-	if result != nil {
-		utils.GetError(
-			fmt.Errorf("user with email %s exists", userEmail),
-			http.StatusBadRequest,
-			w,
-		)
-
-		return
-	}
-
 	// UserEmailVerification
 	randomNumberLimit := 6
 	timeLimit := 24
 	_, comfimationToken := utils.RandomGen(randomNumberLimit, "d")
-	con := &UserEmailVerification{true, comfimationToken, time.Now().Add(time.Minute * time.Duration(timeLimit))}
+	con := &UserEmailVerification{true, comfimationToken, utils.NowUTC().Add(time.Minute * time.Duration(timeLimit))}
 
 	// Hash password
 	hashPassword, err := GetHash(uRequest.Password)
@@ -380,17 +516,21 @@ func (uh *UserHandler) CreateUserFromUUID(w http.ResponseWriter, r *http.Request
 	user := &User{
 		FirstName:         uRequest.FirstName,
 		LastName:          uRequest.LastName,
-		Email:             email,
+		Email:             userEmail,
 		Password:          hashPassword,
 		IsVerified:        true,
 		EmailVerification: con,
-		CreatedAt:         time.Now(),
+		CreatedAt:         utils.NowUTC(),
 		Deactivated:       false,
 	}
 
 	// Save user to DB
 	data, _ := utils.StructToMap(user)
-	resp, err := utils.CreateMongoDBDoc(UserCollectionName, data)
+	resp, err := utils.CreateMongoDBDoc(r.Context(), UserCollectionName, data)
+	if utils.IsDuplicateKeyError(err) {
+		utils.GetError(fmt.Errorf("user with email %s exists", userEmail), http.StatusConflict, w)
+		return
+	}
 
 	if err != nil {
 		utils.GetError(err, http.StatusInternalServerError, w)
@@ -400,6 +540,24 @@ func (uh *UserHandler) CreateUserFromUUID(w http.ResponseWriter, r *http.Request
 	utils.GetSuccess("user successfully created", resp, w)
 }
 
+// decryptPhone replaces the phone number of a user document, which is stored encrypted when
+// field encryption keys are configured, with its plaintext.
+func (uh *UserHandler) decryptPhone(doc map[string]interface{}) error {
+	phone, ok := doc["phone"].(string)
+	if !ok {
+		return nil
+	}
+
+	phone, err := utils.DecryptField(phone, uh.configs.FieldEncryptionKeys)
+	if err != nil {
+		return fmt.Errorf("could not read phone number: %w", err)
+	}
+
+	doc["phone"] = phone
+
+	return nil
+}
+
 func DeleteMapProps(m map[string]interface{}, s []string) {
 	for _, v := range s {
 		delete(m, v)
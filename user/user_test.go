@@ -0,0 +1,268 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"zuri.chat/zccore/service"
+	"zuri.chat/zccore/utils"
+)
+
+var configs = utils.NewConfigurations()
+
+// noopMailService satisfies service.MailService without sending anything.
+type noopMailService struct{}
+
+func (noopMailService) LoadTemplate(mailReq *service.Mail) (string, error) { return "", nil }
+
+func (noopMailService) SendMail(mailReq *service.Mail) error { return nil }
+
+func (noopMailService) NewCustomMail(to []string, subject, mailBody string) *service.Mail {
+	return &service.Mail{}
+}
+
+func (noopMailService) NewMail(to []string, subject string, mailType service.MailType, data map[string]interface{}) *service.Mail {
+	return &service.Mail{}
+}
+
+func TestMain(m *testing.M) {
+	// load .env file if it exists
+	err := godotenv.Load("../.testenv")
+	if err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
+	}
+
+	if err = utils.ConnectToDB(os.Getenv("CLUSTER_URL")); err != nil {
+		log.Fatal("Could not connect to MongoDB")
+	}
+
+	// start from an empty database, restoring the unique index on email dropped with it
+	ctx := context.TODO()
+	utils.GetDefaultMongoClient().Database(os.Getenv("DB_NAME")).Drop(ctx)
+
+	if err = utils.CreateUniqueIndex(UserCollectionName, "email", 1); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	exitVal := m.Run()
+
+	utils.GetDefaultMongoClient().Database(os.Getenv("DB_NAME")).Drop(ctx)
+
+	os.Exit(exitVal)
+}
+
+func TestCreateUniqueEmail(t *testing.T) {
+	uh := NewUserHandler(configs, noopMailService{})
+
+	t.Run("test concurrent creates yield exactly one user", func(t *testing.T) {
+		emails := []string{"racer@gmail.com", "Racer@Gmail.com"}
+		codes := make([]int, len(emails))
+
+		var wg sync.WaitGroup
+
+		for i, email := range emails {
+			wg.Add(1)
+
+			go func(i int, email string) {
+				defer wg.Done()
+
+				requestBody := []byte(fmt.Sprintf(`{"email": %q, "password": "Password1234"}`, email))
+				req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(requestBody))
+
+				rr := httptest.NewRecorder()
+				uh.Create(rr, req)
+				codes[i] = rr.Code
+			}(i, email)
+		}
+
+		wg.Wait()
+
+		created, conflicts := 0, 0
+
+		for _, code := range codes {
+			switch code {
+			case http.StatusOK:
+				created++
+			case http.StatusConflict:
+				conflicts++
+			}
+		}
+
+		if created != 1 || conflicts != 1 {
+			t.Errorf("expected one create and one conflict, got status codes %v", codes)
+		}
+
+		if n := utils.CountCollection(context.TODO(), UserCollectionName, bson.M{"email": "racer@gmail.com"}); n != 1 {
+			t.Errorf("expected exactly 1 user, found %d", n)
+		}
+	})
+}
+
+func TestStoredTimestampsAreUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("WAT", 60*60)
+
+	defer func() { time.Local = local }()
+
+	uh := NewUserHandler(configs, noopMailService{})
+
+	requestBody := []byte(`{"email": "utcstamp@gmail.com", "password": "Password1234"}`)
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(requestBody))
+
+	rr := httptest.NewRecorder()
+	uh.Create(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	doc, err := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"email": "utcstamp@gmail.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// users are saved through StructToMap, which stores dates as strings with their offset
+	createdAt, ok := doc["created_at"].(string)
+	if !ok {
+		t.Fatalf("expected created_at to be stored as a string, got %T", doc["created_at"])
+	}
+
+	if !strings.HasSuffix(createdAt, "Z") {
+		t.Errorf("expected created_at to be stored in UTC, got %s", createdAt)
+	}
+
+	if got := utils.DocTime(doc, "created_at"); got.Location() != time.UTC || got.IsZero() {
+		t.Errorf("expected created_at to be read in UTC, got %v", got)
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	uh := NewUserHandler(configs, noopMailService{})
+
+	detail, _ := utils.StructToMap(User{Email: "restoreme@gmail.com"})
+
+	res, err := utils.CreateMongoDBDoc(context.TODO(), UserCollectionName, detail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID := res.InsertedID.(primitive.ObjectID).Hex()
+
+	call := func(handler http.HandlerFunc, method string, expectedCode int) {
+		req, _ := http.NewRequest(method, "/users/"+userID, nil)
+		req = mux.SetURLVars(req, map[string]string{"user_id": userID})
+
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+
+		if rr.Code != expectedCode {
+			t.Errorf("expected status %d, got %d: %s", expectedCode, rr.Code, rr.Body.String())
+		}
+	}
+
+	deactivated := func() bool {
+		doc, _ := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"_id": res.InsertedID})
+		return doc["deactivated"] == true
+	}
+
+	t.Run("test a user can be restored within the retention window", func(t *testing.T) {
+		call(uh.DeleteUser, "DELETE", http.StatusOK)
+		call(uh.DeleteUser, "DELETE", http.StatusNotFound)
+
+		if !deactivated() {
+			t.Fatal("expected the user to be deleted")
+		}
+
+		call(uh.RestoreUser, "POST", http.StatusOK)
+
+		if deactivated() {
+			t.Error("expected the user to be restored")
+		}
+
+		call(uh.RestoreUser, "POST", http.StatusBadRequest)
+	})
+
+	t.Run("test a user cannot be restored after the retention window", func(t *testing.T) {
+		call(uh.DeleteUser, "DELETE", http.StatusOK)
+
+		longAgo := bson.M{"deactivated_at": utils.NowUTC().Add(-configs.UserRetention - time.Hour)}
+		if _, err := utils.UpdateOneMongoDBDoc(context.TODO(), UserCollectionName, userID, longAgo); err != nil {
+			t.Fatal(err)
+		}
+
+		call(uh.RestoreUser, "POST", http.StatusGone)
+
+		if !deactivated() {
+			t.Error("expected the user to stay deleted")
+		}
+	})
+}
+
+func TestCreateRequiresJSON(t *testing.T) {
+	uh := NewUserHandler(configs, noopMailService{})
+
+	create := func(contentType string) int {
+		requestBody := []byte(`{"email": "contenttype@gmail.com", "password": "Password1234"}`)
+		req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(requestBody))
+		req.Header.Set("Content-Type", contentType)
+
+		rr := httptest.NewRecorder()
+		uh.Create(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("test non-JSON bodies are refused before decoding", func(t *testing.T) {
+		for _, contentType := range []string{"text/plain", "application/x-www-form-urlencoded"} {
+			if code := create(contentType); code != http.StatusUnsupportedMediaType {
+				t.Errorf("%s: expected status %d, got %d", contentType, http.StatusUnsupportedMediaType, code)
+			}
+		}
+
+		if n := utils.CountCollection(context.TODO(), UserCollectionName, bson.M{"email": "contenttype@gmail.com"}); n != 0 {
+			t.Errorf("expected no user to be created, found %d", n)
+		}
+	})
+
+	t.Run("test JSON bodies are accepted", func(t *testing.T) {
+		if code := create("application/json; charset=utf-8"); code != http.StatusOK && code != http.StatusConflict {
+			t.Errorf("expected the user to be created, got status %d", code)
+		}
+	})
+}
+
+func TestCreateIgnoresProtectedFields(t *testing.T) {
+	uh := NewUserHandler(configs, noopMailService{})
+
+	requestBody := []byte(`{"email": "massassign@gmail.com", "password": "Password1234", "role": "admin", "isverified": true, "workspaces": ["someorg"]}`)
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBuffer(requestBody))
+
+	rr := httptest.NewRecorder()
+	uh.Create(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	doc, err := utils.GetMongoDBDoc(context.TODO(), UserCollectionName, bson.M{"email": "massassign@gmail.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc["role"] != "" || doc["isverified"] != false || doc["workspaces"] != nil {
+		t.Errorf("expected role, verification and workspaces not to be set, got %v %v %v", doc["role"], doc["isverified"], doc["workspaces"])
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// ParseAllowedJSONFromRequest decodes the JSON body of r into v like ParseJSONFromRequest, with
+// only the allowed top-level fields. Other fields are dropped, so a request cannot set what the
+// handler fills in itself, such as the plan or owners of an organization being created. The
+// names of the dropped fields are returned, sorted, for the handler to report.
+//
+// Fields are matched exactly, so a field sent as "Version" is dropped rather than decoded into
+// version the way encoding/json would.
+func ParseAllowedJSONFromRequest(r *http.Request, v interface{}, allowed ...string) ([]string, error) {
+	var fields map[string]json.RawMessage
+	if err := ParseJSONFromRequest(r, &fields); err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool, len(allowed))
+	for _, field := range allowed {
+		keep[field] = true
+	}
+
+	ignored := []string{}
+
+	for field := range fields {
+		if !keep[field] {
+			ignored = append(ignored, field)
+			delete(fields, field)
+		}
+	}
+
+	sort.Strings(ignored)
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return ignored, json.Unmarshal(body, v)
+}
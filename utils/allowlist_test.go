@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestParseAllowedJSONFromRequest(t *testing.T) {
+	type org struct {
+		Name    string   `json:"name"`
+		Version string   `json:"version"`
+		Owners  []string `json:"owners"`
+	}
+
+	tests := []struct {
+		body    string
+		want    org
+		ignored []string
+	}{
+		{`{"name": "Acme"}`, org{Name: "Acme"}, []string{}},
+		{`{"name": "Acme", "version": "pro", "owners": ["1"]}`, org{Name: "Acme"}, []string{"owners", "version"}},
+		{`{"Version": "pro", "NAME": "Acme"}`, org{}, []string{"NAME", "Version"}},
+		{`null`, org{}, []string{}},
+	}
+
+	for _, tc := range tests {
+		req, _ := http.NewRequest("POST", "/organizations", bytes.NewBufferString(tc.body))
+
+		var got org
+
+		ignored, err := ParseAllowedJSONFromRequest(req, &got, "name")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.body, err)
+		}
+
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) || fmt.Sprint(ignored) != fmt.Sprint(tc.ignored) {
+			t.Errorf("%s: expected %+v ignoring %v, got %+v ignoring %v", tc.body, tc.want, tc.ignored, got, ignored)
+		}
+	}
+
+	req, _ := http.NewRequest("POST", "/organizations", bytes.NewBufferString(`{"name": `))
+	if _, err := ParseAllowedJSONFromRequest(req, &struct{}{}, "name"); err == nil {
+		t.Error("expected malformed bodies to be rejected")
+	}
+}
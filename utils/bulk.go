@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// BulkResult is the outcome of a bulk operation item by item, so every bulk endpoint reports
+// partial success the same way. Respond with it using GetBulkResult.
+type BulkResult struct {
+	Succeeded []BulkItem `json:"succeeded"`
+	Failed    []BulkItem `json:"failed"`
+	Skipped   []BulkItem `json:"skipped"`
+}
+
+// BulkItem is one item of a bulk operation, such as an email or an id. Reason says why it
+// failed or was skipped, and Data holds anything else the operation reports about it.
+type BulkItem struct {
+	Item   string      `json:"item"`
+	Reason string      `json:"reason,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+func NewBulkResult() *BulkResult {
+	return &BulkResult{Succeeded: []BulkItem{}, Failed: []BulkItem{}, Skipped: []BulkItem{}}
+}
+
+func (b *BulkResult) Succeed(item string, data interface{}) {
+	b.Succeeded = append(b.Succeeded, BulkItem{Item: item, Data: data})
+}
+
+func (b *BulkResult) Fail(item, reason string, data interface{}) {
+	b.Failed = append(b.Failed, BulkItem{Item: item, Reason: reason, Data: data})
+}
+
+// Skip records an item that needed nothing done, such as a repeated one.
+func (b *BulkResult) Skip(item, reason string, data interface{}) {
+	b.Skipped = append(b.Skipped, BulkItem{Item: item, Reason: reason, Data: data})
+}
+
+// StatusCode is 207 Multi-Status when any item failed, so clients can tell a partial result
+// from a complete one without reading the body, and 200 otherwise.
+func (b *BulkResult) StatusCode() int {
+	if len(b.Failed) > 0 {
+		return http.StatusMultiStatus
+	}
+
+	return http.StatusOK
+}
+
+// GetBulkResult responds with the outcome of a bulk operation, see BulkResult.StatusCode.
+func GetBulkResult(msg string, result *BulkResult, w http.ResponseWriter) {
+	var response = SuccessResponse{
+		Message:    msg,
+		StatusCode: result.StatusCode(),
+		Data:       result,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error sending response: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBulkResultStatusCode(t *testing.T) {
+	result := NewBulkResult()
+	if code := result.StatusCode(); code != http.StatusOK {
+		t.Errorf("expected %d for an empty result, got %d", http.StatusOK, code)
+	}
+
+	result.Succeed("a", nil)
+	result.Skip("b", "already done", nil)
+
+	if code := result.StatusCode(); code != http.StatusOK {
+		t.Errorf("expected %d without failures, got %d", http.StatusOK, code)
+	}
+
+	result.Fail("c", "invalid", nil)
+
+	if code := result.StatusCode(); code != http.StatusMultiStatus {
+		t.Errorf("expected %d with a failure, got %d", http.StatusMultiStatus, code)
+	}
+}
+
+func TestGetBulkResult(t *testing.T) {
+	result := NewBulkResult()
+	result.Succeed("a@gmail.com", M{"invite_id": "1"})
+	result.Fail("not-an-email", "invalid email address", nil)
+
+	w := httptest.NewRecorder()
+	GetBulkResult("invites sent", result, w)
+
+	if w.Code != http.StatusMultiStatus {
+		t.Errorf("expected status %d, got %d", http.StatusMultiStatus, w.Code)
+	}
+
+	var response struct {
+		Status int                          `json:"status"`
+		Data   map[string][]json.RawMessage `json:"data"`
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Status != http.StatusMultiStatus {
+		t.Errorf("expected status %d in the body, got %d", http.StatusMultiStatus, response.Status)
+	}
+
+	for section, want := range map[string]int{"succeeded": 1, "failed": 1, "skipped": 0} {
+		items, ok := response.Data[section]
+		if !ok || len(items) != want {
+			t.Errorf("expected %d %s items, got %v", want, section, response.Data[section])
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Field naming of JSON responses. Documents are stored and encoded in snake_case, which
+// responses can be converted from.
+const (
+	SnakeCase = "snake"
+	CamelCase = "camel"
+)
+
+// toCamelCase converts a snake_case key to camelCase. Leading underscores, as in _id, are kept.
+func toCamelCase(key string) string {
+	trimmed := strings.TrimLeft(key, "_")
+	if !strings.Contains(trimmed, "_") {
+		return key
+	}
+
+	var b strings.Builder
+
+	b.WriteString(key[:len(key)-len(trimmed)])
+
+	upper := false
+
+	for _, c := range trimmed {
+		switch {
+		case c == '_':
+			upper = true
+		case upper:
+			b.WriteString(strings.ToUpper(string(c)))
+			upper = false
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+// CamelCaseKeys converts the keys of decoded JSON to camelCase, at every depth.
+func CamelCaseKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			converted[toCamelCase(key)] = CamelCaseKeys(value)
+		}
+
+		return converted
+	case []interface{}:
+		for i, value := range v {
+			v[i] = CamelCaseKeys(value)
+		}
+	}
+
+	return v
+}
+
+// ResponseCase returns the field naming a request asks for with a case parameter on its
+// Accept header, e.g. "application/json; case=camel", or fallback when it asks for none.
+func ResponseCase(r *http.Request, fallback string) string {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		_, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+
+		switch params["case"] {
+		case CamelCase:
+			return CamelCase
+		case SnakeCase:
+			return SnakeCase
+		}
+	}
+
+	return fallback
+}
+
+// camelCaseWriter holds back JSON responses to convert their keys to camelCase. Responses of
+// any other type, such as streamed exports, are written through as they are.
+type camelCaseWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+	buffering   bool
+	body        bytes.Buffer
+}
+
+func (w *camelCaseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.code = code
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffering = mediaType == "application/json"
+
+	if !w.buffering {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *camelCaseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.buffering {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *camelCaseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && !w.buffering {
+		f.Flush()
+	}
+}
+
+// finish converts and writes a held back JSON response. Bodies that are not valid JSON are
+// written unchanged.
+func (w *camelCaseWriter) finish() {
+	if !w.buffering {
+		return
+	}
+
+	body := w.body.Bytes()
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err == nil {
+		var converted bytes.Buffer
+		if err := json.NewEncoder(&converted).Encode(CamelCaseKeys(v)); err == nil {
+			body = converted.Bytes()
+		}
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.WriteHeader(w.code)
+	//nolint:errcheck //the client has gone if the body cannot be written
+	w.ResponseWriter.Write(body)
+}
+
+// JSONCaseMiddleware converts the keys of JSON responses to camelCase for requests that ask
+// for it, or for every request when camelCase is the configured default. What is stored is
+// not changed.
+func JSONCaseMiddleware(defaultCase string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+
+			if ResponseCase(r, defaultCase) != CamelCase {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &camelCaseWriter{ResponseWriter: w}
+			next.ServeHTTP(cw, r)
+			cw.finish()
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONCaseMiddleware(t *testing.T) {
+	org := M{
+		"_id":           "6145d8a8b6f8c3d2a4e9f2b1",
+		"workspace_url": "zurichat-abc.zuri.chat",
+		"creator_email": "owner@zuri.chat",
+		"member_count":  1024,
+		"plugins": M{
+			"614679ee1a5607b13c00bcb7": M{"installed_at": "2021-10-01T00:00:00Z", "added_by": "owner"},
+		},
+		"announcements": []M{{"created_by": "owner", "body": "Welcome"}},
+	}
+
+	handler := JSONCaseMiddleware(SnakeCase)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		GetSuccess("organization retrieved successfully", org, w)
+	}))
+
+	get := func(t *testing.T, accept string) map[string]interface{} {
+		req, _ := http.NewRequest("GET", "/organizations/6145d8a8b6f8c3d2a4e9f2b1", nil)
+		req.Header.Set("Accept", accept)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+
+		var res map[string]interface{}
+		if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		return res["data"].(map[string]interface{})
+	}
+
+	snake := get(t, "application/json")
+	camel := get(t, "application/json; case=camel")
+
+	t.Run("test snake case is left as stored", func(t *testing.T) {
+		if snake["workspace_url"] != org["workspace_url"] {
+			t.Errorf("expected workspace_url %v, got %v", org["workspace_url"], snake)
+		}
+	})
+
+	t.Run("test camel case converts keys at every depth", func(t *testing.T) {
+		pairs := map[string]string{
+			"_id":           "_id",
+			"workspace_url": "workspaceUrl",
+			"creator_email": "creatorEmail",
+			"member_count":  "memberCount",
+		}
+
+		for snakeKey, camelKey := range pairs {
+			if camel[camelKey] != snake[snakeKey] {
+				t.Errorf("expected %s to be %v, got %v", camelKey, snake[snakeKey], camel[camelKey])
+			}
+
+			if _, ok := camel[snakeKey]; ok && snakeKey != camelKey {
+				t.Errorf("expected %s not to be in the camelCase response", snakeKey)
+			}
+		}
+
+		plugin := camel["plugins"].(map[string]interface{})["614679ee1a5607b13c00bcb7"].(map[string]interface{})
+		if plugin["installedAt"] != "2021-10-01T00:00:00Z" || plugin["addedBy"] != "owner" {
+			t.Errorf("expected nested keys to be converted, got %v", plugin)
+		}
+
+		announcement := camel["announcements"].([]interface{})[0].(map[string]interface{})
+		if announcement["createdBy"] != "owner" {
+			t.Errorf("expected keys inside arrays to be converted, got %v", announcement)
+		}
+	})
+
+	t.Run("test responses other than JSON pass through", func(t *testing.T) {
+		csv := JSONCaseMiddleware(CamelCase)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/csv")
+			w.Write([]byte("first_name,last_name\n")) //nolint:errcheck //recorder
+		}))
+
+		rr := httptest.NewRecorder()
+		csv.ServeHTTP(rr, httptest.NewRequest("GET", "/export", nil))
+
+		if rr.Body.String() != "first_name,last_name\n" {
+			t.Errorf("expected the CSV to be unchanged, got %q", rr.Body.String())
+		}
+	})
+}
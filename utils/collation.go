@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ignore accents and case, so café matches CAFE
+	CollationIgnoreAccents = 1
+	// ignore case only, so café matches CAFÉ but not cafe
+	CollationIgnoreCase = 2
+)
+
+// accentForms are the accented forms of each letter that accent-insensitive searches match.
+var accentForms = map[rune]string{
+	'a': "àáâãäåāăą",
+	'c': "çćĉċč",
+	'd': "ďđ",
+	'e': "èéêëēĕėęě",
+	'g': "ĝğġģ",
+	'h': "ĥħ",
+	'i': "ìíîïĩīĭį",
+	'j': "ĵ",
+	'k': "ķ",
+	'l': "ĺļľŀł",
+	'n': "ñńņň",
+	'o': "òóôõöøōŏő",
+	'r': "ŕŗř",
+	's': "śŝşšș",
+	't': "ţťŧț",
+	'u': "ùúûüũūŭůűų",
+	'w': "ŵ",
+	'y': "ýÿŷ",
+	'z': "źżž",
+}
+
+// baseLetters maps each accented form back to its letter.
+var baseLetters = func() map[rune]rune {
+	base := make(map[rune]rune)
+
+	for letter, forms := range accentForms {
+		for _, form := range forms {
+			base[form] = letter
+		}
+	}
+
+	return base
+}()
+
+// SearchCollation is the collation searches compare text with at the given strength,
+// CollationIgnoreAccents or CollationIgnoreCase, and nil at any other strength.
+func SearchCollation(strength int) *options.Collation {
+	if strength != CollationIgnoreAccents && strength != CollationIgnoreCase {
+		return nil
+	}
+
+	return &options.Collation{Locale: "en", Strength: strength}
+}
+
+// SearchPattern is a regex pattern, for use with the i option, matching query anywhere in a value.
+// Regular expressions ignore collations, so at CollationIgnoreAccents each letter of the query,
+// accented or not, also matches the letter's accented forms.
+func SearchPattern(query string, strength int) string {
+	if strength != CollationIgnoreAccents {
+		return regexp.QuoteMeta(query)
+	}
+
+	var pattern strings.Builder
+
+	for _, r := range strings.ToLower(query) {
+		if base, ok := baseLetters[r]; ok {
+			r = base
+		}
+
+		forms, ok := accentForms[r]
+		if !ok {
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+
+		fmt.Fprintf(&pattern, "[%c%s%s]", r, forms, strings.ToUpper(forms))
+	}
+
+	return pattern.String()
+}
+
+// CreateCollatedIndex creates an index on the fields of a collection with the search collation
+// of the given strength, so queries with that collation can use it. Queries only use indexes
+// with the same collation as theirs, and scan the collection otherwise. It does nothing when
+// there is no search collation.
+func CreateCollatedIndex(collName string, strength int, fields ...string) error {
+	collation := SearchCollation(strength)
+	if collation == nil {
+		return nil
+	}
+
+	keys := bson.D{}
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: 1})
+	}
+
+	indexModel := mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetCollation(collation).SetName(fmt.Sprintf("%s_collation_%d", strings.Join(fields, "_"), strength)),
+	}
+
+	timeOutFactor := 3
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeOutFactor)*time.Second)
+
+	defer cancel()
+
+	if _, err := defaultMongoHandle.GetCollection(collName).Indexes().CreateOne(ctx, indexModel); err != nil {
+		return fmt.Errorf("failed to create collated index on %v in %s: %v", fields, collName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSearchPattern(t *testing.T) {
+	tests := []struct {
+		query, value string
+		strength     int
+		matches      bool
+	}{
+		{"cafe", "Café Noir", CollationIgnoreAccents, true},
+		{"café", "CAFE", CollationIgnoreAccents, true},
+		{"Zoë", "zoe", CollationIgnoreAccents, true},
+		{"jose", "José Ramírez", CollationIgnoreAccents, true},
+		{"cafe", "Café", CollationIgnoreCase, false},
+		{"cafe", "CAFE", CollationIgnoreCase, true},
+		{"a.b", "axb", CollationIgnoreAccents, false},
+		{"(", "a(b", CollationIgnoreCase, true},
+	}
+
+	for _, tc := range tests {
+		// the pattern is sent to MongoDB with the i option
+		pattern := regexp.MustCompile("(?i)" + SearchPattern(tc.query, tc.strength))
+		if got := pattern.MatchString(tc.value); got != tc.matches {
+			t.Errorf("%q at strength %d: expected matching %q to be %v", tc.query, tc.strength, tc.value, tc.matches)
+		}
+	}
+}
+
+func TestSearchCollation(t *testing.T) {
+	if SearchCollation(0) != nil || SearchCollation(3) != nil {
+		t.Error("expected no collation outside strengths 1 and 2")
+	}
+
+	if collation := SearchCollation(CollationIgnoreAccents); collation == nil || collation.Strength != 1 {
+		t.Errorf("expected a collation of strength 1, got %+v", collation)
+	}
+}
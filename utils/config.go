@@ -1,12 +1,44 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
+	"zuri.chat/zccore/logger"
 )
 
+// defaultFeatureFlags is the JSON map of feature flags by plan used when FEATURE_FLAGS is not set.
+const defaultFeatureFlags = `{
+	"free": {"announcements": true, "digest": false},
+	"pro": {"announcements": true, "digest": true, "webhooks": true, "export": true},
+	"enterprise": {"announcements": true, "digest": true, "webhooks": true, "export": true, "member_import": true}
+}`
+
+// defaultRouteTimeouts is the JSON map of timeouts in seconds by route used when ROUTE_TIMEOUTS is
+// not set. Routes are path templates, optionally after a method, e.g. "GET /organizations/{id}".
+// Zero leaves a route unbounded.
+const defaultRouteTimeouts = `{
+	"GET /organizations/{id}": 5,
+	"/organizations/{id}/export": 300,
+	"/audit-logs/export": 300,
+	"/organizations/{id}/watch": 0,
+	"/socket.io/": 0
+}`
+
+// defaultContentTypeExemptRoutes are the route templates that take bodies other than JSON,
+// such as file uploads and the socket connection, used when CONTENT_TYPE_EXEMPT_ROUTES is not set.
+const defaultContentTypeExemptRoutes = "/organizations/{id}/logo," +
+	"/organizations/{id}/members/{mem_id}/photo/{action}," +
+	"/organizations/{id}/members/{mem_id}/uploadfile," +
+	"/organizations/{id}/import-members,/organizations/import," +
+	"/upload/file/{plugin_id},/upload/files/{plugin_id},/upload/mesc/{apk_sec}/{exe_sec}," +
+	"/contact,/external/send-mail,/socket.io/"
+
 // centralize config file using viper.
 type Configurations struct {
 	ClusterURL          string
@@ -27,6 +59,14 @@ type Configurations struct {
 	MailGunDomain      string
 	MailGunSenderEmail string
 
+	// most mails sent a minute, zero for no limit, and how many of them only password resets
+	// and email confirmations may use
+	MailRatePerMinute   int
+	MailPriorityReserve int
+
+	// most webhook deliveries a second replaying a time range sends
+	WebhookReplayPerSecond float64
+
 	EmailSubscriptionTemplate  string
 	ConfirmEmailTemplate       string
 	PasswordResetTemplate      string
@@ -48,9 +88,143 @@ type Configurations struct {
 	// Agora details
 	AppId         string
 	AppCerificate string
+
+	// requests per minute an organization may make to each route, by plan
+	RateLimits map[string]int
+
+	// requests a month an organization may make with API keys, by plan
+	APIQuotas map[string]int
+
+	// token required by the diagnostics endpoint, which is disabled when empty
+	DiagnosticsToken string
+
+	// rules passwords must meet on signup and reset
+	PasswordPolicy *PasswordPolicy
+
+	// most members an organization clone may copy over
+	CloneMemberLimit int
+
+	// most members any organization can have whatever its plan allows, 0 for no cap
+	MaxMembersPerOrg int64
+
+	// feature flags organizations get on each plan; flags missing from a plan are unavailable on it
+	FeatureFlags map[string]map[string]bool
+
+	// whether responses carry a Server-Timing header splitting database and application time
+	ServerTiming bool
+
+	// maintenance mode blocks requests that change data, except on the allowed route templates
+	MaintenanceMode        bool
+	MaintenanceAllowRoutes []string
+
+	// how long organization invites last unless the organization or invite says otherwise, and
+	// the longest any invite may last
+	InviteExpiry    time.Duration
+	InviteMaxExpiry time.Duration
+
+	// how long after an invite is emailed before it can be resent
+	InviteResendInterval time.Duration
+
+	// how long a second owner has to approve deleting or transferring an organization with
+	// several owners
+	OwnerApprovalWindow time.Duration
+
+	// how long checking a workspace url is available holds it for the user who checked
+	URLReservationTTL time.Duration
+
+	// members who have not logged in within this window count as inactive
+	InactiveMemberWindow time.Duration
+
+	// collation strength member searches compare with, 1 to also ignore accents so cafe finds
+	// café, 2 to ignore case only. Zero keeps the plain case-insensitive match
+	SearchCollationStrength int
+
+	// how many days before a temporary role expires its holder and granter are reminded, once
+	// at each
+	ExpiryReminderDays []int
+
+	// lowest level logged, debug, info, warn or error, and the format of logs, json or text
+	LogLevel  string
+	LogFormat string
+
+	// page sizes of paginated lists when a request asks for none, and the most it can ask for
+	Pagination Pagination
+
+	// field naming of JSON responses, snake or camel, unless a request asks otherwise
+	JSONFieldCase string
+
+	// create an unverified account for an organization creator who has none, for provisioning
+	// flows such as SSO sign in. Off by default, so the creator must already have an account
+	ProvisionMissingCreator bool
+
+	// put people creating organizations on a waitlist instead, unless they have one of the
+	// invite codes or an admin has allowed them
+	OrgCreationWaitlist bool
+	WaitlistInviteCodes []string
+
+	// how alike, from 0 to 1, a new organization's name must be to one of its creator's
+	// organizations for them to be pointed out as possible duplicates. Zero turns the check off
+	OrgNameSimilarityThreshold float64
+
+	// locales organizations can choose for their emails, and the one they get unless they choose
+	SupportedLocales []string
+	DefaultLocale    string
+
+	// how long a Zuri admin can act as another user with one impersonation token
+	ImpersonationTTL time.Duration
+
+	// keys that encrypt sensitive fields such as billing addresses, the first one for new values
+	FieldEncryptionKeys []FieldKey
+
+	// how long a deleted user can be restored before they are purged
+	UserRetention time.Duration
+
+	// how long audit log entries are kept, zero to keep them forever
+	AuditLogRetention time.Duration
+
+	// the page users confirm a change of email on, sent to the new address with the token
+	EmailChangeURL string
+
+	// proxies whose X-Forwarded-For header is believed when checking organization IP allowlists
+	TrustedProxies []*net.IPNet
+
+	// read-only organization handlers read from secondaries when there are any
+	ReadFromSecondaries bool
+
+	// how long CDNs and browsers may cache the public profile of an organization
+	PublicOrganizationMaxAge time.Duration
+
+	// organizations are reported missing to callers who are neither their members nor Zuri
+	// admins, so org ids cannot be probed, see OrganizationHandler.hidesOrganization
+	HideOrganizationExistence bool
+
+	// write concern of each class of operation, such as WriteCritical, see ParseWriteConcern
+	WriteConcerns map[string]string
+
+	// how long a request can take, unless its route has its own timeout
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+
+	// how strictly requests that change data must send JSON, off, lenient or strict, and the
+	// route templates, such as uploads, that take other bodies
+	ContentTypeEnforcement  string
+	ContentTypeExemptRoutes []string
 }
 
+// NewConfigurations reads the configuration, logging the settings that could not be read.
 func NewConfigurations() *Configurations {
+	configs, err := LoadConfigurations()
+	if err != nil {
+		logger.Error("%v", err)
+	}
+
+	return configs
+}
+
+// LoadConfigurations reads the configuration, and fails on settings the server must not run
+// without, such as malformed field encryption keys. The configuration read is returned either
+// way; other settings that cannot be read are logged and left at their defaults.
+func LoadConfigurations() (*Configurations, error) {
 	// Load environmental variables
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("..") // for testing
@@ -58,7 +232,7 @@ func NewConfigurations() *Configurations {
 	viper.SetConfigType("env")
 
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Println("could not read in config")
+		logger.Warn("could not read in config")
 	}
 
 	viper.AutomaticEnv()
@@ -79,6 +253,61 @@ func NewConfigurations() *Configurations {
 	viper.SetDefault("WORKSPACE_INVITE_TEMPLATE", "./templates/workspace_invite.html")
 	viper.SetDefault("WORKSPACE_WELCOME_TEMPLATE", "./templates/workspace_welcome.html")
 	viper.SetDefault("GOOGLE_OAUTH_V3", "https://www.googleapis.com/oauth2/v3/userinfo?access_token=:access_token")
+	viper.SetDefault("RATE_LIMIT_FREE", 300)
+	viper.SetDefault("RATE_LIMIT_PRO", 1200)
+	viper.SetDefault("RATE_LIMIT_ENTERPRISE", 6000)
+	viper.SetDefault("API_QUOTA_FREE", 10000)
+	viper.SetDefault("API_QUOTA_PRO", 100000)
+	viper.SetDefault("API_QUOTA_ENTERPRISE", 1000000)
+	viper.SetDefault("PASSWORD_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_REQUIRE_UPPER", true)
+	viper.SetDefault("PASSWORD_REQUIRE_LOWER", true)
+	viper.SetDefault("PASSWORD_REQUIRE_DIGIT", true)
+	viper.SetDefault("PASSWORD_REQUIRE_SYMBOL", false)
+	viper.SetDefault("COMMON_PASSWORDS_FILE", "./templates/common_passwords.txt")
+	viper.SetDefault("CLONE_MEMBER_LIMIT", 500)
+	viper.SetDefault("MAX_MEMBERS_PER_ORG", 0)
+	viper.SetDefault("FEATURE_FLAGS", defaultFeatureFlags)
+	viper.SetDefault("SERVER_TIMING", true)
+	viper.SetDefault("MAINTENANCE_MODE", false)
+	viper.SetDefault("MAINTENANCE_ALLOW_ROUTES", "/auth/login,/auth/logout")
+	viper.SetDefault("INVITE_EXPIRY_HOURS", 168)
+	viper.SetDefault("INVITE_MAX_EXPIRY_HOURS", 720)
+	viper.SetDefault("INVITE_RESEND_MINUTES", 5)
+	viper.SetDefault("OWNER_APPROVAL_HOURS", 48)
+	viper.SetDefault("URL_RESERVATION_MINUTES", 10)
+	viper.SetDefault("INACTIVE_MEMBER_DAYS", 90)
+	viper.SetDefault("SEARCH_COLLATION_STRENGTH", 0)
+	viper.SetDefault("DEFAULT_PAGE_SIZE", 10)
+	viper.SetDefault("MAX_PAGE_SIZE", 100)
+	viper.SetDefault("EXPIRY_REMINDER_DAYS", "7,1")
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("JSON_FIELD_CASE", SnakeCase)
+	viper.SetDefault("PROVISION_MISSING_CREATOR", false)
+	viper.SetDefault("ORG_CREATION_WAITLIST", false)
+	viper.SetDefault("WAITLIST_INVITE_CODES", "")
+	viper.SetDefault("ORG_NAME_SIMILARITY_THRESHOLD", 0.8)
+	viper.SetDefault("SUPPORTED_LOCALES", "en,fr,es,pt,de")
+	viper.SetDefault("DEFAULT_LOCALE", EnglishLocale)
+	viper.SetDefault("IMPERSONATION_TTL_MINUTES", 30)
+	viper.SetDefault("FIELD_ENCRYPTION_KEYS", "")
+	viper.SetDefault("USER_RETENTION_DAYS", 30)
+	viper.SetDefault("AUDIT_LOG_RETENTION_DAYS", "365")
+	viper.SetDefault("EMAIL_CHANGE_URL", "https://zuri.chat/confirm-email")
+	viper.SetDefault("TRUSTED_PROXIES", "")
+	viper.SetDefault("READ_FROM_SECONDARIES", false)
+	viper.SetDefault("HIDE_ORGANIZATION_EXISTENCE", false)
+	viper.SetDefault("PUBLIC_ORGANIZATION_MAX_AGE_SECONDS", 300)
+	viper.SetDefault("MAIL_RATE_PER_MINUTE", 600)
+	viper.SetDefault("MAIL_PRIORITY_RESERVE", 60)
+	viper.SetDefault("WEBHOOK_REPLAY_PER_SECOND", 5)
+	viper.SetDefault("WRITE_CONCERN_CRITICAL", "majority")
+	viper.SetDefault("WRITE_CONCERN_METERING", "1")
+	viper.SetDefault("REQUEST_TIMEOUT_SECONDS", 15)
+	viper.SetDefault("ROUTE_TIMEOUTS", defaultRouteTimeouts)
+	viper.SetDefault("CONTENT_TYPE_ENFORCEMENT", ContentTypeLenient)
+	viper.SetDefault("CONTENT_TYPE_EXEMPT_ROUTES", defaultContentTypeExemptRoutes)
 
 	configs := &Configurations{
 		ClusterURL:          mgURL,
@@ -119,7 +348,155 @@ func NewConfigurations() *Configurations {
 		// Agora details
 		AppId:         viper.GetString("APP_ID"),
 		AppCerificate: viper.GetString("APP_CERTIFICATE"),
+
+		DiagnosticsToken: viper.GetString("DIAGNOSTICS_TOKEN"),
+		CloneMemberLimit: viper.GetInt("CLONE_MEMBER_LIMIT"),
+		MaxMembersPerOrg: viper.GetInt64("MAX_MEMBERS_PER_ORG"),
+		ServerTiming:     viper.GetBool("SERVER_TIMING"),
+		MaintenanceMode:  viper.GetBool("MAINTENANCE_MODE"),
+		InviteExpiry:     time.Duration(viper.GetInt("INVITE_EXPIRY_HOURS")) * time.Hour,
+		InviteMaxExpiry:  time.Duration(viper.GetInt("INVITE_MAX_EXPIRY_HOURS")) * time.Hour,
+
+		InactiveMemberWindow: time.Duration(viper.GetInt("INACTIVE_MEMBER_DAYS")) * 24 * time.Hour,
+		JSONFieldCase:        viper.GetString("JSON_FIELD_CASE"),
+
+		ProvisionMissingCreator: viper.GetBool("PROVISION_MISSING_CREATOR"),
+		DefaultLocale:           viper.GetString("DEFAULT_LOCALE"),
+		ImpersonationTTL:        time.Duration(viper.GetInt("IMPERSONATION_TTL_MINUTES")) * time.Minute,
+		UserRetention:           time.Duration(viper.GetInt("USER_RETENTION_DAYS")) * 24 * time.Hour,
+		EmailChangeURL:          viper.GetString("EMAIL_CHANGE_URL"),
+		ReadFromSecondaries:     viper.GetBool("READ_FROM_SECONDARIES"),
+		RequestTimeout:          time.Duration(viper.GetInt("REQUEST_TIMEOUT_SECONDS")) * time.Second,
+		ContentTypeEnforcement:  viper.GetString("CONTENT_TYPE_ENFORCEMENT"),
+
+		RateLimits: map[string]int{
+			"free":       viper.GetInt("RATE_LIMIT_FREE"),
+			"pro":        viper.GetInt("RATE_LIMIT_PRO"),
+			"enterprise": viper.GetInt("RATE_LIMIT_ENTERPRISE"),
+		},
+
+		APIQuotas: map[string]int{
+			"free":       viper.GetInt("API_QUOTA_FREE"),
+			"pro":        viper.GetInt("API_QUOTA_PRO"),
+			"enterprise": viper.GetInt("API_QUOTA_ENTERPRISE"),
+		},
+
+		PasswordPolicy: &PasswordPolicy{
+			MinLength:     viper.GetInt("PASSWORD_MIN_LENGTH"),
+			RequireUpper:  viper.GetBool("PASSWORD_REQUIRE_UPPER"),
+			RequireLower:  viper.GetBool("PASSWORD_REQUIRE_LOWER"),
+			RequireDigit:  viper.GetBool("PASSWORD_REQUIRE_DIGIT"),
+			RequireSymbol: viper.GetBool("PASSWORD_REQUIRE_SYMBOL"),
+		},
 	}
 
-	return configs
+	configs.OrgCreationWaitlist = viper.GetBool("ORG_CREATION_WAITLIST")
+
+	for _, code := range strings.Split(viper.GetString("WAITLIST_INVITE_CODES"), ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			configs.WaitlistInviteCodes = append(configs.WaitlistInviteCodes, code)
+		}
+	}
+
+	for _, route := range strings.Split(viper.GetString("MAINTENANCE_ALLOW_ROUTES"), ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			configs.MaintenanceAllowRoutes = append(configs.MaintenanceAllowRoutes, route)
+		}
+	}
+
+	configs.OrgNameSimilarityThreshold = viper.GetFloat64("ORG_NAME_SIMILARITY_THRESHOLD")
+	configs.MailRatePerMinute, configs.MailPriorityReserve = viper.GetInt("MAIL_RATE_PER_MINUTE"), viper.GetInt("MAIL_PRIORITY_RESERVE")
+	configs.WebhookReplayPerSecond = viper.GetFloat64("WEBHOOK_REPLAY_PER_SECOND")
+	configs.HideOrganizationExistence = viper.GetBool("HIDE_ORGANIZATION_EXISTENCE")
+	configs.PublicOrganizationMaxAge = time.Duration(viper.GetInt("PUBLIC_ORGANIZATION_MAX_AGE_SECONDS")) * time.Second
+	configs.WriteConcerns = map[string]string{
+		WriteCritical: viper.GetString("WRITE_CONCERN_CRITICAL"),
+		WriteMetering: viper.GetString("WRITE_CONCERN_METERING"),
+	}
+	configs.InviteResendInterval = time.Duration(viper.GetInt("INVITE_RESEND_MINUTES")) * time.Minute
+	configs.OwnerApprovalWindow = time.Duration(viper.GetInt("OWNER_APPROVAL_HOURS")) * time.Hour
+	configs.URLReservationTTL = time.Duration(viper.GetInt("URL_RESERVATION_MINUTES")) * time.Minute
+	configs.LogLevel, configs.LogFormat = viper.GetString("LOG_LEVEL"), viper.GetString("LOG_FORMAT")
+	configs.SearchCollationStrength = viper.GetInt("SEARCH_COLLATION_STRENGTH")
+	configs.Pagination = Pagination{DefaultPerPage: viper.GetInt("DEFAULT_PAGE_SIZE"), MaxPerPage: viper.GetInt("MAX_PAGE_SIZE")}
+
+	// audit logs are kept forever when retention is never
+	if retention := viper.GetString("AUDIT_LOG_RETENTION_DAYS"); retention != "never" {
+		days, err := strconv.Atoi(retention)
+		if err != nil {
+			logger.Warn("could not read audit log retention: %v", err)
+		}
+
+		configs.AuditLogRetention = time.Duration(days) * 24 * time.Hour
+	}
+
+	for _, day := range strings.Split(viper.GetString("EXPIRY_REMINDER_DAYS"), ",") {
+		if day = strings.TrimSpace(day); day == "" {
+			continue
+		}
+
+		days, err := strconv.Atoi(day)
+		if err != nil || days < 1 {
+			logger.Warn("could not read expiry reminder days: %s", day)
+			continue
+		}
+
+		configs.ExpiryReminderDays = append(configs.ExpiryReminderDays, days)
+	}
+
+	for _, route := range strings.Split(viper.GetString("CONTENT_TYPE_EXEMPT_ROUTES"), ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			configs.ContentTypeExemptRoutes = append(configs.ContentTypeExemptRoutes, route)
+		}
+	}
+
+	for _, locale := range strings.Split(viper.GetString("SUPPORTED_LOCALES"), ",") {
+		if locale = strings.TrimSpace(locale); locale != "" {
+			configs.SupportedLocales = append(configs.SupportedLocales, locale)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(viper.GetString("FEATURE_FLAGS")), &configs.FeatureFlags); err != nil {
+		logger.Warn("could not read feature flags: %v", err)
+	}
+
+	var routeTimeouts map[string]int
+	if err := json.Unmarshal([]byte(viper.GetString("ROUTE_TIMEOUTS")), &routeTimeouts); err != nil {
+		logger.Warn("could not read route timeouts: %v", err)
+	}
+
+	configs.RouteTimeouts = make(map[string]time.Duration, len(routeTimeouts))
+	for route, seconds := range routeTimeouts {
+		configs.RouteTimeouts[route] = time.Duration(seconds) * time.Second
+	}
+
+	// values would be stored unencrypted or could not be read back, so there is no safe default
+	fieldKeys, fieldKeysErr := ParseFieldKeys(viper.GetString("FIELD_ENCRYPTION_KEYS"))
+	if fieldKeysErr != nil {
+		fieldKeysErr = fmt.Errorf("could not read field encryption keys: %w", fieldKeysErr)
+	}
+
+	configs.FieldEncryptionKeys = fieldKeys
+
+	var proxies []string
+
+	for _, proxy := range strings.Split(viper.GetString("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	var err error
+	if configs.TrustedProxies, err = ParseCIDRs(proxies); err != nil {
+		logger.Warn("could not read trusted proxies: %v", err)
+	}
+
+	commonPasswords, err := LoadCommonPasswords(viper.GetString("COMMON_PASSWORDS_FILE"))
+	if err != nil {
+		logger.Warn("could not load common passwords: %v", err)
+	}
+
+	configs.PasswordPolicy.CommonPasswords = commonPasswords
+
+	return configs, fieldKeysErr
 }
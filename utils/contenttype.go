@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+)
+
+// How strictly requests that change data must declare a JSON body.
+const (
+	// content types are not checked
+	ContentTypeOff = "off"
+	// bodies declared as anything but JSON are refused, undeclared ones are let through
+	ContentTypeLenient = "lenient"
+	// bodies must be declared as JSON
+	ContentTypeStrict = "strict"
+)
+
+var ErrUnsupportedMediaType = errors.New("request body must be JSON, send it with Content-Type: application/json")
+
+// CheckJSONContentType returns ErrUnsupportedMediaType when a request sends a body that is not
+// declared as JSON, as strictly as mode asks. Requests without a body are never refused.
+func CheckJSONContentType(r *http.Request, mode string) error {
+	if mode == ContentTypeOff || r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		if mode == ContentTypeStrict {
+			return ErrUnsupportedMediaType
+		}
+
+		return nil
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+		return ErrUnsupportedMediaType
+	}
+
+	return nil
+}
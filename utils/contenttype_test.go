@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+func TestCheckJSONContentType(t *testing.T) {
+	tests := []struct {
+		name, mode, contentType string
+		body                    []byte
+		ok                      bool
+	}{
+		{"json is accepted", ContentTypeStrict, "application/json", []byte(`{}`), true},
+		{"json with a charset is accepted", ContentTypeStrict, "application/json; charset=utf-8", []byte(`{}`), true},
+		{"plain text is refused", ContentTypeLenient, "text/plain", []byte(`{}`), false},
+		{"forms are refused", ContentTypeLenient, "application/x-www-form-urlencoded", []byte(`a=b`), false},
+		{"malformed types are refused", ContentTypeLenient, "application/", []byte(`{}`), false},
+		{"a missing type is let through when lenient", ContentTypeLenient, "", []byte(`{}`), true},
+		{"a missing type is refused when strict", ContentTypeStrict, "", []byte(`{}`), false},
+		{"requests without a body are never refused", ContentTypeStrict, "text/plain", nil, true},
+		{"nothing is refused when off", ContentTypeOff, "text/plain", []byte(`{}`), true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var req *http.Request
+			if tc.body == nil {
+				req, _ = http.NewRequest("POST", "/users", nil)
+			} else {
+				req, _ = http.NewRequest("POST", "/users", bytes.NewBuffer(tc.body))
+			}
+
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+
+			if err := CheckJSONContentType(req, tc.mode); (err == nil) != tc.ok {
+				t.Errorf("expected ok %v, got %v", tc.ok, err)
+			}
+		})
+	}
+}
@@ -2,15 +2,21 @@ package utils
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"zuri.chat/zccore/logger"
 )
 
 type MongoDBHandle struct {
@@ -44,15 +50,28 @@ func ConnectToDB(clusterURL string) error {
 		ec.Check(defaultMongoHandle.Connect(clusterURL))
 		ec.Check(CreateUniqueIndex("users", "email", 1))
 		ec.Check(CreateUniqueIndex("plugins", "template_url", 1))
+		ec.Check(CreateCompoundUniqueIndex("teams", "org_id", "name_key"))
 		ec.Check(CreateTextIndexForPlugins())
 	})
 
+	if ec.err != nil {
+		logger.Error("could not connect to MongoDB: %v", ec.err)
+	}
+
 	return ec.err
 }
 
 func (mh *MongoDBHandle) Connect(clusterURL string) error {
 	clientOptions := options.Client().ApplyURI(clusterURL)
 
+	// follow the connection pool and commands for diagnostics
+	clientOptions.SetPoolMonitor(&event.PoolMonitor{Event: monitor.poolEvent})
+	clientOptions.SetMonitor(monitor.commandMonitor())
+
+	if clientOptions.MaxPoolSize != nil {
+		monitor.maxPoolSize = *clientOptions.MaxPoolSize
+	}
+
 	client, err := mongo.NewClient(clientOptions)
 	if err != nil {
 		return err
@@ -77,14 +96,39 @@ func (mh *MongoDBHandle) Connect(clusterURL string) error {
 	return nil
 }
 
-func (mh *MongoDBHandle) GetCollection(collectionName string) *mongo.Collection {
+func (mh *MongoDBHandle) GetCollection(collectionName string, opts ...*options.CollectionOptions) *mongo.Collection {
 	DBName := Env("DB_NAME")
-	return mh.client.Database(DBName).Collection(collectionName)
+	return mh.client.Database(DBName).Collection(collectionName, opts...)
 }
 
 // GetCollection return collection for the db in DB_NAME env variable.
-func GetCollection(collectionName string) *mongo.Collection {
-	return defaultMongoHandle.GetCollection(collectionName)
+func GetCollection(collectionName string, opts ...*options.CollectionOptions) *mongo.Collection {
+	return defaultMongoHandle.GetCollection(collectionName, opts...)
+}
+
+// ReadPreference picks where read-only handlers read from. Secondaries take load off the primary
+// at the cost of possibly lagging behind it, so anything that reads back its own writes must
+// stay on the primary.
+func ReadPreference(fromSecondaries bool) *readpref.ReadPref {
+	if fromSecondaries {
+		return readpref.SecondaryPreferred()
+	}
+
+	return readpref.Primary()
+}
+
+// readFrom returns the collection options reading from rp, or none to read from the primary.
+func readFrom(rp *readpref.ReadPref) []*options.CollectionOptions {
+	if rp == nil {
+		return nil
+	}
+
+	return []*options.CollectionOptions{options.Collection().SetReadPreference(rp)}
+}
+
+// GetCollectionWithWriteConcern returns a collection writing with wc. See WriteConcern.
+func GetCollectionWithWriteConcern(collectionName string, wc *writeconcern.WriteConcern) *mongo.Collection {
+	return defaultMongoHandle.GetCollection(collectionName, writeWith(wc)...)
 }
 
 func (mh *MongoDBHandle) Client() *mongo.Client {
@@ -103,9 +147,13 @@ func GetMongoDBCollection(dbname, collectionName string) (*mongo.Collection, err
 }
 
 // get MongoDb documents for a collection.
-func GetMongoDBDocs(collectionName string, filter map[string]interface{}, opts ...*options.FindOptions) ([]bson.M, error) {
-	ctx := context.Background()
-	collection := defaultMongoHandle.GetCollection(collectionName)
+func GetMongoDBDocs(ctx context.Context, collectionName string, filter map[string]interface{}, opts ...*options.FindOptions) ([]bson.M, error) {
+	return GetMongoDBDocsWithReadPref(ctx, collectionName, nil, filter, opts...)
+}
+
+// get MongoDb documents for a collection, reading from rp. See ReadPreference.
+func GetMongoDBDocsWithReadPref(ctx context.Context, collectionName string, rp *readpref.ReadPref, filter map[string]interface{}, opts ...*options.FindOptions) ([]bson.M, error) {
+	collection := defaultMongoHandle.GetCollection(collectionName, readFrom(rp)...)
 
 	var data []bson.M
 
@@ -122,9 +170,13 @@ func GetMongoDBDocs(collectionName string, filter map[string]interface{}, opts .
 }
 
 // get single MongoDb document for a collection.
-func GetMongoDBDoc(collectionName string, filter map[string]interface{}, opts ...*options.FindOneOptions) (bson.M, error) {
-	ctx := context.Background()
-	collection := defaultMongoHandle.GetCollection(collectionName)
+func GetMongoDBDoc(ctx context.Context, collectionName string, filter map[string]interface{}, opts ...*options.FindOneOptions) (bson.M, error) {
+	return GetMongoDBDocWithReadPref(ctx, collectionName, nil, filter, opts...)
+}
+
+// get single MongoDb document for a collection, reading from rp. See ReadPreference.
+func GetMongoDBDocWithReadPref(ctx context.Context, collectionName string, rp *readpref.ReadPref, filter map[string]interface{}, opts ...*options.FindOneOptions) (bson.M, error) {
+	collection := defaultMongoHandle.GetCollection(collectionName, readFrom(rp)...)
 
 	var data bson.M
 	if err := collection.FindOne(ctx, MapToBson(filter), opts...).Decode(&data); err != nil {
@@ -134,9 +186,13 @@ func GetMongoDBDoc(collectionName string, filter map[string]interface{}, opts ..
 	return data, nil
 }
 
-func CreateMongoDBDoc(collectionName string, data map[string]interface{}) (*mongo.InsertOneResult, error) {
-	ctx := context.Background()
-	collection := defaultMongoHandle.GetCollection(collectionName)
+func CreateMongoDBDoc(ctx context.Context, collectionName string, data map[string]interface{}) (*mongo.InsertOneResult, error) {
+	return CreateMongoDBDocWithWriteConcern(ctx, collectionName, nil, data)
+}
+
+// create a MongoDb document, writing with wc. See WriteConcern.
+func CreateMongoDBDocWithWriteConcern(ctx context.Context, collectionName string, wc *writeconcern.WriteConcern, data map[string]interface{}) (*mongo.InsertOneResult, error) {
+	collection := defaultMongoHandle.GetCollection(collectionName, writeWith(wc)...)
 	res, err := collection.InsertOne(ctx, MapToBson(data))
 
 	if err != nil {
@@ -146,8 +202,46 @@ func CreateMongoDBDoc(collectionName string, data map[string]interface{}) (*mong
 	return res, nil
 }
 
-func CreateManyMongoDBDocs(collectionName string, data []interface{}) (*mongo.InsertManyResult, error) {
-	ctx := context.Background()
+// IsDuplicateKeyError reports whether a write failed because it violated a unique index.
+func IsDuplicateKeyError(err error) bool {
+	return err != nil && mongo.IsDuplicateKeyError(err)
+}
+
+var (
+	dupKeyField = regexp.MustCompile(`dup key: \{ ?"?([\w.]+)"?:`)
+	dupKeyIndex = regexp.MustCompile(`index: ([\w.]+?)(?:_-?1)+ `)
+)
+
+// DuplicateKeyField returns the field of the unique index a write violated, or "" if it is not known.
+func DuplicateKeyField(err error) string {
+	if !IsDuplicateKeyError(err) {
+		return ""
+	}
+
+	if m := dupKeyField.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+
+	// older servers leave the field out of the key, but it is still part of the index name
+	if m := dupKeyIndex.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// DuplicateKeyError describes a duplicate key error without the database details, so it can be
+// shown to clients.
+func DuplicateKeyError(err error) error {
+	field := DuplicateKeyField(err)
+	if field == "" {
+		return errors.New("a record with these details already exists")
+	}
+
+	return fmt.Errorf("%s is already taken", strings.ReplaceAll(field, "_", " "))
+}
+
+func CreateManyMongoDBDocs(ctx context.Context, collectionName string, data []interface{}) (*mongo.InsertManyResult, error) {
 	collection := defaultMongoHandle.GetCollection(collectionName)
 	res, err := collection.InsertMany(ctx, data)
 
@@ -159,9 +253,13 @@ func CreateManyMongoDBDocs(collectionName string, data []interface{}) (*mongo.In
 }
 
 // Update single MongoDb document for a collection.
-func UpdateOneMongoDBDoc(collectionName, id string, data map[string]interface{}) (*mongo.UpdateResult, error) {
-	ctx := context.Background()
-	collection := defaultMongoHandle.GetCollection(collectionName)
+func UpdateOneMongoDBDoc(ctx context.Context, collectionName, id string, data map[string]interface{}) (*mongo.UpdateResult, error) {
+	return UpdateOneMongoDBDocWithWriteConcern(ctx, collectionName, nil, id, data)
+}
+
+// Update single MongoDb document for a collection, writing with wc. See WriteConcern.
+func UpdateOneMongoDBDocWithWriteConcern(ctx context.Context, collectionName string, wc *writeconcern.WriteConcern, id string, data map[string]interface{}) (*mongo.UpdateResult, error) {
+	collection := defaultMongoHandle.GetCollection(collectionName, writeWith(wc)...)
 
 	_id, _ := primitive.ObjectIDFromHex(id)
 	filter := bson.M{"_id": _id}
@@ -178,8 +276,7 @@ func UpdateOneMongoDBDoc(collectionName, id string, data map[string]interface{})
 }
 
 // Update single MongoDb document for a collection.
-func IncrementOneMongoDBDocField(collectionName, id, field string) (*mongo.UpdateResult, error) {
-	ctx := context.Background()
+func IncrementOneMongoDBDocField(ctx context.Context, collectionName, id, field string) (*mongo.UpdateResult, error) {
 	collection := defaultMongoHandle.GetCollection(collectionName)
 
 	_id, _ := primitive.ObjectIDFromHex(id)
@@ -199,9 +296,13 @@ func IncrementOneMongoDBDocField(collectionName, id, field string) (*mongo.Updat
 }
 
 // This methods allows update of any kind e.g array increment, object embedding etc by passing the raw update data.
-func GenericUpdateOneMongoDBDoc(collectionName string, id interface{}, updateData map[string]interface{}) (*mongo.UpdateResult, error) {
-	ctx := context.Background()
-	collection := defaultMongoHandle.GetCollection(collectionName)
+func GenericUpdateOneMongoDBDoc(ctx context.Context, collectionName string, id interface{}, updateData map[string]interface{}) (*mongo.UpdateResult, error) {
+	return GenericUpdateOneMongoDBDocWithWriteConcern(ctx, collectionName, nil, id, updateData)
+}
+
+// GenericUpdateOneMongoDBDoc, writing with wc. See WriteConcern.
+func GenericUpdateOneMongoDBDocWithWriteConcern(ctx context.Context, collectionName string, wc *writeconcern.WriteConcern, id interface{}, updateData map[string]interface{}) (*mongo.UpdateResult, error) {
+	collection := defaultMongoHandle.GetCollection(collectionName, writeWith(wc)...)
 
 	filter := bson.M{"_id": id}
 
@@ -215,8 +316,7 @@ func GenericUpdateOneMongoDBDoc(collectionName string, id interface{}, updateDat
 }
 
 // Update many MongoDb documents for a collection.
-func UpdateManyMongoDBDocs(collectionName string, filter, data map[string]interface{}) (*mongo.UpdateResult, error) {
-	ctx := context.Background()
+func UpdateManyMongoDBDocs(ctx context.Context, collectionName string, filter, data map[string]interface{}) (*mongo.UpdateResult, error) {
 	collection := defaultMongoHandle.GetCollection(collectionName)
 	updateData := bson.M{"$set": MapToBson(data)}
 
@@ -230,8 +330,7 @@ func UpdateManyMongoDBDocs(collectionName string, filter, data map[string]interf
 }
 
 // Replace a document with new data but preserve its id.
-func ReplaceMongoDBDoc(collectionName string, filter, data map[string]interface{}) (*mongo.UpdateResult, error) {
-	ctx := context.Background()
+func ReplaceMongoDBDoc(ctx context.Context, collectionName string, filter, data map[string]interface{}) (*mongo.UpdateResult, error) {
 	collection := defaultMongoHandle.GetCollection(collectionName)
 
 	res, err := collection.ReplaceOne(ctx, MapToBson(filter), MapToBson(data))
@@ -244,8 +343,7 @@ func ReplaceMongoDBDoc(collectionName string, filter, data map[string]interface{
 }
 
 // Delete single MongoDb document for a collection.
-func DeleteOneMongoDBDoc(collectionName, id string) (*mongo.DeleteResult, error) {
-	ctx := context.Background()
+func DeleteOneMongoDBDoc(ctx context.Context, collectionName, id string) (*mongo.DeleteResult, error) {
 	collection := defaultMongoHandle.GetCollection(collectionName)
 
 	_id, err := primitive.ObjectIDFromHex(id)
@@ -264,8 +362,7 @@ func DeleteOneMongoDBDoc(collectionName, id string) (*mongo.DeleteResult, error)
 }
 
 // Delete many MongoDb documents for a collection.
-func DeleteManyMongoDBDoc(collectionName string, filter map[string]interface{}) (*mongo.DeleteResult, error) {
-	ctx := context.Background()
+func DeleteManyMongoDBDoc(ctx context.Context, collectionName string, filter map[string]interface{}) (*mongo.DeleteResult, error) {
 	collection := defaultMongoHandle.GetCollection(collectionName)
 
 	res, err := collection.DeleteMany(ctx, filter)
@@ -298,6 +395,32 @@ func CreateUniqueIndex(collName, field string, order int) error {
 	return nil
 }
 
+// CreateCompoundUniqueIndex makes the combination of the fields unique in a collection.
+func CreateCompoundUniqueIndex(collName string, fields ...string) error {
+	collection := defaultMongoHandle.GetCollection(collName)
+
+	keys := bson.D{}
+	for _, field := range fields {
+		keys = append(keys, bson.E{Key: field, Value: 1})
+	}
+
+	indexModel := mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetUnique(true),
+	}
+
+	timeOutFactor := 3
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeOutFactor)*time.Second)
+
+	defer cancel()
+
+	if _, err := collection.Indexes().CreateOne(ctx, indexModel); err != nil {
+		return fmt.Errorf("failed to create unique index on fields %v in %s", fields, collName)
+	}
+
+	return nil
+}
+
 func CreateTextIndexForPlugins() error {
 	collection := defaultMongoHandle.GetCollection("plugins")
 
@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func duplicateKeyErr(message string) error {
+	return mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000, Message: message}}}
+}
+
+func TestDuplicateKeyError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		message string
+	}{
+		{
+			name:    "field in key",
+			err:     duplicateKeyErr(`E11000 duplicate key error collection: zurichat.users index: email_1 dup key: { email: "a@b.c" }`),
+			message: "email is already taken",
+		},
+		{
+			name:    "quoted field in key",
+			err:     duplicateKeyErr(`E11000 duplicate key error collection: zurichat.organizations index: workspace_url_1 dup key: { "workspace_url": "acme" }`),
+			message: "workspace url is already taken",
+		},
+		{
+			name:    "field only in index name",
+			err:     duplicateKeyErr(`E11000 duplicate key error collection: zurichat.plugins index: template_url_1 dup key: { : "https://a.b" }`),
+			message: "template url is already taken",
+		},
+		{
+			name:    "unknown field",
+			err:     duplicateKeyErr("E11000 duplicate key error"),
+			message: "a record with these details already exists",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DuplicateKeyError(tt.err).Error(); got != tt.message {
+				t.Errorf("got %q, want %q", got, tt.message)
+			}
+		})
+	}
+}
+
+func TestGetWriteError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{
+			name:    "duplicate key",
+			err:     duplicateKeyErr(`E11000 duplicate key error collection: zurichat.users index: email_1 dup key: { email: "a@b.c" }`),
+			status:  http.StatusConflict,
+			message: "email is already taken",
+		},
+		{
+			name:    "other error",
+			err:     errors.New("connection refused"),
+			status:  http.StatusInternalServerError,
+			message: "connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			GetWriteError(tt.err, rr)
+
+			if rr.Code != tt.status {
+				t.Errorf("got status %d, want %d", rr.Code, tt.status)
+			}
+
+			var res ErrorResponse
+			if err := json.NewDecoder(rr.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+
+			if res.ErrorMessage != tt.message {
+				t.Errorf("got message %q, want %q", res.ErrorMessage, tt.message)
+			}
+		})
+	}
+}
+
+func TestReadPreference(t *testing.T) {
+	if mode := ReadPreference(true).Mode(); mode != readpref.SecondaryPreferredMode {
+		t.Errorf("expected secondary preferred reads, got %v", mode)
+	}
+
+	if mode := ReadPreference(false).Mode(); mode != readpref.PrimaryMode {
+		t.Errorf("expected primary reads, got %v", mode)
+	}
+}
+
+// TestReadPreferenceIsApplied needs a replica set in CLUSTER_URL, read preferences only reach
+// the server there.
+func TestReadPreferenceIsApplied(t *testing.T) {
+	clusterURL := os.Getenv("CLUSTER_URL")
+	if clusterURL == "" {
+		t.Skip("CLUSTER_URL is not set")
+	}
+
+	var (
+		mu    sync.Mutex
+		modes []string
+	)
+
+	commandMonitor := &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			if evt.CommandName != "find" {
+				return
+			}
+
+			mode, _ := evt.Command.Lookup("$readPreference", "mode").StringValueOK()
+
+			mu.Lock()
+			modes = append(modes, mode)
+			mu.Unlock()
+		},
+	}
+
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(clusterURL).SetMonitor(commandMonitor))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer client.Disconnect(ctx)
+
+	var hello bson.M
+	if err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+		t.Fatal(err)
+	}
+
+	if hello["setName"] == nil {
+		t.Skip("CLUSTER_URL is not a replica set")
+	}
+
+	handle := defaultMongoHandle
+	defaultMongoHandle = &MongoDBHandle{client: client}
+
+	defer func() { defaultMongoHandle = handle }()
+
+	if _, err = GetMongoDBDocsWithReadPref(context.TODO(), "organizations", ReadPreference(true), bson.M{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = GetMongoDBDocs(context.TODO(), "organizations", bson.M{}); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(modes) != 2 {
+		t.Fatalf("expected 2 finds, got %d", len(modes))
+	}
+
+	if modes[0] != "secondaryPreferred" {
+		t.Errorf("expected the read to prefer secondaries, got %q", modes[0])
+	}
+
+	if modes[1] == "secondaryPreferred" {
+		t.Error("expected reads without a read preference to stay on the primary")
+	}
+}
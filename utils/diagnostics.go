@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	slowQueryThreshold = 100 * time.Millisecond
+	slowQueryHistory   = 10
+	defaultMaxPoolSize = 100
+)
+
+// SlowQuery is a database command that took longer than slowQueryThreshold.
+type SlowQuery struct {
+	Command    string    `json:"command"`
+	Database   string    `json:"database"`
+	Collection string    `json:"collection,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	Failed     bool      `json:"failed"`
+	At         time.Time `json:"at"`
+}
+
+// PoolStats describes how much of the database connection pool is in use.
+type PoolStats struct {
+	Open        int64   `json:"open"`
+	InUse       int64   `json:"in_use"`
+	MaxSize     uint64  `json:"max_size"`
+	Utilization float64 `json:"utilization"`
+}
+
+type MemoryStats struct {
+	AllocBytes      uint64 `json:"alloc_bytes"`
+	TotalAllocBytes uint64 `json:"total_alloc_bytes"`
+	SysBytes        uint64 `json:"sys_bytes"`
+	HeapObjects     uint64 `json:"heap_objects"`
+	NumGC           uint32 `json:"num_gc"`
+}
+
+// Diagnostics is a snapshot of the server's health, used to triage incidents.
+type Diagnostics struct {
+	DBLatencyMS float64     `json:"db_latency_ms"`
+	DBError     string      `json:"db_error,omitempty"`
+	Goroutines  int         `json:"goroutines"`
+	Memory      MemoryStats `json:"memory"`
+	Pool        PoolStats   `json:"pool"`
+	SlowQueries []SlowQuery `json:"slow_queries"`
+}
+
+// dbMonitor follows connection pool and command events of the mongo client.
+type dbMonitor struct {
+	open        int64
+	inUse       int64
+	maxPoolSize uint64
+
+	mu      sync.Mutex
+	started map[int64]SlowQuery
+	slow    []SlowQuery
+}
+
+var monitor = &dbMonitor{maxPoolSize: defaultMaxPoolSize, started: make(map[int64]SlowQuery)}
+
+func (m *dbMonitor) poolEvent(evt *event.PoolEvent) {
+	switch evt.Type {
+	case event.ConnectionCreated:
+		atomic.AddInt64(&m.open, 1)
+	case event.ConnectionClosed:
+		atomic.AddInt64(&m.open, -1)
+	case event.GetSucceeded:
+		atomic.AddInt64(&m.inUse, 1)
+	case event.ConnectionReturned:
+		atomic.AddInt64(&m.inUse, -1)
+	}
+}
+
+func (m *dbMonitor) commandStarted(_ context.Context, evt *event.CommandStartedEvent) {
+	q := SlowQuery{Command: evt.CommandName, Database: evt.DatabaseName}
+	if coll, ok := evt.Command.Lookup(evt.CommandName).StringValueOK(); ok {
+		q.Collection = coll
+	}
+
+	m.mu.Lock()
+	m.started[evt.RequestID] = q
+	m.mu.Unlock()
+}
+
+func (m *dbMonitor) commandFinished(evt event.CommandFinishedEvent, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q, ok := m.started[evt.RequestID]
+	delete(m.started, evt.RequestID)
+
+	duration := time.Duration(evt.DurationNanos)
+	if !ok || duration < slowQueryThreshold {
+		return
+	}
+
+	q.DurationMS = duration.Milliseconds()
+	q.Failed = failed
+	q.At = time.Now()
+
+	m.slow = append(m.slow, q)
+	if len(m.slow) > slowQueryHistory {
+		m.slow = m.slow[len(m.slow)-slowQueryHistory:]
+	}
+}
+
+func (m *dbMonitor) commandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: m.commandStarted,
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			AddDBTime(ctx, time.Duration(evt.DurationNanos))
+			m.commandFinished(evt.CommandFinishedEvent, false)
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			AddDBTime(ctx, time.Duration(evt.DurationNanos))
+			m.commandFinished(evt.CommandFinishedEvent, true)
+		},
+	}
+}
+
+func (m *dbMonitor) slowQueries() []SlowQuery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queries := make([]SlowQuery, len(m.slow))
+	copy(queries, m.slow)
+
+	return queries
+}
+
+// GetDiagnostics collects a snapshot of database latency, runtime and connection pool statistics.
+func GetDiagnostics(ctx context.Context) *Diagnostics {
+	d := &Diagnostics{
+		Goroutines:  runtime.NumGoroutine(),
+		SlowQueries: monitor.slowQueries(),
+	}
+
+	var ms runtime.MemStats
+
+	runtime.ReadMemStats(&ms)
+
+	d.Memory = MemoryStats{
+		AllocBytes:      ms.Alloc,
+		TotalAllocBytes: ms.TotalAlloc,
+		SysBytes:        ms.Sys,
+		HeapObjects:     ms.HeapObjects,
+		NumGC:           ms.NumGC,
+	}
+
+	d.Pool = PoolStats{
+		Open:    atomic.LoadInt64(&monitor.open),
+		InUse:   atomic.LoadInt64(&monitor.inUse),
+		MaxSize: monitor.maxPoolSize,
+	}
+	d.Pool.Utilization = float64(d.Pool.InUse) / float64(d.Pool.MaxSize)
+
+	client := GetDefaultMongoClient()
+	if client == nil {
+		d.DBError = "database is not connected"
+		return d
+	}
+
+	start := time.Now()
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		d.DBError = err.Error()
+	}
+
+	d.DBLatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+	return d
+}
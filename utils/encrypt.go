@@ -7,6 +7,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"io"
 )
 
@@ -64,6 +65,24 @@ func GCMEncrypt(data []byte, passphrase string) []byte {
 	return ciphertext
 }
 
+// GCMDecrypt opens data sealed by GCMEncrypt with the same passphrase.
+func GCMDecrypt(data []byte, passphrase string) ([]byte, error) {
+	block, _ := aes.NewCipher([]byte(createHash(passphrase)))
+	gcm, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
 func Decrypt(key, text string) string {
 	block, err := aes.NewCipher([]byte(key))
 	if err != nil {
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGCMDecrypt(t *testing.T) {
+	plain := []byte("sk_live_plugin_secret")
+	sealed := GCMEncrypt(plain, "passphrase")
+
+	if bytes.Contains(sealed, plain) {
+		t.Fatal("expected sealed data not to contain the plain text")
+	}
+
+	opened, err := GCMDecrypt(sealed, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(opened, plain) {
+		t.Errorf("expected %q, got %q", plain, opened)
+	}
+
+	if _, err := GCMDecrypt(sealed, "wrong passphrase"); err == nil {
+		t.Error("expected opening with the wrong passphrase to fail")
+	}
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// encryptedFieldPrefix marks a stored value as sealed by EncryptField, as "enc:<key id>:<base64>".
+const encryptedFieldPrefix = "enc:"
+
+// FieldKey is one AES key used to encrypt sensitive fields. Its ID is stored in front of
+// every value it seals, so values written before a key rotation can still be read.
+type FieldKey struct {
+	ID  string
+	Key []byte
+}
+
+// ParseFieldKeys reads comma separated "id:base64 key" pairs. The first key encrypts new
+// values; the rest are kept only to read values sealed before the keys were rotated.
+func ParseFieldKeys(s string) ([]FieldKey, error) {
+	var keys []FieldKey
+
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("field key %q must be written as id:key", pair)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("field key %s is not valid base64", parts[0])
+		}
+
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("field key %s must be 16, 24 or 32 bytes long", parts[0])
+		}
+
+		for _, k := range keys {
+			if k.ID == parts[0] {
+				return nil, fmt.Errorf("field key %s is listed twice", parts[0])
+			}
+		}
+
+		keys = append(keys, FieldKey{ID: parts[0], Key: key})
+	}
+
+	return keys, nil
+}
+
+func fieldGCM(key FieldKey) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// EncryptField seals plaintext with the first of keys. Empty values, and every value when
+// no keys are configured, are returned unchanged.
+func EncryptField(plaintext string, keys []FieldKey) (string, error) {
+	if plaintext == "" || len(keys) == 0 {
+		return plaintext, nil
+	}
+
+	key := keys[0]
+
+	gcm, err := fieldGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(key.ID))
+
+	return encryptedFieldPrefix + key.ID + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptField opens a value sealed by EncryptField with the key it names. Values without
+// the prefix were stored before encryption was turned on and are returned as they are.
+func DecryptField(value string, keys []FieldKey) (string, error) {
+	if !strings.HasPrefix(value, encryptedFieldPrefix) {
+		return value, nil
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, encryptedFieldPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", errors.New("malformed encrypted field")
+	}
+
+	var key *FieldKey
+
+	for i := range keys {
+		if keys[i].ID == parts[0] {
+			key = &keys[i]
+			break
+		}
+	}
+
+	if key == nil {
+		return "", fmt.Errorf("no field key with id %s", parts[0])
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.New("malformed encrypted field")
+	}
+
+	gcm, err := fieldGCM(*key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted field")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(key.ID))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
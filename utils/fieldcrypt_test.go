@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testFieldKey(id string, b byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func TestEncryptFieldRoundTrip(t *testing.T) {
+	keys, err := ParseFieldKeys(testFieldKey("k1", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := EncryptField("12 Marina Road, Lagos", keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(sealed, "enc:k1:") {
+		t.Errorf("expected value sealed with key k1, got %q", sealed)
+	}
+
+	if strings.Contains(sealed, "Marina") {
+		t.Errorf("expected plaintext to be hidden, got %q", sealed)
+	}
+
+	got, err := DecryptField(sealed, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "12 Marina Road, Lagos" {
+		t.Errorf("expected the original value back, got %q", got)
+	}
+}
+
+func TestDecryptFieldAfterKeyRotation(t *testing.T) {
+	oldKeys, _ := ParseFieldKeys(testFieldKey("k1", 1))
+	rotated, _ := ParseFieldKeys(testFieldKey("k2", 2) + "," + testFieldKey("k1", 1))
+
+	old, _ := EncryptField("+2348012345678", oldKeys)
+
+	got, err := DecryptField(old, rotated)
+	if err != nil || got != "+2348012345678" {
+		t.Errorf("expected value sealed with the old key to open, got %q, %v", got, err)
+	}
+
+	sealed, _ := EncryptField("+2348012345678", rotated)
+	if !strings.HasPrefix(sealed, "enc:k2:") {
+		t.Errorf("expected new values to use the first key, got %q", sealed)
+	}
+
+	if _, err := DecryptField(sealed, oldKeys); err == nil {
+		t.Error("expected an error for a value sealed with an unknown key")
+	}
+}
+
+func TestFieldEncryptionPassesPlaintextThrough(t *testing.T) {
+	keys, _ := ParseFieldKeys(testFieldKey("k1", 1))
+
+	if got, _ := EncryptField("plain", nil); got != "plain" {
+		t.Errorf("expected no encryption without keys, got %q", got)
+	}
+
+	if got, _ := DecryptField("plain", keys); got != "plain" {
+		t.Errorf("expected unencrypted values to pass through, got %q", got)
+	}
+
+	sealed, _ := EncryptField("plain", keys)
+	tampered := []byte(sealed)
+	tampered[len(tampered)-5] ^= 1
+
+	if _, err := DecryptField(string(tampered), keys); err == nil {
+		t.Error("expected an error for a tampered value")
+	}
+}
+
+func TestParseFieldKeys(t *testing.T) {
+	tests := []struct {
+		keys    string
+		want    int
+		wantErr bool
+	}{
+		{"", 0, false},
+		{testFieldKey("k1", 1) + ", " + testFieldKey("k2", 2), 2, false},
+		{"k1", 0, true},
+		{"k1:not-base64!", 0, true},
+		{"k1:" + base64.StdEncoding.EncodeToString([]byte("short")), 0, true},
+		{testFieldKey("k1", 1) + "," + testFieldKey("k1", 2), 0, true},
+	}
+
+	for _, tc := range tests {
+		keys, err := ParseFieldKeys(tc.keys)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("keys %q: expected error %v, got %v", tc.keys, tc.wantErr, err)
+		}
+
+		if len(keys) != tc.want {
+			t.Errorf("keys %q: expected %d keys, got %d", tc.keys, tc.want, len(keys))
+		}
+	}
+}
+
+func TestLoadConfigurationsRejectsMalformedFieldKeys(t *testing.T) {
+	defer os.Setenv("FIELD_ENCRYPTION_KEYS", os.Getenv("FIELD_ENCRYPTION_KEYS"))
+
+	os.Setenv("FIELD_ENCRYPTION_KEYS", "k1:not-base64")
+
+	if _, err := LoadConfigurations(); err == nil {
+		t.Error("expected malformed field keys to fail loading the configuration")
+	}
+
+	os.Setenv("FIELD_ENCRYPTION_KEYS", testFieldKey("k1", 1))
+
+	configs, err := LoadConfigurations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(configs.FieldEncryptionKeys) != 1 {
+		t.Errorf("expected 1 field key, got %d", len(configs.FieldEncryptionKeys))
+	}
+}
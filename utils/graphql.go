@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"log"
 
 	"github.com/graphql-go/graphql"
@@ -139,7 +140,7 @@ func loadOrganizationsSchema() *graphql.Field {
 		Type:        graphql.NewList(organizationType),
 		Description: "Get Organization list",
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			organizations, err := GetMongoDBDocs(OrganizationCollectionName, bson.M{})
+			organizations, err := GetMongoDBDocs(context.TODO(), OrganizationCollectionName, bson.M{})
 			if err != nil {
 				log.Fatal(err.Error())
 			}
@@ -154,7 +155,7 @@ func loadPluginsSchema() *graphql.Field {
 		Type:        graphql.NewList(pluginType),
 		Description: "Get Plugins List",
 		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
-			plugins, err := GetMongoDBDocs(PluginCollectionName, bson.M{})
+			plugins, err := GetMongoDBDocs(context.TODO(), PluginCollectionName, bson.M{})
 			if err != nil {
 				log.Fatal(err.Error())
 			}
@@ -170,7 +171,7 @@ func loadUsersSchema() *graphql.Field {
 		Type:        graphql.NewList(userType),
 		Description: "Get User List",
 		Resolve: func(params graphql.ResolveParams) (interface{}, error) {
-			users, err := GetMongoDBDocs(UserCollectionName, bson.M{})
+			users, err := GetMongoDBDocs(context.TODO(), UserCollectionName, bson.M{})
 			if err != nil {
 				log.Fatal(err.Error())
 			}
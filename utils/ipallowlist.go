@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const allowlistCacheMaxAge = time.Minute
+
+var ErrIPNotAllowed = errors.New("your IP address is not allowed to access this organization")
+
+// ParseCIDRs parses a list of CIDR ranges. A bare IP address is a range of just itself.
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP range %q", cidr)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range %q", cidr)
+		}
+
+		nets = append(nets, ipNet)
+	}
+
+	return nets, nil
+}
+
+// IPAllowed reports whether ip is in one of the ranges. An empty allowlist allows every IP.
+func IPAllowed(ip net.IP, allowlist []*net.IPNet) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range allowlist {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClientIP is the IP address a request came from. X-Forwarded-For is only believed when the
+// request came through one of the trusted proxies, and then only as far back as the last hop
+// that is not a trusted proxy itself, so clients cannot forge their address. Proxies that send
+// X-Real-IP instead are believed the same way.
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+
+	if len(trustedProxies) == 0 || !IPAllowed(ip, trustedProxies) {
+		return ip
+	}
+
+	forwarded := r.Header.Get("X-Forwarded-For")
+	if strings.TrimSpace(forwarded) == "" {
+		forwarded = r.Header.Get("X-Real-IP")
+	}
+
+	hops := strings.Split(forwarded, ",")
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+
+		ip = hop
+
+		if !IPAllowed(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return ip
+}
+
+type cachedAllowlist struct {
+	allowlist []*net.IPNet
+	fetched   time.Time
+}
+
+// OrgIPAllowlist restricts the routes of an organization to the IP ranges on its allowlist.
+type OrgIPAllowlist struct {
+	trustedProxies []*net.IPNet
+
+	mu         sync.Mutex
+	allowlists map[string]cachedAllowlist
+
+	now         func() time.Time
+	allowlistOf func(orgID string) []string
+}
+
+// NewOrgIPAllowlist creates the middleware, believing X-Forwarded-For from trustedProxies.
+func NewOrgIPAllowlist(trustedProxies []*net.IPNet) *OrgIPAllowlist {
+	return &OrgIPAllowlist{
+		trustedProxies: trustedProxies,
+		allowlists:     make(map[string]cachedAllowlist),
+		now:            time.Now,
+		allowlistOf:    organizationIPAllowlist,
+	}
+}
+
+// looks up the IP allowlist of an organization, which is empty for unknown organizations.
+func organizationIPAllowlist(orgID string) []string {
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return nil
+	}
+
+	org, _ := GetMongoDBDoc(context.TODO(), "organizations", bson.M{"_id": pOrgID}, options.FindOne().SetProjection(bson.M{"ip_allowlist": 1}))
+
+	raw, _ := org["ip_allowlist"].(primitive.A)
+	cidrs := make([]string, 0, len(raw))
+
+	for _, cidr := range raw {
+		if s, ok := cidr.(string); ok {
+			cidrs = append(cidrs, s)
+		}
+	}
+
+	return cidrs
+}
+
+// allowlist returns an organization's allowlist, refreshing it from the database once a minute.
+func (al *OrgIPAllowlist) allowlist(orgID string) []*net.IPNet {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+
+	now := al.now()
+
+	if cached, ok := al.allowlists[orgID]; ok && now.Sub(cached.fetched) < allowlistCacheMaxAge {
+		return cached.allowlist
+	}
+
+	// ranges are validated when saved, so one that no longer parses is dropped rather than
+	// locking everyone out
+	var allowlist []*net.IPNet
+
+	for _, cidr := range al.allowlistOf(orgID) {
+		if ipNets, err := ParseCIDRs([]string{cidr}); err == nil {
+			allowlist = append(allowlist, ipNets...)
+		}
+	}
+
+	al.allowlists[orgID] = cachedAllowlist{allowlist: allowlist, fetched: now}
+
+	return allowlist
+}
+
+// Allowed reports whether a request may reach the routes of an organization.
+func (al *OrgIPAllowlist) Allowed(orgID string, r *http.Request) bool {
+	return IPAllowed(ClientIP(r, al.trustedProxies), al.allowlist(orgID))
+}
+
+// Middleware rejects requests to organization routes, identified by their {id} variable, from
+// IP addresses outside the organization's allowlist. Other routes pass through untouched.
+func (al *OrgIPAllowlist) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orgID := mux.Vars(r)["id"]
+
+		if orgID == "" || !strings.HasPrefix(r.URL.Path, "/organizations/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !al.Allowed(orgID, r) {
+			GetError(ErrIPNotAllowed, http.StatusForbidden, w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestIPAllowed(t *testing.T) {
+	allowlist, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.0/24", "203.0.113.7", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"10.20.30.40", true},
+		{"11.0.0.1", false},
+		{"192.168.1.255", true},
+		{"192.168.2.1", false},
+		{"203.0.113.7", true},
+		{"203.0.113.8", false},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+	}
+
+	for _, tc := range tests {
+		if got := IPAllowed(net.ParseIP(tc.ip), allowlist); got != tc.allowed {
+			t.Errorf("%s: expected allowed %v, got %v", tc.ip, tc.allowed, got)
+		}
+	}
+
+	if !IPAllowed(net.ParseIP("11.0.0.1"), nil) {
+		t.Error("expected an empty allowlist to allow every IP")
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0/8", ""} {
+		if _, err := ParseCIDRs([]string{cidr}); err == nil {
+			t.Errorf("expected %q to be rejected", cidr)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, _ := ParseCIDRs([]string{"10.0.0.0/8"})
+
+	tests := []struct {
+		name, remote, forwarded, want string
+		trusted                       bool
+	}{
+		{"direct clients are who they are", "203.0.113.7:4000", "", "203.0.113.7", true},
+		{"forwarded for is ignored from untrusted clients", "203.0.113.7:4000", "198.51.100.1", "203.0.113.7", true},
+		{"forwarded for is believed from trusted proxies", "10.0.0.1:4000", "198.51.100.1", "198.51.100.1", true},
+		{"forged hops before the proxy are ignored", "10.0.0.1:4000", "192.0.2.1, 198.51.100.1", "198.51.100.1", true},
+		{"chained trusted proxies are skipped", "10.0.0.1:4000", "198.51.100.1, 10.0.0.2", "198.51.100.1", true},
+		{"forwarded for is ignored without trusted proxies", "10.0.0.1:4000", "198.51.100.1", "10.0.0.1", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remote
+
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+
+			trusted := proxies
+			if !tc.trusted {
+				trusted = nil
+			}
+
+			if got := ClientIP(req, trusted); got.String() != tc.want {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestClientIPRealIP(t *testing.T) {
+	proxies, _ := ParseCIDRs([]string{"10.0.0.0/8"})
+
+	get := func(remote, forwarded, realIP string) string {
+		req, _ := http.NewRequest("GET", "/", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-Real-IP", realIP)
+
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+
+		return ClientIP(req, proxies).String()
+	}
+
+	if got := get("10.0.0.1:4000", "", "198.51.100.1"); got != "198.51.100.1" {
+		t.Errorf("expected X-Real-IP to be believed from a trusted proxy, got %s", got)
+	}
+
+	if got := get("203.0.113.7:4000", "", "198.51.100.1"); got != "203.0.113.7" {
+		t.Errorf("expected X-Real-IP to be ignored from an untrusted client, got %s", got)
+	}
+
+	if got := get("10.0.0.1:4000", "192.0.2.1", "198.51.100.1"); got != "192.0.2.1" {
+		t.Errorf("expected X-Forwarded-For to take precedence, got %s", got)
+	}
+
+	if got := get("10.0.0.1:4000", "", "not-an-ip"); got != "10.0.0.1" {
+		t.Errorf("expected a malformed X-Real-IP to be ignored, got %s", got)
+	}
+}
+
+func TestOrgIPAllowlist(t *testing.T) {
+	allowlists := map[string][]string{"lockedorg": {"198.51.100.0/24"}}
+
+	allowlist := NewOrgIPAllowlist(nil)
+	allowlist.allowlistOf = func(orgID string) []string { return allowlists[orgID] }
+
+	r := mux.NewRouter()
+	r.Use(allowlist.Middleware)
+	r.HandleFunc("/organizations/{id}/members", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	get := func(orgID, remote string) int {
+		req, _ := http.NewRequest("GET", "/organizations/"+orgID+"/members", nil)
+		req.RemoteAddr = remote
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	t.Run("test allowed IPs pass", func(t *testing.T) {
+		if code := get("lockedorg", "198.51.100.20:4000"); code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("test blocked IPs are forbidden", func(t *testing.T) {
+		if code := get("lockedorg", "203.0.113.7:4000"); code != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, code)
+		}
+	})
+
+	t.Run("test organizations without an allowlist are open", func(t *testing.T) {
+		if code := get("openorg", "203.0.113.7:4000"); code != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, code)
+		}
+	})
+
+	t.Run("test allowlists are cached for a minute", func(t *testing.T) {
+		now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+		allowlist.now = func() time.Time { return now }
+
+		get("cachedorg", "203.0.113.7:4000")
+		allowlists["cachedorg"] = []string{"198.51.100.0/24"}
+
+		if code := get("cachedorg", "203.0.113.7:4000"); code != http.StatusOK {
+			t.Errorf("expected the cached allowlist, got status %d", code)
+		}
+
+		now = now.Add(time.Minute)
+
+		if code := get("cachedorg", "203.0.113.7:4000"); code != http.StatusForbidden {
+			t.Errorf("expected the refreshed allowlist, got status %d", code)
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnglishLocale is the locale every email template exists in, which others fall back to.
+const EnglishLocale = "en"
+
+// ValidateLocale returns locale in its canonical form, e.g. "pt-BR" as "pt-br", or an error if
+// it is not one of the supported locales.
+func ValidateLocale(locale string, supported []string) (string, error) {
+	locale = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+
+	for _, s := range supported {
+		if strings.EqualFold(locale, s) {
+			return locale, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported locale %q, supported locales are %s", locale, strings.Join(supported, ", "))
+}
@@ -0,0 +1,30 @@
+package utils
+
+import "testing"
+
+func TestValidateLocale(t *testing.T) {
+	supported := []string{"en", "fr", "pt-BR"}
+
+	tests := []struct {
+		locale  string
+		want    string
+		wantErr bool
+	}{
+		{"en", "en", false},
+		{" FR ", "fr", false},
+		{"pt_BR", "pt-br", false},
+		{"de", "", true},
+		{"", "", true},
+	}
+
+	for _, tc := range tests {
+		got, err := ValidateLocale(tc.locale, supported)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("locale %q: expected error %v, got %v", tc.locale, tc.wantErr, err)
+		}
+
+		if got != tc.want {
+			t.Errorf("locale %q: expected %q, got %q", tc.locale, tc.want, got)
+		}
+	}
+}
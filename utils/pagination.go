@@ -0,0 +1,32 @@
+package utils
+
+import "strconv"
+
+// Pagination is how many items a page of a list holds when a request asks for no size, and the
+// most a request can ask for. A max of zero leaves sizes uncapped.
+type Pagination struct {
+	DefaultPerPage int
+	MaxPerPage     int
+}
+
+// PageParams reads the page size and page number from a request's limit and page query values.
+// Missing or invalid sizes get the default, and sizes over the max are cut down to it rather
+// than refused, so clients cannot read a whole collection in one request. Pages start at 1.
+func (p Pagination) PageParams(limit, page string) (perPage, pageNum int) {
+	perPage, _ = strconv.Atoi(limit)
+	pageNum, _ = strconv.Atoi(page)
+
+	if pageNum < 1 {
+		pageNum = 1
+	}
+
+	if perPage < 1 {
+		perPage = p.DefaultPerPage
+	}
+
+	if p.MaxPerPage > 0 && perPage > p.MaxPerPage {
+		perPage = p.MaxPerPage
+	}
+
+	return perPage, pageNum
+}
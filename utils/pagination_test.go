@@ -0,0 +1,30 @@
+package utils
+
+import "testing"
+
+func TestPageParams(t *testing.T) {
+	pagination := Pagination{DefaultPerPage: 10, MaxPerPage: 100}
+
+	tests := []struct {
+		name             string
+		limit, page      string
+		perPage, pageNum int
+	}{
+		{"requested size", "25", "3", 25, 3},
+		{"no size or page", "", "", 10, 1},
+		{"invalid size and page", "lots", "-2", 10, 1},
+		{"oversized request", "100000", "2", 100, 2},
+		{"size at the max", "100", "1", 100, 1},
+	}
+
+	for _, tc := range tests {
+		perPage, page := pagination.PageParams(tc.limit, tc.page)
+		if perPage != tc.perPage || page != tc.pageNum {
+			t.Errorf("%s: expected %d per page on page %d, got %d on page %d", tc.name, tc.perPage, tc.pageNum, perPage, page)
+		}
+	}
+
+	if perPage, _ := (Pagination{DefaultPerPage: 10}).PageParams("100000", ""); perPage != 100000 {
+		t.Errorf("expected sizes to be uncapped without a max, got %d", perPage)
+	}
+}
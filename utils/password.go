@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy is the set of rules passwords must meet when they are set.
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// lowercased passwords that are rejected for being too common
+	CommonPasswords map[string]struct{}
+}
+
+// PasswordError lists every rule a password failed.
+type PasswordError struct {
+	Violations []string
+}
+
+func (e *PasswordError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// LoadCommonPasswords reads a list of common passwords, one per line. Blank lines
+// and lines starting with # are skipped.
+func LoadCommonPasswords(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	passwords := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+
+	return passwords, scanner.Err()
+}
+
+// ValidatePassword checks a password against a policy. The returned *PasswordError
+// has a message for each rule the password breaks.
+func ValidatePassword(password string, policy *PasswordPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	var upper, lower, digit, symbol bool
+
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c):
+			symbol = true
+		}
+	}
+
+	var violations []string
+
+	if len([]rune(password)) < policy.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters long", policy.MinLength))
+	}
+
+	if policy.RequireUpper && !upper {
+		violations = append(violations, "password must contain an uppercase letter")
+	}
+
+	if policy.RequireLower && !lower {
+		violations = append(violations, "password must contain a lowercase letter")
+	}
+
+	if policy.RequireDigit && !digit {
+		violations = append(violations, "password must contain a digit")
+	}
+
+	if policy.RequireSymbol && !symbol {
+		violations = append(violations, "password must contain a symbol")
+	}
+
+	if _, ok := policy.CommonPasswords[strings.ToLower(password)]; ok {
+		violations = append(violations, "password is too common")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordError{Violations: violations}
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	listPath := filepath.Join(t.TempDir(), "common.txt")
+	if err := os.WriteFile(listPath, []byte("# common passwords\nPassword1\n\nqwerty\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	common, err := LoadCommonPasswords(listPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lenient := &PasswordPolicy{MinLength: 6}
+	standard := &PasswordPolicy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, CommonPasswords: common}
+	strict := &PasswordPolicy{MinLength: 12, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true, CommonPasswords: common}
+
+	tests := []struct {
+		name       string
+		policy     *PasswordPolicy
+		password   string
+		violations []string
+	}{
+		{"lenient accepts a plain password", lenient, "abcdef", nil},
+		{"lenient rejects a short password", lenient, "abc", []string{"password must be at least 6 characters long"}},
+		{"standard accepts a mixed password", standard, "Zuri2021chat", nil},
+		{"standard wants an uppercase letter", standard, "zuri2021chat", []string{"password must contain an uppercase letter"}},
+		{"standard wants a lowercase letter", standard, "ZURI2021CHAT", []string{"password must contain a lowercase letter"}},
+		{"standard wants a digit", standard, "ZuriChatApp", []string{"password must contain a digit"}},
+		{"standard rejects common passwords in any case", standard, "PASSWORD1", []string{"password must contain a lowercase letter", "password is too common"}},
+		{"standard lists every failed rule", standard, "abc", []string{
+			"password must be at least 8 characters long",
+			"password must contain an uppercase letter",
+			"password must contain a digit",
+		}},
+		{"strict accepts a long password with a symbol", strict, "Zuri-2021-chat", nil},
+		{"strict wants a symbol", strict, "Zuri2021chatApp", []string{"password must contain a symbol"}},
+		{"strict counts characters not bytes", strict, "Zürich-2021é", nil},
+		{"nil policy accepts anything", nil, "", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidatePassword(tc.password, tc.policy)
+
+			if tc.violations == nil {
+				if err != nil {
+					t.Fatalf("expected %q to be accepted, got %v", tc.password, err)
+				}
+
+				return
+			}
+
+			var perr *PasswordError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected a password error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(perr.Violations, tc.violations) {
+				t.Errorf("expected violations %q, got %q", tc.violations, perr.Violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	APIQuotaCollectionName = "api_quotas"
+
+	QuotaRemainingHeader = "X-Quota-Remaining"
+)
+
+var ErrQuotaExhausted = errors.New("monthly API quota exhausted")
+
+// APIQuota meters requests made with API keys against a monthly quota per organization. The
+// quota depends on the organization's plan, and the count starts again at the start of each
+// month, in UTC. Counts are kept in the database so every instance shares them.
+type APIQuota struct {
+	quotas map[string]int
+
+	now    func() time.Time
+	planOf func(orgID string) string
+	count  func(ctx context.Context, orgID, month string, quota int) (int, error)
+}
+
+// NewAPIQuota creates a meter allowing quotas[plan] requests a month. Organizations on plans
+// missing from quotas get the free plan's quota.
+func NewAPIQuota(quotas map[string]int) *APIQuota {
+	return &APIQuota{
+		quotas: quotas,
+		now:    time.Now,
+		planOf: organizationPlan,
+		count:  countAPIRequest,
+	}
+}
+
+// quotaMonth is the month a request is counted in.
+func quotaMonth(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// nextQuotaMonth is when the count of the month t is in starts again.
+func nextQuotaMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// countAPIRequest counts a request by an organization in a month and returns the count so far,
+// or ErrQuotaExhausted without counting it when the organization has made quota requests.
+func countAPIRequest(ctx context.Context, orgID, month string, quota int) (int, error) {
+	var counted struct {
+		Count int `bson:"count"`
+	}
+
+	// an exhausted month does not match, so the upsert tries to insert it again and is refused
+	err := GetCollectionWithWriteConcern(APIQuotaCollectionName, WriteConcern(WriteMetering)).FindOneAndUpdate(ctx,
+		bson.M{"_id": orgID + ":" + month, "count": bson.M{"$lt": quota}},
+		bson.M{"$inc": bson.M{"count": 1}, "$setOnInsert": bson.M{"org_id": orgID, "month": month}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counted)
+
+	if IsDuplicateKeyError(err) {
+		return quota, ErrQuotaExhausted
+	}
+
+	return counted.Count, err
+}
+
+func (q *APIQuota) quotaFor(plan string) int {
+	if quota, ok := q.quotas[plan]; ok {
+		return quota
+	}
+
+	return q.quotas[defaultPlan]
+}
+
+// Charge counts a request by an organization against its quota for the month, and returns how
+// many requests it has left and when its count starts again. It returns ErrQuotaExhausted,
+// without counting the request, when there are none left.
+func (q *APIQuota) Charge(ctx context.Context, orgID string) (remaining int, reset time.Time, err error) {
+	now := q.now()
+	quota := q.quotaFor(q.planOf(orgID))
+	reset = nextQuotaMonth(now)
+
+	if quota <= 0 {
+		return 0, reset, ErrQuotaExhausted
+	}
+
+	count, err := q.count(ctx, orgID, quotaMonth(now), quota)
+	if err != nil {
+		return 0, reset, err
+	}
+
+	return quota - count, reset, nil
+}
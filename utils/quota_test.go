@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAPIQuota(t *testing.T) {
+	plans := map[string]string{"freeorg": "free", "proorg": "pro"}
+	now := time.Date(2021, time.October, 31, 23, 59, 0, 0, time.UTC)
+
+	counts := make(map[string]int)
+
+	quota := NewAPIQuota(map[string]int{"free": 3, "pro": 5})
+	quota.now = func() time.Time { return now }
+	quota.planOf = func(orgID string) string { return plans[orgID] }
+	quota.count = func(_ context.Context, orgID, month string, limit int) (int, error) {
+		key := orgID + ":" + month
+		if counts[key] >= limit {
+			return limit, ErrQuotaExhausted
+		}
+
+		counts[key]++
+
+		return counts[key], nil
+	}
+
+	// exhaust returns how many requests an organization gets through before its quota runs out.
+	exhaust := func(orgID string) int {
+		for i := 0; i < 100; i++ {
+			if _, _, err := quota.Charge(context.TODO(), orgID); err == ErrQuotaExhausted {
+				return i
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return 100
+	}
+
+	t.Run("test remaining requests and reset", func(t *testing.T) {
+		remaining, reset, err := quota.Charge(context.TODO(), "proorg")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if remaining != 4 {
+			t.Errorf("expected 4 requests remaining, got %d", remaining)
+		}
+
+		if want := time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC); !reset.Equal(want) {
+			t.Errorf("expected the quota to reset at %v, got %v", want, reset)
+		}
+	})
+
+	t.Run("test plans get different quotas", func(t *testing.T) {
+		if n := exhaust("freeorg"); n != 3 {
+			t.Errorf("expected the free organization to get 3 requests, got %d", n)
+		}
+
+		// unknown plans get the free plan's quota
+		if n := exhaust("neworg"); n != 3 {
+			t.Errorf("expected an organization on an unknown plan to get 3 requests, got %d", n)
+		}
+
+		// one request was already made by the reset test
+		if n := exhaust("proorg"); n != 4 {
+			t.Errorf("expected the pro organization to get 4 more requests, got %d", n)
+		}
+	})
+
+	t.Run("test quota resets at the start of the month", func(t *testing.T) {
+		now = now.Add(59 * time.Second)
+
+		if _, _, err := quota.Charge(context.TODO(), "freeorg"); err != ErrQuotaExhausted {
+			t.Errorf("expected the quota to last until the end of the month, got %v", err)
+		}
+
+		now = now.Add(time.Second)
+
+		remaining, reset, err := quota.Charge(context.TODO(), "freeorg")
+		if err != nil {
+			t.Fatalf("expected a request in a new month to pass, got %v", err)
+		}
+
+		if remaining != 2 || reset.Month() != time.December {
+			t.Errorf("expected 2 requests left until December, got %d until %v", remaining, reset)
+		}
+	})
+}
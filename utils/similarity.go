@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// normalizeName lower-cases a name and drops spacing and punctuation, so names that differ
+// only in those compare equal.
+func normalizeName(name string) []rune {
+	normalized := []rune{}
+
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			normalized = append(normalized, r)
+		}
+	}
+
+	return normalized
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+// NameSimilarity scores how alike two names are from 0, nothing in common, to 1, the same
+// once case, spacing and punctuation are ignored. It is the edit distance between the
+// normalized names relative to the longer one.
+func NameSimilarity(a, b string) float64 {
+	na, nb := normalizeName(a), normalizeName(b)
+
+	longest := len(na)
+	if len(nb) > longest {
+		longest = len(nb)
+	}
+
+	if longest == 0 {
+		return 0
+	}
+
+	return 1 - float64(editDistance(na, nb))/float64(longest)
+}
@@ -0,0 +1,23 @@
+package utils
+
+import "testing"
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		min, max float64
+	}{
+		{"Acme Corp", "acme  corp", 1, 1},
+		{"Acme Corp", "Acme-Corp.", 1, 1},
+		{"Acme Corp", "Acme Crop", 0.7, 0.8},
+		{"Zuri Chat", "Zuri Chats", 0.85, 0.9},
+		{"Acme Corp", "Globex", 0, 0.2},
+		{"", "Acme", 0, 0},
+	}
+
+	for _, tc := range tests {
+		if got := NameSimilarity(tc.a, tc.b); got < tc.min || got > tc.max {
+			t.Errorf("%q and %q: expected a similarity between %v and %v, got %v", tc.a, tc.b, tc.min, tc.max, got)
+		}
+	}
+}
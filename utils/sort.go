@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ParseSort turns a sort query parameter, such as "-updated_at,name", into a sort document for
+// a find. Fields are sorted on in the order given, ascending unless prefixed with "-". Fields not
+// among the allowed ones, or given twice, are an error. An empty parameter sorts on nothing and
+// returns nil.
+func ParseSort(sort string, allowed ...string) (bson.D, error) {
+	sortable := make(map[string]bool, len(allowed))
+	for _, field := range allowed {
+		sortable[field] = true
+	}
+
+	var keys bson.D
+
+	seen := map[string]bool{}
+
+	for _, field := range strings.Split(sort, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+
+		order := 1
+		if strings.HasPrefix(field, "-") {
+			field, order = field[1:], -1
+		}
+
+		if !sortable[field] {
+			return nil, fmt.Errorf("cannot sort by %q, sort by one of %s", field, strings.Join(allowed, ", "))
+		}
+
+		if seen[field] {
+			return nil, fmt.Errorf("cannot sort by %q more than once", field)
+		}
+
+		seen[field] = true
+
+		keys = append(keys, bson.E{Key: field, Value: order})
+	}
+
+	return keys, nil
+}
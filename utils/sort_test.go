@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"name", "created_at", "updated_at"}
+
+	t.Run("test multi-field sort keeps the order given", func(t *testing.T) {
+		keys, err := ParseSort("-updated_at, name", allowed...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := bson.D{{Key: "updated_at", Value: -1}, {Key: "name", Value: 1}}
+		if !reflect.DeepEqual(keys, want) {
+			t.Errorf("expected %v, got %v", want, keys)
+		}
+	})
+
+	t.Run("test no sort", func(t *testing.T) {
+		if keys, err := ParseSort("", allowed...); err != nil || keys != nil {
+			t.Errorf("expected no sort, got %v, %v", keys, err)
+		}
+	})
+
+	t.Run("test disallowed and repeated fields are rejected", func(t *testing.T) {
+		for _, sort := range []string{"password", "-name,-billing", "name,-name", "-"} {
+			if _, err := ParseSort(sort, allowed...); err == nil {
+				t.Errorf("expected sort %q to be rejected", sort)
+			}
+		}
+	})
+}
@@ -1,11 +1,19 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/time/rate"
 )
 
@@ -51,3 +59,173 @@ func Throttle(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(w, r)
 	}
 }
+
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+
+	defaultPlan     = "free"
+	planCacheMaxAge = time.Minute
+)
+
+// requests counted for one organization on one route in the current window.
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type cachedPlan struct {
+	plan    string
+	fetched time.Time
+}
+
+// OrgRateLimiter limits how many requests an organization can make to each route per window.
+// The ceiling depends on the organization's plan, so paying organizations get more headroom.
+type OrgRateLimiter struct {
+	limits map[string]int
+	window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	plans   map[string]cachedPlan
+	swept   time.Time
+
+	now    func() time.Time
+	planOf func(orgID string) string
+}
+
+// NewOrgRateLimiter creates a limiter allowing limits[plan] requests per window.
+// Organizations on plans missing from limits get the free plan's ceiling.
+func NewOrgRateLimiter(limits map[string]int, window time.Duration) *OrgRateLimiter {
+	return &OrgRateLimiter{
+		limits:  limits,
+		window:  window,
+		windows: make(map[string]*rateWindow),
+		plans:   make(map[string]cachedPlan),
+		now:     time.Now,
+		planOf:  organizationPlan,
+	}
+}
+
+// looks up the plan of an organization, treating unknown organizations as free.
+func organizationPlan(orgID string) string {
+	pOrgID, err := primitive.ObjectIDFromHex(orgID)
+	if err != nil {
+		return defaultPlan
+	}
+
+	org, _ := GetMongoDBDoc(context.TODO(), "organizations", bson.M{"_id": pOrgID}, options.FindOne().SetProjection(bson.M{"version": 1}))
+
+	if plan, ok := org["version"].(string); ok && plan != "" {
+		return plan
+	}
+
+	return defaultPlan
+}
+
+func (rl *OrgRateLimiter) limitFor(plan string) int {
+	if limit, ok := rl.limits[plan]; ok {
+		return limit
+	}
+
+	return rl.limits[defaultPlan]
+}
+
+// plan returns an organization's plan, refreshing it from the database once a minute. Ids
+// that are not organization ids are never cached, so made up ids cannot fill the cache.
+// Must be called with rl.mu held.
+func (rl *OrgRateLimiter) plan(orgID string, now time.Time) string {
+	if !primitive.IsValidObjectID(orgID) {
+		return rl.planOf(orgID)
+	}
+
+	if cached, ok := rl.plans[orgID]; ok && now.Sub(cached.fetched) < planCacheMaxAge {
+		return cached.plan
+	}
+
+	plan := rl.planOf(orgID)
+	rl.plans[orgID] = cachedPlan{plan: plan, fetched: now}
+
+	return plan
+}
+
+// sweep forgets windows that are over and plans due a refresh, so organizations that stop
+// making requests do not stay in memory. Must be called with rl.mu held.
+func (rl *OrgRateLimiter) sweep(now time.Time) {
+	for key, w := range rl.windows {
+		if now.Sub(w.start) >= rl.window {
+			delete(rl.windows, key)
+		}
+	}
+
+	for orgID, cached := range rl.plans {
+		if now.Sub(cached.fetched) >= planCacheMaxAge {
+			delete(rl.plans, orgID)
+		}
+	}
+
+	rl.swept = now
+}
+
+// Allow counts a request by an organization to a route and reports whether it is within the
+// organization's ceiling, along with the ceiling, the requests left and when the window resets.
+func (rl *OrgRateLimiter) Allow(orgID, route string) (allowed bool, limit, remaining int, reset time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+
+	if now.Sub(rl.swept) >= rl.window {
+		rl.sweep(now)
+	}
+
+	limit = rl.limitFor(rl.plan(orgID, now))
+
+	key := orgID + ":" + route
+
+	w, ok := rl.windows[key]
+	if !ok || now.Sub(w.start) >= rl.window {
+		w = &rateWindow{start: now}
+		rl.windows[key] = w
+	}
+
+	reset = w.start.Add(rl.window)
+
+	if w.count >= limit {
+		return false, limit, 0, reset
+	}
+
+	w.count++
+
+	return true, limit, limit - w.count, reset
+}
+
+// Middleware rate limits requests to organization routes, identified by their {id} variable,
+// per organization and route. Other routes pass through untouched.
+func (rl *OrgRateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orgID := mux.Vars(r)["id"]
+		route := mux.CurrentRoute(r)
+
+		if orgID == "" || route == nil || !strings.HasPrefix(r.URL.Path, "/organizations/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		template, _ := route.GetPathTemplate()
+
+		allowed, limit, remaining, reset := rl.Allow(orgID, r.Method+" "+template)
+
+		w.Header().Set(RateLimitLimitHeader, strconv.Itoa(limit))
+		w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(remaining))
+		w.Header().Set(RateLimitResetHeader, strconv.FormatInt(reset.Unix(), 10))
+
+		if !allowed {
+			GetError(errors.New("rate limit exceeded"), http.StatusTooManyRequests, w)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
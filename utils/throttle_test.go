@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOrgRateLimiter(t *testing.T) {
+	plans := map[string]string{"freeorg": "free", "bigorg": "enterprise"}
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	limiter := NewOrgRateLimiter(map[string]int{"free": 2, "pro": 5, "enterprise": 10}, time.Minute)
+	limiter.now = func() time.Time { return now }
+	limiter.planOf = func(orgID string) string { return plans[orgID] }
+
+	r := mux.NewRouter()
+	r.Use(limiter.Middleware)
+	r.HandleFunc("/organizations/{id}/members", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	get := func(orgID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/organizations/"+orgID+"/members", nil)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	// exhaust returns how many requests an organization gets through before being limited.
+	exhaust := func(orgID string) int {
+		for i := 0; i < 100; i++ {
+			if rr := get(orgID); rr.Code == http.StatusTooManyRequests {
+				return i
+			}
+		}
+
+		return 100
+	}
+
+	t.Run("test headers report limit and remaining", func(t *testing.T) {
+		rr := get("bigorg")
+
+		if got := rr.Header().Get(RateLimitLimitHeader); got != "10" {
+			t.Errorf("expected limit 10, got %q", got)
+		}
+
+		if got := rr.Header().Get(RateLimitRemainingHeader); got != "9" {
+			t.Errorf("expected 9 remaining, got %q", got)
+		}
+
+		if got := rr.Header().Get(RateLimitResetHeader); got != strconv.FormatInt(now.Add(time.Minute).Unix(), 10) {
+			t.Errorf("unexpected reset %q", got)
+		}
+	})
+
+	t.Run("test plans get different ceilings", func(t *testing.T) {
+		if n := exhaust("freeorg"); n != 2 {
+			t.Errorf("expected free organization to get 2 requests, got %d", n)
+		}
+
+		// one request was already made by the headers test
+		if n := exhaust("bigorg"); n != 9 {
+			t.Errorf("expected enterprise organization to get 9 more requests, got %d", n)
+		}
+	})
+
+	t.Run("test window resets", func(t *testing.T) {
+		now = now.Add(time.Minute)
+
+		if rr := get("freeorg"); rr.Code != http.StatusOK {
+			t.Errorf("expected request in a new window to pass, got %d", rr.Code)
+		}
+	})
+}
+
+func TestOrgRateLimiterForgetsIdleOrganizations(t *testing.T) {
+	now := time.Date(2021, time.October, 1, 12, 0, 0, 0, time.UTC)
+	lookups := 0
+
+	limiter := NewOrgRateLimiter(map[string]int{"free": 2}, time.Minute)
+	limiter.now = func() time.Time { return now }
+	limiter.planOf = func(orgID string) string {
+		lookups++
+		return "free"
+	}
+
+	orgID := primitive.NewObjectID().Hex()
+
+	for i := 0; i < 3; i++ {
+		limiter.Allow(orgID, "GET /organizations/{id}")
+		limiter.Allow("not-an-id", "GET /organizations/{id}")
+	}
+
+	if len(limiter.plans) != 1 {
+		t.Errorf("expected only the organization's plan to be cached, got %d plans", len(limiter.plans))
+	}
+
+	// the organization's plan is cached, ids that are not are looked up each time
+	if lookups != 4 {
+		t.Errorf("expected 4 plan lookups, got %d", lookups)
+	}
+
+	now = now.Add(time.Minute)
+	limiter.Allow(primitive.NewObjectID().Hex(), "GET /organizations/{id}")
+
+	if len(limiter.windows) != 1 || len(limiter.plans) != 1 {
+		t.Errorf("expected the idle organizations to be forgotten, got %d windows and %d plans", len(limiter.windows), len(limiter.plans))
+	}
+}
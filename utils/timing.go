@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const ServerTimingHeader = "Server-Timing"
+
+type serverTimingKey struct{}
+
+// serverTiming accumulates the time a request spends waiting on the database.
+type serverTiming struct {
+	start   time.Time
+	dbNanos int64
+}
+
+// WithServerTiming starts timing a request. Database commands run with the returned context
+// count towards the request's database time.
+func WithServerTiming(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serverTimingKey{}, &serverTiming{start: time.Now()})
+}
+
+// AddDBTime adds to the database time of the request timed by ctx, if any. The mongo client
+// calls it for every command, so only commands run with a request's context are counted.
+func AddDBTime(ctx context.Context, d time.Duration) {
+	if ctx == nil {
+		return
+	}
+
+	if t, ok := ctx.Value(serverTimingKey{}).(*serverTiming); ok {
+		atomic.AddInt64(&t.dbNanos, int64(d))
+	}
+}
+
+// header splits the time since the request started into database and application time.
+func (t *serverTiming) header() string {
+	total := time.Since(t.start)
+	db := time.Duration(atomic.LoadInt64(&t.dbNanos))
+
+	app := total - db
+	if app < 0 {
+		// commands running concurrently can add up to more than the time that has passed
+		app = 0
+	}
+
+	return fmt.Sprintf("db;dur=%.3f, app;dur=%.3f", durationMS(db), durationMS(app))
+}
+
+func durationMS(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// serverTimingWriter sets the Server-Timing header just before the response headers are written.
+type serverTimingWriter struct {
+	http.ResponseWriter
+	timing      *serverTiming
+	wroteHeader bool
+}
+
+func (w *serverTimingWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.Header().Set(ServerTimingHeader, w.timing.header())
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *serverTimingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *serverTimingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+
+		f.Flush()
+	}
+}
+
+// ServerTimingMiddleware adds a Server-Timing header to responses, splitting the time taken
+// until the response is written into database and application time.
+func ServerTimingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := WithServerTiming(r.Context())
+		timing, _ := ctx.Value(serverTimingKey{}).(*serverTiming)
+
+		next.ServeHTTP(&serverTimingWriter{ResponseWriter: w, timing: timing}, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var serverTimingPattern = regexp.MustCompile(`^db;dur=([\d.]+), app;dur=([\d.]+)$`)
+
+func TestServerTimingMiddleware(t *testing.T) {
+	const dbTime, handlerTime = 5 * time.Millisecond, 20 * time.Millisecond
+
+	handler := ServerTimingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(handlerTime)
+		AddDBTime(r.Context(), dbTime)
+
+		GetSuccess("ok", nil, w)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	header := rr.Header().Get(ServerTimingHeader)
+
+	m := serverTimingPattern.FindStringSubmatch(header)
+	if m == nil {
+		t.Fatalf("unexpected %s header %q", ServerTimingHeader, header)
+	}
+
+	db, _ := strconv.ParseFloat(m[1], 64)
+	app, _ := strconv.ParseFloat(m[2], 64)
+
+	if db != durationMS(dbTime) {
+		t.Errorf("got db duration %vms, want %vms", db, durationMS(dbTime))
+	}
+
+	// the database time is part of the time the handler took
+	if minApp := durationMS(handlerTime - dbTime); app < minApp || app > durationMS(time.Second) {
+		t.Errorf("got app duration %vms, want at least %vms", app, minApp)
+	}
+}
+
+func TestAddDBTimeWithoutTiming(t *testing.T) {
+	// commands run outside a timed request are ignored
+	AddDBTime(httptest.NewRequest("GET", "/", nil).Context(), time.Second)
+}
+
+// TestServerTimingCountsHelperQueries needs a database in CLUSTER_URL.
+func TestServerTimingCountsHelperQueries(t *testing.T) {
+	clusterURL := os.Getenv("CLUSTER_URL")
+	if clusterURL == "" {
+		t.Skip("CLUSTER_URL is not set")
+	}
+
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(clusterURL).SetMonitor(monitor.commandMonitor()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer client.Disconnect(ctx)
+
+	handle := defaultMongoHandle
+	defaultMongoHandle = &MongoDBHandle{client: client}
+
+	defer func() { defaultMongoHandle = handle }()
+
+	handler := ServerTimingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := GetMongoDBDocs(r.Context(), "organizations", bson.M{}); err != nil {
+			t.Error(err)
+		}
+
+		GetSuccess("ok", nil, w)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	m := serverTimingPattern.FindStringSubmatch(rr.Header().Get(ServerTimingHeader))
+	if m == nil {
+		t.Fatalf("unexpected %s header %q", ServerTimingHeader, rr.Header().Get(ServerTimingHeader))
+	}
+
+	if db, _ := strconv.ParseFloat(m[1], 64); db <= 0 {
+		t.Errorf("expected the query to count towards the database time, got %vms", db)
+	}
+}
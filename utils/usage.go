@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"zuri.chat/zccore/logger"
+)
+
+const UsageEventCollectionName = "usage_events"
+
+const (
+	// usage events are written once this many are waiting, or on the next flush
+	usageBatchSize     = 100
+	usageFlushInterval = 5 * time.Second
+)
+
+// UsageEvent is a metered quantity of something an organization consumed, for usage billing.
+type UsageEvent struct {
+	OrgID     string    `json:"org_id" bson:"org_id"`
+	Metric    string    `json:"metric" bson:"metric"`
+	Quantity  int64     `json:"quantity" bson:"quantity"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// UsageSummary is the total of a metric over a period.
+type UsageSummary struct {
+	Metric   string `json:"metric" bson:"_id"`
+	Quantity int64  `json:"quantity" bson:"quantity"`
+	Events   int64  `json:"events" bson:"events"`
+}
+
+var usageEvents = struct {
+	sync.Mutex
+	pending []interface{}
+}{}
+
+// RecordUsageEvent meters a quantity of a metric consumed by an organization. Events are held
+// in memory and written in batches, so recording one costs no more than taking a lock.
+func RecordUsageEvent(orgID, metric string, quantity int64) {
+	usageEvents.Lock()
+	usageEvents.pending = append(usageEvents.pending, UsageEvent{
+		OrgID:     orgID,
+		Metric:    metric,
+		Quantity:  quantity,
+		CreatedAt: NowUTC(),
+	})
+	full := len(usageEvents.pending) >= usageBatchSize
+	usageEvents.Unlock()
+
+	if full {
+		go func() {
+			if err := FlushUsageEvents(context.Background()); err != nil {
+				logger.Error("could not write usage events: %v", err)
+			}
+		}()
+	}
+}
+
+// FlushUsageEvents writes the usage events waiting to be written. Events that could not be
+// written are kept for the next flush.
+func FlushUsageEvents(ctx context.Context) error {
+	usageEvents.Lock()
+	pending := usageEvents.pending
+	usageEvents.pending = nil
+	usageEvents.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	_, err := GetCollectionWithWriteConcern(UsageEventCollectionName, WriteConcern(WriteMetering)).InsertMany(ctx, pending)
+	if err != nil {
+		usageEvents.Lock()
+		usageEvents.pending = append(pending, usageEvents.pending...)
+		usageEvents.Unlock()
+	}
+
+	return err
+}
+
+// RunUsageFlusher writes waiting usage events every few seconds until the context is
+// cancelled, then writes what is left.
+func RunUsageFlusher(ctx context.Context) {
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			//nolint:errcheck //nothing is left to retry with
+			FlushUsageEvents(context.Background())
+			return
+		case <-ticker.C:
+			if err := FlushUsageEvents(ctx); err != nil {
+				logger.Error("could not write usage events: %v", err)
+			}
+		}
+	}
+}
+
+// SummarizeUsage totals the usage of an organization by metric, over events recorded from
+// from up to but not including to.
+func SummarizeUsage(ctx context.Context, orgID string, from, to time.Time) ([]UsageSummary, error) {
+	pipeline := []bson.M{
+		{"$match": bson.M{"org_id": orgID, "created_at": bson.M{"$gte": from, "$lt": to}}},
+		{"$group": bson.M{"_id": "$metric", "quantity": bson.M{"$sum": "$quantity"}, "events": bson.M{"$sum": 1}}},
+		{"$sort": bson.M{"_id": 1}},
+	}
+
+	cursor, err := GetCollection(UsageEventCollectionName).Aggregate(ctx, pipeline, options.Aggregate())
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []UsageSummary{}
+	if err := cursor.All(ctx, &summaries); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
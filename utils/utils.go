@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -85,6 +86,17 @@ func GetError(err error, statusCode int, w http.ResponseWriter) {
 	}
 }
 
+// GetWriteError responds to a failed database write. Writes rejected by a unique index get a
+// 409 naming the field instead of the raw database error; anything else is a 500.
+func GetWriteError(err error, w http.ResponseWriter) {
+	if IsDuplicateKeyError(err) {
+		GetError(DuplicateKeyError(err), http.StatusConflict, w)
+		return
+	}
+
+	GetError(err, http.StatusInternalServerError, w)
+}
+
 // GetDetailedError: This function provides detailed error information.
 func GetDetailedError(msg string, statusCode int, data interface{}, w http.ResponseWriter) {
 	var response = DetailedErrorResponse{
@@ -117,6 +129,24 @@ func GetSuccess(msg string, data interface{}, w http.ResponseWriter) {
 	}
 }
 
+// GetCreated responds with a 201 for a new resource, pointing the Location header at its
+// canonical URL. The resource is sent in the body as well.
+func GetCreated(msg, location string, data interface{}, w http.ResponseWriter) {
+	var response = SuccessResponse{
+		Message:    msg,
+		StatusCode: http.StatusCreated,
+		Data:       data,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Error sending response: %v", err)
+	}
+}
+
 // get env vars; return empty string if not found.
 func Env(key string) string {
 	return os.Getenv(key)
@@ -138,6 +168,29 @@ func BsonToStruct(input bson.M, output interface{}) error {
 	return bson.Unmarshal(bsonBytes, output)
 }
 
+// NowUTC is the current time in UTC. Timestamps are stored in UTC so that those saved as
+// strings, through StructToMap, compare and sort the same as dates.
+func NowUTC() time.Time {
+	return time.Now().UTC()
+}
+
+// DocTime reads a date field of a document in UTC, returning the zero time when it is missing.
+// Dates saved as RFC 3339 strings are read too.
+func DocTime(doc map[string]interface{}, key string) time.Time {
+	switch v := doc[key].(type) {
+	case primitive.DateTime:
+		return v.Time().UTC()
+	case time.Time:
+		return v.UTC()
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Time{}
+}
+
 // StructToMap converts a struct of any type to a map[string]inteface{}.
 func StructToMap(inStruct interface{}) (map[string]interface{}, error) {
 	out := make(map[string]interface{})
@@ -267,9 +320,10 @@ func GenWorkspaceURL(orgName string) string {
 	_, randNumbers := RandomGen(lenRandNumbers, "d")
 	wksURL := orgNamestr + "-" + randLetters + randNumbers + ".zurichat.com"
 
-	result, _ := GetMongoDBDoc(organizationsCollection, bson.M{"url": wksURL})
+	// generate another url if this one is taken
+	result, _ := GetMongoDBDoc(context.TODO(), organizationsCollection, bson.M{"workspace_url": wksURL})
 	if result != nil {
-		GenWorkspaceURL(orgName)
+		return GenWorkspaceURL(orgName)
 	}
 
 	return wksURL
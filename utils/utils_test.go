@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTimestampsInUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("WAT", 60*60)
+
+	defer func() { time.Local = local }()
+
+	if now := NowUTC(); now.Location() != time.UTC {
+		t.Errorf("expected NowUTC to be in UTC, got %v", now.Location())
+	}
+
+	stored := time.Date(2021, time.October, 1, 9, 30, 0, 0, time.UTC)
+
+	doc, err := StructToMap(struct {
+		CreatedAt time.Time `json:"created_at"`
+	}{NowUTC()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s, _ := doc["created_at"].(string); s == "" || s[len(s)-1] != 'Z' {
+		t.Errorf("expected the stored string to be in UTC, got %v", doc["created_at"])
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  time.Time
+	}{
+		{"date", primitive.NewDateTimeFromTime(stored), stored},
+		{"local time", stored.In(time.Local), stored},
+		{"string with offset", "2021-10-01T10:30:00+01:00", stored},
+		{"missing", nil, time.Time{}},
+		{"not a date", "yesterday", time.Time{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := DocTime(map[string]interface{}{"created_at": tc.value}, "created_at")
+
+			if !got.Equal(tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+
+			if !got.IsZero() && got.Location() != time.UTC {
+				t.Errorf("expected the time in UTC, got %v", got.Location())
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WorkingHours are the hours an organization works on each day of the week, in its time zone.
+// Days are keyed by their lower-case English name, such as "monday". Days left out, or not
+// enabled, are not worked.
+type WorkingHours struct {
+	TimeZone string                 `json:"time_zone" bson:"time_zone"`
+	Days     map[string]*WorkingDay `json:"days" bson:"days"`
+}
+
+// WorkingDay is when work starts and ends on a day, as "15:04" clock times. A shift ending
+// earlier than it starts runs overnight, into the next day.
+type WorkingDay struct {
+	Enabled bool   `json:"enabled" bson:"enabled"`
+	Start   string `json:"start" bson:"start"`
+	End     string `json:"end" bson:"end"`
+}
+
+// clockMinutes parses a "15:04" clock time into minutes since midnight.
+func clockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, use HH:MM", clock)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ValidateWorkingHours checks working hours have a known time zone and days, and valid start
+// and end times on the days worked. A day cannot start and end at the same time.
+func ValidateWorkingHours(hours *WorkingHours) error {
+	if _, err := time.LoadLocation(hours.TimeZone); err != nil || hours.TimeZone == "" {
+		return errors.New("invalid time zone")
+	}
+
+	weekdays := make(map[string]bool, 7)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		weekdays[strings.ToLower(d.String())] = true
+	}
+
+	for name, day := range hours.Days {
+		if !weekdays[name] {
+			return fmt.Errorf("unknown day %q, use monday to sunday", name)
+		}
+
+		if day == nil || !day.Enabled {
+			continue
+		}
+
+		start, err := clockMinutes(day.Start)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		end, err := clockMinutes(day.End)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		if start == end {
+			return fmt.Errorf("%s: work cannot start and end at %s", name, day.Start)
+		}
+	}
+
+	return nil
+}
+
+// shift returns the start and end of a day's work in minutes since midnight, and whether the
+// day is worked.
+func (h *WorkingHours) shift(weekday time.Weekday) (start, end int, ok bool) {
+	day := h.Days[strings.ToLower(weekday.String())]
+	if day == nil || !day.Enabled {
+		return 0, 0, false
+	}
+
+	start, err := clockMinutes(day.Start)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	end, err = clockMinutes(day.End)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return start, end, true
+}
+
+// IsWithinWorkingHours reports whether t falls within an organization's working hours, in its
+// time zone. Overnight shifts count until they end the next morning. Organizations without
+// working hours are always working.
+func IsWithinWorkingHours(hours *WorkingHours, t time.Time) bool {
+	if hours == nil || len(hours.Days) == 0 {
+		return true
+	}
+
+	loc, err := time.LoadLocation(hours.TimeZone)
+	if err != nil {
+		return true
+	}
+
+	local := t.In(loc)
+	now := local.Hour()*60 + local.Minute()
+
+	if start, end, ok := hours.shift(local.Weekday()); ok {
+		if start < end && now >= start && now < end {
+			return true
+		}
+
+		// an overnight shift started today runs until midnight
+		if end < start && now >= start {
+			return true
+		}
+	}
+
+	// and one started yesterday runs until it ends this morning
+	if start, end, ok := hours.shift(local.AddDate(0, 0, -1).Weekday()); ok && end < start && now < end {
+		return true
+	}
+
+	return false
+}
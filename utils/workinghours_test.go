@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsWithinWorkingHours(t *testing.T) {
+	hours := &WorkingHours{
+		TimeZone: "Africa/Lagos",
+		Days: map[string]*WorkingDay{
+			"monday":   {Enabled: true, Start: "09:00", End: "17:00"},
+			"tuesday":  {Enabled: false, Start: "09:00", End: "17:00"},
+			"thursday": {Enabled: true, Start: "22:00", End: "06:00"},
+		},
+	}
+
+	lagos, _ := time.LoadLocation("Africa/Lagos")
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		within bool
+	}{
+		// 2024-01-01 is a Monday
+		{"in hours", time.Date(2024, 1, 1, 9, 0, 0, 0, lagos), true},
+		{"before hours", time.Date(2024, 1, 1, 8, 59, 0, 0, lagos), false},
+		{"at the end of the day", time.Date(2024, 1, 1, 17, 0, 0, 0, lagos), false},
+		{"in hours in another time zone", time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC), true},
+		{"on a disabled day", time.Date(2024, 1, 2, 10, 0, 0, 0, lagos), false},
+		{"on a day left out", time.Date(2024, 1, 3, 10, 0, 0, 0, lagos), false},
+		{"on an overnight shift before midnight", time.Date(2024, 1, 4, 23, 0, 0, 0, lagos), true},
+		{"on an overnight shift the next morning", time.Date(2024, 1, 5, 5, 59, 0, 0, lagos), true},
+		{"after an overnight shift", time.Date(2024, 1, 5, 6, 0, 0, 0, lagos), false},
+		{"before an overnight shift", time.Date(2024, 1, 4, 5, 0, 0, 0, lagos), false},
+	}
+
+	for _, tc := range tests {
+		if got := IsWithinWorkingHours(hours, tc.at); got != tc.within {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.within, got)
+		}
+	}
+
+	if !IsWithinWorkingHours(nil, time.Now()) {
+		t.Error("expected organizations without working hours to always be working")
+	}
+}
+
+func TestValidateWorkingHours(t *testing.T) {
+	valid := map[string]*WorkingDay{"friday": {Enabled: true, Start: "22:00", End: "06:00"}}
+
+	tests := []struct {
+		name  string
+		hours WorkingHours
+		valid bool
+	}{
+		{"overnight shift", WorkingHours{TimeZone: "Europe/Paris", Days: valid}, true},
+		{"unknown time zone", WorkingHours{TimeZone: "Mars/Olympus", Days: valid}, false},
+		{"unknown day", WorkingHours{TimeZone: "UTC", Days: map[string]*WorkingDay{"funday": {Enabled: true, Start: "09:00", End: "17:00"}}}, false},
+		{"invalid time", WorkingHours{TimeZone: "UTC", Days: map[string]*WorkingDay{"monday": {Enabled: true, Start: "25:00", End: "17:00"}}}, false},
+		{"empty shift", WorkingHours{TimeZone: "UTC", Days: map[string]*WorkingDay{"monday": {Enabled: true, Start: "09:00", End: "09:00"}}}, false},
+		{"disabled day without times", WorkingHours{TimeZone: "UTC", Days: map[string]*WorkingDay{"sunday": {Enabled: false}}}, true},
+	}
+
+	for _, tc := range tests {
+		if err := ValidateWorkingHours(&tc.hours); (err == nil) != tc.valid {
+			t.Errorf("%s: expected valid %v, got %v", tc.name, tc.valid, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+const (
+	// writes that must survive the primary failing, such as ownership and billing changes
+	WriteCritical = "critical"
+	// high-volume writes that can afford to be lost in a failover, such as usage metering
+	WriteMetering = "metering"
+)
+
+var writeConcerns = struct {
+	sync.RWMutex
+	classes map[string]*writeconcern.WriteConcern
+}{classes: make(map[string]*writeconcern.WriteConcern)}
+
+// ParseWriteConcern reads a write concern: "majority", the number of members that must
+// acknowledge a write, or the name of a replica set tag. Empty keeps the client's write concern
+// and returns nil. Unacknowledged writes are refused, as callers read the results of writes.
+func ParseWriteConcern(w string) (*writeconcern.WriteConcern, error) {
+	w = strings.TrimSpace(w)
+
+	switch {
+	case w == "":
+		return nil, nil
+	case strings.EqualFold(w, "majority"):
+		return writeconcern.New(writeconcern.WMajority()), nil
+	}
+
+	if n, err := strconv.Atoi(w); err == nil {
+		if n < 1 {
+			return nil, errors.New("write concern must have at least one member acknowledge writes")
+		}
+
+		return writeconcern.New(writeconcern.W(n)), nil
+	}
+
+	return writeconcern.New(writeconcern.WTagSet(w)), nil
+}
+
+// SetWriteConcerns sets the write concern of each class of operation, see ParseWriteConcern.
+// Nothing is changed if any of them is invalid.
+func SetWriteConcerns(classes map[string]string) error {
+	parsed := make(map[string]*writeconcern.WriteConcern, len(classes))
+
+	for class, w := range classes {
+		wc, err := ParseWriteConcern(w)
+		if err != nil {
+			return fmt.Errorf("%s write concern: %v", class, err)
+		}
+
+		parsed[class] = wc
+	}
+
+	writeConcerns.Lock()
+	writeConcerns.classes = parsed
+	writeConcerns.Unlock()
+
+	return nil
+}
+
+// WriteConcern is the write concern of a class of operation, such as WriteCritical, to pass to
+// the helpers taking one. Classes without one are nil, which keeps the client's write concern.
+func WriteConcern(class string) *writeconcern.WriteConcern {
+	writeConcerns.RLock()
+	defer writeConcerns.RUnlock()
+
+	return writeConcerns.classes[class]
+}
+
+// writeWith returns the collection options writing with wc, or none to keep the client's.
+func writeWith(wc *writeconcern.WriteConcern) []*options.CollectionOptions {
+	if wc == nil {
+		return nil
+	}
+
+	return []*options.CollectionOptions{options.Collection().SetWriteConcern(wc)}
+}
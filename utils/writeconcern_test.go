@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestParseWriteConcern(t *testing.T) {
+	tests := []struct {
+		w       string
+		want    interface{}
+		wantErr bool
+	}{
+		{w: "majority", want: "majority"},
+		{w: "Majority", want: "majority"},
+		{w: "1", want: 1},
+		{w: " 2 ", want: 2},
+		{w: "datacenters", want: "datacenters"},
+		{w: "0", wantErr: true},
+		{w: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.w, func(t *testing.T) {
+			wc, err := ParseWriteConcern(tt.w)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected %q to be rejected", tt.w)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := wc.GetW(); got != tt.want {
+				t.Errorf("expected w to be %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if wc, err := ParseWriteConcern(""); wc != nil || err != nil {
+		t.Errorf("expected no write concern, got %v, %v", wc, err)
+	}
+}
+
+func TestSetWriteConcerns(t *testing.T) {
+	defer SetWriteConcerns(nil)
+
+	if err := SetWriteConcerns(map[string]string{WriteCritical: "majority", WriteMetering: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := WriteConcern(WriteCritical).GetW(); w != "majority" {
+		t.Errorf("expected critical writes to wait for a majority, got %v", w)
+	}
+
+	if w := WriteConcern(WriteMetering).GetW(); w != 1 {
+		t.Errorf("expected metering writes to wait for one member, got %v", w)
+	}
+
+	if wc := WriteConcern("other"); wc != nil {
+		t.Errorf("expected unknown classes to keep the client's write concern, got %v", wc)
+	}
+
+	if err := SetWriteConcerns(map[string]string{WriteCritical: "1", WriteMetering: "0"}); err == nil {
+		t.Fatal("expected an unacknowledged write concern to be rejected")
+	}
+
+	if w := WriteConcern(WriteCritical).GetW(); w != "majority" {
+		t.Errorf("expected the write concerns to be kept when one is invalid, got %v", w)
+	}
+}
+
+// TestWriteConcernIsApplied needs a replica set in CLUSTER_URL, majority writes are refused
+// by standalone servers.
+func TestWriteConcernIsApplied(t *testing.T) {
+	clusterURL := os.Getenv("CLUSTER_URL")
+	if clusterURL == "" {
+		t.Skip("CLUSTER_URL is not set")
+	}
+
+	var (
+		mu       sync.Mutex
+		concerns []interface{}
+	)
+
+	commandMonitor := &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			if evt.CommandName != "insert" && evt.CommandName != "update" {
+				return
+			}
+
+			var w interface{}
+			if val, err := evt.Command.LookupErr("writeConcern", "w"); err == nil {
+				if s, ok := val.StringValueOK(); ok {
+					w = s
+				} else {
+					w = int(val.AsInt64())
+				}
+			}
+
+			mu.Lock()
+			concerns = append(concerns, w)
+			mu.Unlock()
+		},
+	}
+
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(clusterURL).SetMonitor(commandMonitor))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer client.Disconnect(ctx)
+
+	var hello bson.M
+	if err = client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+		t.Fatal(err)
+	}
+
+	if hello["setName"] == nil {
+		t.Skip("CLUSTER_URL is not a replica set")
+	}
+
+	handle := defaultMongoHandle
+	defaultMongoHandle = &MongoDBHandle{client: client}
+
+	defer func() { defaultMongoHandle = handle }()
+	defer SetWriteConcerns(nil)
+
+	if err = SetWriteConcerns(map[string]string{WriteCritical: "majority", WriteMetering: "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := CreateMongoDBDocWithWriteConcern(context.TODO(), "write_concern_tests", WriteConcern(WriteCritical), bson.M{"name": GenUUID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = GenericUpdateOneMongoDBDocWithWriteConcern(context.TODO(), "write_concern_tests", WriteConcern(WriteMetering), res.InsertedID,
+		bson.M{"$set": bson.M{"updated": true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(concerns) != 2 {
+		t.Fatalf("expected 2 writes, got %d", len(concerns))
+	}
+
+	if concerns[0] != "majority" {
+		t.Errorf("expected the critical write to wait for a majority, got %v", concerns[0])
+	}
+
+	if concerns[1] != 1 {
+		t.Errorf("expected the metering write to wait for one member, got %v", concerns[1])
+	}
+}